@@ -8,8 +8,10 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"decred.org/dcrdex/client/core"
 	"decred.org/dcrdex/client/mm"
@@ -38,6 +40,7 @@ const (
 var (
 	defaultApplicationDirectory = dcrutil.AppDataDir("dexc", false)
 	defaultConfigPath           = filepath.Join(defaultApplicationDirectory, configFilename)
+	profileNameRegexp           = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
 )
 
 // RPCConfig encapsulates the configuration needed for the RPC server.
@@ -106,6 +109,7 @@ type CoreConfig struct {
 	NoAutoWalletLock   bool `long:"no-wallet-lock" description:"Disable locking of wallets on shutdown or logout. Use this if you want your external wallets to stay unlocked after closing the DEX app."`
 	NoAutoDBBackup     bool `long:"no-db-backup" description:"Disable creation of a database backup on shutdown."`
 	UnlockCoinsOnLogin bool `long:"release-wallet-coins" description:"On login or wallet creation, instruct the wallet to release any coins that it may have locked."`
+	ReadOnly           bool `long:"readonly" description:"Run in read-only mode. Order, match and bond status is requested from the DEX servers, but trading, bonding, sending and any other action that would sign or spend is refused. Use with accounts and watch-only wallets imported with importreadonly."`
 
 	ExtensionModeFile string `long:"extension-mode-file" description:"path to a file that specifies options for running core as an extension."`
 }
@@ -135,6 +139,18 @@ type MMConfig struct {
 	EventLogDBPath string `long:"eventLogDBPath"`
 }
 
+// BackupConfig encapsulates the settings for automatic encrypted backups of
+// the database and market making files.
+type BackupConfig struct {
+	AutoBackup     bool          `long:"autobackup" description:"Periodically create encrypted backups of the database, market making config and event log while logged in. Backups are encrypted with the app password."`
+	BackupDir      string        `long:"backupdir" description:"Directory for automatic backups. Default is a backups directory in the network directory."`
+	BackupInterval time.Duration `long:"backupinterval" description:"Time between automatic backups, e.g. 6h."`
+	BackupDaily    int           `long:"backupdaily" description:"Keep the newest backup from each of this many days."`
+	BackupWeekly   int           `long:"backupweekly" description:"Keep the newest backup from each of this many weeks."`
+	// Restore is used by the bisonw restore command.
+	Restore string `long:"restore" description:"Restore the database and market making files from an encrypted backup archive, then exit. bisonw must not be running. With a single --profile, the files of that profile are restored."`
+}
+
 // Config is the common application configuration definition. This composite
 // struct captures the configuration needed for core and both web and rpc
 // servers, as well as some application-level directives.
@@ -144,6 +160,7 @@ type Config struct {
 	WebConfig
 	LogConfig
 	MMConfig
+	BackupConfig
 	// AppData and ConfigPath should be parsed from the command-line,
 	// as it makes no sense to set these in the config file itself. If no values
 	// are assigned, defaults will be used.
@@ -158,6 +175,54 @@ type Config struct {
 	CPUProfile string `long:"cpuprofile" description:"File for CPU profiling."`
 	ShowVer    bool   `short:"V" long:"version" description:"Display version information and exit"`
 	Language   string `long:"lang" description:"BCP 47 tag for preferred language, e.g. en-GB, fr, zh-CN"`
+
+	NotifyConfigPath string `long:"notifyconfig" description:"Path to a JSON file that configures forwarding of notifications to webhooks, email, ntfy or Matrix."`
+
+	Profiles []string `long:"profile" description:"Name of an additional user profile. Each profile has its own app password, database, wallets, DEX accounts and market making config, and is selected at login in the web UI, or with the bwctl --profile option. May be specified multiple times. Names may contain letters, numbers, dashes and underscores."`
+	// ProfileRPCAuth are the RPC server credentials of the profiles, as
+	// name:user:pass.
+	ProfileRPCAuth []string `long:"profilerpcauth" description:"RPC server credentials for a user profile, as name:user:pass. Requests for the profile must use these credentials or an API token of the profile. Profiles without credentials are not served by the RPC server. May be specified multiple times."`
+	// ProfilesDir is a derivative field set by ResolveConfig. The data of each
+	// profile is stored in a subdirectory named for the profile.
+	ProfilesDir string
+}
+
+// ProfileRPCCredentials returns the RPC server user name and password of the
+// named user profile. found is false if no credentials are configured for the
+// profile.
+func (cfg *Config) ProfileRPCCredentials(name string) (user, pass string, found bool) {
+	for _, auth := range cfg.ProfileRPCAuth {
+		n, creds, _ := strings.Cut(auth, ":")
+		if n != name {
+			continue
+		}
+		user, pass, _ = strings.Cut(creds, ":")
+		return user, pass, true
+	}
+	return "", "", false
+}
+
+// Profile creates the Config for the named user profile. The profile's
+// database, market making files and backups are stored separately from those
+// of the default profile and any other profiles. The wallet data directories
+// are placed alongside the database, so they are separate too.
+func (cfg *Config) Profile(name string) (*Config, error) {
+	if !profileNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid profile name %q", name)
+	}
+	dir := filepath.Join(cfg.ProfilesDir, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating profile directory: %w", err)
+	}
+	pcfg := *cfg
+	pcfg.Profiles, pcfg.ProfileRPCAuth = nil, nil
+	pcfg.DBPath = filepath.Join(dir, "dexc.db")
+	pcfg.MMConfig = MMConfig{
+		BotConfigPath:  filepath.Join(dir, "mm_cfg.json"),
+		EventLogDBPath: filepath.Join(dir, "eventlog.db"),
+	}
+	pcfg.BackupDir = filepath.Join(cfg.BackupDir, name)
+	return &pcfg, nil
 }
 
 // Web creates a configuration for the webserver. This is a Config method
@@ -208,6 +273,15 @@ func (cfg *Config) Web(c *core.Core, mm *mm.MarketMaker, log dex.Logger, utc boo
 // instead of a CoreConfig method because Language is an app-level setting used
 // by both core and rpcserver.
 func (cfg *Config) Core(log dex.Logger) *core.Config {
+	var autoBackup *core.AutoBackupConfig
+	if cfg.AutoBackup {
+		autoBackup = &core.AutoBackupConfig{
+			Dir:      cfg.BackupDir,
+			Interval: cfg.BackupInterval,
+			Daily:    cfg.BackupDaily,
+			Weekly:   cfg.BackupWeekly,
+		}
+	}
 	return &core.Config{
 		DBPath:             cfg.DBPath,
 		Net:                cfg.Net,
@@ -221,6 +295,8 @@ func (cfg *Config) Core(log dex.Logger) *core.Config {
 		NoAutoDBBackup:     cfg.NoAutoDBBackup,
 		ExtensionModeFile:  cfg.ExtensionModeFile,
 		TheOneHost:         cfg.TheOneHost,
+		AutoBackup:         autoBackup,
+		ReadOnly:           cfg.ReadOnly,
 	}
 }
 
@@ -228,6 +304,11 @@ var DefaultConfig = Config{
 	AppData:    defaultApplicationDirectory,
 	ConfigPath: defaultConfigPath,
 	LogConfig:  LogConfig{DebugLevel: defaultLogLevel},
+	BackupConfig: BackupConfig{
+		BackupInterval: core.DefaultBackupInterval,
+		BackupDaily:    7,
+		BackupWeekly:   4,
+	},
 	RPCConfig: RPCConfig{
 		CertHosts: []string{defaultTestnetHost, defaultSimnetHost, defaultMainnetHost},
 	},
@@ -354,6 +435,46 @@ func ResolveConfig(appData string, cfg *Config) error {
 		cfg.MMConfig.EventLogDBPath = defaultMMEventLogDBPath
 	}
 
+	if cfg.BackupDir == "" {
+		cfg.BackupDir = filepath.Join(filepath.Dir(defaultDBPath), "backups")
+	} else {
+		cfg.BackupDir = dex.CleanAndExpandPath(cfg.BackupDir)
+	}
+
+	if cfg.Restore != "" {
+		cfg.Restore = dex.CleanAndExpandPath(cfg.Restore)
+	}
+
+	cfg.ProfilesDir = filepath.Join(filepath.Dir(defaultDBPath), "profiles")
+	seen := make(map[string]bool, len(cfg.Profiles))
+	for _, name := range cfg.Profiles {
+		if !profileNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid profile name %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate profile name %q", name)
+		}
+		seen[name] = true
+	}
+	seenAuth := make(map[string]bool, len(cfg.ProfileRPCAuth))
+	for _, auth := range cfg.ProfileRPCAuth {
+		name, creds, _ := strings.Cut(auth, ":")
+		if !seen[name] {
+			return fmt.Errorf("rpc credentials for unknown profile %q", name)
+		}
+		if seenAuth[name] {
+			return fmt.Errorf("duplicate rpc credentials for profile %q", name)
+		}
+		seenAuth[name] = true
+		user, pass, _ := strings.Cut(creds, ":")
+		if pass == "" {
+			return fmt.Errorf("no rpc password for profile %q", name)
+		}
+		if user == cfg.RPCUser && pass == cfg.RPCPass {
+			return fmt.Errorf("profile %q must not use the rpc credentials of the default profile", name)
+		}
+	}
+
 	return nil
 }
 
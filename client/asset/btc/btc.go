@@ -919,6 +919,9 @@ type ExchangeWalletCustom struct {
 
 // Check that wallets satisfy their supported interfaces.
 var _ asset.Wallet = (*intermediaryWallet)(nil)
+var _ asset.WatchOnlyExporter = (*ExchangeWalletSPV)(nil)
+var _ asset.WatchOnlyExporter = (*ExchangeWalletFullNode)(nil)
+var _ asset.WatchOnlyImporter = (*ExchangeWalletFullNode)(nil)
 var _ asset.Accelerator = (*ExchangeWalletAccelerator)(nil)
 var _ asset.Accelerator = (*ExchangeWalletSPV)(nil)
 var _ asset.Withdrawer = (*baseWallet)(nil)
@@ -965,6 +968,36 @@ func (btc *ExchangeWalletSPV) GetRecoveryCfg() (map[string]string, error) {
 	return cfg, nil
 }
 
+// WatchOnlyDescriptors returns the wallet's active public descriptors. Part of
+// the WatchOnlyExporter interface.
+func (btc *ExchangeWalletFullNode) WatchOnlyDescriptors() ([]string, error) {
+	node, ok := btc.node.(*rpcClient)
+	if !ok || !node.descriptors {
+		return nil, errors.New("watch-only descriptors are only available for descriptor wallets")
+	}
+	return node.watchOnlyDescriptors()
+}
+
+// ImportWatchOnlyDescriptors imports public descriptors exported from another
+// wallet. Part of the WatchOnlyImporter interface.
+func (btc *ExchangeWalletFullNode) ImportWatchOnlyDescriptors(descs []string) error {
+	node, ok := btc.node.(*rpcClient)
+	if !ok || !node.descriptors {
+		return errors.New("watch-only descriptors can only be imported into descriptor wallets")
+	}
+	return node.importWatchOnlyDescriptors(descs)
+}
+
+// WatchOnlyDescriptors returns the public descriptors of the receive and
+// change addresses of the wallet's account. Part of the WatchOnlyExporter
+// interface.
+func (btc *ExchangeWalletSPV) WatchOnlyDescriptors() ([]string, error) {
+	if !btc.segwit {
+		return nil, errors.New("watch-only descriptors are only available for segwit wallets")
+	}
+	return btc.spvNode.watchOnlyDescriptors()
+}
+
 // Destroy will delete all the wallet files so the wallet can be recreated.
 // Part of the Recoverer interface.
 func (btc *ExchangeWalletSPV) Move(backupDir string) error {
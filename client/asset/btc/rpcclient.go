@@ -47,6 +47,8 @@ const (
 	methodGetWalletInfo        = "getwalletinfo"
 	methodGetAddressInfo       = "getaddressinfo"
 	methodListDescriptors      = "listdescriptors"
+	methodImportDescriptors    = "importdescriptors"
+	methodGetDescriptorInfo    = "getdescriptorinfo"
 	methodValidateAddress      = "validateaddress"
 	methodEstimateSmartFee     = "estimatesmartfee"
 	methodSendRawTransaction   = "sendrawtransaction"
@@ -640,6 +642,67 @@ func (wc *rpcClient) listDescriptors(private bool) (*listDescriptorsResult, erro
 	return descriptors, wc.call(methodListDescriptors, anylist{private}, descriptors)
 }
 
+// watchOnlyDescriptors returns the wallet's active public descriptors.
+func (wc *rpcClient) watchOnlyDescriptors() ([]string, error) {
+	res, err := wc.listDescriptors(false)
+	if err != nil {
+		return nil, err
+	}
+	var descs []string
+	for _, d := range res.Descriptors {
+		if d.Active {
+			descs = append(descs, d.Descriptor)
+		}
+	}
+	if len(descs) == 0 {
+		return nil, errors.New("no active descriptors")
+	}
+	return descs, nil
+}
+
+// importWatchOnlyDescriptors imports the public descriptors into the wallet,
+// rescanning from the genesis block. The wallet must be a descriptor wallet
+// with private keys disabled.
+func (wc *rpcClient) importWatchOnlyDescriptors(descs []string) error {
+	type importRequest struct {
+		Descriptor string `json:"desc"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+	reqs := make([]*importRequest, 0, len(descs))
+	for _, desc := range descs {
+		// Add the checksum if it's missing.
+		if !strings.Contains(desc, "#") {
+			info := new(struct {
+				Checksum string `json:"checksum"`
+			})
+			if err := wc.call(methodGetDescriptorInfo, anylist{desc}, info); err != nil {
+				return fmt.Errorf("error checking descriptor %q: %w", desc, err)
+			}
+			desc += "#" + info.Checksum
+		}
+		reqs = append(reqs, &importRequest{Descriptor: desc})
+	}
+	var res []struct {
+		Success bool `json:"success"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := wc.call(methodImportDescriptors, anylist{reqs}, &res); err != nil {
+		return err
+	}
+	for i, r := range res {
+		if !r.Success {
+			var msg string
+			if r.Error != nil {
+				msg = r.Error.Message
+			}
+			return fmt.Errorf("error importing descriptor %q: %s", reqs[i].Descriptor, msg)
+		}
+	}
+	return nil
+}
+
 func (wc *rpcClient) ListTransactionsSinceBlock(blockHeight int32) ([]*ListTransactionsResult, error) {
 	blockHash, err := wc.GetBlockHash(int64(blockHeight))
 	if err != nil {
@@ -1083,6 +1083,23 @@ func (w *spvWallet) Fingerprint() (string, error) {
 	return hex.EncodeToString(btcutil.Hash160(pk.SerializeCompressed())), nil
 }
 
+// watchOnlyDescriptors returns the public descriptors of the receive and
+// change addresses of the wallet's BIP84 account.
+func (w *spvWallet) watchOnlyDescriptors() ([]string, error) {
+	props, err := w.wallet.AccountProperties(waddrmgr.KeyScopeBIP0084, w.acctNum)
+	if err != nil {
+		return nil, err
+	}
+	if props.AccountPubKey == nil {
+		return nil, fmt.Errorf("no account key available")
+	}
+	xPub := props.AccountPubKey.String()
+	return []string{
+		fmt.Sprintf("wpkh(%s/0/*)", xPub),
+		fmt.Sprintf("wpkh(%s/1/*)", xPub),
+	}, nil
+}
+
 // GetTxOut finds an unspent transaction output and its number of confirmations.
 // To match the behavior of the RPC method, even if an output is found, if it's
 // known to be spent, no *wire.TxOut and no error will be returned.
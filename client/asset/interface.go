@@ -1549,6 +1549,23 @@ type GeocodeRedeemer interface {
 	RedeemGeocode(code []byte, msg string) (dex.Bytes, uint64, error)
 }
 
+// WatchOnlyExporter is a wallet that can export the output descriptors, with
+// extended public keys, needed to create a watch-only copy of the wallet.
+type WatchOnlyExporter interface {
+	// WatchOnlyDescriptors returns the public output descriptors of the
+	// wallet's receive and change addresses.
+	WatchOnlyDescriptors() ([]string, error)
+}
+
+// WatchOnlyImporter is a wallet that can track the balance of another wallet
+// by importing the public descriptors from a WatchOnlyExporter.
+type WatchOnlyImporter interface {
+	// ImportWatchOnlyDescriptors imports the public output descriptors.
+	// Funds received to the descriptors' addresses will be reported in the
+	// wallet's balance, but cannot be spent.
+	ImportWatchOnlyDescriptors(descs []string) error
+}
+
 // MaxMatchesCounter counts the maximum number of matches that can go in a tx.
 type MaxMatchesCounter interface {
 	MaxSwaps(serverVer uint32, feeRate uint64) (int, error)
@@ -22,6 +22,7 @@ import (
 	_ "decred.org/dcrdex/client/asset/importall"
 	"decred.org/dcrdex/client/core"
 	"decred.org/dcrdex/client/mm"
+	"decred.org/dcrdex/client/notify"
 	"decred.org/dcrdex/client/rpcserver"
 	"decred.org/dcrdex/client/webserver"
 	"decred.org/dcrdex/dex"
@@ -41,6 +42,10 @@ func runCore(cfg *app.Config) error {
 
 	asset.SetNetwork(cfg.Net)
 
+	if cfg.Restore != "" {
+		return restoreBackup(cfg)
+	}
+
 	// If explicitly running without web server then you must run the rpc
 	// server.
 	if cfg.NoWeb && !cfg.RPCOn {
@@ -81,15 +86,27 @@ func runCore(cfg *app.Config) error {
 		}
 	}()
 
-	// Prepare the Core.
-	clientCore, err := core.New(cfg.Core(logMaker.Logger("CORE")))
+	// Prepare the Core and market maker for the default profile and any
+	// additional user profiles.
+	clientCore, marketMaker, err := newProfile(cfg, logMaker, "")
 	if err != nil {
-		return fmt.Errorf("error creating client core: %w", err)
+		return err
 	}
-
-	marketMaker, err := mm.NewMarketMaker(clientCore, cfg.MMConfig.EventLogDBPath, cfg.MMConfig.BotConfigPath, logMaker.Logger("MM"))
-	if err != nil {
-		return fmt.Errorf("error creating market maker: %w", err)
+	profiles := make([]*profile, 0, len(cfg.Profiles))
+	for _, name := range cfg.Profiles {
+		pcfg, err := cfg.Profile(name)
+		if err != nil {
+			return err
+		}
+		c, m, err := newProfile(pcfg, logMaker, name)
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		profiles = append(profiles, &profile{name: name, core: c, mm: m})
+	}
+	cores := []*core.Core{clientCore}
+	for _, p := range profiles {
+		cores = append(cores, p.core)
 	}
 
 	// Catch interrupt signal (e.g. ctrl+c), prompting to shutdown if the user
@@ -98,7 +115,7 @@ func runCore(cfg *app.Config) error {
 	signal.Notify(killChan, os.Interrupt)
 	go func() {
 		for range killChan {
-			if promptShutdown(clientCore) {
+			if promptShutdown(cores) {
 				log.Infof("Shutting down...")
 				cancel()
 				return
@@ -107,34 +124,79 @@ func runCore(cfg *app.Config) error {
 	}()
 
 	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		clientCore.Run(appCtx)
-		cancel() // in the event that Run returns prematurely prior to context cancellation
-	}()
+	for _, c := range cores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Run(appCtx)
+			cancel() // in the event that Run returns prematurely prior to context cancellation
+		}()
+	}
 
-	<-clientCore.Ready()
+	for _, c := range cores {
+		<-c.Ready()
+	}
 
-	var mmCM *dex.ConnectionMaster
+	var mmCMs []*dex.ConnectionMaster
 	defer func() {
 		log.Info("Exiting bisonw main.")
 		cancel()  // no-op with clean rpc/web server setup
 		wg.Wait() // no-op with clean setup and shutdown
-		if mmCM != nil {
+		for _, mmCM := range mmCMs {
 			mmCM.Wait()
 		}
 	}()
 
-	if marketMaker != nil {
-		mmCM = dex.NewConnectionMaster(marketMaker)
+	mms := []*mm.MarketMaker{marketMaker}
+	for _, p := range profiles {
+		mms = append(mms, p.mm)
+	}
+	for _, m := range mms {
+		mmCM := dex.NewConnectionMaster(m)
 		if err := mmCM.ConnectOnce(appCtx); err != nil {
 			return fmt.Errorf("Error connecting market maker")
 		}
+		mmCMs = append(mmCMs, mmCM)
+	}
+
+	if cfg.NotifyConfigPath != "" {
+		notifyCfg, err := notify.LoadConfig(cfg.NotifyConfigPath)
+		if err != nil {
+			return err
+		}
+		dispatcher, err := notify.New(notifyCfg, clientCore, logMaker.Logger("NTFY"))
+		if err != nil {
+			return fmt.Errorf("failed to create notification dispatcher: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cm := dex.NewConnectionMaster(dispatcher)
+			if err := cm.Connect(appCtx); err != nil {
+				log.Errorf("Error starting notification dispatcher: %v", err)
+				return
+			}
+			cm.Wait()
+		}()
 	}
 
 	if cfg.RPCOn {
-		rpcSrv, err := rpcserver.New(cfg.RPC(clientCore, marketMaker, logMaker.Logger("RPC")))
+		rpcCfg := cfg.RPC(clientCore, marketMaker, logMaker.Logger("RPC"))
+		for _, p := range profiles {
+			user, pass, found := cfg.ProfileRPCCredentials(p.name)
+			if !found {
+				log.Warnf("No rpc credentials for profile %q. The profile will not be served by the rpc server.", p.name)
+				continue
+			}
+			rpcCfg.Profiles = append(rpcCfg.Profiles, &rpcserver.Profile{
+				Name:        p.name,
+				Core:        p.core,
+				MarketMaker: p.mm,
+				User:        user,
+				Pass:        pass,
+			})
+		}
+		rpcSrv, err := rpcserver.New(rpcCfg)
 		if err != nil {
 			return fmt.Errorf("failed to create rpc server: %w", err)
 		}
@@ -154,7 +216,11 @@ func runCore(cfg *app.Config) error {
 	}
 
 	if !cfg.NoWeb {
-		webSrv, err := webserver.New(cfg.Web(clientCore, marketMaker, logMaker.Logger("WEB"), utc))
+		webCfg := cfg.Web(clientCore, marketMaker, logMaker.Logger("WEB"), utc)
+		for _, p := range profiles {
+			webCfg.Profiles = append(webCfg.Profiles, &webserver.Profile{Name: p.name, Core: p.core, MarketMaker: p.mm})
+		}
+		webSrv, err := webserver.New(webCfg)
 		if err != nil {
 			return fmt.Errorf("failed creating web server: %w", err)
 		}
@@ -182,23 +248,56 @@ func runCore(cfg *app.Config) error {
 	return nil
 }
 
-// promptShutdown checks if there are active orders and asks confirmation to
-// shutdown if there are. The return value indicates if it is safe to stop Core
-// or if the user has confirmed they want to shutdown with active orders.
-func promptShutdown(clientCore *core.Core) bool {
+// profile is an additional user profile.
+type profile struct {
+	name string
+	core *core.Core
+	mm   *mm.MarketMaker
+}
+
+// newProfile creates the Core and market maker for a user profile. The name is
+// empty for the default profile.
+func newProfile(cfg *app.Config, logMaker *dex.LoggerMaker, name string) (*core.Core, *mm.MarketMaker, error) {
+	coreLogName, mmLogName := "CORE", "MM"
+	if name != "" {
+		coreLogName, mmLogName = "CORE["+name+"]", "MM["+name+"]"
+	}
+	clientCore, err := core.New(cfg.Core(logMaker.Logger(coreLogName)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating client core: %w", err)
+	}
+
+	marketMaker, err := mm.NewMarketMaker(clientCore, cfg.MMConfig.EventLogDBPath, cfg.MMConfig.BotConfigPath, logMaker.Logger(mmLogName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating market maker: %w", err)
+	}
+
+	if cfg.AutoBackup {
+		clientCore.AddBackupSource(mmConfigBackupFile, marketMaker.BackupConfig)
+		clientCore.AddBackupSource(mmEventLogBackupFile, marketMaker.BackupEventLog)
+	}
+	return clientCore, marketMaker, nil
+}
+
+// promptShutdown checks if there are active orders in any of the profiles and
+// asks confirmation to shutdown if there are. The return value indicates if it
+// is safe to stop Core or if the user has confirmed they want to shutdown with
+// active orders.
+func promptShutdown(cores []*core.Core) bool {
 	log.Infof("Attempting to logout...")
 	// Do not allow Logout hanging to prevent shutdown.
 	res := make(chan bool, 1)
 	go func() {
 		// Only block logout if err is ActiveOrdersLogoutErr.
-		var ok bool
-		err := clientCore.Logout()
-		if err == nil {
-			ok = true
-		} else if !errors.Is(err, core.ActiveOrdersLogoutErr) {
-			log.Errorf("Unexpected logout error: %v", err)
-			ok = true
-		} // else not ok => prompt
+		ok := true
+		for _, clientCore := range cores {
+			err := clientCore.Logout()
+			if errors.Is(err, core.ActiveOrdersLogoutErr) {
+				ok = false // prompt
+			} else if err != nil {
+				log.Errorf("Unexpected logout error: %v", err)
+			}
+		}
 		res <- ok
 	}()
 
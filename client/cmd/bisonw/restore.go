@@ -0,0 +1,62 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"decred.org/dcrdex/client/app"
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex/encode"
+	"golang.org/x/term"
+)
+
+// Names of the market making files in backup archives.
+const (
+	mmConfigBackupFile   = "mm_cfg.json"
+	mmEventLogBackupFile = "eventlog.db"
+)
+
+// restoreBackup prompts for the app password and restores the database and
+// market making files from the backup archive specified with --restore. The
+// files of a user profile are restored if a single --profile is specified.
+func restoreBackup(cfg *app.Config) error {
+	switch len(cfg.Profiles) {
+	case 0:
+	case 1:
+		pcfg, err := cfg.Profile(cfg.Profiles[0])
+		if err != nil {
+			return err
+		}
+		cfg = pcfg
+	default:
+		return fmt.Errorf("only one profile can be restored at a time")
+	}
+	fmt.Printf("Restoring backup %s\n", cfg.Restore)
+	fmt.Print("App password: ")
+	pw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("error reading password: %w", err)
+	}
+	defer encode.ClearBytes(pw)
+
+	manifest, err := core.RestoreBackup(cfg.Restore, pw, cfg.Net, map[string]string{
+		core.BackupDBFile:    cfg.DBPath,
+		mmConfigBackupFile:   cfg.BotConfigPath,
+		mmEventLogBackupFile: cfg.EventLogDBPath,
+	})
+	if err != nil {
+		return fmt.Errorf("error restoring backup: %w", err)
+	}
+
+	fmt.Printf("Restored backup from %s:\n", time.UnixMilli(int64(manifest.Stamp)).Format(time.RFC1123))
+	for _, f := range manifest.Files {
+		fmt.Printf("  %s (%d bytes)\n", f.Name, f.Size)
+	}
+	fmt.Println("Replaced files were renamed with a .prerestore extension.")
+	return nil
+}
@@ -38,8 +38,10 @@ type config struct {
 	Config       string   `short:"C" long:"config" description:"Path to configuration file"`
 	RPCUser      string   `short:"u" long:"rpcuser" description:"RPC username"`
 	RPCPass      string   `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCToken     string   `long:"rpctoken" default-mask:"-" description:"RPC API token. Used instead of the RPC username and password."`
 	RPCAddr      string   `short:"a" long:"rpcaddr" description:"RPC server to connect to"`
 	RPCCert      string   `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	Profile      string   `long:"profile" description:"The bisonw user profile to use. The default profile is used if not set. The profile's own RPC credentials or API token must be used."`
 	PrintJSON    bool     `short:"j" long:"json" description:"Print json messages sent and received"`
 	Proxy        string   `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyUser    string   `long:"proxyuser" description:"Username for proxy server"`
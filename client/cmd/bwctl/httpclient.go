@@ -78,6 +78,9 @@ func newHTTPClient(cfg *config, urlStr string) (*http.Client, error) {
 func sendPostRequest(marshalledJSON []byte, cfg *config) (*msgjson.Message, error) {
 	// Generate a request to the configured RPC server.
 	urlStr := "https://" + cfg.RPCAddr
+	if cfg.Profile != "" {
+		urlStr += "/profile/" + url.PathEscape(cfg.Profile) + "/"
+	}
 	if cfg.PrintJSON {
 		fmt.Println(string(marshalledJSON))
 	}
@@ -89,8 +92,12 @@ func sendPostRequest(marshalledJSON []byte, cfg *config) (*msgjson.Message, erro
 	httpRequest.Close = true
 	httpRequest.Header.Set("Content-Type", "application/json")
 
-	// Configure basic access authorization.
-	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPass)
+	// Configure bearer token or basic access authorization.
+	if cfg.RPCToken != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+cfg.RPCToken)
+	} else {
+		httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPass)
+	}
 
 	// Create the new HTTP client that is configured according to the user-
 	// specified options and submit the request.
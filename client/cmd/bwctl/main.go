@@ -63,6 +63,8 @@ var promptPasswords = map[string][]string{
 	"purchasetickets":   {"App password:"},
 	"startmmbot":        {"App password:"},
 	"withdrawbchspv":    {"App password"},
+	"createapitoken":    {"App password:"},
+	"conditionaltrade":  {"App password:"},
 }
 
 // optionalTextFiles is a map of routes to arg index for routes that should read
@@ -0,0 +1,1134 @@
+package main
+
+/*
+ * Starts an http server that responds to some of the Kraken API's endpoints.
+ * Unlike testbinance, testkraken does not require any harness wallets or
+ * external price data. Deposits are credited without checking the chain, and
+ * withdrawals are completed with a fake transaction ID.
+ */
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"decred.org/dcrdex/client/mm/libxc/krtypes"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/ws"
+	"decred.org/dcrdex/server/comms"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+
+	// transferDelay is how long deposits and withdrawals take to complete.
+	transferDelay = 10 * time.Second
+
+	// maxWalkingSpeed is the maximum ratio that the mid-gap can change per
+	// shuffle.
+	maxWalkingSpeed = 0.03
+)
+
+var (
+	log dex.Logger
+
+	// assets is keyed by Kraken's internal asset name.
+	assets = map[string]*krtypes.AssetInfo{
+		"XXBT": makeAssetInfo("XBT", 10),
+		"XETH": makeAssetInfo("ETH", 10),
+		"DCR":  makeAssetInfo("DCR", 8),
+		"USDC": makeAssetInfo("USDC", 8),
+		"XZEC": makeAssetInfo("ZEC", 10),
+	}
+
+	// fiatRates are used to set the basis rates for the markets.
+	fiatRates = map[string]float64{
+		"XXBT": 60_000,
+		"XETH": 3_000,
+		"DCR":  15,
+		"USDC": 1,
+		"XZEC": 30,
+	}
+
+	pairs = map[string]*krtypes.AssetPair{
+		"DCRXBT":   makePair("DCR", "XXBT", 8, 8, 0.1),
+		"XETHXXBT": makePair("XETH", "XXBT", 5, 8, 0.002),
+		"DCRUSDC":  makePair("DCR", "USDC", 4, 8, 0.1),
+		"XZECXXBT": makePair("XZEC", "XXBT", 6, 8, 0.05),
+	}
+
+	withdrawMethods = []*krtypes.WithdrawMethod{
+		makeWithdrawMethod("XXBT", "Bitcoin", "Bitcoin", 0.0005),
+		makeWithdrawMethod("XETH", "Ether", "Ethereum", 0.004),
+		makeWithdrawMethod("DCR", "Decred", "Decred", 0.05),
+		makeWithdrawMethod("USDC", "USDC - Polygon", "Polygon", 5),
+		makeWithdrawMethod("XZEC", "Zcash (Transparent)", "Zcash", 0.01),
+	}
+
+	initialBalances = map[string]float64{
+		"XXBT": 1.5,
+		"XETH": 5,
+		"DCR":  10000,
+		"USDC": 1152,
+		"XZEC": 10000,
+	}
+)
+
+func makeAssetInfo(altName string, decimals int) *krtypes.AssetInfo {
+	return &krtypes.AssetInfo{
+		AssetClass:      "currency",
+		AltName:         altName,
+		Decimals:        decimals,
+		DisplayDecimals: 5,
+		Status:          "enabled",
+	}
+}
+
+func makePair(base, quote string, pairDecimals, lotDecimals int, orderMin float64) *krtypes.AssetPair {
+	baseAlt, quoteAlt := assets[base].AltName, assets[quote].AltName
+	return &krtypes.AssetPair{
+		AltName:      baseAlt + quoteAlt,
+		WSName:       baseAlt + "/" + quoteAlt,
+		Base:         base,
+		Quote:        quote,
+		PairDecimals: pairDecimals,
+		LotDecimals:  lotDecimals,
+		OrderMin:     orderMin,
+		TickSize:     math.Pow10(-pairDecimals),
+		Status:       "online",
+	}
+}
+
+func makeWithdrawMethod(asset, method, network string, min float64) *krtypes.WithdrawMethod {
+	return &krtypes.WithdrawMethod{
+		Asset:   asset,
+		Method:  method,
+		Network: network,
+		Minimum: min,
+	}
+}
+
+// wsSymbol converts the REST wsname to the symbol used by the websocket v2
+// API.
+func wsSymbol(wsName string) string {
+	return strings.ReplaceAll(wsName, "XBT", "BTC")
+}
+
+// internalAssetName converts an asset's altname to Kraken's internal name.
+func internalAssetName(altName string) string {
+	for name, a := range assets {
+		if a.AltName == altName || name == altName {
+			return name
+		}
+	}
+	return ""
+}
+
+func main() {
+	var logDebug, logTrace bool
+	flag.BoolVar(&logDebug, "debug", false, "use debug logging")
+	flag.BoolVar(&logTrace, "trace", false, "use trace logging")
+	flag.Parse()
+
+	switch {
+	case logTrace:
+		log = dex.StdOutLogger("TK", dex.LevelTrace)
+		comms.UseLogger(dex.StdOutLogger("C", dex.LevelTrace))
+	case logDebug:
+		log = dex.StdOutLogger("TK", dex.LevelDebug)
+		comms.UseLogger(dex.StdOutLogger("C", dex.LevelDebug))
+	default:
+		log = dex.StdOutLogger("TK", dex.LevelInfo)
+		comms.UseLogger(dex.StdOutLogger("C", dex.LevelInfo))
+	}
+
+	if err := mainErr(); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func mainErr() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	killChan := make(chan os.Signal, 1)
+	signal.Notify(killChan, os.Interrupt)
+	go func() {
+		<-killChan
+		log.Info("Shutting down...")
+		cancel()
+	}()
+
+	kr, err := newFakeKrakenServer(ctx)
+	if err != nil {
+		return err
+	}
+
+	kr.run(ctx)
+
+	return nil
+}
+
+type transfer struct {
+	krtypes.Transfer
+	apiKey    string
+	completes time.Time
+}
+
+type userOrder struct {
+	id       string
+	clientID string
+	pair     *krtypes.AssetPair
+	sell     bool
+	rate     float64
+	qty      float64
+	apiKey   string
+	stamp    time.Time
+	status   string
+}
+
+type account struct {
+	// balances are keyed by internal asset name.
+	balances map[string]*krtypes.Balance
+}
+
+type marketSubscriber struct {
+	*ws.WSLink
+
+	// symbols is protected by the fakeKraken.marketsMtx.
+	symbols map[string]struct{}
+}
+
+type fakeKraken struct {
+	ctx context.Context
+	srv *comms.Server
+
+	accountsMtx sync.Mutex
+	accounts    map[string]*account // keyed by API key
+	orders      map[string]*userOrder
+	deposits    map[string]*transfer // keyed by tx ID
+	withdrawals map[string]*transfer // keyed by ref ID
+
+	tokensMtx sync.RWMutex
+	tokens    map[string]string // token -> API key
+
+	userSubscribersMtx sync.RWMutex
+	userSubscribers    map[string]map[*ws.WSLink]struct{} // keyed by API key
+
+	marketsMtx        sync.RWMutex
+	markets           map[string]*market // keyed by ws symbol
+	marketSubscribers map[*marketSubscriber]struct{}
+}
+
+func newFakeKrakenServer(ctx context.Context) (*fakeKraken, error) {
+	srv, err := comms.NewServer(&comms.RPCConfig{
+		ListenAddrs: []string{":37347"},
+		NoTLS:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error creating server: %w", err)
+	}
+
+	f := &fakeKraken{
+		ctx:               ctx,
+		srv:               srv,
+		accounts:          make(map[string]*account),
+		orders:            make(map[string]*userOrder),
+		deposits:          make(map[string]*transfer),
+		withdrawals:       make(map[string]*transfer),
+		tokens:            make(map[string]string),
+		userSubscribers:   make(map[string]map[*ws.WSLink]struct{}),
+		markets:           make(map[string]*market),
+		marketSubscribers: make(map[*marketSubscriber]struct{}),
+	}
+
+	for _, pair := range pairs {
+		baseRate, quoteRate := fiatRates[pair.Base], fiatRates[pair.Quote]
+		f.markets[wsSymbol(pair.WSName)] = newMarket(pair, baseRate/quoteRate)
+	}
+
+	mux := srv.Mux()
+
+	mux.Route("/0/public", func(r chi.Router) {
+		r.Get("/Assets", f.handleAssets)
+		r.Get("/AssetPairs", f.handleAssetPairs)
+		r.Get("/Ticker", f.handleTicker)
+	})
+	mux.Route("/0/private", func(r chi.Router) {
+		r.Post("/BalanceEx", f.handleBalanceEx)
+		r.Post("/GetWebSocketsToken", f.handleGetWebSocketsToken)
+		r.Post("/AddOrder", f.handleAddOrder)
+		r.Post("/CancelOrder", f.handleCancelOrder)
+		r.Post("/QueryOrders", f.handleQueryOrders)
+		r.Post("/DepositMethods", f.handleDepositMethods)
+		r.Post("/DepositAddresses", f.handleDepositAddresses)
+		r.Post("/DepositStatus", f.handleDepositStatus)
+		r.Post("/WithdrawMethods", f.handleWithdrawMethods)
+		r.Post("/WithdrawAddresses", f.handleWithdrawAddresses)
+		r.Post("/Withdraw", f.handleWithdraw)
+		r.Post("/WithdrawStatus", f.handleWithdrawStatus)
+	})
+	mux.Get("/v2", f.handleMarketStream)
+	mux.Get("/v2/auth", f.handleUserStream)
+
+	return f, nil
+}
+
+func (f *fakeKraken) run(ctx context.Context) {
+	// Shuffle the books periodically.
+	go func() {
+		const marketMinTick, marketTickRange = time.Second * 5, time.Second * 25
+		for {
+			delay := marketMinTick + time.Duration(rand.Float64()*float64(marketTickRange))
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			f.marketsMtx.RLock()
+			for symbol, mkt := range f.markets {
+				mkt.mtx.Lock()
+				bids, asks := mkt.shuffle()
+				mkt.mtx.Unlock()
+				update := bookMessage("update", symbol, bids, asks)
+				for sub := range f.marketSubscribers {
+					if _, found := sub.symbols[symbol]; found {
+						sub.SendRaw(update)
+					}
+				}
+			}
+			f.marketsMtx.RUnlock()
+		}
+	}()
+
+	// 50% chance of filling all open orders every 5 to 30 seconds.
+	go func() {
+		const minFillTick, fillTickRange = 5 * time.Second, 25 * time.Second
+		for {
+			select {
+			case <-time.After(minFillTick + time.Duration(rand.Float64()*float64(fillTickRange))):
+			case <-ctx.Done():
+				return
+			}
+			if rand.Float32() < 0.5 {
+				continue
+			}
+			f.fillOrders()
+		}
+	}()
+
+	// Complete deposits and withdrawals.
+	go func() {
+		for {
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
+			f.processTransfers()
+		}
+	}()
+
+	// Send heartbeats to market subscribers. Kraken sends heartbeats every
+	// second, but every ten seconds is sufficient here.
+	go func() {
+		heartbeat, _ := json.Marshal(&krtypes.WSMessage{Channel: "heartbeat"})
+		for {
+			select {
+			case <-time.After(time.Second * 10):
+			case <-ctx.Done():
+				return
+			}
+			f.marketsMtx.RLock()
+			for sub := range f.marketSubscribers {
+				sub.SendRaw(heartbeat)
+			}
+			f.marketsMtx.RUnlock()
+		}
+	}()
+
+	f.srv.Run(ctx)
+}
+
+// acct gets the account for the API key, creating a new account with the
+// initial balances if it doesn't exist. accountsMtx MUST be held.
+func (f *fakeKraken) acct(apiKey string) *account {
+	a, found := f.accounts[apiKey]
+	if !found {
+		a = &account{balances: make(map[string]*krtypes.Balance, len(initialBalances))}
+		for name, bal := range initialBalances {
+			a.balances[name] = &krtypes.Balance{Balance: bal}
+		}
+		f.accounts[apiKey] = a
+	}
+	return a
+}
+
+func (f *fakeKraken) fillOrders() {
+	type fill struct {
+		apiKey string
+		exec   *krtypes.Execution
+	}
+	var fills []*fill
+	f.accountsMtx.Lock()
+	for id, ord := range f.orders {
+		if ord.status != krtypes.OrderStatusOpen {
+			if time.Since(ord.stamp) > time.Hour {
+				delete(f.orders, id)
+			}
+			continue
+		}
+		ord.status = krtypes.OrderStatusClosed
+		f.settleOrder(ord)
+		fills = append(fills, &fill{
+			apiKey: ord.apiKey,
+			exec:   ord.execution(krtypes.ExecTypeFilled, "filled", ord.qty),
+		})
+	}
+	f.accountsMtx.Unlock()
+
+	if len(fills) > 0 {
+		log.Debugf("Filling %d open orders", len(fills))
+	}
+	for _, fill := range fills {
+		f.sendExecution(fill.apiKey, fill.exec)
+		f.sendBalanceUpdate(fill.apiKey)
+	}
+}
+
+// settleOrder updates the balances for a filled order. accountsMtx MUST be
+// held.
+func (f *fakeKraken) settleOrder(ord *userOrder) {
+	a := f.acct(ord.apiKey)
+	base, quote := a.balances[ord.pair.Base], a.balances[ord.pair.Quote]
+	quoteQty := ord.qty * ord.rate
+	if ord.sell {
+		base.Balance -= ord.qty
+		base.HoldTrade -= ord.qty
+		quote.Balance += quoteQty
+	} else {
+		quote.Balance -= quoteQty
+		quote.HoldTrade -= quoteQty
+		base.Balance += ord.qty
+	}
+}
+
+func (ord *userOrder) execution(execType, status string, filled float64) *krtypes.Execution {
+	side := "buy"
+	if ord.sell {
+		side = "sell"
+	}
+	return &krtypes.Execution{
+		OrderID:       ord.id,
+		ClientOrderID: ord.clientID,
+		ExecType:      execType,
+		OrderStatus:   status,
+		Symbol:        wsSymbol(ord.pair.WSName),
+		Side:          side,
+		CumQty:        filled,
+		CumCost:       filled * ord.rate,
+	}
+}
+
+func (f *fakeKraken) processTransfers() {
+	var updated []string
+	f.accountsMtx.Lock()
+	for _, d := range f.deposits {
+		if d.Status != krtypes.TransferStatusPending || time.Now().Before(d.completes) {
+			continue
+		}
+		d.Status = krtypes.TransferStatusSuccess
+		bal := f.acct(d.apiKey).balances[d.Asset]
+		if bal == nil {
+			bal = new(krtypes.Balance)
+			f.acct(d.apiKey).balances[d.Asset] = bal
+		}
+		bal.Balance += d.Amount - d.Fee
+		updated = append(updated, d.apiKey)
+		log.Infof("Credited deposit of %.8f %s", d.Amount, d.Asset)
+	}
+	for _, w := range f.withdrawals {
+		if w.Status != krtypes.TransferStatusPending || time.Now().Before(w.completes) {
+			continue
+		}
+		w.Status = krtypes.TransferStatusSuccess
+		w.TxID = hex.EncodeToString(encode.RandomBytes(32))
+		log.Infof("Completed withdrawal of %.8f %s with fake tx ID %s", w.Amount, w.Asset, w.TxID)
+	}
+	f.accountsMtx.Unlock()
+
+	for _, apiKey := range updated {
+		f.sendBalanceUpdate(apiKey)
+	}
+}
+
+func apiKey(r *http.Request) string {
+	return r.Header.Get("API-Key")
+}
+
+func (f *fakeKraken) handleAssets(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, assets)
+}
+
+func (f *fakeKraken) handleAssetPairs(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, pairs)
+}
+
+func (f *fakeKraken) handleTicker(w http.ResponseWriter, r *http.Request) {
+	requested := make(map[string]bool)
+	for _, altName := range strings.Split(r.URL.Query().Get("pair"), ",") {
+		requested[altName] = true
+	}
+
+	f.marketsMtx.RLock()
+	defer f.marketsMtx.RUnlock()
+	num := func(v float64) json.Number {
+		return json.Number(strconv.FormatFloat(v, 'f', 8, 64))
+	}
+	tickers := make(map[string]*krtypes.Ticker)
+	for name, pair := range pairs {
+		if len(requested) > 0 && !requested[pair.AltName] {
+			continue
+		}
+		mkt := f.markets[wsSymbol(pair.WSName)]
+		mkt.mtx.RLock()
+		last, open := mkt.rate, mkt.basisRate
+		mkt.mtx.RUnlock()
+		vol := 1e5 / fiatRates[pair.Base]
+		tickers[name] = &krtypes.Ticker{
+			LastTrade:    [2]json.Number{num(last), num(1)},
+			Volume:       [2]json.Number{num(vol), num(vol)},
+			VWAP:         [2]json.Number{num((last + open) / 2), num((last + open) / 2)},
+			Low:          [2]json.Number{num(mkt.minRate), num(mkt.minRate)},
+			High:         [2]json.Number{num(mkt.maxRate), num(mkt.maxRate)},
+			OpeningPrice: num(open),
+		}
+	}
+	writeResult(w, tickers)
+}
+
+func (f *fakeKraken) handleBalanceEx(w http.ResponseWriter, r *http.Request) {
+	f.accountsMtx.Lock()
+	defer f.accountsMtx.Unlock()
+	writeResult(w, f.acct(apiKey(r)).balances)
+}
+
+func (f *fakeKraken) handleGetWebSocketsToken(w http.ResponseWriter, r *http.Request) {
+	token := hex.EncodeToString(encode.RandomBytes(16))
+	f.tokensMtx.Lock()
+	f.tokens[token] = apiKey(r)
+	f.tokensMtx.Unlock()
+	writeResult(w, &krtypes.WebSocketsToken{Token: token, Expires: 900})
+}
+
+func findPair(altName string) *krtypes.AssetPair {
+	for _, pair := range pairs {
+		if pair.AltName == altName {
+			return pair
+		}
+	}
+	return nil
+}
+
+func (f *fakeKraken) handleAddOrder(w http.ResponseWriter, r *http.Request) {
+	key := apiKey(r)
+	pair := findPair(r.FormValue("pair"))
+	if pair == nil {
+		writeError(w, "EQuery:Unknown asset pair")
+		return
+	}
+	if r.FormValue("ordertype") != "limit" {
+		writeError(w, "EGeneral:Invalid arguments:ordertype")
+		return
+	}
+	sell := r.FormValue("type") == "sell"
+	rate, err := strconv.ParseFloat(r.FormValue("price"), 64)
+	if err != nil || rate <= 0 {
+		writeError(w, "EGeneral:Invalid arguments:price")
+		return
+	}
+	qty, err := strconv.ParseFloat(r.FormValue("volume"), 64)
+	if err != nil || qty < pair.OrderMin {
+		writeError(w, "EOrder:Order minimum not met")
+		return
+	}
+
+	ord := &userOrder{
+		id:       strings.ToUpper(hex.EncodeToString(encode.RandomBytes(9))),
+		clientID: r.FormValue("cl_ord_id"),
+		pair:     pair,
+		sell:     sell,
+		rate:     rate,
+		qty:      qty,
+		apiKey:   key,
+		stamp:    time.Now(),
+		status:   krtypes.OrderStatusOpen,
+	}
+
+	f.accountsMtx.Lock()
+	a := f.acct(key)
+	lockAsset, lockQty := pair.Quote, qty*rate
+	if sell {
+		lockAsset, lockQty = pair.Base, qty
+	}
+	bal := a.balances[lockAsset]
+	if bal == nil || bal.Balance-bal.HoldTrade < lockQty {
+		f.accountsMtx.Unlock()
+		writeError(w, "EOrder:Insufficient funds")
+		return
+	}
+	bal.HoldTrade += lockQty
+	f.orders[ord.id] = ord
+	f.accountsMtx.Unlock()
+
+	log.Debugf("Order %s placed: sell = %t, %s, rate = %f, qty = %f", ord.id, sell, pair.AltName, rate, qty)
+
+	writeResult(w, &krtypes.AddOrderResult{
+		Description: &krtypes.OrderDescription{
+			Order: fmt.Sprintf("%s %s %s @ limit %s", r.FormValue("type"), r.FormValue("volume"), pair.AltName, r.FormValue("price")),
+		},
+		TxIDs: []string{ord.id},
+	})
+
+	f.sendExecution(key, ord.execution(krtypes.ExecTypeNew, "new", 0))
+	f.sendBalanceUpdate(key)
+}
+
+func (f *fakeKraken) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	key := apiKey(r)
+	f.accountsMtx.Lock()
+	ord, found := f.orders[r.FormValue("txid")]
+	if !found || ord.apiKey != key {
+		f.accountsMtx.Unlock()
+		writeError(w, "EOrder:Unknown order")
+		return
+	}
+	if ord.status != krtypes.OrderStatusOpen {
+		f.accountsMtx.Unlock()
+		writeResult(w, &krtypes.CancelOrderResult{})
+		return
+	}
+	ord.status = krtypes.OrderStatusCanceled
+	bal := f.acct(key).balances[ord.pair.Quote]
+	lockQty := ord.qty * ord.rate
+	if ord.sell {
+		bal, lockQty = f.acct(key).balances[ord.pair.Base], ord.qty
+	}
+	bal.HoldTrade -= lockQty
+	f.accountsMtx.Unlock()
+
+	writeResult(w, &krtypes.CancelOrderResult{Count: 1})
+
+	f.sendExecution(key, ord.execution(krtypes.ExecTypeCanceled, "canceled", 0))
+	f.sendBalanceUpdate(key)
+}
+
+func (f *fakeKraken) handleQueryOrders(w http.ResponseWriter, r *http.Request) {
+	key := apiKey(r)
+	resp := make(map[string]*krtypes.OrderInfo)
+	f.accountsMtx.Lock()
+	for _, id := range strings.Split(r.FormValue("txid"), ",") {
+		ord, found := f.orders[id]
+		if !found || ord.apiKey != key {
+			continue
+		}
+		side := "buy"
+		if ord.sell {
+			side = "sell"
+		}
+		var filled float64
+		if ord.status == krtypes.OrderStatusClosed {
+			filled = ord.qty
+		}
+		resp[id] = &krtypes.OrderInfo{
+			ClientOrderID: ord.clientID,
+			Status:        ord.status,
+			Description: &krtypes.OrderDescription{
+				Pair:      ord.pair.AltName,
+				Type:      side,
+				OrderType: "limit",
+				Price:     ord.rate,
+			},
+			Volume:     ord.qty,
+			VolumeExec: filled,
+			Cost:       filled * ord.rate,
+			Price:      ord.rate,
+		}
+	}
+	f.accountsMtx.Unlock()
+	writeResult(w, resp)
+}
+
+func depositMethod(asset string) string {
+	name := internalAssetName(asset)
+	for _, m := range withdrawMethods {
+		if m.Asset == name {
+			return m.Method
+		}
+	}
+	return ""
+}
+
+func (f *fakeKraken) handleDepositMethods(w http.ResponseWriter, r *http.Request) {
+	method := depositMethod(r.FormValue("asset"))
+	if method == "" {
+		writeError(w, "EFunding:Unknown asset")
+		return
+	}
+	writeResult(w, []*krtypes.DepositMethod{{Method: method, Minimum: "0", GenAddress: true}})
+}
+
+func (f *fakeKraken) handleDepositAddresses(w http.ResponseWriter, r *http.Request) {
+	if depositMethod(r.FormValue("asset")) == "" {
+		writeError(w, "EFunding:Unknown asset")
+		return
+	}
+	// The address is not used. Deposits are credited when the client checks
+	// the deposit status.
+	writeResult(w, []*krtypes.DepositAddress{{Address: "fake" + hex.EncodeToString(encode.RandomBytes(16))}})
+}
+
+func (f *fakeKraken) handleDepositStatus(w http.ResponseWriter, r *http.Request) {
+	key := apiKey(r)
+	asset := internalAssetName(r.FormValue("asset"))
+	txID := r.FormValue("txid")
+
+	f.accountsMtx.Lock()
+	defer f.accountsMtx.Unlock()
+
+	// The libxc client sends the deposit's tx ID and amount to the fake
+	// server.
+	if _, found := f.deposits[txID]; !found && txID != "" {
+		amt, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+		if err != nil {
+			writeError(w, "EGeneral:Invalid arguments:amount")
+			return
+		}
+		f.deposits[txID] = &transfer{
+			Transfer: krtypes.Transfer{
+				Method: depositMethod(asset),
+				Asset:  asset,
+				RefID:  hex.EncodeToString(encode.RandomBytes(8)),
+				TxID:   txID,
+				Amount: amt,
+				Time:   time.Now().Unix(),
+				Status: krtypes.TransferStatusPending,
+			},
+			apiKey:    key,
+			completes: time.Now().Add(transferDelay),
+		}
+	}
+
+	resp := make([]*krtypes.Transfer, 0)
+	for _, d := range f.deposits {
+		if d.apiKey == key && d.Asset == asset {
+			resp = append(resp, &d.Transfer)
+		}
+	}
+	writeResult(w, resp)
+}
+
+func (f *fakeKraken) handleWithdrawMethods(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, withdrawMethods)
+}
+
+func (f *fakeKraken) handleWithdrawAddresses(w http.ResponseWriter, r *http.Request) {
+	// Every address is in the fake address book.
+	addr := r.FormValue("address")
+	if addr == "" {
+		writeResult(w, []*krtypes.WithdrawAddress{})
+		return
+	}
+	writeResult(w, []*krtypes.WithdrawAddress{{
+		Address:  addr,
+		Asset:    r.FormValue("asset"),
+		Method:   r.FormValue("method"),
+		Key:      "key-" + addr,
+		Verified: true,
+	}})
+}
+
+func (f *fakeKraken) handleWithdraw(w http.ResponseWriter, r *http.Request) {
+	key := apiKey(r)
+	asset := internalAssetName(r.FormValue("asset"))
+	amt, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+	if err != nil || amt <= 0 {
+		writeError(w, "EGeneral:Invalid arguments:amount")
+		return
+	}
+	if r.FormValue("key") != "key-"+r.FormValue("address") {
+		writeError(w, "EFunding:Unknown withdraw key")
+		return
+	}
+
+	f.accountsMtx.Lock()
+	bal := f.acct(key).balances[asset]
+	if bal == nil || bal.Balance-bal.HoldTrade < amt {
+		f.accountsMtx.Unlock()
+		writeError(w, "EFunding:Insufficient funds")
+		return
+	}
+	bal.Balance -= amt
+	refID := hex.EncodeToString(encode.RandomBytes(8))
+	f.withdrawals[refID] = &transfer{
+		Transfer: krtypes.Transfer{
+			Method: depositMethod(asset),
+			Asset:  asset,
+			RefID:  refID,
+			Amount: amt,
+			Time:   time.Now().Unix(),
+			Status: krtypes.TransferStatusPending,
+		},
+		apiKey:    key,
+		completes: time.Now().Add(transferDelay),
+	}
+	f.accountsMtx.Unlock()
+
+	log.Infof("Withdrawal of %.8f %s to %s requested", amt, asset, r.FormValue("address"))
+
+	writeResult(w, &krtypes.WithdrawResult{RefID: refID})
+	f.sendBalanceUpdate(key)
+}
+
+func (f *fakeKraken) handleWithdrawStatus(w http.ResponseWriter, r *http.Request) {
+	key := apiKey(r)
+	asset := internalAssetName(r.FormValue("asset"))
+	f.accountsMtx.Lock()
+	defer f.accountsMtx.Unlock()
+	resp := make([]*krtypes.Transfer, 0)
+	for _, wd := range f.withdrawals {
+		if wd.apiKey == key && wd.Asset == asset {
+			resp = append(resp, &wd.Transfer)
+		}
+	}
+	writeResult(w, resp)
+}
+
+// wsRequest is a websocket v2 request as received by the server.
+type wsRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	ReqID  uint64          `json:"req_id"`
+}
+
+func (f *fakeKraken) newWSLink(w http.ResponseWriter, r *http.Request, handler func([]byte)) (_ *ws.WSLink, _ *dex.ConnectionMaster) {
+	wsConn, err := ws.NewConnection(w, r, pongWait)
+	if err != nil {
+		log.Errorf("ws.NewConnection error: %v", err)
+		http.Error(w, "error initializing connection", http.StatusInternalServerError)
+		return
+	}
+
+	ip := dex.NewIPKey(r.RemoteAddr)
+
+	conn := ws.NewWSLink(ip.String(), wsConn, pingPeriod, func(msg *msgjson.Message) *msgjson.Error {
+		return nil
+	}, dex.StdOutLogger(fmt.Sprintf("CL[%s]", ip), dex.LevelDebug))
+	conn.RawHandler = handler
+
+	cm := dex.NewConnectionMaster(conn)
+	if err = cm.ConnectOnce(f.ctx); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	return conn, cm
+}
+
+func sendMethodResponse(conn *ws.WSLink, req *wsRequest, errMsg string) {
+	b, _ := json.Marshal(&krtypes.WSMessage{
+		Method:  req.Method,
+		Success: errMsg == "",
+		Error:   errMsg,
+		ReqID:   req.ReqID,
+	})
+	conn.SendRaw(b)
+}
+
+func (f *fakeKraken) handleMarketStream(w http.ResponseWriter, r *http.Request) {
+	sub := &marketSubscriber{symbols: make(map[string]struct{})}
+
+	handler := func(b []byte) {
+		var req wsRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			log.Errorf("Error unmarshaling market stream request: %v", err)
+			return
+		}
+		var params krtypes.BookSubscription
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Channel != "book" {
+			sendMethodResponse(sub.WSLink, &req, "Unsupported channel")
+			return
+		}
+
+		f.marketsMtx.Lock()
+		defer f.marketsMtx.Unlock()
+		for _, symbol := range params.Symbol {
+			if _, found := f.markets[symbol]; !found {
+				sendMethodResponse(sub.WSLink, &req, "Currency pair not supported "+symbol)
+				return
+			}
+		}
+		switch req.Method {
+		case "subscribe":
+			sendMethodResponse(sub.WSLink, &req, "")
+			for _, symbol := range params.Symbol {
+				sub.symbols[symbol] = struct{}{}
+				mkt := f.markets[symbol]
+				mkt.mtx.RLock()
+				bids, asks := mkt.levels()
+				mkt.mtx.RUnlock()
+				sub.SendRaw(bookMessage("snapshot", symbol, bids, asks))
+			}
+		case "unsubscribe":
+			for _, symbol := range params.Symbol {
+				delete(sub.symbols, symbol)
+			}
+			sendMethodResponse(sub.WSLink, &req, "")
+		default:
+			sendMethodResponse(sub.WSLink, &req, "Unsupported method")
+		}
+	}
+
+	conn, cm := f.newWSLink(w, r, handler)
+	if conn == nil { // Already logged.
+		return
+	}
+	f.marketsMtx.Lock()
+	sub.WSLink = conn
+	f.marketSubscribers[sub] = struct{}{}
+	f.marketsMtx.Unlock()
+
+	go func() {
+		cm.Wait()
+		f.marketsMtx.Lock()
+		delete(f.marketSubscribers, sub)
+		f.marketsMtx.Unlock()
+	}()
+}
+
+func (f *fakeKraken) handleUserStream(w http.ResponseWriter, r *http.Request) {
+	var conn *ws.WSLink
+	var connMtx sync.Mutex
+	var subscribedKey string
+
+	handler := func(b []byte) {
+		connMtx.Lock()
+		defer connMtx.Unlock()
+		var req wsRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			log.Errorf("Error unmarshaling user stream request: %v", err)
+			return
+		}
+		var params krtypes.PrivateSubscription
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			sendMethodResponse(conn, &req, "Invalid params")
+			return
+		}
+		f.tokensMtx.RLock()
+		key, found := f.tokens[params.Token]
+		f.tokensMtx.RUnlock()
+		if !found {
+			sendMethodResponse(conn, &req, "EAccount:Invalid token")
+			return
+		}
+		if req.Method != "subscribe" {
+			sendMethodResponse(conn, &req, "Unsupported method")
+			return
+		}
+		sendMethodResponse(conn, &req, "")
+		if subscribedKey != "" {
+			return
+		}
+		subscribedKey = key
+		f.userSubscribersMtx.Lock()
+		subs, found := f.userSubscribers[key]
+		if !found {
+			subs = make(map[*ws.WSLink]struct{})
+			f.userSubscribers[key] = subs
+		}
+		subs[conn] = struct{}{}
+		f.userSubscribersMtx.Unlock()
+	}
+
+	link, cm := f.newWSLink(w, r, handler)
+	if link == nil { // Already logged.
+		return
+	}
+	connMtx.Lock()
+	conn = link
+	connMtx.Unlock()
+
+	go func() {
+		cm.Wait()
+		connMtx.Lock()
+		key := subscribedKey
+		connMtx.Unlock()
+		if key == "" {
+			return
+		}
+		f.userSubscribersMtx.Lock()
+		delete(f.userSubscribers[key], link)
+		f.userSubscribersMtx.Unlock()
+	}()
+}
+
+func (f *fakeKraken) sendUserMessage(apiKey, channel string, data interface{}) {
+	dataB, _ := json.Marshal(data)
+	b, _ := json.Marshal(&krtypes.WSMessage{
+		Channel: channel,
+		Type:    "update",
+		Data:    dataB,
+	})
+	f.userSubscribersMtx.RLock()
+	defer f.userSubscribersMtx.RUnlock()
+	for conn := range f.userSubscribers[apiKey] {
+		conn.SendRaw(b)
+	}
+}
+
+func (f *fakeKraken) sendExecution(apiKey string, exec *krtypes.Execution) {
+	f.sendUserMessage(apiKey, "executions", []*krtypes.Execution{exec})
+}
+
+func (f *fakeKraken) sendBalanceUpdate(apiKey string) {
+	f.accountsMtx.Lock()
+	bals := make([]*krtypes.WSBalance, 0, len(f.acct(apiKey).balances))
+	for name, bal := range f.acct(apiKey).balances {
+		bals = append(bals, &krtypes.WSBalance{Asset: assets[name].AltName, Balance: bal.Balance})
+	}
+	f.accountsMtx.Unlock()
+	f.sendUserMessage(apiKey, "balances", bals)
+}
+
+func bookMessage(msgType, symbol string, bids, asks []*krtypes.PriceLevel) []byte {
+	data, _ := json.Marshal([]*krtypes.BookUpdate{{
+		Symbol: symbol,
+		Bids:   bids,
+		Asks:   asks,
+	}})
+	b, _ := json.Marshal(&krtypes.WSMessage{
+		Channel: "book",
+		Type:    msgType,
+		Data:    data,
+	})
+	return b
+}
+
+type market struct {
+	pair             *krtypes.AssetPair
+	basisRate        float64
+	minRate, maxRate float64
+	baseFiatRate     float64
+	mtx              sync.RWMutex
+	rate             float64
+	bids, asks       []*krtypes.PriceLevel
+}
+
+func newMarket(pair *krtypes.AssetPair, basisRate float64) *market {
+	const maxVariation = 0.1
+	m := &market{
+		pair:         pair,
+		basisRate:    basisRate,
+		minRate:      basisRate / (1 + maxVariation),
+		maxRate:      basisRate * (1 + maxVariation),
+		baseFiatRate: fiatRates[pair.Base],
+		rate:         basisRate,
+	}
+	m.shuffle()
+	return m
+}
+
+func (m *market) levels() (bids, asks []*krtypes.PriceLevel) {
+	return append([]*krtypes.PriceLevel(nil), m.bids...), append([]*krtypes.PriceLevel(nil), m.asks...)
+}
+
+func (m *market) roundRate(r float64) float64 {
+	p := math.Pow10(m.pair.PairDecimals)
+	return math.Round(r*p) / p
+}
+
+// shuffle randomizes the order book and returns the updates needed to go
+// from the old book to the new one. mtx MUST be held.
+func (m *market) shuffle() (bids, asks []*krtypes.PriceLevel) {
+	shift := m.basisRate * maxWalkingSpeed * (rand.Float64()*2 - 1)
+	m.rate = math.Min(math.Max(m.rate+shift, m.minRate), m.maxRate)
+
+	halfGap := 0.002 + rand.Float64()*0.02
+	spacing := (0.002 + rand.Float64()*0.01) * m.rate
+
+	makeSide := func(old []*krtypes.PriceLevel, best, direction float64) (levels, updates []*krtypes.PriceLevel) {
+		removed := make(map[float64]bool, len(old))
+		for _, l := range old {
+			removed[l.Price] = true
+		}
+		n := rand.Intn(20) + 5
+		levels = make([]*krtypes.PriceLevel, 0, n)
+		for i := 0; i < n; i++ {
+			rate := m.roundRate(best + spacing*direction*float64(i))
+			if rate <= 0 {
+				break
+			}
+			// Each level has between 1 and 10,001 USD equivalent.
+			qty := (1 + 10_000*rand.Float64()) / m.baseFiatRate
+			qty = math.Round(qty*1e8) / 1e8
+			delete(removed, rate)
+			l := &krtypes.PriceLevel{Price: rate, Qty: qty}
+			levels = append(levels, l)
+			updates = append(updates, l)
+		}
+		for rate := range removed {
+			updates = append(updates, &krtypes.PriceLevel{Price: rate})
+		}
+		return levels, updates
+	}
+
+	m.bids, bids = makeSide(m.bids, m.rate/(1+halfGap), -1)
+	m.asks, asks = makeSide(m.asks, m.rate*(1+halfGap), 1)
+	return bids, asks
+}
+
+type response struct {
+	Error  []string    `json:"error"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	writeJSONWithStatus(w, &response{Error: []string{}, Result: result}, http.StatusOK)
+}
+
+func writeError(w http.ResponseWriter, errs ...string) {
+	writeJSONWithStatus(w, &response{Error: errs}, http.StatusOK)
+}
+
+// writeJSONWithStatus marshals the provided interface and writes the bytes to
+// the ResponseWriter with the specified response code.
+func writeJSONWithStatus(w http.ResponseWriter, thing interface{}, code int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	b, err := json.Marshal(thing)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Errorf("JSON encode error: %v", err)
+		return
+	}
+	w.WriteHeader(code)
+	if _, err := w.Write(append(b, byte('\n'))); err != nil {
+		log.Errorf("Write error: %v", err)
+	}
+}
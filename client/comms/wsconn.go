@@ -149,6 +149,11 @@ type WsCfg struct {
 
 	// EchoPingData will echo any data from pings as the pong data.
 	EchoPingData bool
+
+	// ExtendDeadlineOnMessage extends the read deadline by PingWait whenever
+	// a message is received. This is useful for servers that send
+	// application-level heartbeats instead of websocket pings.
+	ExtendDeadlineOnMessage bool
 }
 
 // wsConn represents a client websocket connection.
@@ -402,6 +407,11 @@ func (conn *wsConn) readRaw(ctx context.Context) {
 			conn.handleReadError(err)
 			return
 		}
+		if conn.cfg.ExtendDeadlineOnMessage {
+			if err := ws.SetReadDeadline(time.Now().Add(conn.cfg.PingWait)); err != nil {
+				conn.log.Errorf("set read deadline failed: %v", err)
+			}
+		}
 		conn.cfg.RawHandler(msgBytes)
 	}
 }
@@ -34,6 +34,7 @@ func (c *Core) stopDEXConnection(dc *dexConnection) {
 		}
 	}
 	dc.cfgMtx.RUnlock()
+	dc.stopParamsTimers()
 	dc.connMaster.Disconnect() // disconnect
 }
 
@@ -374,6 +375,7 @@ func (c *Core) UpdateDEXHost(oldHost, newHost string, appPW []byte, certI any) (
 	defer func() {
 		// Either disconnect or promote this connection.
 		if !updatedHost {
+			newDc.stopParamsTimers()
 			newDc.connMaster.Disconnect()
 			return
 		}
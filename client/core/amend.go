@@ -0,0 +1,157 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"fmt"
+
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
+)
+
+// AmendOrder changes the rate and quantity of a standing limit order. The
+// order is canceled and replaced by a new limit order with a single amend
+// request, and the server processes both orders in the same epoch. The
+// replacement order is funded separately, and is only matched if the cancel
+// order executes. The cancel order of an amend is not counted against the
+// account's cancellation rate. The replacement order is returned.
+func (c *Core) AmendOrder(pw []byte, oidB dex.Bytes, rate, qty uint64) (*Order, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return nil, err
+	}
+	oid, err := order.IDFromBytes(oidB)
+	if err != nil {
+		return nil, err
+	}
+
+	var dc *dexConnection
+	var tracker *trackedTrade
+	for _, d := range c.dexConnections() {
+		if t, isCancel := d.findOrder(oid); t != nil && !isCancel {
+			dc, tracker = d, t
+			break
+		}
+	}
+	if tracker == nil {
+		return nil, newError(unknownOrderErr, "amend: order %s not found", oid)
+	}
+
+	lo, ok := tracker.Order.(*order.LimitOrder)
+	if !ok || lo.Force != order.StandingTiF {
+		return nil, newError(orderParamsErr, "cannot amend %s order %s that is not a standing limit order", tracker.Type(), oid)
+	}
+	if lo.Rate == rate && lo.Quantity == qty {
+		return nil, newError(orderParamsErr, "amend does not change the rate or quantity of order %s", oid)
+	}
+
+	mktConf := dc.marketConfig(tracker.mktID)
+	if mktConf == nil {
+		return nil, newError(marketErr, "unknown market %q", tracker.mktID)
+	}
+
+	// Check before funding the replacement, and again when sending.
+	tracker.mtx.Lock()
+	err = tracker.checkCancelable()
+	tracker.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := c.prepareTradeRequest(pw, &TradeForm{
+		Host:    dc.acct.host,
+		IsLimit: true,
+		Sell:    lo.Sell,
+		Base:    lo.BaseAsset,
+		Quote:   lo.QuoteAsset,
+		Qty:     qty,
+		Rate:    rate,
+		Options: tracker.options,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tr.errCloser.Done(c.log)
+	defer close(tr.commitSig) // signals on both success and failure
+
+	tracker.mtx.Lock()
+	defer tracker.mtx.Unlock()
+
+	if err := tracker.checkCancelable(); err != nil {
+		return nil, err
+	}
+
+	preImg, co, coCommitSig, err := c.newCancelOrder(dc, oid, lo.BaseAsset, lo.QuoteAsset)
+	if err != nil {
+		return nil, err
+	}
+	defer close(coCommitSig)
+
+	_, msgCancel, _ := messageOrder(co, nil)
+	amend := &msgjson.AmendOrder{
+		Cancel: *msgCancel.(*msgjson.CancelOrder),
+		Order:  *tr.msgOrder.(*msgjson.LimitOrder),
+	}
+	result := new(msgjson.AmendResult)
+	err = dc.signAndRequest(amend, msgjson.AmendRoute, result, fundingTxWait+DefaultResponseTimeout)
+	if err != nil {
+		// As with other orders, both orders are ABANDONED if the server
+		// accepted the request but the response was lost.
+		c.forgetCommit(co.Commit)
+		return nil, fmt.Errorf("amend request for order %s with DEX server %v failed: %w", oid, dc.acct.host, err)
+	}
+	if result.Cancel == nil || result.Order == nil {
+		c.forgetCommit(co.Commit)
+		return nil, fmt.Errorf("incomplete amend result for order %s", oid)
+	}
+	if err = validateOrderResponse(dc, result.Cancel, co, &amend.Cancel); err != nil {
+		c.forgetCommit(co.Commit)
+		return nil, fmt.Errorf("Abandoning amend. cancel preimage: %x, server time: %d: %w",
+			preImg[:], result.Cancel.ServerTime, err)
+	}
+	replacement := tr.dbOrder.Order
+	if err = validateOrderResponse(dc, result.Order, replacement, &amend.Order); err != nil {
+		c.forgetCommit(co.Commit)
+		return nil, fmt.Errorf("Abandoning amend. replacement preimage: %x, server time: %d: %w",
+			tr.preImg[:], result.Order.ServerTime, err)
+	}
+
+	corder, err := c.trackTradeRequest(tr, result.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	// Track the cancel order with the amended order, and link the cancel order
+	// to the replacement. The amended order is linked to the cancel order.
+	if err = tracker.cancelTrade(co, preImg, mktConf.EpochLen); err != nil {
+		return nil, fmt.Errorf("error storing amend cancel order info %s: %w", co.ID(), err)
+	}
+	tracker.cancel.replacement = replacement.ID()
+
+	err = c.db.UpdateOrder(&db.MetaOrder{
+		MetaData: &db.OrderMetaData{
+			Status: order.OrderStatusEpoch,
+			Host:   dc.acct.host,
+			Proof: db.OrderProof{
+				DEXSig:   result.Cancel.Sig,
+				Preimage: preImg[:],
+			},
+			EpochDur:    mktConf.EpochLen,
+			LinkedOrder: replacement.ID(),
+		},
+		Order: co,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store amend cancel order in database: %w", err)
+	}
+
+	c.log.Infof("Order %s at %s amended with cancel order %s and replacement order %s",
+		oid, dc.acct.host, co.ID(), replacement.ID())
+
+	subject, details := c.formatDetails(TopicCancellingOrder, makeOrderToken(tracker.token()))
+	c.notify(newOrderNote(TopicCancellingOrder, subject, details, db.Poke, tracker.coreOrderInternal()))
+
+	return corder, nil
+}
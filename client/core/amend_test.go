@@ -0,0 +1,161 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"testing"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
+)
+
+func TestAmendOrder(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+	dc := rig.dc
+
+	dcrWallet, tDcrWallet := newTWallet(tUTXOAssetA.ID)
+	tCore.wallets[tUTXOAssetA.ID] = dcrWallet
+	dcrWallet.address = "DsVmA7aqqWeKWy461hXjytbZbgCqbB8g2dq"
+	dcrWallet.Unlock(rig.crypter)
+
+	btcWallet, _ := newTWallet(tUTXOAssetB.ID)
+	tCore.wallets[tUTXOAssetB.ID] = btcWallet
+	btcWallet.address = "12DXGkvxFjuq5btXYkwWfBZaz1rVwFgini"
+	btcWallet.Unlock(rig.crypter)
+
+	qty := dcrBtcLotSize * 10
+	rate := dcrBtcRateStep * 1000
+
+	fund := func() {
+		tDcrWallet.fundingCoins = asset.Coins{&tCoin{id: encode.RandomBytes(36), val: qty * 2}}
+		tDcrWallet.fundRedeemScripts = []dex.Bytes{nil}
+	}
+
+	book := newBookie(dc, tUTXOAssetA.ID, tUTXOAssetB.ID, nil, tLogger)
+	dc.books[tDcrBtcMktName] = book
+	err := book.Sync(&msgjson.OrderBook{
+		MarketID: tDcrBtcMktName,
+		Seq:      1,
+		Epoch:    1,
+	})
+	if err != nil {
+		t.Fatalf("order book sync error: %v", err)
+	}
+
+	rig.ws.queueResponse(msgjson.LimitRoute, func(msg *msgjson.Message, f msgFunc) error {
+		msgOrder := new(msgjson.LimitOrder)
+		if err := msg.Unmarshal(msgOrder); err != nil {
+			t.Fatalf("unmarshal error: %v", err)
+		}
+		f(orderResponse(msg.ID, msgOrder, convertMsgLimitOrder(msgOrder), false, false, false))
+		return nil
+	})
+	fund()
+	corder, err := tCore.Trade(tPW, &TradeForm{
+		Host:    tDexHost,
+		IsLimit: true,
+		Sell:    true,
+		Base:    tUTXOAssetA.ID,
+		Quote:   tUTXOAssetB.ID,
+		Qty:     qty,
+		Rate:    rate,
+	})
+	if err != nil {
+		t.Fatalf("trade error: %v", err)
+	}
+	oid := order.OrderID(corder.ID)
+	tracker, _ := dc.findOrder(oid)
+	if tracker == nil {
+		t.Fatalf("order not tracked")
+	}
+	tracker.metaData.Status = order.OrderStatusBooked
+
+	badSig := false
+	queueAmend := func() {
+		rig.ws.queueResponse(msgjson.AmendRoute, func(msg *msgjson.Message, f msgFunc) error {
+			amend := new(msgjson.AmendOrder)
+			if err := msg.Unmarshal(amend); err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+			coResp := orderResponse(msg.ID, &amend.Cancel, convertMsgCancelOrder(&amend.Cancel), false, false, false)
+			loResp := orderResponse(msg.ID, &amend.Order, convertMsgLimitOrder(&amend.Order), badSig, false, false)
+			result := new(msgjson.AmendResult)
+			if err := coResp.UnmarshalResult(&result.Cancel); err != nil {
+				t.Fatalf("cancel result error: %v", err)
+			}
+			if err := loResp.UnmarshalResult(&result.Order); err != nil {
+				t.Fatalf("order result error: %v", err)
+			}
+			resp, _ := msgjson.NewResponse(msg.ID, result, nil)
+			f(resp)
+			return nil
+		})
+	}
+
+	// Nothing to amend.
+	if _, err := tCore.AmendOrder(tPW, oid[:], rate, qty); err == nil {
+		t.Fatalf("no error for unchanged order")
+	}
+	if _, err := tCore.AmendOrder(tPW, encode.RandomBytes(32), rate, qty); !errorHasCode(err, unknownOrderErr) {
+		t.Fatalf("wrong error for unknown order: %v", err)
+	}
+
+	// A bad server signature on the replacement abandons the amend, and the
+	// order can still be canceled.
+	badSig = true
+	queueAmend()
+	fund()
+	if _, err := tCore.AmendOrder(tPW, oid[:], rate*2, qty); err == nil {
+		t.Fatalf("no error for bad replacement signature")
+	}
+	badSig = false
+	if tracker.cancel != nil {
+		t.Fatalf("cancel order tracked after failed amend")
+	}
+
+	queueAmend()
+	fund()
+	replacement, err := tCore.AmendOrder(tPW, oid[:], rate*2, qty/2)
+	if err != nil {
+		t.Fatalf("AmendOrder error: %v", err)
+	}
+	if replacement.Rate != rate*2 || replacement.Qty != qty/2 || !replacement.Sell {
+		t.Fatalf("wrong replacement order %+v", replacement)
+	}
+	rt, _ := dc.findOrder(order.OrderID(replacement.ID))
+	if rt == nil {
+		t.Fatalf("replacement order not tracked")
+	}
+	if tracker.cancel == nil || tracker.cancel.replacement != rt.ID() {
+		t.Fatalf("cancel order not linked to replacement")
+	}
+
+	// Only one amend or cancel at a time.
+	if _, err := tCore.AmendOrder(tPW, oid[:], rate*3, qty); err == nil {
+		t.Fatalf("no error for second amend")
+	}
+	if err := tCore.Cancel(oid[:]); err == nil {
+		t.Fatalf("no error for cancel of amended order")
+	}
+
+	// If the cancel is not matched, the replacement is not matched by the
+	// server, and must not be left standing.
+	coid := tracker.cancel.ID()
+	for _, id := range []order.OrderID{coid, rt.ID()} {
+		msg, _ := msgjson.NewRequest(1, msgjson.NoMatchRoute, &msgjson.NoMatch{OrderID: id[:]})
+		if err := handleNoMatchRoute(tCore, dc, msg); err != nil {
+			t.Fatalf("nomatch error: %v", err)
+		}
+	}
+	if tracker.cancel != nil {
+		t.Fatalf("cancel order still tracked after nomatch")
+	}
+	if rt.metaData.Status != order.OrderStatusExecuted {
+		t.Fatalf("replacement order not executed after failed amend, status = %s", rt.metaData.Status)
+	}
+}
@@ -0,0 +1,287 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/encrypt"
+)
+
+// API token scopes. Every token can use the routes that only read data. The
+// admin scope grants access to everything.
+const (
+	APIScopeRead  = "read"
+	APIScopeTrade = "trade"
+	APIScopeMM    = "mm"
+	APIScopeSend  = "send"
+	APIScopeAdmin = "admin"
+)
+
+// apiTokenSecretSize is the number of random bytes in an API token.
+const apiTokenSecretSize = 32
+
+var (
+	// ErrUnknownAPIToken is returned by AuthorizeAPIToken for a token that
+	// does not exist or has been revoked.
+	ErrUnknownAPIToken = errors.New("unknown API token")
+	// ErrExpiredAPIToken is returned by AuthorizeAPIToken for an expired
+	// token.
+	ErrExpiredAPIToken = errors.New("expired API token")
+)
+
+// APIToken is a named API token that grants scoped access to the RPC server.
+// The token itself is only known to the holder. A hash of the token is stored
+// with the token's properties, encrypted in the client DB.
+type APIToken struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Markets, if not empty, restricts the trade and mm scopes to these
+	// markets. A market is a market name, e.g. dcr_btc, optionally prefixed
+	// with the DEX host and a slash, e.g. dex.decred.org:7232/dcr_btc.
+	Markets []string `json:"markets,omitempty"`
+	// Created is the UNIX time, in seconds, at which the token was created.
+	Created int64 `json:"created"`
+	// Expiration is the UNIX time, in seconds, after which the token can no
+	// longer be used. Zero means that the token does not expire.
+	Expiration int64 `json:"expiration,omitempty"`
+}
+
+// HasScope checks whether the token grants the scope.
+func (t *APIToken) HasScope(scope string) bool {
+	if scope == APIScopeRead {
+		return true
+	}
+	for _, s := range t.Scopes {
+		if s == scope || s == APIScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// MarketRestricted is true if the token is restricted to specific markets.
+func (t *APIToken) MarketRestricted() bool {
+	return len(t.Markets) > 0
+}
+
+// AllowsMarket checks whether the token may be used on the market.
+func (t *APIToken) AllowsMarket(host string, baseID, quoteID uint32) bool {
+	if len(t.Markets) == 0 {
+		return true
+	}
+	mktName, err := dex.MarketName(baseID, quoteID)
+	if err != nil {
+		return false
+	}
+	for _, mkt := range t.Markets {
+		if mkt == mktName || mkt == host+"/"+mktName {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired checks whether the token has expired.
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.Expiration > 0 && now.Unix() > t.Expiration
+}
+
+// APITokenForm is the information necessary to create an API token.
+type APITokenForm struct {
+	Name    string
+	Scopes  []string
+	Markets []string
+	// Expiration is when the token expires. The zero value is for a token
+	// that does not expire.
+	Expiration time.Time
+}
+
+// storedAPIToken is the API token as encrypted and stored in the DB.
+type storedAPIToken struct {
+	*APIToken
+	Hash dex.Bytes `json:"hash"`
+}
+
+func validateAPITokenMarket(mkt string) error {
+	mktName := mkt
+	if i := strings.LastIndex(mkt, "/"); i >= 0 {
+		if i == 0 {
+			return fmt.Errorf("empty host in market %q", mkt)
+		}
+		mktName = mkt[i+1:]
+	}
+	base, quote, found := strings.Cut(mktName, "_")
+	if !found {
+		return fmt.Errorf("invalid market %q", mkt)
+	}
+	if _, found := dex.BipSymbolID(base); !found {
+		return fmt.Errorf("unknown base asset in market %q", mkt)
+	}
+	if _, found := dex.BipSymbolID(quote); !found {
+		return fmt.Errorf("unknown quote asset in market %q", mkt)
+	}
+	return nil
+}
+
+func (form *APITokenForm) validate() error {
+	if form.Name == "" {
+		return errors.New("no token name")
+	}
+	if len(form.Scopes) == 0 {
+		return errors.New("no token scopes")
+	}
+	for _, scope := range form.Scopes {
+		switch scope {
+		case APIScopeRead, APIScopeTrade, APIScopeMM, APIScopeSend, APIScopeAdmin:
+		default:
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	for _, mkt := range form.Markets {
+		if err := validateAPITokenMarket(mkt); err != nil {
+			return err
+		}
+	}
+	if !form.Expiration.IsZero() && time.Now().After(form.Expiration) {
+		return errors.New("expiration is in the past")
+	}
+	return nil
+}
+
+// loadAPITokens decrypts the API tokens stored in the DB.
+func (c *Core) loadAPITokens(crypter encrypt.Crypter) error {
+	encTokens, err := c.db.APITokens()
+	if err != nil {
+		return fmt.Errorf("error retrieving API tokens: %w", err)
+	}
+	tokens := make(map[string]*storedAPIToken, len(encTokens))
+	for name, encToken := range encTokens {
+		b, err := crypter.Decrypt(encToken)
+		if err != nil {
+			return fmt.Errorf("error decrypting API token %q: %w", name, err)
+		}
+		tkn := new(storedAPIToken)
+		if err := json.Unmarshal(b, tkn); err != nil {
+			return fmt.Errorf("error decoding API token %q: %w", name, err)
+		}
+		tokens[name] = tkn
+	}
+	c.apiTokenMtx.Lock()
+	c.apiTokens = tokens
+	c.apiTokenMtx.Unlock()
+	return nil
+}
+
+// CreateAPIToken creates and stores a new API token. The token is returned
+// and cannot be retrieved later.
+func (c *Core) CreateAPIToken(appPW []byte, form *APITokenForm) (string, *APIToken, error) {
+	if err := form.validate(); err != nil {
+		return "", nil, fmt.Errorf("invalid API token: %w", err)
+	}
+	crypter, err := c.encryptionKey(appPW)
+	if err != nil {
+		return "", nil, codedError(passwordErr, err)
+	}
+	defer crypter.Close()
+
+	c.apiTokenMtx.Lock()
+	defer c.apiTokenMtx.Unlock()
+
+	encTokens, err := c.db.APITokens()
+	if err != nil {
+		return "", nil, fmt.Errorf("error retrieving API tokens: %w", err)
+	}
+	if _, found := encTokens[form.Name]; found {
+		return "", nil, fmt.Errorf("an API token named %q already exists", form.Name)
+	}
+
+	secret := hex.EncodeToString(encode.RandomBytes(apiTokenSecretSize))
+	hash := sha256.Sum256([]byte(secret))
+	tkn := &storedAPIToken{
+		APIToken: &APIToken{
+			Name:    form.Name,
+			Scopes:  append([]string(nil), form.Scopes...),
+			Markets: append([]string(nil), form.Markets...),
+			Created: time.Now().Unix(),
+		},
+		Hash: hash[:],
+	}
+	if !form.Expiration.IsZero() {
+		tkn.Expiration = form.Expiration.Unix()
+	}
+
+	b, err := json.Marshal(tkn)
+	if err != nil {
+		return "", nil, fmt.Errorf("error encoding API token: %w", err)
+	}
+	encToken, err := crypter.Encrypt(b)
+	if err != nil {
+		return "", nil, fmt.Errorf("error encrypting API token: %w", err)
+	}
+	if err := c.db.SaveAPIToken(form.Name, encToken); err != nil {
+		return "", nil, fmt.Errorf("error storing API token: %w", err)
+	}
+	if c.apiTokens == nil {
+		c.apiTokens = make(map[string]*storedAPIToken)
+	}
+	c.apiTokens[form.Name] = tkn
+
+	c.log.Infof("Created API token %q with scopes %v", form.Name, form.Scopes)
+
+	return secret, tkn.APIToken, nil
+}
+
+// APITokens returns the API tokens, sorted by name. The tokens are loaded
+// from the DB on login.
+func (c *Core) APITokens() []*APIToken {
+	c.apiTokenMtx.RLock()
+	defer c.apiTokenMtx.RUnlock()
+	tokens := make([]*APIToken, 0, len(c.apiTokens))
+	for _, tkn := range c.apiTokens {
+		tokens = append(tokens, tkn.APIToken)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens
+}
+
+// RevokeAPIToken deletes the API token with the specified name.
+func (c *Core) RevokeAPIToken(name string) error {
+	c.apiTokenMtx.Lock()
+	defer c.apiTokenMtx.Unlock()
+	if err := c.db.DeleteAPIToken(name); err != nil {
+		return err
+	}
+	delete(c.apiTokens, name)
+	c.log.Infof("Revoked API token %q", name)
+	return nil
+}
+
+// AuthorizeAPIToken returns the API token matching the provided token.
+// Tokens can only be authorized after they are loaded on login.
+func (c *Core) AuthorizeAPIToken(token string) (*APIToken, error) {
+	hash := sha256.Sum256([]byte(token))
+	c.apiTokenMtx.RLock()
+	defer c.apiTokenMtx.RUnlock()
+	for _, tkn := range c.apiTokens {
+		if subtle.ConstantTimeCompare(tkn.Hash, hash[:]) != 1 {
+			continue
+		}
+		if tkn.Expired(time.Now()) {
+			return nil, ErrExpiredAPIToken
+		}
+		return tkn.APIToken, nil
+	}
+	return nil, ErrUnknownAPIToken
+}
@@ -0,0 +1,131 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAPITokens(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+
+	for _, form := range []*APITokenForm{
+		{Scopes: []string{APIScopeTrade}},
+		{Name: "bot"},
+		{Name: "bot", Scopes: []string{"withdraw"}},
+		{Name: "bot", Scopes: []string{APIScopeTrade}, Markets: []string{"dcr-btc"}},
+		{Name: "bot", Scopes: []string{APIScopeTrade}, Markets: []string{"dcr_xyz"}},
+		{Name: "bot", Scopes: []string{APIScopeTrade}, Markets: []string{"/dcr_btc"}},
+		{Name: "bot", Scopes: []string{APIScopeTrade}, Expiration: time.Now().Add(-time.Hour)},
+	} {
+		if _, _, err := tCore.CreateAPIToken(tPW, form); err == nil {
+			t.Fatalf("no error for invalid form %+v", form)
+		}
+	}
+
+	rig.crypter.(*tCrypter).recryptErr = tErr
+	if _, _, err := tCore.CreateAPIToken(tPW, &APITokenForm{Name: "bot", Scopes: []string{APIScopeTrade}}); !errorHasCode(err, passwordErr) {
+		t.Fatalf("wrong error for password error: %v", err)
+	}
+	rig.crypter.(*tCrypter).recryptErr = nil
+
+	botToken, tkn, err := tCore.CreateAPIToken(tPW, &APITokenForm{
+		Name:    "bot",
+		Scopes:  []string{APIScopeTrade, APIScopeMM},
+		Markets: []string{"dcr_btc", tDexHost + "/eth_btc"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIToken error: %v", err)
+	}
+	if tkn.Name != "bot" || tkn.Expiration != 0 {
+		t.Fatalf("wrong token %+v", tkn)
+	}
+	if _, _, err := tCore.CreateAPIToken(tPW, &APITokenForm{Name: "bot", Scopes: []string{APIScopeRead}}); err == nil {
+		t.Fatalf("no error for duplicate token name")
+	}
+	adminToken, _, err := tCore.CreateAPIToken(tPW, &APITokenForm{
+		Name:       "admin",
+		Scopes:     []string{APIScopeAdmin},
+		Expiration: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIToken error: %v", err)
+	}
+
+	tkn, err = tCore.AuthorizeAPIToken(botToken)
+	if err != nil {
+		t.Fatalf("AuthorizeAPIToken error: %v", err)
+	}
+	if tkn.Name != "bot" {
+		t.Fatalf("wrong token authorized: %s", tkn.Name)
+	}
+	for scope, exp := range map[string]bool{
+		APIScopeRead:  true,
+		APIScopeTrade: true,
+		APIScopeMM:    true,
+		APIScopeSend:  false,
+		APIScopeAdmin: false,
+	} {
+		if tkn.HasScope(scope) != exp {
+			t.Fatalf("expected HasScope(%s) = %t", scope, exp)
+		}
+	}
+	if !tkn.AllowsMarket("other.host", 42, 0) || !tkn.AllowsMarket(tDexHost, 60, 0) ||
+		tkn.AllowsMarket("other.host", 60, 0) || tkn.AllowsMarket(tDexHost, 42, 60) {
+		t.Fatalf("wrong market restrictions")
+	}
+
+	tkn, err = tCore.AuthorizeAPIToken(adminToken)
+	if err != nil {
+		t.Fatalf("AuthorizeAPIToken error: %v", err)
+	}
+	if !tkn.HasScope(APIScopeSend) || tkn.MarketRestricted() {
+		t.Fatalf("admin token is restricted")
+	}
+	if _, err := tCore.AuthorizeAPIToken(adminToken + "00"); !errors.Is(err, ErrUnknownAPIToken) {
+		t.Fatalf("wrong error for unknown token: %v", err)
+	}
+
+	// Tokens are reloaded from the DB.
+	tCore.apiTokens = nil
+	if err := tCore.loadAPITokens(rig.crypter); err != nil {
+		t.Fatalf("loadAPITokens error: %v", err)
+	}
+	tkns := tCore.APITokens()
+	if len(tkns) != 2 || tkns[0].Name != "admin" || tkns[1].Name != "bot" {
+		t.Fatalf("wrong tokens loaded")
+	}
+
+	tCore.apiTokens["admin"].Expiration = time.Now().Add(-time.Second).Unix()
+	if _, err := tCore.AuthorizeAPIToken(adminToken); !errors.Is(err, ErrExpiredAPIToken) {
+		t.Fatalf("wrong error for expired token: %v", err)
+	}
+
+	if err := tCore.RevokeAPIToken("bot"); err != nil {
+		t.Fatalf("RevokeAPIToken error: %v", err)
+	}
+	if err := tCore.RevokeAPIToken("bot"); err == nil {
+		t.Fatalf("no error revoking unknown token")
+	}
+	if _, err := tCore.AuthorizeAPIToken(botToken); !errors.Is(err, ErrUnknownAPIToken) {
+		t.Fatalf("wrong error for revoked token: %v", err)
+	}
+	if len(rig.db.apiTokens) != 1 {
+		t.Fatalf("token not deleted from DB")
+	}
+
+	// Tokens are cleared on logout.
+	if err := tCore.Login(tPW); err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+	if err := tCore.Logout(); err != nil {
+		t.Fatalf("Logout error: %v", err)
+	}
+	if _, err := tCore.AuthorizeAPIToken(adminToken); !errors.Is(err, ErrUnknownAPIToken) {
+		t.Fatalf("wrong error for token after logout: %v", err)
+	}
+}
@@ -0,0 +1,675 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encrypt"
+)
+
+const (
+	// BackupDBFile is the name of the client database in a backup archive.
+	BackupDBFile = "dexc.db"
+	// DefaultBackupInterval is the default time between automatic backups.
+	DefaultBackupInterval = 24 * time.Hour
+
+	backupManifestFile = "manifest.json"
+	backupFilePrefix   = "bisonw-backup-"
+	backupFileExt      = ".bwbak"
+	backupTimeFormat   = "20060102T150405Z"
+	backupVersion      = 0
+	// backupChunkSize is the size of the plaintext chunks that are encrypted
+	// individually. Crypter ciphertexts are limited to ~16 MB.
+	backupChunkSize = 1 << 20
+	// backupCheckInterval is how often the scheduler checks whether a backup
+	// is due.
+	backupCheckInterval = time.Minute
+	// preRestoreExt is appended to existing files replaced by RestoreBackup.
+	preRestoreExt = ".prerestore"
+)
+
+// backupMagic begins every backup archive.
+var backupMagic = []byte("BWBACKUP")
+
+// AutoBackupConfig configures periodic encrypted backups of the client
+// database and any sources added with AddBackupSource. Backups are encrypted
+// with a key derived from the app password, so they are only created while
+// logged in.
+type AutoBackupConfig struct {
+	// Dir is the directory that backup archives are written to.
+	Dir string
+	// Interval is the time between backups.
+	Interval time.Duration
+	// Daily is the number of days for which the newest archive is kept.
+	Daily int
+	// Weekly is the number of weeks for which the newest archive is kept.
+	Weekly int
+}
+
+// BackupManifest describes the contents of a backup archive.
+type BackupManifest struct {
+	Version uint16            `json:"version"`
+	Net     dex.Network       `json:"net"`
+	Stamp   uint64            `json:"stamp"`
+	Files   []*BackupFileInfo `json:"files"`
+}
+
+// BackupFileInfo describes a file in a backup archive.
+type BackupFileInfo struct {
+	Name string    `json:"name"`
+	Size int64     `json:"size"`
+	Hash dex.Bytes `json:"sha256"`
+}
+
+// backupSource is a file to be included in backup archives.
+type backupSource struct {
+	name  string
+	write func(io.Writer) error
+}
+
+// stagedBackupFile is a backup source that has been written to a temporary
+// file.
+type stagedBackupFile struct {
+	*BackupFileInfo
+	path string
+}
+
+// AddBackupSource adds a file to automatic backups. write should write a
+// consistent copy of the file contents.
+func (c *Core) AddBackupSource(name string, write func(io.Writer) error) {
+	c.backupMtx.Lock()
+	defer c.backupMtx.Unlock()
+	c.backupSources = append(c.backupSources, &backupSource{name: name, write: write})
+}
+
+// setBackupCrypter sets the crypter used for automatic backups, closing any
+// existing crypter. The crypter is nil while logged out.
+func (c *Core) setBackupCrypter(crypter encrypt.Crypter) {
+	c.backupMtx.Lock()
+	defer c.backupMtx.Unlock()
+	if c.backupCrypter != nil {
+		c.backupCrypter.Close()
+	}
+	c.backupCrypter = crypter
+}
+
+// runAutoBackups creates a backup archive whenever one is due, and prunes the
+// archives according to the rotation settings.
+func (c *Core) runAutoBackups(ctx context.Context) {
+	cfg := c.cfg.AutoBackup
+	var lastBackup time.Time
+	if stamps, err := backupArchives(cfg.Dir); err != nil {
+		c.log.Errorf("Error listing backup archives: %v", err)
+	} else if len(stamps) > 0 {
+		lastBackup = stamps[0].stamp
+	}
+
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+	for {
+		if time.Since(lastBackup) >= cfg.Interval {
+			path, err := c.backup(time.Now())
+			switch {
+			case errors.Is(err, errNoBackupCrypter):
+				// Try again after login.
+			case err != nil:
+				c.log.Errorf("Automatic backup failed: %v", err)
+				// Don't retry every minute.
+				lastBackup = time.Now().Add(-cfg.Interval + backupCheckInterval*10)
+			default:
+				c.log.Infof("Created backup archive %s", path)
+				lastBackup = time.Now()
+				if err := pruneBackups(cfg.Dir, cfg.Daily, cfg.Weekly); err != nil {
+					c.log.Errorf("Error pruning backup archives: %v", err)
+				}
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+var errNoBackupCrypter = errors.New("not logged in")
+
+// backup writes a new backup archive to the backup directory, returning its
+// path.
+func (c *Core) backup(now time.Time) (string, error) {
+	c.backupMtx.Lock()
+	defer c.backupMtx.Unlock()
+	if c.backupCrypter == nil {
+		return "", errNoBackupCrypter
+	}
+	dir := c.cfg.AutoBackup.Dir
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	files, err := c.stageBackupFiles(dir)
+	defer func() {
+		for _, f := range files {
+			os.Remove(f.path)
+		}
+	}()
+	if err != nil {
+		return "", err
+	}
+
+	manifest := &BackupManifest{
+		Version: backupVersion,
+		Net:     c.net,
+		Stamp:   uint64(now.UnixMilli()),
+	}
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.BackupFileInfo)
+	}
+
+	path := filepath.Join(dir, backupFilePrefix+now.UTC().Format(backupTimeFormat)+backupFileExt)
+	tmpPath := path + ".tmp"
+	archive, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	err = writeBackupArchive(archive, c.backupCrypter, manifest, files)
+	if err == nil {
+		err = archive.Sync()
+	}
+	archive.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error writing backup archive: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return path, nil
+}
+
+// stageBackupFiles writes the database and the backup sources to temporary
+// files in dir. The caller should remove the files, even if an error is
+// returned.
+func (c *Core) stageBackupFiles(dir string) (files []*stagedBackupFile, err error) {
+	stage := func(name string, write func(*os.File) error) error {
+		f, err := os.CreateTemp(dir, ".stage-*")
+		if err != nil {
+			return err
+		}
+		files = append(files, &stagedBackupFile{
+			BackupFileInfo: &BackupFileInfo{Name: name},
+			path:           f.Name(),
+		})
+		err = write(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("error staging %s for backup: %w", name, err)
+		}
+		return nil
+	}
+
+	err = stage(BackupDBFile, func(f *os.File) error {
+		// The database writes its own copy.
+		f.Close()
+		return c.db.BackupTo(f.Name(), true, false)
+	})
+	if err != nil {
+		return files, err
+	}
+	for _, src := range c.backupSources {
+		if err := stage(src.name, func(f *os.File) error { return src.write(f) }); err != nil {
+			return files, err
+		}
+	}
+
+	// Sources that have nothing to write, such as a database that is not
+	// open yet, are left out of the archive.
+	staged := make([]*stagedBackupFile, 0, len(files))
+	for _, f := range files {
+		if f.Size, f.Hash, err = hashFile(f.path); err != nil {
+			return files, err
+		}
+		if f.Size == 0 && f.Name != BackupDBFile {
+			os.Remove(f.path)
+			continue
+		}
+		staged = append(staged, f)
+	}
+	return staged, nil
+}
+
+// hashFile returns the size and sha256 hash of the file.
+func hashFile(path string) (int64, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, h.Sum(nil), nil
+}
+
+// writeBackupArchive writes an encrypted archive with the manifest and files.
+// The archive is a header with the serialized crypter, followed by a gzipped
+// tar stream that is encrypted in chunks.
+func writeBackupArchive(w io.Writer, crypter encrypt.Crypter, manifest *BackupManifest, files []*stagedBackupFile) error {
+	params := crypter.Serialize()
+	header := make([]byte, 0, len(backupMagic)+3+len(params))
+	header = append(header, backupMagic...)
+	header = append(header, backupVersion)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(params)))
+	header = append(header, params...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	enc := &backupEncrypter{w: w, crypter: crypter}
+	gz := gzip.NewWriter(enc)
+	tw := tar.NewWriter(gz)
+
+	addFile := func(name string, size int64, r io.Reader) error {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    size,
+			ModTime: time.UnixMilli(int64(manifest.Stamp)),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, r)
+		return err
+	}
+
+	manifestB, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := addFile(backupManifestFile, int64(len(manifestB)), bytes.NewReader(manifestB)); err != nil {
+		return err
+	}
+	for _, f := range files {
+		r, err := os.Open(f.path)
+		if err != nil {
+			return err
+		}
+		err = addFile(f.Name, f.Size, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("error archiving %s: %w", f.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// backupEncrypter is an io.WriteCloser that encrypts the data in chunks. Each
+// chunk's plaintext is prefixed with the chunk index and a flag for the final
+// chunk, so that reordered or truncated archives are detected on decryption.
+type backupEncrypter struct {
+	w       io.Writer
+	crypter encrypt.Crypter
+	buf     []byte
+	idx     uint64
+}
+
+func (e *backupEncrypter) Write(b []byte) (int, error) {
+	e.buf = append(e.buf, b...)
+	for len(e.buf) >= backupChunkSize {
+		if err := e.writeChunk(e.buf[:backupChunkSize], false); err != nil {
+			return 0, err
+		}
+		e.buf = e.buf[backupChunkSize:]
+	}
+	return len(b), nil
+}
+
+// Close writes the final chunk.
+func (e *backupEncrypter) Close() error {
+	return e.writeChunk(e.buf, true)
+}
+
+func (e *backupEncrypter) writeChunk(data []byte, final bool) error {
+	plainText := make([]byte, 9, 9+len(data))
+	binary.BigEndian.PutUint64(plainText, e.idx)
+	if final {
+		plainText[8] = 1
+	}
+	plainText = append(plainText, data...)
+	cipherText, err := e.crypter.Encrypt(plainText)
+	if err != nil {
+		return err
+	}
+	e.idx++
+	if _, err := e.w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(cipherText)))); err != nil {
+		return err
+	}
+	_, err = e.w.Write(cipherText)
+	return err
+}
+
+// backupDecrypter is an io.Reader for the plaintext of the chunks written by a
+// backupEncrypter. io.EOF is only returned after the final chunk.
+type backupDecrypter struct {
+	r       io.Reader
+	crypter encrypt.Crypter
+	buf     []byte
+	idx     uint64
+	final   bool
+}
+
+func (d *backupDecrypter) Read(b []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.final {
+			return 0, io.EOF
+		}
+		if err := d.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *backupDecrypter) readChunk() error {
+	var lenB [4]byte
+	if _, err := io.ReadFull(d.r, lenB[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("backup archive is truncated")
+		}
+		return err
+	}
+	// Allow generous room for the encryption overhead.
+	l := binary.BigEndian.Uint32(lenB[:])
+	if l > backupChunkSize+1024 {
+		return fmt.Errorf("backup archive chunk too large: %d bytes", l)
+	}
+	cipherText := make([]byte, l)
+	if _, err := io.ReadFull(d.r, cipherText); err != nil {
+		return fmt.Errorf("error reading backup archive chunk %d: %w", d.idx, err)
+	}
+	plainText, err := d.crypter.Decrypt(cipherText)
+	if err != nil {
+		return fmt.Errorf("error decrypting backup archive chunk %d: %w", d.idx, err)
+	}
+	if len(plainText) < 9 {
+		return fmt.Errorf("backup archive chunk %d too short", d.idx)
+	}
+	if idx := binary.BigEndian.Uint64(plainText); idx != d.idx {
+		return fmt.Errorf("backup archive chunk %d out of order. expected chunk %d", idx, d.idx)
+	}
+	d.idx++
+	if plainText[8] == 1 {
+		d.final = true
+		// Nothing may follow the final chunk.
+		if n, _ := d.r.Read(lenB[:1]); n > 0 {
+			return errors.New("unexpected data after final backup archive chunk")
+		}
+	}
+	d.buf = plainText[9:]
+	return nil
+}
+
+// readBackupHeader reads the archive header and deserializes the crypter with
+// the app password.
+func readBackupHeader(r io.Reader, appPW []byte) (encrypt.Crypter, error) {
+	header := make([]byte, len(backupMagic)+3)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("error reading backup archive header: %w", err)
+	}
+	if !bytes.Equal(header[:len(backupMagic)], backupMagic) {
+		return nil, errors.New("not a backup archive")
+	}
+	if ver := header[len(backupMagic)]; ver != backupVersion {
+		return nil, fmt.Errorf("unknown backup archive version %d", ver)
+	}
+	params := make([]byte, binary.BigEndian.Uint16(header[len(backupMagic)+1:]))
+	if _, err := io.ReadFull(r, params); err != nil {
+		return nil, fmt.Errorf("error reading backup archive header: %w", err)
+	}
+	crypter, err := encrypt.Deserialize(appPW, params)
+	if err != nil {
+		return nil, newError(authErr, "app password error: %w", err)
+	}
+	return crypter, nil
+}
+
+// RestoreBackup decrypts the backup archive with the app password and
+// validates the contents against the archive's manifest before writing the
+// files to the paths in dsts, keyed by file name. Files without a destination
+// are validated but not restored. Existing files are renamed with a
+// ".prerestore" extension rather than deleted. Core must not be running with
+// any of the destination files.
+func RestoreBackup(archivePath string, appPW []byte, net dex.Network, dsts map[string]string) (*BackupManifest, error) {
+	if _, found := dsts[BackupDBFile]; !found {
+		return nil, errors.New("no destination for the database")
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	crypter, err := readBackupHeader(f, appPW)
+	if err != nil {
+		return nil, err
+	}
+	defer crypter.Close()
+
+	dec := &backupDecrypter{r: f, crypter: crypter}
+	gz, err := gzip.NewReader(dec)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing backup archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	// The manifest is always first.
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup manifest: %w", err)
+	}
+	if hdr.Name != backupManifestFile {
+		return nil, fmt.Errorf("expected backup manifest, found %q", hdr.Name)
+	}
+	manifest := new(BackupManifest)
+	if err := json.NewDecoder(io.LimitReader(tr, 1<<20)).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("error decoding backup manifest: %w", err)
+	}
+	if manifest.Version != backupVersion {
+		return nil, fmt.Errorf("unknown backup manifest version %d", manifest.Version)
+	}
+	if manifest.Net != net {
+		return nil, fmt.Errorf("backup is for %s, not %s", manifest.Net, net)
+	}
+	infos := make(map[string]*BackupFileInfo, len(manifest.Files))
+	for _, fi := range manifest.Files {
+		infos[fi.Name] = fi
+	}
+	if infos[BackupDBFile] == nil {
+		return nil, errors.New("backup archive has no database")
+	}
+
+	// Extract to temporary files next to the destinations.
+	tmpPaths := make(map[string]string)
+	defer func() {
+		for _, p := range tmpPaths {
+			os.Remove(p)
+		}
+	}()
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading backup archive: %w", err)
+		}
+		fi := infos[hdr.Name]
+		if fi == nil {
+			return nil, fmt.Errorf("file %q is not in the backup manifest", hdr.Name)
+		}
+		if _, found := tmpPaths[fi.Name]; found {
+			return nil, fmt.Errorf("duplicate file %q in backup archive", hdr.Name)
+		}
+		tmpPath, err := extractBackupFile(tr, fi, dsts[fi.Name])
+		if err != nil {
+			return nil, err
+		}
+		tmpPaths[fi.Name] = tmpPath
+	}
+	// Read through the final chunk to authenticate the complete archive.
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return nil, fmt.Errorf("error reading backup archive: %w", err)
+	}
+	for _, fi := range manifest.Files {
+		if _, found := tmpPaths[fi.Name]; !found {
+			return nil, fmt.Errorf("%s is missing from the backup archive", fi.Name)
+		}
+	}
+
+	// The archive is valid. Move the files into place.
+	for name, tmpPath := range tmpPaths {
+		if tmpPath == "" {
+			continue
+		}
+		dst := dsts[name]
+		if _, err := os.Stat(dst); err == nil {
+			if err := os.Rename(dst, dst+preRestoreExt); err != nil {
+				return nil, fmt.Errorf("error moving existing %s: %w", dst, err)
+			}
+		}
+		if err := os.Rename(tmpPath, dst); err != nil {
+			return nil, fmt.Errorf("error restoring %s: %w", dst, err)
+		}
+		delete(tmpPaths, name)
+	}
+	return manifest, nil
+}
+
+// extractBackupFile reads a file from the archive and checks it against the
+// manifest. If dst is not empty, the file is written to a temporary file in
+// the destination directory, and the path is returned.
+func extractBackupFile(r io.Reader, fi *BackupFileInfo, dst string) (tmpPath string, err error) {
+	w := io.Discard
+	if dst != "" {
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return "", err
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(dst), ".restore-*")
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			tmp.Close()
+			if err != nil {
+				os.Remove(tmp.Name())
+			}
+		}()
+		tmpPath, w = tmp.Name(), tmp
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return "", fmt.Errorf("error extracting %s: %w", fi.Name, err)
+	}
+	if n != fi.Size || !bytes.Equal(h.Sum(nil), fi.Hash) {
+		return "", fmt.Errorf("%s does not match the backup manifest", fi.Name)
+	}
+	if tmp, ok := w.(*os.File); ok {
+		if err := tmp.Sync(); err != nil {
+			return "", err
+		}
+	}
+	return tmpPath, nil
+}
+
+// backupArchive is an archive in the backup directory.
+type backupArchive struct {
+	path  string
+	stamp time.Time
+}
+
+// backupArchives lists the backup archives in the directory, newest first.
+func backupArchives(dir string) ([]*backupArchive, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var archives []*backupArchive
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupFilePrefix) || !strings.HasSuffix(name, backupFileExt) {
+			continue
+		}
+		stamp, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, backupFilePrefix), backupFileExt))
+		if err != nil {
+			continue
+		}
+		archives = append(archives, &backupArchive{path: filepath.Join(dir, name), stamp: stamp})
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].stamp.After(archives[j].stamp) })
+	return archives, nil
+}
+
+// pruneBackups deletes the archives that are neither the newest archive of one
+// of the most recent daily days with an archive, nor the newest archive of one
+// of the most recent weekly weeks with an archive.
+func pruneBackups(dir string, daily, weekly int) error {
+	archives, err := backupArchives(dir)
+	if err != nil {
+		return err
+	}
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for _, a := range archives {
+		var keep bool
+		if day := a.stamp.Format(time.DateOnly); !days[day] && len(days) < daily {
+			days[day] = true
+			keep = true
+		}
+		year, week := a.stamp.ISOWeek()
+		if wk := fmt.Sprintf("%d-%d", year, week); !weeks[wk] && len(weeks) < weekly {
+			weeks[wk] = true
+			keep = true
+		}
+		if !keep {
+			if err := os.Remove(a.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/encrypt"
+)
+
+func TestBackupRestore(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+
+	backupDir := t.TempDir()
+	tCore.cfg.AutoBackup = &AutoBackupConfig{Dir: backupDir, Interval: time.Hour, Daily: 1, Weekly: 1}
+	// Span multiple encryption chunks.
+	dbData := encode.RandomBytes(backupChunkSize*2 + 100)
+	rig.db.backupData = dbData
+	mmCfg := []byte(`{"botConfigs":[]}`)
+	tCore.AddBackupSource("mm_cfg.json", func(w io.Writer) error {
+		_, err := w.Write(mmCfg)
+		return err
+	})
+	// A source that writes nothing is not included.
+	tCore.AddBackupSource("mm_eventlog.db", func(w io.Writer) error {
+		return nil
+	})
+
+	if _, err := tCore.backup(time.Now()); !errors.Is(err, errNoBackupCrypter) {
+		t.Fatalf("wrong error for backup while logged out: %v", err)
+	}
+
+	pw := []byte("abc")
+	tCore.setBackupCrypter(encrypt.NewCrypter(pw))
+	archivePath, err := tCore.backup(time.Now())
+	if err != nil {
+		t.Fatalf("backup error: %v", err)
+	}
+	// Only the archive is left in the directory.
+	if entries, _ := os.ReadDir(backupDir); len(entries) != 1 {
+		t.Fatalf("expected 1 file in backup directory, found %d", len(entries))
+	}
+
+	restoreDir := t.TempDir()
+	dbPath := filepath.Join(restoreDir, "dexc.db")
+	mmCfgPath := filepath.Join(restoreDir, "mm", "mm_cfg.json")
+	dsts := map[string]string{BackupDBFile: dbPath, "mm_cfg.json": mmCfgPath}
+	if err := os.WriteFile(dbPath, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestoreBackup(archivePath, []byte("wrong"), tCore.net, dsts); !errorHasCode(err, authErr) {
+		t.Fatalf("wrong error for wrong password: %v", err)
+	}
+	if _, err := RestoreBackup(archivePath, pw, tCore.net+1, dsts); err == nil {
+		t.Fatalf("no error for wrong network")
+	}
+
+	// Tampered and truncated archives are rejected without touching the
+	// existing files.
+	archive, _ := os.ReadFile(archivePath)
+	tampered := bytes.Clone(archive)
+	tampered[len(tampered)-5] ^= 1
+	badPath := filepath.Join(t.TempDir(), "bad.bwbak")
+	for _, bad := range [][]byte{
+		tampered,
+		archive[:len(archive)/2],
+		append(bytes.Clone(archive), 0),
+	} {
+		os.WriteFile(badPath, bad, 0600)
+		if _, err := RestoreBackup(badPath, pw, tCore.net, dsts); err == nil {
+			t.Fatalf("no error for bad archive")
+		}
+	}
+	if b, _ := os.ReadFile(dbPath); !bytes.Equal(b, []byte("old")) {
+		t.Fatalf("database changed by failed restore")
+	}
+	if _, err := os.Stat(mmCfgPath); err == nil {
+		t.Fatalf("mm config written by failed restore")
+	}
+
+	manifest, err := RestoreBackup(archivePath, pw, tCore.net, dsts)
+	if err != nil {
+		t.Fatalf("RestoreBackup error: %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("expected 2 files in manifest, got %d", len(manifest.Files))
+	}
+	for path, exp := range map[string][]byte{
+		dbPath:                 dbData,
+		mmCfgPath:              mmCfg,
+		dbPath + preRestoreExt: []byte("old"),
+	} {
+		if b, err := os.ReadFile(path); err != nil || !bytes.Equal(b, exp) {
+			t.Fatalf("wrong contents for %s, err = %v", path, err)
+		}
+	}
+
+	// Logging out stops backups.
+	tCore.setBackupCrypter(nil)
+	if _, err := tCore.backup(time.Now()); !errors.Is(err, errNoBackupCrypter) {
+		t.Fatalf("wrong error for backup after logout: %v", err)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	// Wednesday.
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	var stamps []time.Time
+	for _, ago := range []time.Duration{
+		0, time.Hour, // today
+		24 * time.Hour, 25 * time.Hour, // yesterday
+		48 * time.Hour,      // Monday
+		7 * 24 * time.Hour,  // last week
+		8 * 24 * time.Hour,  // last week
+		14 * 24 * time.Hour, // two weeks ago
+	} {
+		stamp := now.Add(-ago)
+		stamps = append(stamps, stamp)
+		name := backupFilePrefix + stamp.Format(backupTimeFormat) + backupFileExt
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Other files are ignored.
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600)
+
+	if err := pruneBackups(dir, 2, 2); err != nil {
+		t.Fatalf("pruneBackups error: %v", err)
+	}
+	archives, err := backupArchives(dir)
+	if err != nil {
+		t.Fatalf("backupArchives error: %v", err)
+	}
+	// The newest of today and yesterday, and the newest of this week
+	// (today's) and last week.
+	exp := []time.Time{stamps[0], stamps[2], stamps[5]}
+	if len(archives) != len(exp) {
+		t.Fatalf("expected %d archives, found %d", len(exp), len(archives))
+	}
+	for i, a := range archives {
+		if !a.stamp.Equal(exp[i]) {
+			t.Fatalf("wrong archive kept %s, expected %s", a.stamp, exp[i])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatalf("unrelated file removed")
+	}
+}
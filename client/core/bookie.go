@@ -6,6 +6,7 @@ package core
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,6 +23,9 @@ import (
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
 
+// tradeTapeSize is the number of recent trades kept for a market's trade tape.
+const tradeTapeSize = 100
+
 var (
 	feederID        uint32
 	bookFeedTimeout = time.Minute
@@ -35,6 +39,7 @@ type BookFeed interface {
 	Next() <-chan *BookUpdate
 	Close()
 	Candles(dur string) error
+	Trades() error
 }
 
 // bookFeed implements BookFeed.
@@ -62,6 +67,12 @@ func (f *bookFeed) Candles(durStr string) error {
 	return f.bookie.candles(durStr, f.id)
 }
 
+// Trades subscribes to the market's trade tape and sends the most recent
+// trades over the update channel.
+func (f *bookFeed) Trades() error {
+	return f.bookie.trades(f.id)
+}
+
 // candleCache adds synchronization and an on/off switch to *candles.Cache.
 type candleCache struct {
 	*candles.Cache
@@ -111,6 +122,14 @@ type bookie struct {
 	timerMtx   sync.Mutex
 	closeTimer *time.Timer
 
+	// tradeTape is the market's most recent trades, newest first. The tape is
+	// only kept after a feed requests it with Trades. tradesReqMtx serializes
+	// the request for the initial tape.
+	tradesReqMtx sync.Mutex
+	tradesMtx    sync.RWMutex
+	tradesOn     bool
+	tradeTape    []*MarketTrade
+
 	base, quote           uint32
 	baseUnits, quoteUnits dex.UnitInfo
 }
@@ -217,6 +236,44 @@ func (b *bookie) logEpochReport(note *msgjson.EpochReportNote) error {
 	return nil
 }
 
+// logTradeTape adds the trades in the trade_tape message to the trade tape.
+func (b *bookie) logTradeTape(note *msgjson.TradeTapeNote) {
+	b.tradesMtx.Lock()
+	if !b.tradesOn {
+		b.tradesMtx.Unlock()
+		return
+	}
+	// Trades are in match order. Reverse them for newest first.
+	trades := make([]*MarketTrade, 0, len(note.Trades))
+	for i := len(note.Trades) - 1; i >= 0; i-- {
+		trades = append(trades, b.marketTrade(note.Trades[i]))
+	}
+	b.tradeTape = append(trades, b.tradeTape...)
+	if len(b.tradeTape) > tradeTapeSize {
+		b.tradeTape = b.tradeTape[:tradeTapeSize]
+	}
+	b.tradesMtx.Unlock()
+
+	b.send(&BookUpdate{
+		Action:   TradeTapeAction,
+		Host:     b.dc.acct.host,
+		MarketID: marketName(b.base, b.quote),
+		Payload:  &TradesPayload{Trades: trades},
+	})
+}
+
+// marketTrade converts a *msgjson.MarketTrade to a *MarketTrade.
+func (b *bookie) marketTrade(t *msgjson.MarketTrade) *MarketTrade {
+	return &MarketTrade{
+		Stamp:     t.Stamp,
+		Qty:       float64(t.Qty) / float64(b.baseUnits.Conventional.ConversionFactor),
+		QtyAtomic: t.Qty,
+		Rate:      calc.ConventionalRate(t.Rate, b.baseUnits, b.quoteUnits),
+		MsgRate:   t.Rate,
+		TakerSell: t.TakerSell,
+	}
+}
+
 // newFeed gets a new *bookFeed and cancels the close timer. feed must be called
 // with the bookie.mtx locked. The feed is primed with the provided *BookUpdate.
 func (b *bookie) newFeed(u *BookUpdate) *bookFeed {
@@ -310,6 +367,95 @@ func (b *bookie) candles(durStr string, feedID uint32) error {
 	return nil
 }
 
+// initTradeTape activates the trade tape, fetching the most recent trades from
+// the server if the tape is not already active.
+func (b *bookie) initTradeTape() error {
+	b.tradesReqMtx.Lock()
+	defer b.tradesReqMtx.Unlock()
+
+	// The tape is activated before the request is sent so that the trades
+	// from trade_tape notes that arrive while the request is in flight are
+	// kept, to be merged with the response.
+	b.tradesMtx.Lock()
+	on := b.tradesOn
+	b.tradesOn = true
+	b.tradesMtx.Unlock()
+	if on {
+		return nil
+	}
+
+	payload := &msgjson.TradesRequest{
+		BaseID:    b.base,
+		QuoteID:   b.quote,
+		NumTrades: tradeTapeSize,
+	}
+	var msgTrades []*msgjson.MarketTrade
+	err := sendRequest(b.dc.WsConn, msgjson.TradesRoute, payload, &msgTrades, DefaultResponseTimeout)
+	if err != nil {
+		b.tradesMtx.Lock()
+		b.tradesOn = false
+		b.tradeTape = nil
+		b.tradesMtx.Unlock()
+		return err
+	}
+	tape := make([]*MarketTrade, 0, len(msgTrades))
+	for _, t := range msgTrades { // newest first
+		tape = append(tape, b.marketTrade(t))
+	}
+	b.tradesMtx.Lock()
+	b.tradeTape = mergeTradeTape(b.tradeTape, tape)
+	b.tradesMtx.Unlock()
+	return nil
+}
+
+// mergeTradeTape merges the trades from trade_tape notes that arrived while
+// the trades request was in flight with the requested trades. Both are newest
+// first. A note has all of the trades of its epoch, so the requested trades
+// from the epochs of the notes are dropped as duplicates.
+func mergeTradeTape(noted, requested []*MarketTrade) []*MarketTrade {
+	tape := noted
+	if len(noted) == 0 {
+		tape = requested
+	} else {
+		oldest := noted[len(noted)-1].Stamp
+		for _, t := range requested {
+			if t.Stamp < oldest {
+				tape = append(tape, t)
+			}
+		}
+	}
+	if len(tape) > tradeTapeSize {
+		tape = tape[:tradeTapeSize]
+	}
+	return tape
+}
+
+// trades activates the trade tape and sends the tape to the feed.
+func (b *bookie) trades(feedID uint32) error {
+	if err := b.initTradeTape(); err != nil {
+		return err
+	}
+
+	b.tradesMtx.RLock()
+	tape := slices.Clone(b.tradeTape)
+	b.tradesMtx.RUnlock()
+
+	b.feedsMtx.RLock()
+	defer b.feedsMtx.RUnlock()
+	f, ok := b.feeds[feedID]
+	if !ok {
+		// Feed must have been closed in another thread.
+		return nil
+	}
+	f.c <- &BookUpdate{
+		Action:   FreshTradesAction,
+		Host:     b.dc.acct.host,
+		MarketID: marketName(b.base, b.quote),
+		Payload:  &TradesPayload{Trades: tape},
+	}
+	return nil
+}
+
 // closeFeed closes the specified feed, and if no more feeds are open, sets a
 // close timer to disconnect from the market feed.
 func (b *bookie) closeFeed(feedID uint32) {
@@ -678,6 +824,67 @@ func (dc *dexConnection) setMarketFinalEpoch(name string, finalEpoch uint64, per
 	mkt.Persist = &persist
 }
 
+// setMarketParamsChange revises the ParamsChange field of the named market in
+// the stored ConfigResponse.
+func (dc *dexConnection) setMarketParamsChange(name string, change *msgjson.MarketParamsChange) {
+	dc.cfgMtx.Lock()
+	defer dc.cfgMtx.Unlock()
+	mkt := dc.findMarketConfig(name)
+	if mkt == nil {
+		return
+	}
+	mkt.ParamsChange = change
+}
+
+// applyMarketParamsChange sets the LotSize and RateStep fields of the named
+// market in the stored ConfigResponse if the change is still scheduled, and
+// emits a ServerConfigUpdateNote.
+func (dc *dexConnection) applyMarketParamsChange(name string, change *msgjson.MarketParamsChange) {
+	dc.cfgMtx.Lock()
+	mkt := dc.findMarketConfig(name)
+	if mkt == nil || mkt.ParamsChange == nil || mkt.ParamsChange.Epoch != change.Epoch {
+		dc.cfgMtx.Unlock()
+		return
+	}
+	mkt.LotSize, mkt.RateStep = change.LotSize, change.RateStep
+	mkt.ParamsChange = nil
+	dc.cfgMtx.Unlock()
+
+	dc.log.Infof("Market %s at %s now has lot size %d and rate step %d.",
+		name, dc.acct.host, change.LotSize, change.RateStep)
+	dc.notify(newServerConfigUpdateNote(dc.acct.host))
+}
+
+// scheduleMarketParamsChange applies a scheduled lot size and rate step change
+// for the named market when the change epoch begins. Any change previously
+// scheduled for the market is replaced.
+func (dc *dexConnection) scheduleMarketParamsChange(name string, epochLen uint64, change *msgjson.MarketParamsChange) {
+	changeTime := time.UnixMilli(int64(change.Epoch * epochLen))
+	dc.paramsTimersMtx.Lock()
+	defer dc.paramsTimersMtx.Unlock()
+	if dc.paramsTimers == nil {
+		dc.paramsTimers = make(map[string]*time.Timer)
+	}
+	if timer := dc.paramsTimers[name]; timer != nil {
+		timer.Stop()
+	}
+	dc.paramsTimers[name] = time.AfterFunc(time.Until(changeTime), func() {
+		dc.applyMarketParamsChange(name, change)
+	})
+}
+
+// stopParamsTimers stops and clears the timers of any scheduled lot size and
+// rate step changes. It is called when the dexConnection is torn down, and
+// before the changes are rescheduled from a refreshed server config.
+func (dc *dexConnection) stopParamsTimers() {
+	dc.paramsTimersMtx.Lock()
+	defer dc.paramsTimersMtx.Unlock()
+	for _, timer := range dc.paramsTimers {
+		timer.Stop()
+	}
+	dc.paramsTimers = nil
+}
+
 // handleTradeSuspensionMsg is called when a trade suspension notification is
 // received. This message may come in advance of suspension, in which case it
 // has a SuspendTime set, or at the time of suspension if subscribed to the
@@ -782,9 +989,17 @@ func handleTradeResumptionMsg(c *Core, dc *dexConnection, msg *msgjson.Message)
 		return fmt.Errorf("trade resumption unmarshal error: %w", err)
 	}
 
-	// Ensure the provided market exists for the dex.
+	// A resumption for an unknown market is sent when the server adds a
+	// market while running. Fetch the updated DEX configuration, which
+	// includes the new market and any new assets.
 	if dc.marketConfig(rs.MarketID) == nil {
-		return fmt.Errorf("no market at %v found with ID %s", dc.acct.host, rs.MarketID)
+		if _, err := dc.refreshServerConfig(); err != nil {
+			return fmt.Errorf("unable to refresh config for new market %s at %v: %w", rs.MarketID, dc.acct.host, err)
+		}
+		if dc.marketConfig(rs.MarketID) == nil {
+			return fmt.Errorf("no market at %v found with ID %s", dc.acct.host, rs.MarketID)
+		}
+		c.notify(newServerConfigUpdateNote(dc.acct.host))
 	}
 
 	// rs.ResumeTime == 0 means resume now.
@@ -803,13 +1018,6 @@ func handleTradeResumptionMsg(c *Core, dc *dexConnection, msg *msgjson.Message)
 	dc.epoch[rs.MarketID] = rs.StartEpoch
 	dc.epochMtx.Unlock()
 
-	// TODO: Server config change without restart is not implemented on the
-	// server, but it would involve either getting the config response or adding
-	// the entire market config to the TradeResumption payload.
-	//
-	// Fetch the updated DEX configuration.
-	// dc.refreshServerConfig()
-
 	subject, detail := c.formatDetails(TopicMarketResumed, rs.MarketID, dc.acct.host, rs.StartEpoch)
 	c.notify(newServerNotifyNote(TopicMarketResumed, subject, detail, db.Success))
 
@@ -818,6 +1026,40 @@ func handleTradeResumptionMsg(c *Core, dc *dexConnection, msg *msgjson.Message)
 	return nil
 }
 
+// handleMarketParamsMsg is called when a market_params notification is
+// received. The server sends this in advance of a change to a market's lot
+// size and rate step. Booked orders that do not conform to the new parameters
+// are revoked by the server when the change takes effect.
+func handleMarketParamsMsg(c *Core, dc *dexConnection, msg *msgjson.Message) error {
+	var note msgjson.MarketParamsNote
+	err := msg.Unmarshal(&note)
+	if err != nil {
+		return fmt.Errorf("market params unmarshal error: %w", err)
+	}
+
+	mkt := dc.marketConfig(note.MarketID)
+	if mkt == nil {
+		return fmt.Errorf("no market found with ID %s", note.MarketID)
+	}
+	if note.LotSize == 0 || note.RateStep == 0 {
+		return fmt.Errorf("invalid market params for %s: lot size %d, rate step %d",
+			note.MarketID, note.LotSize, note.RateStep)
+	}
+
+	change := note.MarketParamsChange
+	dc.setMarketParamsChange(note.MarketID, &change)
+	dc.scheduleMarketParamsChange(note.MarketID, mkt.EpochLen, &change)
+
+	lotSize := fmt.Sprintf("%d", change.LotSize)
+	if a := dc.assetConfig(mkt.Base); a != nil {
+		lotSize = a.UnitInfo.FormatAtoms(change.LotSize)
+	}
+	changeTime := time.UnixMilli(int64(change.Epoch * mkt.EpochLen))
+	subject, detail := c.formatDetails(TopicMarketParamsScheduled, note.MarketID, dc.acct.host, lotSize, changeTime)
+	c.notify(newServerNotifyNote(TopicMarketParamsScheduled, subject, detail, db.WarningLevel))
+	return nil
+}
+
 func (dc *dexConnection) apiVersion() int32 {
 	return atomic.LoadInt32(&dc.apiVer)
 }
@@ -891,6 +1133,15 @@ func (dc *dexConnection) refreshServerConfig() (*msgjson.ConfigResult, error) {
 	dc.resolvedEpoch = utils.CopyMap(epochs)
 	dc.epochMtx.Unlock()
 
+	// Schedule any announced lot size and rate step changes, dropping any
+	// that the server no longer announces.
+	dc.stopParamsTimers()
+	for _, mkt := range cfg.Markets {
+		if mkt.ParamsChange != nil {
+			dc.scheduleMarketParamsChange(mkt.Name, mkt.EpochLen, mkt.ParamsChange)
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -1018,6 +1269,22 @@ func handleEpochReportMsg(c *Core, dc *dexConnection, msg *msgjson.Message) erro
 	return nil
 }
 
+// handleTradeTapeMsg is called when a trade_tape notification is received.
+func handleTradeTapeMsg(_ *Core, dc *dexConnection, msg *msgjson.Message) error {
+	note := new(msgjson.TradeTapeNote)
+	err := msg.Unmarshal(note)
+	if err != nil {
+		return fmt.Errorf("trade tape note unmarshal error: %w", err)
+	}
+	book := dc.bookie(note.MarketID)
+	if book == nil {
+		return fmt.Errorf("no order book found with market id '%v'",
+			note.MarketID)
+	}
+	book.logTradeTape(note)
+	return nil
+}
+
 // handleEpochOrderMsg is called when an epoch_order notification is
 // received.
 func handleEpochOrderMsg(_ *Core, dc *dexConnection, msg *msgjson.Message) error {
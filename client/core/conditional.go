@@ -0,0 +1,554 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/encrypt"
+)
+
+// Conditional order types.
+const (
+	// ConditionalStop places a market order when the price moves against
+	// the order, i.e. falls to the trigger rate for a sell or rises to it for
+	// a buy.
+	ConditionalStop = "stop"
+	// ConditionalStopLimit is like ConditionalStop, but places a limit order.
+	ConditionalStopLimit = "stoplimit"
+	// ConditionalTakeProfit places the order when the price moves in favor of
+	// the order, i.e. rises to the trigger rate for a sell or falls to it for
+	// a buy.
+	ConditionalTakeProfit = "takeprofit"
+	// ConditionalTrailingStop is a stop whose trigger rate follows the best
+	// price seen since the order was placed at a fixed percentage.
+	ConditionalTrailingStop = "trailingstop"
+)
+
+// Price sources against which conditional orders are evaluated.
+const (
+	PriceSourceMidGap    = "midgap"
+	PriceSourceLastTrade = "lasttrade"
+)
+
+// Conditional order statuses.
+const (
+	ConditionalStatusActive    = "active"
+	ConditionalStatusTriggered = "triggered"
+	ConditionalStatusFailed    = "failed"
+)
+
+// conditionalRetryDelay is how long to wait before trying to sync a book for
+// conditional orders again after the book could not be synced or the feed
+// was closed.
+var conditionalRetryDelay = time.Minute
+
+// ConditionalOrderForm is the information necessary to place a conditional
+// order.
+type ConditionalOrderForm struct {
+	Type string `json:"type"`
+	// PriceSource is the price that the condition is evaluated against, the
+	// book's mid-gap or the last trade. The default is the mid-gap.
+	PriceSource string `json:"priceSource"`
+	// TriggerRate is the rate, in message-rate units, at which the order is
+	// triggered. Not used for trailing stops.
+	TriggerRate uint64 `json:"triggerRate"`
+	// TrailPct is the distance of a trailing stop from the best price seen,
+	// in percent.
+	TrailPct float64 `json:"trailPct"`
+	// Trade is the order that is placed when the condition is met.
+	Trade *TradeForm `json:"trade"`
+}
+
+func (form *ConditionalOrderForm) validate() error {
+	t := form.Trade
+	if t == nil {
+		return errors.New("no order")
+	}
+	if t.Qty == 0 {
+		return errors.New("zero quantity")
+	}
+	if t.IsLimit && t.Rate == 0 {
+		return errors.New("zero rate for limit order")
+	}
+	switch form.Type {
+	case ConditionalStop:
+		if t.IsLimit {
+			return errors.New("stop orders place market orders. use a stop-limit order instead")
+		}
+	case ConditionalStopLimit:
+		if !t.IsLimit {
+			return errors.New("stop-limit orders must place a limit order")
+		}
+	case ConditionalTakeProfit:
+	case ConditionalTrailingStop:
+		if form.TrailPct <= 0 || form.TrailPct >= 100 {
+			return fmt.Errorf("trailing stop percentage must be between 0 and 100, got %.2f", form.TrailPct)
+		}
+	default:
+		return fmt.Errorf("unknown conditional order type %q", form.Type)
+	}
+	if form.Type != ConditionalTrailingStop && form.TriggerRate == 0 {
+		return errors.New("zero trigger rate")
+	}
+	switch form.PriceSource {
+	case "", PriceSourceMidGap, PriceSourceLastTrade:
+	default:
+		return fmt.Errorf("unknown price source %q", form.PriceSource)
+	}
+	return nil
+}
+
+// ConditionalOrder is an order that is held by the client and placed when a
+// price condition is met.
+type ConditionalOrder struct {
+	ID          dex.Bytes  `json:"id"`
+	Type        string     `json:"type"`
+	PriceSource string     `json:"priceSource"`
+	TriggerRate uint64     `json:"triggerRate,omitempty"`
+	TrailPct    float64    `json:"trailPct,omitempty"`
+	Trade       *TradeForm `json:"trade"`
+	// ExtremeRate is the best rate seen by a trailing stop, the highest for a
+	// sell and the lowest for a buy.
+	ExtremeRate uint64 `json:"extremeRate,omitempty"`
+	Status      string `json:"status"`
+	// Stamp is the time the order was placed, in milliseconds.
+	Stamp uint64 `json:"stamp"`
+	// TriggerStamp and TriggeredRate are the time, in milliseconds, and the
+	// price at which the condition was met.
+	TriggerStamp  uint64 `json:"triggerStamp,omitempty"`
+	TriggeredRate uint64 `json:"triggeredRate,omitempty"`
+	// OrderID is the ID of the order placed when the condition was met.
+	OrderID dex.Bytes `json:"orderID,omitempty"`
+	// Error is set if the order could not be placed.
+	Error string `json:"error,omitempty"`
+}
+
+func (o *ConditionalOrder) copy() *ConditionalOrder {
+	ord := *o
+	trade := *o.Trade
+	ord.Trade = &trade
+	return &ord
+}
+
+func (o *ConditionalOrder) active() bool {
+	return o.Status == ConditionalStatusActive
+}
+
+// stopRate is the rate at which a trailing stop is triggered.
+func (o *ConditionalOrder) stopRate() uint64 {
+	if o.Trade.Sell {
+		return uint64(float64(o.ExtremeRate) * (1 - o.TrailPct/100))
+	}
+	return uint64(float64(o.ExtremeRate) * (1 + o.TrailPct/100))
+}
+
+// evaluate checks whether the rate meets the order's condition. For trailing
+// stops, the best rate is updated first, and updated is true if it changed.
+func (o *ConditionalOrder) evaluate(rate uint64) (triggered, updated bool) {
+	sell := o.Trade.Sell
+	switch o.Type {
+	case ConditionalStop, ConditionalStopLimit:
+		if sell {
+			return rate <= o.TriggerRate, false
+		}
+		return rate >= o.TriggerRate, false
+	case ConditionalTakeProfit:
+		if sell {
+			return rate >= o.TriggerRate, false
+		}
+		return rate <= o.TriggerRate, false
+	case ConditionalTrailingStop:
+		if o.ExtremeRate == 0 || (sell && rate > o.ExtremeRate) || (!sell && rate < o.ExtremeRate) {
+			o.ExtremeRate = rate
+			updated = true
+		}
+		if sell {
+			return rate <= o.stopRate(), updated
+		}
+		return rate >= o.stopRate(), updated
+	}
+	return false, false
+}
+
+// conditionalMarket identifies a market with conditional orders.
+type conditionalMarket struct {
+	host        string
+	base, quote uint32
+}
+
+func (m conditionalMarket) String() string {
+	return m.host + "/" + marketName(m.base, m.quote)
+}
+
+func (o *ConditionalOrder) market() conditionalMarket {
+	return conditionalMarket{host: o.Trade.Host, base: o.Trade.Base, quote: o.Trade.Quote}
+}
+
+// storeConditionalOrder saves the conditional order to the DB. The
+// condOrdersMtx must be held.
+func (c *Core) storeConditionalOrder(o *ConditionalOrder) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("error encoding conditional order: %w", err)
+	}
+	return c.db.UpdateConditionalOrder(o.ID, b)
+}
+
+// loadConditionalOrders loads the conditional orders from the DB and starts
+// watching the markets of active orders.
+func (c *Core) loadConditionalOrders() error {
+	encOrds, err := c.db.ConditionalOrders()
+	if err != nil {
+		return fmt.Errorf("error retrieving conditional orders: %w", err)
+	}
+	c.condOrdersMtx.Lock()
+	defer c.condOrdersMtx.Unlock()
+	c.condOrders = make(map[string]*ConditionalOrder, len(encOrds))
+	for _, b := range encOrds {
+		o := new(ConditionalOrder)
+		if err := json.Unmarshal(b, o); err != nil {
+			c.log.Errorf("Error decoding conditional order: %v", err)
+			continue
+		}
+		c.condOrders[o.ID.String()] = o
+		if o.active() {
+			c.watchConditionalMarket(o.market())
+		}
+	}
+	return nil
+}
+
+// conditionalPrice gets the current price of the market from the book.
+func (c *Core) conditionalPrice(mkt conditionalMarket, src string) (uint64, error) {
+	dc, _, err := c.dex(mkt.host)
+	if err != nil {
+		return 0, err
+	}
+	booky := dc.bookie(marketName(mkt.base, mkt.quote))
+	if booky == nil {
+		return 0, fmt.Errorf("no book for %s", mkt)
+	}
+	if src == PriceSourceLastTrade {
+		matches := booky.RecentMatches()
+		if len(matches) == 0 {
+			return 0, fmt.Errorf("no recent trades for %s", mkt)
+		}
+		return matches[0].Rate, nil
+	}
+	return booky.MidGap()
+}
+
+// PlaceConditionalOrder stores an order that is placed when the price on the
+// market meets the specified condition. The wallets for the market are
+// unlocked now, and must still be unlocked when the order is placed.
+func (c *Core) PlaceConditionalOrder(pw []byte, form *ConditionalOrderForm) (*ConditionalOrder, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return nil, err
+	}
+	if err := form.validate(); err != nil {
+		return nil, newError(orderParamsErr, "invalid conditional order: %v", err)
+	}
+	crypter, err := c.encryptionKey(pw)
+	if err != nil {
+		return nil, codedError(passwordErr, err)
+	}
+	defer crypter.Close()
+
+	t := form.Trade
+	dc, err := c.registeredDEX(t.Host)
+	if err != nil {
+		return nil, err
+	}
+	mktID := marketName(t.Base, t.Quote)
+	mktConf := dc.marketConfig(mktID)
+	if mktConf == nil {
+		return nil, newError(marketErr, "conditional order for unknown market %q", mktID)
+	}
+	if (t.IsLimit || t.Sell) && t.Qty%mktConf.LotSize != 0 {
+		return nil, newError(orderParamsErr, "quantity %d is not a multiple of the lot size %d", t.Qty, mktConf.LotSize)
+	}
+	if err := c.unlockTradeWallets(crypter, dc, t.Base, t.Quote, t.Sell); err != nil {
+		return nil, err
+	}
+
+	trade := *t
+	src := form.PriceSource
+	if src == "" {
+		src = PriceSourceMidGap
+	}
+	o := &ConditionalOrder{
+		ID:          encode.RandomBytes(16),
+		Type:        form.Type,
+		PriceSource: src,
+		TriggerRate: form.TriggerRate,
+		TrailPct:    form.TrailPct,
+		Trade:       &trade,
+		Status:      ConditionalStatusActive,
+		Stamp:       uint64(time.Now().UnixMilli()),
+	}
+	if o.Type == ConditionalTrailingStop {
+		o.ExtremeRate, _ = c.conditionalPrice(o.market(), src)
+	}
+
+	c.condOrdersMtx.Lock()
+	if err := c.storeConditionalOrder(o); err != nil {
+		c.condOrdersMtx.Unlock()
+		return nil, err
+	}
+	if c.condOrders == nil {
+		c.condOrders = make(map[string]*ConditionalOrder)
+	}
+	c.condOrders[o.ID.String()] = o
+	c.watchConditionalMarket(o.market())
+	ord := o.copy()
+	c.condOrdersMtx.Unlock()
+
+	subject, details := c.formatDetails(TopicConditionalOrderPlaced, o.Type, mktID, t.Host)
+	c.notify(newConditionalOrderNote(TopicConditionalOrderPlaced, subject, details, db.Poke, ord))
+
+	return ord, nil
+}
+
+// unlockTradeWallets connects and unlocks the wallets for the market, so that
+// orders can be placed later without a password.
+func (c *Core) unlockTradeWallets(crypter encrypt.Crypter, dc *dexConnection, base, quote uint32, sell bool) error {
+	wallets, _, versCompat, err := c.walletSet(dc, base, quote, sell)
+	if err != nil {
+		return err
+	}
+	if !versCompat {
+		return fmt.Errorf("client and server asset versions are incompatible for %v", dc.acct.host)
+	}
+	for _, w := range []*xcWallet{wallets.fromWallet, wallets.toWallet} {
+		if err := c.connectAndUnlock(crypter, w); err != nil {
+			return fmt.Errorf("%s connectAndUnlock error: %w", unbip(w.AssetID), err)
+		}
+	}
+	return nil
+}
+
+// ConditionalOrders returns the conditional orders, newest first.
+func (c *Core) ConditionalOrders() []*ConditionalOrder {
+	c.condOrdersMtx.RLock()
+	ords := make([]*ConditionalOrder, 0, len(c.condOrders))
+	for _, o := range c.condOrders {
+		ords = append(ords, o.copy())
+	}
+	c.condOrdersMtx.RUnlock()
+	sort.Slice(ords, func(i, j int) bool { return ords[i].Stamp > ords[j].Stamp })
+	return ords
+}
+
+// ConditionalOrder returns the conditional order with the specified ID.
+func (c *Core) ConditionalOrder(id dex.Bytes) (*ConditionalOrder, error) {
+	c.condOrdersMtx.RLock()
+	defer c.condOrdersMtx.RUnlock()
+	o, found := c.condOrders[id.String()]
+	if !found {
+		return nil, newError(unknownOrderErr, "unknown conditional order %s", id)
+	}
+	return o.copy(), nil
+}
+
+// CancelConditionalOrder cancels and deletes an active conditional order.
+// Conditional orders that are no longer active are deleted without
+// notification.
+func (c *Core) CancelConditionalOrder(id dex.Bytes) error {
+	c.condOrdersMtx.Lock()
+	o, found := c.condOrders[id.String()]
+	if !found {
+		c.condOrdersMtx.Unlock()
+		return newError(unknownOrderErr, "unknown conditional order %s", id)
+	}
+	if err := c.db.DeleteConditionalOrder(o.ID); err != nil {
+		c.condOrdersMtx.Unlock()
+		return fmt.Errorf("error deleting conditional order: %w", err)
+	}
+	delete(c.condOrders, id.String())
+	wasActive := o.active()
+	if wasActive {
+		c.pruneConditionalWatcher(o.market())
+	}
+	ord := o.copy()
+	c.condOrdersMtx.Unlock()
+
+	if wasActive {
+		subject, details := c.formatDetails(TopicConditionalOrderCanceled, ord.Type, marketName(ord.Trade.Base, ord.Trade.Quote), ord.Trade.Host)
+		c.notify(newConditionalOrderNote(TopicConditionalOrderCanceled, subject, details, db.Poke, ord))
+	}
+	return nil
+}
+
+// watchConditionalMarket starts watching the market's book for conditional
+// orders, if it is not already being watched. The condOrdersMtx must be held.
+func (c *Core) watchConditionalMarket(mkt conditionalMarket) {
+	if _, found := c.condWatchers[mkt]; found {
+		return
+	}
+	if c.condWatchers == nil {
+		c.condWatchers = make(map[conditionalMarket]context.CancelFunc)
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.condWatchers[mkt] = cancel
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.runConditionalWatcher(ctx, mkt)
+	}()
+}
+
+// pruneConditionalWatcher stops watching the market if there are no more
+// active conditional orders for it. The condOrdersMtx must be held.
+func (c *Core) pruneConditionalWatcher(mkt conditionalMarket) {
+	for _, o := range c.condOrders {
+		if o.active() && o.market() == mkt {
+			return
+		}
+	}
+	if cancel, found := c.condWatchers[mkt]; found {
+		cancel()
+		delete(c.condWatchers, mkt)
+	}
+}
+
+// stopConditionalWatchers stops watching the books for conditional orders.
+func (c *Core) stopConditionalWatchers() {
+	c.condOrdersMtx.Lock()
+	defer c.condOrdersMtx.Unlock()
+	for mkt, cancel := range c.condWatchers {
+		cancel()
+		delete(c.condWatchers, mkt)
+	}
+}
+
+// runConditionalWatcher subscribes to the market's book and checks the
+// conditional orders on every book update.
+func (c *Core) runConditionalWatcher(ctx context.Context, mkt conditionalMarket) {
+	for {
+		_, feed, err := c.SyncBook(mkt.host, mkt.base, mkt.quote)
+		if err != nil {
+			c.log.Warnf("Unable to sync %s book for conditional orders. Retrying in %s: %v", mkt, conditionalRetryDelay, err)
+		} else {
+			c.checkConditionalOrders(mkt)
+			if closed := c.readConditionalFeed(ctx, mkt, feed); !closed {
+				return
+			}
+			c.log.Warnf("%s book feed closed. Resubscribing in %s", mkt, conditionalRetryDelay)
+		}
+		select {
+		case <-time.After(conditionalRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readConditionalFeed checks the conditional orders on every book update until
+// the context is canceled or the feed is closed. closed is true if the feed
+// was closed.
+func (c *Core) readConditionalFeed(ctx context.Context, mkt conditionalMarket, feed BookFeed) (closed bool) {
+	for {
+		select {
+		case _, ok := <-feed.Next():
+			if !ok {
+				return true
+			}
+			c.checkConditionalOrders(mkt)
+		case <-ctx.Done():
+			feed.Close()
+			return false
+		}
+	}
+}
+
+// checkConditionalOrders checks the active conditional orders for the market
+// and places the orders for the ones whose condition is met.
+func (c *Core) checkConditionalOrders(mkt conditionalMarket) {
+	prices := make(map[string]uint64, 2)
+	price := func(src string) (uint64, bool) {
+		if rate, found := prices[src]; found {
+			return rate, rate > 0
+		}
+		rate, err := c.conditionalPrice(mkt, src)
+		if err != nil {
+			c.log.Tracef("No %s price for conditional orders on %s: %v", src, mkt, err)
+		}
+		prices[src] = rate
+		return rate, rate > 0
+	}
+
+	var triggered []*ConditionalOrder
+	c.condOrdersMtx.Lock()
+	for _, o := range c.condOrders {
+		if !o.active() || o.market() != mkt {
+			continue
+		}
+		rate, ok := price(o.PriceSource)
+		if !ok {
+			continue
+		}
+		trigger, updated := o.evaluate(rate)
+		if trigger {
+			o.Status = ConditionalStatusTriggered
+			o.TriggerStamp = uint64(time.Now().UnixMilli())
+			o.TriggeredRate = rate
+			triggered = append(triggered, o)
+		}
+		if trigger || updated {
+			if err := c.storeConditionalOrder(o); err != nil {
+				c.log.Errorf("Error storing conditional order %s: %v", o.ID, err)
+			}
+		}
+	}
+	if len(triggered) > 0 {
+		c.pruneConditionalWatcher(mkt)
+	}
+	c.condOrdersMtx.Unlock()
+
+	for _, o := range triggered {
+		c.placeTriggeredOrder(o)
+	}
+}
+
+// placeTriggeredOrder places the order for a conditional order whose
+// condition was met. The wallets must be unlocked.
+func (c *Core) placeTriggeredOrder(o *ConditionalOrder) {
+	c.condOrdersMtx.RLock()
+	form := *o.Trade
+	c.condOrdersMtx.RUnlock()
+
+	c.log.Infof("Conditional %s order %s triggered on %s/%s. Placing order.", o.Type, o.ID, form.Host, marketName(form.Base, form.Quote))
+	corder, err := c.Trade(nil, &form)
+
+	c.condOrdersMtx.Lock()
+	if err != nil {
+		o.Status = ConditionalStatusFailed
+		o.Error = err.Error()
+	} else {
+		o.OrderID = corder.ID
+	}
+	if err := c.storeConditionalOrder(o); err != nil {
+		c.log.Errorf("Error storing conditional order %s: %v", o.ID, err)
+	}
+	ord := o.copy()
+	c.condOrdersMtx.Unlock()
+
+	mktID := marketName(form.Base, form.Quote)
+	if err != nil {
+		subject, details := c.formatDetails(TopicConditionalOrderFailed, ord.Type, mktID, form.Host, err)
+		c.notify(newConditionalOrderNote(TopicConditionalOrderFailed, subject, details, db.ErrorLevel, ord))
+		return
+	}
+	subject, details := c.formatDetails(TopicConditionalOrderTriggered, ord.Type, mktID, form.Host, corder.ID)
+	c.notify(newConditionalOrderNote(TopicConditionalOrderTriggered, subject, details, db.Success, ord))
+}
@@ -0,0 +1,331 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/msgjson"
+)
+
+func TestConditionalOrderEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		ordType     string
+		sell        bool
+		triggerRate uint64
+		trailPct    float64
+		extremeRate uint64
+		rate        uint64
+		expTrigger  bool
+		expExtreme  uint64
+	}{{
+		name:        "sell stop above trigger",
+		ordType:     ConditionalStop,
+		sell:        true,
+		triggerRate: 100,
+		rate:        101,
+	}, {
+		name:        "sell stop at trigger",
+		ordType:     ConditionalStop,
+		sell:        true,
+		triggerRate: 100,
+		rate:        100,
+		expTrigger:  true,
+	}, {
+		name:        "buy stop-limit below trigger",
+		ordType:     ConditionalStopLimit,
+		triggerRate: 100,
+		rate:        99,
+	}, {
+		name:        "buy stop-limit above trigger",
+		ordType:     ConditionalStopLimit,
+		triggerRate: 100,
+		rate:        101,
+		expTrigger:  true,
+	}, {
+		name:        "sell take-profit below trigger",
+		ordType:     ConditionalTakeProfit,
+		sell:        true,
+		triggerRate: 100,
+		rate:        99,
+	}, {
+		name:        "sell take-profit above trigger",
+		ordType:     ConditionalTakeProfit,
+		sell:        true,
+		triggerRate: 100,
+		rate:        101,
+		expTrigger:  true,
+	}, {
+		name:        "buy take-profit below trigger",
+		ordType:     ConditionalTakeProfit,
+		triggerRate: 100,
+		rate:        99,
+		expTrigger:  true,
+	}, {
+		name:       "sell trailing stop first rate",
+		ordType:    ConditionalTrailingStop,
+		sell:       true,
+		trailPct:   10,
+		rate:       1000,
+		expExtreme: 1000,
+	}, {
+		name:        "sell trailing stop new high",
+		ordType:     ConditionalTrailingStop,
+		sell:        true,
+		trailPct:    10,
+		extremeRate: 1000,
+		rate:        1200,
+		expExtreme:  1200,
+	}, {
+		name:        "sell trailing stop within trail",
+		ordType:     ConditionalTrailingStop,
+		sell:        true,
+		trailPct:    10,
+		extremeRate: 1000,
+		rate:        901,
+		expExtreme:  1000,
+	}, {
+		name:        "sell trailing stop triggered",
+		ordType:     ConditionalTrailingStop,
+		sell:        true,
+		trailPct:    10,
+		extremeRate: 1000,
+		rate:        900,
+		expTrigger:  true,
+		expExtreme:  1000,
+	}, {
+		name:        "buy trailing stop new low",
+		ordType:     ConditionalTrailingStop,
+		trailPct:    10,
+		extremeRate: 1000,
+		rate:        800,
+		expExtreme:  800,
+	}, {
+		name:        "buy trailing stop triggered",
+		ordType:     ConditionalTrailingStop,
+		trailPct:    10,
+		extremeRate: 1000,
+		rate:        1100,
+		expTrigger:  true,
+		expExtreme:  1000,
+	}}
+
+	for _, tt := range tests {
+		o := &ConditionalOrder{
+			Type:        tt.ordType,
+			TriggerRate: tt.triggerRate,
+			TrailPct:    tt.trailPct,
+			ExtremeRate: tt.extremeRate,
+			Trade:       &TradeForm{Sell: tt.sell},
+		}
+		triggered, updated := o.evaluate(tt.rate)
+		if triggered != tt.expTrigger {
+			t.Fatalf("%s: expected triggered = %t", tt.name, tt.expTrigger)
+		}
+		if updated != (tt.expExtreme != tt.extremeRate) {
+			t.Fatalf("%s: wrong updated = %t", tt.name, updated)
+		}
+		if o.ExtremeRate != tt.expExtreme {
+			t.Fatalf("%s: expected extreme rate %d, got %d", tt.name, tt.expExtreme, o.ExtremeRate)
+		}
+	}
+}
+
+func TestConditionalOrders(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+
+	dcrWallet, tDcrWallet := newTWallet(tUTXOAssetA.ID)
+	tCore.wallets[tUTXOAssetA.ID] = dcrWallet
+	dcrWallet.address = "DsVmA7aqqWeKWy461hXjytbZbgCqbB8g2dq"
+	dcrWallet.Unlock(rig.crypter)
+
+	btcWallet, _ := newTWallet(tUTXOAssetB.ID)
+	tCore.wallets[tUTXOAssetB.ID] = btcWallet
+	btcWallet.address = "12DXGkvxFjuq5btXYkwWfBZaz1rVwFgini"
+	btcWallet.Unlock(rig.crypter)
+
+	qty := dcrBtcLotSize * 10
+	rate := dcrBtcRateStep * 1000
+
+	tDcrWallet.fundingCoins = asset.Coins{&tCoin{id: encode.RandomBytes(36), val: qty * 2}}
+	tDcrWallet.fundRedeemScripts = []dex.Bytes{nil}
+
+	book := newBookie(rig.dc, tUTXOAssetA.ID, tUTXOAssetB.ID, nil, tLogger)
+	rig.dc.books[tDcrBtcMktName] = book
+	err := book.Sync(&msgjson.OrderBook{
+		MarketID: tDcrBtcMktName,
+		Seq:      1,
+		Epoch:    1,
+		Orders: []*msgjson.BookOrderNote{{
+			OrderNote: msgjson.OrderNote{OrderID: encode.RandomBytes(32)},
+			TradeNote: msgjson.TradeNote{
+				Side:     msgjson.SellOrderNum,
+				Quantity: dcrBtcLotSize,
+				Time:     uint64(time.Now().Unix()),
+				Rate:     rate * 2,
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("order book sync error: %v", err)
+	}
+
+	ch := tCore.NotificationFeed()
+	waitForNote := func(topic Topic) *ConditionalOrderNote {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case n := <-ch.C:
+				if note, ok := n.(*ConditionalOrderNote); ok && note.Topic() == topic {
+					return note
+				}
+			case <-timeout:
+				t.Fatalf("no %s notification", topic)
+			}
+		}
+	}
+
+	newForm := func() *ConditionalOrderForm {
+		return &ConditionalOrderForm{
+			Type:        ConditionalStopLimit,
+			PriceSource: PriceSourceLastTrade,
+			TriggerRate: rate * 3 / 2,
+			Trade: &TradeForm{
+				Host:    tDexHost,
+				IsLimit: true,
+				Sell:    true,
+				Base:    tUTXOAssetA.ID,
+				Quote:   tUTXOAssetB.ID,
+				Qty:     qty,
+				Rate:    rate,
+			},
+		}
+	}
+
+	for name, mod := range map[string]func(*ConditionalOrderForm){
+		"no order":            func(f *ConditionalOrderForm) { f.Trade = nil },
+		"unknown type":        func(f *ConditionalOrderForm) { f.Type = "oco" },
+		"stop with limit":     func(f *ConditionalOrderForm) { f.Type = ConditionalStop },
+		"stop-limit w/o rate": func(f *ConditionalOrderForm) { f.Trade.Rate = 0 },
+		"no trigger rate":     func(f *ConditionalOrderForm) { f.TriggerRate = 0 },
+		"bad trail":           func(f *ConditionalOrderForm) { f.Type = ConditionalTrailingStop; f.TrailPct = 100 },
+		"bad price source":    func(f *ConditionalOrderForm) { f.PriceSource = "oracle" },
+		"unknown market":      func(f *ConditionalOrderForm) { f.Trade.Quote = 12345 },
+		"partial lot":         func(f *ConditionalOrderForm) { f.Trade.Qty = qty + 1 },
+	} {
+		form := newForm()
+		mod(form)
+		if _, err := tCore.PlaceConditionalOrder(tPW, form); err == nil {
+			t.Fatalf("%s: no error", name)
+		}
+	}
+
+	rig.crypter.(*tCrypter).recryptErr = tErr
+	if _, err := tCore.PlaceConditionalOrder(tPW, newForm()); !errorHasCode(err, passwordErr) {
+		t.Fatalf("wrong error for password error: %v", err)
+	}
+	rig.crypter.(*tCrypter).recryptErr = nil
+
+	// There are no recent trades, so the stop-limit is not triggered.
+	stopLimit, err := tCore.PlaceConditionalOrder(tPW, newForm())
+	if err != nil {
+		t.Fatalf("PlaceConditionalOrder error: %v", err)
+	}
+	waitForNote(TopicConditionalOrderPlaced)
+
+	// The mid-gap is 2 * rate, below the take-profit trigger.
+	form := newForm()
+	form.Type = ConditionalTakeProfit
+	form.PriceSource = ""
+	form.TriggerRate = rate * 3
+	takeProfit, err := tCore.PlaceConditionalOrder(tPW, form)
+	if err != nil {
+		t.Fatalf("PlaceConditionalOrder error: %v", err)
+	}
+	if takeProfit.PriceSource != PriceSourceMidGap {
+		t.Fatalf("wrong default price source %q", takeProfit.PriceSource)
+	}
+	waitForNote(TopicConditionalOrderPlaced)
+
+	if ords := tCore.ConditionalOrders(); len(ords) != 2 {
+		t.Fatalf("expected 2 conditional orders, got %d", len(ords))
+	}
+
+	if err := tCore.CancelConditionalOrder(takeProfit.ID); err != nil {
+		t.Fatalf("CancelConditionalOrder error: %v", err)
+	}
+	waitForNote(TopicConditionalOrderCanceled)
+	if err := tCore.CancelConditionalOrder(takeProfit.ID); !errorHasCode(err, unknownOrderErr) {
+		t.Fatalf("wrong error canceling unknown conditional order: %v", err)
+	}
+	if len(rig.db.condOrders) != 1 {
+		t.Fatalf("conditional order not deleted from DB")
+	}
+
+	// A trade at the rate triggers the stop-limit.
+	rig.ws.queueResponse(msgjson.LimitRoute, func(msg *msgjson.Message, f msgFunc) error {
+		msgOrder := new(msgjson.LimitOrder)
+		if err := msg.Unmarshal(msgOrder); err != nil {
+			t.Fatalf("unmarshal error: %v", err)
+		}
+		lo := convertMsgLimitOrder(msgOrder)
+		f(orderResponse(msg.ID, msgOrder, lo, false, false, false))
+		return nil
+	})
+	book.AddRecentMatches([][2]int64{{int64(rate), int64(qty)}}, uint64(time.Now().UnixMilli()))
+	tCore.checkConditionalOrders(stopLimit.market())
+	note := waitForNote(TopicConditionalOrderTriggered)
+	if note.ConditionalOrder.Status != ConditionalStatusTriggered || len(note.ConditionalOrder.OrderID) == 0 ||
+		note.ConditionalOrder.TriggeredRate != rate {
+		t.Fatalf("wrong triggered conditional order %+v", note.ConditionalOrder)
+	}
+	ord, err := tCore.ConditionalOrder(stopLimit.ID)
+	if err != nil {
+		t.Fatalf("ConditionalOrder error: %v", err)
+	}
+	if ord.Status != ConditionalStatusTriggered {
+		t.Fatalf("wrong status %s", ord.Status)
+	}
+
+	// A stop that is placed below the last trade is triggered right away. The
+	// order fails because the server does not respond.
+	form = newForm()
+	form.Type = ConditionalStop
+	form.Trade.IsLimit = false
+	form.Trade.Rate = 0
+	if _, err := tCore.PlaceConditionalOrder(tPW, form); err != nil {
+		t.Fatalf("PlaceConditionalOrder error: %v", err)
+	}
+	note = waitForNote(TopicConditionalOrderFailed)
+	if note.ConditionalOrder.Status != ConditionalStatusFailed || note.ConditionalOrder.Error == "" ||
+		note.Severity() != db.ErrorLevel {
+		t.Fatalf("wrong failed conditional order %+v", note.ConditionalOrder)
+	}
+
+	// Conditional orders are reloaded from the DB.
+	tCore.stopConditionalWatchers()
+	if err := tCore.loadConditionalOrders(); err != nil {
+		t.Fatalf("loadConditionalOrders error: %v", err)
+	}
+	// Both orders may have the same stamp, so their order is not checked.
+	ords := tCore.ConditionalOrders()
+	statuses := make(map[string]int, len(ords))
+	for _, o := range ords {
+		statuses[o.Status]++
+	}
+	if len(ords) != 2 || statuses[ConditionalStatusFailed] != 1 || statuses[ConditionalStatusTriggered] != 1 {
+		t.Fatalf("wrong conditional orders loaded")
+	}
+	if len(tCore.condWatchers) != 0 {
+		t.Fatalf("watching markets without active conditional orders")
+	}
+}
@@ -200,6 +200,16 @@ type dexConnection struct {
 	anomaliesCount uint32 // atomic
 	lastConnectMtx sync.RWMutex
 	lastConnect    time.Time
+
+	// activity is the account status last reported by the server in
+	// read-only mode.
+	activityMtx sync.RWMutex
+	activity    *AccountActivity
+
+	// paramsTimers apply scheduled market lot size and rate step changes,
+	// keyed by market name.
+	paramsTimersMtx sync.Mutex
+	paramsTimers    map[string]*time.Timer
 }
 
 // DefaultResponseTimeout is the default timeout for responses after a request is
@@ -368,6 +378,7 @@ func coreMarketFromMsgMarket(dc *dexConnection, msgMkt *msgjson.Market) *Market
 		MarketBuyBuffer: msgMkt.MarketBuyBuffer,
 		AtomToConv:      float64(bconv) / float64(qconv),
 		MinimumRate:     dc.minimumMarketRate(quote, msgMkt.LotSize),
+		ParamsChange:    msgMkt.ParamsChange,
 	}
 
 	trades, inFlight := dc.marketTrades(mkt.marketName())
@@ -623,7 +634,10 @@ func (dc *dexConnection) findOrder(oid order.OrderID) (tracker *trackedTrade, is
 	return
 }
 
-func (c *Core) sendCancelOrder(dc *dexConnection, oid order.OrderID, base, quote uint32) (order.Preimage, *order.CancelOrder, []byte, chan struct{}, error) {
+// newCancelOrder creates a cancel order targeting the order, and registers its
+// commitment so that the server's preimage request can be answered. The
+// returned channel must be closed when the cancel order is tracked.
+func (c *Core) newCancelOrder(dc *dexConnection, oid order.OrderID, base, quote uint32) (order.Preimage, *order.CancelOrder, chan struct{}, error) {
 	preImg := newPreimage()
 	co := &order.CancelOrder{
 		P: order.Prefix{
@@ -638,7 +652,7 @@ func (c *Core) sendCancelOrder(dc *dexConnection, oid order.OrderID, base, quote
 	}
 	err := order.ValidateOrder(co, order.OrderStatusEpoch, 0)
 	if err != nil {
-		return preImg, nil, nil, nil, err
+		return preImg, nil, nil, err
 	}
 
 	commitSig := make(chan struct{})
@@ -646,6 +660,23 @@ func (c *Core) sendCancelOrder(dc *dexConnection, oid order.OrderID, base, quote
 	c.sentCommits[co.Commit] = commitSig
 	c.sentCommitsMtx.Unlock()
 
+	return preImg, co, commitSig, nil
+}
+
+// forgetCommit removes a commitment registered for an order that was not
+// accepted by the server.
+func (c *Core) forgetCommit(commit order.Commitment) {
+	c.sentCommitsMtx.Lock()
+	delete(c.sentCommits, commit)
+	c.sentCommitsMtx.Unlock()
+}
+
+func (c *Core) sendCancelOrder(dc *dexConnection, oid order.OrderID, base, quote uint32) (order.Preimage, *order.CancelOrder, []byte, chan struct{}, error) {
+	preImg, co, commitSig, err := c.newCancelOrder(dc, oid, base, quote)
+	if err != nil {
+		return preImg, nil, nil, nil, err
+	}
+
 	// Create and send the order message. Check the response before using it.
 	route, msgOrder, _ := messageOrder(co, nil)
 	var result = new(msgjson.OrderResult)
@@ -655,16 +686,12 @@ func (c *Core) sendCancelOrder(dc *dexConnection, oid order.OrderID, base, quote
 		// and created the cancel order, but we lost the connection before
 		// receiving the response with the cancel's order ID. Any preimage
 		// request will be unrecognized. This order is ABANDONED.
-		c.sentCommitsMtx.Lock()
-		delete(c.sentCommits, co.Commit)
-		c.sentCommitsMtx.Unlock()
+		c.forgetCommit(co.Commit)
 		return preImg, nil, nil, nil, fmt.Errorf("failed to submit cancel order targeting trade %v: %w", oid, err)
 	}
 	err = validateOrderResponse(dc, result, co, msgOrder)
 	if err != nil {
-		c.sentCommitsMtx.Lock()
-		delete(c.sentCommits, co.Commit)
-		c.sentCommitsMtx.Unlock()
+		c.forgetCommit(co.Commit)
 		return preImg, nil, nil, nil, fmt.Errorf("Abandoning order. preimage: %x, server time: %d: %w",
 			preImg[:], result.ServerTime, err)
 	}
@@ -697,19 +724,8 @@ func (c *Core) tryCancelTrade(dc *dexConnection, tracker *trackedTrade) error {
 	tracker.mtx.Lock()
 	defer tracker.mtx.Unlock()
 
-	if status := tracker.metaData.Status; status != order.OrderStatusEpoch && status != order.OrderStatusBooked {
-		return fmt.Errorf("order %v not cancellable in status %v", oid, status)
-	}
-
-	if tracker.cancel != nil {
-		// Existing cancel might be stale. Deleting it now allows this
-		// cancel attempt to proceed.
-		tracker.deleteStaleCancelOrder()
-
-		if tracker.cancel != nil {
-			return fmt.Errorf("order %s - only one cancel order can be submitted per order per epoch. "+
-				"still waiting on cancel order %s to match", oid, tracker.cancel.ID())
-		}
+	if err := tracker.checkCancelable(); err != nil {
+		return err
 	}
 
 	// Construct and send the order.
@@ -1465,6 +1481,15 @@ type Config struct {
 	ExtensionModeFile string
 
 	TheOneHost string
+
+	// AutoBackup, if set, enables periodic encrypted backups of the database
+	// and any sources added with AddBackupSource.
+	AutoBackup *AutoBackupConfig
+
+	// ReadOnly runs Core in read-only mode. DEX accounts are authenticated
+	// without authorizing the connection for trading, and any action that
+	// would sign or spend is refused with ErrReadOnly. See ExportReadOnly.
+	ReadOnly bool
 }
 
 // locale is data associated with the currently selected language.
@@ -1538,6 +1563,21 @@ type Core struct {
 
 	requestedActionMtx sync.RWMutex
 	requestedActions   map[string]*asset.ActionRequiredNote
+
+	apiTokenMtx sync.RWMutex
+	apiTokens   map[string]*storedAPIToken
+
+	condOrdersMtx sync.RWMutex
+	condOrders    map[string]*ConditionalOrder
+	condWatchers  map[conditionalMarket]context.CancelFunc
+
+	execMtx     sync.RWMutex
+	executions  map[string]*Execution
+	execRunners map[string]*execRunner
+
+	backupMtx     sync.Mutex
+	backupCrypter encrypt.Crypter // derived from the app password on login
+	backupSources []*backupSource
 }
 
 // New is the constructor for a new Core.
@@ -1557,6 +1597,17 @@ func New(cfg *Config) (*Core, error) {
 			return nil, err
 		}
 	}
+	if bc := cfg.AutoBackup; bc != nil {
+		if bc.Dir == "" {
+			return nil, errors.New("no automatic backup directory specified")
+		}
+		if bc.Interval <= 0 {
+			bc.Interval = DefaultBackupInterval
+		}
+		if bc.Daily+bc.Weekly <= 0 {
+			return nil, errors.New("automatic backup rotation must keep at least one archive")
+		}
+	}
 	if cfg.Onion != "" {
 		if _, _, err = net.SplitHostPort(cfg.Onion); err != nil {
 			return nil, err
@@ -1755,12 +1806,28 @@ fetchers:
 		}
 	}()
 
-	// Start bond supervisor.
-	c.wg.Add(1)
-	go func() {
-		defer c.wg.Done()
-		c.watchBonds(ctx)
-	}()
+	if c.cfg.AutoBackup != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.runAutoBackups(ctx)
+		}()
+	}
+
+	if c.cfg.ReadOnly {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.refreshAccountActivity(ctx)
+		}()
+	} else {
+		// Start bond supervisor.
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.watchBonds(ctx)
+		}()
+	}
 
 	// Handle wallet notifications.
 	c.wg.Add(1)
@@ -3508,6 +3575,13 @@ func (c *Core) changeAppPass(newAppPW, innerKey []byte, creds *db.PrimaryCredent
 
 	c.setCredentials(newCreds)
 
+	// New backups are encrypted with the new password.
+	c.loginMtx.Lock()
+	if c.loggedIn && c.cfg.AutoBackup != nil {
+		c.setBackupCrypter(c.newCrypter(newAppPW))
+	}
+	c.loginMtx.Unlock()
+
 	return nil
 }
 
@@ -4128,6 +4202,7 @@ func (c *Core) AddDEX(appPW []byte, dexAddr string, certI any) error {
 	if err != nil {
 		if dc != nil {
 			// Stop (re)connect loop, which may be running even if err != nil.
+			dc.stopParamsTimers()
 			dc.connMaster.Disconnect()
 		}
 		return codedError(connectionErr, err)
@@ -4137,6 +4212,7 @@ func (c *Core) AddDEX(appPW []byte, dexAddr string, certI any) error {
 	var success bool
 	defer func() {
 		if !success {
+			dc.stopParamsTimers()
 			dc.connMaster.Disconnect()
 		}
 	}()
@@ -4361,6 +4437,7 @@ func (c *Core) DiscoverAccount(dexAddr string, appPW []byte, certI any) (*Exchan
 		defer func() {
 			// Either disconnect or promote this connection.
 			if !ready {
+				dc.stopParamsTimers()
 				dc.connMaster.Disconnect()
 				return
 			}
@@ -4619,6 +4696,9 @@ func (c *Core) Login(pw []byte) error {
 			if err != nil {
 				return false, fmt.Errorf("GenDeepChild error: %w", err)
 			}
+			if c.cfg.AutoBackup != nil {
+				c.setBackupCrypter(c.newCrypter(pw))
+			}
 			c.loggedIn = true
 			return true, nil
 		}
@@ -4635,11 +4715,25 @@ func (c *Core) Login(pw []byte) error {
 		// and the balance updated there.
 		c.notify(newLoginNote("Connecting wallets..."))
 		c.connectWallets(crypter) // initialize reserves
-		c.notify(newLoginNote("Resuming active trades..."))
-		c.resolveActiveTrades(crypter)
+		// Nothing is traded in read-only mode, so there are no trades to
+		// resume, and no conditional orders or executions to run.
+		if !c.cfg.ReadOnly {
+			c.notify(newLoginNote("Resuming active trades..."))
+			c.resolveActiveTrades(crypter)
+		}
 		c.notify(newLoginNote("Connecting to DEX servers..."))
 		c.initializeDEXConnections(crypter)
-
+		if err := c.loadAPITokens(crypter); err != nil {
+			c.log.Errorf("Error loading API tokens: %v", err)
+		}
+		if !c.cfg.ReadOnly {
+			if err := c.loadConditionalOrders(); err != nil {
+				c.log.Errorf("Error loading conditional orders: %v", err)
+			}
+			if err := c.loadExecutions(); err != nil {
+				c.log.Errorf("Error loading executions: %v", err)
+			}
+		}
 	}
 
 	return nil
@@ -4891,6 +4985,11 @@ func (c *Core) Logout() error {
 		return codedError(activeOrdersErr, ActiveOrdersLogoutErr)
 	}
 
+	// Conditional orders and executions cannot place orders while logged
+	// out. They are resumed on login.
+	c.stopConditionalWatchers()
+	c.stopExecutions()
+
 	// Lock wallets
 	if !c.cfg.NoAutoWalletLock {
 		// Ensure wallet lock in c.Run waits for c.Logout if this is called
@@ -4919,6 +5018,13 @@ func (c *Core) Logout() error {
 	c.bondXPriv.Zero()
 	c.bondXPriv = nil
 
+	c.setBackupCrypter(nil)
+
+	// API tokens are decrypted at login, and are not kept while logged out.
+	c.apiTokenMtx.Lock()
+	c.apiTokens = nil
+	c.apiTokenMtx.Unlock()
+
 	c.loggedIn = false
 
 	return nil
@@ -5203,7 +5309,7 @@ func (c *Core) initializeDEXConnection(dc *dexConnection, crypter encrypt.Crypte
 	}
 
 	// Unlock the bond wallet if a target tier is set.
-	if bondAssetID, targetTier, maxBondedAmt := dc.bondOpts(); targetTier > 0 {
+	if bondAssetID, targetTier, maxBondedAmt := dc.bondOpts(); targetTier > 0 && !c.cfg.ReadOnly {
 		c.log.Debugf("Preparing %s wallet to maintain target tier of %d for %v, bonding limit %v",
 			unbip(bondAssetID), targetTier, dc.acct.host, maxBondedAmt)
 		wallet, exists := c.wallet(bondAssetID)
@@ -5362,6 +5468,9 @@ func (c *Core) feeSuggestion(dc *dexConnection, assetID uint32) (feeSuggestion u
 // is true, fees are subtracted from the value else fees are taken from the
 // exchange wallet.
 func (c *Core) Send(pw []byte, assetID uint32, value uint64, address string, subtract bool) (asset.Coin, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return nil, err
+	}
 	var crypter encrypt.Crypter
 	// Empty password can be provided if wallet is already unlocked. Webserver
 	// and RPCServer should not allow empty password, but this is used for
@@ -5432,6 +5541,9 @@ func (c *Core) ValidateAddress(address string, assetID uint32) (bool, error) {
 // ApproveToken calls a wallet's ApproveToken method. It approves the version
 // of the token used by the dex at the specified address.
 func (c *Core) ApproveToken(appPW []byte, assetID uint32, dexAddr string, onConfirm func()) (string, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return "", err
+	}
 	crypter, err := c.encryptionKey(appPW)
 	if err != nil {
 		return "", err
@@ -5482,6 +5594,9 @@ func (c *Core) ApproveToken(appPW []byte, assetID uint32, dexAddr string, onConf
 // UnapproveToken calls a wallet's UnapproveToken method for a specified
 // version of the token.
 func (c *Core) UnapproveToken(appPW []byte, assetID uint32, version uint32) (string, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return "", err
+	}
 	crypter, err := c.encryptionKey(appPW)
 	if err != nil {
 		return "", err
@@ -5538,6 +5653,9 @@ func (c *Core) BridgeContractApprovalStatus(assetID uint32) (asset.ApprovalStatu
 
 // ApproveBridgeContract approves the bridge contract for the specified asset.
 func (c *Core) ApproveBridgeContract(assetID uint32) (string, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return "", err
+	}
 	wallet, err := c.connectedWallet(assetID)
 	if err != nil {
 		return "", err
@@ -5563,6 +5681,9 @@ func (c *Core) ApproveBridgeContract(assetID uint32) (string, error) {
 // UnapproveBridgeContract unapproves the bridge contract for the specified
 // asset.
 func (c *Core) UnapproveBridgeContract(assetID uint32) (string, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return "", err
+	}
 	wallet, err := c.connectedWallet(assetID)
 	if err != nil {
 		return "", err
@@ -5587,6 +5708,9 @@ func (c *Core) UnapproveBridgeContract(assetID uint32) (string, error) {
 
 // Bridge initiates a bridge.
 func (c *Core) Bridge(fromAssetID, toAssetID uint32, amt uint64) (txID string, err error) {
+	if err := c.checkReadOnly(); err != nil {
+		return "", err
+	}
 	// Connect and unlock the source wallet.
 	sourceWallet, err := c.connectedWallet(fromAssetID)
 	if err != nil {
@@ -6032,6 +6156,10 @@ func (c *Core) prepareForTradeRequestPrep(pw []byte, base, quote uint32, host st
 		return nil, nil, nil, nil, err
 	}
 
+	if err := c.checkReadOnly(); err != nil {
+		return fail(err)
+	}
+
 	// Check the user password. A Trade can be attempted with an empty password,
 	// which should work if both wallets are unlocked. We use this feature for
 	// bots.
@@ -6527,8 +6655,8 @@ func (c *Core) prepareMultiTradeRequests(pw []byte, form *MultiTradeForm) ([]*tr
 // sendTradeRequest sends an order, processes the result, then prepares and
 // stores the trackedTrade.
 func (c *Core) sendTradeRequest(tr *tradeRequest) (*Order, error) {
-	dc, dbOrder, wallets, form, route := tr.dc, tr.dbOrder, tr.wallets, tr.form, tr.route
-	mktID, msgOrder, preImg, recoveryCoin, coins := tr.mktID, tr.msgOrder, tr.preImg, tr.recoveryCoin, tr.coins
+	dc, dbOrder, route := tr.dc, tr.dbOrder, tr.route
+	mktID, msgOrder, preImg := tr.mktID, tr.msgOrder, tr.preImg
 	defer tr.errCloser.Done(c.log)
 	defer close(tr.commitSig) // signals on both success and failure
 
@@ -6551,6 +6679,15 @@ func (c *Core) sendTradeRequest(tr *tradeRequest) (*Order, error) {
 		return nil, fmt.Errorf("validateOrderResponse error: %w", err)
 	}
 
+	return c.trackTradeRequest(tr, result)
+}
+
+// trackTradeRequest stores the order that was accepted by the server, and
+// prepares the trackedTrade.
+func (c *Core) trackTradeRequest(tr *tradeRequest, result *msgjson.OrderResult) (*Order, error) {
+	dc, dbOrder, wallets, form := tr.dc, tr.dbOrder, tr.wallets, tr.form
+	preImg, recoveryCoin, coins := tr.preImg, tr.recoveryCoin, tr.coins
+
 	// TODO: Need xcWallet fields for acceptable SwapConf values: a min
 	// acceptable for security, and even a max confs override to act sooner.
 
@@ -6561,7 +6698,7 @@ func (c *Core) sendTradeRequest(tr *tradeRequest) (*Order, error) {
 		Preimage: tr.preImg[:],
 	}
 
-	err = c.db.UpdateOrder(dbOrder)
+	err := c.db.UpdateOrder(dbOrder)
 	if err != nil {
 		c.log.Errorf("Abandoning order. preimage: %x, server time: %d: %v",
 			preImg[:], result.ServerTime, fmt.Sprintf("failed to store order in database: %v", err))
@@ -6571,6 +6708,7 @@ func (c *Core) sendTradeRequest(tr *tradeRequest) (*Order, error) {
 	// Prepare and store the tracker and get the core.Order to return.
 	tracker := newTrackedTrade(dbOrder, preImg, dc, c.lockTimeTaker, c.lockTimeMaker,
 		c.db, c.latencyQ, wallets, coins, c.notify, c.formatDetails)
+	tracker.fiatRates = c.fiatConversions
 
 	tracker.redemptionLocked = tracker.redemptionReserves
 	tracker.refundLocked = tracker.refundReserves
@@ -6698,6 +6836,9 @@ func (c *Core) walletSet(dc *dexConnection, baseID, quoteID uint32, sell bool) (
 }
 
 func (c *Core) Cancel(oidB dex.Bytes) error {
+	if err := c.checkReadOnly(); err != nil {
+		return err
+	}
 	oid, err := order.IDFromBytes(oidB)
 	if err != nil {
 		return err
@@ -6870,40 +7011,29 @@ func (dc *dexConnection) maxScore() uint32 {
 	return 60 // Assume the default for < v2 servers.
 }
 
-// authDEX authenticates the connection for a DEX.
-func (c *Core) authDEX(dc *dexConnection) error {
-	bondAssets, bondExpiry := dc.bondAssets()
-	if bondAssets == nil { // reconnect loop may be running
-		return fmt.Errorf("dex connection not usable prior to config request")
-	}
-
-	// Copy the local bond slices since bondConfirmed will modify them.
-	dc.acct.authMtx.RLock()
-	localActiveBonds := make([]*db.Bond, len(dc.acct.bonds))
-
-	copy(localActiveBonds, dc.acct.bonds)
-	localPendingBonds := make([]*db.Bond, len(dc.acct.pendingBonds))
-	copy(localPendingBonds, dc.acct.pendingBonds)
-	dc.acct.authMtx.RUnlock()
-
+// sendConnect signs and sends a 'connect' request for the account. The
+// returned serialized payload is what the server signs in its response. A
+// read-only connect does not authorize the connection for trading.
+func (dc *dexConnection) sendConnect(readOnly bool) (*msgjson.ConnectResult, []byte, error) {
 	// Prepare and sign the message for the 'connect' route.
 	acctID := dc.acct.ID()
 	payload := &msgjson.Connect{
 		AccountID:  acctID[:],
 		APIVersion: 0,
 		Time:       uint64(time.Now().UnixMilli()),
+		ReadOnly:   readOnly,
 	}
 	sigMsg := payload.Serialize()
 	sig, err := dc.acct.sign(sigMsg)
 	if err != nil {
-		return fmt.Errorf("signing error: %w", err)
+		return nil, nil, fmt.Errorf("signing error: %w", err)
 	}
 	payload.SetSig(sig)
 
 	// Send the 'connect' request.
 	req, err := msgjson.NewRequest(dc.NextID(), msgjson.ConnectRoute, payload)
 	if err != nil {
-		return fmt.Errorf("error encoding 'connect' request: %w", err)
+		return nil, nil, fmt.Errorf("error encoding 'connect' request: %w", err)
 	}
 	errChan := make(chan error, 1)
 	result := new(msgjson.ConnectResult)
@@ -6914,11 +7044,35 @@ func (c *Core) authDEX(dc *dexConnection) error {
 	})
 	// Check the request error.
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Check the response error.
-	err = <-errChan
+	return result, sigMsg, <-errChan
+}
+
+// authDEX authenticates the connection for a DEX.
+func (c *Core) authDEX(dc *dexConnection) error {
+	if c.cfg.ReadOnly {
+		return c.authDEXReadOnly(dc)
+	}
+
+	bondAssets, bondExpiry := dc.bondAssets()
+	if bondAssets == nil { // reconnect loop may be running
+		return fmt.Errorf("dex connection not usable prior to config request")
+	}
+
+	// Copy the local bond slices since bondConfirmed will modify them.
+	dc.acct.authMtx.RLock()
+	localActiveBonds := make([]*db.Bond, len(dc.acct.bonds))
+
+	copy(localActiveBonds, dc.acct.bonds)
+	localPendingBonds := make([]*db.Bond, len(dc.acct.pendingBonds))
+	copy(localPendingBonds, dc.acct.pendingBonds)
+	dc.acct.authMtx.RUnlock()
+
+	acctID := dc.acct.ID()
+	result, sigMsg, err := dc.sendConnect(false)
 	// AccountNotFoundError may signal we have an initial bond to post.
 	var mErr *msgjson.Error
 	if errors.As(err, &mErr) && mErr.Code == msgjson.AccountNotFoundError {
@@ -7487,6 +7641,7 @@ func (c *Core) dbTrackers(dc *dexConnection) (map[order.OrderID]*trackedTrade, e
 		copy(preImg[:], dbOrder.MetaData.Proof.Preimage)
 		tracker := newTrackedTrade(dbOrder, preImg, dc, c.lockTimeTaker, c.lockTimeMaker,
 			c.db, c.latencyQ, nil, nil, c.notify, c.formatDetails)
+		tracker.fiatRates = c.fiatConversions
 		tracker.readyToTick = false
 		trackers[dbOrder.Order.ID()] = tracker
 
@@ -7550,6 +7705,10 @@ func (c *Core) dbTrackers(dc *dexConnection) (map[order.OrderID]*trackedTrade, e
 			c.log.Errorf("Error setting cancel order info %s: %v", co.ID(), err)
 		} else {
 			tracker.cancel.matches.maker = makerCancel
+			// The cancel order of an amend is linked to the replacement.
+			if linked := metaCancel.MetaData.LinkedOrder; !linked.IsZero() && linked != oid {
+				tracker.cancel.replacement = linked
+			}
 		}
 		delete(unknownCancels, cancelID) // this one is known
 		c.log.Debugf("Loaded cancel order %v for trade %v", cancelID, oid)
@@ -8246,6 +8405,7 @@ func (c *Core) connectDEXWithFlag(acctInfo *db.AccountInfo, flag connectDEXFlag)
 
 	err = c.startDexConnection(acctInfo, dc)
 	if err != nil {
+		dc.stopParamsTimers()
 		dc.connMaster.Disconnect() // stop any retry loop for this new connection.
 		return nil, err
 	}
@@ -8715,8 +8875,14 @@ func handleRevokeOrderMsg(c *Core, dc *dexConnection, msg *msgjson.Message) erro
 	}
 	tracker.revoke()
 
-	subject, details := c.formatDetails(TopicOrderRevoked, tracker.token(), tracker.mktID, dc.acct.host)
-	c.notify(newOrderNote(TopicOrderRevoked, subject, details, db.ErrorLevel, tracker.coreOrder()))
+	topic := TopicOrderRevoked
+	args := []any{tracker.token(), tracker.mktID, dc.acct.host}
+	if revocation.Reason != "" {
+		topic = TopicOrderRevokedReason
+		args = append(args, revocation.Reason)
+	}
+	subject, details := c.formatDetails(topic, args...)
+	c.notify(newOrderNote(topic, subject, details, db.ErrorLevel, tracker.coreOrder()))
 
 	// Update market orders, and the balance to account for unlocked coins.
 	c.updateAssetBalance(tracker.fromAssetID)
@@ -8889,8 +9055,10 @@ var noteHandlers = map[string]routeHandler{
 	msgjson.PriceUpdateRoute:     handlePriceUpdateNote,
 	msgjson.UpdateRemainingRoute: handleUpdateRemainingMsg,
 	msgjson.EpochReportRoute:     handleEpochReportMsg,
+	msgjson.TradeTapeRoute:       handleTradeTapeMsg,
 	msgjson.SuspensionRoute:      handleTradeSuspensionMsg,
 	msgjson.ResumptionRoute:      handleTradeResumptionMsg,
+	msgjson.MarketParamsRoute:    handleMarketParamsMsg,
 	msgjson.NotifyRoute:          handleNotifyMsg,
 	msgjson.PenaltyRoute:         handlePenaltyMsg,
 	msgjson.NoMatchRoute:         handleNoMatchRoute,
@@ -8911,6 +9079,7 @@ func (c *Core) listen(dc *dexConnection) {
 	msgs := dc.MessageSource() // dc.connMaster.Disconnect closes it e.g. cancel of client/comms.(*wsConn).Connect
 
 	defer dc.ticker.Stop()
+	defer dc.stopParamsTimers()
 	lastTick := time.Now()
 
 	// Messages must be run in the order in which they are received, but they
@@ -9287,7 +9456,7 @@ func handleNoMatchRoute(c *Core, dc *dexConnection, msg *msgjson.Message) error
 	var oid order.OrderID
 	copy(oid[:], nomatchMsg.OrderID)
 
-	tracker, _ := dc.findOrder(oid)
+	tracker, isCancel := dc.findOrder(oid)
 	if tracker == nil {
 		dc.blindCancelsMtx.Lock()
 		_, found := dc.blindCancels[oid]
@@ -9300,10 +9469,28 @@ func handleNoMatchRoute(c *Core, dc *dexConnection, msg *msgjson.Message) error
 		return newError(unknownOrderErr, "nomatch request received for unknown order %v from %s", oid, dc.acct.host)
 	}
 
+	// The replacement order of an amend is not matched if the amend's cancel
+	// order did not execute. The nomatch for the cancel order is sent first.
+	var replacement order.OrderID
+	if isCancel {
+		tracker.mtx.RLock()
+		if tracker.cancel != nil && tracker.cancel.ID() == oid {
+			replacement = tracker.cancel.replacement
+		}
+		tracker.mtx.RUnlock()
+	}
+
 	updatedAssets, err := tracker.nomatch(oid)
 	if len(updatedAssets) > 0 {
 		c.updateBalances(updatedAssets)
 	}
+	if !replacement.IsZero() {
+		if rt, _ := dc.findOrder(replacement); rt != nil {
+			rt.mtx.Lock()
+			rt.amendFailed = true
+			rt.mtx.Unlock()
+		}
+	}
 	c.checkEpochResolution(dc.acct.host, tracker.mktID)
 	return err
 }
@@ -10298,6 +10485,9 @@ func (c *Core) DeleteArchivedRecords(olderThan *time.Time, matchesFile, ordersFi
 // AccelerateOrder will use the Child-Pays-For-Parent technique to accelerate
 // the swap transactions in an order.
 func (c *Core) AccelerateOrder(pw []byte, oidB dex.Bytes, newFeeRate uint64) (string, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return "", err
+	}
 	_, err := c.encryptionKey(pw)
 	if err != nil {
 		return "", fmt.Errorf("AccelerateOrder password error: %w", err)
@@ -10724,6 +10914,9 @@ func (c *Core) SetVSP(assetID uint32, addr string) error {
 // PurchaseTickets purchases n tickets. Returns the purchased ticket hashes if
 // successful. Used for ticket purchasing.
 func (c *Core) PurchaseTickets(assetID uint32, pw []byte, n int) error {
+	if err := c.checkReadOnly(); err != nil {
+		return err
+	}
 	wallet, tb, err := c.stakingWallet(assetID)
 	if err != nil {
 		return err
@@ -10753,6 +10946,9 @@ func (c *Core) PurchaseTickets(assetID uint32, pw []byte, n int) error {
 // purchasing.
 func (c *Core) SetVotingPreferences(assetID uint32, choices, tSpendPolicy,
 	treasuryPolicy map[string]string) error {
+	if err := c.checkReadOnly(); err != nil {
+		return err
+	}
 	_, tb, err := c.stakingWallet(assetID)
 	if err != nil {
 		return err
@@ -10805,6 +11001,9 @@ func (c *Core) FundsMixingStats(assetID uint32) (*asset.FundsMixingStats, error)
 
 // ConfigureFundsMixer configures the wallet for funds mixing.
 func (c *Core) ConfigureFundsMixer(pw []byte, assetID uint32, isMixerEnabled bool) error {
+	if err := c.checkReadOnly(); err != nil {
+		return err
+	}
 	wallet, mw, err := c.mixingWallet(assetID)
 	if err != nil {
 		return err
@@ -10909,6 +11108,9 @@ func (c *Core) handleCoreAction(actionID string, actionB json.RawMessage) ( /* h
 // TakeAction is called in response to a ActionRequiredNote. The note may have
 // come from core or from a wallet.
 func (c *Core) TakeAction(assetID uint32, actionID string, actionB json.RawMessage) (err error) {
+	if err := c.checkReadOnly(); err != nil {
+		return err
+	}
 	defer func() {
 		if err != nil {
 			c.log.Errorf("Error while attempting user action %q with parameters %q, asset ID %d: %v",
@@ -10935,6 +11137,9 @@ func (c *Core) TakeAction(assetID uint32, actionID string, actionB json.RawMessa
 // GenerateBCHRecoveryTransaction generates a tx that spends all inputs from the
 // deprecated BCH wallet to the given recipient.
 func (c *Core) GenerateBCHRecoveryTransaction(appPW []byte, recipient string) ([]byte, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return nil, err
+	}
 	const bipID = 145
 	crypter, err := c.encryptionKey(appPW)
 	if err != nil {
@@ -11011,6 +11216,9 @@ func (c *Core) checkEpochResolution(host string, mktID string) {
 // prepaid bond (code is a prepaid bond). If the user is not registered with
 // dex.decred.org yet, the dex will be added first.
 func (c *Core) RedeemGeocode(appPW, code []byte, msg string) (dex.Bytes, uint64, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return nil, 0, err
+	}
 	const dcrBipID = 42
 	dcrWallet, found := c.wallet(dcrBipID)
 	if !found {
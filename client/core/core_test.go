@@ -14,6 +14,7 @@ import (
 	"math/rand"
 	"os"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -357,6 +358,8 @@ type TDB struct {
 	addBondErr               error
 	updateOrderErr           error
 	activeDEXOrders          []*db.MetaOrder
+	orders                   []*db.MetaOrder
+	backupData               []byte
 	matchesForOID            []*db.MetaMatch
 	matchesForOIDErr         error
 	updateMatchChan          chan order.MatchStatus
@@ -386,6 +389,11 @@ type TDB struct {
 	deleteInactiveMatchesErr error
 	archivedMatches          int
 	updateAccountInfoErr     error
+	apiTokens                map[string][]byte
+	condOrdersMtx            sync.Mutex
+	condOrders               map[string][]byte
+	executionsMtx            sync.Mutex
+	executions               map[string][]byte
 }
 
 func (tdb *TDB) Run(context.Context) {}
@@ -462,7 +470,7 @@ func (tdb *TDB) Order(oid order.OrderID) (*db.MetaOrder, error) {
 }
 
 func (tdb *TDB) Orders(*db.OrderFilter) ([]*db.MetaOrder, error) {
-	return nil, nil
+	return tdb.orders, nil
 }
 
 func (tdb *TDB) MarketOrders(dex string, base, quote uint32, n int, since uint64) ([]*db.MetaOrder, error) {
@@ -529,11 +537,17 @@ func (tdb *TDB) Wallet([]byte) (*db.Wallet, error) {
 	return tdb.wallet, tdb.walletErr
 }
 
-func (tdb *TDB) SaveNotification(*db.Notification) error            { return nil }
-func (tdb *TDB) BackupTo(dst string, overwrite, compact bool) error { return nil }
-func (tdb *TDB) NotificationsN(int) ([]*db.Notification, error)     { return nil, nil }
-func (tdb *TDB) SavePokes([]*db.Notification) error                 { return nil }
-func (tdb *TDB) LoadPokes() ([]*db.Notification, error)             { return nil, nil }
+func (tdb *TDB) SaveNotification(*db.Notification) error        { return nil }
+func (tdb *TDB) NotificationsN(int) ([]*db.Notification, error) { return nil, nil }
+func (tdb *TDB) SavePokes([]*db.Notification) error             { return nil }
+func (tdb *TDB) LoadPokes() ([]*db.Notification, error)         { return nil, nil }
+
+func (tdb *TDB) BackupTo(dst string, overwrite, compact bool) error {
+	if tdb.backupData == nil {
+		return nil
+	}
+	return os.WriteFile(dst, tdb.backupData, 0600)
+}
 
 func (tdb *TDB) SetPrimaryCredentials(creds *db.PrimaryCredentials) error {
 	if tdb.setCredsErr != nil {
@@ -589,6 +603,90 @@ func (tdb *TDB) Language() (string, error) {
 	return "en-US", nil
 }
 
+func (tdb *TDB) SaveAPIToken(name string, encToken []byte) error {
+	if tdb.apiTokens == nil {
+		tdb.apiTokens = make(map[string][]byte)
+	}
+	tdb.apiTokens[name] = encToken
+	return nil
+}
+
+func (tdb *TDB) APITokens() (map[string][]byte, error) {
+	tokens := make(map[string][]byte, len(tdb.apiTokens))
+	for name, encToken := range tdb.apiTokens {
+		tokens[name] = encToken
+	}
+	return tokens, nil
+}
+
+func (tdb *TDB) DeleteAPIToken(name string) error {
+	if _, found := tdb.apiTokens[name]; !found {
+		return fmt.Errorf("no API token named %q", name)
+	}
+	delete(tdb.apiTokens, name)
+	return nil
+}
+
+func (tdb *TDB) UpdateConditionalOrder(id []byte, ord []byte) error {
+	tdb.condOrdersMtx.Lock()
+	defer tdb.condOrdersMtx.Unlock()
+	if tdb.condOrders == nil {
+		tdb.condOrders = make(map[string][]byte)
+	}
+	tdb.condOrders[string(id)] = ord
+	return nil
+}
+
+func (tdb *TDB) ConditionalOrders() ([][]byte, error) {
+	tdb.condOrdersMtx.Lock()
+	defer tdb.condOrdersMtx.Unlock()
+	ords := make([][]byte, 0, len(tdb.condOrders))
+	for _, ord := range tdb.condOrders {
+		ords = append(ords, ord)
+	}
+	return ords, nil
+}
+
+func (tdb *TDB) DeleteConditionalOrder(id []byte) error {
+	tdb.condOrdersMtx.Lock()
+	defer tdb.condOrdersMtx.Unlock()
+	if _, found := tdb.condOrders[string(id)]; !found {
+		return fmt.Errorf("no conditional order %x", id)
+	}
+	delete(tdb.condOrders, string(id))
+	return nil
+}
+
+func (tdb *TDB) UpdateExecution(id []byte, exec []byte) error {
+	tdb.executionsMtx.Lock()
+	defer tdb.executionsMtx.Unlock()
+	if tdb.executions == nil {
+		tdb.executions = make(map[string][]byte)
+	}
+	tdb.executions[string(id)] = exec
+	return nil
+}
+
+func (tdb *TDB) Executions() ([][]byte, error) {
+	tdb.executionsMtx.Lock()
+	defer tdb.executionsMtx.Unlock()
+	execs := make([][]byte, 0, len(tdb.executions))
+	for _, exec := range tdb.executions {
+		execs = append(execs, exec)
+	}
+	return execs, nil
+}
+
+func (tdb *TDB) DeleteExecution(id []byte) error {
+	tdb.executionsMtx.Lock()
+	defer tdb.executionsMtx.Unlock()
+	if _, found := tdb.executions[string(id)]; !found {
+		return fmt.Errorf("no execution %x", id)
+	}
+	delete(tdb.executions, string(id))
+	return nil
+}
+
 type tCoin struct {
 	id []byte
 
@@ -1089,8 +1187,15 @@ func (w *TXCWallet) MaxFundingFees(_ uint32, _ uint64, _ map[string]string) uint
 	return 0
 }
 
-func (*TXCWallet) FundMultiOrder(ord *asset.MultiOrder, maxLock uint64) (coins []asset.Coins, redeemScripts [][]dex.Bytes, fundingFees uint64, err error) {
-	return nil, nil, 0, nil
+func (w *TXCWallet) FundMultiOrder(ord *asset.MultiOrder, maxLock uint64) (coins []asset.Coins, redeemScripts [][]dex.Bytes, fundingFees uint64, err error) {
+	if w.fundingCoins == nil {
+		return nil, nil, 0, w.fundingCoinErr
+	}
+	for range ord.Values {
+		coins = append(coins, w.fundingCoins)
+		redeemScripts = append(redeemScripts, w.fundRedeemScripts)
+	}
+	return coins, redeemScripts, 0, w.fundingCoinErr
 }
 
 var _ asset.Bonder = (*TXCWallet)(nil)
@@ -1831,6 +1936,96 @@ func TestBookFeed(t *testing.T) {
 	checkAction(feed2, EpochMatchSummary)
 	checkAction(feed2, CandleUpdateAction)
 	checkAction(feed2, CandleUpdateAction)
+
+	// A trade tape note before the tape is requested is ignored.
+	tapeNote, _ := msgjson.NewNotification(msgjson.TradeTapeRoute, &msgjson.TradeTapeNote{
+		MarketID: tDcrBtcMktName,
+		Epoch:    2,
+		Trades: []*msgjson.MarketTrade{
+			{Stamp: 3, Rate: 5e7, Qty: 1e8},
+			{Stamp: 3, Rate: 6e7, Qty: 2e8, TakerSell: true},
+		},
+	})
+	if err := handleTradeTapeMsg(tCore, dc, tapeNote); err != nil {
+		t.Fatalf("handleTradeTapeMsg error: %v", err)
+	}
+	select {
+	case u := <-feed2.Next():
+		t.Fatalf("unexpected %s update before requesting trades", u.Action)
+	default:
+	}
+
+	// A failed trades request leaves the tape inactive.
+	rig.ws.queueResponse(msgjson.TradesRoute, func(msg *msgjson.Message, f msgFunc) error {
+		return errors.New("test error")
+	})
+	if err := feed2.Trades(); err == nil {
+		t.Fatalf("no error for failed trades request")
+	}
+	if err := handleTradeTapeMsg(tCore, dc, tapeNote); err != nil {
+		t.Fatalf("handleTradeTapeMsg error: %v", err)
+	}
+	select {
+	case u := <-feed2.Next():
+		t.Fatalf("unexpected %s update after failed trades request", u.Action)
+	default:
+	}
+
+	// Test trades. A trade tape note that arrives while the request is in
+	// flight is merged with the response, which also has the note's trade.
+	rig.ws.queueResponse(msgjson.TradesRoute, func(msg *msgjson.Message, f msgFunc) error {
+		inFlightNote, _ := msgjson.NewNotification(msgjson.TradeTapeRoute, &msgjson.TradeTapeNote{
+			MarketID: tDcrBtcMktName,
+			Epoch:    1,
+			Trades:   []*msgjson.MarketTrade{{Stamp: 2, Rate: 45e6, Qty: 1e8}},
+		})
+		if err := handleTradeTapeMsg(tCore, dc, inFlightNote); err != nil {
+			t.Errorf("handleTradeTapeMsg error: %v", err)
+		}
+		resp, _ := msgjson.NewResponse(msg.ID, []*msgjson.MarketTrade{
+			{Stamp: 2, Rate: 45e6, Qty: 1e8},
+			{Stamp: 1, Rate: 4e7, Qty: 3e8},
+		}, nil)
+		f(resp)
+		return nil
+	})
+	if err := feed2.Trades(); err != nil {
+		t.Fatalf("Trades error: %v", err)
+	}
+	checkTrades := func(action string, expRates ...uint64) {
+		t.Helper()
+		select {
+		case u := <-feed2.Next():
+			if u.Action != action {
+				t.Fatalf("expected action = %s, got %s", action, u.Action)
+			}
+			trades := u.Payload.(*TradesPayload).Trades
+			if len(trades) != len(expRates) {
+				t.Fatalf("expected %d trades, got %d", len(expRates), len(trades))
+			}
+			for i, trade := range trades {
+				if trade.MsgRate != expRates[i] {
+					t.Fatalf("wrong rate for trade %d. expected %d, got %d", i, expRates[i], trade.MsgRate)
+				}
+			}
+		default:
+			t.Fatalf("no %s received", action)
+		}
+	}
+	checkTrades(TradeTapeAction, 45e6)
+	checkTrades(FreshTradesAction, 45e6, 4e7)
+
+	// Now the trade tape note should be sent, newest first.
+	if err := handleTradeTapeMsg(tCore, dc, tapeNote); err != nil {
+		t.Fatalf("handleTradeTapeMsg error: %v", err)
+	}
+	checkTrades(TradeTapeAction, 6e7, 5e7)
+
+	// A second request is served from the tape.
+	if err := feed2.Trades(); err != nil {
+		t.Fatalf("second Trades error: %v", err)
+	}
+	checkTrades(FreshTradesAction, 6e7, 5e7, 45e6, 4e7)
 }
 
 type tDriver struct {
@@ -4464,6 +4659,19 @@ func TestHandleRevokeOrderMsg(t *testing.T) {
 	if tracker.metaData.Status != order.OrderStatusRevoked {
 		t.Errorf("expected order status %v, got %v", order.OrderStatusRevoked, tracker.metaData.Status)
 	}
+
+	// A revocation with a reason, e.g. for a market lot size change.
+	tracker.mtx.Lock()
+	tracker.metaData.Status = order.OrderStatusBooked
+	tracker.mtx.Unlock()
+	payload.Reason = "lot size changed"
+	req, _ = msgjson.NewRequest(rig.dc.NextID(), msgjson.RevokeOrderRoute, payload)
+	err = handleRevokeOrderMsg(rig.core, rig.dc, req)
+	if err != nil {
+		t.Fatalf("handleRevokeOrderMsg error: %v", err)
+	}
+
+	verifyRevokeNotification(orderNotes, TopicOrderRevokedReason, t)
 }
 
 func TestHandleRevokeMatchMsg(t *testing.T) {
@@ -7049,6 +7257,146 @@ func TestHandleTradeResumptionMsg(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected trade error %v", err)
 	}
+
+	// A resumption for a market added by the server refreshes the config.
+	rig.dc.cfgMtx.Lock()
+	fullCfg := rig.dc.cfg
+	cfg := *fullCfg
+	cfg.Markets = slices.DeleteFunc(slices.Clone(cfg.Markets), func(mkt *msgjson.Market) bool {
+		return mkt.Name == tBtcEthMktName
+	})
+	rig.dc.cfg = &cfg
+	rig.dc.cfgMtx.Unlock()
+	if rig.dc.marketConfig(tBtcEthMktName) != nil {
+		t.Fatalf("market %s not removed", tBtcEthMktName)
+	}
+	rig.ws.queueResponse(msgjson.ConfigRoute, func(msg *msgjson.Message, f msgFunc) error {
+		resp, _ := msgjson.NewResponse(msg.ID, fullCfg, nil)
+		f(resp)
+		return nil
+	})
+	payload = &msgjson.TradeResumption{
+		MarketID:   tBtcEthMktName,
+		ResumeTime: uint64(time.Now().Add(time.Minute).UnixMilli()),
+		StartEpoch: 1234,
+	}
+	req, _ = msgjson.NewRequest(rig.dc.NextID(), msgjson.ResumptionRoute, payload)
+	err = handleTradeResumptionMsg(rig.core, rig.dc, req)
+	if err != nil {
+		t.Fatalf("[handleTradeResumptionMsg] unexpected error for new market: %v", err)
+	}
+	mktConf = rig.dc.marketConfig(tBtcEthMktName)
+	if mktConf == nil {
+		t.Fatalf("new market %s not found after config refresh", tBtcEthMktName)
+	}
+	if mktConf.StartEpoch != payload.StartEpoch {
+		t.Fatalf("wrong start epoch for new market. wanted %d, got %d", payload.StartEpoch, mktConf.StartEpoch)
+	}
+}
+
+func TestHandleMarketParamsMsg(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+
+	mktConf := rig.dc.marketConfig(tDcrBtcMktName)
+	epochLen := mktConf.EpochLen
+	newLotSize, newRateStep := dcrBtcLotSize*2, dcrBtcRateStep*10
+
+	handle := func(note *msgjson.MarketParamsNote) error {
+		req, _ := msgjson.NewNotification(msgjson.MarketParamsRoute, note)
+		return handleMarketParamsMsg(rig.core, rig.dc, req)
+	}
+
+	// Unknown market.
+	err := handle(&msgjson.MarketParamsNote{
+		MarketID:           "dcr_dcr",
+		MarketParamsChange: msgjson.MarketParamsChange{Epoch: 1, LotSize: newLotSize, RateStep: newRateStep},
+	})
+	if err == nil {
+		t.Fatal("no error for unknown market")
+	}
+
+	// Zero lot size.
+	err = handle(&msgjson.MarketParamsNote{
+		MarketID:           tDcrBtcMktName,
+		MarketParamsChange: msgjson.MarketParamsChange{Epoch: 1, RateStep: newRateStep},
+	})
+	if err == nil {
+		t.Fatal("no error for zero lot size")
+	}
+
+	// A change scheduled for the future is recorded but not applied.
+	futureEpoch := uint64(time.Now().Add(time.Hour).UnixMilli()) / epochLen
+	err = handle(&msgjson.MarketParamsNote{
+		MarketID:           tDcrBtcMktName,
+		MarketParamsChange: msgjson.MarketParamsChange{Epoch: futureEpoch, LotSize: newLotSize, RateStep: newRateStep},
+	})
+	if err != nil {
+		t.Fatalf("error handling market params: %v", err)
+	}
+	mkt := rig.dc.marketMap()[tDcrBtcMktName]
+	if mkt.ParamsChange == nil || mkt.ParamsChange.Epoch != futureEpoch {
+		t.Fatalf("scheduled change not recorded: %+v", mkt.ParamsChange)
+	}
+	if mkt.LotSize != dcrBtcLotSize || mkt.RateStep != dcrBtcRateStep {
+		t.Fatalf("params changed early: lot size %d, rate step %d", mkt.LotSize, mkt.RateStep)
+	}
+
+	// A change that does not match the scheduled epoch is ignored.
+	rig.dc.applyMarketParamsChange(tDcrBtcMktName, &msgjson.MarketParamsChange{
+		Epoch: futureEpoch + 1, LotSize: newLotSize, RateStep: newRateStep,
+	})
+	if mkt := rig.dc.marketConfig(tDcrBtcMktName); mkt.LotSize != dcrBtcLotSize {
+		t.Fatalf("params applied for wrong epoch")
+	}
+
+	// Rescheduling for a change epoch that has already begun applies the
+	// change right away.
+	nowEpoch := uint64(time.Now().UnixMilli()) / epochLen
+	err = handle(&msgjson.MarketParamsNote{
+		MarketID:           tDcrBtcMktName,
+		MarketParamsChange: msgjson.MarketParamsChange{Epoch: nowEpoch, LotSize: newLotSize, RateStep: newRateStep},
+	})
+	if err != nil {
+		t.Fatalf("error handling market params: %v", err)
+	}
+	applied := func() bool {
+		rig.dc.cfgMtx.RLock()
+		defer rig.dc.cfgMtx.RUnlock()
+		mkt := rig.dc.findMarketConfig(tDcrBtcMktName)
+		return mkt.LotSize == newLotSize && mkt.RateStep == newRateStep && mkt.ParamsChange == nil
+	}
+	for deadline := time.Now().Add(time.Second); !applied(); {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled params change not applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Scheduled changes are stopped when the connection is torn down.
+	err = handle(&msgjson.MarketParamsNote{
+		MarketID:           tDcrBtcMktName,
+		MarketParamsChange: msgjson.MarketParamsChange{Epoch: futureEpoch, LotSize: dcrBtcLotSize, RateStep: dcrBtcRateStep},
+	})
+	if err != nil {
+		t.Fatalf("error handling market params: %v", err)
+	}
+	rig.dc.paramsTimersMtx.Lock()
+	timer := rig.dc.paramsTimers[tDcrBtcMktName]
+	rig.dc.paramsTimersMtx.Unlock()
+	if timer == nil {
+		t.Fatal("params change not scheduled")
+	}
+	rig.core.stopDEXConnection(rig.dc)
+	rig.dc.paramsTimersMtx.Lock()
+	numTimers := len(rig.dc.paramsTimers)
+	rig.dc.paramsTimersMtx.Unlock()
+	if numTimers != 0 {
+		t.Fatalf("%d params timers left after teardown", numTimers)
+	}
+	if timer.Stop() {
+		t.Fatal("params timer still running after teardown")
+	}
 }
 
 func TestHandleNomatch(t *testing.T) {
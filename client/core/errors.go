@@ -52,6 +52,7 @@ const (
 	bondTimeErr
 	bondAssetErr
 	bondPostErr // TODO
+	readOnlyErr
 )
 
 // Error is an error code and a wrapped error.
@@ -109,6 +110,7 @@ func UnwrapErr(err error) error {
 
 var (
 	ErrAccountSuspended = errors.New("may not trade while account is suspended")
+	ErrReadOnly         = errors.New("not permitted in read-only mode")
 )
 
 // WalletNoPeersError should be returned when a wallet has no network peers.
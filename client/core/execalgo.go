@@ -0,0 +1,663 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/order"
+)
+
+// Execution algorithms.
+const (
+	// ExecAlgoTWAP splits the parent order into equal slices that are placed
+	// at regular intervals over the duration of the execution.
+	ExecAlgoTWAP = "twap"
+	// ExecAlgoIceberg only shows a visible slice of the parent order on the
+	// book at a time. The next slice is placed when the previous one is
+	// filled or canceled.
+	ExecAlgoIceberg = "iceberg"
+)
+
+// Execution statuses.
+const (
+	ExecutionStatusActive    = "active"
+	ExecutionStatusCompleted = "completed"
+	ExecutionStatusCanceled  = "canceled"
+)
+
+// execTickInterval is the longest time between checks of an execution's child
+// orders.
+var execTickInterval = 15 * time.Second
+
+// finishedExecutionRetention is how long completed and canceled executions are
+// kept before they are deleted.
+const finishedExecutionRetention = 30 * 24 * time.Hour
+
+// ExecutionForm is the information necessary to start an execution.
+type ExecutionForm struct {
+	Algo  string `json:"algo"`
+	Host  string `json:"host"`
+	Sell  bool   `json:"sell"`
+	Base  uint32 `json:"base"`
+	Quote uint32 `json:"quote"`
+	// Qty is the quantity of the parent order, in units of the base asset.
+	Qty uint64 `json:"qty"`
+	// Rate is the limit rate of the parent order. Child orders are placed at
+	// the book's mid-gap, but never at a worse rate than this. If zero, child
+	// orders follow the mid-gap without a limit.
+	Rate uint64 `json:"rate"`
+	// Duration is the time over which a TWAP execution is spread, in
+	// milliseconds.
+	Duration uint64 `json:"duration,omitempty"`
+	// Slices is the number of child orders of a TWAP execution.
+	Slices uint32 `json:"slices,omitempty"`
+	// VisibleQty is the quantity of the child orders of an iceberg execution.
+	VisibleQty uint64 `json:"visibleQty,omitempty"`
+	// DriftPct is how far, in percent, the child order rate can move away
+	// from the rate of a booked child order before the child order is
+	// canceled and replaced at the new rate. Zero disables repricing.
+	DriftPct float64 `json:"driftPct,omitempty"`
+	// MaxLock is the maximum amount of the "from" asset that the wallet
+	// should lock when funding child orders. See MultiTradeForm.
+	MaxLock uint64            `json:"maxLock,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+func (form *ExecutionForm) validate() error {
+	if form.Qty == 0 {
+		return errors.New("zero quantity")
+	}
+	switch form.Algo {
+	case ExecAlgoTWAP:
+		if form.Slices == 0 {
+			return errors.New("zero slices")
+		}
+		if form.Duration == 0 {
+			return errors.New("zero duration")
+		}
+	case ExecAlgoIceberg:
+		if form.VisibleQty == 0 || form.VisibleQty > form.Qty {
+			return fmt.Errorf("visible quantity %d must be between 1 and the order quantity %d", form.VisibleQty, form.Qty)
+		}
+	default:
+		return fmt.Errorf("unknown execution algorithm %q", form.Algo)
+	}
+	if form.DriftPct < 0 || form.DriftPct >= 100 {
+		return fmt.Errorf("drift percentage must be between 0 and 100, got %.2f", form.DriftPct)
+	}
+	return nil
+}
+
+// ExecutionChild is a child order placed by an execution.
+type ExecutionChild struct {
+	OrderID dex.Bytes `json:"orderID"`
+	Qty     uint64    `json:"qty"`
+	Rate    uint64    `json:"rate"`
+	Filled  uint64    `json:"filled"`
+	// Repriced is true if the order was canceled because the rate drifted.
+	Repriced bool `json:"repriced,omitempty"`
+	// Done is true when the order is no longer on the book.
+	Done bool `json:"done,omitempty"`
+}
+
+// committed is the quantity of the parent order that the child order
+// accounts for. The unfilled quantity of a child order is released when the
+// child order is done.
+func (ch *ExecutionChild) committed() uint64 {
+	if ch.Done {
+		return ch.Filled
+	}
+	return ch.Qty
+}
+
+// Execution is a parent order that is executed over time as child limit
+// orders.
+type Execution struct {
+	ExecutionForm
+	ID     dex.Bytes `json:"id"`
+	Status string    `json:"status"`
+	// Stamp is the time the execution was started, in milliseconds.
+	Stamp uint64 `json:"stamp"`
+	// EndStamp is the time the execution was completed or canceled, in
+	// milliseconds.
+	EndStamp uint64 `json:"endStamp,omitempty"`
+	// Filled is the filled quantity of all child orders.
+	Filled   uint64            `json:"filled"`
+	Children []*ExecutionChild `json:"children"`
+	// PlaceError is the error from the most recent failed attempt to place
+	// child orders. It is cleared when child orders are placed.
+	PlaceError string `json:"placeError,omitempty"`
+}
+
+func (e *Execution) copy() *Execution {
+	exec := *e
+	exec.Children = make([]*ExecutionChild, 0, len(e.Children))
+	for _, ch := range e.Children {
+		child := *ch
+		exec.Children = append(exec.Children, &child)
+	}
+	return &exec
+}
+
+func (e *Execution) active() bool {
+	return e.Status == ExecutionStatusActive
+}
+
+func (e *Execution) marketName() string {
+	return marketName(e.Base, e.Quote)
+}
+
+// expired is true if the execution finished longer than
+// finishedExecutionRetention ago.
+func (e *Execution) expired(now time.Time) bool {
+	if e.active() {
+		return false
+	}
+	end := e.EndStamp
+	if end == 0 {
+		end = e.Stamp
+	}
+	return now.Sub(time.UnixMilli(int64(end))) > finishedExecutionRetention
+}
+
+// sliceQty is the quantity of a TWAP slice. Any remainder is added to the
+// last slice.
+func (e *Execution) sliceQty(lotSize uint64) uint64 {
+	lots := e.Qty / lotSize / uint64(e.Slices)
+	if lots == 0 {
+		lots = 1
+	}
+	return lots * lotSize
+}
+
+// sliceInterval is the time between TWAP slices.
+func (e *Execution) sliceInterval() time.Duration {
+	return time.Duration(e.Duration) * time.Millisecond / time.Duration(e.Slices)
+}
+
+// scheduledQty is the quantity of a TWAP execution that should have been
+// placed by now. The first slice is due when the execution starts.
+func (e *Execution) scheduledQty(now time.Time, lotSize uint64) uint64 {
+	elapsed := now.Sub(time.UnixMilli(int64(e.Stamp)))
+	slices := uint64(e.Slices)
+	if interval := e.sliceInterval(); interval > 0 && elapsed >= 0 {
+		slices = min(uint64(elapsed/interval)+1, slices)
+	}
+	if slices == uint64(e.Slices) {
+		return e.Qty
+	}
+	return min(slices*e.sliceQty(lotSize), e.Qty)
+}
+
+// dueQty is the quantity that should be placed as new child orders now.
+func (e *Execution) dueQty(now time.Time, children []*ExecutionChild, lotSize uint64) uint64 {
+	var committed uint64
+	var open int
+	for _, ch := range children {
+		committed += ch.committed()
+		if !ch.Done {
+			open++
+		}
+	}
+	var qty uint64
+	switch e.Algo {
+	case ExecAlgoTWAP:
+		if sched := e.scheduledQty(now, lotSize); sched > committed {
+			qty = sched - committed
+		}
+	case ExecAlgoIceberg:
+		if open == 0 && e.Qty > committed {
+			qty = min(e.VisibleQty, e.Qty-committed)
+		}
+	}
+	return qty - qty%lotSize
+}
+
+// placements splits the quantity into child orders. A TWAP execution that is
+// behind schedule, e.g. after a restart, places the overdue slices together
+// so that they are funded at once.
+func (e *Execution) placements(qty, rate, lotSize uint64) []*QtyRate {
+	if e.Algo != ExecAlgoTWAP {
+		return []*QtyRate{{Qty: qty, Rate: rate}}
+	}
+	sliceQty := e.sliceQty(lotSize)
+	var placements []*QtyRate
+	for qty >= 2*sliceQty {
+		placements = append(placements, &QtyRate{Qty: sliceQty, Rate: rate})
+		qty -= sliceQty
+	}
+	return append(placements, &QtyRate{Qty: qty, Rate: rate})
+}
+
+// childRate is the rate for new child orders, the mid-gap bounded by the
+// limit rate, and rounded to the rate step away from the spread. Zero is
+// returned if there is neither a mid-gap nor a limit rate.
+func (e *Execution) childRate(midGap, rateStep uint64) uint64 {
+	rate := midGap
+	if e.Rate > 0 && (rate == 0 || (e.Sell && rate < e.Rate) || (!e.Sell && rate > e.Rate)) {
+		rate = e.Rate
+	}
+	if rate == 0 || rateStep == 0 {
+		return rate
+	}
+	if e.Sell && rate%rateStep != 0 {
+		rate += rateStep - rate%rateStep
+	}
+	rate -= rate % rateStep
+	if rate == 0 {
+		rate = rateStep
+	}
+	return rate
+}
+
+// drifted checks whether the child order's rate is more than DriftPct
+// percent away from the rate.
+func (e *Execution) drifted(ch *ExecutionChild, rate uint64) bool {
+	if e.DriftPct == 0 || rate == 0 {
+		return false
+	}
+	return math.Abs(float64(ch.Rate)-float64(rate))/float64(rate)*100 > e.DriftPct
+}
+
+// execRunner is the goroutine that runs an active execution.
+type execRunner struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// storeExecution saves the execution to the DB. The execMtx must be held.
+func (c *Core) storeExecution(e *Execution) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error encoding execution: %w", err)
+	}
+	return c.db.UpdateExecution(e.ID, b)
+}
+
+// pruneExecutions deletes the executions that finished longer than
+// finishedExecutionRetention ago. The execMtx must be held.
+func (c *Core) pruneExecutions() {
+	now := time.Now()
+	for id, e := range c.executions {
+		if !e.expired(now) {
+			continue
+		}
+		if err := c.db.DeleteExecution(e.ID); err != nil {
+			c.log.Errorf("Error deleting execution %s: %v", e.ID, err)
+			continue
+		}
+		delete(c.executions, id)
+	}
+}
+
+// loadExecutions loads the executions from the DB and resumes the active
+// ones. Expired executions are deleted.
+func (c *Core) loadExecutions() error {
+	encExecs, err := c.db.Executions()
+	if err != nil {
+		return fmt.Errorf("error retrieving executions: %w", err)
+	}
+	c.execMtx.Lock()
+	defer c.execMtx.Unlock()
+	c.executions = make(map[string]*Execution, len(encExecs))
+	for _, b := range encExecs {
+		e := new(Execution)
+		if err := json.Unmarshal(b, e); err != nil {
+			c.log.Errorf("Error decoding execution: %v", err)
+			continue
+		}
+		c.executions[e.ID.String()] = e
+		if e.active() {
+			c.startExecutionRunner(e)
+		}
+	}
+	c.pruneExecutions()
+	return nil
+}
+
+// StartExecution starts executing a parent order as child limit orders using
+// the specified algorithm. The wallets for the market are unlocked now, and
+// must remain unlocked while the execution is active.
+func (c *Core) StartExecution(pw []byte, form *ExecutionForm) (*Execution, error) {
+	if err := c.checkReadOnly(); err != nil {
+		return nil, err
+	}
+	if err := form.validate(); err != nil {
+		return nil, newError(orderParamsErr, "invalid execution: %v", err)
+	}
+	crypter, err := c.encryptionKey(pw)
+	if err != nil {
+		return nil, codedError(passwordErr, err)
+	}
+	defer crypter.Close()
+
+	dc, err := c.registeredDEX(form.Host)
+	if err != nil {
+		return nil, err
+	}
+	mktID := marketName(form.Base, form.Quote)
+	mktConf := dc.marketConfig(mktID)
+	if mktConf == nil {
+		return nil, newError(marketErr, "execution for unknown market %q", mktID)
+	}
+	lotSize := mktConf.LotSize
+	if form.Qty%lotSize != 0 {
+		return nil, newError(orderParamsErr, "quantity %d is not a multiple of the lot size %d", form.Qty, lotSize)
+	}
+	if form.Algo == ExecAlgoIceberg && form.VisibleQty%lotSize != 0 {
+		return nil, newError(orderParamsErr, "visible quantity %d is not a multiple of the lot size %d", form.VisibleQty, lotSize)
+	}
+	if form.Algo == ExecAlgoTWAP && form.Qty/uint64(form.Slices) < lotSize {
+		return nil, newError(orderParamsErr, "%d slices of %d is less than one lot per slice", form.Slices, form.Qty)
+	}
+	if err := c.unlockTradeWallets(crypter, dc, form.Base, form.Quote, form.Sell); err != nil {
+		return nil, err
+	}
+
+	e := &Execution{
+		ExecutionForm: *form,
+		ID:            encode.RandomBytes(16),
+		Status:        ExecutionStatusActive,
+		Stamp:         uint64(time.Now().UnixMilli()),
+	}
+
+	c.execMtx.Lock()
+	if err := c.storeExecution(e); err != nil {
+		c.execMtx.Unlock()
+		return nil, err
+	}
+	if c.executions == nil {
+		c.executions = make(map[string]*Execution)
+	}
+	c.executions[e.ID.String()] = e
+	c.startExecutionRunner(e)
+	exec := e.copy()
+	c.execMtx.Unlock()
+
+	subject, details := c.formatDetails(TopicExecutionStarted, e.Algo, mktID, e.Host)
+	c.notify(newExecutionNote(TopicExecutionStarted, subject, details, db.Poke, exec))
+
+	return exec, nil
+}
+
+// Executions returns the executions, newest first.
+func (c *Core) Executions() []*Execution {
+	c.execMtx.RLock()
+	execs := make([]*Execution, 0, len(c.executions))
+	for _, e := range c.executions {
+		execs = append(execs, e.copy())
+	}
+	c.execMtx.RUnlock()
+	sort.Slice(execs, func(i, j int) bool { return execs[i].Stamp > execs[j].Stamp })
+	return execs
+}
+
+// Execution returns the execution with the specified ID.
+func (c *Core) Execution(id dex.Bytes) (*Execution, error) {
+	c.execMtx.RLock()
+	defer c.execMtx.RUnlock()
+	e, found := c.executions[id.String()]
+	if !found {
+		return nil, newError(unknownOrderErr, "unknown execution %s", id)
+	}
+	return e.copy(), nil
+}
+
+// CancelExecution stops an active execution and cancels its booked child
+// orders. Child orders that are already matched are not affected.
+func (c *Core) CancelExecution(id dex.Bytes) error {
+	c.execMtx.Lock()
+	e, found := c.executions[id.String()]
+	if !found {
+		c.execMtx.Unlock()
+		return newError(unknownOrderErr, "unknown execution %s", id)
+	}
+	if !e.active() {
+		c.execMtx.Unlock()
+		return fmt.Errorf("execution %s is %s", id, e.Status)
+	}
+	e.Status = ExecutionStatusCanceled
+	e.EndStamp = uint64(time.Now().UnixMilli())
+	r := c.execRunners[id.String()]
+	delete(c.execRunners, id.String())
+	c.execMtx.Unlock()
+
+	// The runner is the only one that modifies the execution while it is
+	// running.
+	if r != nil {
+		r.cancel()
+		<-r.done
+	}
+
+	for _, ch := range e.Children {
+		if ch.Done || ch.Repriced {
+			continue
+		}
+		if err := c.Cancel(ch.OrderID); err != nil {
+			c.log.Warnf("Error canceling child order %s of execution %s: %v", ch.OrderID, id, err)
+		}
+	}
+
+	c.execMtx.Lock()
+	if err := c.storeExecution(e); err != nil {
+		c.log.Errorf("Error storing execution %s: %v", id, err)
+	}
+	exec := e.copy()
+	c.pruneExecutions()
+	c.execMtx.Unlock()
+
+	subject, details := c.formatDetails(TopicExecutionCanceled, exec.Algo, exec.marketName(), exec.Host)
+	c.notify(newExecutionNote(TopicExecutionCanceled, subject, details, db.Poke, exec))
+	return nil
+}
+
+// startExecutionRunner starts the goroutine that runs the execution. The
+// execMtx must be held.
+func (c *Core) startExecutionRunner(e *Execution) {
+	if c.execRunners == nil {
+		c.execRunners = make(map[string]*execRunner)
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	r := &execRunner{cancel: cancel, done: make(chan struct{})}
+	c.execRunners[e.ID.String()] = r
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(r.done)
+		c.runExecution(ctx, e)
+	}()
+}
+
+// stopExecutions stops running the active executions. The child orders are
+// not canceled.
+func (c *Core) stopExecutions() {
+	c.execMtx.Lock()
+	runners := c.execRunners
+	c.execRunners = nil
+	c.execMtx.Unlock()
+	for _, r := range runners {
+		r.cancel()
+		<-r.done
+	}
+}
+
+// runExecution steps the execution on every tick until it is complete or the
+// context is canceled. The market's book is synced for the mid-gap.
+func (c *Core) runExecution(ctx context.Context, e *Execution) {
+	tick := execTickInterval
+	if e.Algo == ExecAlgoTWAP {
+		if interval := e.sliceInterval(); interval > 0 && interval < tick {
+			tick = interval
+		}
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var feed BookFeed
+	var updates <-chan *BookUpdate
+	defer func() {
+		if feed != nil {
+			feed.Close()
+		}
+	}()
+
+	for {
+		if feed == nil {
+			_, f, err := c.SyncBook(e.Host, e.Base, e.Quote)
+			if err != nil {
+				c.log.Warnf("Unable to sync %s book for execution %s: %v", e.marketName(), e.ID, err)
+			} else {
+				feed, updates = f, f.Next()
+			}
+		}
+		if done := c.stepExecution(e); done {
+			return
+		}
+	wait:
+		for {
+			select {
+			case _, ok := <-updates:
+				// The book is only read when stepping, but the feed must be
+				// drained.
+				if !ok {
+					feed, updates = nil, nil
+				}
+			case <-ticker.C:
+				break wait
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// stepExecution updates the execution's child orders, reprices the booked
+// ones that drifted, and places the child orders that are due. done is true
+// when the execution is complete. Only the execution's runner calls
+// stepExecution.
+func (c *Core) stepExecution(e *Execution) (done bool) {
+	mktID := e.marketName()
+	dc, _, err := c.dex(e.Host)
+	if err != nil {
+		c.log.Errorf("Error stepping execution %s: %v", e.ID, err)
+		return false
+	}
+	mktConf := dc.marketConfig(mktID)
+	if mktConf == nil {
+		c.log.Warnf("Market %s for execution %s not found at %s", mktID, e.ID, e.Host)
+		return false
+	}
+	lotSize := mktConf.LotSize
+
+	var midGap uint64
+	if booky := dc.bookie(mktID); booky != nil {
+		midGap, _ = booky.MidGap()
+	}
+	rate := e.childRate(midGap, mktConf.RateStep)
+
+	children := make([]*ExecutionChild, 0, len(e.Children))
+	var filled uint64
+	for _, ch := range e.Children {
+		child := *ch
+		children = append(children, &child)
+		if !child.Done {
+			if ord, err := c.Order(child.OrderID); err != nil {
+				c.log.Warnf("Error retrieving child order %s of execution %s: %v", child.OrderID, e.ID, err)
+			} else {
+				child.Filled = ord.Filled
+				child.Done = ord.Status > order.OrderStatusBooked
+				if ord.Status == order.OrderStatusBooked && !ord.Cancelling && !child.Repriced && e.drifted(&child, rate) {
+					c.log.Infof("Repricing child order %s of execution %s from %d to %d", child.OrderID, e.ID, child.Rate, rate)
+					if err := c.Cancel(child.OrderID); err != nil {
+						c.log.Warnf("Error canceling child order %s of execution %s: %v", child.OrderID, e.ID, err)
+					} else {
+						child.Repriced = true
+					}
+				}
+			}
+		}
+		filled += child.Filled
+	}
+
+	complete := e.Qty-min(filled, e.Qty) < lotSize
+	var placeErr error
+	var placed bool
+	if qty := e.dueQty(time.Now(), children, lotSize); !complete && qty > 0 && rate > 0 {
+		placements := e.placements(qty, rate, lotSize)
+		results := c.MultiTrade(nil, &MultiTradeForm{
+			Host:       e.Host,
+			Sell:       e.Sell,
+			Base:       e.Base,
+			Quote:      e.Quote,
+			Placements: placements,
+			Options:    e.Options,
+			MaxLock:    e.MaxLock,
+		})
+		for i, res := range results {
+			if res.Error != nil {
+				placeErr = res.Error
+				continue
+			}
+			children = append(children, &ExecutionChild{
+				OrderID: res.Order.ID,
+				Qty:     placements[i].Qty,
+				Rate:    rate,
+			})
+			placed = true
+		}
+	}
+
+	c.execMtx.Lock()
+	progressed := filled != e.Filled
+	e.Children = children
+	e.Filled = filled
+	// The execution may have been canceled in the meantime.
+	completed := complete && e.active()
+	if completed {
+		e.Status = ExecutionStatusCompleted
+		e.EndStamp = uint64(time.Now().UnixMilli())
+		delete(c.execRunners, e.ID.String())
+	}
+	// Only notify for a failure to place child orders when the error
+	// changes, since the placement is retried on every tick.
+	var newPlaceErr bool
+	if placeErr != nil {
+		newPlaceErr = placeErr.Error() != e.PlaceError
+		e.PlaceError = placeErr.Error()
+	} else if placed {
+		e.PlaceError = ""
+	}
+	if err := c.storeExecution(e); err != nil {
+		c.log.Errorf("Error storing execution %s: %v", e.ID, err)
+	}
+	exec := e.copy()
+	if completed {
+		c.pruneExecutions()
+	}
+	c.execMtx.Unlock()
+
+	if newPlaceErr {
+		subject, details := c.formatDetails(TopicExecutionOrderFailed, e.Algo, mktID, e.Host, placeErr)
+		c.notify(newExecutionNote(TopicExecutionOrderFailed, subject, details, db.WarningLevel, exec))
+	}
+	if completed {
+		subject, details := c.formatDetails(TopicExecutionCompleted, e.Algo, mktID, e.Host)
+		c.notify(newExecutionNote(TopicExecutionCompleted, subject, details, db.Success, exec))
+	} else if progressed {
+		pct := float64(filled) / float64(e.Qty) * 100
+		subject, details := c.formatDetails(TopicExecutionProgress, e.Algo, mktID, e.Host, pct)
+		c.notify(newExecutionNote(TopicExecutionProgress, subject, details, db.Data, exec))
+	}
+	return complete
+}
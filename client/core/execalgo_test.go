@@ -0,0 +1,453 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
+)
+
+func TestExecutionSchedule(t *testing.T) {
+	const lotSize = 100
+	start := time.Now()
+	twap := &Execution{
+		ExecutionForm: ExecutionForm{
+			Algo:     ExecAlgoTWAP,
+			Qty:      10 * lotSize,
+			Slices:   3,
+			Duration: uint64(3 * time.Minute / time.Millisecond),
+		},
+		Stamp: uint64(start.UnixMilli()),
+	}
+	iceberg := &Execution{
+		ExecutionForm: ExecutionForm{
+			Algo:       ExecAlgoIceberg,
+			Qty:        10 * lotSize,
+			VisibleQty: 4 * lotSize,
+		},
+		Stamp: uint64(start.UnixMilli()),
+	}
+
+	tests := []struct {
+		name          string
+		exec          *Execution
+		elapsed       time.Duration
+		children      []*ExecutionChild
+		expQty        uint64
+		expPlacements int
+	}{{
+		name:          "twap first slice",
+		exec:          twap,
+		expQty:        3 * lotSize,
+		expPlacements: 1,
+	}, {
+		name:     "twap slice open",
+		exec:     twap,
+		elapsed:  30 * time.Second,
+		children: []*ExecutionChild{{Qty: 3 * lotSize}},
+	}, {
+		name:          "twap second slice",
+		exec:          twap,
+		elapsed:       90 * time.Second,
+		children:      []*ExecutionChild{{Qty: 3 * lotSize}},
+		expQty:        3 * lotSize,
+		expPlacements: 1,
+	}, {
+		name:          "twap replace unfilled",
+		exec:          twap,
+		elapsed:       90 * time.Second,
+		children:      []*ExecutionChild{{Qty: 3 * lotSize, Filled: lotSize, Done: true}},
+		expQty:        5 * lotSize,
+		expPlacements: 1,
+	}, {
+		name:          "twap overdue slices",
+		exec:          twap,
+		elapsed:       time.Hour,
+		expQty:        10 * lotSize,
+		expPlacements: 3,
+	}, {
+		name:          "iceberg first slice",
+		exec:          iceberg,
+		expQty:        4 * lotSize,
+		expPlacements: 1,
+	}, {
+		name:     "iceberg slice open",
+		exec:     iceberg,
+		children: []*ExecutionChild{{Qty: 4 * lotSize, Filled: lotSize}},
+	}, {
+		name:          "iceberg next slice",
+		exec:          iceberg,
+		children:      []*ExecutionChild{{Qty: 4 * lotSize, Filled: 4 * lotSize, Done: true}},
+		expQty:        4 * lotSize,
+		expPlacements: 1,
+	}, {
+		name: "iceberg last slice",
+		exec: iceberg,
+		children: []*ExecutionChild{
+			{Qty: 4 * lotSize, Filled: 4 * lotSize, Done: true},
+			{Qty: 4 * lotSize, Filled: 4 * lotSize, Done: true},
+		},
+		expQty:        2 * lotSize,
+		expPlacements: 1,
+	}}
+
+	for _, tt := range tests {
+		qty := tt.exec.dueQty(start.Add(tt.elapsed), tt.children, lotSize)
+		if qty != tt.expQty {
+			t.Fatalf("%s: expected qty %d, got %d", tt.name, tt.expQty, qty)
+		}
+		if qty == 0 {
+			continue
+		}
+		placements := tt.exec.placements(qty, 1e6, lotSize)
+		if len(placements) != tt.expPlacements {
+			t.Fatalf("%s: expected %d placements, got %d", tt.name, tt.expPlacements, len(placements))
+		}
+		var sum uint64
+		for _, p := range placements {
+			if p.Qty%lotSize != 0 {
+				t.Fatalf("%s: placement qty %d is not a multiple of the lot size", tt.name, p.Qty)
+			}
+			sum += p.Qty
+		}
+		if sum != qty {
+			t.Fatalf("%s: placements add up to %d, not %d", tt.name, sum, qty)
+		}
+	}
+}
+
+func TestExecutionRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sell    bool
+		limit   uint64
+		midGap  uint64
+		expRate uint64
+	}{{
+		name:    "sell rounds up",
+		sell:    true,
+		midGap:  1005,
+		expRate: 1010,
+	}, {
+		name:    "buy rounds down",
+		midGap:  1005,
+		expRate: 1000,
+	}, {
+		name:    "sell limit",
+		sell:    true,
+		limit:   1100,
+		midGap:  1000,
+		expRate: 1100,
+	}, {
+		name:    "sell above limit",
+		sell:    true,
+		limit:   900,
+		midGap:  1000,
+		expRate: 1000,
+	}, {
+		name:    "buy limit",
+		limit:   900,
+		midGap:  1000,
+		expRate: 900,
+	}, {
+		name:    "no mid-gap",
+		limit:   905,
+		expRate: 900,
+	}, {
+		name: "no mid-gap or limit",
+	}}
+	for _, tt := range tests {
+		e := &Execution{ExecutionForm: ExecutionForm{Sell: tt.sell, Rate: tt.limit}}
+		if rate := e.childRate(tt.midGap, 10); rate != tt.expRate {
+			t.Fatalf("%s: expected rate %d, got %d", tt.name, tt.expRate, rate)
+		}
+	}
+
+	e := &Execution{ExecutionForm: ExecutionForm{DriftPct: 5}}
+	if e.drifted(&ExecutionChild{Rate: 1040}, 1000) {
+		t.Fatalf("drifted within threshold")
+	}
+	if !e.drifted(&ExecutionChild{Rate: 940}, 1000) {
+		t.Fatalf("not drifted beyond threshold")
+	}
+}
+
+func TestExecutions(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+
+	execTickInterval = time.Hour
+
+	dcrWallet, tDcrWallet := newTWallet(tUTXOAssetA.ID)
+	tCore.wallets[tUTXOAssetA.ID] = dcrWallet
+	dcrWallet.address = "DsVmA7aqqWeKWy461hXjytbZbgCqbB8g2dq"
+	dcrWallet.Unlock(rig.crypter)
+
+	btcWallet, _ := newTWallet(tUTXOAssetB.ID)
+	tCore.wallets[tUTXOAssetB.ID] = btcWallet
+	btcWallet.address = "12DXGkvxFjuq5btXYkwWfBZaz1rVwFgini"
+	btcWallet.Unlock(rig.crypter)
+
+	lots := func(n uint64) uint64 { return n * dcrBtcLotSize }
+	rate := dcrBtcRateStep * 1000
+
+	tDcrWallet.fundingCoins = asset.Coins{&tCoin{id: encode.RandomBytes(36), val: lots(10)}}
+	tDcrWallet.fundRedeemScripts = []dex.Bytes{nil}
+
+	book := newBookie(rig.dc, tUTXOAssetA.ID, tUTXOAssetB.ID, nil, tLogger)
+	rig.dc.books[tDcrBtcMktName] = book
+	synced := false
+	syncBook := func(sellRate uint64) {
+		t.Helper()
+		sync := book.Sync
+		if synced {
+			sync = book.Reset
+		}
+		synced = true
+		err := sync(&msgjson.OrderBook{
+			MarketID: tDcrBtcMktName,
+			Seq:      1,
+			Epoch:    1,
+			Orders: []*msgjson.BookOrderNote{{
+				OrderNote: msgjson.OrderNote{OrderID: encode.RandomBytes(32)},
+				TradeNote: msgjson.TradeNote{
+					Side:     msgjson.SellOrderNum,
+					Quantity: dcrBtcLotSize,
+					Time:     uint64(time.Now().Unix()),
+					Rate:     sellRate,
+				},
+			}},
+		})
+		if err != nil {
+			t.Fatalf("order book sync error: %v", err)
+		}
+	}
+	syncBook(rate)
+
+	ch := tCore.NotificationFeed()
+	waitForNote := func(topic Topic) *ExecutionNote {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case n := <-ch.C:
+				if note, ok := n.(*ExecutionNote); ok && note.Topic() == topic {
+					return note
+				}
+			case <-timeout:
+				t.Fatalf("no %s notification", topic)
+			}
+		}
+	}
+
+	queueLimit := func() {
+		rig.ws.queueResponse(msgjson.LimitRoute, func(msg *msgjson.Message, f msgFunc) error {
+			msgOrder := new(msgjson.LimitOrder)
+			if err := msg.Unmarshal(msgOrder); err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+			lo := convertMsgLimitOrder(msgOrder)
+			f(orderResponse(msg.ID, msgOrder, lo, false, false, false))
+			return nil
+		})
+	}
+
+	setChildStatus := func(oid dex.Bytes, status order.OrderStatus, filled uint64) {
+		t.Helper()
+		tracker, _ := rig.dc.findOrder(order.OrderID(oid))
+		if tracker == nil {
+			t.Fatalf("child order %s not found", oid)
+		}
+		tracker.mtx.Lock()
+		tracker.metaData.Status = status
+		tracker.Trade().SetFill(filled)
+		tracker.mtx.Unlock()
+	}
+
+	newForm := func() *ExecutionForm {
+		return &ExecutionForm{
+			Algo:       ExecAlgoIceberg,
+			Host:       tDexHost,
+			Sell:       true,
+			Base:       tUTXOAssetA.ID,
+			Quote:      tUTXOAssetB.ID,
+			Qty:        lots(4),
+			VisibleQty: lots(2),
+			DriftPct:   10,
+		}
+	}
+
+	for name, mod := range map[string]func(*ExecutionForm){
+		"unknown algo":       func(f *ExecutionForm) { f.Algo = "vwap" },
+		"zero qty":           func(f *ExecutionForm) { f.Qty = 0 },
+		"visible > qty":      func(f *ExecutionForm) { f.VisibleQty = lots(5) },
+		"twap no slices":     func(f *ExecutionForm) { f.Algo = ExecAlgoTWAP; f.Duration = 1000 },
+		"twap no duration":   func(f *ExecutionForm) { f.Algo = ExecAlgoTWAP; f.Slices = 2 },
+		"twap sub-lot slice": func(f *ExecutionForm) { f.Algo = ExecAlgoTWAP; f.Slices = 5; f.Duration = 1000 },
+		"bad drift":          func(f *ExecutionForm) { f.DriftPct = 100 },
+		"partial lot":        func(f *ExecutionForm) { f.Qty++ },
+		"partial lot slice":  func(f *ExecutionForm) { f.VisibleQty++ },
+		"unknown market":     func(f *ExecutionForm) { f.Quote = 12345 },
+	} {
+		form := newForm()
+		mod(form)
+		if _, err := tCore.StartExecution(tPW, form); err == nil {
+			t.Fatalf("%s: no error", name)
+		}
+	}
+
+	rig.crypter.(*tCrypter).recryptErr = tErr
+	if _, err := tCore.StartExecution(tPW, newForm()); !errorHasCode(err, passwordErr) {
+		t.Fatalf("wrong error for password error: %v", err)
+	}
+	rig.crypter.(*tCrypter).recryptErr = nil
+
+	// The first slice of the iceberg is placed right away.
+	queueLimit()
+	iceberg, err := tCore.StartExecution(tPW, newForm())
+	if err != nil {
+		t.Fatalf("StartExecution error: %v", err)
+	}
+	waitForNote(TopicExecutionStarted)
+	var exec *Execution
+	for i := 0; i < 100; i++ {
+		if exec, _ = tCore.Execution(iceberg.ID); len(exec.Children) > 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(exec.Children) != 1 || exec.Children[0].Qty != lots(2) || exec.Children[0].Rate != rate {
+		t.Fatalf("wrong first slice %+v", exec.Children)
+	}
+
+	tCore.execMtx.RLock()
+	e := tCore.executions[iceberg.ID.String()]
+	tCore.execMtx.RUnlock()
+
+	// Nothing happens while the slice is on the book.
+	setChildStatus(exec.Children[0].OrderID, order.OrderStatusBooked, 0)
+	tCore.stepExecution(e)
+	if exec, _ = tCore.Execution(iceberg.ID); len(exec.Children) != 1 {
+		t.Fatalf("new slice placed while the first is booked")
+	}
+
+	// The next slice is placed when the first is filled.
+	setChildStatus(exec.Children[0].OrderID, order.OrderStatusExecuted, lots(2))
+	queueLimit()
+	tCore.stepExecution(e)
+	note := waitForNote(TopicExecutionProgress)
+	if note.Execution.Filled != lots(2) || len(note.Execution.Children) != 2 || !note.Execution.Children[0].Done {
+		t.Fatalf("wrong execution progress %+v", note.Execution)
+	}
+
+	// The second slice is repriced when the mid-gap moves.
+	secondID := note.Execution.Children[1].OrderID
+	setChildStatus(secondID, order.OrderStatusBooked, 0)
+	syncBook(rate * 2)
+	rig.queueCancel(nil)
+	tCore.stepExecution(e)
+	if exec, _ = tCore.Execution(iceberg.ID); !exec.Children[1].Repriced {
+		t.Fatalf("drifted child order not repriced")
+	}
+
+	// The canceled slice is replaced at the new rate.
+	setChildStatus(secondID, order.OrderStatusCanceled, 0)
+	queueLimit()
+	tCore.stepExecution(e)
+	if exec, _ = tCore.Execution(iceberg.ID); len(exec.Children) != 3 || exec.Children[2].Rate != rate*2 {
+		t.Fatalf("repriced child order not replaced %+v", exec.Children)
+	}
+
+	// The execution completes when the parent order is filled.
+	setChildStatus(exec.Children[2].OrderID, order.OrderStatusExecuted, lots(2))
+	if done := tCore.stepExecution(e); !done {
+		t.Fatalf("execution not done")
+	}
+	note = waitForNote(TopicExecutionCompleted)
+	if note.Execution.Status != ExecutionStatusCompleted || note.Execution.Filled != lots(4) {
+		t.Fatalf("wrong completed execution %+v", note.Execution)
+	}
+	if err := tCore.CancelExecution(iceberg.ID); err == nil {
+		t.Fatalf("no error canceling completed execution")
+	}
+
+	// A TWAP that can't place its first slice notifies and keeps running
+	// until it is canceled.
+	form := newForm()
+	form.Algo = ExecAlgoTWAP
+	form.Slices = 2
+	form.Duration = uint64(time.Hour / time.Millisecond)
+	twap, err := tCore.StartExecution(tPW, form)
+	if err != nil {
+		t.Fatalf("StartExecution error: %v", err)
+	}
+	waitForNote(TopicExecutionOrderFailed)
+
+	// Retrying the placement with the same error doesn't notify again.
+	tCore.execMtx.RLock()
+	e = tCore.executions[twap.ID.String()]
+	tCore.execMtx.RUnlock()
+	tCore.stepExecution(e)
+	if exec, _ = tCore.Execution(twap.ID); exec.PlaceError == "" {
+		t.Fatalf("placement error not recorded")
+	}
+	timeout := time.After(100 * time.Millisecond)
+noteLoop:
+	for {
+		select {
+		case n := <-ch.C:
+			if note, ok := n.(*ExecutionNote); ok && note.Topic() == TopicExecutionOrderFailed {
+				t.Fatalf("repeated placement failure notification")
+			}
+		case <-timeout:
+			break noteLoop
+		}
+	}
+
+	if err := tCore.CancelExecution(twap.ID); err != nil {
+		t.Fatalf("CancelExecution error: %v", err)
+	}
+	note = waitForNote(TopicExecutionCanceled)
+	if note.Execution.Status != ExecutionStatusCanceled {
+		t.Fatalf("wrong canceled execution status %s", note.Execution.Status)
+	}
+	if err := tCore.CancelExecution(twap.ID); err == nil {
+		t.Fatalf("no error canceling canceled execution")
+	}
+
+	// Executions are reloaded from the DB.
+	tCore.stopExecutions()
+	if err := tCore.loadExecutions(); err != nil {
+		t.Fatalf("loadExecutions error: %v", err)
+	}
+	execs := tCore.Executions()
+	if len(execs) != 2 || execs[0].Status != ExecutionStatusCanceled || execs[1].Status != ExecutionStatusCompleted {
+		t.Fatalf("wrong executions loaded")
+	}
+	if len(tCore.execRunners) != 0 {
+		t.Fatalf("running inactive executions")
+	}
+
+	// Executions that finished long ago are deleted.
+	tCore.execMtx.Lock()
+	e = tCore.executions[iceberg.ID.String()]
+	e.EndStamp = uint64(time.Now().Add(-finishedExecutionRetention - time.Hour).UnixMilli())
+	tCore.storeExecution(e)
+	tCore.execMtx.Unlock()
+	if err := tCore.loadExecutions(); err != nil {
+		t.Fatalf("loadExecutions error: %v", err)
+	}
+	if _, err := tCore.Execution(iceberg.ID); err == nil {
+		t.Fatalf("expired execution not pruned")
+	}
+	if encExecs, _ := rig.db.Executions(); len(encExecs) != 1 {
+		t.Fatalf("expected 1 stored execution after pruning, got %d", len(encExecs))
+	}
+}
@@ -0,0 +1,509 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/calc"
+	"decred.org/dcrdex/dex/order"
+)
+
+// LedgerFormat is the CSV layout of an exported trade ledger.
+type LedgerFormat string
+
+const (
+	// LedgerFormatDefault includes every field of the LedgerEntry.
+	LedgerFormatDefault LedgerFormat = "default"
+	// LedgerFormatKoinly is the Koinly universal import format.
+	LedgerFormatKoinly LedgerFormat = "koinly"
+	// LedgerFormatCoinTracking is the CoinTracking CSV import format.
+	LedgerFormatCoinTracking LedgerFormat = "cointracking"
+)
+
+// LedgerFormats are the supported ledger export formats.
+var LedgerFormats = []LedgerFormat{LedgerFormatDefault, LedgerFormatKoinly, LedgerFormatCoinTracking}
+
+// LedgerFilter is used to select the trades in a ledger.
+type LedgerFilter struct {
+	// Hosts is a list of acceptable hosts. A zero-length Hosts means all
+	// hosts are accepted.
+	Hosts []string `json:"hosts"`
+	// Assets is a list of BIP IDs for acceptable assets. A zero-length Assets
+	// means all assets are accepted.
+	Assets []uint32 `json:"assets"`
+	// Since and Until limit the ledger to matches made in the time range (ms
+	// UNIX), inclusive. Zero values do not limit the range.
+	Since uint64 `json:"since"`
+	Until uint64 `json:"until"`
+}
+
+// LedgerEntry is a single settled fill of a trade. Amounts are in atomic
+// units, and fiat values are in USD at the time of the match. Fiat values are
+// zero if the rate for an asset was not recorded when the match was made.
+type LedgerEntry struct {
+	Stamp    uint64          `json:"stamp"`
+	Host     string          `json:"host"`
+	BaseID   uint32          `json:"baseID"`
+	QuoteID  uint32          `json:"quoteID"`
+	OrderID  dex.Bytes       `json:"orderID"`
+	MatchID  dex.Bytes       `json:"matchID"`
+	Sell     bool            `json:"sell"`
+	Side     order.MatchSide `json:"side"`
+	Rate     uint64          `json:"rate"`
+	Qty      uint64          `json:"qty"`
+	Refunded bool            `json:"refunded"`
+	// Sent and Received are the amounts that were traded. If the match was
+	// refunded, Sent is zero, and Received is zero unless the counterparty's
+	// swap was also redeemed.
+	SentAssetID     uint32 `json:"sentAssetID"`
+	Sent            uint64 `json:"sent"`
+	ReceivedAssetID uint32 `json:"receivedAssetID"`
+	Received        uint64 `json:"received"`
+	// SwapFees and RefundFees are paid in SwapFeeAssetID, and RedeemFees are
+	// paid in RedeemFeeAssetID. Swap and redemption fees are the order's fees
+	// apportioned to the match by the amount swapped or redeemed.
+	SwapFeeAssetID   uint32 `json:"swapFeeAssetID"`
+	SwapFees         uint64 `json:"swapFees"`
+	RefundFees       uint64 `json:"refundFees"`
+	RedeemFeeAssetID uint32 `json:"redeemFeeAssetID"`
+	RedeemFees       uint64 `json:"redeemFees"`
+
+	SentFiatValue     float64 `json:"sentFiatValue"`
+	ReceivedFiatValue float64 `json:"receivedFiatValue"`
+	FeesFiatValue     float64 `json:"feesFiatValue"`
+	// CostBasis is the fiat cost of the received amount, which is the value of
+	// the sent amount plus all fees.
+	CostBasis float64 `json:"costBasis"`
+}
+
+// feeAssetID is the asset that pays the network fees of an asset's
+// transactions. This is the parent asset for tokens.
+func feeAssetID(assetID uint32) uint32 {
+	if tkn := asset.TokenInfo(assetID); tkn != nil {
+		return tkn.ParentID
+	}
+	return assetID
+}
+
+// Ledger returns the settled fills of the user's trades, oldest first. Fills
+// are the non-cancel matches that were redeemed or refunded.
+func (c *Core) Ledger(filter *LedgerFilter) ([]*LedgerEntry, error) {
+	ords, err := c.db.Orders(&db.OrderFilter{
+		Hosts:  filter.Hosts,
+		Assets: filter.Assets,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving orders: %w", err)
+	}
+
+	entries := make([]*LedgerEntry, 0, len(ords))
+	for _, mOrd := range ords {
+		matches, err := c.db.MatchesForOrder(mOrd.Order.ID(), true)
+		if err != nil {
+			return nil, fmt.Errorf("error loading matches for order %s: %w", mOrd.Order.ID(), err)
+		}
+		for _, e := range c.ledgerEntries(mOrd, matches) {
+			if (filter.Since > 0 && e.Stamp < filter.Since) || (filter.Until > 0 && e.Stamp > filter.Until) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Stamp < entries[j].Stamp
+	})
+	return entries, nil
+}
+
+// ledgerEntries creates the ledger entries for the settled matches of an
+// order.
+func (c *Core) ledgerEntries(mOrd *db.MetaOrder, matches []*db.MetaMatch) []*LedgerEntry {
+	ord, md := mOrd.Order, mOrd.MetaData
+	oid := ord.ID()
+	sell := ord.Trade().Sell
+	fromID, toID := ord.Quote(), ord.Base()
+	if sell {
+		fromID, toID = ord.Base(), ord.Quote()
+	}
+	swapFeeAssetID, redeemFeeAssetID := feeAssetID(fromID), feeAssetID(toID)
+
+	type settledMatch struct {
+		*db.MetaMatch
+		sent, received uint64
+		swapped        bool
+		redeemed       bool
+	}
+	var totalSwapped, totalRedeemed uint64
+	settled := make([]*settledMatch, 0, len(matches))
+	for _, m := range matches {
+		proof := &m.MetaData.Proof
+		swapCoin, redeemCoin := proof.TakerSwap, proof.TakerRedeem
+		if m.Side == order.Maker {
+			swapCoin, redeemCoin = proof.MakerSwap, proof.MakerRedeem
+		}
+		sm := &settledMatch{
+			MetaMatch: m,
+			swapped:   len(swapCoin) > 0,
+			redeemed:  len(redeemCoin) > 0,
+		}
+		quoteQty := calc.BaseToQuote(m.Rate, m.Quantity)
+		if sell {
+			sm.sent, sm.received = m.Quantity, quoteQty
+		} else {
+			sm.sent, sm.received = quoteQty, m.Quantity
+		}
+		// The order's fees include those of unsettled matches.
+		if sm.swapped {
+			totalSwapped += sm.sent
+		}
+		if sm.redeemed {
+			totalRedeemed += sm.received
+		}
+		if sm.redeemed || len(proof.RefundCoin) > 0 {
+			settled = append(settled, sm)
+		}
+	}
+
+	entries := make([]*LedgerEntry, 0, len(settled))
+	for _, sm := range settled {
+		m := sm.MetaMatch
+		e := &LedgerEntry{
+			Stamp:            m.MetaData.Stamp,
+			Host:             md.Host,
+			BaseID:           ord.Base(),
+			QuoteID:          ord.Quote(),
+			OrderID:          oid[:],
+			MatchID:          m.MatchID[:],
+			Sell:             sell,
+			Side:             m.Side,
+			Rate:             m.Rate,
+			Qty:              m.Quantity,
+			Refunded:         len(m.MetaData.Proof.RefundCoin) > 0,
+			SentAssetID:      fromID,
+			Sent:             sm.sent,
+			ReceivedAssetID:  toID,
+			SwapFeeAssetID:   swapFeeAssetID,
+			RedeemFeeAssetID: redeemFeeAssetID,
+		}
+		if e.Refunded {
+			e.Sent = 0
+			e.RefundFees = c.refundFees(fromID, m.MetaData.Proof.RefundCoin)
+		}
+		if sm.redeemed {
+			e.Received = sm.received
+			e.RedeemFees = applyFraction(sm.received, totalRedeemed, md.RedemptionFeesPaid)
+		}
+		if sm.swapped {
+			e.SwapFees = applyFraction(sm.sent, totalSwapped, md.SwapFeesPaid)
+		}
+
+		rates := m.MetaData.FiatRates
+		e.SentFiatValue = fiatValue(fromID, e.Sent, rates)
+		e.ReceivedFiatValue = fiatValue(toID, e.Received, rates)
+		e.FeesFiatValue = fiatValue(swapFeeAssetID, e.SwapFees+e.RefundFees, rates) +
+			fiatValue(redeemFeeAssetID, e.RedeemFees, rates)
+		e.CostBasis = e.SentFiatValue + e.FeesFiatValue
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// refundFees looks up the fees paid for a refund transaction in the wallet's
+// transaction history. Zero is returned if the transaction is not found.
+func (c *Core) refundFees(assetID uint32, refundCoin order.CoinID) uint64 {
+	wallet, found := c.wallet(assetID)
+	if !found {
+		return 0
+	}
+	tx, err := wallet.WalletTransaction(c.ctx, hex.EncodeToString(refundCoin))
+	if err != nil {
+		c.log.Debugf("Unable to find %s refund transaction %s for ledger: %v", unbip(assetID), coinIDString(assetID, refundCoin), err)
+		return 0
+	}
+	return tx.Fees
+}
+
+// fiatValue is the fiat value of an amount of an asset at the provided rates.
+func fiatValue(assetID uint32, v uint64, rates map[uint32]float64) float64 {
+	rate := rates[assetID]
+	if rate == 0 || v == 0 {
+		return 0
+	}
+	ui, err := asset.UnitInfo(assetID)
+	if err != nil {
+		return 0
+	}
+	return float64(v) / float64(ui.Conventional.ConversionFactor) * rate
+}
+
+// ledgerAmount formats an amount of an asset in conventional units.
+func ledgerAmount(assetID uint32, v uint64) string {
+	ui, err := asset.UnitInfo(assetID)
+	if err != nil {
+		return strconv.FormatUint(v, 10)
+	}
+	return trimTrailingZeros(ui.ConventionalString(v))
+}
+
+// ledgerCurrency is the ticker symbol of an asset used by tax tools.
+func ledgerCurrency(assetID uint32) string {
+	ui, err := asset.UnitInfo(assetID)
+	if err != nil || ui.Conventional.Unit == "" {
+		return strings.ToUpper(unbip(assetID))
+	}
+	return ui.Conventional.Unit
+}
+
+func ledgerFiat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// ExportLedger writes the ledger of the trades that pass the filter to w as
+// CSV in the specified format.
+func (c *Core) ExportLedger(w io.Writer, filter *LedgerFilter, format LedgerFormat) error {
+	var header []string
+	var rows func(*LedgerEntry) [][]string
+	switch format {
+	case LedgerFormatDefault, "":
+		header, rows = defaultLedgerHeader, defaultLedgerRows
+	case LedgerFormatKoinly:
+		header, rows = koinlyLedgerHeader, koinlyLedgerRows
+	case LedgerFormatCoinTracking:
+		header, rows = coinTrackingLedgerHeader, coinTrackingLedgerRows
+	default:
+		return fmt.Errorf("unknown ledger format %q", format)
+	}
+
+	entries, err := c.Ledger(filter)
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.UseCRLF = runtime.GOOS == "windows"
+	if err = csvWriter.Write(header); err != nil {
+		return fmt.Errorf("error writing ledger CSV: %w", err)
+	}
+	for _, e := range entries {
+		if err = csvWriter.WriteAll(rows(e)); err != nil {
+			return fmt.Errorf("error writing ledger CSV: %w", err)
+		}
+	}
+	csvWriter.Flush()
+	if err = csvWriter.Error(); err != nil {
+		return fmt.Errorf("error writing ledger CSV: %w", err)
+	}
+	return nil
+}
+
+var defaultLedgerHeader = []string{
+	"Time",
+	"Host",
+	"Market",
+	"Order ID",
+	"Match ID",
+	"Side",
+	"Liquidity",
+	"Rate",
+	"Quantity",
+	"Sent",
+	"Sent Currency",
+	"Received",
+	"Received Currency",
+	"Swap Fees",
+	"Refund Fees",
+	"Swap Fees Currency",
+	"Redemption Fees",
+	"Redemption Fees Currency",
+	"Refunded",
+	"Sent Value (USD)",
+	"Received Value (USD)",
+	"Fees Value (USD)",
+	"Cost Basis (USD)",
+}
+
+func defaultLedgerRows(e *LedgerEntry) [][]string {
+	side := "buy"
+	if e.Sell {
+		side = "sell"
+	}
+	var rate string
+	if baseUI, err := asset.UnitInfo(e.BaseID); err == nil {
+		if quoteUI, err := asset.UnitInfo(e.QuoteID); err == nil {
+			rate = strconv.FormatFloat(calc.ConventionalRate(e.Rate, baseUI, quoteUI), 'f', -1, 64)
+		}
+	}
+	if rate == "" {
+		rate = strconv.FormatUint(e.Rate, 10)
+	}
+	return [][]string{{
+		time.UnixMilli(int64(e.Stamp)).UTC().Format(time.RFC3339), // Time
+		e.Host,                              // Host
+		marketName(e.BaseID, e.QuoteID),     // Market
+		e.OrderID.String(),                  // Order ID
+		e.MatchID.String(),                  // Match ID
+		side,                                // Side
+		e.Side.String(),                     // Liquidity
+		rate,                                // Rate
+		ledgerAmount(e.BaseID, e.Qty),       // Quantity
+		ledgerAmount(e.SentAssetID, e.Sent), // Sent
+		ledgerCurrency(e.SentAssetID),       // Sent Currency
+		ledgerAmount(e.ReceivedAssetID, e.Received),    // Received
+		ledgerCurrency(e.ReceivedAssetID),              // Received Currency
+		ledgerAmount(e.SwapFeeAssetID, e.SwapFees),     // Swap Fees
+		ledgerAmount(e.SwapFeeAssetID, e.RefundFees),   // Refund Fees
+		ledgerCurrency(e.SwapFeeAssetID),               // Swap Fees Currency
+		ledgerAmount(e.RedeemFeeAssetID, e.RedeemFees), // Redemption Fees
+		ledgerCurrency(e.RedeemFeeAssetID),             // Redemption Fees Currency
+		strconv.FormatBool(e.Refunded),                 // Refunded
+		ledgerFiat(e.SentFiatValue),                    // Sent Value
+		ledgerFiat(e.ReceivedFiatValue),                // Received Value
+		ledgerFiat(e.FeesFiatValue),                    // Fees Value
+		ledgerFiat(e.CostBasis),                        // Cost Basis
+	}}
+}
+
+// ledgerFee is a network fee paid in a single asset.
+type ledgerFee struct {
+	assetID uint32
+	amt     uint64
+}
+
+// fees are the non-zero fees of the entry. Swap and refund fees are paid in
+// the same asset. The swap fee is listed first.
+func (e *LedgerEntry) fees() []*ledgerFee {
+	fees := make([]*ledgerFee, 0, 2)
+	if amt := e.SwapFees + e.RefundFees; amt > 0 {
+		fees = append(fees, &ledgerFee{e.SwapFeeAssetID, amt})
+	}
+	if e.RedeemFees > 0 {
+		fees = append(fees, &ledgerFee{e.RedeemFeeAssetID, e.RedeemFees})
+	}
+	return fees
+}
+
+var koinlyLedgerHeader = []string{
+	"Date",
+	"Sent Amount",
+	"Sent Currency",
+	"Received Amount",
+	"Received Currency",
+	"Fee Amount",
+	"Fee Currency",
+	"Net Worth Amount",
+	"Net Worth Currency",
+	"Label",
+	"Description",
+	"TxHash",
+}
+
+// koinlyLedgerRows creates a trade row for an entry. A refunded match is not
+// a trade, and its fees are reported as costs. A second fee is reported as a
+// separate cost row, since Koinly has a single fee column.
+func koinlyLedgerRows(e *LedgerEntry) [][]string {
+	date := time.UnixMilli(int64(e.Stamp)).UTC().Format("2006-01-02 15:04:05 UTC")
+	desc := fmt.Sprintf("%s %s match %s", e.Host, marketName(e.BaseID, e.QuoteID), e.MatchID)
+	costRow := func(fee *ledgerFee) []string {
+		return []string{date, ledgerAmount(fee.assetID, fee.amt), ledgerCurrency(fee.assetID),
+			"", "", "", "", "", "", "cost", desc, ""}
+	}
+	fees := e.fees()
+	rows := make([][]string, 0, 2)
+	if e.Sent == 0 && e.Received == 0 {
+		for _, fee := range fees {
+			rows = append(rows, costRow(fee))
+		}
+		return rows
+	}
+	row := make([]string, 0, len(koinlyLedgerHeader))
+	row = append(row, date)
+	if e.Sent > 0 {
+		row = append(row, ledgerAmount(e.SentAssetID, e.Sent), ledgerCurrency(e.SentAssetID))
+	} else {
+		row = append(row, "", "") // refunded, but the counterparty's swap was redeemed
+	}
+	row = append(row, ledgerAmount(e.ReceivedAssetID, e.Received), ledgerCurrency(e.ReceivedAssetID))
+	if len(fees) > 0 {
+		row = append(row, ledgerAmount(fees[0].assetID, fees[0].amt), ledgerCurrency(fees[0].assetID))
+		fees = fees[1:]
+	} else {
+		row = append(row, "", "")
+	}
+	var netWorth, netWorthCurrency string
+	if e.ReceivedFiatValue > 0 {
+		netWorth, netWorthCurrency = ledgerFiat(e.ReceivedFiatValue), "USD"
+	}
+	row = append(row, netWorth, netWorthCurrency, "", desc, "")
+	rows = append(rows, row)
+	for _, fee := range fees {
+		rows = append(rows, costRow(fee))
+	}
+	return rows
+}
+
+var coinTrackingLedgerHeader = []string{
+	"Type",
+	"Buy Amount",
+	"Buy Currency",
+	"Sell Amount",
+	"Sell Currency",
+	"Fee",
+	"Fee Currency",
+	"Exchange",
+	"Trade-Group",
+	"Comment",
+	"Date",
+}
+
+// coinTrackingLedgerRows creates a trade row for an entry. A refunded match is
+// not a trade, and its fees are reported as "Other Fee" rows. A second fee is
+// also reported as an "Other Fee" row, since CoinTracking has a single fee
+// column.
+func coinTrackingLedgerRows(e *LedgerEntry) [][]string {
+	date := time.UnixMilli(int64(e.Stamp)).UTC().Format("2006-01-02 15:04:05")
+	group := marketName(e.BaseID, e.QuoteID)
+	comment := fmt.Sprintf("match %s", e.MatchID)
+	feeRow := func(fee *ledgerFee) []string {
+		return []string{"Other Fee", "", "", ledgerAmount(fee.assetID, fee.amt), ledgerCurrency(fee.assetID),
+			"", "", e.Host, group, comment, date}
+	}
+	fees := e.fees()
+	rows := make([][]string, 0, 2)
+	if e.Sent == 0 {
+		// The swap was refunded. If the counterparty's swap was also redeemed,
+		// the received amount is a deposit.
+		if e.Received > 0 {
+			rows = append(rows, []string{"Deposit", ledgerAmount(e.ReceivedAssetID, e.Received),
+				ledgerCurrency(e.ReceivedAssetID), "", "", "", "", e.Host, group, comment, date})
+		}
+		for _, fee := range fees {
+			rows = append(rows, feeRow(fee))
+		}
+		return rows
+	}
+	var feeAmt, feeCurrency string
+	if len(fees) > 0 {
+		feeAmt, feeCurrency = ledgerAmount(fees[0].assetID, fees[0].amt), ledgerCurrency(fees[0].assetID)
+		fees = fees[1:]
+	}
+	rows = append(rows, []string{"Trade", ledgerAmount(e.ReceivedAssetID, e.Received), ledgerCurrency(e.ReceivedAssetID),
+		ledgerAmount(e.SentAssetID, e.Sent), ledgerCurrency(e.SentAssetID), feeAmt, feeCurrency,
+		e.Host, group, comment, date})
+	for _, fee := range fees {
+		rows = append(rows, feeRow(fee))
+	}
+	return rows
+}
@@ -0,0 +1,135 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/order"
+	ordertest "decred.org/dcrdex/dex/order/test"
+)
+
+func TestLedger(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+
+	const rate = 1e6 // 0.01 BTC/DCR
+	lotSize := dcrBtcLotSize
+	_, dbOrder, _, _ := makeLimitOrder(rig.dc, true, lotSize*3, rate)
+	dbOrder.MetaData.SwapFeesPaid = 300
+	dbOrder.MetaData.RedemptionFeesPaid = 100
+	rig.db.orders = []*db.MetaOrder{dbOrder}
+
+	fiatRates := map[uint32]float64{tUTXOAssetA.ID: 20, tUTXOAssetB.ID: 50_000}
+	newMatch := func(stamp uint64) *db.MetaMatch {
+		m := &db.MetaMatch{
+			UserMatch: ordertest.RandomUserMatch(),
+			MetaData: &db.MatchMetaData{
+				DEX:       tDexHost,
+				Base:      tUTXOAssetA.ID,
+				Quote:     tUTXOAssetB.ID,
+				Stamp:     stamp,
+				FiatRates: fiatRates,
+			},
+		}
+		m.OrderID = dbOrder.Order.ID()
+		m.Side = order.Maker
+		m.Quantity = lotSize
+		m.Rate = rate
+		m.MetaData.Proof.MakerSwap = encode.RandomBytes(36)
+		return m
+	}
+	redeemed := newMatch(2000)
+	redeemed.MetaData.Proof.MakerRedeem = encode.RandomBytes(36)
+	refunded := newMatch(1000)
+	refunded.MetaData.Proof.RefundCoin = encode.RandomBytes(36)
+	unsettled := newMatch(3000)
+	rig.db.matchesForOID = []*db.MetaMatch{redeemed, refunded, unsettled}
+
+	entries, err := tCore.Ledger(&LedgerFilter{})
+	if err != nil {
+		t.Fatalf("Ledger error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 ledger entries, got %d", len(entries))
+	}
+
+	// Oldest first.
+	refundEntry, tradeEntry := entries[0], entries[1]
+	if !refundEntry.Refunded || refundEntry.Sent != 0 || refundEntry.Received != 0 {
+		t.Fatalf("wrong refunded entry %+v", refundEntry)
+	}
+	if refundEntry.SwapFees != 100 || refundEntry.RedeemFees != 0 {
+		t.Fatalf("wrong refunded entry fees, swap = %d, redeem = %d", refundEntry.SwapFees, refundEntry.RedeemFees)
+	}
+
+	const quoteQty = 1e5 // lotSize * rate / 1e8
+	if tradeEntry.Refunded || tradeEntry.Sent != lotSize || tradeEntry.Received != quoteQty {
+		t.Fatalf("wrong trade entry %+v", tradeEntry)
+	}
+	if tradeEntry.SentAssetID != tUTXOAssetA.ID || tradeEntry.ReceivedAssetID != tUTXOAssetB.ID {
+		t.Fatalf("wrong trade entry assets %d -> %d", tradeEntry.SentAssetID, tradeEntry.ReceivedAssetID)
+	}
+	// The swap fees are apportioned to all three swaps, but only one match
+	// was redeemed.
+	if tradeEntry.SwapFees != 100 || tradeEntry.RedeemFees != 100 {
+		t.Fatalf("wrong trade entry fees, swap = %d, redeem = %d", tradeEntry.SwapFees, tradeEntry.RedeemFees)
+	}
+	floatsEqual := func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-9
+	}
+	sentValue, receivedValue := 0.1*20, 0.001*50_000
+	feesValue := 100e-8*20 + 100e-8*50_000
+	if !floatsEqual(tradeEntry.SentFiatValue, sentValue) || !floatsEqual(tradeEntry.ReceivedFiatValue, receivedValue) ||
+		!floatsEqual(tradeEntry.FeesFiatValue, feesValue) || !floatsEqual(tradeEntry.CostBasis, sentValue+feesValue) {
+		t.Fatalf("wrong trade entry fiat values %+v", tradeEntry)
+	}
+
+	entries, err = tCore.Ledger(&LedgerFilter{Since: 1500})
+	if err != nil {
+		t.Fatalf("Ledger error: %v", err)
+	}
+	if len(entries) != 1 || !bytes.Equal(entries[0].MatchID, redeemed.MatchID[:]) {
+		t.Fatalf("wrong entries for time filter")
+	}
+
+	// A match without fiat rates has no fiat values.
+	redeemed.MetaData.FiatRates = nil
+	entries, _ = tCore.Ledger(&LedgerFilter{Since: 1500})
+	if entries[0].CostBasis != 0 {
+		t.Fatalf("cost basis without fiat rates")
+	}
+	redeemed.MetaData.FiatRates = fiatRates
+
+	for _, tt := range []struct {
+		format LedgerFormat
+		rows   int // including the header
+	}{
+		{LedgerFormatDefault, 3},
+		// The trade's redemption fee is a separate row.
+		{LedgerFormatKoinly, 4},
+		{LedgerFormatCoinTracking, 4},
+	} {
+		var b bytes.Buffer
+		if err := tCore.ExportLedger(&b, &LedgerFilter{}, tt.format); err != nil {
+			t.Fatalf("%s: ExportLedger error: %v", tt.format, err)
+		}
+		rows, err := csv.NewReader(&b).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: error reading CSV: %v", tt.format, err)
+		}
+		if len(rows) != tt.rows {
+			t.Fatalf("%s: expected %d rows, got %d", tt.format, tt.rows, len(rows))
+		}
+	}
+
+	if err := tCore.ExportLedger(new(bytes.Buffer), &LedgerFilter{}, "unknown"); err == nil {
+		t.Fatalf("no error for unknown format")
+	}
+}
@@ -129,6 +129,10 @@ var originLocale = map[Topic]*translation{
 		subject:  intl.Translation{T: "Missed cancel"},
 		template: intl.Translation{T: "Cancel order did not match for order %s. This can happen if the cancel order is submitted in the same epoch as the trade or if the target order is fully executed before matching with the cancel order.", Notes: "args: [token]"},
 	},
+	TopicAmendFailed: {
+		subject:  intl.Translation{T: "Amend failed"},
+		template: intl.Translation{T: "The amended order was not canceled, so replacement order %s was not placed. The amended order may have been fully executed before the cancel.", Notes: "args: [token]"},
+	},
 	TopicBuyOrderCanceled: {
 		subject:  intl.Translation{T: "Order canceled"},
 		template: intl.Translation{Version: 1, T: "Buy order on %s-%s at %s has been canceled (%s)", Notes: "args: [base ticker, quote ticker, host, token]"},
@@ -185,6 +189,10 @@ var originLocale = map[Topic]*translation{
 		subject:  intl.Translation{T: "Order revoked"},
 		template: intl.Translation{T: "Order %s on market %s at %s has been revoked by the server", Notes: "args: [token, market name, host]"},
 	},
+	TopicOrderRevokedReason: {
+		subject:  intl.Translation{T: "Order revoked"},
+		template: intl.Translation{T: "Order %s on market %s at %s has been revoked by the server: %s", Notes: "args: [token, market name, host, reason]"},
+	},
 	TopicOrderAutoRevoked: {
 		subject:  intl.Translation{T: "Order auto-revoked"},
 		template: intl.Translation{T: "Order %s on market %s at %s revoked due to market suspension", Notes: "args: [token, market name, host]"},
@@ -258,6 +266,10 @@ var originLocale = map[Topic]*translation{
 		subject:  intl.Translation{T: "Market resumed"},
 		template: intl.Translation{T: "Market %s at %s has resumed trading at epoch %d", Notes: "args: [market name, host, epoch]"},
 	},
+	TopicMarketParamsScheduled: {
+		subject:  intl.Translation{T: "Market lot size change scheduled"},
+		template: intl.Translation{T: "Market %s at %s will change its lot size to %s at %v. Booked orders that do not conform will be revoked.", Notes: "args: [market name, host, lot size, time]"},
+	},
 	TopicUpgradeNeeded: {
 		subject:  intl.Translation{T: "Upgrade needed"},
 		template: intl.Translation{T: "You may need to update your client to trade at %s.", Notes: "args: [host]"},
@@ -355,6 +367,42 @@ var originLocale = map[Topic]*translation{
 			Notes: "args: [bond asset, dex host]",
 		},
 	},
+	TopicConditionalOrderPlaced: {
+		subject:  intl.Translation{T: "Conditional order placed"},
+		template: intl.Translation{T: "A %s order will be placed on the %s market at %s when its condition is met", Notes: "args: [conditional order type, market, host]"},
+	},
+	TopicConditionalOrderTriggered: {
+		subject:  intl.Translation{T: "Conditional order triggered"},
+		template: intl.Translation{T: "The %s condition on the %s market at %s was met. Order %s was placed.", Notes: "args: [conditional order type, market, host, order ID]"},
+	},
+	TopicConditionalOrderFailed: {
+		subject:  intl.Translation{T: "Conditional order failed"},
+		template: intl.Translation{T: "The %s condition on the %s market at %s was met, but the order could not be placed: %v", Notes: "args: [conditional order type, market, host, error]"},
+	},
+	TopicConditionalOrderCanceled: {
+		subject:  intl.Translation{T: "Conditional order canceled"},
+		template: intl.Translation{T: "The %s order on the %s market at %s was canceled", Notes: "args: [conditional order type, market, host]"},
+	},
+	TopicExecutionStarted: {
+		subject:  intl.Translation{T: "Execution started"},
+		template: intl.Translation{T: "A %s execution on the %s market at %s was started", Notes: "args: [execution algorithm, market, host]"},
+	},
+	TopicExecutionProgress: {
+		subject:  intl.Translation{T: "Execution progress"},
+		template: intl.Translation{T: "The %s execution on the %s market at %s is %.1f%% filled", Notes: "args: [execution algorithm, market, host, percent filled]"},
+	},
+	TopicExecutionOrderFailed: {
+		subject:  intl.Translation{T: "Execution order failed"},
+		template: intl.Translation{T: "Error placing an order for the %s execution on the %s market at %s: %v", Notes: "args: [execution algorithm, market, host, error]"},
+	},
+	TopicExecutionCompleted: {
+		subject:  intl.Translation{T: "Execution completed"},
+		template: intl.Translation{T: "The %s execution on the %s market at %s is complete", Notes: "args: [execution algorithm, market, host]"},
+	},
+	TopicExecutionCanceled: {
+		subject:  intl.Translation{T: "Execution canceled"},
+		template: intl.Translation{T: "The %s execution on the %s market at %s was canceled", Notes: "args: [execution algorithm, market, host]"},
+	},
 }
 
 var ptBR = map[Topic]*translation{
@@ -43,6 +43,8 @@ const (
 	NoteTypeWalletNote     = "walletnote"
 	NoteTypeReputation     = "reputation"
 	NoteTypeActionRequired = "actionrequired"
+	NoteTypeConditional    = "conditionalorder"
+	NoteTypeExecution      = "execution"
 )
 
 var noteChanCounter uint64
@@ -376,6 +378,7 @@ const (
 	TopicPreimageSent         Topic = "PreimageSent"
 	TopicCancelPreimageSent   Topic = "CancelPreimageSent"
 	TopicMissedCancel         Topic = "MissedCancel"
+	TopicAmendFailed          Topic = "AmendFailed"
 	TopicOrderBooked          Topic = "OrderBooked"
 	TopicNoMatch              Topic = "NoMatch"
 	TopicBuyOrderCanceled     Topic = "BuyOrderCanceled"
@@ -393,6 +396,7 @@ const (
 	TopicMatchesRefunded      Topic = "MatchesRefunded"
 	TopicMatchRevoked         Topic = "MatchRevoked"
 	TopicOrderRevoked         Topic = "OrderRevoked"
+	TopicOrderRevokedReason   Topic = "OrderRevokedReason"
 	TopicOrderAutoRevoked     Topic = "OrderAutoRevoked"
 	TopicMatchRecovered       Topic = "MatchRecovered"
 	TopicCancellingOrder      Topic = "CancellingOrder"
@@ -419,6 +423,47 @@ func newOrderNoteWithTempID(topic Topic, subject, details string, severity db.Se
 	return note
 }
 
+// ConditionalOrderNote is a notification about a conditional order.
+type ConditionalOrderNote struct {
+	db.Notification
+	ConditionalOrder *ConditionalOrder `json:"conditionalOrder"`
+}
+
+const (
+	TopicConditionalOrderPlaced    Topic = "ConditionalOrderPlaced"
+	TopicConditionalOrderTriggered Topic = "ConditionalOrderTriggered"
+	TopicConditionalOrderFailed    Topic = "ConditionalOrderFailed"
+	TopicConditionalOrderCanceled  Topic = "ConditionalOrderCanceled"
+)
+
+func newConditionalOrderNote(topic Topic, subject, details string, severity db.Severity, ord *ConditionalOrder) *ConditionalOrderNote {
+	return &ConditionalOrderNote{
+		Notification:     db.NewNotification(NoteTypeConditional, topic, subject, details, severity),
+		ConditionalOrder: ord,
+	}
+}
+
+// ExecutionNote is a notification about an execution algorithm parent order.
+type ExecutionNote struct {
+	db.Notification
+	Execution *Execution `json:"execution"`
+}
+
+const (
+	TopicExecutionStarted     Topic = "ExecutionStarted"
+	TopicExecutionProgress    Topic = "ExecutionProgress"
+	TopicExecutionOrderFailed Topic = "ExecutionOrderFailed"
+	TopicExecutionCompleted   Topic = "ExecutionCompleted"
+	TopicExecutionCanceled    Topic = "ExecutionCanceled"
+)
+
+func newExecutionNote(topic Topic, subject, details string, severity db.Severity, exec *Execution) *ExecutionNote {
+	return &ExecutionNote{
+		Notification: db.NewNotification(NoteTypeExecution, topic, subject, details, severity),
+		Execution:    exec,
+	}
+}
+
 // MatchNote is a notification about a match.
 type MatchNote struct {
 	db.Notification
@@ -661,6 +706,7 @@ const (
 	TopicMarketSuspendedWithPurge Topic = "MarketSuspendedWithPurge"
 	TopicMarketResumeScheduled    Topic = "MarketResumeScheduled"
 	TopicMarketResumed            Topic = "MarketResumed"
+	TopicMarketParamsScheduled    Topic = "MarketParamsScheduled"
 	TopicPenalized                Topic = "Penalized"
 	TopicDEXNotification          Topic = "DEXNotification"
 )
@@ -0,0 +1,244 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/order"
+)
+
+// accountActivityRefreshInterval is how often the account status is requested
+// from the servers in read-only mode. A read-only connection is not sent order
+// or match notifications.
+const accountActivityRefreshInterval = time.Minute
+
+// AccountActivity is the status of an account as reported by the server to a
+// read-only client.
+type AccountActivity struct {
+	Host    string           `json:"host"`
+	Stamp   uint64           `json:"stamp"`
+	Orders  []*ActivityOrder `json:"orders"`
+	Matches []*ActivityMatch `json:"matches"`
+	Bonds   []*ActivityBond  `json:"bonds"`
+	Score   int32            `json:"score"`
+}
+
+// ActivityOrder is an active order reported by the server.
+type ActivityOrder struct {
+	ID     dex.Bytes `json:"id"`
+	Status string    `json:"status"`
+}
+
+// ActivityMatch is an active match reported by the server.
+type ActivityMatch struct {
+	OrderID dex.Bytes `json:"orderID"`
+	MatchID dex.Bytes `json:"matchID"`
+	Qty     uint64    `json:"qty"`
+	Rate    uint64    `json:"rate"`
+	Status  string    `json:"status"`
+	Side    string    `json:"side"`
+}
+
+// ActivityBond is an active bond reported by the server.
+type ActivityBond struct {
+	AssetID  uint32 `json:"assetID"`
+	CoinID   string `json:"coinID"`
+	Amount   uint64 `json:"amount"`
+	Expiry   uint64 `json:"expiry"`
+	Strength uint32 `json:"strength"`
+}
+
+// ReadOnlyAccount is an exported DEX account and its bonds. The account key is
+// needed to authenticate with the server, but a read-only client never uses it
+// to trade.
+type ReadOnlyAccount struct {
+	Account *Account   `json:"account"`
+	Bonds   []*db.Bond `json:"bonds"`
+}
+
+// ReadOnlyExport is everything needed to set up a read-only client.
+type ReadOnlyExport struct {
+	Accounts []*ReadOnlyAccount `json:"accounts"`
+	// WatchOnly is the public descriptors for each wallet that supports
+	// watch-only export, keyed by asset ID.
+	WatchOnly map[uint32][]string `json:"watchOnly"`
+}
+
+// checkReadOnly returns an error if Core is running in read-only mode.
+func (c *Core) checkReadOnly() error {
+	if c.cfg.ReadOnly {
+		return newError(readOnlyErr, "%w", ErrReadOnly)
+	}
+	return nil
+}
+
+// authDEXReadOnly authenticates with the DEX without authorizing the
+// connection for trading, and records the account status reported by the
+// server. Unlike authDEX, no bonds are posted and no orders are canceled or
+// reconciled.
+func (c *Core) authDEXReadOnly(dc *dexConnection) error {
+	result, sigMsg, err := dc.sendConnect(true)
+	if err != nil {
+		return fmt.Errorf("'connect' error: %w", err)
+	}
+	if err = dc.acct.checkSig(sigMsg, result.Sig); err != nil {
+		return newError(signatureErr, "DEX signature validation error: %w", err)
+	}
+
+	act := &AccountActivity{
+		Host:    dc.acct.host,
+		Stamp:   uint64(time.Now().UnixMilli()),
+		Orders:  make([]*ActivityOrder, 0, len(result.ActiveOrderStatuses)),
+		Matches: make([]*ActivityMatch, 0, len(result.ActiveMatches)),
+		Bonds:   make([]*ActivityBond, 0, len(result.ActiveBonds)),
+		Score:   result.Score,
+	}
+	for _, ord := range result.ActiveOrderStatuses {
+		act.Orders = append(act.Orders, &ActivityOrder{
+			ID:     dex.Bytes(ord.ID),
+			Status: order.OrderStatus(ord.Status).String(),
+		})
+	}
+	for _, m := range result.ActiveMatches {
+		act.Matches = append(act.Matches, &ActivityMatch{
+			OrderID: dex.Bytes(m.OrderID),
+			MatchID: dex.Bytes(m.MatchID),
+			Qty:     m.Quantity,
+			Rate:    m.Rate,
+			Status:  order.MatchStatus(m.Status).String(),
+			Side:    order.MatchSide(m.Side).String(),
+		})
+	}
+	for _, b := range result.ActiveBonds {
+		act.Bonds = append(act.Bonds, &ActivityBond{
+			AssetID:  b.AssetID,
+			CoinID:   coinIDString(b.AssetID, b.CoinID),
+			Amount:   b.Amount,
+			Expiry:   b.Expiry,
+			Strength: b.Strength,
+		})
+	}
+
+	dc.activityMtx.Lock()
+	dc.activity = act
+	dc.activityMtx.Unlock()
+
+	dc.acct.authMtx.Lock()
+	if result.Reputation != nil {
+		dc.updateReputation(result.Reputation)
+	}
+	rep := dc.acct.rep
+	dc.acct.isAuthed = true
+	dc.acct.authMtx.Unlock()
+
+	c.log.Debugf("Read-only connection to %s, %d active orders, %d active matches, %d active bonds",
+		dc.acct.host, len(act.Orders), len(act.Matches), len(act.Bonds))
+	c.notify(newReputationNote(dc.acct.host, rep))
+	return nil
+}
+
+// refreshAccountActivity periodically requests the status of each account in
+// read-only mode.
+func (c *Core) refreshAccountActivity(ctx context.Context) {
+	ticker := time.NewTicker(accountActivityRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, dc := range c.dexConnections() {
+				if dc.acct.isViewOnly() || dc.acct.isDisabled() || dc.acct.locked() || dc.IsDown() {
+					continue
+				}
+				if err := c.authDEXReadOnly(dc); err != nil {
+					c.log.Errorf("Error refreshing account status for %s: %v", dc.acct.host, err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// AccountActivity returns the status of the account last reported by the
+// server. Only available in read-only mode, since a trading client tracks its
+// own orders.
+func (c *Core) AccountActivity(host string) (*AccountActivity, error) {
+	if !c.cfg.ReadOnly {
+		return nil, fmt.Errorf("account activity is only available in read-only mode")
+	}
+	dc, _, err := c.dex(host)
+	if err != nil {
+		return nil, err
+	}
+	dc.activityMtx.RLock()
+	defer dc.activityMtx.RUnlock()
+	if dc.activity == nil {
+		return nil, fmt.Errorf("no account status received from %s yet", dc.acct.host)
+	}
+	return dc.activity, nil
+}
+
+// ExportReadOnly exports the DEX accounts and the public descriptors of the
+// wallets that support watch-only export, for import into a read-only client
+// with ImportReadOnly.
+func (c *Core) ExportReadOnly(pw []byte) (*ReadOnlyExport, error) {
+	exp := &ReadOnlyExport{
+		WatchOnly: make(map[uint32][]string),
+	}
+	for _, dc := range c.dexConnections() {
+		if dc.acct.isViewOnly() {
+			continue
+		}
+		acct, bonds, err := c.AccountExport(pw, dc.acct.host)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting account for %s: %w", dc.acct.host, err)
+		}
+		exp.Accounts = append(exp.Accounts, &ReadOnlyAccount{Account: acct, Bonds: bonds})
+	}
+	for _, w := range c.xcWallets() {
+		exporter, ok := w.Wallet.(asset.WatchOnlyExporter)
+		if !ok || !w.connected() {
+			continue
+		}
+		descs, err := exporter.WatchOnlyDescriptors()
+		if err != nil {
+			c.log.Warnf("Unable to export watch-only descriptors for %s: %v", unbip(w.AssetID), err)
+			continue
+		}
+		exp.WatchOnly[w.AssetID] = descs
+	}
+	return exp, nil
+}
+
+// ImportReadOnly imports the accounts and watch-only descriptors exported with
+// ExportReadOnly. The wallets for the watch-only descriptors must already be
+// configured, and must be watch-only wallets, i.e. without private keys.
+func (c *Core) ImportReadOnly(pw []byte, exp *ReadOnlyExport) error {
+	for _, a := range exp.Accounts {
+		if err := c.AccountImport(pw, a.Account, a.Bonds); err != nil {
+			return fmt.Errorf("error importing account for %s: %w", a.Account.Host, err)
+		}
+	}
+	for assetID, descs := range exp.WatchOnly {
+		w, err := c.connectedWallet(assetID)
+		if err != nil {
+			return err
+		}
+		importer, ok := w.Wallet.(asset.WatchOnlyImporter)
+		if !ok {
+			return newError(walletErr, "%s wallet does not support watch-only import", unbip(assetID))
+		}
+		if err := importer.ImportWatchOnlyDescriptors(descs); err != nil {
+			return newError(walletErr, "error importing %s watch-only descriptors: %w", unbip(assetID), err)
+		}
+		c.updateAssetBalance(assetID)
+	}
+	return nil
+}
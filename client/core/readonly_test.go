@@ -0,0 +1,98 @@
+//go:build !harness && !botlive
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/account"
+)
+
+func TestReadOnly(t *testing.T) {
+	rig := newTestRig()
+	defer rig.shutdown()
+	tCore := rig.core
+	tCore.cfg.ReadOnly = true
+
+	_, err := tCore.Trade(tPW, &TradeForm{
+		Host:    tDexHost,
+		IsLimit: true,
+		Sell:    true,
+		Base:    tUTXOAssetA.ID,
+		Quote:   tUTXOAssetB.ID,
+		Qty:     dcrBtcLotSize,
+		Rate:    dcrBtcRateStep,
+	})
+	if !errorHasCode(err, readOnlyErr) {
+		t.Fatalf("wrong error for trade: %v", err)
+	}
+	if _, err := tCore.Send(tPW, tUTXOAssetA.ID, 1e8, "addr", false); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("wrong error for send: %v", err)
+	}
+	if err := tCore.Cancel(encode.RandomBytes(32)); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("wrong error for cancel: %v", err)
+	}
+	if _, err := tCore.PostBond(&PostBondForm{Addr: tDexHost}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("wrong error for post bond: %v", err)
+	}
+
+	if _, err := tCore.AccountActivity(tDexHost); err == nil {
+		t.Fatalf("no error for account activity before connect")
+	}
+
+	oid := encode.RandomBytes(32)
+	match := &msgjson.Match{
+		OrderID:  oid,
+		MatchID:  encode.RandomBytes(32),
+		Quantity: dcrBtcLotSize,
+		Rate:     dcrBtcRateStep,
+		Status:   uint8(order.MakerSwapCast),
+		Side:     uint8(order.Taker),
+	}
+	orders := []*msgjson.OrderStatus{{ID: oid, Status: uint16(order.OrderStatusBooked)}}
+	var readOnly bool
+	rig.ws.queueResponse(msgjson.ConnectRoute, func(msg *msgjson.Message, f msgFunc) error {
+		connect := new(msgjson.Connect)
+		msg.Unmarshal(connect)
+		readOnly = connect.ReadOnly
+		sign(tDexPriv, connect)
+		resp, _ := msgjson.NewResponse(msg.ID, &msgjson.ConnectResult{
+			Sig:                 connect.Sig,
+			ActiveMatches:       []*msgjson.Match{match},
+			ActiveOrderStatuses: orders,
+			Score:               10,
+			Reputation:          &account.Reputation{BondedTier: 1},
+		}, nil)
+		f(resp)
+		return nil
+	})
+	if err := tCore.authDEX(rig.dc); err != nil {
+		t.Fatalf("authDEX error: %v", err)
+	}
+	if !readOnly {
+		t.Fatalf("connect request not flagged read-only")
+	}
+	// The unknown order is not canceled or tracked.
+	if len(rig.dc.trades) != 0 {
+		t.Fatalf("read-only connect added trades")
+	}
+
+	act, err := tCore.AccountActivity(tDexHost)
+	if err != nil {
+		t.Fatalf("AccountActivity error: %v", err)
+	}
+	if len(act.Orders) != 1 || !bytes.Equal(act.Orders[0].ID, oid) || act.Orders[0].Status != order.OrderStatusBooked.String() {
+		t.Fatalf("wrong orders %+v", act.Orders)
+	}
+	if len(act.Matches) != 1 || act.Matches[0].Status != order.MakerSwapCast.String() || act.Matches[0].Side != order.Taker.String() {
+		t.Fatalf("wrong matches %+v", act.Matches)
+	}
+	if act.Score != 10 {
+		t.Fatalf("wrong score %d", act.Score)
+	}
+}
@@ -218,6 +218,9 @@ type trackedCancel struct {
 		maker *msgjson.Match
 		taker *msgjson.Match
 	}
+	// replacement is the replacement order ID if this cancel order is part of
+	// an amend.
+	replacement order.OrderID
 }
 
 type feeStamped struct {
@@ -273,10 +276,11 @@ type trackedTrade struct {
 	lockTimeMaker      time.Duration
 	notify             func(Notification)
 	formatDetails      func(Topic, ...any) (string, string)
-	fromAssetID        uint32            // wallets.fromWallet.AssetID
-	options            map[string]string // metaData.Options (immutable) for Redeem and Swap
-	redemptionReserves uint64            // metaData.RedemptionReserves (immutable)
-	refundReserves     uint64            // metaData.RefundReserves (immutable)
+	fiatRates          func() map[uint32]float64 // may be nil
+	fromAssetID        uint32                    // wallets.fromWallet.AssetID
+	options            map[string]string         // metaData.Options (immutable) for Redeem and Swap
+	redemptionReserves uint64                    // metaData.RedemptionReserves (immutable)
+	refundReserves     uint64                    // metaData.RefundReserves (immutable)
 	preImg             order.Preimage
 
 	csumMtx      sync.RWMutex
@@ -298,6 +302,9 @@ type trackedTrade struct {
 	redemptionLocked uint64 // remaining locked of redemptionReserves
 	refundLocked     uint64 // remaining locked of refundReserves
 	readyToTick      bool   // this will be false if either of the wallets cannot be connected and unlocked
+	// amendFailed is set for the replacement order of an amend if the amend's
+	// cancel order did not execute. The server will not match the order.
+	amendFailed bool
 }
 
 // newTrackedTrade is a constructor for a trackedTrade.
@@ -770,6 +777,27 @@ func (t *trackedTrade) cancelTrade(co *order.CancelOrder, preImg order.Preimage,
 	return nil
 }
 
+// checkCancelable checks that the order can be canceled now. The mtx must be
+// held.
+func (t *trackedTrade) checkCancelable() error {
+	oid := t.ID()
+	if status := t.metaData.Status; status != order.OrderStatusEpoch && status != order.OrderStatusBooked {
+		return fmt.Errorf("order %v not cancellable in status %v", oid, status)
+	}
+
+	if t.cancel != nil {
+		// Existing cancel might be stale. Deleting it now allows this
+		// cancel attempt to proceed.
+		t.deleteStaleCancelOrder()
+
+		if t.cancel != nil {
+			return fmt.Errorf("order %s - only one cancel order can be submitted per order per epoch. "+
+				"still waiting on cancel order %s to match", oid, t.cancel.ID())
+		}
+	}
+	return nil
+}
+
 // nomatch sets the appropriate order status and returns funding coins.
 func (t *trackedTrade) nomatch(oid order.OrderID) (assetMap, error) {
 	assets := make(assetMap)
@@ -789,11 +817,15 @@ func (t *trackedTrade) nomatch(oid order.OrderID) (assetMap, error) {
 			t.dc.log.Errorf("DB error unlinking cancel order %s for trade %s: %v", oid, t.ID(), err)
 		}
 		// Clearing the trackedCancel allows this order to be canceled again.
+		amend := !t.cancel.replacement.IsZero()
 		t.clearCancel(order.Preimage{})
 		t.metaData.LinkedOrder = order.OrderID{}
 
-		subject, details := t.formatDetails(TopicMissedCancel, makeOrderToken(t.token()))
-		t.notify(newOrderNote(TopicMissedCancel, subject, details, db.WarningLevel, t.coreOrderInternal()))
+		// A failed amend is reported by the replacement order.
+		if !amend {
+			subject, details := t.formatDetails(TopicMissedCancel, makeOrderToken(t.token()))
+			t.notify(newOrderNote(TopicMissedCancel, subject, details, db.WarningLevel, t.coreOrderInternal()))
+		}
 		return assets, t.db.UpdateOrderStatus(oid, order.OrderStatusExecuted)
 	}
 
@@ -802,7 +834,7 @@ func (t *trackedTrade) nomatch(oid order.OrderID) (assetMap, error) {
 	if t.metaData.Status != order.OrderStatusEpoch {
 		return assets, fmt.Errorf("nomatch sent for non-epoch order %s", oid)
 	}
-	if lo, ok := t.Order.(*order.LimitOrder); ok && lo.Force == order.StandingTiF {
+	if lo, ok := t.Order.(*order.LimitOrder); ok && lo.Force == order.StandingTiF && !t.amendFailed {
 		t.dc.log.Infof("Standing order %s did not match and is now booked.", t.token())
 		t.metaData.Status = order.OrderStatusBooked
 		t.notify(newOrderNote(TopicOrderBooked, "", "", db.Data, t.coreOrderInternal()))
@@ -811,9 +843,15 @@ func (t *trackedTrade) nomatch(oid order.OrderID) (assetMap, error) {
 		t.unlockRedemptionFraction(1, 1)
 		t.unlockRefundFraction(1, 1)
 		assets.count(t.wallets.fromWallet.AssetID)
-		t.dc.log.Infof("Non-standing order %s did not match.", t.token())
 		t.metaData.Status = order.OrderStatusExecuted
-		t.notify(newOrderNote(TopicNoMatch, "", "", db.Data, t.coreOrderInternal()))
+		if t.amendFailed {
+			t.dc.log.Infof("Replacement order %s of a failed amend was not matched.", t.token())
+			subject, details := t.formatDetails(TopicAmendFailed, makeOrderToken(t.token()))
+			t.notify(newOrderNote(TopicAmendFailed, subject, details, db.WarningLevel, t.coreOrderInternal()))
+		} else {
+			t.dc.log.Infof("Non-standing order %s did not match.", t.token())
+			t.notify(newOrderNote(TopicNoMatch, "", "", db.Data, t.coreOrderInternal()))
+		}
 	}
 	return assets, t.db.UpdateOrderStatus(t.ID(), t.metaData.Status)
 }
@@ -1070,6 +1108,18 @@ func (t *trackedTrade) makeMetaMatch(msgMatch *msgjson.Match) *db.MetaMatch {
 	// 	feeRateSwap = maxFeeRate
 	// }
 
+	// Record the fiat rates at the time of the match for accounting.
+	var fiatRates map[uint32]float64
+	if t.fiatRates != nil {
+		rates := t.fiatRates()
+		fiatRates = make(map[uint32]float64, 4)
+		for _, assetID := range []uint32{t.Base(), t.Quote(), feeAssetID(t.Base()), feeAssetID(t.Quote())} {
+			if rate, found := rates[assetID]; found {
+				fiatRates[assetID] = rate
+			}
+		}
+	}
+
 	var oid order.OrderID
 	copy(oid[:], msgMatch.OrderID)
 	var mid order.MatchID
@@ -1082,10 +1132,11 @@ func (t *trackedTrade) makeMetaMatch(msgMatch *msgjson.Match) *db.MetaMatch {
 					MatchStamp: msgMatch.ServerTime,
 				},
 			},
-			DEX:   t.dc.acct.host,
-			Base:  t.Base(),
-			Quote: t.Quote(),
-			Stamp: msgMatch.ServerTime,
+			DEX:       t.dc.acct.host,
+			Base:      t.Base(),
+			Quote:     t.Quote(),
+			Stamp:     msgMatch.ServerTime,
+			FiatRates: fiatRates,
 		},
 		UserMatch: &order.UserMatch{
 			OrderID:     oid,
@@ -561,6 +561,9 @@ type Market struct {
 	// MinimumRate is the minimum rate allowed for the market, which is the
 	// minimum rate at which 1 lot converts to something greater than dust.
 	MinimumRate uint64 `json:"minimumRate"`
+	// ParamsChange is a lot size and rate step change scheduled by the server,
+	// if any.
+	ParamsChange *msgjson.MarketParamsChange `json:"paramsChange,omitempty"`
 }
 
 // BaseContractLocked is the amount of base asset locked in un-redeemed
@@ -761,6 +764,17 @@ type MarketOrderBook struct {
 	Book  *OrderBook `json:"book"`
 }
 
+// MarketTrade is an anonymized trade from a market's trade tape.
+type MarketTrade struct {
+	// Stamp is the end time (ms) of the epoch in which the trade was matched.
+	Stamp     uint64  `json:"stamp"`
+	Qty       float64 `json:"qty"`
+	QtyAtomic uint64  `json:"qtyAtomic"`
+	Rate      float64 `json:"rate"`
+	MsgRate   uint64  `json:"msgRate"`
+	TakerSell bool    `json:"takerSell"`
+}
+
 type CandleUpdate struct {
 	Dur          string          `json:"dur"`
 	DurMilliSecs uint64          `json:"ms"`
@@ -777,6 +791,8 @@ const (
 	CandleUpdateAction    = "candle_update"
 	EpochMatchSummary     = "epoch_match_summary"
 	EpochResolved         = "epoch_resolved"
+	FreshTradesAction     = "trades"
+	TradeTapeAction       = "trade_tape"
 )
 
 // BookUpdate is an order book update.
@@ -793,6 +809,12 @@ type CandlesPayload struct {
 	Candles      []msgjson.Candle `json:"candles"`
 }
 
+// TradesPayload is the payload of a FreshTradesAction or TradeTapeAction
+// BookUpdate. Trades are newest first.
+type TradesPayload struct {
+	Trades []*MarketTrade `json:"trades"`
+}
+
 type EpochMatchSummaryPayload struct {
 	MatchSummaries []*orderbook.MatchSummary `json:"matchSummaries"`
 	Epoch          uint64                    `json:"epoch"`
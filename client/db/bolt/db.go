@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"slices"
@@ -69,6 +70,9 @@ var (
 	notesBucket           = []byte("notes")
 	pokesBucket           = []byte("pokes")
 	credentialsBucket     = []byte("credentials")
+	apiTokensBucket       = []byte("apiTokens")
+	condOrdersBucket      = []byte("conditionalOrders")
+	executionsBucket      = []byte("executions")
 
 	// value keys
 	versionKey            = []byte("version")
@@ -125,6 +129,7 @@ var (
 	walletDisabledKey     = []byte("walletDisabled")
 	programKey            = []byte("program")
 	langKey               = []byte("lang")
+	fiatRatesKey          = []byte("fiatRates")
 
 	// values
 	byteTrue   = encode.ByteTrue
@@ -183,7 +188,8 @@ func NewDB(dbPath string, logger dex.Logger, opts ...Opts) (dexdb.DB, error) {
 		activeOrdersBucket, archivedOrdersBucket,
 		activeMatchesBucket, archivedMatchesBucket,
 		walletsBucket, notesBucket, credentialsBucket,
-		botProgramsBucket, pokesBucket,
+		botProgramsBucket, pokesBucket, apiTokensBucket, condOrdersBucket,
+		executionsBucket,
 	}); err != nil {
 		return nil, err
 	}
@@ -1490,10 +1496,35 @@ func (db *BoltDB) UpdateMatch(m *dexdb.MetaMatch) error {
 			put(matchIDKey, match.MatchID[:]).
 			put(matchKey, order.EncodeMatch(match)).
 			put(stampKey, uint64Bytes(md.Stamp)).
+			put(fiatRatesKey, encodeFiatRates(md.FiatRates)).
 			err()
 	})
 }
 
+// encodeFiatRates encodes the fiat rates of a match as a sequence of 4-byte
+// asset IDs and 8-byte rates.
+func encodeFiatRates(rates map[uint32]float64) []byte {
+	b := make([]byte, 0, len(rates)*12)
+	for assetID, rate := range rates {
+		b = append(b, uint32Bytes(assetID)...)
+		b = append(b, uint64Bytes(math.Float64bits(rate))...)
+	}
+	return b
+}
+
+// decodeFiatRates decodes the fiat rates of a match. Matches stored before the
+// rates were recorded have no rates.
+func decodeFiatRates(b []byte) map[uint32]float64 {
+	if len(b) == 0 || len(b)%12 != 0 {
+		return nil
+	}
+	rates := make(map[uint32]float64, len(b)/12)
+	for ; len(b) > 0; b = b[12:] {
+		rates[intCoder.Uint32(b[:4])] = math.Float64frombits(intCoder.Uint64(b[4:12]))
+	}
+	return rates
+}
+
 // ActiveMatches retrieves the matches that are in an active state, which is
 // any match that is still active.
 func (db *BoltDB) ActiveMatches() ([]*dexdb.MetaMatch, error) {
@@ -1621,11 +1652,12 @@ func loadMatchBucket(mBkt *bbolt.Bucket, excludeCancels bool) (*dexdb.MetaMatch,
 	}
 	return &dexdb.MetaMatch{
 		MetaData: &dexdb.MatchMetaData{
-			Proof: *proof,
-			DEX:   string(getCopy(mBkt, dexKey)),
-			Base:  intCoder.Uint32(mBkt.Get(baseKey)),
-			Quote: intCoder.Uint32(mBkt.Get(quoteKey)),
-			Stamp: intCoder.Uint64(mBkt.Get(stampKey)),
+			Proof:     *proof,
+			DEX:       string(getCopy(mBkt, dexKey)),
+			Base:      intCoder.Uint32(mBkt.Get(baseKey)),
+			Quote:     intCoder.Uint32(mBkt.Get(quoteKey)),
+			Stamp:     intCoder.Uint64(mBkt.Get(stampKey)),
+			FiatRates: decodeFiatRates(mBkt.Get(fiatRatesKey)),
 		},
 		UserMatch: match,
 	}, nil
@@ -2459,6 +2491,138 @@ func (db *BoltDB) Language() (lang string, _ error) {
 	})
 }
 
+// SaveAPIToken stores an encrypted API token, replacing any token with the
+// same name.
+func (db *BoltDB) SaveAPIToken(name string, encToken []byte) error {
+	if name == "" {
+		return fmt.Errorf("empty API token name")
+	}
+	return db.Update(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(apiTokensBucket)
+		if bkt == nil {
+			return fmt.Errorf("failed to open %s bucket", string(apiTokensBucket))
+		}
+		return bkt.Put([]byte(name), encToken)
+	})
+}
+
+// APITokens retrieves the encrypted API tokens, keyed by name.
+func (db *BoltDB) APITokens() (map[string][]byte, error) {
+	tokens := make(map[string][]byte)
+	return tokens, db.View(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(apiTokensBucket)
+		if bkt == nil {
+			return fmt.Errorf("no %s bucket", string(apiTokensBucket))
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			tokens[string(k)] = bytes.Clone(v)
+			return nil
+		})
+	})
+}
+
+// DeleteAPIToken deletes the API token with the specified name.
+func (db *BoltDB) DeleteAPIToken(name string) error {
+	return db.Update(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(apiTokensBucket)
+		if bkt == nil {
+			return fmt.Errorf("failed to open %s bucket", string(apiTokensBucket))
+		}
+		if bkt.Get([]byte(name)) == nil {
+			return fmt.Errorf("no API token named %q", name)
+		}
+		return bkt.Delete([]byte(name))
+	})
+}
+
+// UpdateConditionalOrder stores the encoded conditional order, replacing any
+// order with the same ID.
+func (db *BoltDB) UpdateConditionalOrder(id []byte, ord []byte) error {
+	if len(id) == 0 {
+		return fmt.Errorf("empty conditional order ID")
+	}
+	return db.Update(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(condOrdersBucket)
+		if bkt == nil {
+			return fmt.Errorf("failed to open %s bucket", string(condOrdersBucket))
+		}
+		return bkt.Put(id, ord)
+	})
+}
+
+// ConditionalOrders retrieves the encoded conditional orders.
+func (db *BoltDB) ConditionalOrders() ([][]byte, error) {
+	var ords [][]byte
+	return ords, db.View(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(condOrdersBucket)
+		if bkt == nil {
+			return fmt.Errorf("no %s bucket", string(condOrdersBucket))
+		}
+		return bkt.ForEach(func(_, v []byte) error {
+			ords = append(ords, bytes.Clone(v))
+			return nil
+		})
+	})
+}
+
+// DeleteConditionalOrder deletes the conditional order with the specified ID.
+func (db *BoltDB) DeleteConditionalOrder(id []byte) error {
+	return db.Update(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(condOrdersBucket)
+		if bkt == nil {
+			return fmt.Errorf("failed to open %s bucket", string(condOrdersBucket))
+		}
+		if bkt.Get(id) == nil {
+			return fmt.Errorf("no conditional order %x", id)
+		}
+		return bkt.Delete(id)
+	})
+}
+
+// UpdateExecution stores the encoded execution algorithm parent order,
+// replacing any execution with the same ID.
+func (db *BoltDB) UpdateExecution(id []byte, exec []byte) error {
+	if len(id) == 0 {
+		return fmt.Errorf("empty execution ID")
+	}
+	return db.Update(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(executionsBucket)
+		if bkt == nil {
+			return fmt.Errorf("failed to open %s bucket", string(executionsBucket))
+		}
+		return bkt.Put(id, exec)
+	})
+}
+
+// Executions retrieves the encoded execution algorithm parent orders.
+func (db *BoltDB) Executions() ([][]byte, error) {
+	var execs [][]byte
+	return execs, db.View(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(executionsBucket)
+		if bkt == nil {
+			return fmt.Errorf("no %s bucket", string(executionsBucket))
+		}
+		return bkt.ForEach(func(_, v []byte) error {
+			execs = append(execs, bytes.Clone(v))
+			return nil
+		})
+	})
+}
+
+// DeleteExecution deletes the execution with the specified ID.
+func (db *BoltDB) DeleteExecution(id []byte) error {
+	return db.Update(func(dbTx *bbolt.Tx) error {
+		bkt := dbTx.Bucket(executionsBucket)
+		if bkt == nil {
+			return fmt.Errorf("failed to open %s bucket", string(executionsBucket))
+		}
+		if bkt.Get(id) == nil {
+			return fmt.Errorf("no execution %x", id)
+		}
+		return bkt.Delete(id)
+	})
+}
+
 // timeNow is the current unix timestamp in milliseconds.
 func timeNow() uint64 {
 	return uint64(time.Now().UnixMilli())
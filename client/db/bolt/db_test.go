@@ -894,6 +894,9 @@ func TestMatches(t *testing.T) {
 			},
 			UserMatch: ordertest.RandomUserMatch(),
 		}
+		if i%2 == 0 {
+			m.MetaData.FiatRates = map[uint32]float64{base: rand.Float64(), quote: rand.Float64()}
+		}
 		if i < numActive {
 			m.Status = order.MatchStatus(rand.Intn(4))
 		} else {
@@ -1613,3 +1616,119 @@ func TestPokes(t *testing.T) {
 		t.Fatal("Result from second LoadPokes wasn't empty")
 	}
 }
+
+func TestAPITokens(t *testing.T) {
+	boltdb, shutdown := newTestDB(t)
+	defer shutdown()
+
+	if err := boltdb.SaveAPIToken("", []byte{0x01}); err == nil {
+		t.Fatalf("no error for empty token name")
+	}
+	if err := boltdb.SaveAPIToken("a", []byte{0x01}); err != nil {
+		t.Fatalf("SaveAPIToken error: %v", err)
+	}
+	if err := boltdb.SaveAPIToken("b", []byte{0x02}); err != nil {
+		t.Fatalf("SaveAPIToken error: %v", err)
+	}
+	if err := boltdb.SaveAPIToken("a", []byte{0x03}); err != nil {
+		t.Fatalf("SaveAPIToken (replace) error: %v", err)
+	}
+
+	tokens, err := boltdb.APITokens()
+	if err != nil {
+		t.Fatalf("APITokens error: %v", err)
+	}
+	if len(tokens) != 2 || !bytes.Equal(tokens["a"], []byte{0x03}) || !bytes.Equal(tokens["b"], []byte{0x02}) {
+		t.Fatalf("wrong tokens %v", tokens)
+	}
+
+	if err := boltdb.DeleteAPIToken("a"); err != nil {
+		t.Fatalf("DeleteAPIToken error: %v", err)
+	}
+	if err := boltdb.DeleteAPIToken("a"); err == nil {
+		t.Fatalf("no error deleting unknown token")
+	}
+	tokens, err = boltdb.APITokens()
+	if err != nil {
+		t.Fatalf("APITokens error: %v", err)
+	}
+	if len(tokens) != 1 || tokens["b"] == nil {
+		t.Fatalf("wrong tokens after deletion %v", tokens)
+	}
+}
+
+func TestConditionalOrders(t *testing.T) {
+	boltdb, shutdown := newTestDB(t)
+	defer shutdown()
+
+	if err := boltdb.UpdateConditionalOrder(nil, []byte{0x01}); err == nil {
+		t.Fatalf("no error for empty conditional order ID")
+	}
+	if err := boltdb.UpdateConditionalOrder([]byte{0x0a}, []byte{0x01}); err != nil {
+		t.Fatalf("UpdateConditionalOrder error: %v", err)
+	}
+	if err := boltdb.UpdateConditionalOrder([]byte{0x0b}, []byte{0x02}); err != nil {
+		t.Fatalf("UpdateConditionalOrder error: %v", err)
+	}
+	if err := boltdb.UpdateConditionalOrder([]byte{0x0a}, []byte{0x03}); err != nil {
+		t.Fatalf("UpdateConditionalOrder (replace) error: %v", err)
+	}
+
+	ords, err := boltdb.ConditionalOrders()
+	if err != nil {
+		t.Fatalf("ConditionalOrders error: %v", err)
+	}
+	if len(ords) != 2 || !bytes.Equal(ords[0], []byte{0x03}) || !bytes.Equal(ords[1], []byte{0x02}) {
+		t.Fatalf("wrong conditional orders %v", ords)
+	}
+
+	if err := boltdb.DeleteConditionalOrder([]byte{0x0a}); err != nil {
+		t.Fatalf("DeleteConditionalOrder error: %v", err)
+	}
+	if err := boltdb.DeleteConditionalOrder([]byte{0x0a}); err == nil {
+		t.Fatalf("no error deleting unknown conditional order")
+	}
+	ords, err = boltdb.ConditionalOrders()
+	if err != nil {
+		t.Fatalf("ConditionalOrders error: %v", err)
+	}
+	if len(ords) != 1 {
+		t.Fatalf("wrong conditional orders after deletion %v", ords)
+	}
+}
+
+func TestExecutions(t *testing.T) {
+	boltdb, shutdown := newTestDB(t)
+	defer shutdown()
+
+	if err := boltdb.UpdateExecution(nil, []byte{0x01}); err == nil {
+		t.Fatalf("no error for empty execution ID")
+	}
+	if err := boltdb.UpdateExecution([]byte{0x0a}, []byte{0x01}); err != nil {
+		t.Fatalf("UpdateExecution error: %v", err)
+	}
+	if err := boltdb.UpdateExecution([]byte{0x0b}, []byte{0x02}); err != nil {
+		t.Fatalf("UpdateExecution error: %v", err)
+	}
+	if err := boltdb.UpdateExecution([]byte{0x0a}, []byte{0x03}); err != nil {
+		t.Fatalf("UpdateExecution (replace) error: %v", err)
+	}
+
+	execs, err := boltdb.Executions()
+	if err != nil {
+		t.Fatalf("Executions error: %v", err)
+	}
+	if len(execs) != 2 || !bytes.Equal(execs[0], []byte{0x03}) || !bytes.Equal(execs[1], []byte{0x02}) {
+		t.Fatalf("wrong executions %v", execs)
+	}
+
+	if err := boltdb.DeleteExecution([]byte{0x0a}); err != nil {
+		t.Fatalf("DeleteExecution error: %v", err)
+	}
+	if err := boltdb.DeleteExecution([]byte{0x0a}); err == nil {
+		t.Fatalf("no error deleting unknown execution")
+	}
+	if execs, _ = boltdb.Executions(); len(execs) != 1 || !bytes.Equal(execs[0], []byte{0x02}) {
+		t.Fatalf("wrong executions after delete %v", execs)
+	}
+}
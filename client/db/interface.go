@@ -155,4 +155,26 @@ type DB interface {
 	SetLanguage(lang string) error
 	// Language gets the language stored with SetLanguage.
 	Language() (string, error)
+	// SaveAPIToken stores an encrypted API token, replacing any token with
+	// the same name.
+	SaveAPIToken(name string, encToken []byte) error
+	// APITokens retrieves the encrypted API tokens, keyed by name.
+	APITokens() (map[string][]byte, error)
+	// DeleteAPIToken deletes the API token with the specified name.
+	DeleteAPIToken(name string) error
+	// UpdateConditionalOrder stores the encoded conditional order, replacing
+	// any order with the same ID.
+	UpdateConditionalOrder(id []byte, ord []byte) error
+	// ConditionalOrders retrieves the encoded conditional orders.
+	ConditionalOrders() ([][]byte, error)
+	// DeleteConditionalOrder deletes the conditional order with the specified
+	// ID.
+	DeleteConditionalOrder(id []byte) error
+	// UpdateExecution stores the encoded execution algorithm parent order,
+	// replacing any execution with the same ID.
+	UpdateExecution(id []byte, exec []byte) error
+	// Executions retrieves the encoded execution algorithm parent orders.
+	Executions() ([][]byte, error)
+	// DeleteExecution deletes the execution with the specified ID.
+	DeleteExecution(id []byte) error
 }
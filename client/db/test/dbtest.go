@@ -226,6 +226,14 @@ func MustCompareMatchMetaData(t testKiller, m1, m2 *db.MatchMetaData) {
 	if m1.Stamp != m2.Stamp {
 		t.Fatalf("Stamp mismatch. %d != %d", m1.Stamp, m2.Stamp)
 	}
+	if len(m1.FiatRates) != len(m2.FiatRates) {
+		t.Fatalf("FiatRates length mismatch. %d != %d", len(m1.FiatRates), len(m2.FiatRates))
+	}
+	for assetID, rate := range m1.FiatRates {
+		if m2.FiatRates[assetID] != rate {
+			t.Fatalf("FiatRates mismatch for asset %d. %f != %f", assetID, rate, m2.FiatRates[assetID])
+		}
+	}
 	MustCompareMatchProof(t, &m1.Proof, &m2.Proof)
 }
 
@@ -419,7 +419,8 @@ type OrderMetaData struct {
 	// change from that matches ini tx funds the next match, etc.
 	ChangeCoin order.CoinID
 	// LinkedOrder is used to specify the cancellation order for a trade, or
-	// vice-versa.
+	// vice-versa. The cancel order of an amend is linked to the replacement
+	// order instead of the amended trade.
 	LinkedOrder order.OrderID
 	// SwapFeesPaid is the sum of the actual fees paid for all swaps.
 	SwapFeesPaid uint64
@@ -584,6 +585,10 @@ type MatchMetaData struct {
 	// Stamp is the match time (ms UNIX), according to the server's 'match'
 	// request timestamp.
 	Stamp uint64
+	// FiatRates are the fiat exchange rates of the market's assets and their
+	// fee assets at the time of the match, keyed by asset ID. Assets without a
+	// known rate are omitted.
+	FiatRates map[uint32]float64
 	// TODO: ReceiveTime uint64 -- local time stamp for match age and time display
 }
 
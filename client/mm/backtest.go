@@ -0,0 +1,513 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/calc"
+)
+
+// BookLevel is an aggregated price level of an order book. Rate is a message
+// rate and Qty is in atoms of the base asset.
+type BookLevel struct {
+	Rate uint64 `json:"rate"`
+	Qty  uint64 `json:"qty"`
+}
+
+// BacktestMatch is a match that was made on the DEX market during an epoch.
+type BacktestMatch struct {
+	Rate uint64 `json:"rate"`
+	Qty  uint64 `json:"qty"`
+	// TakerSell is true if the taker was selling the base asset.
+	TakerSell bool `json:"takerSell"`
+}
+
+// BacktestEpoch is a snapshot of the recorded market state at the close of a
+// DEX epoch.
+type BacktestEpoch struct {
+	// Epoch is the index of the epoch that was just resolved.
+	Epoch uint64 `json:"epoch"`
+	// Stamp is the time of the snapshot, in milliseconds.
+	Stamp    int64        `json:"stamp"`
+	DEXBuys  []*BookLevel `json:"dexBuys"`
+	DEXSells []*BookLevel `json:"dexSells"`
+	// Matches are the matches that were made on the DEX in the epoch.
+	Matches []*BacktestMatch `json:"matches,omitempty"`
+	// CEXBuys and CEXSells are the CEX order book at the time of the
+	// snapshot. They are only required when backtesting arbitrage bots.
+	CEXBuys  []*BookLevel `json:"cexBuys,omitempty"`
+	CEXSells []*BookLevel `json:"cexSells,omitempty"`
+	// OracleRate is the conventional exchange rate reported by the price
+	// oracle. If not set, the CEX mid-gap, or the DEX mid-gap if there is no
+	// CEX data, is used.
+	OracleRate float64 `json:"oracleRate,omitempty"`
+}
+
+// BacktestDataSource provides the recorded market data that is replayed
+// during a backtest.
+type BacktestDataSource interface {
+	// NextEpoch returns the next epoch of market data. io.EOF is returned
+	// when there is no more data.
+	NextEpoch() (*BacktestEpoch, error)
+}
+
+type backtestSliceSource struct {
+	epochs []*BacktestEpoch
+	i      int
+}
+
+// NewBacktestSliceSource creates a BacktestDataSource from a slice of epochs.
+func NewBacktestSliceSource(epochs []*BacktestEpoch) BacktestDataSource {
+	return &backtestSliceSource{epochs: epochs}
+}
+
+func (s *backtestSliceSource) NextEpoch() (*BacktestEpoch, error) {
+	if s.i >= len(s.epochs) {
+		return nil, io.EOF
+	}
+	s.i++
+	return s.epochs[s.i-1], nil
+}
+
+type backtestJSONSource struct {
+	dec *json.Decoder
+}
+
+// NewBacktestJSONSource creates a BacktestDataSource that reads a stream of
+// JSON-encoded BacktestEpochs, e.g. a file with one epoch per line.
+func NewBacktestJSONSource(r io.Reader) BacktestDataSource {
+	return &backtestJSONSource{dec: json.NewDecoder(r)}
+}
+
+func (s *backtestJSONSource) NextEpoch() (*BacktestEpoch, error) {
+	var e BacktestEpoch
+	if err := s.dec.Decode(&e); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("error decoding epoch: %w", err)
+	}
+	return &e, nil
+}
+
+// BacktestConfig is the configuration for a backtest.
+type BacktestConfig struct {
+	// Bot is the configuration of the bot being tested. Exactly one of the
+	// BasicMMConfig, SimpleArbConfig or ArbMarketMakerConfig must be set.
+	Bot *BotConfig `json:"bot"`
+	// Market is the DEX market. BaseID, QuoteID, LotSize, RateStep and
+	// EpochLen are required.
+	Market *core.Market `json:"market"`
+	// DEXBalances and CEXBalances are the initial bot allocations.
+	DEXBalances map[uint32]uint64 `json:"dexBalances"`
+	CEXBalances map[uint32]uint64 `json:"cexBalances"`
+	// FiatRates are the USD rates of the assets. The base asset's rate is
+	// updated every epoch using the quote asset's rate and the market rate.
+	FiatRates map[uint32]float64 `json:"fiatRates"`
+	// BaseFees and QuoteFees are the on-chain fees, per lot, of swaps,
+	// redemptions and refunds in each asset. The fees are denominated in
+	// the fee asset.
+	BaseFees  *LotFees `json:"baseFees"`
+	QuoteFees *LotFees `json:"quoteFees"`
+	// CEXFeeRate is the CEX trading fee as a fraction of the traded amount.
+	// The fee is deducted from the asset being received.
+	CEXFeeRate float64 `json:"cexFeeRate"`
+}
+
+func (cfg *BacktestConfig) validate() error {
+	if cfg.Bot == nil {
+		return errors.New("no bot config")
+	}
+	if err := cfg.Bot.validate(); err != nil {
+		return err
+	}
+	if cfg.Market == nil {
+		return errors.New("no market")
+	}
+	if cfg.Market.BaseID != cfg.Bot.BaseID || cfg.Market.QuoteID != cfg.Bot.QuoteID {
+		return fmt.Errorf("bot market %d-%d does not match backtest market %d-%d",
+			cfg.Bot.BaseID, cfg.Bot.QuoteID, cfg.Market.BaseID, cfg.Market.QuoteID)
+	}
+	if cfg.Market.LotSize == 0 || cfg.Market.RateStep == 0 {
+		return errors.New("market lot size and rate step must be set")
+	}
+	if cfg.CEXFeeRate < 0 || cfg.CEXFeeRate >= 1 {
+		return fmt.Errorf("invalid cex fee rate %f", cfg.CEXFeeRate)
+	}
+	return nil
+}
+
+// InventorySnapshot is the bot's balances at the end of an epoch.
+type InventorySnapshot struct {
+	Epoch uint64                 `json:"epoch"`
+	Stamp int64                  `json:"stamp"`
+	DEX   map[uint32]*BotBalance `json:"dex"`
+	CEX   map[uint32]*BotBalance `json:"cex,omitempty"`
+}
+
+// BacktestFees are the fees that were paid during a backtest.
+type BacktestFees struct {
+	// DEX are the on-chain swap and redemption fees, keyed by fee asset.
+	DEX map[uint32]uint64 `json:"dex"`
+	// CEX are the CEX trading fees, keyed by asset.
+	CEX map[uint32]uint64 `json:"cex"`
+	// USD is the total value of the fees using the final fiat rates.
+	USD float64 `json:"usd"`
+}
+
+// BacktestFillStats are statistics about the orders that the bot placed
+// during a backtest. Quantities are in atoms of the base asset.
+type BacktestFillStats struct {
+	DEXOrders         uint32 `json:"dexOrders"`
+	DEXOrdersFilled   uint32 `json:"dexOrdersFilled"`
+	DEXOrdersCanceled uint32 `json:"dexOrdersCanceled"`
+	DEXMatches        uint32 `json:"dexMatches"`
+	DEXBuyQty         uint64 `json:"dexBuyQty"`
+	DEXSellQty        uint64 `json:"dexSellQty"`
+	CEXTrades         uint32 `json:"cexTrades"`
+	CEXTradesFilled   uint32 `json:"cexTradesFilled"`
+	CEXBuyQty         uint64 `json:"cexBuyQty"`
+	CEXSellQty        uint64 `json:"cexSellQty"`
+}
+
+// BacktestResult is the result of a backtest.
+type BacktestResult struct {
+	// Overview is the same overview that would be stored in the event log
+	// for a live run.
+	Overview   *MarketMakingRunOverview `json:"overview"`
+	Events     []*MarketMakingEvent     `json:"events"`
+	Fees       *BacktestFees            `json:"fees"`
+	Fills      *BacktestFillStats       `json:"fills"`
+	Inventory  []*InventorySnapshot     `json:"inventory"`
+	Epochs     uint64                   `json:"epochs"`
+	StartStamp int64                    `json:"startStamp"`
+	EndStamp   int64                    `json:"endStamp"`
+}
+
+// backtestOracle is the oracle used by the basic market maker during a
+// backtest. The price is updated by the backtester every epoch.
+type backtestOracle struct {
+	mtx  sync.RWMutex
+	rate float64
+}
+
+var _ oracle = (*backtestOracle)(nil)
+
+func (o *backtestOracle) getMarketPrice(baseID, quoteID uint32) float64 {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+	return o.rate
+}
+
+// getOracleReports returns a single report at the backtest's oracle price,
+// so that an OracleConfig can still be used in backtests.
+func (o *backtestOracle) getOracleReports(baseID, quoteID uint32) []*OracleReport {
+	rate := o.getMarketPrice(baseID, quoteID)
+	if rate == 0 {
+		return nil
+	}
+	return []*OracleReport{{
+		Host:     "backtest",
+		USDVol:   minimumUSDVolumeForOraclesAvg,
+		BestBuy:  rate,
+		BestSell: rate,
+	}}
+}
+
+func (o *backtestOracle) setRate(rate float64) {
+	o.mtx.Lock()
+	o.rate = rate
+	o.mtx.Unlock()
+}
+
+// newBacktestBot creates the bot described by the config, with its exchange
+// adaptor wrapped by a simBotCoreAdaptor.
+func newBacktestBot(ctx context.Context, cfg *BotConfig, adaptorCfg *exchangeAdaptorCfg, o oracle, log dex.Logger) (bot, *simBotCoreAdaptor, error) {
+	switch {
+	case cfg.ArbMarketMakerConfig != nil:
+		b, err := newArbMarketMaker(cfg, adaptorCfg, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		a := newSimBotCoreAdaptor(ctx, b.unifiedExchangeAdaptor)
+		b.core = a
+		return b, a, nil
+	case cfg.BasicMMConfig != nil:
+		b, err := newBasicMarketMaker(cfg, adaptorCfg, o, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		a := newSimBotCoreAdaptor(ctx, b.unifiedExchangeAdaptor)
+		b.core = a
+		return b, a, nil
+	case cfg.SimpleArbConfig != nil:
+		b, err := newSimpleArbMarketMaker(cfg, adaptorCfg, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		a := newSimBotCoreAdaptor(ctx, b.unifiedExchangeAdaptor)
+		b.core = a
+		return b, a, nil
+	case cfg.TriangularArbConfig != nil:
+		return nil, nil, errors.New("triangular arbitrage bots cannot be backtested")
+	default:
+		return nil, nil, errors.New("no bot config found")
+	}
+}
+
+// backtester replays recorded market data through a bot.
+type backtester struct {
+	cfg     *BacktestConfig
+	log     dex.Logger
+	core    *simCore
+	cex     *simCEX
+	oracle  *backtestOracle
+	bot     bot
+	adaptor *simBotCoreAdaptor
+	mkt     *market
+}
+
+// RunBacktest replays the market data from src through the real bot
+// implementation described by cfg.Bot. DEX orders are filled against the
+// recorded book and matches, and CEX trades are filled against the recorded
+// CEX book. The bot's balances are tracked by the exchange adaptor just as
+// they would be in a live run.
+func RunBacktest(ctx context.Context, cfg *BacktestConfig, src BacktestDataSource, log dex.Logger) (*BacktestResult, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid backtest config: %w", err)
+	}
+
+	first, err := src.NextEpoch()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no market data")
+		}
+		return nil, err
+	}
+
+	mkt, err := parseMarket(cfg.Bot.Host, cfg.Market)
+	if err != nil {
+		return nil, err
+	}
+
+	bt := &backtester{
+		cfg:    cfg,
+		log:    log,
+		core:   newSimCore(cfg, log),
+		oracle: &backtestOracle{},
+		mkt:    mkt,
+	}
+	if cfg.Bot.requiresCEX() {
+		bt.cex = newSimCEX(cfg, log)
+	}
+	bt.updateMarketData(first)
+
+	mwh := &MarketWithHost{
+		Host:    cfg.Bot.Host,
+		BaseID:  cfg.Bot.BaseID,
+		QuoteID: cfg.Bot.QuoteID,
+	}
+	eventLog := newMemEventLogDB()
+	adaptorCfg := &exchangeAdaptorCfg{
+		botID:           dexMarketID(mwh.Host, mwh.BaseID, mwh.QuoteID),
+		mwh:             mwh,
+		baseDexBalances: cfg.DEXBalances,
+		baseCexBalances: cfg.CEXBalances,
+		core:            bt.core,
+		log:             log,
+		eventLogDB:      eventLog,
+		botCfg:          cfg.Bot.copy(),
+	}
+	if bt.cex != nil {
+		adaptorCfg.cex = bt.cex
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	bt.bot, bt.adaptor, err = newBacktestBot(ctx, cfg.Bot, adaptorCfg, bt.oracle, log)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := dex.NewConnectionMaster(bt.bot)
+	if err := cm.ConnectOnce(ctx); err != nil {
+		return nil, fmt.Errorf("error connecting bot: %w", err)
+	}
+
+	res := &BacktestResult{
+		StartStamp: first.Stamp,
+		Inventory:  make([]*InventorySnapshot, 0, 64),
+	}
+
+	for e := first; ; {
+		if err := bt.runEpoch(ctx, e); err != nil {
+			cancel()
+			cm.Wait()
+			return nil, err
+		}
+		res.Epochs++
+		res.EndStamp = e.Stamp
+		res.Inventory = append(res.Inventory, bt.inventory(e))
+
+		e, err = src.NextEpoch()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			cancel()
+			cm.Wait()
+			return nil, err
+		}
+	}
+
+	// Cancel anything that is still open so that all of the bot's balances
+	// are settled before the run ends.
+	bt.core.cancelAll()
+	if bt.cex != nil {
+		bt.cex.cancelAll()
+	}
+	if err := bt.deliverUpdates(ctx); err != nil {
+		cancel()
+		cm.Wait()
+		return nil, err
+	}
+	bt.core.setDone()
+
+	cancel()
+	cm.Wait()
+
+	res.Overview, err = eventLog.runOverview(bt.adaptor.timeStart(), mwh)
+	if err != nil {
+		return nil, err
+	}
+	res.Events, err = eventLog.runEvents(bt.adaptor.timeStart(), mwh, 0, nil, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(res.Events, func(i, j int) bool { return res.Events[i].ID < res.Events[j].ID })
+
+	fiatRates := bt.core.FiatConversionRates()
+	res.Fees = &BacktestFees{
+		DEX: bt.core.feesPaid(),
+		CEX: make(map[uint32]uint64),
+	}
+	res.Fills = bt.core.fillStats()
+	if bt.cex != nil {
+		res.Fees.CEX = bt.cex.feesPaid()
+		bt.cex.addFillStats(res.Fills)
+	}
+	for _, fees := range []map[uint32]uint64{res.Fees.DEX, res.Fees.CEX} {
+		for assetID, v := range fees {
+			res.Fees.USD += NewAmount(assetID, int64(v), fiatRates[assetID]).USD
+		}
+	}
+
+	return res, nil
+}
+
+// convRate converts a message rate to a conventional rate.
+func (bt *backtester) convRate(msgRate uint64) float64 {
+	return calc.ConventionalRate(msgRate, bt.mkt.bui, bt.mkt.qui)
+}
+
+// updateMarketData updates the simulated exchanges, the oracle and the fiat
+// rates with the data from a new epoch.
+func (bt *backtester) updateMarketData(e *BacktestEpoch) {
+	bt.core.setBook(e)
+	if bt.cex != nil {
+		bt.cex.setBook(e)
+	}
+
+	rate := e.OracleRate
+	if rate == 0 && bt.cex != nil {
+		if midGap := bt.cex.MidGap(bt.cfg.Bot.BaseID, bt.cfg.Bot.QuoteID); midGap > 0 {
+			rate = bt.convRate(midGap)
+		}
+	}
+	if rate == 0 {
+		if midGap, err := bt.core.book.MidGap(); err == nil {
+			rate = bt.convRate(midGap)
+		}
+	}
+	if rate > 0 {
+		bt.oracle.setRate(rate)
+		bt.core.updateBaseFiatRate(rate)
+	}
+}
+
+// runEpoch processes a single epoch of market data. Orders placed during the
+// previous epoch are matched, the bot is notified of the updates, and then
+// the bot is informed of the new epoch.
+func (bt *backtester) runEpoch(ctx context.Context, e *BacktestEpoch) error {
+	bt.updateMarketData(e)
+	bt.core.matchOrders(e)
+
+	if err := bt.deliverUpdates(ctx); err != nil {
+		return err
+	}
+
+	if err := bt.core.resolveEpoch(ctx, e.Epoch); err != nil {
+		return err
+	}
+
+	// Deliver the results of any trades the bot made while handling the new
+	// epoch.
+	return bt.deliverUpdates(ctx)
+}
+
+// deliverUpdates sends queued DEX and CEX updates to the bot and waits for
+// them to be processed.
+func (bt *backtester) deliverUpdates(ctx context.Context) error {
+	sent, err := bt.core.sendNotes(ctx)
+	if err != nil {
+		return err
+	}
+	if sent {
+		// Arbitrage bots trade on the CEX in response to DEX order
+		// updates, so wait for them to be handled before delivering the
+		// CEX updates.
+		if err := bt.adaptor.waitForOrderUpdates(ctx); err != nil {
+			return err
+		}
+	}
+	if bt.cex != nil {
+		return bt.cex.sendUpdates(ctx)
+	}
+	return nil
+}
+
+// inventory records the bot's balances.
+func (bt *backtester) inventory(e *BacktestEpoch) *InventorySnapshot {
+	assets := map[uint32]bool{
+		bt.mkt.baseID:     true,
+		bt.mkt.quoteID:    true,
+		bt.mkt.baseFeeID:  true,
+		bt.mkt.quoteFeeID: true,
+	}
+	snap := &InventorySnapshot{
+		Epoch: e.Epoch,
+		Stamp: e.Stamp,
+		DEX:   make(map[uint32]*BotBalance, len(assets)),
+	}
+	if bt.cex != nil {
+		snap.CEX = make(map[uint32]*BotBalance, len(assets))
+	}
+	for assetID := range assets {
+		snap.DEX[assetID] = bt.bot.DEXBalance(assetID)
+		if bt.cex != nil {
+			snap.CEX[assetID] = bt.bot.CEXBalance(assetID)
+		}
+	}
+	return snap
+}
@@ -0,0 +1,1322 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc"
+	"decred.org/dcrdex/client/orderbook"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/calc"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
+)
+
+var errBacktestUnsupported = errors.New("not supported in backtests")
+
+// sortedLevels returns a copy of the levels, sorted best first.
+func sortedLevels(levels []*BookLevel, buys bool) []*BookLevel {
+	sorted := make([]*BookLevel, 0, len(levels))
+	for _, l := range levels {
+		sorted = append(sorted, &BookLevel{Rate: l.Rate, Qty: l.Qty})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if buys {
+			return sorted[i].Rate > sorted[j].Rate
+		}
+		return sorted[i].Rate < sorted[j].Rate
+	})
+	return sorted
+}
+
+// takeLiquidity removes up to qty from the levels that satisfy crosses. The
+// levels must be sorted best first. The amount taken is a multiple of
+// lotSize.
+func takeLiquidity(levels []*BookLevel, qty, lotSize uint64, crosses func(rate uint64) bool) uint64 {
+	var avail uint64
+	for _, l := range levels {
+		if !crosses(l.Rate) || avail >= qty {
+			break
+		}
+		avail += l.Qty
+	}
+	take := min(avail, qty)
+	take -= take % lotSize
+	for remain, i := take, 0; remain > 0; i++ {
+		l := levels[i]
+		q := min(l.Qty, remain)
+		l.Qty -= q
+		remain -= q
+	}
+	return take
+}
+
+// simBookFeed is the core.BookFeed returned by simCore.SyncBook.
+type simBookFeed struct {
+	c         chan *core.BookUpdate
+	closed    chan struct{}
+	closeOnce sync.Once
+	core      *simCore
+}
+
+var _ core.BookFeed = (*simBookFeed)(nil)
+
+func (f *simBookFeed) Next() <-chan *core.BookUpdate {
+	return f.c
+}
+
+func (f *simBookFeed) Close() {
+	f.closeOnce.Do(func() {
+		close(f.closed)
+		f.core.feedsMtx.Lock()
+		delete(f.core.feeds, f)
+		f.core.feedsMtx.Unlock()
+	})
+}
+
+func (f *simBookFeed) Candles(dur string) error {
+	return nil
+}
+
+func (f *simBookFeed) Trades() error {
+	return nil
+}
+
+type simOrder struct {
+	*core.Order
+	cancelRequested bool
+	updated         bool
+}
+
+// simCore is a clientCore that simulates a DEX using recorded market data.
+// Orders are settled instantly when they are matched, and the fees are
+// applied based on the number of lots matched.
+type simCore struct {
+	cfg       *BacktestConfig
+	mkt       *core.Market
+	log       dex.Logger
+	book      *orderbook.OrderBook
+	noteFeed  chan core.Notification
+	baseFees  *LotFees
+	quoteFees *LotFees
+
+	feedsMtx sync.Mutex
+	feeds    map[*simBookFeed]bool
+	done     bool
+
+	mtx       sync.Mutex
+	epoch     uint64
+	stamp     int64
+	nonce     uint64
+	orders    map[order.OrderID]*simOrder
+	txs       map[string]*asset.WalletTransaction
+	fees      map[uint32]uint64
+	fiatRates map[uint32]float64
+	stats     BacktestFillStats
+}
+
+var _ clientCore = (*simCore)(nil)
+
+func newSimCore(cfg *BacktestConfig, log dex.Logger) *simCore {
+	mkt := *cfg.Market
+	if mkt.Name == "" {
+		mkt.Name, _ = dex.MarketName(mkt.BaseID, mkt.QuoteID)
+	}
+	fiatRates := make(map[uint32]float64, len(cfg.FiatRates))
+	for assetID, rate := range cfg.FiatRates {
+		fiatRates[assetID] = rate
+	}
+	baseFees, quoteFees := cfg.BaseFees, cfg.QuoteFees
+	if baseFees == nil {
+		baseFees = &LotFees{}
+	}
+	if quoteFees == nil {
+		quoteFees = &LotFees{}
+	}
+	return &simCore{
+		cfg:       cfg,
+		mkt:       &mkt,
+		log:       log,
+		book:      orderbook.NewOrderBook(log.SubLogger("BOOK")),
+		noteFeed:  make(chan core.Notification),
+		baseFees:  baseFees,
+		quoteFees: quoteFees,
+		feeds:     make(map[*simBookFeed]bool),
+		orders:    make(map[order.OrderID]*simOrder),
+		txs:       make(map[string]*asset.WalletTransaction),
+		fees:      make(map[uint32]uint64),
+		fiatRates: fiatRates,
+	}
+}
+
+// newID generates a unique ID for orders, matches and coins. The mtx must be
+// locked.
+func (c *simCore) newID() (id [32]byte) {
+	c.nonce++
+	binary.BigEndian.PutUint64(id[24:], c.nonce)
+	return
+}
+
+// setBook replaces the DEX order book with the book recorded at the end of
+// the epoch.
+func (c *simCore) setBook(e *BacktestEpoch) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.epoch = e.Epoch + 1
+	c.stamp = e.Stamp
+
+	snap := &msgjson.OrderBook{
+		MarketID: c.mkt.Name,
+		Epoch:    c.epoch,
+		Orders:   make([]*msgjson.BookOrderNote, 0, len(e.DEXBuys)+len(e.DEXSells)),
+	}
+	addSide := func(levels []*BookLevel, side uint8) {
+		for _, l := range levels {
+			oid := c.newID()
+			snap.Orders = append(snap.Orders, &msgjson.BookOrderNote{
+				OrderNote: msgjson.OrderNote{
+					MarketID: c.mkt.Name,
+					OrderID:  oid[:],
+				},
+				TradeNote: msgjson.TradeNote{
+					Side:     side,
+					Quantity: l.Qty,
+					Rate:     l.Rate,
+					Time:     uint64(e.Stamp),
+				},
+			})
+		}
+	}
+	addSide(e.DEXBuys, msgjson.BuyOrderNum)
+	addSide(e.DEXSells, msgjson.SellOrderNum)
+	if err := c.book.Reset(snap); err != nil {
+		c.log.Errorf("Error resetting order book for epoch %d: %v", e.Epoch, err)
+	}
+}
+
+// updateBaseFiatRate sets the fiat rate of the base asset using the
+// conventional market rate and the fiat rate of the quote asset.
+func (c *simCore) updateBaseFiatRate(convRate float64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if quoteRate := c.fiatRates[c.mkt.QuoteID]; quoteRate > 0 {
+		c.fiatRates[c.mkt.BaseID] = convRate * quoteRate
+	}
+}
+
+// matchOrders matches the bot's orders against the liquidity that was
+// recorded during the epoch. Sell orders are matched against the buy side of
+// the book and takers that were buying, and vice versa. Cancel requests are
+// processed after matching.
+func (c *simCore) matchOrders(e *BacktestEpoch) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	bids, asks := make([]*BookLevel, 0, len(e.DEXBuys)), make([]*BookLevel, 0, len(e.DEXSells))
+	bids = append(bids, e.DEXBuys...)
+	asks = append(asks, e.DEXSells...)
+	for _, m := range e.Matches {
+		if m.TakerSell {
+			asks = append(asks, &BookLevel{Rate: m.Rate, Qty: m.Qty})
+		} else {
+			bids = append(bids, &BookLevel{Rate: m.Rate, Qty: m.Qty})
+		}
+	}
+	bids, asks = sortedLevels(bids, true), sortedLevels(asks, false)
+
+	var buys, sells []*simOrder
+	for _, o := range c.orders {
+		if o.Status > order.OrderStatusBooked {
+			continue
+		}
+		if o.Sell {
+			sells = append(sells, o)
+		} else {
+			buys = append(buys, o)
+		}
+	}
+	sortOrders := func(ords []*simOrder, sell bool) {
+		sort.Slice(ords, func(i, j int) bool {
+			if ords[i].Rate == ords[j].Rate {
+				return bytes.Compare(ords[i].ID, ords[j].ID) < 0
+			}
+			if sell {
+				return ords[i].Rate < ords[j].Rate
+			}
+			return ords[i].Rate > ords[j].Rate
+		})
+	}
+	sortOrders(sells, true)
+	sortOrders(buys, false)
+
+	lotSize := c.mkt.LotSize
+	for _, o := range sells {
+		qty := takeLiquidity(bids, o.Qty-o.Filled, lotSize, func(rate uint64) bool { return rate >= o.Rate })
+		if qty > 0 {
+			c.fill(o, qty)
+		}
+	}
+	for _, o := range buys {
+		qty := takeLiquidity(asks, o.Qty-o.Filled, lotSize, func(rate uint64) bool { return rate <= o.Rate })
+		if qty > 0 {
+			c.fill(o, qty)
+		}
+	}
+
+	for _, o := range c.orders {
+		if o.Status > order.OrderStatusBooked {
+			continue
+		}
+		if o.cancelRequested {
+			o.Status = order.OrderStatusCanceled
+			o.LockedAmt = 0
+			o.updated = true
+			c.stats.DEXOrdersCanceled++
+			continue
+		}
+		if o.Status == order.OrderStatusEpoch {
+			o.Status = order.OrderStatusBooked
+			o.updated = true
+		}
+	}
+}
+
+// fill matches qty of an order at the order's rate. The swap and redeem
+// transactions are created and confirmed immediately. The mtx must be
+// locked.
+func (c *simCore) fill(o *simOrder, qty uint64) {
+	fromAsset, fromFeeAsset, toAsset, toFeeAsset := orderAssets(o.BaseID, o.QuoteID, o.Sell)
+	lots := qty / c.mkt.LotSize
+	quoteQty := calc.BaseToQuote(o.Rate, qty)
+
+	var swapAmt, redeemAmt, swapFees, redeemFees uint64
+	if o.Sell {
+		swapAmt, redeemAmt = qty, quoteQty
+		swapFees, redeemFees = c.baseFees.Swap*lots, c.quoteFees.Redeem*lots
+		c.stats.DEXSellQty += qty
+	} else {
+		swapAmt, redeemAmt = quoteQty, qty
+		swapFees, redeemFees = c.quoteFees.Swap*lots, c.baseFees.Redeem*lots
+		c.stats.DEXBuyQty += qty
+	}
+
+	newTx := func(txType asset.TransactionType, amt, fees uint64) []byte {
+		id := c.newID()
+		coinID := dex.Bytes(id[:])
+		c.txs[coinID.String()] = &asset.WalletTransaction{
+			Type:      txType,
+			ID:        coinID.String(),
+			Amount:    amt,
+			Fees:      fees,
+			Timestamp: uint64(c.stamp / 1000),
+			Confirmed: true,
+		}
+		return coinID
+	}
+
+	matchID := c.newID()
+	match := &core.Match{
+		MatchID: matchID[:],
+		Status:  order.MatchConfirmed,
+		Rate:    o.Rate,
+		Qty:     qty,
+		Side:    order.Maker,
+		Swap:    core.NewCoin(fromAsset, newTx(asset.Swap, swapAmt, swapFees)),
+		Redeem:  core.NewCoin(toAsset, newTx(asset.Redeem, redeemAmt, redeemFees)),
+		Stamp:   uint64(c.stamp),
+	}
+
+	if o.Filled == 0 {
+		c.stats.DEXOrdersFilled++
+	}
+	c.stats.DEXMatches++
+	o.Matches = append(o.Matches, match)
+	o.Filled += qty
+	if o.LockedAmt > swapAmt {
+		o.LockedAmt -= swapAmt
+	} else {
+		o.LockedAmt = 0
+	}
+	if o.Filled >= o.Qty {
+		o.Status = order.OrderStatusExecuted
+		o.LockedAmt = 0
+	}
+	o.FeesPaid.Swap += swapFees
+	o.FeesPaid.Redemption += redeemFees
+	c.fees[fromFeeAsset] += swapFees
+	c.fees[toFeeAsset] += redeemFees
+	o.updated = true
+}
+
+// cancelAll cancels all of the bot's active orders immediately.
+func (c *simCore) cancelAll() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, o := range c.orders {
+		if o.Status > order.OrderStatusBooked {
+			continue
+		}
+		o.Status = order.OrderStatusCanceled
+		o.LockedAmt = 0
+		o.updated = true
+		c.stats.DEXOrdersCanceled++
+	}
+}
+
+// copyOrder returns a copy of an order that is safe to pass to the bot.
+func copyOrder(o *core.Order) *core.Order {
+	ord := *o
+	ord.Matches = make([]*core.Match, len(o.Matches))
+	copy(ord.Matches, o.Matches)
+	if o.FeesPaid != nil {
+		fees := *o.FeesPaid
+		ord.FeesPaid = &fees
+	}
+	return &ord
+}
+
+func (c *simCore) fiatRatesNote() *core.FiatRatesNote {
+	return &core.FiatRatesNote{FiatRates: c.FiatConversionRates()}
+}
+
+// sendNotes sends notifications for all orders that were updated since the
+// last call. Fiat rate notes are sent before and after the order notes. Since
+// the note feed is unbuffered, the trailing note is only received once all
+// of the order notes have been handled.
+func (c *simCore) sendNotes(ctx context.Context) (sent bool, err error) {
+	c.mtx.Lock()
+	notes := make([]core.Notification, 0, 8)
+	for _, o := range c.orders {
+		if !o.updated {
+			continue
+		}
+		o.updated = false
+		notes = append(notes, &core.OrderNote{Order: copyOrder(o.Order)})
+	}
+	c.mtx.Unlock()
+
+	sort.Slice(notes, func(i, j int) bool {
+		return bytes.Compare(notes[i].(*core.OrderNote).Order.ID, notes[j].(*core.OrderNote).Order.ID) < 0
+	})
+	notes = append([]core.Notification{c.fiatRatesNote()}, notes...)
+	notes = append(notes, c.fiatRatesNote())
+
+	for _, n := range notes {
+		select {
+		case c.noteFeed <- n:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	return len(notes) > 2, nil
+}
+
+// resolveEpoch informs the bot that an epoch has been resolved, and waits
+// for the bot to finish handling it.
+func (c *simCore) resolveEpoch(ctx context.Context, epoch uint64) error {
+	c.feedsMtx.Lock()
+	feeds := make([]*simBookFeed, 0, len(c.feeds))
+	for f := range c.feeds {
+		feeds = append(feeds, f)
+	}
+	c.feedsMtx.Unlock()
+
+	update := &core.BookUpdate{
+		Action:   core.EpochResolved,
+		Host:     c.cfg.Bot.Host,
+		MarketID: c.mkt.Name,
+		Payload: &core.ResolvedEpoch{
+			Current:  epoch + 1,
+			Resolved: epoch,
+		},
+	}
+	for _, f := range feeds {
+		// The second, empty update is only received after the bot has
+		// finished handling the first.
+		for _, u := range []*core.BookUpdate{update, {}} {
+			select {
+			case f.c <- u:
+			case <-f.closed:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// setDone is called once the backtest has finished. Book feeds requested
+// after this will be closed.
+func (c *simCore) setDone() {
+	c.feedsMtx.Lock()
+	c.done = true
+	c.feedsMtx.Unlock()
+}
+
+func (c *simCore) feesPaid() map[uint32]uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	fees := make(map[uint32]uint64, len(c.fees))
+	for assetID, v := range c.fees {
+		fees[assetID] = v
+	}
+	return fees
+}
+
+func (c *simCore) fillStats() *BacktestFillStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	stats := c.stats
+	return &stats
+}
+
+func (c *simCore) NotificationFeed() *core.NoteFeed {
+	return &core.NoteFeed{C: c.noteFeed}
+}
+
+func (c *simCore) ExchangeMarket(host string, baseID, quoteID uint32) (*core.Market, error) {
+	if baseID != c.mkt.BaseID || quoteID != c.mkt.QuoteID {
+		return nil, fmt.Errorf("unknown market %d-%d", baseID, quoteID)
+	}
+	mkt := *c.mkt
+	return &mkt, nil
+}
+
+func (c *simCore) SyncBook(host string, baseID, quoteID uint32) (*orderbook.OrderBook, core.BookFeed, error) {
+	if baseID != c.mkt.BaseID || quoteID != c.mkt.QuoteID {
+		return nil, nil, fmt.Errorf("unknown market %d-%d", baseID, quoteID)
+	}
+	f := &simBookFeed{
+		c:      make(chan *core.BookUpdate),
+		closed: make(chan struct{}),
+		core:   c,
+	}
+	c.feedsMtx.Lock()
+	defer c.feedsMtx.Unlock()
+	if c.done {
+		close(f.c)
+	} else {
+		c.feeds[f] = true
+	}
+	return c.book, f, nil
+}
+
+func (c *simCore) SupportedAssets() map[uint32]*core.SupportedAsset {
+	return nil
+}
+
+func (c *simCore) SingleLotFees(form *core.SingleLotFeesForm) (swapFees, redeemFees, refundFees uint64, err error) {
+	if form.Sell {
+		return c.baseFees.Swap, c.quoteFees.Redeem, c.baseFees.Refund, nil
+	}
+	return c.quoteFees.Swap, c.baseFees.Redeem, c.quoteFees.Refund, nil
+}
+
+func (c *simCore) Cancel(oidB dex.Bytes) error {
+	var oid order.OrderID
+	copy(oid[:], oidB)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	o, found := c.orders[oid]
+	if !found {
+		return fmt.Errorf("order %s not found", oid)
+	}
+	if o.Status > order.OrderStatusBooked {
+		return fmt.Errorf("order %s is not active", oid)
+	}
+	o.cancelRequested = true
+	return nil
+}
+
+func (c *simCore) AssetBalance(assetID uint32) (*core.WalletBalance, error) {
+	return nil, errBacktestUnsupported
+}
+
+func (c *simCore) WalletTraits(assetID uint32) (asset.WalletTrait, error) {
+	return 0, nil
+}
+
+func (c *simCore) MultiTrade(pw []byte, form *core.MultiTradeForm) []*core.MultiTradeResult {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	results := make([]*core.MultiTradeResult, 0, len(form.Placements))
+	for _, p := range form.Placements {
+		if p.Qty == 0 || p.Qty%c.mkt.LotSize != 0 || p.Rate == 0 || p.Rate%c.mkt.RateStep != 0 {
+			results = append(results, &core.MultiTradeResult{
+				Error: fmt.Errorf("invalid placement: qty %d, rate %d", p.Qty, p.Rate),
+			})
+			continue
+		}
+		lockedAmt := p.Qty
+		if !form.Sell {
+			lockedAmt = calc.BaseToQuote(p.Rate, p.Qty)
+		}
+		oid := c.newID()
+		o := &simOrder{
+			Order: &core.Order{
+				Host:             form.Host,
+				BaseID:           form.Base,
+				QuoteID:          form.Quote,
+				MarketID:         c.mkt.Name,
+				Type:             order.LimitOrderType,
+				ID:               oid[:],
+				Stamp:            uint64(c.stamp),
+				Status:           order.OrderStatusEpoch,
+				Epoch:            c.epoch,
+				Qty:              p.Qty,
+				Sell:             form.Sell,
+				Rate:             p.Rate,
+				TimeInForce:      order.StandingTiF,
+				LockedAmt:        lockedAmt,
+				FeesPaid:         &core.FeeBreakdown{},
+				AllFeesConfirmed: true,
+			},
+		}
+		c.orders[oid] = o
+		c.stats.DEXOrders++
+		results = append(results, &core.MultiTradeResult{Order: copyOrder(o.Order)})
+	}
+	return results
+}
+
+func (c *simCore) MaxFundingFees(fromAsset uint32, host string, numTrades uint32, options map[string]string) (uint64, error) {
+	return 0, nil
+}
+
+func (c *simCore) Login(pw []byte) error {
+	return nil
+}
+
+func (c *simCore) OpenWallet(assetID uint32, pw []byte) error {
+	return nil
+}
+
+func (c *simCore) Broadcast(core.Notification) {}
+
+func (c *simCore) FiatConversionRates() map[uint32]float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	rates := make(map[uint32]float64, len(c.fiatRates))
+	for assetID, rate := range c.fiatRates {
+		rates[assetID] = rate
+	}
+	return rates
+}
+
+func (c *simCore) Send(pw []byte, assetID uint32, value uint64, address string, subtract bool) (asset.Coin, error) {
+	return nil, errBacktestUnsupported
+}
+
+func (c *simCore) NewDepositAddress(assetID uint32) (string, error) {
+	return "", errBacktestUnsupported
+}
+
+func (c *simCore) Network() dex.Network {
+	return dex.Mainnet
+}
+
+func (c *simCore) Order(oidB dex.Bytes) (*core.Order, error) {
+	var oid order.OrderID
+	copy(oid[:], oidB)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	o, found := c.orders[oid]
+	if !found {
+		return nil, fmt.Errorf("order %s not found", oid)
+	}
+	return copyOrder(o.Order), nil
+}
+
+func (c *simCore) WalletTransaction(assetID uint32, txID string) (*asset.WalletTransaction, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	tx, found := c.txs[txID]
+	if !found {
+		return nil, asset.CoinNotFoundError
+	}
+	txCopy := *tx
+	return &txCopy, nil
+}
+
+func (c *simCore) TradingLimits(host string) (userParcels, parcelLimit uint32, err error) {
+	return 0, ^uint32(0), nil
+}
+
+func (c *simCore) WalletState(assetID uint32) *core.WalletState {
+	return &core.WalletState{
+		AssetID:   assetID,
+		Open:      true,
+		Running:   true,
+		Synced:    true,
+		PeerCount: 1,
+	}
+}
+
+func (c *simCore) Exchange(host string) (*core.Exchange, error) {
+	return &core.Exchange{
+		Host: host,
+		Auth: core.ExchangeAuth{
+			EffectiveTier: 1,
+		},
+	}, nil
+}
+
+// simBotCoreAdaptor wraps the exchange adaptor of a backtested bot. DEX order
+// updates are forwarded to the bot over an unbuffered channel so that the
+// backtester can tell when the bot has finished handling them.
+type simBotCoreAdaptor struct {
+	*unifiedExchangeAdaptor
+	ctx        context.Context
+	botUpdates chan *core.Order
+	flushes    chan chan struct{}
+	subscribed atomic.Bool
+}
+
+var _ botCoreAdaptor = (*simBotCoreAdaptor)(nil)
+
+func newSimBotCoreAdaptor(ctx context.Context, u *unifiedExchangeAdaptor) *simBotCoreAdaptor {
+	return &simBotCoreAdaptor{
+		unifiedExchangeAdaptor: u,
+		ctx:                    ctx,
+		botUpdates:             make(chan *core.Order),
+		flushes:                make(chan chan struct{}),
+	}
+}
+
+// SubscribeOrderUpdates starts forwarding the exchange adaptor's order updates
+// to the bot. Part of the botCoreAdaptor interface.
+func (a *simBotCoreAdaptor) SubscribeOrderUpdates() <-chan *core.Order {
+	updates := a.unifiedExchangeAdaptor.SubscribeOrderUpdates()
+	go a.forwardOrderUpdates(updates)
+	a.subscribed.Store(true)
+	return a.botUpdates
+}
+
+func (a *simBotCoreAdaptor) sendOrderUpdate(o *core.Order) bool {
+	select {
+	case a.botUpdates <- o:
+		return true
+	case <-a.ctx.Done():
+		return false
+	}
+}
+
+// forwardOrderUpdates sends the updates to the bot until the backtest ends.
+// When a flush is requested, any updates still queued are sent, followed by
+// an empty order that no bot is tracking. Since the bot's channel is
+// unbuffered, the empty order is only received once the bot has finished
+// handling the last real update.
+func (a *simBotCoreAdaptor) forwardOrderUpdates(updates <-chan *core.Order) {
+	for {
+		select {
+		case o := <-updates:
+			if !a.sendOrderUpdate(o) {
+				return
+			}
+		case flushed := <-a.flushes:
+			for len(updates) > 0 {
+				if !a.sendOrderUpdate(<-updates) {
+					return
+				}
+			}
+			if !a.sendOrderUpdate(&core.Order{}) {
+				return
+			}
+			close(flushed)
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// waitForOrderUpdates blocks until the bot has handled all of the order
+// updates queued by the exchange adaptor. The updates for the simCore's notes
+// must have already been queued.
+func (a *simBotCoreAdaptor) waitForOrderUpdates(ctx context.Context) error {
+	if !a.subscribed.Load() {
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case a.flushes <- flushed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type simCEXTrade struct {
+	*libxc.Trade
+	subscriptionID int
+	seq            uint64
+	// filled is the amount of the base asset matched, before fees.
+	filled uint64
+}
+
+type simCEXUpdate struct {
+	subscriptionID int
+	trade          *libxc.Trade
+}
+
+// simCEX is a libxc.CEX that fills trades against the recorded CEX order
+// book. The trading fee is deducted from the asset being received.
+type simCEX struct {
+	cfg     *BacktestConfig
+	log     dex.Logger
+	baseID  uint32
+	quoteID uint32
+	mktName string
+
+	mtx           sync.Mutex
+	bids, asks    []*BookLevel
+	nonce         uint64
+	trades        map[string]*simCEXTrade
+	balances      map[uint32]int64
+	fees          map[uint32]uint64
+	subscriptions map[int]chan *libxc.Trade
+	nextSubID     int
+	updates       []*simCEXUpdate
+	stats         BacktestFillStats
+}
+
+var _ libxc.CEX = (*simCEX)(nil)
+
+func newSimCEX(cfg *BacktestConfig, log dex.Logger) *simCEX {
+	balances := make(map[uint32]int64, len(cfg.CEXBalances))
+	for assetID, bal := range cfg.CEXBalances {
+		balances[assetID] = int64(bal)
+	}
+	mktName, _ := dex.MarketName(cfg.Bot.BaseID, cfg.Bot.QuoteID)
+	return &simCEX{
+		cfg:           cfg,
+		log:           log,
+		baseID:        cfg.Bot.BaseID,
+		quoteID:       cfg.Bot.QuoteID,
+		mktName:       mktName,
+		trades:        make(map[string]*simCEXTrade),
+		balances:      balances,
+		fees:          make(map[uint32]uint64),
+		subscriptions: make(map[int]chan *libxc.Trade),
+	}
+}
+
+func (c *simCEX) checkMarket(baseID, quoteID uint32) error {
+	if baseID != c.baseID || quoteID != c.quoteID {
+		return fmt.Errorf("unknown market %d-%d", baseID, quoteID)
+	}
+	return nil
+}
+
+// setBook replaces the CEX order book with the book recorded at the end of
+// the epoch, and matches open trades against it.
+func (c *simCEX) setBook(e *BacktestEpoch) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.bids, c.asks = sortedLevels(e.CEXBuys, true), sortedLevels(e.CEXSells, false)
+
+	open := make([]*simCEXTrade, 0, len(c.trades))
+	for _, t := range c.trades {
+		if !t.Complete {
+			open = append(open, t)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].seq < open[j].seq })
+	for _, t := range open {
+		if c.match(t) {
+			c.queueUpdate(t)
+		}
+	}
+}
+
+// match fills as much of the trade as possible against the current book.
+// True is returned if the trade was updated. The mtx must be locked.
+func (c *simCEX) match(t *simCEXTrade) bool {
+	levels, crosses := c.asks, func(rate uint64) bool { return rate <= t.Rate }
+	if t.Sell {
+		levels, crosses = c.bids, func(rate uint64) bool { return rate >= t.Rate }
+	}
+
+	startFilled := t.filled
+	for _, l := range levels {
+		if t.filled >= t.Qty || !crosses(l.Rate) {
+			break
+		}
+		qty := min(l.Qty, t.Qty-t.filled)
+		if qty == 0 {
+			continue
+		}
+		l.Qty -= qty
+		t.filled += qty
+		quoteQty := calc.BaseToQuote(l.Rate, qty)
+		if t.Sell {
+			fee := uint64(float64(quoteQty) * c.cfg.CEXFeeRate)
+			t.BaseFilled += qty
+			t.QuoteFilled += quoteQty - fee
+			c.fees[t.QuoteID] += fee
+			c.balances[t.BaseID] -= int64(qty)
+			c.balances[t.QuoteID] += int64(quoteQty - fee)
+			c.stats.CEXSellQty += qty
+		} else {
+			fee := uint64(float64(qty) * c.cfg.CEXFeeRate)
+			t.BaseFilled += qty - fee
+			t.QuoteFilled += quoteQty
+			c.fees[t.BaseID] += fee
+			c.balances[t.BaseID] += int64(qty - fee)
+			c.balances[t.QuoteID] -= int64(quoteQty)
+			c.stats.CEXBuyQty += qty
+		}
+	}
+	if t.filled == startFilled {
+		return false
+	}
+	if startFilled == 0 {
+		c.stats.CEXTradesFilled++
+	}
+	if t.filled >= t.Qty {
+		t.Complete = true
+	}
+	return true
+}
+
+// queueUpdate queues a trade update to be sent to the subscriber. The mtx
+// must be locked.
+func (c *simCEX) queueUpdate(t *simCEXTrade) {
+	trade := *t.Trade
+	c.updates = append(c.updates, &simCEXUpdate{
+		subscriptionID: t.subscriptionID,
+		trade:          &trade,
+	})
+}
+
+// sendUpdates sends the queued trade updates to the subscribers. An empty
+// trade is sent after the updates, which will only be received once the
+// updates have been handled.
+func (c *simCEX) sendUpdates(ctx context.Context) error {
+	c.mtx.Lock()
+	updates := c.updates
+	c.updates = nil
+	subs := make(map[int]chan *libxc.Trade, len(c.subscriptions))
+	for id, ch := range c.subscriptions {
+		subs[id] = ch
+	}
+	c.mtx.Unlock()
+
+	notified := make(map[int]bool)
+	send := func(ch chan *libxc.Trade, t *libxc.Trade) error {
+		select {
+		case ch <- t:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _, u := range updates {
+		ch, found := subs[u.subscriptionID]
+		if !found {
+			continue
+		}
+		if err := send(ch, u.trade); err != nil {
+			return err
+		}
+		notified[u.subscriptionID] = true
+	}
+	for id := range notified {
+		if err := send(subs[id], &libxc.Trade{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelAll cancels all open trades.
+func (c *simCEX) cancelAll() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, t := range c.trades {
+		if !t.Complete {
+			t.Complete = true
+			c.queueUpdate(t)
+		}
+	}
+}
+
+func (c *simCEX) feesPaid() map[uint32]uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	fees := make(map[uint32]uint64, len(c.fees))
+	for assetID, v := range c.fees {
+		fees[assetID] = v
+	}
+	return fees
+}
+
+func (c *simCEX) addFillStats(stats *BacktestFillStats) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	stats.CEXTrades = c.stats.CEXTrades
+	stats.CEXTradesFilled = c.stats.CEXTradesFilled
+	stats.CEXBuyQty = c.stats.CEXBuyQty
+	stats.CEXSellQty = c.stats.CEXSellQty
+}
+
+func (c *simCEX) Connect(ctx context.Context) (*sync.WaitGroup, error) {
+	return &sync.WaitGroup{}, nil
+}
+
+func (c *simCEX) Balance(assetID uint32) (*libxc.ExchangeBalance, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return &libxc.ExchangeBalance{Available: uint64(max(c.balances[assetID], 0))}, nil
+}
+
+func (c *simCEX) Balances(ctx context.Context) (map[uint32]*libxc.ExchangeBalance, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	bals := make(map[uint32]*libxc.ExchangeBalance, len(c.balances))
+	for assetID, bal := range c.balances {
+		bals[assetID] = &libxc.ExchangeBalance{Available: uint64(max(bal, 0))}
+	}
+	return bals, nil
+}
+
+func (c *simCEX) CancelTrade(ctx context.Context, baseID, quoteID uint32, tradeID string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	t, found := c.trades[tradeID]
+	if !found {
+		return fmt.Errorf("trade %s not found", tradeID)
+	}
+	if !t.Complete {
+		t.Complete = true
+		c.queueUpdate(t)
+	}
+	return nil
+}
+
+func (c *simCEX) MatchedMarkets(ctx context.Context) ([]*libxc.MarketMatch, error) {
+	return []*libxc.MarketMatch{{
+		BaseID:   c.baseID,
+		QuoteID:  c.quoteID,
+		MarketID: c.mktName,
+		Slug:     c.mktName,
+	}}, nil
+}
+
+func (c *simCEX) Markets(ctx context.Context) (map[string]*libxc.Market, error) {
+	return map[string]*libxc.Market{
+		c.mktName: {
+			BaseID:  c.baseID,
+			QuoteID: c.quoteID,
+		},
+	}, nil
+}
+
+func (c *simCEX) SubscribeMarket(ctx context.Context, baseID, quoteID uint32) error {
+	return c.checkMarket(baseID, quoteID)
+}
+
+func (c *simCEX) UnsubscribeMarket(baseID, quoteID uint32) error {
+	return c.checkMarket(baseID, quoteID)
+}
+
+func (c *simCEX) SubscribeTradeUpdates() (<-chan *libxc.Trade, func(), int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	id := c.nextSubID
+	c.nextSubID++
+	ch := make(chan *libxc.Trade)
+	c.subscriptions[id] = ch
+	return ch, func() {
+		c.mtx.Lock()
+		delete(c.subscriptions, id)
+		c.mtx.Unlock()
+	}, id
+}
+
+func (c *simCEX) Trade(ctx context.Context, baseID, quoteID uint32, sell bool, rate, qty uint64, subscriptionID int) (*libxc.Trade, error) {
+	if err := c.checkMarket(baseID, quoteID); err != nil {
+		return nil, err
+	}
+	if qty == 0 || rate == 0 {
+		return nil, fmt.Errorf("invalid trade: qty %d, rate %d", qty, rate)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.nonce++
+	t := &simCEXTrade{
+		Trade: &libxc.Trade{
+			ID:      strconv.FormatUint(c.nonce, 10),
+			Sell:    sell,
+			Qty:     qty,
+			Rate:    rate,
+			BaseID:  baseID,
+			QuoteID: quoteID,
+		},
+		subscriptionID: subscriptionID,
+		seq:            c.nonce,
+	}
+	c.trades[t.ID] = t
+	c.stats.CEXTrades++
+	c.match(t)
+	trade := *t.Trade
+	return &trade, nil
+}
+
+func (c *simCEX) VWAP(baseID, quoteID uint32, sell bool, qty uint64) (vwap, extrema uint64, filled bool, err error) {
+	if err := c.checkMarket(baseID, quoteID); err != nil {
+		return 0, 0, false, err
+	}
+	if qty == 0 {
+		return 0, 0, false, nil
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	levels := c.bids
+	if sell {
+		levels = c.asks
+	}
+	remaining := qty
+	var weightedSum uint64
+	for _, l := range levels {
+		if l.Qty == 0 {
+			continue
+		}
+		extrema = l.Rate
+		if l.Qty >= remaining {
+			filled = true
+			weightedSum += remaining * extrema
+			break
+		}
+		remaining -= l.Qty
+		weightedSum += l.Qty * extrema
+	}
+	if !filled {
+		return 0, 0, false, nil
+	}
+	return weightedSum / qty, extrema, true, nil
+}
+
+func (c *simCEX) MidGap(baseID, quoteID uint32) uint64 {
+	if c.checkMarket(baseID, quoteID) != nil {
+		return 0
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if len(c.bids) == 0 || len(c.asks) == 0 {
+		return 0
+	}
+	return (c.bids[0].Rate + c.asks[0].Rate) / 2
+}
+
+func (c *simCEX) GetDepositAddress(ctx context.Context, assetID uint32) (string, error) {
+	return "", errBacktestUnsupported
+}
+
+func (c *simCEX) ConfirmDeposit(ctx context.Context, deposit *libxc.DepositData) (bool, uint64) {
+	return true, 0
+}
+
+func (c *simCEX) Withdraw(ctx context.Context, assetID uint32, amt uint64, address string) (string, error) {
+	return "", errBacktestUnsupported
+}
+
+func (c *simCEX) ConfirmWithdrawal(ctx context.Context, withdrawalID string, assetID uint32) (uint64, string, error) {
+	return 0, "", errBacktestUnsupported
+}
+
+func (c *simCEX) TradeStatus(ctx context.Context, id string, baseID, quoteID uint32) (*libxc.Trade, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	t, found := c.trades[id]
+	if !found {
+		return nil, fmt.Errorf("trade %s not found", id)
+	}
+	trade := *t.Trade
+	return &trade, nil
+}
+
+func (c *simCEX) Book(baseID, quoteID uint32) (buys, sells []*core.MiniOrder, _ error) {
+	if err := c.checkMarket(baseID, quoteID); err != nil {
+		return nil, nil, err
+	}
+	bui, err := asset.UnitInfo(baseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	qui, err := asset.UnitInfo(quoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	convertSide := func(levels []*BookLevel, sell bool) []*core.MiniOrder {
+		ords := make([]*core.MiniOrder, 0, len(levels))
+		for _, l := range levels {
+			if l.Qty == 0 {
+				continue
+			}
+			ords = append(ords, &core.MiniOrder{
+				Qty:       float64(l.Qty) / float64(bui.Conventional.ConversionFactor),
+				QtyAtomic: l.Qty,
+				Rate:      calc.ConventionalRate(l.Rate, bui, qui),
+				MsgRate:   l.Rate,
+				Sell:      sell,
+			})
+		}
+		return ords
+	}
+	return convertSide(c.bids, false), convertSide(c.asks, true), nil
+}
+
+// memEventLogDB is an in-memory eventLogDB that stores a single run.
+type memEventLogDB struct {
+	mtx         sync.Mutex
+	startTime   int64
+	mkt         *MarketWithHost
+	cfgs        []*CfgUpdate
+	initialBals map[uint32]uint64
+	finalState  *BalanceState
+	endTime     *int64
+	events      map[uint64]*MarketMakingEvent
+}
+
+var _ eventLogDB = (*memEventLogDB)(nil)
+
+func newMemEventLogDB() *memEventLogDB {
+	return &memEventLogDB{
+		events: make(map[uint64]*MarketMakingEvent),
+	}
+}
+
+func (db *memEventLogDB) checkRun(startTime int64, mkt *MarketWithHost) error {
+	if db.mkt == nil || startTime != db.startTime || *mkt != *db.mkt {
+		return fmt.Errorf("run %d %s not found", startTime, mkt)
+	}
+	return nil
+}
+
+func (db *memEventLogDB) storeNewRun(startTime int64, mkt *MarketWithHost, cfg *BotConfig, initialState *BalanceState) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	db.startTime = startTime
+	db.mkt = mkt
+	db.cfgs = []*CfgUpdate{{Timestamp: startTime, Cfg: cfg}}
+	db.initialBals = make(map[uint32]uint64, len(initialState.Balances))
+	for assetID, bal := range initialState.Balances {
+		db.initialBals[assetID] = bal.Available
+	}
+	db.finalState = initialState
+	return nil
+}
+
+func (db *memEventLogDB) storeEvent(startTime int64, mkt *MarketWithHost, e *MarketMakingEvent, fs *BalanceState) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	if db.checkRun(startTime, mkt) != nil {
+		return
+	}
+	db.events[e.ID] = e
+	if e.UpdateConfig != nil {
+		db.cfgs = append(db.cfgs, &CfgUpdate{Timestamp: e.TimeStamp, Cfg: e.UpdateConfig})
+	}
+	if fs != nil {
+		db.finalState = fs
+	}
+}
+
+func (db *memEventLogDB) endRun(startTime int64, mkt *MarketWithHost, endTime int64) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	if err := db.checkRun(startTime, mkt); err != nil {
+		return err
+	}
+	db.endTime = &endTime
+	return nil
+}
+
+func (db *memEventLogDB) runs(n uint64, refStartTime *uint64, refMkt *MarketWithHost) ([]*MarketMakingRun, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	if db.mkt == nil {
+		return nil, nil
+	}
+	return []*MarketMakingRun{{StartTime: db.startTime, Market: db.mkt}}, nil
+}
+
+func (db *memEventLogDB) runOverview(startTime int64, mkt *MarketWithHost) (*MarketMakingRunOverview, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	if err := db.checkRun(startTime, mkt); err != nil {
+		return nil, err
+	}
+
+	finalBals := make(map[uint32]uint64, len(db.finalState.Balances))
+	for assetID, bal := range db.finalState.Balances {
+		finalBals[assetID] = bal.Available + bal.Pending + bal.Locked + bal.Reserved
+	}
+
+	return &MarketMakingRunOverview{
+		EndTime:         db.endTime,
+		Cfgs:            db.cfgs,
+		InitialBalances: db.initialBals,
+		ProfitLoss:      newProfitLoss(db.initialBals, finalBals, db.finalState.InventoryMods, db.finalState.FiatRates),
+		FinalState:      db.finalState,
+	}, nil
+}
+
+func (db *memEventLogDB) runEvents(startTime int64, mkt *MarketWithHost, n uint64, refID *uint64, pendingOnly bool, filters *RunLogFilters) ([]*MarketMakingEvent, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	if err := db.checkRun(startTime, mkt); err != nil {
+		return nil, err
+	}
+
+	events := make([]*MarketMakingEvent, 0, len(db.events))
+	for _, e := range db.events {
+		if refID != nil && e.ID > *refID {
+			continue
+		}
+		if pendingOnly && !e.Pending {
+			continue
+		}
+		if filters != nil && !filters.filter(e) {
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID > events[j].ID })
+	if n > 0 && uint64(len(events)) > n {
+		events = events[:n]
+	}
+	return events, nil
+}
+
+func (db *memEventLogDB) backup(io.Writer) error {
+	return errors.New("in-memory event log cannot be backed up")
+}
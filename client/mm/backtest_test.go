@@ -0,0 +1,265 @@
+package mm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+)
+
+func TestTakeLiquidity(t *testing.T) {
+	const lotSize = 10
+	levels := func() []*BookLevel {
+		return []*BookLevel{{Rate: 100, Qty: 15}, {Rate: 90, Qty: 12}, {Rate: 80, Qty: 100}}
+	}
+	sellCrosses := func(orderRate uint64) func(uint64) bool {
+		return func(rate uint64) bool { return rate >= orderRate }
+	}
+
+	tests := []struct {
+		name      string
+		qty       uint64
+		orderRate uint64
+		expTaken  uint64
+		expRemain []uint64
+	}{
+		{
+			name:      "partial first level, rounded to lot",
+			qty:       50,
+			orderRate: 100,
+			expTaken:  10,
+			expRemain: []uint64{5, 12, 100},
+		},
+		{
+			name:      "two levels",
+			qty:       50,
+			orderRate: 90,
+			expTaken:  20,
+			expRemain: []uint64{0, 7, 100},
+		},
+		{
+			name:      "limited by qty",
+			qty:       30,
+			orderRate: 80,
+			expTaken:  30,
+			expRemain: []uint64{0, 0, 97},
+		},
+		{
+			name:      "no crossing levels",
+			qty:       30,
+			orderRate: 110,
+			expTaken:  0,
+			expRemain: []uint64{15, 12, 100},
+		},
+	}
+
+	for _, test := range tests {
+		ls := levels()
+		taken := takeLiquidity(ls, test.qty, lotSize, sellCrosses(test.orderRate))
+		if taken != test.expTaken {
+			t.Fatalf("%s: expected %d taken, got %d", test.name, test.expTaken, taken)
+		}
+		for i, l := range ls {
+			if l.Qty != test.expRemain[i] {
+				t.Fatalf("%s: expected %d remaining at level %d, got %d", test.name, test.expRemain[i], i, l.Qty)
+			}
+		}
+	}
+}
+
+func TestBacktestJSONSource(t *testing.T) {
+	epochs := []*BacktestEpoch{
+		{Epoch: 1, Stamp: 1000, DEXBuys: []*BookLevel{{Rate: 1, Qty: 2}}},
+		{Epoch: 2, Stamp: 2000, Matches: []*BacktestMatch{{Rate: 3, Qty: 4, TakerSell: true}}},
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range epochs {
+		if err := enc.Encode(e); err != nil {
+			t.Fatalf("error encoding epoch: %v", err)
+		}
+	}
+
+	src := NewBacktestJSONSource(&buf)
+	for _, exp := range epochs {
+		e, err := src.NextEpoch()
+		if err != nil {
+			t.Fatalf("error reading epoch: %v", err)
+		}
+		expB, _ := json.Marshal(exp)
+		eB, _ := json.Marshal(e)
+		if !bytes.Equal(expB, eB) {
+			t.Fatalf("expected epoch %s, got %s", expB, eB)
+		}
+	}
+	if _, err := src.NextEpoch(); err == nil {
+		t.Fatalf("expected EOF")
+	}
+}
+
+func TestBacktestBasicMM(t *testing.T) {
+	const lotSize = 1e8
+	book := func(e *BacktestEpoch) *BacktestEpoch {
+		e.Stamp = int64(e.Epoch) * 10_000
+		e.DEXBuys = []*BookLevel{{Rate: 19_000, Qty: 5 * lotSize}}
+		e.DEXSells = []*BookLevel{{Rate: 21_000, Qty: 5 * lotSize}}
+		e.OracleRate = 0.0002
+		return e
+	}
+	epochs := []*BacktestEpoch{
+		book(&BacktestEpoch{Epoch: 1}),
+		book(&BacktestEpoch{Epoch: 2}),
+		// A taker buy at a rate above the bot's sell.
+		book(&BacktestEpoch{Epoch: 3, Matches: []*BacktestMatch{{Rate: 20_500, Qty: 2 * lotSize}}}),
+		// A taker sell at a rate below the bot's buy.
+		book(&BacktestEpoch{Epoch: 4, Matches: []*BacktestMatch{{Rate: 19_500, Qty: lotSize, TakerSell: true}}}),
+		book(&BacktestEpoch{Epoch: 5}),
+	}
+
+	cfg := &BacktestConfig{
+		Bot: &BotConfig{
+			Host:    "host1",
+			BaseID:  42,
+			QuoteID: 0,
+			BasicMMConfig: &BasicMarketMakingConfig{
+				GapStrategy:    GapStrategyPercent,
+				SellPlacements: []*OrderPlacement{{Lots: 1, GapFactor: 0.01}},
+				BuyPlacements:  []*OrderPlacement{{Lots: 1, GapFactor: 0.01}},
+			},
+		},
+		Market: &core.Market{
+			BaseID:   42,
+			QuoteID:  0,
+			LotSize:  lotSize,
+			RateStep: 100,
+			EpochLen: 10_000,
+		},
+		DEXBalances: map[uint32]uint64{
+			42: 10 * lotSize,
+			0:  1e6,
+		},
+		FiatRates: map[uint32]float64{
+			42: 12,
+			0:  60_000,
+		},
+		BaseFees:  &LotFees{Swap: 1000, Redeem: 500},
+		QuoteFees: &LotFees{Swap: 200, Redeem: 100},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	res, err := RunBacktest(ctx, cfg, NewBacktestSliceSource(epochs), tLogger)
+	if err != nil {
+		t.Fatalf("backtest error: %v", err)
+	}
+
+	if res.Epochs != uint64(len(epochs)) || len(res.Inventory) != len(epochs) {
+		t.Fatalf("expected %d epochs, got %d epochs and %d inventory snapshots", len(epochs), res.Epochs, len(res.Inventory))
+	}
+
+	fills := res.Fills
+	if fills.DEXMatches != 2 || fills.DEXSellQty != lotSize || fills.DEXBuyQty != lotSize {
+		t.Fatalf("unexpected fill stats: %+v", fills)
+	}
+
+	// Sell at 20200 and buy at 19800.
+	if res.Fees.DEX[42] != 1500 || res.Fees.DEX[0] != 300 {
+		t.Fatalf("unexpected dex fees: %v", res.Fees.DEX)
+	}
+
+	finalBals := res.Overview.FinalState.Balances
+	totalBal := func(assetID uint32) uint64 {
+		bal := finalBals[assetID]
+		return bal.Available + bal.Locked + bal.Pending + bal.Reserved
+	}
+	if bal := totalBal(42); bal != 10*lotSize-1500 {
+		t.Fatalf("unexpected final dcr balance %d", bal)
+	}
+	if bal := totalBal(0); bal != 1e6+20_200-19_800-300 {
+		t.Fatalf("unexpected final btc balance %d", bal)
+	}
+	if res.Overview.ProfitLoss == nil {
+		t.Fatalf("no profit loss")
+	}
+	if len(res.Events) == 0 {
+		t.Fatalf("no events")
+	}
+}
+
+func TestBacktestSimpleArb(t *testing.T) {
+	const lotSize = 1e8
+	epochs := make([]*BacktestEpoch, 0, 4)
+	for i := uint64(1); i <= 4; i++ {
+		epochs = append(epochs, &BacktestEpoch{
+			Epoch:    i,
+			Stamp:    int64(i) * 10_000,
+			DEXBuys:  []*BookLevel{{Rate: 20_000, Qty: lotSize}},
+			DEXSells: []*BookLevel{{Rate: 21_000, Qty: lotSize}},
+			CEXBuys:  []*BookLevel{{Rate: 18_900, Qty: 10 * lotSize}},
+			CEXSells: []*BookLevel{{Rate: 19_000, Qty: 10 * lotSize}},
+		})
+	}
+
+	cfg := &BacktestConfig{
+		Bot: &BotConfig{
+			Host:    "host1",
+			BaseID:  42,
+			QuoteID: 0,
+			CEXName: "Binance",
+			SimpleArbConfig: &SimpleArbConfig{
+				ProfitTrigger:      0.01,
+				MaxActiveArbs:      5,
+				NumEpochsLeaveOpen: 5,
+			},
+		},
+		Market: &core.Market{
+			BaseID:   42,
+			QuoteID:  0,
+			LotSize:  lotSize,
+			RateStep: 100,
+			EpochLen: 10_000,
+		},
+		DEXBalances: map[uint32]uint64{
+			42: 10 * lotSize,
+			0:  1e6,
+		},
+		CEXBalances: map[uint32]uint64{
+			42: 10 * lotSize,
+			0:  1e6,
+		},
+		FiatRates: map[uint32]float64{
+			42: 12,
+			0:  60_000,
+		},
+		CEXFeeRate: 0.001,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	res, err := RunBacktest(ctx, cfg, NewBacktestSliceSource(epochs), tLogger)
+	if err != nil {
+		t.Fatalf("backtest error: %v", err)
+	}
+
+	fills := res.Fills
+	if fills.DEXSellQty == 0 || fills.CEXBuyQty == 0 {
+		t.Fatalf("expected arbitrage trades, got %+v", fills)
+	}
+	// Every arb places one order on each exchange. The DEX order placed in
+	// the final epoch is never matched and is canceled when the run ends.
+	if fills.DEXOrders != fills.CEXTrades {
+		t.Fatalf("%d dex orders != %d cex trades", fills.DEXOrders, fills.CEXTrades)
+	}
+	if fills.DEXSellQty+lotSize != fills.CEXBuyQty || fills.DEXOrdersCanceled != 1 {
+		t.Fatalf("unexpected fill stats: %+v", fills)
+	}
+	if res.Fees.CEX[42] == 0 {
+		t.Fatalf("expected cex fees")
+	}
+	if res.Overview.ProfitLoss.Profit <= 0 {
+		t.Fatalf("expected profit, got %f", res.Overview.ProfitLoss.Profit)
+	}
+}
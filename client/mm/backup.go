@@ -0,0 +1,34 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// BackupConfig writes the market making configuration file contents to w.
+func (m *MarketMaker) BackupConfig(w io.Writer) error {
+	data, err := json.MarshalIndent(m.defaultConfig(), "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshalling market making config: %v", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// BackupEventLog writes a consistent copy of the event log database to w. If
+// the MarketMaker is not connected, the database is not open and nothing is
+// written.
+func (m *MarketMaker) BackupEventLog(w io.Writer) error {
+	m.eventLogDBMtx.RLock()
+	db := m.eventLogDB
+	m.eventLogDBMtx.RUnlock()
+	if db == nil {
+		m.log.Warnf("Skipping event log backup. The event log database is not open.")
+		return nil
+	}
+	return db.backup(w)
+}
@@ -12,6 +12,11 @@ import (
 type MarketMakingConfig struct {
 	BotConfigs []*BotConfig `json:"botConfigs"`
 	CexConfigs []*CEXConfig `json:"cexConfigs"`
+	// Schedules start, stop, or update bots on a schedule.
+	Schedules []*BotSchedule `json:"schedules,omitempty"`
+	// Portfolio configures the portfolio allocator, which redistributes
+	// funds between the running bots.
+	Portfolio *PortfolioConfig `json:"portfolio,omitempty"`
 }
 
 func (cfg *MarketMakingConfig) Copy() *MarketMakingConfig {
@@ -21,6 +26,11 @@ func (cfg *MarketMakingConfig) Copy() *MarketMakingConfig {
 	}
 	copy(c.BotConfigs, cfg.BotConfigs)
 	copy(c.CexConfigs, cfg.CexConfigs)
+	if len(cfg.Schedules) > 0 {
+		c.Schedules = make([]*BotSchedule, len(cfg.Schedules))
+		copy(c.Schedules, cfg.Schedules)
+	}
+	c.Portfolio = cfg.Portfolio
 	return c
 }
 
@@ -151,10 +161,14 @@ type BotConfig struct {
 	// when they are starting the bot.
 	LotSize uint64 `json:"lotSize"`
 
+	// RiskLimits are optional limits that stop the bot if exceeded.
+	RiskLimits *RiskLimits `json:"riskLimits,omitempty"`
+
 	// Only one of the following configs should be set
 	BasicMMConfig        *BasicMarketMakingConfig `json:"basicMarketMakingConfig,omitempty"`
 	SimpleArbConfig      *SimpleArbConfig         `json:"simpleArbConfig,omitempty"`
 	ArbMarketMakerConfig *ArbMarketMakerConfig    `json:"arbMarketMakingConfig,omitempty"`
+	TriangularArbConfig  *TriangularArbConfig     `json:"triangularArbConfig,omitempty"`
 }
 
 func (c *BotConfig) copy() *BotConfig {
@@ -170,6 +184,9 @@ func (c *BotConfig) copy() *BotConfig {
 	if c.RPCConfig != nil {
 		b.RPCConfig = c.RPCConfig.copy()
 	}
+	if c.RiskLimits != nil {
+		b.RiskLimits = c.RiskLimits.copy()
+	}
 	if c.BasicMMConfig != nil {
 		b.BasicMMConfig = c.BasicMMConfig.copy()
 	}
@@ -179,6 +196,9 @@ func (c *BotConfig) copy() *BotConfig {
 	if c.ArbMarketMakerConfig != nil {
 		b.ArbMarketMakerConfig = c.ArbMarketMakerConfig.copy()
 	}
+	if c.TriangularArbConfig != nil {
+		b.TriangularArbConfig = c.TriangularArbConfig.copy()
+	}
 
 	return &b
 }
@@ -194,12 +214,23 @@ func (c *BotConfig) updateLotSize(oldLotSize, newLotSize uint64) {
 }
 
 func (c *BotConfig) validate() error {
+	if c.RiskLimits != nil {
+		if err := c.RiskLimits.validate(); err != nil {
+			return fmt.Errorf("invalid risk limits: %w", err)
+		}
+	}
+
 	if c.BasicMMConfig != nil {
+		if c.BasicMMConfig.Oracle != nil && c.BasicMMConfig.Oracle.CEXWeight > 0 && c.CEXName == "" {
+			return fmt.Errorf("oracle cex weight set without a cex")
+		}
 		return c.BasicMMConfig.validate()
 	} else if c.SimpleArbConfig != nil {
 		return c.SimpleArbConfig.validate()
 	} else if c.ArbMarketMakerConfig != nil {
 		return c.ArbMarketMakerConfig.validate()
+	} else if c.TriangularArbConfig != nil {
+		return c.TriangularArbConfig.validate(c.BaseID, c.QuoteID)
 	}
 
 	return fmt.Errorf("no bot config set")
@@ -208,7 +239,8 @@ func (c *BotConfig) validate() error {
 func validateConfigUpdate(old, new *BotConfig) error {
 	if (old.BasicMMConfig == nil) != (new.BasicMMConfig == nil) ||
 		(old.SimpleArbConfig == nil) != (new.SimpleArbConfig == nil) ||
-		(old.ArbMarketMakerConfig == nil) != (new.ArbMarketMakerConfig == nil) {
+		(old.ArbMarketMakerConfig == nil) != (new.ArbMarketMakerConfig == nil) ||
+		(old.TriangularArbConfig == nil) != (new.TriangularArbConfig == nil) {
 		return fmt.Errorf("cannot change bot type")
 	}
 
@@ -220,7 +252,18 @@ func (c *BotConfig) requiresPriceOracle() bool {
 }
 
 func (c *BotConfig) requiresCEX() bool {
-	return c.SimpleArbConfig != nil || c.ArbMarketMakerConfig != nil
+	return c.SimpleArbConfig != nil || c.ArbMarketMakerConfig != nil || c.TriangularArbConfig != nil
+}
+
+// cexAssets returns the assets that the bot trades on the CEX.
+func (c *BotConfig) cexAssets() []uint32 {
+	if !c.requiresCEX() {
+		return nil
+	}
+	if c.TriangularArbConfig != nil {
+		return []uint32{c.BaseID, c.QuoteID, c.TriangularArbConfig.BridgeAssetID}
+	}
+	return []uint32{c.BaseID, c.QuoteID}
 }
 
 // multiSplitBuffer returns the additional buffer to add to the order size
@@ -244,7 +287,7 @@ func (c *BotConfig) multiSplitBuffer() float64 {
 // either side of the market in an epoch.
 func (c *BotConfig) maxPlacements() (buy, sell uint32) {
 	switch {
-	case c.SimpleArbConfig != nil:
+	case c.SimpleArbConfig != nil, c.TriangularArbConfig != nil:
 		return 1, 1
 	case c.ArbMarketMakerConfig != nil:
 		return uint32(len(c.ArbMarketMakerConfig.BuyPlacements)), uint32(len(c.ArbMarketMakerConfig.SellPlacements))
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"decred.org/dcrdex/client/asset"
 	"decred.org/dcrdex/dex"
@@ -28,6 +29,8 @@ type DEXOrderEvent struct {
 // CEXOrderEvent represents a a cex order that a bot placed.
 type CEXOrderEvent struct {
 	ID          string `json:"id"`
+	BaseID      uint32 `json:"baseID"`
+	QuoteID     uint32 `json:"quoteID"`
 	Rate        uint64 `json:"rate"`
 	Qty         uint64 `json:"qty"`
 	Sell        bool   `json:"sell"`
@@ -64,6 +67,7 @@ type MarketMakingEvent struct {
 	WithdrawalEvent *WithdrawalEvent  `json:"withdrawalEvent,omitempty"`
 	UpdateConfig    *BotConfig        `json:"updateConfig,omitempty"`
 	UpdateInventory *map[uint32]int64 `json:"updateInventory,omitempty"`
+	RiskLimitEvent  *RiskLimitEvent   `json:"riskLimitEvent,omitempty"`
 }
 
 // MarketMakingRun identifies a market making run.
@@ -119,6 +123,8 @@ type eventLogDB interface {
 	// including and after the event with the ID will be returned. If
 	// pendingOnly is true, only pending events will be returned.
 	runEvents(startTime int64, mkt *MarketWithHost, n uint64, refID *uint64, pendingOnly bool, filters *RunLogFilters) ([]*MarketMakingEvent, error)
+	// backup writes a consistent copy of the database to w.
+	backup(w io.Writer) error
 }
 
 // eventUpdate is used to asynchronously add events to the event log.
@@ -382,6 +388,14 @@ func parseRunKey(key []byte) (startTime int64, mkt *MarketWithHost, err error) {
 	return
 }
 
+// backup writes a consistent copy of the database to w.
+func (db *boltEventLogDB) backup(w io.Writer) error {
+	return db.View(func(tx *bbolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
+
 func (db *boltEventLogDB) Close() error {
 	close(db.eventUpdates)
 	return db.DB.Close()
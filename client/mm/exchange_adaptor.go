@@ -420,7 +420,8 @@ type unifiedExchangeAdaptor struct {
 	orderUpdates    atomic.Value // chan *core.Order
 	mwh             *MarketWithHost
 	eventLogDB      eventLogDB
-	botCfgV         atomic.Value // *BotConfig
+	botCfgV         atomic.Value                        // *BotConfig
+	saveLotSize     func(oldLotSize, newLotSize uint64) // saves lot size changes to the stored config, may be nil
 	initialBalances map[uint32]uint64
 	baseTraits      asset.WalletTrait
 	quoteTraits     asset.WalletTrait
@@ -476,6 +477,8 @@ type unifiedExchangeAdaptor struct {
 
 	epochReport atomic.Value // *EpochReport
 
+	risk riskMonitor
+
 	cexProblemsMtx sync.RWMutex
 	cexProblems    *CEXProblems
 }
@@ -683,10 +686,10 @@ func (u *unifiedExchangeAdaptor) SufficientBalanceForCEXTrade(baseID, quoteID ui
 	var fromAssetID uint32
 	var fromAssetQty uint64
 	if sell {
-		fromAssetID = u.baseID
+		fromAssetID = baseID
 		fromAssetQty = qty
 	} else {
-		fromAssetID = u.quoteID
+		fromAssetID = quoteID
 		fromAssetQty = calc.BaseToQuote(rate, qty)
 	}
 
@@ -749,6 +752,8 @@ func cexOrderEvent(trade *libxc.Trade, eventID uint64, timestamp int64) *MarketM
 		BalanceEffects: cexTradeBalanceEffects(trade),
 		CEXOrderEvent: &CEXOrderEvent{
 			ID:          trade.ID,
+			BaseID:      trade.BaseID,
+			QuoteID:     trade.QuoteID,
 			Rate:        trade.Rate,
 			Qty:         trade.Qty,
 			Sell:        trade.Sell,
@@ -2754,6 +2759,8 @@ func (u *unifiedExchangeAdaptor) handleDEXOrderUpdate(o *core.Order) {
 	}
 	pendingOrder.txsMtx.Unlock()
 
+	u.recordSwapOutcomes(o)
+
 	orderUpdates := u.orderUpdates.Load()
 	if orderUpdates != nil {
 		orderUpdates.(chan *core.Order) <- o
@@ -2795,15 +2802,21 @@ func (u *unifiedExchangeAdaptor) handleServerConfigUpdate() {
 	}
 
 	err = u.withPause(func() error {
-		if coreMkt.LotSize != u.lotSize.Load() {
+		if oldLotSize := u.lotSize.Load(); coreMkt.LotSize != oldLotSize {
 			cfg := u.botCfg()
 			copy := cfg.copy()
-			copy.updateLotSize(u.lotSize.Load(), coreMkt.LotSize)
+			copy.updateLotSize(oldLotSize, coreMkt.LotSize)
+			copy.LotSize = coreMkt.LotSize
 			err := u.updateConfig(copy)
 			if err != nil {
 				return err
 			}
 			u.lotSize.Store(coreMkt.LotSize)
+			// Save the new lot size so that the bot can be restarted without
+			// overriding the lot size change.
+			if u.saveLotSize != nil {
+				u.saveLotSize(oldLotSize, coreMkt.LotSize)
+			}
 		}
 		u.rateStep.Store(coreMkt.RateStep)
 		return nil
@@ -2841,6 +2854,9 @@ func (u *unifiedExchangeAdaptor) handleDEXNotification(n core.Notification) {
 		}
 	case *core.FiatRatesNote:
 		u.fiatRates.Store(note.FiatRates)
+		if u.botCfg().RiskLimits != nil {
+			u.checkRiskLimits(u.stats())
+		}
 	case *core.ServerConfigUpdateNote:
 		if note.Host != u.host {
 			return
@@ -3610,7 +3626,9 @@ func (u *unifiedExchangeAdaptor) stats() *RunStats {
 }
 
 func (u *unifiedExchangeAdaptor) sendStatsUpdate() {
-	u.clientCore.Broadcast(newRunStatsNote(u.host, u.baseID, u.quoteID, u.stats()))
+	stats := u.stats()
+	u.clientCore.Broadcast(newRunStatsNote(u.host, u.baseID, u.quoteID, stats))
+	u.checkRiskLimits(stats)
 }
 
 func (u *unifiedExchangeAdaptor) notifyEvent(e *MarketMakingEvent) {
@@ -3854,6 +3872,7 @@ type exchangeAdaptorCfg struct {
 	log                 dex.Logger
 	eventLogDB          eventLogDB
 	botCfg              *BotConfig
+	saveLotSize         func(oldLotSize, newLotSize uint64)
 }
 
 // newUnifiedExchangeAdaptor is the constructor for a unifiedExchangeAdaptor.
@@ -3901,6 +3920,7 @@ func newUnifiedExchangeAdaptor(cfg *exchangeAdaptorCfg) (*unifiedExchangeAdaptor
 		botID:            cfg.botID,
 		log:              cfg.log,
 		eventLogDB:       cfg.eventLogDB,
+		saveLotSize:      cfg.saveLotSize,
 		initialBalances:  initialBalances,
 		baseTraits:       baseTraits,
 		quoteTraits:      quoteTraits,
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"sort"
@@ -84,6 +85,9 @@ func (db *tEventLogDB) runOverview(startTime int64, mkt *MarketWithHost) (*Marke
 func (db *tEventLogDB) runEvents(startTime int64, mkt *MarketWithHost, n uint64, refID *uint64, pendingOnly bool, filters *RunLogFilters) ([]*MarketMakingEvent, error) {
 	return nil, nil
 }
+func (db *tEventLogDB) backup(io.Writer) error {
+	return nil
+}
 
 func tFees(swap, redeem, refund, funding uint64) *OrderFees {
 	lotFees := &LotFees{
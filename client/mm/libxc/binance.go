@@ -375,6 +375,13 @@ func init() {
 	for key, value := range dexToBinanceSymbol {
 		binanceToDexSymbol[value] = key
 	}
+
+	RegisterCEX(Binance, func(cfg *CEXConfig) (CEX, error) {
+		return newBinance(cfg, false), nil
+	})
+	RegisterCEX(BinanceUS, func(cfg *CEXConfig) (CEX, error) {
+		return newBinance(cfg, true), nil
+	})
 }
 
 func mapDexToBinanceSymbol(symbol string) string {
@@ -520,6 +527,7 @@ type binance struct {
 }
 
 var _ CEX = (*binance)(nil)
+var _ BookSubscriber = (*binance)(nil)
 
 // TODO: Investigate stablecoin auto-conversion.
 // https://developers.binance.com/docs/wallet/endpoints/switch-busd-stable-coins-convertion
@@ -1225,6 +1233,7 @@ func (bnc *binance) Markets(ctx context.Context) (map[string]*Market, error) {
 		return nil, err
 	}
 
+	bnMarkets := bnc.markets.Load().(map[string]*bntypes.Market)
 	m := make(map[string]*Market, len(ds))
 	for _, d := range ds {
 		ms, found := mkts[d.Symbol]
@@ -1232,6 +1241,10 @@ func (bnc *binance) Markets(ctx context.Context) (map[string]*Market, error) {
 			bnc.log.Errorf("Market %s not returned in market data request", d.Symbol)
 			continue
 		}
+		var lotSize uint64
+		if bnMkt := bnMarkets[d.Symbol]; bnMkt != nil {
+			lotSize = bnMkt.LotSize
+		}
 		for _, mkt := range ms {
 			baseMinWithdraw, quoteMinWithdraw := bnc.minimumWithdraws(mkt.BaseID, mkt.QuoteID)
 			m[mkt.MarketID] = &Market{
@@ -1239,6 +1252,7 @@ func (bnc *binance) Markets(ctx context.Context) (map[string]*Market, error) {
 				QuoteID:          mkt.QuoteID,
 				BaseMinWithdraw:  baseMinWithdraw,
 				QuoteMinWithdraw: quoteMinWithdraw,
+				LotSize:          lotSize,
 				Day: &MarketDay{
 					Vol:            d.Volume,
 					QuoteVol:       d.QuoteVolume,
@@ -2097,6 +2111,17 @@ func (bnc *binance) Book(baseID, quoteID uint32) (buys, sells []*core.MiniOrder,
 	return
 }
 
+// SubscribeBookUpdates returns a feed of updates to a market's order book.
+// SubscribeMarket must be called first. Part of the BookSubscriber interface.
+func (bnc *binance) SubscribeBookUpdates(baseID, quoteID uint32) (<-chan *BookUpdate, func(), error) {
+	book, err := bnc.book(baseID, quoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	updates, unsubscribe := book.book.subscribe()
+	return updates, unsubscribe, nil
+}
+
 // VWAP returns the volume weighted average price for a certain quantity
 // of the base asset on a market. SubscribeMarket must be called, and the
 // market must be synced before results can be expected.
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
 	"decred.org/dcrdex/client/core"
 	"decred.org/dcrdex/dex"
@@ -48,6 +50,9 @@ type Market struct {
 	Day              *MarketDay `json:"day"`
 	BaseMinWithdraw  uint64     `json:"baseMinWithdraw"`
 	QuoteMinWithdraw uint64     `json:"quoteMinWithdraw"`
+	// LotSize is the quantity step of the market, in atoms of the base
+	// asset.
+	LotSize uint64 `json:"lotSize"`
 }
 
 type Status struct {
@@ -76,6 +81,22 @@ type BalanceUpdate struct {
 	Balance *ExchangeBalance `json:"balance"`
 }
 
+// BookEntry is a price level in a CEX order book. Rate is a message-rate and
+// Qty is in units of the base asset.
+type BookEntry struct {
+	Rate uint64 `json:"rate"`
+	Qty  uint64 `json:"qty"`
+}
+
+// BookUpdate is an update to a CEX order book. If Snapshot is true, the
+// entries replace the book. Otherwise, each entry sets the quantity at its
+// rate, with a zero quantity removing the level.
+type BookUpdate struct {
+	Snapshot bool         `json:"snapshot"`
+	Bids     []*BookEntry `json:"bids"`
+	Asks     []*BookEntry `json:"asks"`
+}
+
 var (
 	ErrWithdrawalPending = errors.New("withdrawal pending")
 	ErrUnsyncedOrderbook = errors.New("orderbook not synced")
@@ -136,14 +157,64 @@ type CEX interface {
 	Book(baseID, quoteID uint32) (buys, sells []*core.MiniOrder, _ error)
 }
 
+// BookSubscriber is implemented by CEXs that can stream updates to the order
+// books of subscribed markets.
+type BookSubscriber interface {
+	// SubscribeBookUpdates returns a feed of updates to a market's order
+	// book. SubscribeMarket must be called first. The first update on the
+	// feed is a snapshot. A snapshot is also sent whenever the book is
+	// resynced or the subscriber falls behind. The channel is closed when
+	// unsubscribe is called.
+	SubscribeBookUpdates(baseID, quoteID uint32) (updates <-chan *BookUpdate, unsubscribe func(), err error)
+}
+
 const (
 	Binance   = "Binance"
 	BinanceUS = "BinanceUS"
+	Kraken    = "Kraken"
 )
 
+// CEXConstructor is a function that creates a new CEX.
+type CEXConstructor func(cfg *CEXConfig) (CEX, error)
+
+var (
+	cexesMtx sync.RWMutex
+	cexes    = make(map[string]CEXConstructor)
+)
+
+// RegisterCEX registers a constructor for a CEX. RegisterCEX should be called
+// from an init function. RegisterCEX panics if a CEX with the same name is
+// already registered.
+func RegisterCEX(cexName string, constructor CEXConstructor) {
+	cexesMtx.Lock()
+	defer cexesMtx.Unlock()
+	if constructor == nil {
+		panic("libxc: nil CEX constructor for " + cexName)
+	}
+	if _, found := cexes[cexName]; found {
+		panic("libxc: CEX " + cexName + " already registered")
+	}
+	cexes[cexName] = constructor
+}
+
+// RegisteredCEXes returns the sorted names of all registered CEXes.
+func RegisteredCEXes() []string {
+	cexesMtx.RLock()
+	defer cexesMtx.RUnlock()
+	names := make([]string, 0, len(cexes))
+	for name := range cexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // IsValidCEXName returns whether or not a cex name is supported.
 func IsValidCexName(cexName string) bool {
-	return cexName == Binance || cexName == BinanceUS
+	cexesMtx.RLock()
+	defer cexesMtx.RUnlock()
+	_, found := cexes[cexName]
+	return found
 }
 
 type CEXConfig struct {
@@ -156,12 +227,11 @@ type CEXConfig struct {
 
 // NewCEX creates a new CEX.
 func NewCEX(cexName string, cfg *CEXConfig) (CEX, error) {
-	switch cexName {
-	case Binance:
-		return newBinance(cfg, false), nil
-	case BinanceUS:
-		return newBinance(cfg, true), nil
-	default:
+	cexesMtx.RLock()
+	constructor, found := cexes[cexName]
+	cexesMtx.RUnlock()
+	if !found {
 		return nil, fmt.Errorf("unrecognized CEX: %v", cexName)
 	}
+	return constructor(cfg)
 }
@@ -0,0 +1,1600 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package libxc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/client/comms"
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc/krtypes"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/calc"
+	"decred.org/dcrdex/dex/dexnet"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/utils"
+)
+
+// Kraken API docs:
+// REST: https://docs.kraken.com/api/docs/rest-api/add-order
+// Websocket v2: https://docs.kraken.com/api/docs/websocket-v2/book
+
+const (
+	krakenHttpURL     = "https://api.kraken.com"
+	krakenWsURL       = "wss://ws.kraken.com/v2"
+	krakenWsAuthURL   = "wss://ws-auth.kraken.com/v2"
+	krakenBookDepth   = 500
+	krakenSyncTimeout = time.Second * 30
+
+	// Kraken does not have a test network. On testnet and simnet, we connect
+	// to the process at client/cmd/testkraken.
+	fakeKrakenURL       = "http://localhost:37347"
+	fakeKrakenWsURL     = "ws://localhost:37347/v2"
+	fakeKrakenWsAuthURL = "ws://localhost:37347/v2/auth"
+)
+
+// dexToKrakenSymbol maps DEX symbols to Kraken asset names where they differ.
+var dexToKrakenSymbol = map[string]string{
+	"btc":     "XBT",
+	"doge":    "XDG",
+	"polygon": "POL",
+	"weth":    "ETH",
+}
+
+var krakenToDexSymbol = map[string]string{
+	"XBT": "btc",
+	"XDG": "doge",
+	"POL": "polygon",
+}
+
+// krakenWSSymbols maps Kraken REST asset names to the names used by the
+// websocket v2 API where they differ.
+var krakenWSSymbols = strings.NewReplacer("XBT", "BTC", "XDG", "DOGE")
+
+// krakenTokenNetworks maps the DEX symbol of a token's parent chain to the
+// name used for that network in Kraken's funding method names.
+var krakenTokenNetworks = map[string]string{
+	"eth":     "ERC20",
+	"polygon": "Polygon",
+	"base":    "Base",
+}
+
+func mapDexToKrakenSymbol(symbol string) string {
+	if krakenSymbol, found := dexToKrakenSymbol[strings.ToLower(symbol)]; found {
+		return krakenSymbol
+	}
+	return strings.ToUpper(symbol)
+}
+
+// convertKrakenCoin converts a Kraken asset name to a DEX symbol.
+func convertKrakenCoin(coin string) string {
+	if symbol, found := krakenToDexSymbol[strings.ToUpper(coin)]; found {
+		return symbol
+	}
+	return strings.ToLower(coin)
+}
+
+type krAssetConfig struct {
+	assetID uint32
+	// symbol is the DEX asset symbol, always lower case.
+	symbol string
+	// coin is the Kraken asset name, always upper case.
+	coin string
+	// network is the network name used in Kraken's deposit and withdrawal
+	// method names. network is only set for tokens.
+	network          string
+	conversionFactor uint64
+}
+
+func krAssetCfg(assetID uint32) (*krAssetConfig, error) {
+	ui, err := asset.UnitInfo(assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := dex.BipIDSymbol(assetID)
+	if symbol == "" {
+		return nil, fmt.Errorf("no symbol found for asset ID %d", assetID)
+	}
+
+	parts := strings.Split(symbol, ".")
+	var network string
+	if len(parts) > 1 {
+		var found bool
+		if network, found = krakenTokenNetworks[parts[1]]; !found {
+			return nil, fmt.Errorf("%s network not supported by Kraken", parts[1])
+		}
+	}
+
+	return &krAssetConfig{
+		assetID:          assetID,
+		symbol:           symbol,
+		coin:             mapDexToKrakenSymbol(parts[0]),
+		network:          network,
+		conversionFactor: ui.Conventional.ConversionFactor,
+	}, nil
+}
+
+func krAssetCfgs(baseID, quoteID uint32) (*krAssetConfig, *krAssetConfig, error) {
+	baseCfg, err := krAssetCfg(baseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	quoteCfg, err := krAssetCfg(quoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return baseCfg, quoteCfg, nil
+}
+
+// krakenCoinDEXAssetIDs returns the IDs of all registered DEX assets that are
+// represented by the Kraken asset. Tokens are only included if their network
+// is supported.
+func krakenCoinDEXAssetIDs(coin string) []uint32 {
+	symbol := convertKrakenCoin(coin)
+	tokenSymbol := symbol
+	if symbol == "eth" {
+		tokenSymbol = "weth"
+	}
+
+	assetIDs := make([]uint32, 0, 1)
+	for assetID, ra := range asset.Assets() {
+		if ra.Symbol == symbol {
+			assetIDs = append(assetIDs, assetID)
+		}
+		if _, found := krakenTokenNetworks[ra.Symbol]; !found {
+			continue
+		}
+		for tokenID := range ra.Tokens {
+			if dex.TokenSymbol(dex.BipIDSymbol(tokenID)) == tokenSymbol {
+				assetIDs = append(assetIDs, tokenID)
+			}
+		}
+	}
+	return assetIDs
+}
+
+// KrakenErr is the error returned when a Kraken API response contains errors.
+type KrakenErr struct {
+	Errors []string
+}
+
+func (e *KrakenErr) Error() string {
+	return fmt.Sprintf("kraken errors: %s", strings.Join(e.Errors, ", "))
+}
+
+// krakenSignature generates the API-Sign header for a private endpoint
+// request.
+func krakenSignature(path, nonce, postData string, secret []byte) string {
+	sha := sha256.Sum256([]byte(nonce + postData))
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(append([]byte(path), sha[:]...))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// krakenOrderBook is the order book for a single market. Kraken sends a
+// full snapshot on subscription, followed by incremental updates.
+type krakenOrderBook struct {
+	mtx                   sync.Mutex
+	numSubscribers        uint32
+	syncChan              chan struct{}
+	synced                atomic.Bool
+	book                  *orderbook
+	wsSymbol              string
+	baseConversionFactor  uint64
+	quoteConversionFactor uint64
+}
+
+func newKrakenOrderBook(wsSymbol string, baseConversionFactor, quoteConversionFactor uint64) *krakenOrderBook {
+	return &krakenOrderBook{
+		numSubscribers:        1,
+		syncChan:              make(chan struct{}),
+		book:                  newOrderBook(),
+		wsSymbol:              wsSymbol,
+		baseConversionFactor:  baseConversionFactor,
+		quoteConversionFactor: quoteConversionFactor,
+	}
+}
+
+// krakenMsgRate converts a Kraken price to a message-rate. Kraken prices are
+// decimal strings, so the rate is rounded rather than truncated to undo any
+// floating point error in the conversion.
+func krakenMsgRate(price float64, baseFactor, quoteFactor uint64) uint64 {
+	return uint64(math.Round(price * calc.RateEncodingFactor / float64(baseFactor) * float64(quoteFactor)))
+}
+
+func (b *krakenOrderBook) convert(levels []*krtypes.PriceLevel) []*obEntry {
+	entries := make([]*obEntry, 0, len(levels))
+	for _, l := range levels {
+		entries = append(entries, &obEntry{
+			rate: krakenMsgRate(l.Price, b.baseConversionFactor, b.quoteConversionFactor),
+			qty:  uint64(math.Round(l.Qty * float64(b.baseConversionFactor))),
+		})
+	}
+	return entries
+}
+
+func (b *krakenOrderBook) handleUpdate(update *krtypes.BookUpdate, snapshot bool) {
+	if snapshot {
+		b.book.clear()
+	} else if !b.synced.Load() {
+		// Updates received before the snapshot are not applicable.
+		return
+	}
+	b.book.update(b.convert(update.Bids), b.convert(update.Asks))
+	if snapshot {
+		b.synced.Store(true)
+		b.mtx.Lock()
+		if b.syncChan != nil {
+			close(b.syncChan)
+			b.syncChan = nil
+		}
+		b.mtx.Unlock()
+	}
+}
+
+func (b *krakenOrderBook) vwap(bids bool, qty uint64) (vwap, extrema uint64, filled bool, err error) {
+	if !b.synced.Load() {
+		return 0, 0, false, ErrUnsyncedOrderbook
+	}
+	vwap, extrema, filled = b.book.vwap(bids, qty)
+	return
+}
+
+type krTradeInfo struct {
+	updaterID   int
+	orderID     string
+	baseID      uint32
+	quoteID     uint32
+	sell        bool
+	rate        uint64
+	qty         uint64
+	baseFilled  uint64
+	quoteFilled uint64
+}
+
+type kraken struct {
+	log          dex.Logger
+	apiURL       string
+	wsURL        string
+	wsAuthURL    string
+	apiKey       string
+	secret       []byte
+	net          dex.Network
+	broadcast    func(interface{})
+	nonce        atomic.Uint64
+	tradeIDNonce atomic.Uint32
+	// tradeIDNoncePrefix is 5 bytes so that the hex-encoded trade ID fits
+	// in the 18 characters that Kraken allows for free-text client order IDs.
+	tradeIDNoncePrefix dex.Bytes
+
+	// assets maps Kraken's internal asset names, e.g. XXBT, to the asset
+	// info.
+	assets atomic.Value // map[string]*krtypes.AssetInfo
+	// pairs maps Kraken's internal pair names, e.g. XXBTZUSD, to the pair
+	// info.
+	pairs atomic.Value // map[string]*krtypes.AssetPair
+	// withdrawMethods maps DEX asset IDs to their withdraw method.
+	withdrawMethods atomic.Value // map[uint32]*krtypes.WithdrawMethod
+
+	marketSnapshotMtx sync.Mutex
+	marketSnapshot    struct {
+		stamp time.Time
+		m     map[string]*Market
+	}
+
+	balanceMtx sync.RWMutex
+	balances   map[uint32]*ExchangeBalance
+
+	marketStreamMtx sync.Mutex
+	marketStream    comms.WsConn
+	marketStreamCM  *dex.ConnectionMaster
+
+	userStream atomic.Value // comms.WsConn
+
+	booksMtx sync.RWMutex
+	books    map[string]*krakenOrderBook
+
+	tradeUpdaterMtx    sync.RWMutex
+	tradeInfo          map[string]*krTradeInfo // keyed by client order ID
+	tradeUpdaters      map[int]chan *Trade
+	tradeUpdateCounter int
+
+	balanceRefresh chan struct{}
+}
+
+var _ CEX = (*kraken)(nil)
+var _ BookSubscriber = (*kraken)(nil)
+
+func init() {
+	RegisterCEX(Kraken, func(cfg *CEXConfig) (CEX, error) {
+		return newKraken(cfg)
+	})
+}
+
+func newKraken(cfg *CEXConfig) (*kraken, error) {
+	secret, err := base64.StdEncoding.DecodeString(cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Kraken API secret: %w", err)
+	}
+
+	apiURL, wsURL, wsAuthURL := krakenHttpURL, krakenWsURL, krakenWsAuthURL
+	if cfg.Net != dex.Mainnet {
+		apiURL, wsURL, wsAuthURL = fakeKrakenURL, fakeKrakenWsURL, fakeKrakenWsAuthURL
+	}
+
+	kr := &kraken{
+		log:                cfg.Logger,
+		apiURL:             apiURL,
+		wsURL:              wsURL,
+		wsAuthURL:          wsAuthURL,
+		apiKey:             cfg.APIKey,
+		secret:             secret,
+		net:                cfg.Net,
+		broadcast:          cfg.Notify,
+		tradeIDNoncePrefix: encode.RandomBytes(5),
+		balances:           make(map[uint32]*ExchangeBalance),
+		books:              make(map[string]*krakenOrderBook),
+		tradeInfo:          make(map[string]*krTradeInfo),
+		tradeUpdaters:      make(map[int]chan *Trade),
+		balanceRefresh:     make(chan struct{}, 1),
+	}
+	kr.assets.Store(make(map[string]*krtypes.AssetInfo))
+	kr.pairs.Store(make(map[string]*krtypes.AssetPair))
+	kr.withdrawMethods.Store(make(map[uint32]*krtypes.WithdrawMethod))
+
+	return kr, nil
+}
+
+func (kr *kraken) nextNonce() string {
+	for {
+		last := kr.nonce.Load()
+		n := uint64(time.Now().UnixMicro())
+		if n <= last {
+			n = last + 1
+		}
+		if kr.nonce.CompareAndSwap(last, n) {
+			return strconv.FormatUint(n, 10)
+		}
+	}
+}
+
+func (kr *kraken) publicAPI(ctx context.Context, endpoint string, query url.Values, thing interface{}) error {
+	return kr.request(ctx, http.MethodGet, "/0/public/"+endpoint, query, false, thing)
+}
+
+func (kr *kraken) privateAPI(ctx context.Context, endpoint string, form url.Values, thing interface{}) error {
+	return kr.request(ctx, http.MethodPost, "/0/private/"+endpoint, form, true, thing)
+}
+
+func (kr *kraken) request(ctx context.Context, method, path string, params url.Values, sign bool, thing interface{}) error {
+	if params == nil {
+		params = make(url.Values)
+	}
+
+	fullURL := kr.apiURL + path
+	header := make(http.Header, 3)
+	body := bytes.NewBuffer(nil)
+	var encodedParams string
+	if sign {
+		nonce := kr.nextNonce()
+		params.Set("nonce", nonce)
+		encodedParams = params.Encode()
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+		header.Set("API-Key", kr.apiKey)
+		header.Set("API-Sign", krakenSignature(path, nonce, encodedParams, kr.secret))
+		body = bytes.NewBufferString(encodedParams)
+	} else if len(params) > 0 {
+		encodedParams = params.Encode()
+		fullURL = fmt.Sprintf("%s?%s", fullURL, encodedParams)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	if err != nil {
+		return fmt.Errorf("NewRequestWithContext error: %w", err)
+	}
+	req.Header = header
+
+	var resp krtypes.Response
+	if err := dexnet.Do(req, &resp, dexnet.WithSizeLimit(1<<24)); err != nil {
+		kr.log.Errorf("request error from endpoint %s %q: %v", method, path, err)
+		return err
+	}
+	if len(resp.Error) > 0 {
+		kr.log.Errorf("kraken error from endpoint %s %q with params %q: %s", method, path, encodedParams, resp.Err())
+		return &KrakenErr{Errors: resp.Error}
+	}
+	if thing == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, thing); err != nil {
+		return fmt.Errorf("error decoding %s result: %w", path, err)
+	}
+	return nil
+}
+
+// getAssets retrieves the Kraken asset list.
+func (kr *kraken) getAssets(ctx context.Context) error {
+	assets := make(map[string]*krtypes.AssetInfo)
+	if err := kr.publicAPI(ctx, "Assets", nil, &assets); err != nil {
+		return err
+	}
+	kr.assets.Store(assets)
+	return nil
+}
+
+// altName converts one of Kraken's internal asset names, e.g. XXBT, to the
+// asset's altname, e.g. XBT.
+func (kr *kraken) altName(krAsset string) string {
+	assets := kr.assets.Load().(map[string]*krtypes.AssetInfo)
+	if a, found := assets[krAsset]; found {
+		return a.AltName
+	}
+	return krAsset
+}
+
+func (kr *kraken) getPairs(ctx context.Context) (map[string]*krtypes.AssetPair, error) {
+	var resp map[string]*krtypes.AssetPair
+	if err := kr.publicAPI(ctx, "AssetPairs", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	pairs := make(map[string]*krtypes.AssetPair, len(resp))
+	for name, pair := range resp {
+		if pair.Status != "online" {
+			continue
+		}
+		baseIDs := krakenCoinDEXAssetIDs(kr.altName(pair.Base))
+		quoteIDs := krakenCoinDEXAssetIDs(kr.altName(pair.Quote))
+		if len(baseIDs) == 0 || len(quoteIDs) == 0 {
+			continue
+		}
+		// All DEX assets that match a Kraken asset have the same
+		// conversion factor, so we can use any of them.
+		bui, _ := asset.UnitInfo(baseIDs[0])
+		qui, _ := asset.UnitInfo(quoteIDs[0])
+		conv := float64(qui.Conventional.ConversionFactor) / float64(bui.Conventional.ConversionFactor) * calc.RateEncodingFactor
+		pair.RateStep = uint64(math.Round(pair.TickSize * conv))
+		pair.LotSize = uint64(math.Round(math.Pow10(-pair.LotDecimals) * float64(bui.Conventional.ConversionFactor)))
+		pair.MinQty = uint64(math.Round(pair.OrderMin * float64(bui.Conventional.ConversionFactor)))
+		if pair.RateStep == 0 || pair.LotSize == 0 {
+			kr.log.Errorf("Invalid tick size or lot decimals for Kraken pair %s", name)
+			continue
+		}
+		pairs[name] = pair
+	}
+
+	kr.pairs.Store(pairs)
+	return pairs, nil
+}
+
+// pair finds the Kraken pair for the DEX market.
+func (kr *kraken) pair(baseCfg, quoteCfg *krAssetConfig) (string, *krtypes.AssetPair, error) {
+	pairs := kr.pairs.Load().(map[string]*krtypes.AssetPair)
+	for name, pair := range pairs {
+		if kr.altName(pair.Base) == baseCfg.coin && kr.altName(pair.Quote) == quoteCfg.coin {
+			return name, pair, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no Kraken market for %s-%s", baseCfg.symbol, quoteCfg.symbol)
+}
+
+// getWithdrawMethods stores the withdraw method for each supported DEX
+// asset.
+func (kr *kraken) getWithdrawMethods(ctx context.Context) error {
+	var methods []*krtypes.WithdrawMethod
+	if err := kr.privateAPI(ctx, "WithdrawMethods", nil, &methods); err != nil {
+		return err
+	}
+	withdrawMethods := make(map[uint32]*krtypes.WithdrawMethod)
+	for _, m := range methods {
+		for _, assetID := range krakenCoinDEXAssetIDs(kr.altName(m.Asset)) {
+			assetCfg, err := krAssetCfg(assetID)
+			if err != nil {
+				continue
+			}
+			if assetCfg.network != "" && !strings.Contains(m.Method+" "+m.Network, assetCfg.network) {
+				continue
+			}
+			if _, found := withdrawMethods[assetID]; !found {
+				withdrawMethods[assetID] = m
+			}
+		}
+	}
+	kr.withdrawMethods.Store(withdrawMethods)
+	return nil
+}
+
+func (kr *kraken) withdrawMethod(assetID uint32) (*krtypes.WithdrawMethod, error) {
+	methods := kr.withdrawMethods.Load().(map[uint32]*krtypes.WithdrawMethod)
+	m, found := methods[assetID]
+	if !found {
+		return nil, fmt.Errorf("no withdraw method for %s", dex.BipIDSymbol(assetID))
+	}
+	return m, nil
+}
+
+func (kr *kraken) setBalances(ctx context.Context) error {
+	kr.balanceMtx.Lock()
+	defer kr.balanceMtx.Unlock()
+	_, err := kr.refreshBalances(ctx)
+	return err
+}
+
+// refreshBalances fetches the balances from Kraken and returns the balances
+// that changed. balanceMtx MUST be held.
+func (kr *kraken) refreshBalances(ctx context.Context) ([]*BalanceUpdate, error) {
+	var resp map[string]*krtypes.Balance
+	if err := kr.privateAPI(ctx, "BalanceEx", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	var updates []*BalanceUpdate
+	for krAsset, bal := range resp {
+		if strings.Contains(krAsset, ".") {
+			// Staked and opt-in rewards balances, e.g. DOT.S or XBT.M.
+			continue
+		}
+		for _, assetID := range krakenCoinDEXAssetIDs(kr.altName(krAsset)) {
+			ui, err := asset.UnitInfo(assetID)
+			if err != nil {
+				continue
+			}
+			locked := bal.HoldTrade
+			avail := bal.Balance - locked
+			if avail < 0 {
+				avail = 0
+			}
+			newBal := &ExchangeBalance{
+				Available: uint64(math.Round(avail * float64(ui.Conventional.ConversionFactor))),
+				Locked:    uint64(math.Round(locked * float64(ui.Conventional.ConversionFactor))),
+			}
+			if oldBal, found := kr.balances[assetID]; found && *oldBal != *newBal {
+				updates = append(updates, &BalanceUpdate{AssetID: assetID, Balance: newBal})
+			}
+			kr.balances[assetID] = newBal
+		}
+	}
+	return updates, nil
+}
+
+func (kr *kraken) refreshBalancesAndBroadcast(ctx context.Context) {
+	kr.balanceMtx.Lock()
+	updates, err := kr.refreshBalances(ctx)
+	kr.balanceMtx.Unlock()
+	if err != nil {
+		kr.log.Errorf("Error refreshing balances: %v", err)
+		return
+	}
+	for _, u := range updates {
+		kr.broadcast(u)
+	}
+}
+
+// Connect connects to the Kraken API.
+func (kr *kraken) Connect(ctx context.Context) (*sync.WaitGroup, error) {
+	if err := kr.getAssets(ctx); err != nil {
+		return nil, fmt.Errorf("error getting assets: %w", err)
+	}
+
+	if _, err := kr.getPairs(ctx); err != nil {
+		return nil, fmt.Errorf("error getting markets: %w", err)
+	}
+
+	if err := kr.getWithdrawMethods(ctx); err != nil {
+		return nil, fmt.Errorf("error getting withdraw methods: %w", err)
+	}
+
+	if err := kr.setBalances(ctx); err != nil {
+		return nil, fmt.Errorf("error getting balances: %w", err)
+	}
+
+	wg := new(sync.WaitGroup)
+
+	if err := kr.connectUserStream(ctx, wg); err != nil {
+		return nil, fmt.Errorf("error connecting to user data stream: %w", err)
+	}
+
+	// Refresh balances periodically, and whenever the user data stream
+	// indicates that a balance has changed. Kraken's balances channel reports
+	// only the total balance, so the amount on hold for trades must be
+	// fetched from the REST API.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-kr.balanceRefresh:
+			case <-ctx.Done():
+				return
+			}
+			kr.refreshBalancesAndBroadcast(ctx)
+		}
+	}()
+
+	// Refresh the markets and withdraw methods periodically.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		nextTick := time.After(time.Hour)
+		for {
+			select {
+			case <-nextTick:
+				_, err := kr.getPairs(ctx)
+				if err == nil {
+					err = kr.getWithdrawMethods(ctx)
+				}
+				if err != nil {
+					kr.log.Errorf("Error refreshing markets: %v", err)
+					nextTick = time.After(time.Minute)
+				} else {
+					nextTick = time.After(time.Hour)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		kr.marketStreamMtx.Lock()
+		if kr.marketStreamCM != nil {
+			kr.marketStreamCM.Disconnect()
+		}
+		kr.marketStream = nil
+		kr.marketStreamCM = nil
+		kr.marketStreamMtx.Unlock()
+	}()
+
+	return wg, nil
+}
+
+// sendKrakenWS marshals and sends a websocket v2 request.
+func sendKrakenWS(conn comms.WsConn, method string, params interface{}) error {
+	b, err := json.Marshal(&krtypes.WSRequest{
+		Method: method,
+		Params: params,
+		ReqID:  conn.NextID(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling %s request: %w", method, err)
+	}
+	return conn.SendRaw(b)
+}
+
+// subscribeUserChannels gets a new websockets token and subscribes to the
+// executions and balances channels.
+func (kr *kraken) subscribeUserChannels(ctx context.Context, conn comms.WsConn) error {
+	var token krtypes.WebSocketsToken
+	if err := kr.privateAPI(ctx, "GetWebSocketsToken", nil, &token); err != nil {
+		return fmt.Errorf("error getting websockets token: %w", err)
+	}
+	for _, channel := range []string{"executions", "balances"} {
+		if err := sendKrakenWS(conn, "subscribe", &krtypes.PrivateSubscription{
+			Channel: channel,
+			Token:   token.Token,
+		}); err != nil {
+			return fmt.Errorf("error subscribing to %s channel: %w", channel, err)
+		}
+	}
+	return nil
+}
+
+func (kr *kraken) connectUserStream(ctx context.Context, wg *sync.WaitGroup) error {
+	var conn comms.WsConn
+	conn, err := comms.NewWsConn(&comms.WsCfg{
+		URL: kr.wsAuthURL,
+		// Kraken sends heartbeat messages every second when subscribed
+		// to any channel.
+		PingWait:                time.Minute,
+		ExtendDeadlineOnMessage: true,
+		ReconnectSync: func() {
+			kr.log.Debugf("Kraken user data stream reconnected")
+			if err := kr.subscribeUserChannels(ctx, conn); err != nil {
+				kr.log.Errorf("Error resubscribing to user data stream: %v", err)
+			}
+			kr.requestBalanceRefresh()
+		},
+		Logger:     kr.log.SubLogger("KRWS"),
+		RawHandler: kr.handleUserDataStreamUpdate,
+	})
+	if err != nil {
+		return fmt.Errorf("NewWsConn error: %w", err)
+	}
+
+	cm := dex.NewConnectionMaster(conn)
+	if err := cm.ConnectOnce(ctx); err != nil {
+		return err
+	}
+	if err := kr.subscribeUserChannels(ctx, conn); err != nil {
+		cm.Disconnect()
+		return err
+	}
+	kr.userStream.Store(conn)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cm.Wait()
+	}()
+
+	return nil
+}
+
+func (kr *kraken) requestBalanceRefresh() {
+	select {
+	case kr.balanceRefresh <- struct{}{}:
+	default:
+	}
+}
+
+func (kr *kraken) handleUserDataStreamUpdate(b []byte) {
+	var msg krtypes.WSMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		kr.log.Errorf("Error unmarshaling user data stream update: %v\nRaw message: %s", err, string(b))
+		return
+	}
+
+	if msg.Method != "" {
+		if !msg.Success {
+			kr.log.Errorf("Kraken %s request failed: %s", msg.Method, msg.Error)
+		}
+		return
+	}
+
+	switch msg.Channel {
+	case "executions":
+		kr.log.Tracef("Received executions update: %s", string(b))
+		var execs []*krtypes.Execution
+		if err := json.Unmarshal(msg.Data, &execs); err != nil {
+			kr.log.Errorf("Error unmarshaling executions: %v", err)
+			return
+		}
+		for _, e := range execs {
+			kr.handleExecution(e)
+		}
+	case "balances":
+		kr.log.Tracef("Received balances update: %s", string(b))
+		if msg.Type == "update" {
+			kr.requestBalanceRefresh()
+		}
+	}
+}
+
+func (kr *kraken) handleExecution(e *krtypes.Execution) {
+	kr.tradeUpdaterMtx.Lock()
+	defer kr.tradeUpdaterMtx.Unlock()
+
+	info, found := kr.tradeInfo[e.ClientOrderID]
+	if !found {
+		kr.log.Debugf("Execution report for unknown order %s (client ID %q)", e.OrderID, e.ClientOrderID)
+		return
+	}
+	updater, found := kr.tradeUpdaters[info.updaterID]
+	if !found {
+		kr.log.Errorf("No updater with ID %d for order %s", info.updaterID, e.OrderID)
+		return
+	}
+
+	baseCfg, quoteCfg, err := krAssetCfgs(info.baseID, info.quoteID)
+	if err != nil {
+		kr.log.Errorf("Error getting asset configs: %v", err)
+		return
+	}
+
+	// Not all execution types report the cumulative fills, so we keep track
+	// of the largest fill amounts seen.
+	if baseFilled := uint64(math.Round(e.CumQty * float64(baseCfg.conversionFactor))); baseFilled > info.baseFilled {
+		info.baseFilled = baseFilled
+	}
+	if quoteFilled := uint64(math.Round(e.CumCost * float64(quoteCfg.conversionFactor))); quoteFilled > info.quoteFilled {
+		info.quoteFilled = quoteFilled
+	}
+	if info.orderID == "" {
+		info.orderID = e.OrderID
+	}
+
+	complete := e.OrderStatus == "filled" || e.OrderStatus == "canceled" || e.OrderStatus == "expired"
+
+	updater <- &Trade{
+		ID:          info.orderID,
+		Sell:        info.sell,
+		Qty:         info.qty,
+		Rate:        info.rate,
+		BaseID:      info.baseID,
+		QuoteID:     info.quoteID,
+		BaseFilled:  info.baseFilled,
+		QuoteFilled: info.quoteFilled,
+		Complete:    complete,
+	}
+
+	if complete {
+		delete(kr.tradeInfo, e.ClientOrderID)
+	}
+}
+
+// Balance returns the balance of an asset at the CEX.
+func (kr *kraken) Balance(assetID uint32) (*ExchangeBalance, error) {
+	kr.balanceMtx.RLock()
+	defer kr.balanceMtx.RUnlock()
+
+	bal, found := kr.balances[assetID]
+	if !found {
+		return nil, fmt.Errorf("no %s balance found", dex.BipIDSymbol(assetID))
+	}
+	return bal, nil
+}
+
+// Balances returns the balances of known assets on the CEX.
+func (kr *kraken) Balances(ctx context.Context) (map[uint32]*ExchangeBalance, error) {
+	kr.balanceMtx.Lock()
+	defer kr.balanceMtx.Unlock()
+
+	if len(kr.balances) == 0 {
+		if _, err := kr.refreshBalances(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	balances := make(map[uint32]*ExchangeBalance, len(kr.balances))
+	for assetID, bal := range kr.balances {
+		balances[assetID] = bal
+	}
+	return balances, nil
+}
+
+func (kr *kraken) generateTradeID() string {
+	nonce := kr.tradeIDNonce.Add(1)
+	nonceB := encode.Uint32Bytes(nonce)
+	return hex.EncodeToString(append(kr.tradeIDNoncePrefix, nonceB...))
+}
+
+// Trade executes a trade on the CEX. subscriptionID takes an ID returned from
+// SubscribeTradeUpdates.
+func (kr *kraken) Trade(ctx context.Context, baseID, quoteID uint32, sell bool, rate, qty uint64, subscriptionID int) (*Trade, error) {
+	baseCfg, quoteCfg, err := krAssetCfgs(baseID, quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting asset configs: %w", err)
+	}
+
+	pairName, pair, err := kr.pair(baseCfg, quoteCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rate = steppedRate(rate, pair.RateStep)
+	convRate := calc.ConventionalRateAlt(rate, baseCfg.conversionFactor, quoteCfg.conversionFactor)
+	rateStr := strconv.FormatFloat(convRate, 'f', pair.PairDecimals, 64)
+
+	if qty < pair.MinQty {
+		return nil, fmt.Errorf("quantity %v is below the minimum %d for market %s", qty, pair.MinQty, pairName)
+	}
+	steppedQty := steppedRate(qty, pair.LotSize)
+	convQty := float64(steppedQty) / float64(baseCfg.conversionFactor)
+	qtyStr := strconv.FormatFloat(convQty, 'f', pair.LotDecimals, 64)
+
+	side := "buy"
+	if sell {
+		side = "sell"
+	}
+
+	clientOrderID := kr.generateTradeID()
+
+	v := make(url.Values)
+	v.Add("ordertype", "limit")
+	v.Add("type", side)
+	v.Add("pair", pair.AltName)
+	v.Add("price", rateStr)
+	v.Add("volume", qtyStr)
+	v.Add("cl_ord_id", clientOrderID)
+
+	kr.tradeUpdaterMtx.Lock()
+	if _, found := kr.tradeUpdaters[subscriptionID]; !found {
+		kr.tradeUpdaterMtx.Unlock()
+		return nil, fmt.Errorf("no trade updater with ID %v", subscriptionID)
+	}
+	info := &krTradeInfo{
+		updaterID: subscriptionID,
+		baseID:    baseID,
+		quoteID:   quoteID,
+		sell:      sell,
+		rate:      rate,
+		qty:       qty,
+	}
+	kr.tradeInfo[clientOrderID] = info
+	kr.tradeUpdaterMtx.Unlock()
+
+	var resp krtypes.AddOrderResult
+	if err := kr.privateAPI(ctx, "AddOrder", v, &resp); err != nil {
+		kr.tradeUpdaterMtx.Lock()
+		delete(kr.tradeInfo, clientOrderID)
+		kr.tradeUpdaterMtx.Unlock()
+		return nil, err
+	}
+	if len(resp.TxIDs) == 0 {
+		return nil, fmt.Errorf("no order ID returned for order on %s", pairName)
+	}
+
+	orderID := resp.TxIDs[0]
+	kr.tradeUpdaterMtx.Lock()
+	info.orderID = orderID
+	baseFilled, quoteFilled := info.baseFilled, info.quoteFilled
+	kr.tradeUpdaterMtx.Unlock()
+
+	return &Trade{
+		ID:          orderID,
+		Sell:        sell,
+		Rate:        rate,
+		Qty:         qty,
+		BaseID:      baseID,
+		QuoteID:     quoteID,
+		BaseFilled:  baseFilled,
+		QuoteFilled: quoteFilled,
+	}, nil
+}
+
+// CancelTrade cancels a trade on the CEX.
+func (kr *kraken) CancelTrade(ctx context.Context, baseID, quoteID uint32, tradeID string) error {
+	v := make(url.Values)
+	v.Add("txid", tradeID)
+	var resp krtypes.CancelOrderResult
+	return kr.privateAPI(ctx, "CancelOrder", v, &resp)
+}
+
+// SubscribeTradeUpdates returns a channel that the caller can use to
+// listen for updates to a trade's status. When the subscription ID
+// returned from this function is passed as the updaterID argument to
+// Trade, then updates to the trade will be sent on the updated channel
+// returned from this function.
+func (kr *kraken) SubscribeTradeUpdates() (<-chan *Trade, func(), int) {
+	kr.tradeUpdaterMtx.Lock()
+	defer kr.tradeUpdaterMtx.Unlock()
+	updaterID := kr.tradeUpdateCounter
+	kr.tradeUpdateCounter++
+	updater := make(chan *Trade, 256)
+	kr.tradeUpdaters[updaterID] = updater
+
+	unsubscribe := func() {
+		kr.tradeUpdaterMtx.Lock()
+		delete(kr.tradeUpdaters, updaterID)
+		kr.tradeUpdaterMtx.Unlock()
+	}
+
+	return updater, unsubscribe, updaterID
+}
+
+// TradeStatus returns the current status of a trade.
+func (kr *kraken) TradeStatus(ctx context.Context, tradeID string, baseID, quoteID uint32) (*Trade, error) {
+	baseCfg, quoteCfg, err := krAssetCfgs(baseID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make(url.Values)
+	v.Add("txid", tradeID)
+	var resp map[string]*krtypes.OrderInfo
+	if err := kr.privateAPI(ctx, "QueryOrders", v, &resp); err != nil {
+		return nil, err
+	}
+	ord, found := resp[tradeID]
+	if !found || ord.Description == nil {
+		return nil, fmt.Errorf("order %s not found", tradeID)
+	}
+
+	return &Trade{
+		ID:          tradeID,
+		Sell:        ord.Description.Type == "sell",
+		Rate:        krakenMsgRate(ord.Description.Price, baseCfg.conversionFactor, quoteCfg.conversionFactor),
+		Qty:         uint64(math.Round(ord.Volume * float64(baseCfg.conversionFactor))),
+		BaseID:      baseID,
+		QuoteID:     quoteID,
+		BaseFilled:  uint64(math.Round(ord.VolumeExec * float64(baseCfg.conversionFactor))),
+		QuoteFilled: uint64(math.Round(ord.Cost * float64(quoteCfg.conversionFactor))),
+		Complete:    ord.Status != krtypes.OrderStatusOpen && ord.Status != krtypes.OrderStatusPending,
+	}, nil
+}
+
+// depositMethod finds the Kraken deposit method for the asset.
+func (kr *kraken) depositMethod(ctx context.Context, assetCfg *krAssetConfig) (string, error) {
+	v := make(url.Values)
+	v.Add("asset", assetCfg.coin)
+	var methods []*krtypes.DepositMethod
+	if err := kr.privateAPI(ctx, "DepositMethods", v, &methods); err != nil {
+		return "", err
+	}
+	for _, m := range methods {
+		if assetCfg.network == "" || strings.Contains(m.Method, assetCfg.network) {
+			return m.Method, nil
+		}
+	}
+	return "", fmt.Errorf("no deposit method found for %s", assetCfg.symbol)
+}
+
+// GetDepositAddress returns a deposit address for an asset.
+func (kr *kraken) GetDepositAddress(ctx context.Context, assetID uint32) (string, error) {
+	assetCfg, err := krAssetCfg(assetID)
+	if err != nil {
+		return "", fmt.Errorf("error getting asset cfg for %d: %w", assetID, err)
+	}
+
+	method, err := kr.depositMethod(ctx, assetCfg)
+	if err != nil {
+		return "", err
+	}
+
+	getAddrs := func(generate bool) ([]*krtypes.DepositAddress, error) {
+		v := make(url.Values)
+		v.Add("asset", assetCfg.coin)
+		v.Add("method", method)
+		if generate {
+			v.Add("new", "true")
+		}
+		var addrs []*krtypes.DepositAddress
+		return addrs, kr.privateAPI(ctx, "DepositAddresses", v, &addrs)
+	}
+
+	addrs, err := getAddrs(false)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		if addrs, err = getAddrs(true); err != nil {
+			return "", err
+		}
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no deposit address returned for %s", assetCfg.symbol)
+	}
+	return addrs[0].Address, nil
+}
+
+// ConfirmDeposit is an async function that calls onConfirm when the status of
+// a deposit has been confirmed.
+func (kr *kraken) ConfirmDeposit(ctx context.Context, deposit *DepositData) (bool, uint64) {
+	assetCfg, err := krAssetCfg(deposit.AssetID)
+	if err != nil {
+		kr.log.Errorf("Error getting asset cfg for %d: %v", deposit.AssetID, err)
+		return false, 0
+	}
+
+	v := make(url.Values)
+	v.Add("asset", assetCfg.coin)
+	// We'll add info for the fake server.
+	if kr.apiURL == fakeKrakenURL {
+		v.Add("txid", deposit.TxID)
+		v.Add("amount", strconv.FormatFloat(deposit.AmountConventional, 'f', 9, 64))
+		v.Add("network", assetCfg.symbol)
+	}
+
+	var transfers []*krtypes.Transfer
+	if err := kr.privateAPI(ctx, "DepositStatus", v, &transfers); err != nil {
+		kr.log.Errorf("Error getting deposit status: %v", err)
+		return false, 0
+	}
+
+	for _, t := range transfers {
+		if t.TxID != deposit.TxID {
+			continue
+		}
+		switch t.Status {
+		case krtypes.TransferStatusSuccess:
+			credit := t.Amount - t.Fee
+			if credit < 0 {
+				credit = 0
+			}
+			return true, uint64(math.Round(credit * float64(assetCfg.conversionFactor)))
+		case krtypes.TransferStatusFailure:
+			kr.log.Errorf("Deposit %s to Kraken failed: %s", deposit.TxID, t.Info)
+			return true, 0
+		default:
+			return false, 0
+		}
+	}
+
+	return false, 0
+}
+
+// Withdraw withdraws funds from the CEX to a certain address. Kraken only
+// allows withdrawals to addresses that have been added to the account's
+// withdrawal address book.
+func (kr *kraken) Withdraw(ctx context.Context, assetID uint32, qty uint64, address string) (string, error) {
+	assetCfg, err := krAssetCfg(assetID)
+	if err != nil {
+		return "", fmt.Errorf("error getting asset cfg for %d: %w", assetID, err)
+	}
+
+	method, err := kr.withdrawMethod(assetID)
+	if err != nil {
+		return "", err
+	}
+
+	v := make(url.Values)
+	v.Add("asset", assetCfg.coin)
+	v.Add("method", method.Method)
+	// The fake server adds any address to the address book.
+	if kr.apiURL == fakeKrakenURL {
+		v.Add("address", address)
+	}
+	var addrs []*krtypes.WithdrawAddress
+	if err := kr.privateAPI(ctx, "WithdrawAddresses", v, &addrs); err != nil {
+		return "", err
+	}
+	var key string
+	for _, a := range addrs {
+		if a.Address == address {
+			key = a.Key
+			break
+		}
+	}
+	if key == "" {
+		return "", fmt.Errorf("%s address %s is not in the Kraken withdrawal address book", assetCfg.symbol, address)
+	}
+
+	prec := int(math.Round(math.Log10(float64(assetCfg.conversionFactor))))
+	assets := kr.assets.Load().(map[string]*krtypes.AssetInfo)
+	for _, a := range assets {
+		if a.AltName == assetCfg.coin && a.Decimals < prec {
+			prec = a.Decimals
+		}
+	}
+	qtyStr := strconv.FormatFloat(float64(qty)/float64(assetCfg.conversionFactor), 'f', prec, 64)
+
+	v = make(url.Values)
+	v.Add("asset", assetCfg.coin)
+	v.Add("key", key)
+	v.Add("address", address)
+	v.Add("amount", qtyStr)
+	var resp krtypes.WithdrawResult
+	if err := kr.privateAPI(ctx, "Withdraw", v, &resp); err != nil {
+		return "", err
+	}
+	return resp.RefID, nil
+}
+
+// ConfirmWithdrawal checks whether a withdrawal has been completed. If the
+// withdrawal has not yet been sent, ErrWithdrawalPending is returned.
+func (kr *kraken) ConfirmWithdrawal(ctx context.Context, withdrawalID string, assetID uint32) (uint64, string, error) {
+	assetCfg, err := krAssetCfg(assetID)
+	if err != nil {
+		return 0, "", fmt.Errorf("error getting asset cfg for %d: %w", assetID, err)
+	}
+
+	v := make(url.Values)
+	v.Add("asset", assetCfg.coin)
+	var transfers []*krtypes.Transfer
+	if err := kr.privateAPI(ctx, "WithdrawStatus", v, &transfers); err != nil {
+		return 0, "", err
+	}
+
+	for _, t := range transfers {
+		if t.RefID != withdrawalID {
+			continue
+		}
+		if t.Status == krtypes.TransferStatusFailure {
+			return 0, "", fmt.Errorf("withdrawal %s failed: %s", withdrawalID, t.Info)
+		}
+		if t.TxID == "" {
+			return 0, "", ErrWithdrawalPending
+		}
+		return uint64(math.Round(t.Amount * float64(assetCfg.conversionFactor))), t.TxID, nil
+	}
+
+	return 0, "", fmt.Errorf("withdrawal status not found for %s", withdrawalID)
+}
+
+// MatchedMarkets returns the list of markets at the CEX.
+func (kr *kraken) MatchedMarkets(ctx context.Context) (_ []*MarketMatch, err error) {
+	pairs := kr.pairs.Load().(map[string]*krtypes.AssetPair)
+	if len(pairs) == 0 {
+		if len(kr.assets.Load().(map[string]*krtypes.AssetInfo)) == 0 {
+			if err := kr.getAssets(ctx); err != nil {
+				return nil, fmt.Errorf("error getting assets: %w", err)
+			}
+		}
+		if pairs, err = kr.getPairs(ctx); err != nil {
+			return nil, fmt.Errorf("error getting markets: %w", err)
+		}
+	}
+
+	markets := make([]*MarketMatch, 0, len(pairs))
+	for _, pair := range pairs {
+		for _, baseID := range krakenCoinDEXAssetIDs(kr.altName(pair.Base)) {
+			for _, quoteID := range krakenCoinDEXAssetIDs(kr.altName(pair.Quote)) {
+				markets = append(markets, &MarketMatch{
+					Slug:     pair.AltName,
+					MarketID: dex.BipIDSymbol(baseID) + "_" + dex.BipIDSymbol(quoteID),
+					BaseID:   baseID,
+					QuoteID:  quoteID,
+				})
+			}
+		}
+	}
+	return markets, nil
+}
+
+// Markets returns the list of markets at the CEX.
+func (kr *kraken) Markets(ctx context.Context) (map[string]*Market, error) {
+	kr.marketSnapshotMtx.Lock()
+	defer kr.marketSnapshotMtx.Unlock()
+
+	const snapshotTimeout = time.Minute * 30
+	if kr.marketSnapshot.m != nil && time.Since(kr.marketSnapshot.stamp) < snapshotTimeout {
+		return kr.marketSnapshot.m, nil
+	}
+
+	matches, err := kr.MatchedMarkets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting market list for market data request: %w", err)
+	}
+
+	mkts := make(map[string][]*MarketMatch, len(matches))
+	for _, m := range matches {
+		mkts[m.Slug] = append(mkts[m.Slug], m)
+	}
+
+	q := make(url.Values)
+	q.Set("pair", strings.Join(utils.MapKeys(mkts), ","))
+	var tickers map[string]*krtypes.Ticker
+	if err := kr.publicAPI(ctx, "Ticker", q, &tickers); err != nil {
+		return nil, err
+	}
+
+	pairs := kr.pairs.Load().(map[string]*krtypes.AssetPair)
+	withdrawMethods := kr.withdrawMethods.Load().(map[uint32]*krtypes.WithdrawMethod)
+	minWithdraw := func(assetID uint32) uint64 {
+		m, found := withdrawMethods[assetID]
+		if !found {
+			return 0
+		}
+		ui, err := asset.UnitInfo(assetID)
+		if err != nil {
+			return 0
+		}
+		return uint64(math.Round(m.Minimum * float64(ui.Conventional.ConversionFactor)))
+	}
+
+	num := func(n json.Number) float64 {
+		f, _ := n.Float64()
+		return f
+	}
+
+	m := make(map[string]*Market, len(matches))
+	for pairName, t := range tickers {
+		pair, found := pairs[pairName]
+		if !found {
+			kr.log.Errorf("Ticker returned for unknown pair %s", pairName)
+			continue
+		}
+		lastPrice, openPrice := num(t.LastTrade[0]), num(t.OpeningPrice)
+		vol, avgPrice := num(t.Volume[1]), num(t.VWAP[1])
+		var priceChangePct float64
+		if openPrice > 0 {
+			priceChangePct = (lastPrice - openPrice) / openPrice * 100
+		}
+		for _, mkt := range mkts[pair.AltName] {
+			m[mkt.MarketID] = &Market{
+				BaseID:           mkt.BaseID,
+				QuoteID:          mkt.QuoteID,
+				BaseMinWithdraw:  minWithdraw(mkt.BaseID),
+				QuoteMinWithdraw: minWithdraw(mkt.QuoteID),
+				LotSize:          pair.LotSize,
+				Day: &MarketDay{
+					Vol:            vol,
+					QuoteVol:       vol * avgPrice,
+					PriceChange:    lastPrice - openPrice,
+					PriceChangePct: priceChangePct,
+					AvgPrice:       avgPrice,
+					LastPrice:      lastPrice,
+					OpenPrice:      openPrice,
+					HighPrice:      num(t.High[1]),
+					LowPrice:       num(t.Low[1]),
+				},
+			}
+		}
+	}
+	kr.marketSnapshot.m = m
+	kr.marketSnapshot.stamp = time.Now()
+
+	return m, nil
+}
+
+func (kr *kraken) handleMarketDataNote(b []byte) {
+	var msg krtypes.WSMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		kr.log.Errorf("Error unmarshaling market data note: %v", err)
+		return
+	}
+
+	if msg.Method != "" {
+		if !msg.Success {
+			kr.log.Errorf("Kraken %s request failed: %s", msg.Method, msg.Error)
+		}
+		return
+	}
+
+	if msg.Channel != "book" {
+		return
+	}
+
+	var updates []*krtypes.BookUpdate
+	if err := json.Unmarshal(msg.Data, &updates); err != nil {
+		kr.log.Errorf("Error unmarshaling book updates: %v", err)
+		return
+	}
+
+	kr.booksMtx.RLock()
+	defer kr.booksMtx.RUnlock()
+	for _, u := range updates {
+		book, found := kr.books[u.Symbol]
+		if !found {
+			kr.log.Debugf("Book update received for unsubscribed market %s", u.Symbol)
+			continue
+		}
+		book.handleUpdate(u, msg.Type == "snapshot")
+	}
+}
+
+// bookSymbols returns the websocket symbols of all subscribed books.
+func (kr *kraken) bookSymbols() []string {
+	kr.booksMtx.RLock()
+	defer kr.booksMtx.RUnlock()
+	return utils.MapKeys(kr.books)
+}
+
+// connectMarketStream connects to the public websocket API. marketStreamMtx
+// MUST be held.
+func (kr *kraken) connectMarketStream(ctx context.Context) error {
+	var conn comms.WsConn
+	conn, err := comms.NewWsConn(&comms.WsCfg{
+		URL:                     kr.wsURL,
+		PingWait:                time.Minute,
+		ExtendDeadlineOnMessage: true,
+		ReconnectSync: func() {
+			kr.log.Debugf("Kraken market data stream reconnected")
+			symbols := kr.bookSymbols()
+			if len(symbols) == 0 {
+				return
+			}
+			// Resubscribing will send new snapshots.
+			if err := sendKrakenWS(conn, "subscribe", &krtypes.BookSubscription{
+				Channel:  "book",
+				Symbol:   symbols,
+				Depth:    krakenBookDepth,
+				Snapshot: true,
+			}); err != nil {
+				kr.log.Errorf("Error resubscribing to books: %v", err)
+			}
+		},
+		ConnectEventFunc: func(cs comms.ConnectionStatus) {
+			if cs != comms.Disconnected {
+				return
+			}
+			// If disconnected, set all books to unsynced so bots will not
+			// place new orders.
+			kr.booksMtx.RLock()
+			defer kr.booksMtx.RUnlock()
+			for _, b := range kr.books {
+				b.synced.Store(false)
+			}
+		},
+		Logger:     kr.log.SubLogger("KRBOOK"),
+		RawHandler: kr.handleMarketDataNote,
+	})
+	if err != nil {
+		return err
+	}
+
+	cm := dex.NewConnectionMaster(conn)
+	if err = cm.ConnectOnce(ctx); err != nil {
+		return fmt.Errorf("error connecting to market data stream: %w", err)
+	}
+	kr.marketStream = conn
+	kr.marketStreamCM = cm
+	return nil
+}
+
+// SubscribeMarket subscribes to order book updates on a market. This must
+// be called before calling VWAP.
+func (kr *kraken) SubscribeMarket(ctx context.Context, baseID, quoteID uint32) error {
+	baseCfg, quoteCfg, err := krAssetCfgs(baseID, quoteID)
+	if err != nil {
+		return err
+	}
+	_, pair, err := kr.pair(baseCfg, quoteCfg)
+	if err != nil {
+		return err
+	}
+	wsSymbol := krakenWSSymbols.Replace(pair.WSName)
+
+	kr.marketStreamMtx.Lock()
+	defer kr.marketStreamMtx.Unlock()
+
+	if kr.marketStream == nil {
+		if err := kr.connectMarketStream(ctx); err != nil {
+			return err
+		}
+	}
+
+	kr.booksMtx.Lock()
+	book, found := kr.books[wsSymbol]
+	if found {
+		book.mtx.Lock()
+		book.numSubscribers++
+		book.mtx.Unlock()
+		kr.booksMtx.Unlock()
+		return nil
+	}
+	book = newKrakenOrderBook(wsSymbol, baseCfg.conversionFactor, quoteCfg.conversionFactor)
+	syncChan := book.syncChan
+	kr.books[wsSymbol] = book
+	kr.booksMtx.Unlock()
+
+	if err := sendKrakenWS(kr.marketStream, "subscribe", &krtypes.BookSubscription{
+		Channel:  "book",
+		Symbol:   []string{wsSymbol},
+		Depth:    krakenBookDepth,
+		Snapshot: true,
+	}); err != nil {
+		kr.booksMtx.Lock()
+		delete(kr.books, wsSymbol)
+		kr.booksMtx.Unlock()
+		return fmt.Errorf("error subscribing to %s book: %w", wsSymbol, err)
+	}
+
+	select {
+	case <-syncChan:
+		kr.log.Infof("Synced %s orderbook", wsSymbol)
+	case <-time.After(krakenSyncTimeout):
+		kr.log.Warnf("Timed out waiting for %s orderbook snapshot", wsSymbol)
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+// UnsubscribeMarket unsubscribes from order book updates on a market.
+func (kr *kraken) UnsubscribeMarket(baseID, quoteID uint32) error {
+	baseCfg, quoteCfg, err := krAssetCfgs(baseID, quoteID)
+	if err != nil {
+		return err
+	}
+	_, pair, err := kr.pair(baseCfg, quoteCfg)
+	if err != nil {
+		return err
+	}
+	wsSymbol := krakenWSSymbols.Replace(pair.WSName)
+
+	kr.marketStreamMtx.Lock()
+	defer kr.marketStreamMtx.Unlock()
+
+	if kr.marketStream == nil {
+		return fmt.Errorf("can't unsubscribe. no stream")
+	}
+
+	kr.booksMtx.Lock()
+	book, found := kr.books[wsSymbol]
+	if !found {
+		kr.booksMtx.Unlock()
+		return nil
+	}
+	book.mtx.Lock()
+	book.numSubscribers--
+	unsubscribe := book.numSubscribers == 0
+	book.mtx.Unlock()
+	if unsubscribe {
+		delete(kr.books, wsSymbol)
+	}
+	kr.booksMtx.Unlock()
+
+	if !unsubscribe {
+		return nil
+	}
+
+	return sendKrakenWS(kr.marketStream, "unsubscribe", &krtypes.BookSubscription{
+		Channel: "book",
+		Symbol:  []string{wsSymbol},
+		Depth:   krakenBookDepth,
+	})
+}
+
+func (kr *kraken) book(baseID, quoteID uint32) (*krakenOrderBook, error) {
+	baseCfg, quoteCfg, err := krAssetCfgs(baseID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	_, pair, err := kr.pair(baseCfg, quoteCfg)
+	if err != nil {
+		return nil, err
+	}
+	wsSymbol := krakenWSSymbols.Replace(pair.WSName)
+
+	kr.booksMtx.RLock()
+	book, found := kr.books[wsSymbol]
+	kr.booksMtx.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("no book for market %s", wsSymbol)
+	}
+	return book, nil
+}
+
+// Book generates the CEX's current view of a market's orderbook.
+func (kr *kraken) Book(baseID, quoteID uint32) (buys, sells []*core.MiniOrder, _ error) {
+	book, err := kr.book(baseID, quoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	bids, asks := book.book.snap()
+	bFactor := float64(book.baseConversionFactor)
+	convertSide := func(side []*obEntry, sell bool) []*core.MiniOrder {
+		ords := make([]*core.MiniOrder, len(side))
+		for i, e := range side {
+			ords[i] = &core.MiniOrder{
+				Qty:       float64(e.qty) / bFactor,
+				QtyAtomic: e.qty,
+				Rate:      calc.ConventionalRateAlt(e.rate, book.baseConversionFactor, book.quoteConversionFactor),
+				MsgRate:   e.rate,
+				Sell:      sell,
+			}
+		}
+		return ords
+	}
+	return convertSide(bids, false), convertSide(asks, true), nil
+}
+
+// SubscribeBookUpdates returns a feed of updates to a market's order book.
+// SubscribeMarket must be called first. Part of the BookSubscriber interface.
+func (kr *kraken) SubscribeBookUpdates(baseID, quoteID uint32) (<-chan *BookUpdate, func(), error) {
+	book, err := kr.book(baseID, quoteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	updates, unsubscribe := book.book.subscribe()
+	return updates, unsubscribe, nil
+}
+
+// VWAP returns the volume weighted average price for a certain quantity
+// of the base asset on a market. SubscribeMarket must be called, and the
+// market must be synced before results can be expected.
+func (kr *kraken) VWAP(baseID, quoteID uint32, sell bool, qty uint64) (avgPrice, extrema uint64, filled bool, err error) {
+	book, err := kr.book(baseID, quoteID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return book.vwap(!sell, qty)
+}
+
+// MidGap returns the mid-gap price for an order book.
+func (kr *kraken) MidGap(baseID, quoteID uint32) uint64 {
+	book, err := kr.book(baseID, quoteID)
+	if err != nil {
+		kr.log.Errorf("Error getting order book for (%d, %d): %v", baseID, quoteID, err)
+		return 0
+	}
+	return book.book.midGap()
+}
@@ -0,0 +1,201 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package libxc
+
+import (
+	"encoding/base64"
+	"math"
+	"testing"
+
+	"decred.org/dcrdex/client/mm/libxc/krtypes"
+	"decred.org/dcrdex/dex/calc"
+)
+
+func TestKrakenSignature(t *testing.T) {
+	// Example from https://docs.kraken.com/api/docs/guides/spot-rest-auth
+	secret, _ := base64.StdEncoding.DecodeString("kQH5HW/8p1uGOVjbgWA7FunAmGO8lsSUXNsu3eow76sz84Q18fWxnyRzBHCd3pd5nE9qa99HAZtuZuj6F1huXg==")
+	const nonce = "1616492376594"
+	postData := "nonce=1616492376594&ordertype=limit&pair=XBTUSD&price=37500&type=buy&volume=1.25"
+	sig := krakenSignature("/0/private/AddOrder", nonce, postData, secret)
+	const expSig = "4/dpxb3iT4tp/ZCVEwSnEsLxx0bqyhLpdfOpc6fn7OR8+UClSV5n9E6aSS8MPtnRfp32bAb0nmbRn6H8ndwLUQ=="
+	if sig != expSig {
+		t.Fatalf("wrong signature. expected %s, got %s", expSig, sig)
+	}
+}
+
+func TestKrAssetCfg(t *testing.T) {
+	tests := map[uint32]*krAssetConfig{
+		0: {
+			assetID:          0,
+			symbol:           "btc",
+			coin:             "XBT",
+			conversionFactor: 1e8,
+		},
+		3: {
+			assetID:          3,
+			symbol:           "doge",
+			coin:             "XDG",
+			conversionFactor: 1e8,
+		},
+		60: {
+			assetID:          60,
+			symbol:           "eth",
+			coin:             "ETH",
+			conversionFactor: 1e9,
+		},
+		966: {
+			assetID:          966,
+			symbol:           "polygon",
+			coin:             "POL",
+			conversionFactor: 1e9,
+		},
+		60001: {
+			assetID:          60001,
+			symbol:           "usdc.eth",
+			coin:             "USDC",
+			network:          "ERC20",
+			conversionFactor: 1e6,
+		},
+		966002: {
+			assetID:          966002,
+			symbol:           "weth.polygon",
+			coin:             "ETH",
+			network:          "Polygon",
+			conversionFactor: 1e9,
+		},
+	}
+
+	for assetID, expected := range tests {
+		cfg, err := krAssetCfg(assetID)
+		if err != nil {
+			t.Fatalf("error getting asset config for %d: %v", assetID, err)
+		}
+		if *expected != *cfg {
+			t.Fatalf("expected %+v but got %+v", expected, cfg)
+		}
+	}
+}
+
+func TestKrakenCoinDEXAssetIDs(t *testing.T) {
+	contains := func(ids []uint32, id uint32) bool {
+		for _, i := range ids {
+			if i == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	tests := map[string][]uint32{
+		"XBT":  {0},
+		"XDG":  {3},
+		"ETH":  {60, 966002},
+		"USDC": {60001, 966001},
+	}
+	for coin, expIDs := range tests {
+		ids := krakenCoinDEXAssetIDs(coin)
+		for _, id := range expIDs {
+			if !contains(ids, id) {
+				t.Fatalf("%s: expected asset ID %d in %v", coin, id, ids)
+			}
+		}
+	}
+}
+
+func TestKrakenOrderBook(t *testing.T) {
+	const baseFactor, quoteFactor = 1e8, 1e8
+	book := newKrakenOrderBook("DCR/BTC", baseFactor, quoteFactor)
+
+	levels := func(pts ...[2]float64) []*krtypes.PriceLevel {
+		l := make([]*krtypes.PriceLevel, 0, len(pts))
+		for _, pt := range pts {
+			l = append(l, &krtypes.PriceLevel{Price: pt[0], Qty: pt[1]})
+		}
+		return l
+	}
+	msgRate := func(r float64) uint64 {
+		return uint64(math.Round(r * calc.RateEncodingFactor / baseFactor * quoteFactor))
+	}
+
+	// Updates before the snapshot are ignored.
+	book.handleUpdate(&krtypes.BookUpdate{Bids: levels([2]float64{0.0005, 1})}, false)
+	if _, _, _, err := book.vwap(true, 1e8); err == nil {
+		t.Fatalf("expected error for unsynced book")
+	}
+
+	book.handleUpdate(&krtypes.BookUpdate{
+		Bids: levels([2]float64{0.0002, 1}, [2]float64{0.0001, 2}),
+		Asks: levels([2]float64{0.0003, 1}, [2]float64{0.0004, 2}),
+	}, true)
+
+	select {
+	case <-book.syncChan:
+	default:
+		if book.syncChan != nil {
+			t.Fatalf("sync channel not closed")
+		}
+	}
+
+	if midGap := book.book.midGap(); midGap != msgRate(0.00025) {
+		t.Fatalf("wrong mid-gap. expected %d, got %d", msgRate(0.00025), midGap)
+	}
+
+	vwap, extrema, filled, err := book.vwap(true, 2e8)
+	if err != nil {
+		t.Fatalf("vwap error: %v", err)
+	}
+	if !filled {
+		t.Fatalf("expected filled")
+	}
+	if extrema != msgRate(0.0001) {
+		t.Fatalf("wrong extrema. expected %d, got %d", msgRate(0.0001), extrema)
+	}
+	if vwap != msgRate(0.00015) {
+		t.Fatalf("wrong vwap. expected %d, got %d", msgRate(0.00015), vwap)
+	}
+
+	// Remove the best bid and add a new one.
+	book.handleUpdate(&krtypes.BookUpdate{
+		Bids: levels([2]float64{0.0002, 0}, [2]float64{0.00015, 3}),
+	}, false)
+	if midGap := book.book.midGap(); midGap != msgRate(0.000225) {
+		t.Fatalf("wrong mid-gap after update. expected %d, got %d", msgRate(0.000225), midGap)
+	}
+
+	// A new snapshot replaces the book.
+	book.handleUpdate(&krtypes.BookUpdate{
+		Bids: levels([2]float64{0.001, 1}),
+		Asks: levels([2]float64{0.003, 1}),
+	}, true)
+	if midGap := book.book.midGap(); midGap != msgRate(0.002) {
+		t.Fatalf("wrong mid-gap after snapshot. expected %d, got %d", msgRate(0.002), midGap)
+	}
+}
+
+func TestKrakenSubscribeTradeUpdates(t *testing.T) {
+	kr := &kraken{
+		tradeUpdaters: make(map[int]chan *Trade),
+	}
+	_, unsub0, _ := kr.SubscribeTradeUpdates()
+	_, _, id1 := kr.SubscribeTradeUpdates()
+	unsub0()
+	_, _, id2 := kr.SubscribeTradeUpdates()
+	if len(kr.tradeUpdaters) != 2 {
+		t.Fatalf("wrong number of updaters. wanted 2, got %d", len(kr.tradeUpdaters))
+	}
+	if id1 == id2 {
+		t.Fatalf("ids should be unique. got %d twice", id1)
+	}
+}
+
+func TestKrakenTradeID(t *testing.T) {
+	kr := &kraken{tradeIDNoncePrefix: make([]byte, 5)}
+	id1, id2 := kr.generateTradeID(), kr.generateTradeID()
+	if len(id1) != 18 {
+		t.Fatalf("wrong trade ID length %d", len(id1))
+	}
+	if id1 == id2 {
+		t.Fatalf("duplicate trade IDs")
+	}
+}
@@ -0,0 +1,225 @@
+package krtypes
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Response is the envelope for all Kraken REST API responses.
+type Response struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// Err returns the errors in the response joined into a single string, or an
+// empty string if there are no errors.
+func (r *Response) Err() string {
+	return strings.Join(r.Error, ", ")
+}
+
+type AssetInfo struct {
+	AssetClass      string `json:"aclass"`
+	AltName         string `json:"altname"`
+	Decimals        int    `json:"decimals"`
+	DisplayDecimals int    `json:"display_decimals"`
+	Status          string `json:"status"`
+}
+
+type AssetPair struct {
+	AltName      string  `json:"altname"`
+	WSName       string  `json:"wsname"`
+	Base         string  `json:"base"`
+	Quote        string  `json:"quote"`
+	PairDecimals int     `json:"pair_decimals"`
+	LotDecimals  int     `json:"lot_decimals"`
+	OrderMin     float64 `json:"ordermin,string"`
+	TickSize     float64 `json:"tick_size,string"`
+	Status       string  `json:"status"`
+
+	// Below fields are parsed from the fields above.
+	LotSize  uint64 `json:"-"`
+	MinQty   uint64 `json:"-"`
+	RateStep uint64 `json:"-"`
+}
+
+// Ticker is the 24-hour ticker for a pair. Most fields are arrays where the
+// first element is the value for today and the second element is the value
+// for the last 24 hours.
+type Ticker struct {
+	Ask          [3]json.Number `json:"a"`
+	Bid          [3]json.Number `json:"b"`
+	LastTrade    [2]json.Number `json:"c"`
+	Volume       [2]json.Number `json:"v"`
+	VWAP         [2]json.Number `json:"p"`
+	NumTrades    [2]int64       `json:"t"`
+	Low          [2]json.Number `json:"l"`
+	High         [2]json.Number `json:"h"`
+	OpeningPrice json.Number    `json:"o"`
+}
+
+type Balance struct {
+	Balance   float64 `json:"balance,string"`
+	HoldTrade float64 `json:"hold_trade,string"`
+}
+
+type OrderDescription struct {
+	Pair      string  `json:"pair"`
+	Type      string  `json:"type"`
+	OrderType string  `json:"ordertype"`
+	Price     float64 `json:"price,string"`
+	Order     string  `json:"order"`
+}
+
+type AddOrderResult struct {
+	Description *OrderDescription `json:"descr"`
+	TxIDs       []string          `json:"txid"`
+}
+
+type CancelOrderResult struct {
+	Count int `json:"count"`
+}
+
+const (
+	OrderStatusPending  = "pending"
+	OrderStatusOpen     = "open"
+	OrderStatusClosed   = "closed"
+	OrderStatusCanceled = "canceled"
+	OrderStatusExpired  = "expired"
+)
+
+type OrderInfo struct {
+	ClientOrderID string            `json:"cl_ord_id"`
+	Status        string            `json:"status"`
+	Description   *OrderDescription `json:"descr"`
+	Volume        float64           `json:"vol,string"`
+	VolumeExec    float64           `json:"vol_exec,string"`
+	Cost          float64           `json:"cost,string"`
+	Fee           float64           `json:"fee,string"`
+	Price         float64           `json:"price,string"`
+}
+
+type DepositMethod struct {
+	Method     string `json:"method"`
+	Minimum    string `json:"minimum"`
+	GenAddress bool   `json:"gen-address"`
+}
+
+type DepositAddress struct {
+	Address string `json:"address"`
+	New     bool   `json:"new"`
+}
+
+type WithdrawMethod struct {
+	Asset   string  `json:"asset"`
+	Method  string  `json:"method"`
+	Network string  `json:"network"`
+	Minimum float64 `json:"minimum,string"`
+}
+
+type WithdrawAddress struct {
+	Address  string `json:"address"`
+	Asset    string `json:"asset"`
+	Method   string `json:"method"`
+	Key      string `json:"key"`
+	Verified bool   `json:"verified"`
+}
+
+type WithdrawResult struct {
+	RefID string `json:"refid"`
+}
+
+const (
+	TransferStatusInitial = "Initial"
+	TransferStatusPending = "Pending"
+	TransferStatusSettled = "Settled"
+	TransferStatusSuccess = "Success"
+	TransferStatusFailure = "Failure"
+)
+
+// Transfer is a deposit or withdrawal as returned by the DepositStatus and
+// WithdrawStatus endpoints.
+type Transfer struct {
+	Method string  `json:"method"`
+	Asset  string  `json:"asset"`
+	RefID  string  `json:"refid"`
+	TxID   string  `json:"txid"`
+	Info   string  `json:"info"`
+	Amount float64 `json:"amount,string"`
+	Fee    float64 `json:"fee,string"`
+	Time   int64   `json:"time"`
+	Status string  `json:"status"`
+}
+
+type WebSocketsToken struct {
+	Token   string `json:"token"`
+	Expires int64  `json:"expires"`
+}
+
+// WSRequest is a websocket v2 request.
+type WSRequest struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+	ReqID  uint64      `json:"req_id,omitempty"`
+}
+
+type BookSubscription struct {
+	Channel  string   `json:"channel"`
+	Symbol   []string `json:"symbol"`
+	Depth    int      `json:"depth,omitempty"`
+	Snapshot bool     `json:"snapshot"`
+}
+
+type PrivateSubscription struct {
+	Channel    string `json:"channel"`
+	Token      string `json:"token"`
+	SnapOrders bool   `json:"snap_orders"`
+	Snapshot   bool   `json:"snapshot"`
+}
+
+// WSMessage is a websocket v2 message. Method responses have a Method and
+// Success field. Channel messages have a Channel, Type and Data.
+type WSMessage struct {
+	Method  string          `json:"method"`
+	Success bool            `json:"success"`
+	Error   string          `json:"error"`
+	ReqID   uint64          `json:"req_id"`
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type PriceLevel struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+type BookUpdate struct {
+	Symbol   string        `json:"symbol"`
+	Bids     []*PriceLevel `json:"bids"`
+	Asks     []*PriceLevel `json:"asks"`
+	Checksum uint32        `json:"checksum"`
+}
+
+const (
+	ExecTypeNew      = "new"
+	ExecTypeTrade    = "trade"
+	ExecTypeFilled   = "filled"
+	ExecTypeCanceled = "canceled"
+	ExecTypeExpired  = "expired"
+)
+
+type Execution struct {
+	OrderID       string  `json:"order_id"`
+	ClientOrderID string  `json:"cl_ord_id"`
+	ExecType      string  `json:"exec_type"`
+	OrderStatus   string  `json:"order_status"`
+	Symbol        string  `json:"symbol"`
+	Side          string  `json:"side"`
+	CumQty        float64 `json:"cum_qty"`
+	CumCost       float64 `json:"cum_cost"`
+}
+
+type WSBalance struct {
+	Asset   string  `json:"asset"`
+	Balance float64 `json:"balance"`
+}
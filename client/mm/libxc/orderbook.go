@@ -51,21 +51,115 @@ func (o obEntryComparable) CalcScore(key interface{}) float64 {
 	}
 }
 
+// bookFeedBufferSize is the capacity of a book update feed's channel.
+const bookFeedBufferSize = 256
+
+// bookFeed is a subscription to orderbook updates. If an update cannot be
+// delivered because the channel is full, the feed is marked for resync and
+// the next update delivered is a snapshot of the full book.
+type bookFeed struct {
+	c      chan *BookUpdate
+	resync bool
+}
+
 // orderbook is an implementation of a limit order book that allows for quick
 // updates and calculation of the volume weighted average price (VWAP).
 type orderbook struct {
-	mtx  sync.RWMutex
-	bids skiplist.SkipList
-	asks skiplist.SkipList
+	mtx   sync.RWMutex
+	bids  skiplist.SkipList
+	asks  skiplist.SkipList
+	feeds map[int]*bookFeed
+	feedN int
 }
 
 func newOrderBook() *orderbook {
 	return &orderbook{
-		bids: *skiplist.New(bidsComparable),
-		asks: *skiplist.New(asksComparable),
+		bids:  *skiplist.New(bidsComparable),
+		asks:  *skiplist.New(asksComparable),
+		feeds: make(map[int]*bookFeed),
+	}
+}
+
+// subscribe creates a feed of updates to the book. The first update sent is
+// a snapshot of the current book.
+func (ob *orderbook) subscribe() (<-chan *BookUpdate, func()) {
+	ob.mtx.Lock()
+	defer ob.mtx.Unlock()
+
+	feed := &bookFeed{
+		c:      make(chan *BookUpdate, bookFeedBufferSize),
+		resync: true,
+	}
+	id := ob.feedN
+	ob.feedN++
+	ob.feeds[id] = feed
+	if ob.bids.Len() > 0 || ob.asks.Len() > 0 {
+		// Only the new feed gets the snapshot. The channel is empty, so
+		// this won't block.
+		bids, asks := ob.entries()
+		feed.c <- &BookUpdate{
+			Snapshot: true,
+			Bids:     bookEntries(bids),
+			Asks:     bookEntries(asks),
+		}
+		feed.resync = false
+	}
+
+	return feed.c, func() {
+		ob.mtx.Lock()
+		defer ob.mtx.Unlock()
+		if _, found := ob.feeds[id]; found {
+			delete(ob.feeds, id)
+			close(feed.c)
+		}
 	}
 }
 
+// sendToFeeds sends the update to all feeds. Feeds that need a resync are sent
+// a snapshot instead. The mtx MUST be held.
+func (ob *orderbook) sendToFeeds(bids, asks []*obEntry) {
+	if len(ob.feeds) == 0 {
+		return
+	}
+	var update, snapshot *BookUpdate
+	for _, feed := range ob.feeds {
+		var u *BookUpdate
+		if feed.resync {
+			if snapshot == nil {
+				bids, asks := ob.entries()
+				snapshot = &BookUpdate{
+					Snapshot: true,
+					Bids:     bookEntries(bids),
+					Asks:     bookEntries(asks),
+				}
+			}
+			u = snapshot
+		} else {
+			if update == nil {
+				update = &BookUpdate{
+					Bids: bookEntries(bids),
+					Asks: bookEntries(asks),
+				}
+			}
+			u = update
+		}
+		select {
+		case feed.c <- u:
+			feed.resync = false
+		default:
+			feed.resync = true
+		}
+	}
+}
+
+func bookEntries(entries []*obEntry) []*BookEntry {
+	es := make([]*BookEntry, len(entries))
+	for i, e := range entries {
+		es[i] = &BookEntry{Rate: e.rate, Qty: e.qty}
+	}
+	return es
+}
+
 func (ob *orderbook) String() string {
 	ob.mtx.RLock()
 	defer ob.mtx.RUnlock()
@@ -104,14 +198,21 @@ func (ob *orderbook) update(bids []*obEntry, asks []*obEntry) {
 		}
 		ob.asks.Set(entry, entry)
 	}
+
+	ob.sendToFeeds(bids, asks)
 }
 
+// clear removes all entries from the orderbook. Subscribers will be sent a
+// snapshot with the next update.
 func (ob *orderbook) clear() {
 	ob.mtx.Lock()
 	defer ob.mtx.Unlock()
 
 	ob.bids = *skiplist.New(bidsComparable)
 	ob.asks = *skiplist.New(asksComparable)
+	for _, feed := range ob.feeds {
+		feed.resync = true
+	}
 }
 
 func (ob *orderbook) vwap(bids bool, qty uint64) (vwap, extrema uint64, filled bool) {
@@ -172,7 +273,12 @@ func (ob *orderbook) midGap() uint64 {
 func (ob *orderbook) snap() (bids, asks []*obEntry) {
 	ob.mtx.RLock()
 	defer ob.mtx.RUnlock()
+	return ob.entries()
+}
 
+// entries returns the entries on both sides of the book. The mtx MUST be
+// held.
+func (ob *orderbook) entries() (bids, asks []*obEntry) {
 	bids = make([]*obEntry, 0, ob.bids.Len())
 	for curr := ob.bids.Front(); curr != nil; curr = curr.Next() {
 		bids = append(bids, curr.Value.(*obEntry))
@@ -99,3 +99,73 @@ func TestOrderbook(t *testing.T) {
 		t.Fatalf("wrong extrema")
 	}
 }
+
+func TestOrderbookFeed(t *testing.T) {
+	ob := newOrderBook()
+	ob.update([]*obEntry{{qty: 10, rate: 100}}, []*obEntry{{qty: 20, rate: 200}})
+
+	feed, unsubscribe := ob.subscribe()
+
+	next := func() *BookUpdate {
+		t.Helper()
+		select {
+		case u := <-feed:
+			return u
+		default:
+			t.Fatalf("no update")
+		}
+		return nil
+	}
+
+	// The first update is a snapshot of the current book.
+	u := next()
+	if !u.Snapshot || len(u.Bids) != 1 || len(u.Asks) != 1 || u.Bids[0].Qty != 10 || u.Asks[0].Rate != 200 {
+		t.Fatalf("wrong initial snapshot: %+v", u)
+	}
+
+	// A new subscription doesn't send anything to existing feeds.
+	feed2, unsubscribe2 := ob.subscribe()
+	if u := <-feed2; !u.Snapshot {
+		t.Fatalf("second feed's first update not a snapshot")
+	}
+	unsubscribe2()
+	select {
+	case u := <-feed:
+		t.Fatalf("existing feed received an update for a new subscription: %+v", u)
+	default:
+	}
+
+	// Updates are forwarded as-is.
+	ob.update([]*obEntry{{qty: 0, rate: 100}}, nil)
+	u = next()
+	if u.Snapshot || len(u.Bids) != 1 || u.Bids[0].Qty != 0 || len(u.Asks) != 0 {
+		t.Fatalf("wrong update: %+v", u)
+	}
+
+	// Clearing the book results in a snapshot with the next update.
+	ob.clear()
+	ob.update([]*obEntry{{qty: 5, rate: 90}}, nil)
+	u = next()
+	if !u.Snapshot || len(u.Bids) != 1 || u.Bids[0].Rate != 90 || len(u.Asks) != 0 {
+		t.Fatalf("wrong snapshot after clear: %+v", u)
+	}
+
+	// Overflowing the feed results in a snapshot once there is room.
+	for i := 0; i < bookFeedBufferSize+1; i++ {
+		ob.update([]*obEntry{{qty: uint64(i + 1), rate: 90}}, nil)
+	}
+	for i := 0; i < bookFeedBufferSize; i++ {
+		next()
+	}
+	ob.update(nil, []*obEntry{{qty: 1, rate: 300}})
+	u = next()
+	if !u.Snapshot || u.Bids[0].Qty != bookFeedBufferSize+1 || len(u.Asks) != 1 {
+		t.Fatalf("wrong snapshot after overflow: %+v", u)
+	}
+
+	unsubscribe()
+	if _, ok := <-feed; ok {
+		t.Fatalf("feed not closed")
+	}
+	unsubscribe()
+}
@@ -0,0 +1,882 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc"
+	"decred.org/dcrdex/client/orderbook"
+	"decred.org/dcrdex/dex/msgjson"
+)
+
+// Market data files are append-only logs of the order book updates of a
+// single market. A file starts with the magic bytes, a version byte, and a
+// length-prefixed JSON-encoded MarketDataHeader. The header is followed by
+// records, each encoded as
+//
+//	type (1 byte) | body length (uvarint) | body
+//
+// Every body starts with the time the update was received as a uvarint unix
+// millisecond timestamp. All other integers are uvarints, booleans are a
+// single byte, and DEX orders are identified by their 4-byte token. A record
+// that was only partially written, e.g. because of a crash, is ignored by
+// readers and truncated before more records are appended.
+
+const (
+	mktDataMagic   = "BWMD"
+	mktDataVersion = 0
+	// maxMktDataRecordSize is a sanity limit on the size of a record body.
+	maxMktDataRecordSize = 1 << 26
+)
+
+type mktDataRecordType byte
+
+const (
+	// mdRecDEXSnapshot replaces the DEX book. The body is the number of
+	// orders followed by the orders.
+	mdRecDEXSnapshot mktDataRecordType = iota + 1
+	// mdRecDEXBookOrder adds an order to the DEX book.
+	mdRecDEXBookOrder
+	// mdRecDEXUnbookOrder removes an order from the DEX book.
+	mdRecDEXUnbookOrder
+	// mdRecDEXUpdateRemaining updates the remaining quantity of a DEX order.
+	mdRecDEXUpdateRemaining
+	// mdRecDEXEpoch is a DEX epoch report with the epoch's matches.
+	mdRecDEXEpoch
+	// mdRecCEXSnapshot replaces the CEX book.
+	mdRecCEXSnapshot
+	// mdRecCEXUpdate updates CEX book levels. A zero quantity removes the
+	// level.
+	mdRecCEXUpdate
+)
+
+// MarketDataHeader describes the market that a market data file records.
+type MarketDataHeader struct {
+	Host    string `json:"host"`
+	BaseID  uint32 `json:"baseID"`
+	QuoteID uint32 `json:"quoteID"`
+	CEXName string `json:"cexName,omitempty"`
+}
+
+type mdOrder struct {
+	token [4]byte
+	sell  bool
+	rate  uint64
+	qty   uint64
+}
+
+// mdRecord is a decoded market data record. Only the fields relevant to the
+// record type are populated.
+type mdRecord struct {
+	typ     mktDataRecordType
+	stamp   int64
+	orders  []*mdOrder
+	epoch   uint64
+	matches []*BacktestMatch
+	bids    []*BookLevel
+	asks    []*BookLevel
+}
+
+func parseToken(tokenStr string) (token [4]byte, err error) {
+	b, err := hex.DecodeString(tokenStr)
+	if err != nil || len(b) != len(token) {
+		return token, fmt.Errorf("invalid order token %q", tokenStr)
+	}
+	copy(token[:], b)
+	return token, nil
+}
+
+type mdEncoder struct {
+	b []byte
+}
+
+func (e *mdEncoder) uvarint(v uint64) {
+	e.b = binary.AppendUvarint(e.b, v)
+}
+
+func (e *mdEncoder) bool(v bool) {
+	if v {
+		e.b = append(e.b, 1)
+	} else {
+		e.b = append(e.b, 0)
+	}
+}
+
+func (e *mdEncoder) order(o *mdOrder) {
+	e.b = append(e.b, o.token[:]...)
+	e.bool(o.sell)
+	e.uvarint(o.rate)
+	e.uvarint(o.qty)
+}
+
+func (e *mdEncoder) levels(levels []*BookLevel) {
+	e.uvarint(uint64(len(levels)))
+	for _, l := range levels {
+		e.uvarint(l.Rate)
+		e.uvarint(l.Qty)
+	}
+}
+
+// encode encodes the record body.
+func (r *mdRecord) encode() []byte {
+	e := &mdEncoder{b: make([]byte, 0, 64)}
+	e.uvarint(uint64(r.stamp))
+	switch r.typ {
+	case mdRecDEXSnapshot:
+		e.uvarint(uint64(len(r.orders)))
+		for _, o := range r.orders {
+			e.order(o)
+		}
+	case mdRecDEXBookOrder:
+		e.order(r.orders[0])
+	case mdRecDEXUnbookOrder:
+		e.b = append(e.b, r.orders[0].token[:]...)
+	case mdRecDEXUpdateRemaining:
+		e.b = append(e.b, r.orders[0].token[:]...)
+		e.uvarint(r.orders[0].qty)
+	case mdRecDEXEpoch:
+		e.uvarint(r.epoch)
+		e.uvarint(uint64(len(r.matches)))
+		for _, m := range r.matches {
+			e.uvarint(m.Rate)
+			e.uvarint(m.Qty)
+			e.bool(m.TakerSell)
+		}
+	case mdRecCEXSnapshot, mdRecCEXUpdate:
+		e.levels(r.bids)
+		e.levels(r.asks)
+	}
+	return e.b
+}
+
+type mdDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *mdDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = errors.New("invalid uvarint")
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// count reads a uvarint count of items that are each at least minSize bytes.
+func (d *mdDecoder) count(minSize int) int {
+	n := d.uvarint()
+	if d.err == nil && n > uint64(len(d.b)/minSize) {
+		d.err = fmt.Errorf("count %d too large for %d remaining bytes", n, len(d.b))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *mdDecoder) bool() bool {
+	if d.err != nil {
+		return false
+	}
+	if len(d.b) == 0 {
+		d.err = io.ErrUnexpectedEOF
+		return false
+	}
+	v := d.b[0]
+	d.b = d.b[1:]
+	return v != 0
+}
+
+func (d *mdDecoder) token() (token [4]byte) {
+	if d.err != nil {
+		return
+	}
+	if len(d.b) < len(token) {
+		d.err = io.ErrUnexpectedEOF
+		return
+	}
+	copy(token[:], d.b)
+	d.b = d.b[len(token):]
+	return
+}
+
+func (d *mdDecoder) order() *mdOrder {
+	return &mdOrder{
+		token: d.token(),
+		sell:  d.bool(),
+		rate:  d.uvarint(),
+		qty:   d.uvarint(),
+	}
+}
+
+func (d *mdDecoder) levels() []*BookLevel {
+	n := d.count(2)
+	levels := make([]*BookLevel, 0, n)
+	for i := 0; i < n; i++ {
+		levels = append(levels, &BookLevel{Rate: d.uvarint(), Qty: d.uvarint()})
+	}
+	return levels
+}
+
+func decodeMktDataRecord(typ mktDataRecordType, body []byte) (*mdRecord, error) {
+	d := &mdDecoder{b: body}
+	r := &mdRecord{
+		typ:   typ,
+		stamp: int64(d.uvarint()),
+	}
+	switch typ {
+	case mdRecDEXSnapshot:
+		n := d.count(7)
+		r.orders = make([]*mdOrder, 0, n)
+		for i := 0; i < n; i++ {
+			r.orders = append(r.orders, d.order())
+		}
+	case mdRecDEXBookOrder:
+		r.orders = []*mdOrder{d.order()}
+	case mdRecDEXUnbookOrder:
+		r.orders = []*mdOrder{{token: d.token()}}
+	case mdRecDEXUpdateRemaining:
+		r.orders = []*mdOrder{{token: d.token(), qty: d.uvarint()}}
+	case mdRecDEXEpoch:
+		r.epoch = d.uvarint()
+		n := d.count(3)
+		r.matches = make([]*BacktestMatch, 0, n)
+		for i := 0; i < n; i++ {
+			r.matches = append(r.matches, &BacktestMatch{
+				Rate:      d.uvarint(),
+				Qty:       d.uvarint(),
+				TakerSell: d.bool(),
+			})
+		}
+	case mdRecCEXSnapshot, mdRecCEXUpdate:
+		r.bids = d.levels()
+		r.asks = d.levels()
+	default:
+		return nil, fmt.Errorf("unknown record type %d", typ)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("error decoding record of type %d: %w", typ, d.err)
+	}
+	return r, nil
+}
+
+// readMktDataHeader reads and validates the file header, returning the
+// header and the number of bytes read.
+func readMktDataHeader(r *bufio.Reader) (*MarketDataHeader, int64, error) {
+	pre := make([]byte, len(mktDataMagic)+1)
+	if _, err := io.ReadFull(r, pre); err != nil {
+		return nil, 0, fmt.Errorf("error reading header: %w", err)
+	}
+	if string(pre[:len(mktDataMagic)]) != mktDataMagic {
+		return nil, 0, errors.New("not a market data file")
+	}
+	if v := pre[len(mktDataMagic)]; v != mktDataVersion {
+		return nil, 0, fmt.Errorf("unknown market data file version %d", v)
+	}
+	hdrLen, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading header length: %w", err)
+	}
+	if hdrLen > maxMktDataRecordSize {
+		return nil, 0, fmt.Errorf("header length %d too large", hdrLen)
+	}
+	b := make([]byte, hdrLen)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, 0, fmt.Errorf("error reading header: %w", err)
+	}
+	var hdr MarketDataHeader
+	if err := json.Unmarshal(b, &hdr); err != nil {
+		return nil, 0, fmt.Errorf("error decoding header: %w", err)
+	}
+	n := int64(len(pre)) + int64(uvarintSize(hdrLen)) + int64(hdrLen)
+	return &hdr, n, nil
+}
+
+func uvarintSize(v uint64) int {
+	var b [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(b[:], v)
+}
+
+// readMktDataRecord reads the next record. The number of bytes read is
+// returned with the record. io.EOF is returned at the end of the data,
+// including when the last record is incomplete.
+func readMktDataRecord(r *bufio.Reader) (*mdRecord, int64, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, 0, io.EOF
+	}
+	bodyLen, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, io.EOF
+	}
+	if bodyLen > maxMktDataRecordSize {
+		return nil, 0, fmt.Errorf("record length %d too large", bodyLen)
+	}
+	body := make([]byte, bodyLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, 0, io.EOF
+	}
+	rec, err := decodeMktDataRecord(mktDataRecordType(typ), body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rec, 1 + int64(uvarintSize(bodyLen)) + int64(bodyLen), nil
+}
+
+// mktDataWriter appends records to a market data file.
+type mktDataWriter struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+// openMktDataWriter opens the market data file at path for appending,
+// creating it if it does not exist. If the file exists, its header must match
+// hdr.
+func openMktDataWriter(path string, hdr *MarketDataHeader) (*mktDataWriter, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if fi.Size() == 0 {
+		hdrB, err := json.Marshal(hdr)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		b := append([]byte(mktDataMagic), mktDataVersion)
+		b = binary.AppendUvarint(b, uint64(len(hdrB)))
+		if _, err := f.Write(append(b, hdrB...)); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error writing header: %w", err)
+		}
+		return &mktDataWriter{f: f, w: bufio.NewWriter(f)}, nil
+	}
+
+	// Find the end of the last complete record, and discard anything after
+	// it.
+	br := bufio.NewReader(f)
+	fileHdr, end, err := readMktDataHeader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if *fileHdr != *hdr {
+		f.Close()
+		return nil, fmt.Errorf("market data file %s is for a different market: %+v", path, fileHdr)
+	}
+	for {
+		_, n, err := readMktDataRecord(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error reading market data file %s: %w", path, err)
+		}
+		end += n
+	}
+	if end != fi.Size() {
+		if err := f.Truncate(end); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error truncating incomplete record: %w", err)
+		}
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &mktDataWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (w *mktDataWriter) write(r *mdRecord) error {
+	body := r.encode()
+	b := make([]byte, 0, 1+binary.MaxVarintLen64+len(body))
+	b = append(b, byte(r.typ))
+	b = binary.AppendUvarint(b, uint64(len(body)))
+	_, err := w.w.Write(append(b, body...))
+	return err
+}
+
+func (w *mktDataWriter) flush() error {
+	return w.w.Flush()
+}
+
+func (w *mktDataWriter) close() error {
+	err := w.w.Flush()
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// mktDataState is an order book reconstructed from market data records.
+type mktDataState struct {
+	stamp     int64
+	dexOrders map[[4]byte]*mdOrder
+	cexBids   map[uint64]uint64
+	cexAsks   map[uint64]uint64
+	epoch     uint64
+}
+
+func newMktDataState() *mktDataState {
+	return &mktDataState{
+		dexOrders: make(map[[4]byte]*mdOrder),
+		cexBids:   make(map[uint64]uint64),
+		cexAsks:   make(map[uint64]uint64),
+	}
+}
+
+func (s *mktDataState) apply(r *mdRecord) {
+	s.stamp = r.stamp
+	setLevels := func(side map[uint64]uint64, levels []*BookLevel) {
+		for _, l := range levels {
+			if l.Qty == 0 {
+				delete(side, l.Rate)
+			} else {
+				side[l.Rate] = l.Qty
+			}
+		}
+	}
+	switch r.typ {
+	case mdRecDEXSnapshot:
+		s.dexOrders = make(map[[4]byte]*mdOrder, len(r.orders))
+		for _, o := range r.orders {
+			s.dexOrders[o.token] = o
+		}
+	case mdRecDEXBookOrder:
+		o := *r.orders[0]
+		s.dexOrders[o.token] = &o
+	case mdRecDEXUnbookOrder:
+		delete(s.dexOrders, r.orders[0].token)
+	case mdRecDEXUpdateRemaining:
+		if o, found := s.dexOrders[r.orders[0].token]; found {
+			o.qty = r.orders[0].qty
+		}
+	case mdRecDEXEpoch:
+		s.epoch = r.epoch
+	case mdRecCEXSnapshot:
+		s.cexBids = make(map[uint64]uint64, len(r.bids))
+		s.cexAsks = make(map[uint64]uint64, len(r.asks))
+		setLevels(s.cexBids, r.bids)
+		setLevels(s.cexAsks, r.asks)
+	case mdRecCEXUpdate:
+		setLevels(s.cexBids, r.bids)
+		setLevels(s.cexAsks, r.asks)
+	}
+}
+
+// MarketDataOrder is a DEX order in a reconstructed order book.
+type MarketDataOrder struct {
+	Token string `json:"token"`
+	Rate  uint64 `json:"rate"`
+	Qty   uint64 `json:"qty"`
+}
+
+// MarketDataBook is the state of a market's order books at a point in time.
+// Buys are sorted by descending rate and sells by ascending rate.
+type MarketDataBook struct {
+	// Stamp is the time of the last update applied to the book, in
+	// milliseconds.
+	Stamp int64 `json:"stamp"`
+	// Epoch is the most recently reported DEX epoch.
+	Epoch    uint64             `json:"epoch"`
+	DEXBuys  []*MarketDataOrder `json:"dexBuys"`
+	DEXSells []*MarketDataOrder `json:"dexSells"`
+	CEXBuys  []*BookLevel       `json:"cexBuys,omitempty"`
+	CEXSells []*BookLevel       `json:"cexSells,omitempty"`
+}
+
+func (s *mktDataState) book() *MarketDataBook {
+	book := &MarketDataBook{
+		Stamp: s.stamp,
+		Epoch: s.epoch,
+	}
+	for _, o := range s.dexOrders {
+		mo := &MarketDataOrder{
+			Token: hex.EncodeToString(o.token[:]),
+			Rate:  o.rate,
+			Qty:   o.qty,
+		}
+		if o.sell {
+			book.DEXSells = append(book.DEXSells, mo)
+		} else {
+			book.DEXBuys = append(book.DEXBuys, mo)
+		}
+	}
+	sortOrders := func(ords []*MarketDataOrder, desc bool) {
+		sort.Slice(ords, func(i, j int) bool {
+			if ords[i].Rate != ords[j].Rate {
+				return (ords[i].Rate > ords[j].Rate) == desc
+			}
+			return ords[i].Token < ords[j].Token
+		})
+	}
+	sortOrders(book.DEXBuys, true)
+	sortOrders(book.DEXSells, false)
+	book.CEXBuys = mapLevels(s.cexBids, true)
+	book.CEXSells = mapLevels(s.cexAsks, false)
+	return book
+}
+
+func mapLevels(side map[uint64]uint64, desc bool) []*BookLevel {
+	levels := make([]*BookLevel, 0, len(side))
+	for rate, qty := range side {
+		levels = append(levels, &BookLevel{Rate: rate, Qty: qty})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return (levels[i].Rate > levels[j].Rate) == desc
+	})
+	return levels
+}
+
+// dexLevels aggregates the DEX orders into price levels.
+func (s *mktDataState) dexLevels(sell bool) []*BookLevel {
+	side := make(map[uint64]uint64)
+	for _, o := range s.dexOrders {
+		if o.sell == sell {
+			side[o.rate] += o.qty
+		}
+	}
+	return mapLevels(side, !sell)
+}
+
+type mdSnapshotIndex struct {
+	stamp  int64
+	offset int64
+}
+
+// MarketDataReader reads a market data file written by the market data
+// recorder.
+type MarketDataReader struct {
+	f         *os.File
+	hdr       *MarketDataHeader
+	dataStart int64
+	dataEnd   int64
+	start     int64
+	end       int64
+	hasCEX    bool
+	// dexSnaps and cexSnaps index the DEX and CEX snapshot records, so that
+	// a book can be reconstructed without replaying the whole file.
+	dexSnaps []*mdSnapshotIndex
+	cexSnaps []*mdSnapshotIndex
+}
+
+// OpenMarketData opens a market data file for reading.
+func OpenMarketData(path string) (*MarketDataReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	hdr, offset, err := readMktDataHeader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &MarketDataReader{
+		f:         f,
+		hdr:       hdr,
+		dataStart: offset,
+	}
+	for {
+		rec, n, err := readMktDataRecord(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error reading market data: %w", err)
+		}
+		if r.start == 0 {
+			r.start = rec.stamp
+		}
+		r.end = rec.stamp
+		switch rec.typ {
+		case mdRecDEXSnapshot:
+			r.dexSnaps = append(r.dexSnaps, &mdSnapshotIndex{stamp: rec.stamp, offset: offset})
+		case mdRecCEXSnapshot:
+			r.hasCEX = true
+			r.cexSnaps = append(r.cexSnaps, &mdSnapshotIndex{stamp: rec.stamp, offset: offset})
+		case mdRecCEXUpdate:
+			r.hasCEX = true
+		}
+		offset += n
+	}
+	r.dataEnd = offset
+	return r, nil
+}
+
+// Header returns the header describing the recorded market.
+func (r *MarketDataReader) Header() *MarketDataHeader {
+	hdr := *r.hdr
+	return &hdr
+}
+
+// Range returns the timestamps of the first and last records, in
+// milliseconds.
+func (r *MarketDataReader) Range() (start, end int64) {
+	return r.start, r.end
+}
+
+// Close closes the file.
+func (r *MarketDataReader) Close() error {
+	return r.f.Close()
+}
+
+// replay applies records, starting at the given offset, to the state until
+// the stamp of a record exceeds endStamp. If f is non-nil, it is called after
+// each record is applied, and replay stops if f returns an error.
+func (r *MarketDataReader) replay(state *mktDataState, offset, endStamp int64, f func(*mdRecord) error) error {
+	br := bufio.NewReader(io.NewSectionReader(r.f, offset, r.dataEnd-offset))
+	for {
+		rec, _, err := readMktDataRecord(br)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rec.stamp > endStamp {
+			return nil
+		}
+		state.apply(rec)
+		if f != nil {
+			if err := f(rec); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// lastSnapshot finds the offset of the last snapshot at or before the stamp.
+func lastSnapshot(snaps []*mdSnapshotIndex, stamp int64) (int64, bool) {
+	i := sort.Search(len(snaps), func(i int) bool { return snaps[i].stamp > stamp })
+	if i == 0 {
+		return 0, false
+	}
+	return snaps[i-1].offset, true
+}
+
+// BookAt reconstructs the order books as they were at the specified time, in
+// milliseconds.
+func (r *MarketDataReader) BookAt(stamp int64) (*MarketDataBook, error) {
+	if stamp < r.start {
+		return nil, fmt.Errorf("no data before %d", r.start)
+	}
+	offset, found := lastSnapshot(r.dexSnaps, stamp)
+	if !found {
+		offset = r.dataStart
+	}
+	if r.hasCEX {
+		cexOffset, found := lastSnapshot(r.cexSnaps, stamp)
+		if !found {
+			cexOffset = r.dataStart
+		}
+		offset = min(offset, cexOffset)
+	}
+	state := newMktDataState()
+	if err := r.replay(state, offset, stamp, nil); err != nil {
+		return nil, err
+	}
+	return state.book(), nil
+}
+
+// BacktestSource creates a BacktestDataSource that replays the recorded
+// market. An epoch is generated for every recorded DEX epoch report.
+func (r *MarketDataReader) BacktestSource() BacktestDataSource {
+	return &mktDataBacktestSource{
+		r:     r,
+		br:    bufio.NewReader(io.NewSectionReader(r.f, r.dataStart, r.dataEnd-r.dataStart)),
+		state: newMktDataState(),
+	}
+}
+
+type mktDataBacktestSource struct {
+	r     *MarketDataReader
+	br    *bufio.Reader
+	state *mktDataState
+}
+
+func (s *mktDataBacktestSource) NextEpoch() (*BacktestEpoch, error) {
+	for {
+		rec, _, err := readMktDataRecord(s.br)
+		if err != nil {
+			return nil, err
+		}
+		s.state.apply(rec)
+		if rec.typ != mdRecDEXEpoch {
+			continue
+		}
+		e := &BacktestEpoch{
+			Epoch:    rec.epoch,
+			Stamp:    rec.stamp,
+			DEXBuys:  s.state.dexLevels(false),
+			DEXSells: s.state.dexLevels(true),
+			Matches:  rec.matches,
+		}
+		if s.r.hasCEX {
+			e.CEXBuys = mapLevels(s.state.cexBids, true)
+			e.CEXSells = mapLevels(s.state.cexAsks, false)
+		}
+		return e, nil
+	}
+}
+
+// dexSnapshotRecord creates a DEX snapshot record from the order book.
+func dexSnapshotRecord(stamp int64, buys, sells []*core.MiniOrder) (*mdRecord, error) {
+	r := &mdRecord{
+		typ:    mdRecDEXSnapshot,
+		stamp:  stamp,
+		orders: make([]*mdOrder, 0, len(buys)+len(sells)),
+	}
+	for _, ords := range [][]*core.MiniOrder{buys, sells} {
+		for _, o := range ords {
+			mo, err := mdOrderFromMini(o)
+			if err != nil {
+				return nil, err
+			}
+			r.orders = append(r.orders, mo)
+		}
+	}
+	return r, nil
+}
+
+func mdOrderFromMini(o *core.MiniOrder) (*mdOrder, error) {
+	token, err := parseToken(o.Token)
+	if err != nil {
+		return nil, err
+	}
+	return &mdOrder{
+		token: token,
+		sell:  o.Sell,
+		rate:  o.MsgRate,
+		qty:   o.QtyAtomic,
+	}, nil
+}
+
+// dexBookRecord translates a core.BookUpdate into a market data record. A nil
+// record is returned for updates that are not recorded.
+func dexBookRecord(stamp int64, u *core.BookUpdate) (*mdRecord, error) {
+	switch u.Action {
+	case core.FreshBookAction:
+		mob, ok := u.Payload.(*core.MarketOrderBook)
+		if !ok || mob.Book == nil {
+			return nil, fmt.Errorf("unexpected payload type %T for %s", u.Payload, u.Action)
+		}
+		return dexSnapshotRecord(stamp, mob.Book.Buys, mob.Book.Sells)
+	case core.BookOrderAction:
+		o, ok := u.Payload.(*core.MiniOrder)
+		if !ok {
+			return nil, fmt.Errorf("unexpected payload type %T for %s", u.Payload, u.Action)
+		}
+		mo, err := mdOrderFromMini(o)
+		if err != nil {
+			return nil, err
+		}
+		return &mdRecord{typ: mdRecDEXBookOrder, stamp: stamp, orders: []*mdOrder{mo}}, nil
+	case core.UnbookOrderAction:
+		o, ok := u.Payload.(*core.MiniOrder)
+		if !ok {
+			return nil, fmt.Errorf("unexpected payload type %T for %s", u.Payload, u.Action)
+		}
+		token, err := parseToken(o.Token)
+		if err != nil {
+			return nil, err
+		}
+		return &mdRecord{typ: mdRecDEXUnbookOrder, stamp: stamp, orders: []*mdOrder{{token: token}}}, nil
+	case core.UpdateRemainingAction:
+		ru, ok := u.Payload.(*core.RemainderUpdate)
+		if !ok {
+			return nil, fmt.Errorf("unexpected payload type %T for %s", u.Payload, u.Action)
+		}
+		token, err := parseToken(ru.Token)
+		if err != nil {
+			return nil, err
+		}
+		return &mdRecord{typ: mdRecDEXUpdateRemaining, stamp: stamp, orders: []*mdOrder{{token: token, qty: ru.QtyAtomic}}}, nil
+	case core.EpochMatchSummary:
+		p, ok := u.Payload.(*core.EpochMatchSummaryPayload)
+		if !ok {
+			return nil, fmt.Errorf("unexpected payload type %T for %s", u.Payload, u.Action)
+		}
+		r := &mdRecord{
+			typ:     mdRecDEXEpoch,
+			stamp:   stamp,
+			epoch:   p.Epoch,
+			matches: make([]*BacktestMatch, 0, len(p.MatchSummaries)),
+		}
+		for _, m := range p.MatchSummaries {
+			r.matches = append(r.matches, &BacktestMatch{
+				Rate: m.Rate,
+				Qty:  m.Qty,
+				// The MatchSummary's Sell field refers to the maker.
+				TakerSell: !m.Sell,
+			})
+		}
+		return r, nil
+	}
+	return nil, nil
+}
+
+// cexBookRecord translates a libxc.BookUpdate into a market data record.
+func cexBookRecord(stamp int64, u *libxc.BookUpdate) *mdRecord {
+	levels := func(entries []*libxc.BookEntry) []*BookLevel {
+		ls := make([]*BookLevel, len(entries))
+		for i, e := range entries {
+			ls[i] = &BookLevel{Rate: e.Rate, Qty: e.Qty}
+		}
+		return ls
+	}
+	typ := mdRecCEXUpdate
+	if u.Snapshot {
+		typ = mdRecCEXSnapshot
+	}
+	return &mdRecord{
+		typ:   typ,
+		stamp: stamp,
+		bids:  levels(u.Bids),
+		asks:  levels(u.Asks),
+	}
+}
+
+// dexSnapshotFromBook creates a DEX snapshot record from a synced order book.
+func dexSnapshotFromBook(stamp int64, book *orderbook.OrderBook) *mdRecord {
+	buys, sells, _ := book.Orders()
+	r := &mdRecord{
+		typ:    mdRecDEXSnapshot,
+		stamp:  stamp,
+		orders: make([]*mdOrder, 0, len(buys)+len(sells)),
+	}
+	for _, ords := range [][]*orderbook.Order{buys, sells} {
+		for _, o := range ords {
+			mo := &mdOrder{
+				sell: o.Side == msgjson.SellOrderNum,
+				rate: o.Rate,
+				qty:  o.Quantity,
+			}
+			copy(mo.token[:], o.OrderID[:])
+			r.orders = append(r.orders, mo)
+		}
+	}
+	return r
+}
@@ -0,0 +1,332 @@
+package mm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc"
+	"decred.org/dcrdex/client/orderbook"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
+)
+
+func TestMarketDataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mkt.mdr")
+	hdr := &MarketDataHeader{Host: "host1", BaseID: 42, QuoteID: 0, CEXName: "Binance"}
+
+	w, err := openMktDataWriter(path, hdr)
+	if err != nil {
+		t.Fatalf("error opening writer: %v", err)
+	}
+
+	write := func(u any, stamp int64) {
+		t.Helper()
+		var rec *mdRecord
+		switch u := u.(type) {
+		case *core.BookUpdate:
+			rec, err = dexBookRecord(stamp, u)
+			if err != nil {
+				t.Fatalf("error translating %s: %v", u.Action, err)
+			}
+		case *libxc.BookUpdate:
+			rec = cexBookRecord(stamp, u)
+		}
+		if rec == nil {
+			return
+		}
+		if err := w.write(rec); err != nil {
+			t.Fatalf("error writing record: %v", err)
+		}
+	}
+	mini := func(token string, sell bool, rate, qty uint64) *core.MiniOrder {
+		return &core.MiniOrder{Token: token, Sell: sell, MsgRate: rate, QtyAtomic: qty}
+	}
+
+	write(&core.BookUpdate{
+		Action: core.FreshBookAction,
+		Payload: &core.MarketOrderBook{Book: &core.OrderBook{
+			Buys:  []*core.MiniOrder{mini("00000001", false, 100, 10)},
+			Sells: []*core.MiniOrder{mini("00000002", true, 120, 20)},
+		}},
+	}, 1000)
+	write(&libxc.BookUpdate{
+		Snapshot: true,
+		Bids:     []*libxc.BookEntry{{Rate: 105, Qty: 50}},
+		Asks:     []*libxc.BookEntry{{Rate: 110, Qty: 60}},
+	}, 1100)
+	write(&core.BookUpdate{Action: core.BookOrderAction, Payload: mini("00000003", false, 101, 30)}, 2000)
+	write(&core.BookUpdate{Action: core.UpdateRemainingAction, Payload: &core.RemainderUpdate{Token: "00000002", QtyAtomic: 5}}, 2100)
+	write(&libxc.BookUpdate{Bids: []*libxc.BookEntry{{Rate: 105, Qty: 0}, {Rate: 104, Qty: 7}}}, 2200)
+	write(&core.BookUpdate{
+		Action: core.EpochMatchSummary,
+		Payload: &core.EpochMatchSummaryPayload{
+			Epoch:          7,
+			MatchSummaries: []*orderbook.MatchSummary{{Rate: 120, Qty: 15, Sell: true}},
+		},
+	}, 3000)
+	write(&core.BookUpdate{Action: core.UnbookOrderAction, Payload: mini("00000001", false, 0, 0)}, 4000)
+	// Updates that aren't recorded.
+	write(&core.BookUpdate{Action: core.EpochOrderAction, Payload: mini("00000004", false, 99, 1)}, 4500)
+	write(&core.BookUpdate{
+		Action:  core.EpochMatchSummary,
+		Payload: &core.EpochMatchSummaryPayload{Epoch: 8},
+	}, 5000)
+	if err := w.close(); err != nil {
+		t.Fatalf("error closing writer: %v", err)
+	}
+
+	// Simulate a crash during a write by appending a partial record.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	f.Write([]byte{byte(mdRecDEXBookOrder), 50, 1, 2})
+	f.Close()
+
+	r, err := OpenMarketData(path)
+	if err != nil {
+		t.Fatalf("error opening reader: %v", err)
+	}
+	defer r.Close()
+
+	if !reflect.DeepEqual(r.Header(), hdr) {
+		t.Fatalf("wrong header %+v", r.Header())
+	}
+	if start, end := r.Range(); start != 1000 || end != 5000 {
+		t.Fatalf("wrong range %d - %d", start, end)
+	}
+
+	if _, err := r.BookAt(999); err == nil {
+		t.Fatalf("no error for book before start")
+	}
+
+	levels := func(pts ...uint64) []*BookLevel {
+		ls := make([]*BookLevel, 0, len(pts)/2)
+		for i := 0; i < len(pts); i += 2 {
+			ls = append(ls, &BookLevel{Rate: pts[i], Qty: pts[i+1]})
+		}
+		return ls
+	}
+	ords := func(pts ...any) []*MarketDataOrder {
+		mos := make([]*MarketDataOrder, 0, len(pts)/3)
+		for i := 0; i < len(pts); i += 3 {
+			mos = append(mos, &MarketDataOrder{Token: pts[i].(string), Rate: uint64(pts[i+1].(int)), Qty: uint64(pts[i+2].(int))})
+		}
+		return mos
+	}
+
+	tests := []struct {
+		stamp int64
+		exp   *MarketDataBook
+	}{
+		{
+			stamp: 1050,
+			exp: &MarketDataBook{
+				Stamp:    1000,
+				DEXBuys:  ords("00000001", 100, 10),
+				DEXSells: ords("00000002", 120, 20),
+				CEXBuys:  levels(),
+				CEXSells: levels(),
+			},
+		},
+		{
+			stamp: 2500,
+			exp: &MarketDataBook{
+				Stamp:    2200,
+				DEXBuys:  ords("00000003", 101, 30, "00000001", 100, 10),
+				DEXSells: ords("00000002", 120, 5),
+				CEXBuys:  levels(104, 7),
+				CEXSells: levels(110, 60),
+			},
+		},
+		{
+			stamp: 10000,
+			exp: &MarketDataBook{
+				Stamp:    5000,
+				Epoch:    8,
+				DEXBuys:  ords("00000003", 101, 30),
+				DEXSells: ords("00000002", 120, 5),
+				CEXBuys:  levels(104, 7),
+				CEXSells: levels(110, 60),
+			},
+		},
+	}
+	for _, test := range tests {
+		book, err := r.BookAt(test.stamp)
+		if err != nil {
+			t.Fatalf("%d: BookAt error: %v", test.stamp, err)
+		}
+		if !reflect.DeepEqual(book, test.exp) {
+			t.Fatalf("%d: wrong book.\nexpected %+v\ngot %+v", test.stamp, test.exp, book)
+		}
+	}
+
+	src := r.BacktestSource()
+	e, err := src.NextEpoch()
+	if err != nil {
+		t.Fatalf("error getting first epoch: %v", err)
+	}
+	expEpoch := &BacktestEpoch{
+		Epoch:    7,
+		Stamp:    3000,
+		DEXBuys:  levels(101, 30, 100, 10),
+		DEXSells: levels(120, 5),
+		Matches:  []*BacktestMatch{{Rate: 120, Qty: 15, TakerSell: false}},
+		CEXBuys:  levels(104, 7),
+		CEXSells: levels(110, 60),
+	}
+	if !reflect.DeepEqual(e, expEpoch) {
+		t.Fatalf("wrong first epoch.\nexpected %+v\ngot %+v", expEpoch, e)
+	}
+	if e, err = src.NextEpoch(); err != nil || e.Epoch != 8 || len(e.DEXBuys) != 1 {
+		t.Fatalf("wrong second epoch %+v, err = %v", e, err)
+	}
+	if _, err = src.NextEpoch(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+
+	// Reopening the file for writing truncates the partial record.
+	fi, _ := os.Stat(path)
+	w, err = openMktDataWriter(path, hdr)
+	if err != nil {
+		t.Fatalf("error reopening writer: %v", err)
+	}
+	write(&core.BookUpdate{Action: core.UnbookOrderAction, Payload: mini("00000003", false, 0, 0)}, 6000)
+	w.close()
+	fi2, _ := os.Stat(path)
+	// The 4 byte partial record is replaced by the 8 byte unbook record.
+	if fi2.Size() != fi.Size()-4+8 {
+		t.Fatalf("partial record not truncated. size before %d, size after %d", fi.Size(), fi2.Size())
+	}
+	r2, err := OpenMarketData(path)
+	if err != nil {
+		t.Fatalf("error opening reader: %v", err)
+	}
+	defer r2.Close()
+	if book, err := r2.BookAt(6000); err != nil || len(book.DEXBuys) != 0 {
+		t.Fatalf("wrong book after append: %+v, err = %v", book, err)
+	}
+
+	// A different market can't be appended to the file.
+	if _, err := openMktDataWriter(path, &MarketDataHeader{Host: "host2"}); err == nil {
+		t.Fatalf("no error for wrong market")
+	}
+}
+
+type tBookSubscriberCEX struct {
+	*tCEX
+	updates chan *libxc.BookUpdate
+	subbed  bool
+}
+
+func (c *tBookSubscriberCEX) SubscribeMarket(ctx context.Context, baseID, quoteID uint32) error {
+	c.subbed = true
+	return nil
+}
+
+func (c *tBookSubscriberCEX) UnsubscribeMarket(baseID, quoteID uint32) error {
+	c.subbed = false
+	return nil
+}
+
+func (c *tBookSubscriberCEX) SubscribeBookUpdates(baseID, quoteID uint32) (<-chan *libxc.BookUpdate, func(), error) {
+	return c.updates, func() {}, nil
+}
+
+func TestMarketRecorder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	book := orderbook.NewOrderBook(tLogger)
+	oid := order.OrderID{0xaa, 0xbb, 0xcc, 0xdd}
+	err := book.Sync(&msgjson.OrderBook{
+		MarketID: "dcr_btc",
+		Orders: []*msgjson.BookOrderNote{{
+			OrderNote: msgjson.OrderNote{OrderID: oid[:]},
+			TradeNote: msgjson.TradeNote{Side: msgjson.SellOrderNum, Quantity: 1e8, Rate: 2e6},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("error syncing book: %v", err)
+	}
+
+	tc := newTCore()
+	tc.book = book
+	cex := &tBookSubscriberCEX{tCEX: newTCEX(), updates: make(chan *libxc.BookUpdate)}
+
+	dir := t.TempDir()
+	r := &marketRecorder{
+		mkt: &RecorderMarket{
+			MarketWithHost: MarketWithHost{Host: "host1:7232", BaseID: 42, QuoteID: 0},
+			CEXName:        "Binance",
+		},
+		path: filepath.Join(dir, mktDataFileName(&MarketWithHost{Host: "host1:7232", BaseID: 42, QuoteID: 0})),
+		log:  tLogger,
+		core: tc,
+		cex:  cex,
+	}
+	if filepath.Base(r.path) != "host1_7232_42_0.mdr" {
+		t.Fatalf("wrong file name %s", filepath.Base(r.path))
+	}
+	if err := r.start(ctx); err != nil {
+		t.Fatalf("error starting recorder: %v", err)
+	}
+	if !cex.subbed {
+		t.Fatalf("not subscribed to cex market")
+	}
+
+	runCtx, stopRun := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		r.run(runCtx)
+		close(done)
+	}()
+
+	cex.updates <- &libxc.BookUpdate{Snapshot: true, Asks: []*libxc.BookEntry{{Rate: 3e6, Qty: 2e8}}}
+	tc.bookFeed.c <- &core.BookUpdate{
+		Action:  core.BookOrderAction,
+		Payload: &core.MiniOrder{Token: "01020304", MsgRate: 1e6, QtyAtomic: 1e8},
+	}
+	tc.bookFeed.c <- &core.BookUpdate{
+		Action:  core.EpochMatchSummary,
+		Payload: &core.EpochMatchSummaryPayload{Epoch: 1},
+	}
+	// Syncs with the recorder, since the feed has a buffer of one.
+	tc.bookFeed.c <- &core.BookUpdate{Action: core.CandleUpdateAction}
+
+	stopRun()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("recorder didn't stop")
+	}
+	if cex.subbed {
+		t.Fatalf("still subscribed to cex market")
+	}
+	if n := r.status().Records; n != 4 {
+		t.Fatalf("expected 4 records, got %d", n)
+	}
+
+	mr, err := OpenMarketData(r.path)
+	if err != nil {
+		t.Fatalf("error opening market data: %v", err)
+	}
+	defer mr.Close()
+	_, end := mr.Range()
+	mb, err := mr.BookAt(end)
+	if err != nil {
+		t.Fatalf("BookAt error: %v", err)
+	}
+	if mb.Epoch != 1 || len(mb.DEXBuys) != 1 || mb.DEXBuys[0].Token != "01020304" ||
+		len(mb.DEXSells) != 1 || mb.DEXSells[0].Token != "aabbccdd" || mb.DEXSells[0].Rate != 2e6 ||
+		len(mb.CEXSells) != 1 || mb.CEXSells[0].Qty != 2e8 {
+		t.Fatalf("wrong book %+v", mb)
+	}
+}
@@ -143,9 +143,13 @@ type MarketMaker struct {
 	core           clientCore
 	defaultCfgPath string
 	eventLogDBPath string
-	eventLogDB     eventLogDB
 	oracle         *priceOracle
 
+	// eventLogDB is opened in Connect. The mutex guards it for callers that
+	// may run before Connect, such as backups.
+	eventLogDBMtx sync.RWMutex
+	eventLogDB    eventLogDB
+
 	defaultCfgMtx sync.RWMutex
 	// defaultCfg is the configuration specified by the file at the path passed
 	// to NewMarketMaker as an argument. An alternateCfgPath can be passed to
@@ -161,6 +165,14 @@ type MarketMaker struct {
 
 	cexMtx sync.RWMutex
 	cexes  map[string]*centralizedExchange
+
+	recorderMtx sync.Mutex
+	recorder    *mktDataRecorder
+
+	// portfolioMtx prevents concurrent portfolio reallocations.
+	portfolioMtx     sync.Mutex
+	portfolioProfits map[MarketWithHost]*portfolioProfit
+	portfolioHistory []*PortfolioReallocation
 }
 
 // NewMarketMaker creates a new MarketMaker.
@@ -278,6 +290,9 @@ type EpochReport struct {
 	SellsReport *OrderReport `json:"sellsReport"`
 	// EpochNum is the number of the epoch.
 	EpochNum uint64 `json:"epochNum"`
+	// InventorySkew is the inventory skew applied by a basic market maker
+	// with inventory-aware quoting enabled.
+	InventorySkew *InventorySkewReport `json:"inventorySkew,omitempty"`
 }
 
 func (er *EpochReport) setPreOrderProblems(err error) {
@@ -483,19 +498,31 @@ func (m *MarketMaker) loginAndUnlockWallets(pw []byte, cfg *BotConfig) error {
 		return fmt.Errorf("failed to login: %w", err)
 	}
 
-	err = m.core.OpenWallet(cfg.BaseID, pw)
-	if err != nil {
-		return fmt.Errorf("failed to unlock wallet for asset %d: %w", cfg.BaseID, err)
-	}
-
-	err = m.core.OpenWallet(cfg.QuoteID, pw)
-	if err != nil {
-		return fmt.Errorf("failed to unlock wallet for asset %d: %w", cfg.QuoteID, err)
+	for _, assetID := range requiredWallets(cfg.BaseID, cfg.QuoteID) {
+		err = m.core.OpenWallet(assetID, pw)
+		if err != nil {
+			return fmt.Errorf("failed to unlock wallet for asset %d: %w", assetID, err)
+		}
 	}
 
 	return nil
 }
 
+// requiredWallets returns the assets whose wallets must be unlocked to run a
+// bot on a market. For tokens, this includes the parent chain's wallet, which
+// pays the fees.
+func requiredWallets(baseID, quoteID uint32) []uint32 {
+	assetIDs := make([]uint32, 0, 4)
+	found := make(map[uint32]bool, 4)
+	for _, assetID := range []uint32{baseID, quoteID, feeAssetID(baseID), feeAssetID(quoteID)} {
+		if !found[assetID] {
+			found[assetID] = true
+			assetIDs = append(assetIDs, assetID)
+		}
+	}
+	return assetIDs
+}
+
 func (m *MarketMaker) connectCEX(ctx context.Context, c *centralizedExchange) error {
 	var cm *dex.ConnectionMaster
 	c.mtx.Lock()
@@ -657,12 +684,20 @@ func (m *MarketMaker) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating event log DB: %v", err)
 	}
+	m.eventLogDBMtx.Lock()
 	m.eventLogDB = eventLogDB
+	m.eventLogDBMtx.Unlock()
 
 	m.oracle = newPriceOracle(m.ctx, m.log.SubLogger("oracle"))
 
 	var wg sync.WaitGroup
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.runSchedules(ctx)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -686,8 +721,8 @@ func (m *MarketMaker) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 	return &wg, nil
 }
 
-func (m *MarketMaker) balancesSufficient(balances *BotBalanceAllocation, mkt *MarketWithHost, cexCfg *CEXConfig) error {
-	availableDEXBalances, availableCEXBalances, err := m.availableBalances(mkt, cexCfg)
+func (m *MarketMaker) balancesSufficient(balances *BotBalanceAllocation, mkt *MarketWithHost, botCfg *BotConfig, cexCfg *CEXConfig) error {
+	availableDEXBalances, availableCEXBalances, err := m.availableBalances(mkt, botCfg, cexCfg)
 	if err != nil {
 		return fmt.Errorf("error getting available balances: %v", err)
 	}
@@ -753,6 +788,8 @@ func (m *MarketMaker) botSubLogger(cfg *BotConfig) dex.Logger {
 		return m.log.SubLogger(fmt.Sprintf("ARB-%s", mktID))
 	case cfg.ArbMarketMakerConfig != nil:
 		return m.log.SubLogger(fmt.Sprintf("AMM-%s", mktID))
+	case cfg.TriangularArbConfig != nil:
+		return m.log.SubLogger(fmt.Sprintf("TRI-%s", mktID))
 	}
 	// This will error in the caller.
 	return m.log.SubLogger(fmt.Sprintf("Bot-%s", mktID))
@@ -777,6 +814,8 @@ func (m *MarketMaker) newBot(cfg *BotConfig, adaptorCfg *exchangeAdaptorCfg) (bo
 		return newBasicMarketMaker(cfg, adaptorCfg, m.oracle, m.log.SubLogger(fmt.Sprintf("MM-%s", mktID)))
 	case cfg.SimpleArbConfig != nil:
 		return newSimpleArbMarketMaker(cfg, adaptorCfg, m.log.SubLogger(fmt.Sprintf("ARB-%s", mktID)))
+	case cfg.TriangularArbConfig != nil:
+		return newTriangularArbMarketMaker(cfg, adaptorCfg, m.log.SubLogger(fmt.Sprintf("TRI-%s", mktID)))
 	default:
 		return nil, fmt.Errorf("not bot config found")
 	}
@@ -791,40 +830,68 @@ type StartConfig struct {
 
 // StartBot starts a market making bot.
 func (m *MarketMaker) StartBot(startCfg *StartConfig, alternateConfigPath *string, appPW []byte, overrideLotSizeChange bool) (err error) {
-	mkt := startCfg.MarketWithHost
-
 	m.startUpdateMtx.Lock()
 	defer m.startUpdateMtx.Unlock()
 
+	botCfg, cexCfg, err := m.botStartConfigs(&startCfg.MarketWithHost, alternateConfigPath, overrideLotSizeChange)
+	if err != nil {
+		return err
+	}
+
+	if botCfg.RPCConfig != nil {
+		startCfg.Alloc = botCfg.RPCConfig.Alloc
+		startCfg.AutoRebalance = botCfg.RPCConfig.AutoRebalance
+	}
+
+	if err := m.checkStartAllocation(startCfg, botCfg, cexCfg); err != nil {
+		return err
+	}
+
+	if err := m.loginAndUnlockWallets(appPW, botCfg); err != nil {
+		return err
+	}
+
+	return m.startBot(startCfg, botCfg, cexCfg, alternateConfigPath)
+}
+
+// checkStartAllocation checks that a bot that is to be started has a balance
+// allocation, and that the allocation is covered by the available balances.
+func (m *MarketMaker) checkStartAllocation(startCfg *StartConfig, botCfg *BotConfig, cexCfg *CEXConfig) error {
+	mwh := &startCfg.MarketWithHost
+	if startCfg.Alloc == nil {
+		return fmt.Errorf("no balance allocation for %s", mwh)
+	}
+	return m.balancesSufficient(startCfg.Alloc, mwh, botCfg, cexCfg)
+}
+
+// botStartConfigs checks that no bot is running on the market, cancels any
+// of the user's booked orders on the market, and returns the configurations
+// for the bot that is to be started. startUpdateMtx MUST be held.
+func (m *MarketMaker) botStartConfigs(mkt *MarketWithHost, alternateConfigPath *string, overrideLotSizeChange bool) (*BotConfig, *CEXConfig, error) {
 	m.runningBotsMtx.RLock()
-	_, found := m.runningBots[startCfg.MarketWithHost]
+	_, found := m.runningBots[*mkt]
 	m.runningBotsMtx.RUnlock()
 	if found {
-		return fmt.Errorf("bot for %s already running", mkt)
+		return nil, nil, fmt.Errorf("bot for %s already running", mkt)
 	}
 
-	coreMkt, err := m.core.ExchangeMarket(startCfg.Host, startCfg.BaseID, startCfg.QuoteID)
+	coreMkt, err := m.core.ExchangeMarket(mkt.Host, mkt.BaseID, mkt.QuoteID)
 	if err != nil {
-		return fmt.Errorf("error getting market: %v", err)
+		return nil, nil, fmt.Errorf("error getting market: %v", err)
 	}
 
 	for _, ord := range coreMkt.Orders {
 		if ord.Status <= order.OrderStatusBooked {
 			err = m.core.Cancel(ord.ID)
 			if err != nil {
-				return fmt.Errorf("error canceling order %s: %v", ord.ID, err)
+				return nil, nil, fmt.Errorf("error canceling order %s: %v", ord.ID, err)
 			}
 		}
 	}
 
-	botCfg, cexCfg, err := m.configsForMarket(&startCfg.MarketWithHost, alternateConfigPath)
+	botCfg, cexCfg, err := m.configsForMarket(mkt, alternateConfigPath)
 	if err != nil {
-		return err
-	}
-
-	if botCfg.RPCConfig != nil {
-		startCfg.Alloc = botCfg.RPCConfig.Alloc
-		startCfg.AutoRebalance = botCfg.RPCConfig.AutoRebalance
+		return nil, nil, err
 	}
 
 	// Lot size may be zero if started from RPC. If the lot size in the config
@@ -832,38 +899,25 @@ func (m *MarketMaker) StartBot(startCfg *StartConfig, alternateConfigPath *strin
 	// was saved. If so, and overrideLotSizeChange is false, we return an error.
 	// If overrideLotSizeChange is true, we update the lot size in the config.
 	if botCfg.LotSize > 0 {
-		mktInfo, err := m.core.ExchangeMarket(mkt.Host, mkt.BaseID, mkt.QuoteID)
-		if err != nil {
-			return fmt.Errorf("error getting market info for %s: %w", mkt, err)
-		}
-
-		if botCfg.LotSize != mktInfo.LotSize {
+		if botCfg.LotSize != coreMkt.LotSize {
 			if overrideLotSizeChange {
-				botCfg.LotSize = mktInfo.LotSize
+				botCfg.LotSize = coreMkt.LotSize
 				m.updateDefaultBotConfig(botCfg)
 			} else {
-				return fmt.Errorf("lot size changed since configuration")
+				return nil, nil, fmt.Errorf("lot size for %s has changed: %d -> %d", mkt, botCfg.LotSize, coreMkt.LotSize)
 			}
 		}
-
-		if !overrideLotSizeChange && botCfg.LotSize != mktInfo.LotSize {
-			return fmt.Errorf("lot size for %s has changed: %d -> %d", mkt, botCfg.LotSize, mktInfo.LotSize)
-		}
-		botCfg.LotSize = mktInfo.LotSize
 	}
 
-	return m.startBot(startCfg, botCfg, cexCfg, appPW)
+	return botCfg, cexCfg, nil
 }
 
-func (m *MarketMaker) startBot(startCfg *StartConfig, botCfg *BotConfig, cexCfg *CEXConfig, appPW []byte) (err error) {
+// startBot starts a bot with the provided configuration. The allocation must
+// already be checked with checkStartAllocation, and the bot's wallets must
+// already be unlocked. alternateConfigPath is the path the configuration was
+// loaded from, or nil if it is the default configuration.
+func (m *MarketMaker) startBot(startCfg *StartConfig, botCfg *BotConfig, cexCfg *CEXConfig, alternateConfigPath *string) (err error) {
 	mwh := &startCfg.MarketWithHost
-	if err := m.balancesSufficient(startCfg.Alloc, mwh, cexCfg); err != nil {
-		return err
-	}
-
-	if err := m.loginAndUnlockWallets(appPW, botCfg); err != nil {
-		return err
-	}
 
 	var cex *centralizedExchange
 	if cexCfg != nil {
@@ -900,6 +954,13 @@ func (m *MarketMaker) startBot(startCfg *StartConfig, botCfg *BotConfig, cexCfg
 		botCfg:              botCfg,
 		eventLogDB:          m.eventLogDB,
 	}
+	// Lot size changes are only saved to the default configuration if the
+	// bot was started from it.
+	if alternateConfigPath == nil {
+		adaptorCfg.saveLotSize = func(oldLotSize, newLotSize uint64) {
+			m.updateDefaultBotLotSize(mwh, oldLotSize, newLotSize)
+		}
+	}
 
 	bot, err := m.newBot(botCfg, adaptorCfg)
 	if err != nil {
@@ -1006,6 +1067,32 @@ func (m *MarketMaker) updateDefaultBotConfig(updatedCfg *BotConfig) {
 	}
 }
 
+// updateDefaultBotLotSize updates the lot size of the market's bot in the
+// default configuration, scaling the placements of the stored configuration
+// rather than saving the configuration of the running bot.
+func (m *MarketMaker) updateDefaultBotLotSize(mwh *MarketWithHost, oldLotSize, newLotSize uint64) {
+	var botCfg *BotConfig
+	for _, c := range m.defaultConfig().BotConfigs {
+		if c.Host == mwh.Host && c.BaseID == mwh.BaseID && c.QuoteID == mwh.QuoteID {
+			botCfg = c.copy()
+			break
+		}
+	}
+	if botCfg == nil {
+		m.log.Warnf("No stored bot config for %s. Not saving lot size change.", mwh)
+		return
+	}
+	if botCfg.LotSize == newLotSize {
+		return
+	}
+	if botCfg.LotSize > 0 {
+		oldLotSize = botCfg.LotSize
+	}
+	botCfg.updateLotSize(oldLotSize, newLotSize)
+	botCfg.LotSize = newLotSize
+	m.updateDefaultBotConfig(botCfg)
+}
+
 // UpdateBotConfig updates the configuration for one of the bots.
 func (m *MarketMaker) UpdateBotConfig(updatedCfg *BotConfig) error {
 	m.runningBotsMtx.RLock()
@@ -1068,6 +1155,14 @@ func (m *MarketMaker) RemoveBotConfig(host string, baseID, quoteID uint32) error
 		return fmt.Errorf("config not found")
 	}
 
+	mkt := MarketWithHost{host, baseID, quoteID}
+	for i, sched := range cfg.Schedules {
+		if sched.MarketWithHost == mkt {
+			cfg.Schedules = append(cfg.Schedules[:i], cfg.Schedules[i+1:]...)
+			break
+		}
+	}
+
 	if err := m.writeConfigFile(cfg); err != nil {
 		m.log.Errorf("Error saving updated config file: %v", err)
 	}
@@ -1096,6 +1191,10 @@ func validRunningBotCfgUpdate(oldCfg, newCfg *BotConfig) error {
 		return fmt.Errorf("cannot change bot type for running bot")
 	}
 
+	if oldCfg.TriangularArbConfig == nil != (newCfg.TriangularArbConfig == nil) {
+		return fmt.Errorf("cannot change bot type for running bot")
+	}
+
 	return nil
 }
 
@@ -1111,7 +1210,7 @@ func (m *MarketMaker) UpdateRunningBotInventory(mkt *MarketWithHost, balanceDiff
 		return fmt.Errorf("no bot running on market: %s", mkt)
 	}
 
-	if err := m.balancesSufficient(balanceDiffsToAllocation(balanceDiffs), mkt, rb.cexCfg); err != nil {
+	if err := m.balancesSufficient(balanceDiffsToAllocation(balanceDiffs), mkt, rb.botCfg(), rb.cexCfg); err != nil {
 		return err
 	}
 
@@ -1150,7 +1249,7 @@ func (m *MarketMaker) UpdateRunningBotCfg(cfg *BotConfig, balanceDiffs *BotInven
 	}
 
 	if balanceDiffs != nil {
-		if err := m.balancesSufficient(balanceDiffsToAllocation(balanceDiffs), &mkt, rb.cexCfg); err != nil {
+		if err := m.balancesSufficient(balanceDiffsToAllocation(balanceDiffs), &mkt, cfg, rb.cexCfg); err != nil {
 			return err
 		}
 	}
@@ -1317,7 +1416,14 @@ func (m *MarketMaker) updateCEXOrderEvent(mkt *MarketWithHost, event *MarketMaki
 
 	orderEvent := event.CEXOrderEvent
 
-	trade, err := cex.TradeStatus(m.ctx, orderEvent.ID, mkt.BaseID, mkt.QuoteID)
+	// Events logged before the CEX market was recorded are for the bot's
+	// market.
+	baseID, quoteID := orderEvent.BaseID, orderEvent.QuoteID
+	if baseID == quoteID {
+		baseID, quoteID = mkt.BaseID, mkt.QuoteID
+	}
+
+	trade, err := cex.TradeStatus(m.ctx, orderEvent.ID, baseID, quoteID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching trade status: %v", err)
 	}
@@ -1474,6 +1580,8 @@ func (f *RunLogFilters) filter(event *MarketMakingEvent) bool {
 		return f.Deposits
 	case event.WithdrawalEvent != nil:
 		return f.Withdrawals
+	case event.RiskLimitEvent != nil:
+		return true
 	default:
 		return false
 	}
@@ -1604,7 +1712,7 @@ func marketFees(c clientCore, host string, baseID, quoteID uint32, useMaxFeeRate
 		}, nil
 }
 
-func (m *MarketMaker) availableBalances(mkt *MarketWithHost, cexCfg *CEXConfig) (dexBalances, cexBalances map[uint32]uint64, _ error) {
+func (m *MarketMaker) availableBalances(mkt *MarketWithHost, botCfg *BotConfig, cexCfg *CEXConfig) (dexBalances, cexBalances map[uint32]uint64, _ error) {
 	dexAssets := make(map[uint32]interface{})
 	cexAssets := make(map[uint32]interface{})
 
@@ -1616,6 +1724,12 @@ func (m *MarketMaker) availableBalances(mkt *MarketWithHost, cexCfg *CEXConfig)
 	if cexCfg != nil {
 		cexAssets[mkt.BaseID] = struct{}{}
 		cexAssets[mkt.QuoteID] = struct{}{}
+		// Some strategies trade other assets on the CEX.
+		if botCfg != nil {
+			for _, assetID := range botCfg.cexAssets() {
+				cexAssets[assetID] = struct{}{}
+			}
+		}
 	}
 
 	checkTotalBalances := func() (dexBals, cexBals map[uint32]uint64, err error) {
@@ -1743,12 +1857,12 @@ func (m *MarketMaker) availableBalances(mkt *MarketWithHost, cexCfg *CEXConfig)
 // market making on the specified market on the DEX (including fee assets),
 // and optionally a CEX depending on the configured strategy.
 func (m *MarketMaker) AvailableBalances(mkt *MarketWithHost, alternateConfigPath *string) (dexBalances, cexBalances map[uint32]uint64, _ error) {
-	_, cexCfg, err := m.configsForMarket(mkt, alternateConfigPath)
+	botCfg, cexCfg, err := m.configsForMarket(mkt, alternateConfigPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return m.availableBalances(mkt, cexCfg)
+	return m.availableBalances(mkt, botCfg, cexCfg)
 }
 
 func sellStr(sell bool) string {
@@ -72,6 +72,111 @@ type BasicMarketMakingConfig struct {
 	// before they are replaced (units: ratio of price). Default: 0.1%.
 	// 0 <= x <= 0.01.
 	DriftTolerance float64 `json:"driftTolerance"`
+
+	// InventorySkew enables inventory-aware quoting. If set, the placement
+	// rates and lots are skewed to steer the bot's inventory towards a
+	// target ratio of base to quote asset.
+	InventorySkew *InventorySkewConfig `json:"inventorySkew,omitempty"`
+
+	// Oracle configures how the oracle price is calculated from the price
+	// oracle's sources. If nil, the default volume weighted price is used.
+	Oracle *OracleConfig `json:"oracle,omitempty"`
+}
+
+// InventorySkewConfig configures inventory-aware quoting for the basic market
+// maker. The bot's inventory ratio is the value of its DEX base asset balance
+// as a fraction of the total value of its DEX base and quote asset balances.
+// The deviation of the inventory ratio from the target is normalized to the
+// range [-1, 1], where 1 means the inventory is entirely in the base asset.
+type InventorySkewConfig struct {
+	// TargetBaseRatio is the target inventory ratio. Default: 0.5.
+	// 0 < x < 1.
+	TargetBaseRatio float64 `json:"targetBaseRatio"`
+	// SpreadSkew is the ratio of the basis price by which the rates of all
+	// placements are shifted at maximum deviation. When the bot holds too
+	// much of the base asset, rates are shifted down to make sells more
+	// likely to fill and buys less likely, and vice versa.
+	// 0 <= x <= 0.1.
+	SpreadSkew float64 `json:"spreadSkew"`
+	// LotSkew is the fraction by which the lots of the placements on the side
+	// that would move the inventory further from the target are reduced at
+	// maximum deviation. 0 <= x <= 1.
+	LotSkew float64 `json:"lotSkew"`
+}
+
+// defaultTargetBaseRatio is the TargetBaseRatio used if none is configured.
+const defaultTargetBaseRatio = 0.5
+
+// targetBaseRatio is the configured TargetBaseRatio, or the default if not
+// set.
+func (c *InventorySkewConfig) targetBaseRatio() float64 {
+	if c.TargetBaseRatio == 0 {
+		return defaultTargetBaseRatio
+	}
+	return c.TargetBaseRatio
+}
+
+func (c *InventorySkewConfig) validate() error {
+	if r := c.targetBaseRatio(); r <= 0 || r >= 1 {
+		return fmt.Errorf("target base ratio %f out of bounds", r)
+	}
+	if c.SpreadSkew < 0 || c.SpreadSkew > 0.1 {
+		return fmt.Errorf("spread skew %f out of bounds", c.SpreadSkew)
+	}
+	if c.LotSkew < 0 || c.LotSkew > 1 {
+		return fmt.Errorf("lot skew %f out of bounds", c.LotSkew)
+	}
+	return nil
+}
+
+// InventorySkewReport is the inventory skew applied by a basic market maker
+// during an epoch.
+type InventorySkewReport struct {
+	BaseRatio       float64 `json:"baseRatio"`
+	TargetBaseRatio float64 `json:"targetBaseRatio"`
+	// Deviation is the normalized deviation of the BaseRatio from the
+	// TargetBaseRatio, -1 <= x <= 1.
+	Deviation float64 `json:"deviation"`
+	// BasisPrice is the unskewed basis price, and SkewedBasisPrice is the
+	// basis price from which the placement rates were calculated.
+	BasisPrice       uint64 `json:"basisPrice"`
+	SkewedBasisPrice uint64 `json:"skewedBasisPrice"`
+	// BuyLotsFactor and SellLotsFactor are the multipliers applied to the
+	// configured lots of each buy and sell placement.
+	BuyLotsFactor  float64 `json:"buyLotsFactor"`
+	SellLotsFactor float64 `json:"sellLotsFactor"`
+}
+
+// calcInventorySkew calculates the inventory skew for the bot's DEX balances.
+// baseBal and quoteBal are the total balances of the base and quote assets.
+func calcInventorySkew(cfg *InventorySkewConfig, basisPrice, rateStep, baseBal, quoteBal uint64) *InventorySkewReport {
+	target := cfg.targetBaseRatio()
+	r := &InventorySkewReport{
+		TargetBaseRatio:  target,
+		BasisPrice:       basisPrice,
+		SkewedBasisPrice: basisPrice,
+		BuyLotsFactor:    1,
+		SellLotsFactor:   1,
+	}
+
+	baseValue := calc.BaseToQuote(basisPrice, baseBal)
+	if baseValue+quoteBal == 0 {
+		return r
+	}
+	r.BaseRatio = float64(baseValue) / float64(baseValue+quoteBal)
+
+	if r.BaseRatio > target {
+		r.Deviation = (r.BaseRatio - target) / (1 - target)
+	} else {
+		r.Deviation = (r.BaseRatio - target) / target
+	}
+
+	skewed := math.Round(float64(basisPrice) * (1 - r.Deviation*cfg.SpreadSkew))
+	r.SkewedBasisPrice = steppedRate(uint64(skewed), rateStep)
+	r.BuyLotsFactor = 1 - cfg.LotSkew*max(r.Deviation, 0)
+	r.SellLotsFactor = 1 - cfg.LotSkew*max(-r.Deviation, 0)
+
+	return r
 }
 
 func needBreakEvenHalfSpread(strat GapStrategy) bool {
@@ -136,6 +241,18 @@ func (c *BasicMarketMakingConfig) validate() error {
 		}
 	}
 
+	if c.InventorySkew != nil {
+		if err := c.InventorySkew.validate(); err != nil {
+			return fmt.Errorf("invalid inventory skew config: %w", err)
+		}
+	}
+
+	if c.Oracle != nil {
+		if err := c.Oracle.validate(); err != nil {
+			return fmt.Errorf("invalid oracle config: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -151,6 +268,13 @@ func (c *BasicMarketMakingConfig) copy() *BasicMarketMakingConfig {
 
 	cfg.SellPlacements = utils.Map(c.SellPlacements, copyOrderPlacement)
 	cfg.BuyPlacements = utils.Map(c.BuyPlacements, copyOrderPlacement)
+	if c.InventorySkew != nil {
+		skew := *c.InventorySkew
+		cfg.InventorySkew = &skew
+	}
+	if c.Oracle != nil {
+		cfg.Oracle = c.Oracle.copy()
+	}
 
 	return &cfg
 }
@@ -199,8 +323,10 @@ type basicMMCalculatorImpl struct {
 	*market
 	oracle oracle
 	core   botCoreAdaptor
-	cfg    *BasicMarketMakingConfig
-	log    dex.Logger
+	// cex is only set if the oracle config uses the CEX mid-gap.
+	cex botCexAdaptor
+	cfg *BasicMarketMakingConfig
+	log dex.Logger
 }
 
 var errNoBasisPrice = errors.New("no oracle or fiat rate available")
@@ -217,7 +343,7 @@ var errOracleFiatMismatch = errors.New("oracle rate and fiat rate mismatch")
 // If there is no fiat rate available, the empty market rate in the
 // configuration is used.
 func (b *basicMMCalculatorImpl) basisPrice() (uint64, error) {
-	oracleRate := b.msgRate(b.oracle.getMarketPrice(b.baseID, b.quoteID))
+	oracleRate := b.msgRate(b.oraclePrice())
 	b.log.Tracef("oracle rate = %s", b.fmtRate(oracleRate))
 
 	rateFromFiat := b.core.ExchangeRateFromFiatSources()
@@ -250,6 +376,21 @@ func (b *basicMMCalculatorImpl) basisPrice() (uint64, error) {
 	return steppedRate(oracleRate, rateStep), nil
 }
 
+// oraclePrice returns the conventional oracle price. If the bot has an oracle
+// config, the oracle reports and the CEX mid-gap are combined using the
+// config.
+func (b *basicMMCalculatorImpl) oraclePrice() float64 {
+	if b.cfg.Oracle == nil {
+		return b.oracle.getMarketPrice(b.baseID, b.quoteID)
+	}
+	var cexMidGap float64
+	if b.cex != nil {
+		cexMidGap = calc.ConventionalRate(b.cex.MidGap(b.baseID, b.quoteID), b.bui, b.qui)
+	}
+	reports := b.oracle.getOracleReports(b.baseID, b.quoteID)
+	return aggregateOraclePrice(marketPair{b.baseID, b.quoteID}, reports, cexMidGap, b.cfg.Oracle, time.Now(), b.log)
+}
+
 // halfSpread calculates the distance from the mid-gap where if you sell a lot
 // at the basis price plus half-gap, then buy a lot at the basis price minus
 // half-gap, you will have one lot of the base asset plus the total fees in
@@ -372,15 +513,29 @@ func (m *basicMarketMaker) orderPrice(basisPrice, feeAdj uint64, sell bool, gapF
 	return basisPrice - adj
 }
 
-func (m *basicMarketMaker) ordersToPlace() (buyOrders, sellOrders []*TradePlacement, err error) {
+// inventorySkew calculates the inventory skew based on the bot's current DEX
+// balances. nil is returned if inventory skew is not configured.
+func (m *basicMarketMaker) inventorySkew(basisPrice uint64) *InventorySkewReport {
+	skewCfg := m.cfg().InventorySkew
+	if skewCfg == nil {
+		return nil
+	}
+	total := func(assetID uint32) uint64 {
+		bal := m.DEXBalance(assetID)
+		return bal.Available + bal.Locked + bal.Pending + bal.Reserved
+	}
+	return calcInventorySkew(skewCfg, basisPrice, m.rateStep.Load(), total(m.baseID), total(m.quoteID))
+}
+
+func (m *basicMarketMaker) ordersToPlace() (buyOrders, sellOrders []*TradePlacement, skew *InventorySkewReport, err error) {
 	basisPrice, err := m.calculator.basisPrice()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	feeGap, err := m.calculator.feeGapStats(basisPrice)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error calculating fee gap stats: %w", err)
+		return nil, nil, nil, fmt.Errorf("error calculating fee gap stats: %w", err)
 	}
 
 	m.registerFeeGap(feeGap)
@@ -394,10 +549,26 @@ func (m *basicMarketMaker) ordersToPlace() (buyOrders, sellOrders []*TradePlacem
 			m.name, m.fmtRate(basisPrice), m.fmtRate(feeAdj))
 	}
 
+	placementBasis := basisPrice
+	buyLotsFactor, sellLotsFactor := 1.0, 1.0
+	if skew = m.inventorySkew(basisPrice); skew != nil {
+		placementBasis = skew.SkewedBasisPrice
+		buyLotsFactor, sellLotsFactor = skew.BuyLotsFactor, skew.SellLotsFactor
+		if m.log.Level() == dex.LevelTrace {
+			m.log.Tracef("ordersToPlace %s, inventory ratio = %.4f, target = %.4f, skewed basis price = %s, "+
+				"buy lots factor = %.4f, sell lots factor = %.4f", m.name, skew.BaseRatio, skew.TargetBaseRatio,
+				m.fmtRate(skew.SkewedBasisPrice), skew.BuyLotsFactor, skew.SellLotsFactor)
+		}
+	}
+
 	orders := func(orderPlacements []*OrderPlacement, sell bool) []*TradePlacement {
+		lotsFactor := buyLotsFactor
+		if sell {
+			lotsFactor = sellLotsFactor
+		}
 		placements := make([]*TradePlacement, 0, len(orderPlacements))
 		for i, p := range orderPlacements {
-			rate := m.orderPrice(basisPrice, feeAdj, sell, p.GapFactor)
+			rate := m.orderPrice(placementBasis, feeAdj, sell, p.GapFactor)
 
 			if m.log.Level() == dex.LevelTrace {
 				m.log.Tracef("ordersToPlace.orders: %s placement # %d, gap factor = %f, rate = %s, %+v",
@@ -405,6 +576,9 @@ func (m *basicMarketMaker) ordersToPlace() (buyOrders, sellOrders []*TradePlacem
 			}
 
 			lots := p.Lots
+			if lotsFactor != 1 {
+				lots = uint64(math.Round(float64(lots) * lotsFactor))
+			}
 			if rate == 0 {
 				lots = 0
 			}
@@ -418,7 +592,7 @@ func (m *basicMarketMaker) ordersToPlace() (buyOrders, sellOrders []*TradePlacem
 
 	buyOrders = orders(m.cfg().BuyPlacements, false)
 	sellOrders = orders(m.cfg().SellPlacements, true)
-	return buyOrders, sellOrders, nil
+	return buyOrders, sellOrders, skew, nil
 }
 
 func (m *basicMarketMaker) rebalance(newEpoch uint64) {
@@ -435,7 +609,7 @@ func (m *basicMarketMaker) rebalance(newEpoch uint64) {
 	}
 
 	var buysReport, sellsReport *OrderReport
-	buyOrders, sellOrders, determinePlacementsErr := m.ordersToPlace()
+	buyOrders, sellOrders, skew, determinePlacementsErr := m.ordersToPlace()
 	if determinePlacementsErr != nil {
 		m.tryCancelOrders(m.ctx, &newEpoch, false)
 	} else {
@@ -444,9 +618,10 @@ func (m *basicMarketMaker) rebalance(newEpoch uint64) {
 	}
 
 	epochReport := &EpochReport{
-		BuysReport:  buysReport,
-		SellsReport: sellsReport,
-		EpochNum:    newEpoch,
+		BuysReport:    buysReport,
+		SellsReport:   sellsReport,
+		EpochNum:      newEpoch,
+		InventorySkew: skew,
 	}
 	epochReport.setPreOrderProblems(determinePlacementsErr)
 	m.updateEpochReport(epochReport)
@@ -458,13 +633,21 @@ func (m *basicMarketMaker) botLoop(ctx context.Context) (*sync.WaitGroup, error)
 		return nil, fmt.Errorf("failed to sync book: %v", err)
 	}
 
-	m.calculator = &basicMMCalculatorImpl{
+	calculator := &basicMMCalculatorImpl{
 		market: m.market,
 		oracle: m.oracle,
 		core:   m.core,
 		cfg:    m.cfg(),
 		log:    m.log,
 	}
+	if oracleCfg := m.cfg().Oracle; oracleCfg != nil && oracleCfg.CEXWeight > 0 && m.CEX != nil {
+		if err := m.SubscribeMarket(ctx, m.baseID, m.quoteID); err != nil {
+			bookFeed.Close()
+			return nil, fmt.Errorf("failed to subscribe to cex market: %v", err)
+		}
+		calculator.cex = m.unifiedExchangeAdaptor
+	}
+	m.calculator = calculator
 
 	// Process book updates
 	var wg sync.WaitGroup
@@ -472,6 +655,13 @@ func (m *basicMarketMaker) botLoop(ctx context.Context) (*sync.WaitGroup, error)
 	go func() {
 		defer wg.Done()
 		defer bookFeed.Close()
+		if calculator.cex != nil {
+			defer func() {
+				if err := m.CEX.UnsubscribeMarket(m.baseID, m.quoteID); err != nil {
+					m.log.Errorf("Error unsubscribing from cex market: %v", err)
+				}
+			}()
+		}
 		for {
 			select {
 			case ni, ok := <-bookFeed.Next():
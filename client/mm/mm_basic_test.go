@@ -479,3 +479,183 @@ func TestBasicMMRebalance(t *testing.T) {
 		})
 	}
 }
+
+func TestCalcInventorySkew(t *testing.T) {
+	const basisPrice uint64 = 5e6
+	const rateStep uint64 = 1e3
+	// baseBal is worth 5e6 quote atoms at the basis price.
+	const baseBal uint64 = 1e8
+
+	tests := []struct {
+		name        string
+		cfg         *InventorySkewConfig
+		baseBal     uint64
+		quoteBal    uint64
+		expRatio    float64
+		expDev      float64
+		expBasis    uint64
+		expBuyLots  float64
+		expSellLots float64
+	}{
+		{
+			name:        "balanced",
+			cfg:         &InventorySkewConfig{TargetBaseRatio: 0.5, SpreadSkew: 0.02, LotSkew: 1},
+			baseBal:     baseBal,
+			quoteBal:    5e6,
+			expRatio:    0.5,
+			expDev:      0,
+			expBasis:    basisPrice,
+			expBuyLots:  1,
+			expSellLots: 1,
+		},
+		{
+			name:        "all base",
+			cfg:         &InventorySkewConfig{TargetBaseRatio: 0.5, SpreadSkew: 0.02, LotSkew: 1},
+			baseBal:     baseBal,
+			expRatio:    1,
+			expDev:      1,
+			expBasis:    steppedRate(basisPrice*98/100, rateStep),
+			expBuyLots:  0,
+			expSellLots: 1,
+		},
+		{
+			name:        "all quote",
+			cfg:         &InventorySkewConfig{TargetBaseRatio: 0.5, SpreadSkew: 0.02, LotSkew: 0.5},
+			quoteBal:    5e6,
+			expRatio:    0,
+			expDev:      -1,
+			expBasis:    steppedRate(basisPrice*102/100, rateStep),
+			expBuyLots:  1,
+			expSellLots: 0.5,
+		},
+		{
+			name:        "three quarters base",
+			cfg:         &InventorySkewConfig{TargetBaseRatio: 0.5, SpreadSkew: 0.02, LotSkew: 1},
+			baseBal:     3 * baseBal,
+			quoteBal:    5e6,
+			expRatio:    0.75,
+			expDev:      0.5,
+			expBasis:    steppedRate(basisPrice*99/100, rateStep),
+			expBuyLots:  0.5,
+			expSellLots: 1,
+		},
+		{
+			name:        "below non-default target",
+			cfg:         &InventorySkewConfig{TargetBaseRatio: 0.25, SpreadSkew: 0.02, LotSkew: 1},
+			baseBal:     baseBal,
+			quoteBal:    35e6,
+			expRatio:    0.125,
+			expDev:      -0.5,
+			expBasis:    steppedRate(basisPrice*101/100, rateStep),
+			expBuyLots:  1,
+			expSellLots: 0.5,
+		},
+		{
+			name:        "default target",
+			cfg:         &InventorySkewConfig{SpreadSkew: 0.02, LotSkew: 1},
+			baseBal:     3 * baseBal,
+			quoteBal:    5e6,
+			expRatio:    0.75,
+			expDev:      0.5,
+			expBasis:    steppedRate(basisPrice*99/100, rateStep),
+			expBuyLots:  0.5,
+			expSellLots: 1,
+		},
+		{
+			name:        "no balance",
+			cfg:         &InventorySkewConfig{TargetBaseRatio: 0.5, SpreadSkew: 0.02, LotSkew: 1},
+			expBasis:    basisPrice,
+			expBuyLots:  1,
+			expSellLots: 1,
+		},
+	}
+
+	const tolerance = 1e-9
+	for _, tt := range tests {
+		r := calcInventorySkew(tt.cfg, basisPrice, rateStep, tt.baseBal, tt.quoteBal)
+		if math.Abs(r.BaseRatio-tt.expRatio) > tolerance {
+			t.Fatalf("%s: wrong base ratio. expected %f, got %f", tt.name, tt.expRatio, r.BaseRatio)
+		}
+		if math.Abs(r.Deviation-tt.expDev) > tolerance {
+			t.Fatalf("%s: wrong deviation. expected %f, got %f", tt.name, tt.expDev, r.Deviation)
+		}
+		if r.SkewedBasisPrice != tt.expBasis {
+			t.Fatalf("%s: wrong skewed basis price. expected %d, got %d", tt.name, tt.expBasis, r.SkewedBasisPrice)
+		}
+		if math.Abs(r.BuyLotsFactor-tt.expBuyLots) > tolerance || math.Abs(r.SellLotsFactor-tt.expSellLots) > tolerance {
+			t.Fatalf("%s: wrong lots factors. expected buy %f, sell %f, got buy %f, sell %f",
+				tt.name, tt.expBuyLots, tt.expSellLots, r.BuyLotsFactor, r.SellLotsFactor)
+		}
+	}
+}
+
+func TestBasicMMInventorySkew(t *testing.T) {
+	const basisPrice uint64 = 5e6
+	const rateStep uint64 = 1e3
+	const lotSize = 5e9
+	const baseID, quoteID = 42, 0
+
+	mm := &basicMarketMaker{
+		unifiedExchangeAdaptor: mustParseAdaptorFromMarket(&core.Market{
+			RateStep:   rateStep,
+			AtomToConv: 1,
+			LotSize:    lotSize,
+			BaseID:     baseID,
+			QuoteID:    quoteID,
+		}),
+		calculator: &tBasicMMCalculator{bp: basisPrice},
+	}
+	tcore := newTCore()
+	tcore.setWalletsAndExchange(&core.Market{
+		BaseID:  baseID,
+		QuoteID: quoteID,
+	})
+	mm.clientCore = tcore
+	mm.fiatRates.Store(map[uint32]float64{baseID: 1, quoteID: 1})
+	mm.buyFees = &OrderFees{LotFeeRange: &LotFeeRange{Max: &LotFees{}, Estimated: &LotFees{}}}
+	mm.sellFees = &OrderFees{LotFeeRange: &LotFeeRange{Max: &LotFees{}, Estimated: &LotFees{}}}
+	// 75% of the inventory value is in the base asset.
+	mm.baseDexBalances[baseID] = lotSize * 30
+	mm.baseDexBalances[quoteID] = int64(calc.BaseToQuote(basisPrice, lotSize*10))
+	mm.botCfgV.Store(&BotConfig{
+		BasicMMConfig: &BasicMarketMakingConfig{
+			GapStrategy:    GapStrategyPercent,
+			BuyPlacements:  []*OrderPlacement{{Lots: 4, GapFactor: 0.01}},
+			SellPlacements: []*OrderPlacement{{Lots: 4, GapFactor: 0.01}},
+			InventorySkew: &InventorySkewConfig{
+				TargetBaseRatio: 0.5,
+				SpreadSkew:      0.02,
+				LotSkew:         0.5,
+			},
+		}})
+
+	mm.rebalance(100)
+
+	if len(tcore.multiTradesPlaced) != 2 {
+		t.Fatal("expected both buy and sell orders placed")
+	}
+	buys, sells := tcore.multiTradesPlaced[0], tcore.multiTradesPlaced[1]
+	if len(buys.Placements) != 1 || len(sells.Placements) != 1 {
+		t.Fatalf("expected one buy and one sell placement")
+	}
+
+	// Deviation is 0.5, so the basis is shifted down by 1%, and buy lots are
+	// reduced by 25%.
+	skewedBasis := steppedRate(basisPrice*99/100, rateStep)
+	gap := steppedRate(uint64(math.Round(float64(skewedBasis)*0.01)), rateStep)
+	if buys.Placements[0].Rate != skewedBasis-gap || buys.Placements[0].Qty != 3*lotSize {
+		t.Fatalf("wrong buy placement rate = %d, qty = %d", buys.Placements[0].Rate, buys.Placements[0].Qty)
+	}
+	if sells.Placements[0].Rate != skewedBasis+gap || sells.Placements[0].Qty != 4*lotSize {
+		t.Fatalf("wrong sell placement rate = %d, qty = %d", sells.Placements[0].Rate, sells.Placements[0].Qty)
+	}
+
+	report, _ := mm.epochReport.Load().(*EpochReport)
+	if report == nil || report.InventorySkew == nil {
+		t.Fatalf("no inventory skew in epoch report")
+	}
+	if report.InventorySkew.BasisPrice != basisPrice || report.InventorySkew.SkewedBasisPrice != skewedBasis ||
+		report.InventorySkew.Deviation != 0.5 {
+		t.Fatalf("wrong inventory skew report %+v", report.InventorySkew)
+	}
+}
@@ -44,15 +44,21 @@ func (c *SimpleArbConfig) copy() *SimpleArbConfig {
 }
 
 func (c *SimpleArbConfig) validate() error {
-	if c.ProfitTrigger <= 0 || c.ProfitTrigger > 1 {
-		return fmt.Errorf("profit trigger must be 0 < t <= 1, but got %v", c.ProfitTrigger)
+	return validateArbSettings(c.ProfitTrigger, c.MaxActiveArbs, c.NumEpochsLeaveOpen)
+}
+
+// validateArbSettings validates the settings that are common to the
+// arbitrage bot configurations.
+func validateArbSettings(profitTrigger float64, maxActiveArbs, numEpochsLeaveOpen uint32) error {
+	if profitTrigger <= 0 || profitTrigger > 1 {
+		return fmt.Errorf("profit trigger must be 0 < t <= 1, but got %v", profitTrigger)
 	}
 
-	if c.MaxActiveArbs == 0 {
+	if maxActiveArbs == 0 {
 		return fmt.Errorf("must allow at least 1 active arb")
 	}
 
-	if c.NumEpochsLeaveOpen < 2 {
+	if numEpochsLeaveOpen < 2 {
 		return fmt.Errorf("arbs must be left open for at least 2 epochs")
 	}
 
@@ -277,15 +283,26 @@ func (a *simpleArbMarketMaker) sortedOrders() (buys, sells []*core.Order) {
 // already placed on the dex.
 func (a *simpleArbMarketMaker) selfMatch(sell bool, rate uint64) bool {
 	buys, sells := a.sortedOrders()
+	return arbSelfMatch(buys, sells, sell, rate)
+}
 
-	if sell && len(buys) > 0 && buys[0].Rate >= rate {
-		return true
+// arbSelfMatch checks if an order could match with any of an arbitrage bot's
+// buy or sell orders on the dex.
+func arbSelfMatch(buys, sells []*core.Order, sell bool, rate uint64) bool {
+	if sell {
+		for _, o := range buys {
+			if o.Rate >= rate {
+				return true
+			}
+		}
+		return false
 	}
 
-	if !sell && len(sells) > 0 && sells[0].Rate <= rate {
-		return true
+	for _, o := range sells {
+		if o.Rate <= rate {
+			return true
+		}
 	}
-
 	return false
 }
 
@@ -35,6 +35,7 @@ type tBookFeed struct {
 func (t *tBookFeed) Next() <-chan *core.BookUpdate { return t.c }
 func (t *tBookFeed) Close()                        {}
 func (t *tBookFeed) Candles(dur string) error      { return nil }
+func (t *tBookFeed) Trades() error                 { return nil }
 
 var _ core.BookFeed = (*tBookFeed)(nil)
 
@@ -393,12 +394,17 @@ func (o *tOrderBook) MidGap() (uint64, error) {
 
 type tOracle struct {
 	marketPrice float64
+	reports     []*OracleReport
 }
 
 func (o *tOracle) getMarketPrice(base, quote uint32) float64 {
 	return o.marketPrice
 }
 
+func (o *tOracle) getOracleReports(base, quote uint32) []*OracleReport {
+	return o.reports
+}
+
 type vwapResult struct {
 	avg     uint64
 	extrema uint64
@@ -812,3 +818,47 @@ func TestAvailableBalances(t *testing.T) {
 	checkAvailableBalances(btcUsdc, map[uint32]uint64{0: 3e5, 60: 7e5, 60001: 4e5}, map[uint32]uint64{0: 5e5, 60001: 4e5})
 	checkAvailableBalances(dcrUsdc, map[uint32]uint64{42: 9e5, 60: 7e5, 60001: 4e5}, map[uint32]uint64{42: 7e5, 60001: 6e5})
 }
+
+func TestUpdateDefaultBotLotSize(t *testing.T) {
+	mkt := &MarketWithHost{Host: "dex.com", BaseID: 42, QuoteID: 0}
+	storedCfg := &BotConfig{
+		Host:    mkt.Host,
+		BaseID:  mkt.BaseID,
+		QuoteID: mkt.QuoteID,
+		LotSize: 1e6,
+		BasicMMConfig: &BasicMarketMakingConfig{
+			BuyPlacements:  []*OrderPlacement{{Lots: 4, GapFactor: 1}},
+			SellPlacements: []*OrderPlacement{{Lots: 2, GapFactor: 2}},
+		},
+	}
+	m := &MarketMaker{
+		log:            tLogger,
+		defaultCfgPath: t.TempDir() + "/mm.json",
+		defaultCfg:     &MarketMakingConfig{BotConfigs: []*BotConfig{storedCfg}},
+	}
+
+	m.updateDefaultBotLotSize(mkt, 1e6, 2e6)
+
+	cfg, err := getMarketMakingConfig(m.defaultCfgPath)
+	if err != nil {
+		t.Fatalf("error reading config file: %v", err)
+	}
+	if len(cfg.BotConfigs) != 1 {
+		t.Fatalf("expected 1 bot config, got %d", len(cfg.BotConfigs))
+	}
+	botCfg := cfg.BotConfigs[0]
+	if botCfg.LotSize != 2e6 {
+		t.Fatalf("expected lot size 2e6, got %d", botCfg.LotSize)
+	}
+	buys, sells := botCfg.BasicMMConfig.BuyPlacements, botCfg.BasicMMConfig.SellPlacements
+	if len(buys) != 1 || buys[0].Lots != 2 || buys[0].GapFactor != 1 {
+		t.Fatalf("unexpected buy placements %+v", buys)
+	}
+	if len(sells) != 1 || sells[0].Lots != 1 || sells[0].GapFactor != 2 {
+		t.Fatalf("unexpected sell placements %+v", sells)
+	}
+	// The stored config in memory before the update is not modified.
+	if storedCfg.LotSize != 1e6 || storedCfg.BasicMMConfig.BuyPlacements[0].Lots != 4 {
+		t.Fatalf("original config modified")
+	}
+}
@@ -0,0 +1,706 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/calc"
+	"decred.org/dcrdex/dex/order"
+)
+
+// TriangularArbConfig is the configuration for an arbitrage bot that
+// arbitrages a DEX market against a route of CEX markets that pass through a
+// bridge asset. For example, the DCR/BTC DEX market can be arbitraged against
+// the DCR/USDT and BTC/USDT markets on the CEX, with USDT as the bridge asset.
+// The CEX must have markets for both the DEX market's base and quote assets
+// against the bridge asset, with the bridge asset as the quote asset. Profits
+// are realized in the bridge asset on the CEX, so the bot must be allocated
+// some of the bridge asset on the CEX.
+type TriangularArbConfig struct {
+	// BridgeAssetID is the asset through which the DEX market's base and
+	// quote assets are traded on the CEX.
+	BridgeAssetID uint32 `json:"bridgeAssetID"`
+	// ProfitTrigger is the minimum profit, as a fraction of the value of the
+	// bridge asset that would be spent on the CEX, before a trade sequence is
+	// initiated. Range: 0 < ProfitTrigger << 1.
+	ProfitTrigger float64 `json:"profitTrigger"`
+	// MaxActiveArbs sets a limit on the number of active arbitrage sequences
+	// that can be open simultaneously.
+	MaxActiveArbs uint32 `json:"maxActiveArbs"`
+	// NumEpochsLeaveOpen is the number of epochs an arbitrage sequence will
+	// stay open if any of its orders were not filled.
+	NumEpochsLeaveOpen uint32 `json:"numEpochsLeaveOpen"`
+}
+
+func (c *TriangularArbConfig) copy() *TriangularArbConfig {
+	cfg := *c
+	return &cfg
+}
+
+func (c *TriangularArbConfig) validate(baseID, quoteID uint32) error {
+	if c.BridgeAssetID == baseID || c.BridgeAssetID == quoteID {
+		return fmt.Errorf("bridge asset %s must not be one of the market assets", dex.BipIDSymbol(c.BridgeAssetID))
+	}
+
+	return validateArbSettings(c.ProfitTrigger, c.MaxActiveArbs, c.NumEpochsLeaveOpen)
+}
+
+// triArbLeg is a trade on one of the CEX markets of a triangular route.
+type triArbLeg struct {
+	baseID  uint32
+	quoteID uint32
+	sell    bool
+	rate    uint64
+	qty     uint64
+	// lotSize is the quantity step of the CEX market.
+	lotSize uint64
+
+	tradeID string
+	// complete is set when the trade is filled or canceled.
+	complete bool
+	// filled is the quantity of the CEX market's base asset that was traded.
+	filled uint64
+}
+
+// roundDownToStep rounds qty down to a multiple of step. A zero step leaves
+// qty unchanged.
+func roundDownToStep(qty, step uint64) uint64 {
+	if step == 0 {
+		return qty
+	}
+	return qty - qty%step
+}
+
+// triArbRoute is a triangular arbitrage opportunity. The DEX order is
+// offset by a trade of the DEX market's base asset against the bridge asset
+// and a trade of the DEX market's quote asset against the bridge asset.
+type triArbRoute struct {
+	sellOnDEX bool
+	lots      uint64
+	dexRate   uint64
+	baseLeg   *triArbLeg
+	quoteLeg  *triArbLeg
+	// profit is the expected profit in the bridge asset.
+	profit uint64
+}
+
+// triArbSequence represents an attempted triangular arbitrage sequence.
+type triArbSequence struct {
+	// dexOrder is nil if the sequence was abandoned before the DEX order
+	// was placed.
+	dexOrder *core.Order
+	dexQty   uint64
+	// dexOrderDone is set once the DEX order is no longer active, and
+	// dexFilled is the quantity of the DEX order that was filled.
+	dexOrderDone bool
+	dexFilled    uint64
+	sellOnDEX    bool
+	startEpoch   uint64
+	legs         []*triArbLeg
+	// canceled is set once the orders of the sequence have been canceled.
+	canceled bool
+}
+
+func (s *triArbSequence) complete() bool {
+	if !s.dexOrderDone {
+		return false
+	}
+	for _, leg := range s.legs {
+		if !leg.complete {
+			return false
+		}
+	}
+	return true
+}
+
+// excessLegQty is the quantity of a completed leg that was not offset by the
+// DEX order, rounded down to the leg's lot size.
+func (s *triArbSequence) excessLegQty(leg *triArbLeg) uint64 {
+	if s.dexFilled >= s.dexQty {
+		return 0
+	}
+	target := uint64(math.Round(float64(leg.qty) * float64(s.dexFilled) / float64(s.dexQty)))
+	if leg.filled <= target {
+		return 0
+	}
+	return roundDownToStep(leg.filled-target, leg.lotSize)
+}
+
+type triangularArbMarketMaker struct {
+	*unifiedExchangeAdaptor
+	cex              botCexAdaptor
+	core             botCoreAdaptor
+	book             dexOrderBook
+	rebalanceRunning atomic.Bool
+	// baseLegLotSize and quoteLegLotSize are the lot sizes of the CEX
+	// markets of the DEX market's base and quote assets against the bridge
+	// asset. They are set when the bot is started.
+	baseLegLotSize  uint64
+	quoteLegLotSize uint64
+
+	activeArbsMtx sync.RWMutex
+	activeArbs    []*triArbSequence
+}
+
+var _ bot = (*triangularArbMarketMaker)(nil)
+
+func (a *triangularArbMarketMaker) cfg() *TriangularArbConfig {
+	return a.botCfg().TriangularArbConfig
+}
+
+// impliedRate is the base/quote message rate implied by the rates of the
+// base/bridge and quote/bridge markets.
+func impliedRate(baseBridgeRate, quoteBridgeRate uint64) uint64 {
+	if quoteBridgeRate == 0 {
+		return 0
+	}
+	return uint64(math.Round(float64(baseBridgeRate) / float64(quoteBridgeRate) * calc.RateEncodingFactor))
+}
+
+// arbExists checks if a triangular arbitrage opportunity exists.
+func (a *triangularArbMarketMaker) arbExists() (*triArbRoute, error) {
+	route, err := a.arbExistsOnSide(false)
+	if err != nil || route != nil {
+		return route, err
+	}
+	return a.arbExistsOnSide(true)
+}
+
+// arbExistsOnSide checks if a triangular arbitrage opportunity exists either
+// when buying or selling on the dex. When selling on the DEX, the quote asset
+// received is sold on the CEX for the bridge asset, and the bridge asset is
+// used to buy back the base asset. When buying on the DEX, the reverse route
+// is taken. The rate of each CEX leg is estimated using the VWAP of the CEX
+// order book.
+func (a *triangularArbMarketMaker) arbExistsOnSide(sellOnDEX bool) (*triArbRoute, error) {
+	lotSize := a.lotSize.Load()
+	bridgeID := a.cfg().BridgeAssetID
+	var best *triArbRoute
+
+	for numLots := uint64(1); ; numLots++ {
+		dexAvg, dexExtrema, dexFilled, err := a.book.VWAP(numLots, lotSize, !sellOnDEX)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating dex VWAP: %w", err)
+		}
+		if !dexFilled {
+			break
+		}
+
+		// The CEX legs are rounded down to the lot sizes of the CEX
+		// markets.
+		baseQty := roundDownToStep(numLots*lotSize, a.baseLegLotSize)
+		quoteQty := roundDownToStep(calc.BaseToQuote(dexAvg, numLots*lotSize), a.quoteLegLotSize)
+		if baseQty == 0 || quoteQty == 0 {
+			continue
+		}
+
+		// The base asset is bought on the CEX if sold on the DEX, and the
+		// quote asset is sold on the CEX if received on the DEX. The sell
+		// argument to VWAP is the side of the book, so the base leg takes
+		// the asks when selling on the DEX.
+		baseAvg, baseExtrema, baseFilled, err := a.CEX.VWAP(a.baseID, bridgeID, sellOnDEX, baseQty)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating %s-%s VWAP: %w", a.baseTicker, dex.BipIDSymbol(bridgeID), err)
+		}
+		quoteAvg, quoteExtrema, quoteFilled, err := a.CEX.VWAP(a.quoteID, bridgeID, !sellOnDEX, quoteQty)
+		if err != nil {
+			return nil, fmt.Errorf("error calculating %s-%s VWAP: %w", a.quoteTicker, dex.BipIDSymbol(bridgeID), err)
+		}
+		if !baseFilled || !quoteFilled {
+			break
+		}
+
+		dexSufficient, err := a.core.SufficientBalanceForDEXTrade(dexExtrema, numLots*lotSize, sellOnDEX)
+		if err != nil {
+			return nil, fmt.Errorf("error checking dex balance: %w", err)
+		}
+		baseLegSufficient := a.cex.SufficientBalanceForCEXTrade(a.baseID, bridgeID, !sellOnDEX, baseExtrema, baseQty)
+		quoteLegSufficient := a.cex.SufficientBalanceForCEXTrade(a.quoteID, bridgeID, sellOnDEX, quoteExtrema, quoteQty)
+		if !dexSufficient || !baseLegSufficient || !quoteLegSufficient {
+			break
+		}
+
+		baseBridge := calc.BaseToQuote(baseAvg, baseQty)
+		quoteBridge := calc.BaseToQuote(quoteAvg, quoteQty)
+		var bridgeIn, bridgeOut uint64
+		if sellOnDEX {
+			bridgeIn, bridgeOut = quoteBridge, baseBridge
+		} else {
+			bridgeIn, bridgeOut = baseBridge, quoteBridge
+		}
+
+		feesInQuoteUnits, err := a.core.OrderFeesInUnits(sellOnDEX, false, dexAvg)
+		if err != nil {
+			return nil, fmt.Errorf("error getting fees: %w", err)
+		}
+		feesInBridge := calc.BaseToQuote(quoteAvg, feesInQuoteUnits)
+
+		if bridgeIn <= bridgeOut+feesInBridge {
+			break
+		}
+		profit := bridgeIn - bridgeOut - feesInBridge
+		if (best != nil && profit < best.profit) || float64(profit)/float64(bridgeOut) < a.cfg().ProfitTrigger {
+			break
+		}
+
+		best = &triArbRoute{
+			sellOnDEX: sellOnDEX,
+			lots:      numLots,
+			dexRate:   dexExtrema,
+			baseLeg: &triArbLeg{
+				baseID:  a.baseID,
+				quoteID: bridgeID,
+				sell:    !sellOnDEX,
+				rate:    baseExtrema,
+				qty:     baseQty,
+				lotSize: a.baseLegLotSize,
+			},
+			quoteLeg: &triArbLeg{
+				baseID:  a.quoteID,
+				quoteID: bridgeID,
+				sell:    sellOnDEX,
+				rate:    quoteExtrema,
+				qty:     quoteQty,
+				lotSize: a.quoteLegLotSize,
+			},
+			profit: profit,
+		}
+	}
+
+	if best != nil {
+		profit := fmt.Sprintf("%d atoms", best.profit)
+		if ui, err := asset.UnitInfo(bridgeID); err == nil {
+			profit = ui.FormatAtoms(best.profit)
+		}
+		a.log.Infof("triangular arb opportunity - sellOnDex: %t, lots: %d, dexRate: %s, implied cex rate: %s, profit: %s %s",
+			sellOnDEX, best.lots, a.fmtRate(best.dexRate), a.fmtRate(impliedRate(best.baseLeg.rate, best.quoteLeg.rate)),
+			profit, dex.BipIDSymbol(bridgeID))
+	}
+
+	return best, nil
+}
+
+// cancelLegs cancels CEX trades that have been placed.
+func (a *triangularArbMarketMaker) cancelLegs(legs []*triArbLeg) {
+	for _, leg := range legs {
+		if leg.tradeID == "" || leg.complete {
+			continue
+		}
+		if err := a.cex.CancelTrade(a.ctx, leg.baseID, leg.quoteID, leg.tradeID); err != nil {
+			a.log.Errorf("error canceling cex trade ID %s: %v", leg.tradeID, err)
+		}
+	}
+}
+
+// executeArb will execute a triangular arbitrage sequence by placing the
+// orders for each CEX leg and then the DEX order. Each CEX leg is recorded in
+// the event log by the exchange adaptor. An entry will be added to the
+// a.activeArbs slice if all orders are successfully placed.
+func (a *triangularArbMarketMaker) executeArb(route *triArbRoute, epoch uint64) {
+	a.log.Debugf("executing triangular arb opportunity - sellOnDex: %v, lots: %v, dexRate: %v",
+		route.sellOnDEX, route.lots, a.fmtRate(route.dexRate))
+
+	a.activeArbsMtx.RLock()
+	numArbs := len(a.activeArbs)
+	a.activeArbsMtx.RUnlock()
+	if numArbs >= int(a.cfg().MaxActiveArbs) {
+		a.log.Info("cannot execute arb because already at max arbs")
+		return
+	}
+
+	if a.selfMatch(route.sellOnDEX, route.dexRate) {
+		a.log.Info("cannot execute arb opportunity due to self-match")
+		return
+	}
+
+	// Hold the lock for this entire process because updates to the cex
+	// trades may come even before the Trade function has returned.
+	a.activeArbsMtx.Lock()
+	defer a.activeArbsMtx.Unlock()
+
+	arb := &triArbSequence{
+		dexQty:     route.lots * a.lotSize.Load(),
+		sellOnDEX:  route.sellOnDEX,
+		startEpoch: epoch,
+		legs:       []*triArbLeg{route.baseLeg, route.quoteLeg},
+	}
+
+	// Place the cex orders first. If placing any other order fails, the cex
+	// orders that were placed can be canceled.
+	for _, leg := range arb.legs {
+		trade, err := a.cex.CEXTrade(a.ctx, leg.baseID, leg.quoteID, leg.sell, leg.rate, leg.qty)
+		if err != nil {
+			a.log.Errorf("error placing %s-%s cex order: %v", dex.BipIDSymbol(leg.baseID), dex.BipIDSymbol(leg.quoteID), err)
+			a.abandonArb(arb)
+			return
+		}
+		leg.tradeID = trade.ID
+		leg.complete = trade.Complete
+		leg.filled = trade.BaseFilled
+	}
+
+	dexOrder, err := a.core.DEXTrade(route.dexRate, arb.dexQty, route.sellOnDEX)
+	if err != nil {
+		a.log.Errorf("error placing dex order: %v", err)
+		a.abandonArb(arb)
+		return
+	}
+
+	arb.dexOrder = dexOrder
+	a.activeArbs = append(a.activeArbs, arb)
+}
+
+// abandonArb is called when the orders of an arb sequence could not all be
+// placed. The cex legs that were placed are canceled, and the sequence is
+// tracked until they are complete so that any fills can be unwound.
+//
+// activeArbsMtx MUST be held when calling this function.
+func (a *triangularArbMarketMaker) abandonArb(arb *triArbSequence) {
+	arb.dexOrderDone = true
+	arb.canceled = true
+	for _, leg := range arb.legs {
+		if leg.tradeID == "" {
+			leg.complete = true
+		}
+	}
+	a.cancelLegs(arb.legs)
+	for _, leg := range arb.legs {
+		if leg.complete {
+			a.unwindLeg(arb, leg)
+		}
+	}
+	if !arb.complete() {
+		a.activeArbs = append(a.activeArbs, arb)
+	}
+}
+
+// unwindLeg trades back the part of a completed cex leg that was not offset
+// by the DEX order. It is called once both the leg and the DEX order are no
+// longer active.
+//
+// activeArbsMtx MUST be held when calling this function.
+func (a *triangularArbMarketMaker) unwindLeg(arb *triArbSequence, leg *triArbLeg) {
+	qty := arb.excessLegQty(leg)
+	if qty == 0 {
+		return
+	}
+	mkt := fmt.Sprintf("%s-%s", dex.BipIDSymbol(leg.baseID), dex.BipIDSymbol(leg.quoteID))
+	// The sell argument to VWAP is the side of the book, which is the
+	// opposite of the side of the unwinding trade.
+	_, extrema, filled, err := a.CEX.VWAP(leg.baseID, leg.quoteID, leg.sell, qty)
+	if err != nil {
+		a.log.Errorf("error calculating %s VWAP to unwind cex trade ID %s: %v", mkt, leg.tradeID, err)
+		return
+	}
+	if !filled {
+		a.log.Errorf("insufficient %s liquidity to unwind cex trade ID %s", mkt, leg.tradeID)
+		return
+	}
+	if _, err := a.cex.CEXTrade(a.ctx, leg.baseID, leg.quoteID, !leg.sell, extrema, qty); err != nil {
+		a.log.Errorf("error placing %s cex order to unwind cex trade ID %s: %v", mkt, leg.tradeID, err)
+	}
+}
+
+// selfMatch checks if a order could match with any other orders already
+// placed on the dex.
+func (a *triangularArbMarketMaker) selfMatch(sell bool, rate uint64) bool {
+	a.activeArbsMtx.RLock()
+	defer a.activeArbsMtx.RUnlock()
+	var buys, sells []*core.Order
+	for _, arb := range a.activeArbs {
+		if arb.dexOrder == nil || arb.dexOrderDone {
+			continue
+		}
+		if arb.sellOnDEX {
+			sells = append(sells, arb.dexOrder)
+		} else {
+			buys = append(buys, arb.dexOrder)
+		}
+	}
+	return arbSelfMatch(buys, sells, sell, rate)
+}
+
+// cancelArbSequence will cancel the dex order and the cex orders in an arb
+// sequence if they have not yet been filled.
+func (a *triangularArbMarketMaker) cancelArbSequence(arb *triArbSequence) {
+	arb.canceled = true
+	a.cancelLegs(arb.legs)
+
+	if arb.dexOrder != nil && !arb.dexOrderDone {
+		err := a.core.Cancel(arb.dexOrder.ID)
+		if err != nil {
+			a.log.Errorf("failed to cancel dex order ID %s: %v", arb.dexOrder.ID, err)
+		}
+	}
+}
+
+// removeActiveArb removes the active arb at index i.
+//
+// activeArbsMtx MUST be held when calling this function.
+func (a *triangularArbMarketMaker) removeActiveArb(i int) {
+	a.activeArbs[i] = a.activeArbs[len(a.activeArbs)-1]
+	a.activeArbs = a.activeArbs[:len(a.activeArbs)-1]
+}
+
+// handleCEXTradeUpdate is called when the CEX sends a notification that the
+// status of a trade has changed.
+func (a *triangularArbMarketMaker) handleCEXTradeUpdate(update *libxc.Trade) {
+	if !update.Complete {
+		return
+	}
+
+	a.activeArbsMtx.Lock()
+	defer a.activeArbsMtx.Unlock()
+
+	for i, arb := range a.activeArbs {
+		for _, leg := range arb.legs {
+			if leg.tradeID != update.ID {
+				continue
+			}
+			if leg.complete {
+				return
+			}
+			leg.complete = true
+			leg.filled = update.BaseFilled
+			if arb.dexOrderDone {
+				a.unwindLeg(arb, leg)
+			}
+			if arb.complete() {
+				a.removeActiveArb(i)
+			}
+			return
+		}
+	}
+}
+
+// handleDEXOrderUpdate is called when the DEX sends a notification that the
+// status of an order has changed. Once the DEX order is executed, canceled or
+// revoked, the quantity that was filled is recorded. If the order was not
+// completely filled, the cex legs are canceled and any excess that was
+// already traded on the cex is unwound.
+func (a *triangularArbMarketMaker) handleDEXOrderUpdate(o *core.Order) {
+	switch o.Status {
+	case order.OrderStatusExecuted, order.OrderStatusCanceled, order.OrderStatusRevoked:
+	default:
+		return
+	}
+
+	a.activeArbsMtx.Lock()
+	defer a.activeArbsMtx.Unlock()
+
+	for i, arb := range a.activeArbs {
+		if arb.dexOrder == nil || !bytes.Equal(arb.dexOrder.ID, o.ID) {
+			continue
+		}
+		if arb.dexOrderDone {
+			return
+		}
+		arb.dexOrderDone = true
+		arb.dexFilled = o.Filled
+		if arb.dexFilled < arb.dexQty {
+			a.cancelLegs(arb.legs)
+		}
+		for _, leg := range arb.legs {
+			if leg.complete {
+				a.unwindLeg(arb, leg)
+			}
+		}
+		if arb.complete() {
+			a.removeActiveArb(i)
+		}
+		return
+	}
+}
+
+func (a *triangularArbMarketMaker) tryArb(newEpoch uint64) (route *triArbRoute, err error) {
+	if !(a.checkBotHealth(newEpoch) && a.tradingLimitNotReached(newEpoch)) {
+		return nil, nil
+	}
+
+	route, err = a.arbExists()
+	if err != nil {
+		return nil, err
+	}
+	if a.log.Level() == dex.LevelTrace {
+		a.log.Tracef("%s rebalance. exists = %t", a.name, route != nil)
+	}
+	if route != nil {
+		// Execution will not happen if it would cause a self-match.
+		a.executeArb(route, newEpoch)
+	}
+
+	return route, nil
+}
+
+// rebalance checks if there is a triangular arbitrage opportunity between
+// the dex and cex, and if so, executes trades to capitalize on it. Unlike the
+// simple arbitrage bot, the triangular arbitrage bot does not automatically
+// transfer funds between the dex and cex.
+func (a *triangularArbMarketMaker) rebalance(newEpoch uint64) {
+	if !a.rebalanceRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer a.rebalanceRunning.Store(false)
+	a.log.Tracef("rebalance: epoch %d", newEpoch)
+
+	epochReport := &EpochReport{EpochNum: newEpoch}
+
+	route, err := a.tryArb(newEpoch)
+	if err != nil {
+		epochReport.setPreOrderProblems(err)
+		a.unifiedExchangeAdaptor.updateEpochReport(epochReport)
+		return
+	}
+
+	a.unifiedExchangeAdaptor.updateEpochReport(epochReport)
+
+	// Canceled sequences remain active until all of their orders are
+	// complete so that any excess cex fills can be unwound.
+	a.activeArbsMtx.Lock()
+	for _, arb := range a.activeArbs {
+		if arb.canceled {
+			continue
+		}
+		expired := newEpoch-arb.startEpoch > uint64(a.cfg().NumEpochsLeaveOpen)
+		oppositeDirectionArbFound := route != nil && route.sellOnDEX != arb.sellOnDEX
+
+		if expired || oppositeDirectionArbFound {
+			a.cancelArbSequence(arb)
+		}
+	}
+	a.activeArbsMtx.Unlock()
+}
+
+func (a *triangularArbMarketMaker) botLoop(ctx context.Context) (*sync.WaitGroup, error) {
+	bridgeID := a.cfg().BridgeAssetID
+
+	book, bookFeed, err := a.core.SyncBook(a.host, a.baseID, a.quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync book: %v", err)
+	}
+	a.book = book
+
+	cexMkts, err := a.CEX.Markets(ctx)
+	if err != nil {
+		bookFeed.Close()
+		return nil, fmt.Errorf("failed to get cex markets: %v", err)
+	}
+	cexLotSize := func(assetID uint32) (uint64, error) {
+		mktID, err := dex.MarketName(assetID, bridgeID)
+		if err != nil {
+			return 0, err
+		}
+		mkt, found := cexMkts[mktID]
+		if !found {
+			return 0, fmt.Errorf("cex market %s not found", mktID)
+		}
+		return mkt.LotSize, nil
+	}
+	if a.baseLegLotSize, err = cexLotSize(a.baseID); err != nil {
+		bookFeed.Close()
+		return nil, err
+	}
+	if a.quoteLegLotSize, err = cexLotSize(a.quoteID); err != nil {
+		bookFeed.Close()
+		return nil, err
+	}
+
+	for _, assetID := range []uint32{a.baseID, a.quoteID} {
+		if err := a.cex.SubscribeMarket(ctx, assetID, bridgeID); err != nil {
+			bookFeed.Close()
+			return nil, fmt.Errorf("failed to subscribe to cex market %s-%s: %v",
+				dex.BipIDSymbol(assetID), dex.BipIDSymbol(bridgeID), err)
+		}
+	}
+
+	tradeUpdates := a.cex.SubscribeTradeUpdates()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer bookFeed.Close()
+		for {
+			select {
+			case ni, ok := <-bookFeed.Next():
+				if !ok {
+					a.log.Error("Stopping bot due to nil book feed.")
+					a.kill()
+					return
+				}
+				switch epoch := ni.Payload.(type) {
+				case *core.ResolvedEpoch:
+					a.rebalance(epoch.Current)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case update := <-tradeUpdates:
+				a.handleCEXTradeUpdate(update)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		orderUpdates := a.core.SubscribeOrderUpdates()
+		for {
+			select {
+			case n := <-orderUpdates:
+				a.handleDEXOrderUpdate(n)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return &wg, nil
+}
+
+func newTriangularArbMarketMaker(cfg *BotConfig, adaptorCfg *exchangeAdaptorCfg, log dex.Logger) (*triangularArbMarketMaker, error) {
+	if cfg.TriangularArbConfig == nil {
+		// implies bug in caller
+		return nil, fmt.Errorf("no triangular arb config provided")
+	}
+
+	adaptor, err := newUnifiedExchangeAdaptor(adaptorCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing exchange adaptor: %w", err)
+	}
+
+	err = cfg.TriangularArbConfig.validate(cfg.BaseID, cfg.QuoteID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid triangular arb config: %v", err)
+	}
+
+	triArb := &triangularArbMarketMaker{
+		unifiedExchangeAdaptor: adaptor,
+		cex:                    adaptor,
+		core:                   adaptor,
+		activeArbs:             make([]*triArbSequence, 0),
+	}
+	adaptor.setBotLoop(triArb.botLoop)
+	return triArb, nil
+}
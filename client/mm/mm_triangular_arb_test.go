@@ -0,0 +1,494 @@
+//go:build !harness && !botlive
+
+package mm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/order"
+)
+
+// tTriCEX is a tCEX with order books for more than one market. The VWAP of a
+// side of a market is the same for any quantity up to the depth.
+type tTriCEX struct {
+	*tCEX
+	books map[uint32]map[bool]*tTriBookSide // base asset -> sell -> book side
+}
+
+type tTriBookSide struct {
+	vwapResult
+	depth uint64
+}
+
+func (c *tTriCEX) VWAP(baseID, quoteID uint32, sell bool, qty uint64) (vwap, extrema uint64, filled bool, err error) {
+	if c.vwapErr != nil {
+		return 0, 0, false, c.vwapErr
+	}
+	side := c.books[baseID][sell]
+	if side == nil || qty > side.depth {
+		return 0, 0, false, nil
+	}
+	return side.avg, side.extrema, true, nil
+}
+
+// tTriBotCexAdaptor records all of the trades placed.
+type tTriBotCexAdaptor struct {
+	*tBotCexAdaptor
+	trades        []*libxc.Trade
+	tradeErrAfter int
+}
+
+func (c *tTriBotCexAdaptor) CEXTrade(ctx context.Context, baseID, quoteID uint32, sell bool, rate, qty uint64) (*libxc.Trade, error) {
+	if c.tradeErr != nil && len(c.trades) >= c.tradeErrAfter {
+		return nil, c.tradeErr
+	}
+	trade := &libxc.Trade{
+		ID:      fmt.Sprintf("trade%d", len(c.trades)),
+		BaseID:  baseID,
+		QuoteID: quoteID,
+		Rate:    rate,
+		Sell:    sell,
+		Qty:     qty,
+	}
+	c.trades = append(c.trades, trade)
+	return trade, nil
+}
+
+func (c *tTriBotCexAdaptor) SufficientBalanceForCEXTrade(baseID, quoteID uint32, sell bool, rate, qty uint64) bool {
+	// Limit the quantity of the base asset of the DEX market.
+	if baseID != 42 {
+		return true
+	}
+	return c.tBotCexAdaptor.SufficientBalanceForCEXTrade(baseID, quoteID, sell, rate, qty)
+}
+
+func TestTriangularArbRebalance(t *testing.T) {
+	const lotSize uint64 = 1e8
+	const baseID, quoteID, bridgeID uint32 = 42, 0, 60001
+	const currEpoch uint64 = 100
+	const profitTrigger = 0.01
+	const feesInQuoteUnits uint64 = 1e3
+	// DCR/USDT = 20, BTC/USDT = 10,000, so the implied DCR/BTC rate is 0.002.
+	const dcrUSDT, btcUSDT uint64 = 20e6, 1e10
+
+	orderIDs := make([]order.OrderID, 2)
+	for i := range orderIDs {
+		orderIDs[i][0] = byte(i + 1)
+	}
+
+	// The CEX books are deep enough for 2 lots.
+	cexBooks := func() map[uint32]map[bool]*tTriBookSide {
+		return map[uint32]map[bool]*tTriBookSide{
+			baseID: {
+				true:  {vwapResult{dcrUSDT, dcrUSDT + 1e5}, 2 * lotSize},
+				false: {vwapResult{dcrUSDT, dcrUSDT - 1e5}, 2 * lotSize},
+			},
+			quoteID: {
+				true:  {vwapResult{btcUSDT, btcUSDT + 1e7}, 1e6},
+				false: {vwapResult{btcUSDT, btcUSDT - 1e7}, 1e6},
+			},
+		}
+	}
+
+	type test struct {
+		name         string
+		dexBids      map[uint64]vwapResult
+		dexAsks      map[uint64]vwapResult
+		cexMaxQty    uint64
+		cexTradeErr  error
+		existingArbs []*triArbSequence
+		// baseLegLotSize and quoteLegLotSize are the cex lot sizes.
+		baseLegLotSize  uint64
+		quoteLegLotSize uint64
+
+		expDEXOrder   *dexOrder
+		expCEXTrades  []*libxc.Trade
+		expDEXCancels []order.OrderID
+		expCEXCancels []string
+	}
+
+	tests := []*test{
+		{
+			name:    "no arb",
+			dexBids: map[uint64]vwapResult{1: {2e5, 2e5}},
+			dexAsks: map[uint64]vwapResult{1: {2e5, 2e5}},
+		},
+		{
+			name:    "below profit trigger",
+			dexBids: map[uint64]vwapResult{1: {2.02e5, 2.02e5}},
+		},
+		{
+			name:    "sell on dex",
+			dexBids: map[uint64]vwapResult{1: {2.2e5, 2.2e5}},
+			dexAsks: map[uint64]vwapResult{1: {2.4e5, 2.4e5}},
+			expDEXOrder: &dexOrder{
+				rate: 2.2e5,
+				qty:  lotSize,
+				sell: true,
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT + 1e5, Qty: lotSize, Sell: false},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT - 1e7, Qty: 2.2e5, Sell: true},
+			},
+		},
+		{
+			name:    "buy on dex",
+			dexBids: map[uint64]vwapResult{1: {1.6e5, 1.6e5}},
+			dexAsks: map[uint64]vwapResult{1: {1.8e5, 1.8e5}},
+			expDEXOrder: &dexOrder{
+				rate: 1.8e5,
+				qty:  lotSize,
+				sell: false,
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT - 1e5, Qty: lotSize, Sell: true},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT + 1e7, Qty: 1.8e5, Sell: false},
+			},
+		},
+		{
+			name: "2 lots",
+			dexBids: map[uint64]vwapResult{
+				1: {2.2e5, 2.2e5},
+				2: {2.2e5, 2.1e5},
+				3: {2.1e5, 1.9e5},
+			},
+			expDEXOrder: &dexOrder{
+				rate: 2.1e5,
+				qty:  2 * lotSize,
+				sell: true,
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT + 1e5, Qty: 2 * lotSize, Sell: false},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT - 1e7, Qty: 4.4e5, Sell: true},
+			},
+		},
+		{
+			name: "2 lots, insufficient cex balance for 2",
+			dexBids: map[uint64]vwapResult{
+				1: {2.2e5, 2.2e5},
+				2: {2.2e5, 2.1e5},
+			},
+			cexMaxQty: lotSize,
+			expDEXOrder: &dexOrder{
+				rate: 2.2e5,
+				qty:  lotSize,
+				sell: true,
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT + 1e5, Qty: lotSize, Sell: false},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT - 1e7, Qty: 2.2e5, Sell: true},
+			},
+		},
+		{
+			name:            "legs rounded to cex lot sizes",
+			dexBids:         map[uint64]vwapResult{1: {2.23e5, 2.23e5}},
+			baseLegLotSize:  3e7,
+			quoteLegLotSize: 1e4,
+			expDEXOrder: &dexOrder{
+				rate: 2.23e5,
+				qty:  lotSize,
+				sell: true,
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT + 1e5, Qty: 9e7, Sell: false},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT - 1e7, Qty: 2.2e5, Sell: true},
+			},
+		},
+		{
+			name:        "second leg fails",
+			dexBids:     map[uint64]vwapResult{1: {2.2e5, 2.2e5}},
+			cexTradeErr: errors.New("test error"),
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT + 1e5, Qty: lotSize, Sell: false},
+			},
+			expCEXCancels: []string{"trade0"},
+		},
+		{
+			name:    "expired and opposite direction arbs canceled",
+			dexBids: map[uint64]vwapResult{1: {2.2e5, 2.2e5}},
+			existingArbs: []*triArbSequence{
+				{
+					dexOrder:   &core.Order{ID: orderIDs[0][:], Rate: 1e5},
+					startEpoch: currEpoch - 1,
+					legs: []*triArbLeg{
+						{tradeID: "old0", complete: true},
+						{tradeID: "old1"},
+					},
+				},
+				{
+					dexOrder:     &core.Order{ID: orderIDs[1][:], Rate: 3e5},
+					dexOrderDone: true,
+					sellOnDEX:    true,
+					startEpoch:   currEpoch - 20,
+					legs: []*triArbLeg{
+						{tradeID: "old2"},
+						{tradeID: "old3", complete: true},
+					},
+				},
+			},
+			expDEXOrder: &dexOrder{
+				rate: 2.2e5,
+				qty:  lotSize,
+				sell: true,
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT + 1e5, Qty: lotSize, Sell: false},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT - 1e7, Qty: 2.2e5, Sell: true},
+			},
+			expDEXCancels: []order.OrderID{orderIDs[0]},
+			expCEXCancels: []string{"old1", "old2"},
+		},
+	}
+
+	runTest := func(tt *test) {
+		t.Run(tt.name, func(t *testing.T) {
+			cexMaxQty := tt.cexMaxQty
+			if cexMaxQty == 0 {
+				cexMaxQty = 10 * lotSize
+			}
+			cex := &tTriBotCexAdaptor{tBotCexAdaptor: newTBotCEXAdaptor(), tradeErrAfter: 1}
+			cex.tradeErr = tt.cexTradeErr
+			cex.maxBuyQty = cexMaxQty
+			cex.maxSellQty = cexMaxQty
+
+			tc := newTCore()
+			coreAdaptor := newTBotCoreAdaptor(tc)
+			coreAdaptor.buyFeesInQuote = feesInQuoteUnits
+			coreAdaptor.sellFeesInQuote = feesInQuoteUnits
+			coreAdaptor.maxBuyQty = 10 * lotSize
+			coreAdaptor.maxSellQty = 10 * lotSize
+			coreAdaptor.tradeResult = &core.Order{ID: encodeOrderID(0xff)}
+
+			u := mustParseAdaptorFromMarket(&core.Market{
+				LotSize:  lotSize,
+				BaseID:   baseID,
+				QuoteID:  quoteID,
+				RateStep: 1e2,
+			})
+			u.clientCore.(*tCore).userParcels = 0
+			u.clientCore.(*tCore).parcelLimit = 1
+			u.CEX = &tTriCEX{tCEX: newTCEX(), books: cexBooks()}
+			u.botCfgV.Store(&BotConfig{
+				BaseID:  baseID,
+				QuoteID: quoteID,
+				TriangularArbConfig: &TriangularArbConfig{
+					BridgeAssetID:      bridgeID,
+					ProfitTrigger:      profitTrigger,
+					MaxActiveArbs:      5,
+					NumEpochsLeaveOpen: 10,
+				},
+			})
+
+			dexBids, dexAsks := tt.dexBids, tt.dexAsks
+			if dexBids == nil {
+				dexBids = make(map[uint64]vwapResult)
+			}
+			if dexAsks == nil {
+				dexAsks = make(map[uint64]vwapResult)
+			}
+
+			a := &triangularArbMarketMaker{
+				unifiedExchangeAdaptor: u,
+				cex:                    cex,
+				core:                   coreAdaptor,
+				book:                   &tOrderBook{bidsVWAP: dexBids, asksVWAP: dexAsks},
+				baseLegLotSize:         tt.baseLegLotSize,
+				quoteLegLotSize:        tt.quoteLegLotSize,
+				activeArbs:             tt.existingArbs,
+			}
+			a.rebalance(currEpoch)
+
+			if (tt.expDEXOrder == nil) != (coreAdaptor.lastTradePlaced == nil) {
+				t.Fatalf("expected dex order %t but got %t", tt.expDEXOrder != nil, coreAdaptor.lastTradePlaced != nil)
+			}
+			if tt.expDEXOrder != nil && *tt.expDEXOrder != *coreAdaptor.lastTradePlaced {
+				t.Fatalf("dex order %+v != expected %+v", coreAdaptor.lastTradePlaced, tt.expDEXOrder)
+			}
+
+			if len(cex.trades) != len(tt.expCEXTrades) {
+				t.Fatalf("expected %d cex trades but got %d", len(tt.expCEXTrades), len(cex.trades))
+			}
+			for i, trade := range cex.trades {
+				if *trade != *tt.expCEXTrades[i] {
+					t.Fatalf("cex trade %d %+v != expected %+v", i, trade, tt.expCEXTrades[i])
+				}
+			}
+
+			if len(tc.cancelsPlaced) != len(tt.expDEXCancels) {
+				t.Fatalf("expected %d dex cancels but got %d", len(tt.expDEXCancels), len(tc.cancelsPlaced))
+			}
+			for i, oid := range tt.expDEXCancels {
+				if tc.cancelsPlaced[i] != oid {
+					t.Fatalf("expected dex cancel %s but got %s", oid, tc.cancelsPlaced[i])
+				}
+			}
+
+			if len(cex.cancelledTrades) != len(tt.expCEXCancels) {
+				t.Fatalf("expected %d cex cancels but got %d", len(tt.expCEXCancels), len(cex.cancelledTrades))
+			}
+			for i, tradeID := range tt.expCEXCancels {
+				if cex.cancelledTrades[i] != tradeID {
+					t.Fatalf("expected cex cancel %s but got %s", tradeID, cex.cancelledTrades[i])
+				}
+			}
+
+			if tt.expDEXOrder != nil {
+				var found bool
+				for _, arb := range a.activeArbs {
+					if bytes.Equal(arb.dexOrder.ID, coreAdaptor.tradeResult.ID) {
+						found = true
+						if len(arb.legs) != 2 {
+							t.Fatalf("expected 2 legs, got %d", len(arb.legs))
+						}
+					}
+				}
+				if !found {
+					t.Fatalf("new arb sequence not found")
+				}
+			}
+		})
+	}
+
+	for _, tt := range tests {
+		runTest(tt)
+	}
+}
+
+func encodeOrderID(b byte) dex.Bytes {
+	var oid order.OrderID
+	oid[0] = b
+	return oid[:]
+}
+
+func TestTriangularArbUpdates(t *testing.T) {
+	const lotSize uint64 = 1e8
+	const baseID, quoteID, bridgeID uint32 = 42, 0, 60001
+	const dcrUSDT, btcUSDT uint64 = 20e6, 1e10
+
+	oid := encodeOrderID(1)
+	newArb := func() *triArbSequence {
+		return &triArbSequence{
+			dexOrder: &core.Order{ID: oid},
+			dexQty:   2 * lotSize,
+			legs: []*triArbLeg{
+				{baseID: baseID, quoteID: bridgeID, qty: 2 * lotSize, lotSize: 1e6, tradeID: "leg0"},
+				{baseID: quoteID, quoteID: bridgeID, sell: true, qty: 4e5, lotSize: 1e3, tradeID: "leg1"},
+			},
+		}
+	}
+
+	u := mustParseAdaptorFromMarket(&core.Market{
+		LotSize:  lotSize,
+		BaseID:   baseID,
+		QuoteID:  quoteID,
+		RateStep: 1e2,
+	})
+	// Unwinding the base leg sells the base asset, and unwinding the quote
+	// leg buys the quote asset.
+	u.CEX = &tTriCEX{tCEX: newTCEX(), books: map[uint32]map[bool]*tTriBookSide{
+		baseID:  {false: {vwapResult{dcrUSDT, dcrUSDT - 1e5}, 10 * lotSize}},
+		quoteID: {true: {vwapResult{btcUSDT, btcUSDT + 1e7}, 1e7}},
+	}}
+
+	type step struct {
+		cexTrade *libxc.Trade
+		dexOrder *core.Order
+		active   bool
+	}
+
+	tests := []struct {
+		name          string
+		steps         []*step
+		expCEXTrades  []*libxc.Trade
+		expCEXCancels []string
+	}{
+		{
+			name: "dex first",
+			steps: []*step{
+				{dexOrder: &core.Order{ID: oid, Status: order.OrderStatusExecuted, Filled: 2 * lotSize}, active: true},
+				{cexTrade: &libxc.Trade{ID: "leg0", Complete: true, BaseFilled: 2 * lotSize}, active: true},
+				{cexTrade: &libxc.Trade{ID: "leg1", BaseFilled: 1e5}, active: true},
+				{cexTrade: &libxc.Trade{ID: "leg1", Complete: true, BaseFilled: 4e5}, active: false},
+			},
+		},
+		{
+			name: "dex last",
+			steps: []*step{
+				{cexTrade: &libxc.Trade{ID: "leg1", Complete: true, BaseFilled: 4e5}, active: true},
+				{cexTrade: &libxc.Trade{ID: "leg0", Complete: true, BaseFilled: 2 * lotSize}, active: true},
+				{dexOrder: &core.Order{ID: oid, Status: order.OrderStatusBooked, Filled: lotSize}, active: true},
+				{dexOrder: &core.Order{ID: oid, Status: order.OrderStatusExecuted, Filled: 2 * lotSize}, active: false},
+			},
+		},
+		{
+			name: "dex canceled unfilled",
+			steps: []*step{
+				{cexTrade: &libxc.Trade{ID: "leg0", Complete: true, BaseFilled: 2 * lotSize}, active: true},
+				{dexOrder: &core.Order{ID: oid, Status: order.OrderStatusCanceled}, active: true},
+				{cexTrade: &libxc.Trade{ID: "leg1", Complete: true, BaseFilled: 1e5}, active: false},
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT - 1e5, Qty: 2 * lotSize, Sell: true},
+				{ID: "trade1", BaseID: quoteID, QuoteID: bridgeID, Rate: btcUSDT + 1e7, Qty: 1e5, Sell: false},
+			},
+			expCEXCancels: []string{"leg1"},
+		},
+		{
+			name: "dex revoked partially filled",
+			steps: []*step{
+				{dexOrder: &core.Order{ID: oid, Status: order.OrderStatusRevoked, Filled: lotSize}, active: true},
+				// Half of the leg is offset by the DEX fill, and the excess
+				// is rounded down to the leg's lot size.
+				{cexTrade: &libxc.Trade{ID: "leg0", Complete: true, BaseFilled: 150_500_000}, active: true},
+				{cexTrade: &libxc.Trade{ID: "leg1", Complete: true, BaseFilled: 2e5}, active: false},
+			},
+			expCEXTrades: []*libxc.Trade{
+				{ID: "trade0", BaseID: baseID, QuoteID: bridgeID, Rate: dcrUSDT - 1e5, Qty: 5e7, Sell: true},
+			},
+			expCEXCancels: []string{"leg0", "leg1"},
+		},
+	}
+
+	for _, tt := range tests {
+		cex := &tTriBotCexAdaptor{tBotCexAdaptor: newTBotCEXAdaptor()}
+		a := &triangularArbMarketMaker{
+			unifiedExchangeAdaptor: u,
+			cex:                    cex,
+			activeArbs:             []*triArbSequence{newArb()},
+		}
+		for i, s := range tt.steps {
+			if s.cexTrade != nil {
+				a.handleCEXTradeUpdate(s.cexTrade)
+			} else {
+				a.handleDEXOrderUpdate(s.dexOrder)
+			}
+			if active := len(a.activeArbs) == 1; active != s.active {
+				t.Fatalf("%s: step %d: expected active = %t, got %t", tt.name, i, s.active, active)
+			}
+		}
+
+		if len(cex.trades) != len(tt.expCEXTrades) {
+			t.Fatalf("%s: expected %d cex trades but got %d", tt.name, len(tt.expCEXTrades), len(cex.trades))
+		}
+		for i, trade := range cex.trades {
+			if *trade != *tt.expCEXTrades[i] {
+				t.Fatalf("%s: cex trade %d %+v != expected %+v", tt.name, i, trade, tt.expCEXTrades[i])
+			}
+		}
+		if len(cex.cancelledTrades) != len(tt.expCEXCancels) {
+			t.Fatalf("%s: expected %d cex cancels but got %d", tt.name, len(tt.expCEXCancels), len(cex.cancelledTrades))
+		}
+		for i, tradeID := range tt.expCEXCancels {
+			if cex.cancelledTrades[i] != tradeID {
+				t.Fatalf("%s: expected cex cancel %s but got %s", tt.name, tradeID, cex.cancelledTrades[i])
+			}
+		}
+	}
+}
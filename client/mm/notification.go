@@ -4,6 +4,8 @@
 package mm
 
 import (
+	"fmt"
+
 	"decred.org/dcrdex/client/db"
 )
 
@@ -13,6 +15,9 @@ const (
 	NoteTypeCEXNotification = "cexnote"
 	NoteTypeEpochReport     = "epochreport"
 	NoteTypeCEXProblems     = "cexproblems"
+	NoteTypeRiskLimit       = "risklimit"
+	NoteTypeBotSchedule     = "botschedule"
+	NoteTypePortfolio       = "portfolio"
 )
 
 type runStatsNote struct {
@@ -63,7 +68,9 @@ type cexNotification struct {
 }
 
 const (
-	TopicBalanceUpdate = "BalanceUpdate"
+	TopicBalanceUpdate     = "BalanceUpdate"
+	TopicRiskLimitExceeded = "RiskLimitExceeded"
+	TopicScheduleFailed    = "ScheduleFailed"
 )
 
 func newCexUpdateNote(cexName string, topic db.Topic, note interface{}) *cexNotification {
@@ -109,3 +116,57 @@ func newCexProblemsNote(host string, baseID, quoteID uint32, problems *CEXProble
 		Problems:     problems,
 	}
 }
+
+type riskLimitNotification struct {
+	db.Notification
+	Host    string          `json:"host"`
+	BaseID  uint32          `json:"baseID"`
+	QuoteID uint32          `json:"quoteID"`
+	Event   *RiskLimitEvent `json:"event"`
+}
+
+func newRiskLimitNote(host string, baseID, quoteID uint32, mktName string, e *RiskLimitEvent) *riskLimitNotification {
+	return &riskLimitNotification{
+		Notification: db.NewNotification(NoteTypeRiskLimit, TopicRiskLimitExceeded,
+			fmt.Sprintf("%s bot stopped", mktName),
+			fmt.Sprintf("The %s bot on %s was stopped because a risk limit was exceeded. %s.", mktName, host, e.Details),
+			db.ErrorLevel),
+		Host:    host,
+		BaseID:  baseID,
+		QuoteID: quoteID,
+		Event:   e,
+	}
+}
+
+type scheduleNotification struct {
+	db.Notification
+	Host    string         `json:"host"`
+	BaseID  uint32         `json:"baseID"`
+	QuoteID uint32         `json:"quoteID"`
+	Action  ScheduleAction `json:"action"`
+}
+
+func newScheduleErrorNote(mkt *MarketWithHost, action ScheduleAction, err error) *scheduleNotification {
+	return &scheduleNotification{
+		Notification: db.NewNotification(NoteTypeBotSchedule, TopicScheduleFailed,
+			"Scheduled bot action failed",
+			fmt.Sprintf("The scheduled %s action for the %s bot on %s failed: %v", action, mkt.ID(), mkt.Host, err),
+			db.ErrorLevel),
+		Host:    mkt.Host,
+		BaseID:  mkt.BaseID,
+		QuoteID: mkt.QuoteID,
+		Action:  action,
+	}
+}
+
+type portfolioNotification struct {
+	db.Notification
+	Reallocation *PortfolioReallocation `json:"reallocation"`
+}
+
+func newPortfolioReallocationNote(r *PortfolioReallocation) *portfolioNotification {
+	return &portfolioNotification{
+		Notification: db.NewNotification(NoteTypePortfolio, "", "", "", db.Data),
+		Reallocation: r,
+	}
+}
@@ -0,0 +1,166 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/utils"
+)
+
+// OracleConfig configures how the reports from the price oracle's sources are
+// combined into the oracle price used by the basic market maker. By default,
+// the mid-gaps of the reports are weighted by their 24 hour USD volume.
+type OracleConfig struct {
+	// Weights are multipliers applied to the volume weights of the reports,
+	// keyed by OracleReport.Host. Reports from hosts without a weight have a
+	// multiplier of 1. A host with a weight of 0 is not used.
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// MaxDeviation is the maximum ratio by which the mid-gap of a report can
+	// deviate from the median mid-gap of all reports before it is rejected
+	// as an outlier. Outliers are only rejected if there are at least 3
+	// reports. If 0, outliers are not rejected. 0 <= x <= 0.5.
+	MaxDeviation float64 `json:"maxDeviation,omitempty"`
+	// MaxAge is the maximum age of a report, in seconds. Older reports are
+	// not used. If 0, reports expire after 10 minutes.
+	MaxAge uint64 `json:"maxAge,omitempty"`
+	// CEXWeight is the fraction of the oracle price that is determined by the
+	// mid-gap of the bot's CEX market. If no other reports are usable, the
+	// CEX mid-gap is the oracle price. If 0, the CEX is not used. The bot
+	// must be configured with a CEX. 0 <= x <= 1.
+	CEXWeight float64 `json:"cexWeight,omitempty"`
+}
+
+func (c *OracleConfig) validate() error {
+	for host, w := range c.Weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("invalid weight %f for %s", w, host)
+		}
+	}
+	if c.MaxDeviation < 0 || c.MaxDeviation > 0.5 {
+		return fmt.Errorf("max deviation %f out of bounds", c.MaxDeviation)
+	}
+	if c.CEXWeight < 0 || c.CEXWeight > 1 {
+		return fmt.Errorf("cex weight %f out of bounds", c.CEXWeight)
+	}
+	return nil
+}
+
+func (c *OracleConfig) copy() *OracleConfig {
+	cfg := *c
+	cfg.Weights = utils.CopyMap(c.Weights)
+	return &cfg
+}
+
+func (c *OracleConfig) weight(host string) float64 {
+	if w, found := c.Weights[host]; found {
+		return w
+	}
+	return 1
+}
+
+func (c *OracleConfig) maxAge() time.Duration {
+	if c.MaxAge == 0 {
+		return oraclePriceExpiration
+	}
+	return time.Duration(c.MaxAge) * time.Second
+}
+
+func (r *OracleReport) midGap() float64 {
+	return (r.BestBuy + r.BestSell) / 2
+}
+
+// aggregateOraclePrice combines the oracle reports for a market and the
+// mid-gap of the bot's CEX market into an oracle price. cexMidGap is a
+// conventional rate and is ignored if zero. If cfg is nil, the default
+// configuration is used. Zero is returned if there is not enough data.
+func aggregateOraclePrice(mkt marketPair, reports []*OracleReport, cexMidGap float64, cfg *OracleConfig, now time.Time, log dex.Logger) float64 {
+	if cfg == nil {
+		cfg = &OracleConfig{}
+	}
+
+	maxAge := cfg.maxAge()
+	usable := make([]*OracleReport, 0, len(reports))
+	for _, r := range reports {
+		if r.BestBuy <= 0 || r.BestSell <= 0 || cfg.weight(r.Host) == 0 {
+			continue
+		}
+		if r.Stamp > 0 && now.Sub(time.Unix(r.Stamp, 0)) > maxAge {
+			log.Debugf("Ignoring stale %s oracle report from %s", mkt, r.Host)
+			continue
+		}
+		usable = append(usable, r)
+	}
+
+	if cfg.MaxDeviation > 0 {
+		mids := make([]float64, 0, len(usable)+1)
+		for _, r := range usable {
+			mids = append(mids, r.midGap())
+		}
+		if cfg.CEXWeight > 0 && cexMidGap > 0 {
+			mids = append(mids, cexMidGap)
+		}
+		if len(mids) >= 3 {
+			median := medianOf(mids)
+			isOutlier := func(mid float64) bool {
+				return math.Abs(mid-median)/median > cfg.MaxDeviation
+			}
+			filtered := usable[:0]
+			for _, r := range usable {
+				if isOutlier(r.midGap()) {
+					log.Infof("Rejecting %s oracle report from %s. Mid-gap %f deviates from median %f", mkt, r.Host, r.midGap(), median)
+					continue
+				}
+				filtered = append(filtered, r)
+			}
+			usable = filtered
+			if cfg.CEXWeight > 0 && cexMidGap > 0 && isOutlier(cexMidGap) {
+				log.Infof("Rejecting %s CEX mid-gap %f. Deviates from median %f", mkt, cexMidGap, median)
+				cexMidGap = 0
+			}
+		}
+	}
+
+	var weightedSum, totalWeight, usdVolume float64
+	for _, r := range usable {
+		w := r.USDVol * cfg.weight(r.Host)
+		weightedSum += w * r.midGap()
+		totalWeight += w
+		usdVolume += r.USDVol
+	}
+	var price float64
+	if totalWeight > 0 {
+		if usdVolume < minimumUSDVolumeForOraclesAvg {
+			log.Meter("oracle_low_volume_"+mkt.String(), 12*time.Hour).Infof(
+				"Rejecting oracle average price for %s. not enough volume (%.2f USD < %.2f)",
+				mkt, usdVolume, float32(minimumUSDVolumeForOraclesAvg),
+			)
+		} else {
+			price = weightedSum / totalWeight
+		}
+	}
+
+	if cfg.CEXWeight == 0 || cexMidGap == 0 {
+		return price
+	}
+	if price == 0 {
+		return cexMidGap
+	}
+	return price*(1-cfg.CEXWeight) + cexMidGap*cfg.CEXWeight
+}
+
+func medianOf(vs []float64) float64 {
+	sorted := make([]float64, len(vs))
+	copy(sorted, vs)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
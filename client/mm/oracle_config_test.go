@@ -0,0 +1,175 @@
+//go:build !harness && !botlive
+
+package mm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/dex"
+)
+
+func TestAggregateOraclePrice(t *testing.T) {
+	now := time.Now()
+	mkt := marketPair{42, 0}
+	fresh := now.Unix()
+	stale := now.Add(-time.Hour).Unix()
+
+	report := func(host string, mid, usdVol float64, stamp int64) *OracleReport {
+		return &OracleReport{
+			Host:     host,
+			USDVol:   usdVol,
+			BestBuy:  mid * 0.999,
+			BestSell: mid * 1.001,
+			Stamp:    stamp,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		reports   []*OracleReport
+		cexMidGap float64
+		cfg       *OracleConfig
+		exp       float64
+	}{{
+		name: "volume weighted",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 2, 3e6, fresh),
+		},
+		exp: 1.75,
+	}, {
+		name: "not enough volume",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e4, fresh),
+		},
+		exp: 0,
+	}, {
+		name: "stale report ignored",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 2, 3e6, stale),
+		},
+		exp: 1,
+	}, {
+		name: "custom max age",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 2, 3e6, stale),
+		},
+		cfg: &OracleConfig{MaxAge: 7200},
+		exp: 1.75,
+	}, {
+		name: "weights",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 2, 3e6, fresh),
+		},
+		cfg: &OracleConfig{Weights: map[string]float64{"a.com": 3}},
+		exp: 1.5,
+	}, {
+		name: "zero weight excludes host",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 2, 3e6, fresh),
+		},
+		cfg: &OracleConfig{Weights: map[string]float64{"b.com": 0}},
+		exp: 1,
+	}, {
+		name: "outlier rejected",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 1.01, 1e6, fresh),
+			report("c.com", 1.5, 1e7, fresh),
+		},
+		cfg: &OracleConfig{MaxDeviation: 0.05},
+		exp: 1.005,
+	}, {
+		name: "outliers not rejected with two reports",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("c.com", 1.5, 1e6, fresh),
+		},
+		cfg: &OracleConfig{MaxDeviation: 0.05},
+		exp: 1.25,
+	}, {
+		name: "cex mid-gap",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+		},
+		cexMidGap: 2,
+		cfg:       &OracleConfig{CEXWeight: 0.25},
+		exp:       1.25,
+	}, {
+		name:      "cex mid-gap only",
+		cexMidGap: 2,
+		cfg:       &OracleConfig{CEXWeight: 0.25},
+		exp:       2,
+	}, {
+		name: "cex mid-gap ignored without weight",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+		},
+		cexMidGap: 2,
+		exp:       1,
+	}, {
+		name: "cex mid-gap outlier",
+		reports: []*OracleReport{
+			report("a.com", 1, 1e6, fresh),
+			report("b.com", 1.01, 1e6, fresh),
+		},
+		cexMidGap: 2,
+		cfg:       &OracleConfig{CEXWeight: 0.5, MaxDeviation: 0.05},
+		exp:       1.005,
+	}}
+
+	for _, tt := range tests {
+		price := aggregateOraclePrice(mkt, tt.reports, tt.cexMidGap, tt.cfg, now, tLogger)
+		if math.Abs(price-tt.exp) > 1e-9 {
+			t.Fatalf("%s: expected price %f, got %f", tt.name, tt.exp, price)
+		}
+	}
+}
+
+type tOracleSource struct {
+	name    string
+	reports []*OracleReport
+	err     error
+}
+
+func (s *tOracleSource) Name() string {
+	return s.name
+}
+
+func (s *tOracleSource) OracleReports(ctx context.Context, baseID, quoteID uint32, log dex.Logger) ([]*OracleReport, error) {
+	return s.reports, s.err
+}
+
+func TestFetchMarketPrice(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	good := &tOracleSource{
+		name:    "good",
+		reports: []*OracleReport{{Host: "a.com", USDVol: 1e6, BestBuy: 0.9, BestSell: 1.1}},
+	}
+	bad := &tOracleSource{name: "bad", err: errors.New("source down")}
+
+	price, reports, err := fetchMarketPrice(ctx, []OracleSource{bad, good}, 42, 0, tLogger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if price != 1 {
+		t.Fatalf("expected price 1, got %f", price)
+	}
+	if len(reports) != 1 || reports[0].Stamp == 0 {
+		t.Fatalf("expected 1 stamped report")
+	}
+
+	if _, _, err := fetchMarketPrice(ctx, []OracleSource{bad}, 42, 0, tLogger); err == nil {
+		t.Fatalf("expected error when all sources fail")
+	}
+}
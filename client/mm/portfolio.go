@@ -0,0 +1,386 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"decred.org/dcrdex/dex"
+)
+
+const (
+	// defaultPortfolioCron reallocates the portfolio at the start of every
+	// hour.
+	defaultPortfolioCron = "0 * * * *"
+	// portfolioMinMove is the minimum amount, as a ratio of the asset's
+	// budget, that is moved to or from a bot. Smaller moves are skipped to
+	// avoid churning the bots' inventories.
+	portfolioMinMove = 0.01
+	// maxPortfolioHistory is the number of reallocations that are kept in
+	// memory.
+	maxPortfolioHistory = 100
+)
+
+// PortfolioConfig is the configuration for the portfolio allocator. The
+// allocator periodically redistributes a total DEX budget of each asset
+// between the running bots that trade the asset, based on how much of their
+// balance the bots are using and their recent profitability. Funds are moved
+// using inventory updates. CEX balances are not reallocated.
+type PortfolioConfig struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Cron is a five field cron expression specifying when the portfolio is
+	// reallocated. See ScheduleEntry.Cron. Default: hourly.
+	Cron string `json:"cron,omitempty"`
+	// Budgets is the total DEX balance, in atoms, of each asset that is
+	// allocated to the running bots whose market includes the asset. Assets
+	// without a budget are not reallocated.
+	Budgets map[uint32]uint64 `json:"budgets"`
+	// Markets limits the allocator to the bots on these markets. If empty,
+	// all running bots are included.
+	Markets []*MarketWithHost `json:"markets,omitempty"`
+	// ProfitWeight is the weight of the bots' profitability since the
+	// previous reallocation when determining their share of a budget. The
+	// rest of the weight is given to the fraction of the bots' balances that
+	// are in use. 0 <= x <= 1.
+	ProfitWeight float64 `json:"profitWeight"`
+	// MinShare is the minimum share of a budget that a bot is allocated.
+	// It is reduced if it is more than an equal share. 0 <= x <= 1.
+	MinShare float64 `json:"minShare"`
+}
+
+func (c *PortfolioConfig) validate() error {
+	if c.Cron != "" {
+		if _, err := parseCron(c.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression %q: %w", c.Cron, err)
+		}
+	}
+	if len(c.Budgets) == 0 {
+		return fmt.Errorf("no budgets")
+	}
+	if c.ProfitWeight < 0 || c.ProfitWeight > 1 {
+		return fmt.Errorf("profit weight %f out of bounds", c.ProfitWeight)
+	}
+	if c.MinShare < 0 || c.MinShare > 1 {
+		return fmt.Errorf("min share %f out of bounds", c.MinShare)
+	}
+	return nil
+}
+
+func (c *PortfolioConfig) includes(mkt MarketWithHost) bool {
+	if len(c.Markets) == 0 {
+		return true
+	}
+	for _, m := range c.Markets {
+		if *m == mkt {
+			return true
+		}
+	}
+	return false
+}
+
+// BotReallocation is the change to a bot's DEX inventory during a portfolio
+// reallocation.
+type BotReallocation struct {
+	MarketWithHost
+	Diffs map[uint32]int64 `json:"diffs"`
+	Error string           `json:"error,omitempty"`
+}
+
+// PortfolioReallocation is a reallocation of the portfolio between the
+// running bots.
+type PortfolioReallocation struct {
+	Stamp int64              `json:"stamp"`
+	Bots  []*BotReallocation `json:"bots"`
+}
+
+// portfolioBot is a running bot's balance of an asset and its performance,
+// as used to calculate its share of the asset's budget.
+type portfolioBot struct {
+	mkt       MarketWithHost
+	total     uint64
+	available uint64
+	// utilization is the fraction of the bot's balance of the asset that is
+	// in use.
+	utilization float64
+	// profitReturn is the change in the bot's profit ratio since the
+	// previous reallocation.
+	profitReturn float64
+}
+
+// portfolioShares calculates each bot's share of a budget. The shares sum to
+// 1.
+func portfolioShares(bots []*portfolioBot, profitWeight, minShare float64) []float64 {
+	n := float64(len(bots))
+	normalize := func(vs []float64) []float64 {
+		var sum float64
+		for _, v := range vs {
+			sum += v
+		}
+		shares := make([]float64, len(vs))
+		for i, v := range vs {
+			if sum > 0 {
+				shares[i] = v / sum
+			} else {
+				shares[i] = 1 / n
+			}
+		}
+		return shares
+	}
+
+	utilizations := make([]float64, len(bots))
+	minReturn := math.Inf(1)
+	for i, b := range bots {
+		utilizations[i] = b.utilization
+		minReturn = math.Min(minReturn, b.profitReturn)
+	}
+	// Returns are shifted so that the least profitable bot has a score of 0.
+	returns := make([]float64, len(bots))
+	for i, b := range bots {
+		returns[i] = b.profitReturn - minReturn
+	}
+
+	utilizationShares, returnShares := normalize(utilizations), normalize(returns)
+	minShare = math.Min(minShare, 1/n)
+	shares := make([]float64, len(bots))
+	for i := range bots {
+		score := (1-profitWeight)*utilizationShares[i] + profitWeight*returnShares[i]
+		shares[i] = minShare + (1-n*minShare)*score
+	}
+	return shares
+}
+
+// portfolioDiffs calculates the changes to the bots' balances of an asset
+// that move the balances towards their share of the budget. A bot's balance
+// can only be decreased by its available balance, and increases are limited
+// to the funds that are released by other bots plus any unallocated budget.
+func portfolioDiffs(budget uint64, bots []*portfolioBot, profitWeight, minShare float64) []int64 {
+	diffs := make([]int64, len(bots))
+	if len(bots) == 0 {
+		return diffs
+	}
+
+	shares := portfolioShares(bots, profitWeight, minShare)
+	minMove := int64(math.Round(float64(budget) * portfolioMinMove))
+
+	var allocated, released, requested int64
+	targets := make([]int64, len(bots))
+	for i, b := range bots {
+		allocated += int64(b.total)
+		targets[i] = int64(math.Round(float64(budget)*shares[i])) - int64(b.total)
+		if targets[i] < 0 {
+			diffs[i] = -min(-targets[i], int64(b.available))
+			if -diffs[i] < minMove {
+				diffs[i] = 0
+			}
+			released -= diffs[i]
+		} else {
+			requested += targets[i]
+		}
+	}
+
+	pool := released + int64(budget) - allocated
+	if pool <= 0 || requested == 0 {
+		return diffs
+	}
+	scale := math.Min(1, float64(pool)/float64(requested))
+	for i, target := range targets {
+		if target <= 0 {
+			continue
+		}
+		if add := int64(math.Floor(float64(target) * scale)); add >= minMove {
+			diffs[i] = add
+		}
+	}
+	return diffs
+}
+
+type portfolioProfit struct {
+	startTime   int64
+	profitRatio float64
+}
+
+// PortfolioConfig returns the portfolio allocator configuration, or nil if
+// there is none.
+func (m *MarketMaker) PortfolioConfig() *PortfolioConfig {
+	return m.defaultConfig().Portfolio
+}
+
+// UpdatePortfolioConfig saves the portfolio allocator configuration. A nil
+// config removes the configuration.
+func (m *MarketMaker) UpdatePortfolioConfig(portfolioCfg *PortfolioConfig) error {
+	if portfolioCfg != nil {
+		if err := portfolioCfg.validate(); err != nil {
+			return fmt.Errorf("invalid portfolio config: %w", err)
+		}
+	}
+	cfg := m.defaultConfig()
+	cfg.Portfolio = portfolioCfg
+	return m.writeConfigFile(cfg)
+}
+
+// PortfolioReallocations returns the most recent portfolio reallocations.
+func (m *MarketMaker) PortfolioReallocations() []*PortfolioReallocation {
+	m.portfolioMtx.Lock()
+	defer m.portfolioMtx.Unlock()
+	return append([]*PortfolioReallocation(nil), m.portfolioHistory...)
+}
+
+// ReallocatePortfolio redistributes the portfolio budgets between the
+// running bots immediately.
+func (m *MarketMaker) ReallocatePortfolio() (*PortfolioReallocation, error) {
+	cfg := m.PortfolioConfig()
+	if cfg == nil {
+		return nil, fmt.Errorf("no portfolio config")
+	}
+	return m.reallocatePortfolio(cfg), nil
+}
+
+// runPortfolioAllocator reallocates the portfolio if the allocator's cron
+// expression matches t.
+func (m *MarketMaker) runPortfolioAllocator(t time.Time) {
+	cfg := m.PortfolioConfig()
+	if cfg == nil || cfg.Disabled {
+		return
+	}
+	cronExpr := cfg.Cron
+	if cronExpr == "" {
+		cronExpr = defaultPortfolioCron
+	}
+	cron, err := parseCron(cronExpr)
+	if err != nil {
+		m.log.Errorf("Invalid portfolio cron expression %q: %v", cronExpr, err)
+		return
+	}
+	if cron.matches(t) {
+		m.reallocatePortfolio(cfg)
+	}
+}
+
+func (m *MarketMaker) reallocatePortfolio(cfg *PortfolioConfig) *PortfolioReallocation {
+	m.portfolioMtx.Lock()
+	defer m.portfolioMtx.Unlock()
+
+	type botState struct {
+		rb          *runningBot
+		startTime   int64
+		profitRatio float64
+	}
+	bots := make(map[MarketWithHost]*botState)
+	for mkt, rb := range m.runningBotsLookup() {
+		if !cfg.includes(mkt) {
+			continue
+		}
+		s := &botState{rb: rb, startTime: rb.timeStart()}
+		if stats := rb.stats(); stats != nil && stats.ProfitLoss != nil {
+			s.profitRatio = stats.ProfitLoss.ProfitRatio
+		}
+		bots[mkt] = s
+	}
+
+	mktDiffs := make(map[MarketWithHost]map[uint32]int64)
+	for assetID, budget := range cfg.Budgets {
+		var assetBots []*portfolioBot
+		for mkt, s := range bots {
+			if mkt.BaseID != assetID && mkt.QuoteID != assetID {
+				continue
+			}
+			bal := s.rb.DEXBalance(assetID)
+			b := &portfolioBot{
+				mkt:       mkt,
+				total:     bal.Available + bal.Locked + bal.Pending + bal.Reserved,
+				available: bal.Available,
+			}
+			if b.total > 0 {
+				b.utilization = float64(b.total-b.available) / float64(b.total)
+			}
+			b.profitReturn = s.profitRatio
+			if prev, found := m.portfolioProfits[mkt]; found && prev.startTime == s.startTime {
+				b.profitReturn -= prev.profitRatio
+			}
+			assetBots = append(assetBots, b)
+		}
+		sort.Slice(assetBots, func(i, j int) bool {
+			return assetBots[i].mkt.String() < assetBots[j].mkt.String()
+		})
+
+		diffs := portfolioDiffs(budget, assetBots, cfg.ProfitWeight, cfg.MinShare)
+		for i, diff := range diffs {
+			if diff == 0 {
+				continue
+			}
+			mkt := assetBots[i].mkt
+			if mktDiffs[mkt] == nil {
+				mktDiffs[mkt] = make(map[uint32]int64)
+			}
+			mktDiffs[mkt][assetID] = diff
+		}
+	}
+
+	if m.portfolioProfits == nil {
+		m.portfolioProfits = make(map[MarketWithHost]*portfolioProfit)
+	}
+	for mkt, s := range bots {
+		m.portfolioProfits[mkt] = &portfolioProfit{startTime: s.startTime, profitRatio: s.profitRatio}
+	}
+
+	mkts := make([]MarketWithHost, 0, len(mktDiffs))
+	for mkt := range mktDiffs {
+		mkts = append(mkts, mkt)
+	}
+	sort.Slice(mkts, func(i, j int) bool { return mkts[i].String() < mkts[j].String() })
+
+	realloc := &PortfolioReallocation{Stamp: time.Now().Unix()}
+	reallocs := make(map[MarketWithHost]*BotReallocation, len(mkts))
+	for _, mkt := range mkts {
+		r := &BotReallocation{MarketWithHost: mkt, Diffs: mktDiffs[mkt]}
+		reallocs[mkt] = r
+		realloc.Bots = append(realloc.Bots, r)
+	}
+
+	// Decreases are applied first so that the released funds are available
+	// for the increases.
+	applyDiffs := func(increase bool) {
+		for _, mkt := range mkts {
+			r := reallocs[mkt]
+			if r.Error != "" {
+				continue
+			}
+			diffs := &BotInventoryDiffs{DEX: make(map[uint32]int64)}
+			for assetID, diff := range r.Diffs {
+				if (diff > 0) == increase {
+					diffs.DEX[assetID] = diff
+				}
+			}
+			if len(diffs.DEX) == 0 {
+				continue
+			}
+			if err := m.UpdateRunningBotInventory(&mkt, diffs); err != nil {
+				m.log.Errorf("Error reallocating portfolio to %s bot: %v", mkt, err)
+				r.Error = err.Error()
+				continue
+			}
+			for assetID, diff := range diffs.DEX {
+				m.log.Infof("Portfolio reallocation: %s bot %s balance changed by %s",
+					mkt, dex.BipIDSymbol(assetID), conventionalString(assetID, diff))
+			}
+		}
+	}
+	applyDiffs(false)
+	applyDiffs(true)
+
+	if len(realloc.Bots) == 0 {
+		m.log.Debugf("Portfolio reallocation: no changes")
+	}
+
+	m.portfolioHistory = append(m.portfolioHistory, realloc)
+	if len(m.portfolioHistory) > maxPortfolioHistory {
+		m.portfolioHistory = m.portfolioHistory[len(m.portfolioHistory)-maxPortfolioHistory:]
+	}
+	m.core.Broadcast(newPortfolioReallocationNote(realloc))
+
+	return realloc
+}
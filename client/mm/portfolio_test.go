@@ -0,0 +1,240 @@
+//go:build !harness && !botlive
+
+package mm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPortfolioDiffs(t *testing.T) {
+	tests := []struct {
+		name         string
+		budget       uint64
+		bots         []*portfolioBot
+		profitWeight float64
+		minShare     float64
+		exp          []int64
+	}{{
+		name:   "no bots",
+		budget: 100,
+		exp:    []int64{},
+	}, {
+		name:   "equal shares without utilization",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 800, available: 800},
+			{total: 200, available: 200},
+		},
+		exp: []int64{-300, 300},
+	}, {
+		name:   "utilization",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 500, available: 500},
+			{total: 500, available: 100, utilization: 0.8},
+		},
+		minShare: 0.1,
+		exp:      []int64{-400, 400},
+	}, {
+		name:   "min share larger than an equal share",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 500, available: 500},
+			{total: 500, available: 100, utilization: 0.8},
+		},
+		minShare: 0.9,
+		exp:      []int64{0, 0},
+	}, {
+		name:   "profitability",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 500, available: 500, profitReturn: -0.01},
+			{total: 500, available: 500, profitReturn: 0.02},
+			{total: 0, available: 0, profitReturn: 0.01},
+		},
+		profitWeight: 1,
+		exp:          []int64{-500, 100, 400},
+	}, {
+		name:   "decrease limited to available balance",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 900, available: 100, utilization: 0.1},
+			{total: 100, available: 0, utilization: 1},
+		},
+		exp: []int64{-100, 100},
+	}, {
+		name:   "unallocated budget",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 100, available: 100},
+			{total: 100, available: 100},
+		},
+		exp: []int64{400, 400},
+	}, {
+		name:   "over budget",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 1000, available: 1000},
+			{total: 300, available: 300},
+		},
+		exp: []int64{-500, 200},
+	}, {
+		name:   "small moves skipped",
+		budget: 1000,
+		bots: []*portfolioBot{
+			{total: 505, available: 505},
+			{total: 495, available: 495},
+		},
+		exp: []int64{0, 0},
+	}}
+
+	for _, tt := range tests {
+		diffs := portfolioDiffs(tt.budget, tt.bots, tt.profitWeight, tt.minShare)
+		if !reflect.DeepEqual(diffs, tt.exp) {
+			t.Fatalf("%s: expected diffs %v, got %v", tt.name, tt.exp, diffs)
+		}
+	}
+}
+
+// tInventoryExchangeAdaptor applies inventory updates to its DEX balances.
+type tInventoryExchangeAdaptor struct {
+	*tExchangeAdaptor
+	profitRatio float64
+}
+
+func (t *tInventoryExchangeAdaptor) withPause(f func() error) error { return f() }
+
+func (t *tInventoryExchangeAdaptor) updateInventory(diffs *BotInventoryDiffs) {
+	for assetID, diff := range diffs.DEX {
+		bal := t.dexBalances[assetID]
+		if bal == nil {
+			bal = &BotBalance{}
+			t.dexBalances[assetID] = bal
+		}
+		bal.Available = uint64(int64(bal.Available) + diff)
+	}
+}
+
+func (t *tInventoryExchangeAdaptor) stats() *RunStats {
+	return &RunStats{ProfitLoss: &ProfitLoss{ProfitRatio: t.profitRatio}}
+}
+
+func TestReallocatePortfolio(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dcrBtc := MarketWithHost{Host: "host1", BaseID: 42, QuoteID: 0}
+	dcrEth := MarketWithHost{Host: "host1", BaseID: 42, QuoteID: 60}
+	ltcBtc := MarketWithHost{Host: "host1", BaseID: 2, QuoteID: 0}
+
+	tCore := newTCore()
+	tCore.setAssetBalances(map[uint32]uint64{42: 100e8, 0: 2e8, 60: 1e18, 2: 1e8})
+	m := &MarketMaker{
+		ctx:            ctx,
+		log:            tLogger,
+		core:           tCore,
+		defaultCfgPath: t.TempDir() + "/mm.json",
+		defaultCfg:     &MarketMakingConfig{},
+		runningBots:    make(map[MarketWithHost]*runningBot),
+	}
+
+	newBot := func(mkt MarketWithHost, dexBalances map[uint32]*BotBalance) *tInventoryExchangeAdaptor {
+		b := &tInventoryExchangeAdaptor{tExchangeAdaptor: &tExchangeAdaptor{
+			dexBalances: dexBalances,
+			cfg:         &BotConfig{Host: mkt.Host, BaseID: mkt.BaseID, QuoteID: mkt.QuoteID},
+		}}
+		m.runningBots[mkt] = &runningBot{bot: b}
+		return b
+	}
+	idleBot := newBot(dcrBtc, map[uint32]*BotBalance{42: {Available: 10e8}})
+	busyBot := newBot(dcrEth, map[uint32]*BotBalance{42: {Available: 2e8, Locked: 8e8}})
+	// Not included in the portfolio.
+	excludedBot := newBot(ltcBtc, map[uint32]*BotBalance{0: {Available: 1e6}})
+
+	if _, err := m.ReallocatePortfolio(); err == nil {
+		t.Fatalf("expected error without portfolio config")
+	}
+	for _, cfg := range []*PortfolioConfig{
+		{},
+		{Budgets: map[uint32]uint64{42: 20e8}, ProfitWeight: 2},
+		{Budgets: map[uint32]uint64{42: 20e8}, Cron: "* * *"},
+	} {
+		if err := m.UpdatePortfolioConfig(cfg); err == nil {
+			t.Fatalf("expected error for invalid config")
+		}
+	}
+
+	err := m.UpdatePortfolioConfig(&PortfolioConfig{
+		Budgets:  map[uint32]uint64{42: 20e8, 0: 1e8},
+		Markets:  []*MarketWithHost{&dcrBtc, &dcrEth},
+		MinShare: 0.1,
+		Cron:     "0 0 * * *",
+	})
+	if err != nil {
+		t.Fatalf("error saving portfolio config: %v", err)
+	}
+
+	// The cron expression doesn't match.
+	m.runPortfolioAllocator(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	if len(m.PortfolioReallocations()) != 0 {
+		t.Fatalf("portfolio reallocated at wrong time")
+	}
+
+	// Shares are 10% for the idle bot and 90% for the busy bot. The BTC
+	// budget is split between the bots on markets with BTC that are
+	// included.
+	m.runPortfolioAllocator(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	reallocs := m.PortfolioReallocations()
+	if len(reallocs) != 1 {
+		t.Fatalf("expected 1 reallocation, got %d", len(reallocs))
+	}
+	if idleBot.dexBalances[42].Available != 2e8 {
+		t.Fatalf("wrong idle bot balance %d", idleBot.dexBalances[42].Available)
+	}
+	if busyBot.dexBalances[42].Available != 10e8 {
+		t.Fatalf("wrong busy bot balance %d", busyBot.dexBalances[42].Available)
+	}
+	if idleBot.dexBalances[0].Available != 1e8 {
+		t.Fatalf("wrong idle bot BTC balance %d", idleBot.dexBalances[0].Available)
+	}
+	if excludedBot.dexBalances[0].Available != 1e6 {
+		t.Fatalf("excluded bot balance changed")
+	}
+	expBots := []*BotReallocation{
+		{MarketWithHost: dcrBtc, Diffs: map[uint32]int64{42: -8e8, 0: 1e8}},
+		{MarketWithHost: dcrEth, Diffs: map[uint32]int64{42: 8e8}},
+	}
+	if !reflect.DeepEqual(reallocs[0].Bots, expBots) {
+		t.Fatalf("wrong reallocation")
+	}
+
+	// The wallet has no DCR that isn't allocated to the bots, so the
+	// increase is only possible after the decrease is applied.
+	tCore.setAssetBalances(map[uint32]uint64{42: 12e8, 0: 2e8, 60: 1e18, 2: 1e8})
+	idleBot.profitRatio = 0.1
+	if err := m.UpdatePortfolioConfig(&PortfolioConfig{
+		Budgets:      map[uint32]uint64{42: 20e8},
+		Markets:      []*MarketWithHost{&dcrBtc, &dcrEth},
+		ProfitWeight: 1,
+	}); err != nil {
+		t.Fatalf("error updating portfolio config: %v", err)
+	}
+	realloc, err := m.ReallocatePortfolio()
+	if err != nil {
+		t.Fatalf("error reallocating portfolio: %v", err)
+	}
+	if len(realloc.Bots) != 2 {
+		t.Fatalf("expected 2 bots in reallocation, got %d", len(realloc.Bots))
+	}
+	// The busy bot can only release its 10 available DCR, which is enough
+	// for the idle bot.
+	if realloc.Bots[1].Diffs[42] != -10e8 || realloc.Bots[1].Error != "" {
+		t.Fatalf("wrong busy bot reallocation: %+v", realloc.Bots[1])
+	}
+	if realloc.Bots[0].Diffs[42] != 10e8 || realloc.Bots[0].Error != "" {
+		t.Fatalf("wrong idle bot reallocation: %+v", realloc.Bots[0])
+	}
+}
@@ -48,6 +48,43 @@ type OracleReport struct {
 	USDVol   float64 `json:"usdVol"`
 	BestBuy  float64 `json:"bestBuy"`
 	BestSell float64 `json:"bestSell"`
+	// Stamp is the unix time that the rates were fetched.
+	Stamp int64 `json:"stamp"`
+}
+
+// OracleSource is a source of market data for the price oracle.
+type OracleSource interface {
+	// Name is a unique name for the source.
+	Name() string
+	// OracleReports returns reports for the market from one or more
+	// exchanges. Rates are conventional. The USD volume of a report
+	// determines its weight in the oracle price, so reports without volume
+	// data are not used.
+	OracleReports(ctx context.Context, baseID, quoteID uint32, log dex.Logger) ([]*OracleReport, error)
+}
+
+var (
+	oracleSourcesMtx sync.RWMutex
+	oracleSources    = []OracleSource{&coinpaprikaSource{}}
+)
+
+// RegisterOracleSource adds a source for the price oracle. Sources must be
+// registered before the MarketMaker is created.
+func RegisterOracleSource(src OracleSource) {
+	oracleSourcesMtx.Lock()
+	defer oracleSourcesMtx.Unlock()
+	for _, s := range oracleSources {
+		if s.Name() == src.Name() {
+			panic(fmt.Sprintf("oracle source %q already registered", src.Name()))
+		}
+	}
+	oracleSources = append(oracleSources, src)
+}
+
+func registeredOracleSources() []OracleSource {
+	oracleSourcesMtx.RLock()
+	defer oracleSourcesMtx.RUnlock()
+	return append([]OracleSource(nil), oracleSources...)
 }
 
 // stampedPrice is used for caching price data that can expire.
@@ -71,8 +108,9 @@ type syncedMarket struct {
 }
 
 type priceOracle struct {
-	ctx context.Context
-	log dex.Logger
+	ctx     context.Context
+	log     dex.Logger
+	sources []OracleSource
 
 	syncedMarketsMtx sync.RWMutex
 	syncedMarkets    map[marketPair]*syncedMarket
@@ -87,6 +125,7 @@ func newPriceOracle(ctx context.Context, log dex.Logger) *priceOracle {
 		cachedPrices:  make(map[marketPair]*cachedPrice),
 		syncedMarkets: make(map[marketPair]*syncedMarket),
 		log:           log,
+		sources:       registeredOracleSources(),
 	}
 
 	go func() {
@@ -104,6 +143,9 @@ func newPriceOracle(ctx context.Context, log dex.Logger) *priceOracle {
 
 type oracle interface {
 	getMarketPrice(baseID, quoteID uint32) float64
+	// getOracleReports returns the reports that the market price is
+	// calculated from.
+	getOracleReports(baseID, quoteID uint32) []*OracleReport
 }
 
 var _ oracle = (*priceOracle)(nil)
@@ -119,6 +161,16 @@ func (o *priceOracle) getMarketPrice(baseID, quoteID uint32) float64 {
 	return price
 }
 
+// getOracleReports returns the oracle reports for the specified base/quote
+// pair. The reports can be combined using a custom OracleConfig.
+func (o *priceOracle) getOracleReports(baseID, quoteID uint32) []*OracleReport {
+	_, oracles, err := o.getOracleInfo(baseID, quoteID)
+	if err != nil {
+		return nil
+	}
+	return oracles
+}
+
 func (o *priceOracle) getCachedPrice(baseID, quoteID uint32) *cachedPrice {
 	o.cachedPricesMtx.RLock()
 	defer o.cachedPricesMtx.RUnlock()
@@ -216,7 +268,7 @@ func (o *priceOracle) stopAutoSyncingMarket(baseID, quoteID uint32) {
 
 func (o *priceOracle) syncMarket(baseID, quoteID uint32) (float64, []*OracleReport, error) {
 	mkt := marketPair{baseID, quoteID}
-	price, oracles, err := fetchMarketPrice(o.ctx, baseID, quoteID, o.log)
+	price, oracles, err := fetchMarketPrice(o.ctx, o.sources, baseID, quoteID, o.log)
 	if err != nil {
 		return 0, nil, fmt.Errorf("error fetching market price for %s: %v", mkt, err)
 	}
@@ -254,52 +306,36 @@ func coinpapAsset(assetID uint32) (*fiatrates.CoinpaprikaAsset, error) {
 	}, nil
 }
 
-func fetchMarketPrice(ctx context.Context, baseID, quoteID uint32, log dex.Logger) (float64, []*OracleReport, error) {
-	b, err := coinpapAsset(baseID)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	q, err := coinpapAsset(quoteID)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	oracles, err := oracleMarketReport(ctx, b, q, log)
-	if err != nil {
-		return 0, nil, err
-	}
-
-	price, usdVolume, err := oracleAverage(oracles, log)
-	if err != nil {
-		return 0, nil, err
-	}
-	if usdVolume < minimumUSDVolumeForOraclesAvg {
-		log.Meter("oracle_low_volume_"+b.Symbol+"_"+q.Symbol, 12*time.Hour).Infof(
-			"Rejecting oracle average price for %s. not enough volume (%.2f USD < %.2f)",
-			b.Symbol+"_"+q.Symbol, usdVolume, float32(minimumUSDVolumeForOraclesAvg),
-		)
-		return 0, oracles, nil
-	}
-	return price, oracles, err
-}
-
-func oracleAverage(mkts []*OracleReport, log dex.Logger) (rate, usdVolume float64, _ error) {
-	var weightedSum float64
-	var n int
-	for _, mkt := range mkts {
-		n++
-		weightedSum += mkt.USDVol * (mkt.BestBuy + mkt.BestSell) / 2
-		usdVolume += mkt.USDVol
+// fetchMarketPrice fetches the oracle reports for a market from all of the
+// sources and calculates the volume weighted market rate. An error is only
+// returned if all of the sources fail.
+func fetchMarketPrice(ctx context.Context, sources []OracleSource, baseID, quoteID uint32, log dex.Logger) (float64, []*OracleReport, error) {
+	mkt := marketPair{baseID, quoteID}
+	var oracles []*OracleReport
+	var errs []error
+	for _, src := range sources {
+		reports, err := src.OracleReports(ctx, baseID, quoteID, log)
+		if err != nil {
+			log.Meter("oracle_source_"+src.Name()+"_"+mkt.String(), time.Hour).Errorf(
+				"Error fetching %s oracle reports from %s: %v", mkt, src.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+			continue
+		}
+		now := time.Now().Unix()
+		for _, r := range reports {
+			if r.Stamp == 0 {
+				r.Stamp = now
+			}
+		}
+		oracles = append(oracles, reports...)
 	}
-	if usdVolume == 0 {
-		return 0, 0, nil // No markets have data. OK.
+	if len(sources) > 0 && len(errs) == len(sources) {
+		return 0, nil, errors.Join(errs...)
 	}
 
-	rate = weightedSum / usdVolume
-	// TODO: Require a minimum USD volume?
-	log.Tracef("marketAveragedPrice: price calculated from %d markets: rate = %f, USD volume = %f", n, rate, usdVolume)
-	return rate, usdVolume, nil
+	price := aggregateOraclePrice(mkt, oracles, 0, nil, time.Now(), log)
+	log.Tracef("Oracle price for %s calculated from %d reports: rate = %f", mkt, len(oracles), price)
+	return price, oracles, nil
 }
 
 func getRates(ctx context.Context, url string, thing any) (err error) {
@@ -346,6 +382,28 @@ func spread(ctx context.Context, addr string, baseSymbol, quoteSymbol string, lo
 	return sell, buy
 }
 
+// coinpaprikaSource is an OracleSource that finds markets on known exchanges
+// using coinpaprika, and fetches the spreads from the exchanges' public APIs.
+type coinpaprikaSource struct{}
+
+var _ OracleSource = (*coinpaprikaSource)(nil)
+
+func (*coinpaprikaSource) Name() string {
+	return "coinpaprika"
+}
+
+func (*coinpaprikaSource) OracleReports(ctx context.Context, baseID, quoteID uint32, log dex.Logger) ([]*OracleReport, error) {
+	b, err := coinpapAsset(baseID)
+	if err != nil {
+		return nil, err
+	}
+	q, err := coinpapAsset(quoteID)
+	if err != nil {
+		return nil, err
+	}
+	return oracleMarketReport(ctx, b, q, log)
+}
+
 // oracleMarketReport fetches oracle price, spread, and volume data for known
 // exchanges for a market. This is done by fetching the market data from
 // coinpaprika, looking for known exchanges in the results, then pulling the
@@ -426,6 +484,7 @@ func oracleMarketReport(ctx context.Context, b, q *fiatrates.CoinpaprikaAsset, l
 			Host:     host,
 			BestBuy:  buy,
 			BestSell: sell,
+			Stamp:    time.Now().Unix(),
 		}
 		oracles = append(oracles, oracle)
 		usdQuote, found := mkt.Quotes["USD"]
@@ -463,6 +522,9 @@ var spreaders = map[string]Spreader{
 	"bittrex.com":  fetchBittrexSpread,
 	"hitbtc.com":   fetchHitBTCSpread,
 	"exmo.com":     fetchEXMOSpread,
+	"kraken.com":   fetchKrakenSpread,
+	"kucoin.com":   fetchKuCoinSpread,
+	"okx.com":      fetchOKXSpread,
 }
 
 var binanceGlobalIs451, binanceUSIs451 atomic.Bool
@@ -588,3 +650,87 @@ func fetchEXMOSpread(ctx context.Context, baseSymbol, quoteSymbol string, _ dex.
 
 	return mkt.AskTop, mkt.BidTop, nil
 }
+
+func fetchKrakenSpread(ctx context.Context, baseSymbol, quoteSymbol string, _ dex.Logger) (sell, buy float64, err error) {
+	slugSymbol := func(symbol string) string {
+		switch symbol {
+		case "btc":
+			return "XBT"
+		}
+		return strings.ToUpper(symbol)
+	}
+	slug := slugSymbol(baseSymbol) + slugSymbol(quoteSymbol)
+	url := fmt.Sprintf("https://api.kraken.com/0/public/Ticker?pair=%s", slug)
+
+	var resp struct {
+		Error  []string `json:"error"`
+		Result map[string]*struct {
+			Ask []json.Number `json:"a"`
+			Bid []json.Number `json:"b"`
+		} `json:"result"`
+	}
+	if err := getRates(ctx, url, &resp); err != nil {
+		return 0, 0, err
+	}
+	if len(resp.Error) > 0 {
+		return 0, 0, fmt.Errorf("kraken error: %s", strings.Join(resp.Error, ", "))
+	}
+	// The result is keyed by Kraken's name for the pair, which may differ
+	// from the requested pair, e.g. XXBTZUSD.
+	if len(resp.Result) != 1 {
+		return 0, 0, fmt.Errorf("expected 1 pair in response, got %d", len(resp.Result))
+	}
+	for _, mkt := range resp.Result {
+		if mkt == nil || len(mkt.Ask) < 1 || len(mkt.Bid) < 1 {
+			return 0, 0, errors.New("no ask or bid in response")
+		}
+		if sell, err = mkt.Ask[0].Float64(); err != nil {
+			return 0, 0, fmt.Errorf("failed to decode ask price %q", mkt.Ask[0])
+		}
+		if buy, err = mkt.Bid[0].Float64(); err != nil {
+			return 0, 0, fmt.Errorf("failed to decode bid price %q", mkt.Bid[0])
+		}
+	}
+	return sell, buy, nil
+}
+
+func fetchKuCoinSpread(ctx context.Context, baseSymbol, quoteSymbol string, _ dex.Logger) (sell, buy float64, err error) {
+	slug := fmt.Sprintf("%s-%s", strings.ToUpper(baseSymbol), strings.ToUpper(quoteSymbol))
+	url := fmt.Sprintf("https://api.kucoin.com/api/v1/market/orderbook/level1?symbol=%s", slug)
+
+	var resp struct {
+		Code string `json:"code"`
+		Data *struct {
+			BestAsk float64 `json:"bestAsk,string"`
+			BestBid float64 `json:"bestBid,string"`
+		} `json:"data"`
+	}
+	if err := getRates(ctx, url, &resp); err != nil {
+		return 0, 0, err
+	}
+	if resp.Data == nil {
+		return 0, 0, fmt.Errorf("no data in response. code = %s", resp.Code)
+	}
+	return resp.Data.BestAsk, resp.Data.BestBid, nil
+}
+
+func fetchOKXSpread(ctx context.Context, baseSymbol, quoteSymbol string, _ dex.Logger) (sell, buy float64, err error) {
+	slug := fmt.Sprintf("%s-%s", strings.ToUpper(baseSymbol), strings.ToUpper(quoteSymbol))
+	url := fmt.Sprintf("https://www.okx.com/api/v5/market/ticker?instId=%s", slug)
+
+	var resp struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []*struct {
+			AskPx float64 `json:"askPx,string"`
+			BidPx float64 `json:"bidPx,string"`
+		} `json:"data"`
+	}
+	if err := getRates(ctx, url, &resp); err != nil {
+		return 0, 0, err
+	}
+	if len(resp.Data) < 1 {
+		return 0, 0, fmt.Errorf("no data in response. code = %s, msg = %s", resp.Code, resp.Msg)
+	}
+	return resp.Data[0].AskPx, resp.Data[0].BidPx, nil
+}
@@ -117,3 +117,15 @@ func TestFetchHitBTCSpread(t *testing.T) {
 func TestFetchEXMOSpread(t *testing.T) {
 	testSpreader(t, fetchEXMOSpread, "dcr", "btc")
 }
+
+func TestFetchKrakenSpread(t *testing.T) {
+	testSpreader(t, fetchKrakenSpread, "btc", "usd")
+}
+
+func TestFetchKuCoinSpread(t *testing.T) {
+	testSpreader(t, fetchKuCoinSpread, "dcr", "btc")
+}
+
+func TestFetchOKXSpread(t *testing.T) {
+	testSpreader(t, fetchOKXSpread, "btc", "usdt")
+}
@@ -0,0 +1,341 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm/libxc"
+	"decred.org/dcrdex/dex"
+)
+
+// mktDataFlushInterval is how often buffered market data is written to disk.
+const mktDataFlushInterval = time.Second
+
+// RecorderMarket is a market whose order books are recorded by the market
+// data recorder.
+type RecorderMarket struct {
+	MarketWithHost
+	// CEXName is the name of a CEX whose order book for the same market is
+	// recorded alongside the DEX order book. Optional.
+	CEXName string `json:"cexName,omitempty"`
+}
+
+// RecordedMarketStatus is the status of the recording of a market.
+type RecordedMarketStatus struct {
+	RecorderMarket
+	Path    string `json:"path"`
+	Records uint64 `json:"records"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RecorderStatus is the status of the market data recorder.
+type RecorderStatus struct {
+	Running bool                    `json:"running"`
+	Dir     string                  `json:"dir,omitempty"`
+	Markets []*RecordedMarketStatus `json:"markets,omitempty"`
+}
+
+// marketRecorder records the DEX order book, and optionally a CEX order book,
+// of a market to a market data file.
+type marketRecorder struct {
+	mkt  *RecorderMarket
+	path string
+	log  dex.Logger
+	core clientCore
+	cex  libxc.CEX
+
+	w          *mktDataWriter
+	dexFeed    core.BookFeed
+	cexUpdates <-chan *libxc.BookUpdate
+	cexUnsub   func()
+
+	records atomic.Uint64
+	err     atomic.Value // string
+}
+
+// mktDataFileName generates a file name for the market's data file.
+func mktDataFileName(mkt *MarketWithHost) string {
+	host := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, mkt.Host)
+	return fmt.Sprintf("%s_%d_%d.mdr", host, mkt.BaseID, mkt.QuoteID)
+}
+
+// start opens the data file and subscribes to the order books. The DEX book
+// snapshot is written before start returns.
+func (r *marketRecorder) start(ctx context.Context) (err error) {
+	r.w, err = openMktDataWriter(r.path, &MarketDataHeader{
+		Host:    r.mkt.Host,
+		BaseID:  r.mkt.BaseID,
+		QuoteID: r.mkt.QuoteID,
+		CEXName: r.mkt.CEXName,
+	})
+	if err != nil {
+		return fmt.Errorf("error opening market data file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			r.stop()
+		}
+	}()
+
+	book, feed, err := r.core.SyncBook(r.mkt.Host, r.mkt.BaseID, r.mkt.QuoteID)
+	if err != nil {
+		return fmt.Errorf("error syncing book: %w", err)
+	}
+	r.dexFeed = feed
+	if err := r.write(dexSnapshotFromBook(time.Now().UnixMilli(), book)); err != nil {
+		return err
+	}
+
+	if cex := r.cex; cex != nil {
+		// r.cex is only set once subscribed, so that stop knows to
+		// unsubscribe.
+		r.cex = nil
+		subscriber, is := cex.(libxc.BookSubscriber)
+		if !is {
+			return fmt.Errorf("%s does not support book update subscriptions", r.mkt.CEXName)
+		}
+		if err := cex.SubscribeMarket(ctx, r.mkt.BaseID, r.mkt.QuoteID); err != nil {
+			return fmt.Errorf("error subscribing to %s market: %w", r.mkt.CEXName, err)
+		}
+		r.cex = cex
+		r.cexUpdates, r.cexUnsub, err = subscriber.SubscribeBookUpdates(r.mkt.BaseID, r.mkt.QuoteID)
+		if err != nil {
+			return fmt.Errorf("error subscribing to %s book updates: %w", r.mkt.CEXName, err)
+		}
+	}
+
+	return nil
+}
+
+// stop unsubscribes from the order books and closes the data file.
+func (r *marketRecorder) stop() {
+	if r.cexUnsub != nil {
+		r.cexUnsub()
+	}
+	if r.cex != nil {
+		if err := r.cex.UnsubscribeMarket(r.mkt.BaseID, r.mkt.QuoteID); err != nil {
+			r.log.Errorf("Error unsubscribing from %s market: %v", r.mkt.CEXName, err)
+		}
+	}
+	if r.dexFeed != nil {
+		r.dexFeed.Close()
+	}
+	if err := r.w.close(); err != nil {
+		r.log.Errorf("Error closing market data file: %v", err)
+	}
+}
+
+func (r *marketRecorder) write(rec *mdRecord) error {
+	if err := r.w.write(rec); err != nil {
+		return fmt.Errorf("error writing market data: %w", err)
+	}
+	r.records.Add(1)
+	return nil
+}
+
+// run records updates until the context is canceled or a write fails.
+func (r *marketRecorder) run(ctx context.Context) {
+	defer r.stop()
+
+	flushTicker := time.NewTicker(mktDataFlushInterval)
+	defer flushTicker.Stop()
+
+	fail := func(err error) {
+		r.log.Errorf("Market data recording stopped: %v", err)
+		r.err.Store(err.Error())
+	}
+
+	for {
+		select {
+		case u, ok := <-r.dexFeed.Next():
+			if !ok {
+				fail(errors.New("DEX book feed closed"))
+				return
+			}
+			rec, err := dexBookRecord(time.Now().UnixMilli(), u)
+			if err != nil {
+				r.log.Errorf("Error translating %s book update: %v", u.Action, err)
+				continue
+			}
+			if rec == nil {
+				continue
+			}
+			if err := r.write(rec); err != nil {
+				fail(err)
+				return
+			}
+		case u, ok := <-r.cexUpdates:
+			if !ok {
+				r.log.Errorf("%s book update feed closed", r.mkt.CEXName)
+				r.err.Store(fmt.Sprintf("%s book update feed closed", r.mkt.CEXName))
+				r.cexUpdates = nil
+				continue
+			}
+			if err := r.write(cexBookRecord(time.Now().UnixMilli(), u)); err != nil {
+				fail(err)
+				return
+			}
+		case <-flushTicker.C:
+			if err := r.w.flush(); err != nil {
+				fail(fmt.Errorf("error flushing market data: %w", err))
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *marketRecorder) status() *RecordedMarketStatus {
+	errStr, _ := r.err.Load().(string)
+	return &RecordedMarketStatus{
+		RecorderMarket: *r.mkt,
+		Path:           r.path,
+		Records:        r.records.Load(),
+		Error:          errStr,
+	}
+}
+
+// mktDataRecorder is a running market data recorder.
+type mktDataRecorder struct {
+	dir     string
+	markets []*marketRecorder
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// defaultMktDataDir is the directory where market data is recorded if no
+// other directory is specified.
+func (m *MarketMaker) defaultMktDataDir() string {
+	return filepath.Join(filepath.Dir(m.eventLogDBPath), "mktdata")
+}
+
+// StartRecording starts recording the order books of the specified markets.
+// Each market is recorded to its own file in dir, which defaults to a mktdata
+// directory next to the event log database. Recording continues until
+// StopRecording is called or the MarketMaker is shut down. The files can be
+// read with OpenMarketData.
+func (m *MarketMaker) StartRecording(mkts []*RecorderMarket, dir string) (err error) {
+	if len(mkts) == 0 {
+		return errors.New("no markets specified")
+	}
+	if m.ctx == nil {
+		return errors.New("market maker not connected")
+	}
+
+	m.recorderMtx.Lock()
+	defer m.recorderMtx.Unlock()
+	if m.recorder != nil {
+		return errors.New("already recording")
+	}
+
+	if dir == "" {
+		dir = m.defaultMktDataDir()
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("error creating market data directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	rec := &mktDataRecorder{
+		dir:    dir,
+		cancel: cancel,
+	}
+
+	var started []*marketRecorder
+	defer func() {
+		if err != nil {
+			cancel()
+			for _, r := range started {
+				r.stop()
+			}
+		}
+	}()
+
+	seen := make(map[MarketWithHost]bool, len(mkts))
+	for _, mkt := range mkts {
+		if seen[mkt.MarketWithHost] {
+			return fmt.Errorf("duplicate market %s", mkt.MarketWithHost)
+		}
+		seen[mkt.MarketWithHost] = true
+
+		r := &marketRecorder{
+			mkt:  mkt,
+			path: filepath.Join(dir, mktDataFileName(&mkt.MarketWithHost)),
+			log:  m.log.SubLogger(fmt.Sprintf("REC-%s", mkt.MarketWithHost)),
+			core: m.core,
+		}
+		if mkt.CEXName != "" {
+			cex, err := m.connectedCEX(mkt.CEXName)
+			if err != nil {
+				return err
+			}
+			r.cex = cex.CEX
+		}
+		if err := r.start(ctx); err != nil {
+			return fmt.Errorf("error starting recorder for %s: %w", mkt.MarketWithHost, err)
+		}
+		started = append(started, r)
+	}
+
+	rec.markets = started
+	for _, r := range started {
+		rec.wg.Add(1)
+		go func(r *marketRecorder) {
+			defer rec.wg.Done()
+			r.run(ctx)
+		}(r)
+	}
+	m.recorder = rec
+	m.log.Infof("Recording market data for %d markets to %s", len(started), dir)
+
+	return nil
+}
+
+// StopRecording stops the market data recorder.
+func (m *MarketMaker) StopRecording() error {
+	m.recorderMtx.Lock()
+	defer m.recorderMtx.Unlock()
+	if m.recorder == nil {
+		return errors.New("not recording")
+	}
+	m.recorder.cancel()
+	m.recorder.wg.Wait()
+	m.recorder = nil
+	return nil
+}
+
+// RecorderStatus returns the status of the market data recorder.
+func (m *MarketMaker) RecorderStatus() *RecorderStatus {
+	m.recorderMtx.Lock()
+	defer m.recorderMtx.Unlock()
+	if m.recorder == nil {
+		return &RecorderStatus{}
+	}
+	status := &RecorderStatus{
+		Running: true,
+		Dir:     m.recorder.dir,
+		Markets: make([]*RecordedMarketStatus, 0, len(m.recorder.markets)),
+	}
+	for _, r := range m.recorder.markets {
+		status.Markets = append(status.Markets, r.status())
+	}
+	return status
+}
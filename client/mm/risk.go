@@ -0,0 +1,235 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex/order"
+)
+
+// RiskLimits are limits on the losses and risky states that a bot is allowed
+// to incur during a run. If any limit is exceeded, the bot cancels all of its
+// orders and stops. A zero value disables a limit.
+type RiskLimits struct {
+	// MaxDrawdownUSD is the maximum decline, in USD, of the run's profit
+	// from the highest profit reached during the run. The profit is
+	// calculated using the current fiat rates.
+	MaxDrawdownUSD float64 `json:"maxDrawdownUSD,omitempty"`
+	// MaxInventoryImbalance is the maximum imbalance between the USD values
+	// of the bot's base and quote asset holdings, across the DEX and CEX. The
+	// imbalance is |base - quote| / (base + quote), so 0 is perfectly
+	// balanced and 1 means that all of the funds are in one asset.
+	// Range: 0 < MaxInventoryImbalance < 1.
+	MaxInventoryImbalance float64 `json:"maxInventoryImbalance,omitempty"`
+	// MaxConsecutiveFailedSwaps is the number of consecutive failed matches
+	// that will stop the bot. A match fails if it is refunded, or if it is
+	// revoked before the bot's swap was redeemed.
+	MaxConsecutiveFailedSwaps uint32 `json:"maxConsecutiveFailedSwaps,omitempty"`
+	// MaxPendingWithdrawals is the maximum number of CEX withdrawals that can
+	// be pending at once.
+	MaxPendingWithdrawals uint32 `json:"maxPendingWithdrawals,omitempty"`
+}
+
+func (r *RiskLimits) copy() *RiskLimits {
+	limits := *r
+	return &limits
+}
+
+func (r *RiskLimits) validate() error {
+	if r.MaxDrawdownUSD < 0 {
+		return fmt.Errorf("max drawdown must be non-negative, but got %v", r.MaxDrawdownUSD)
+	}
+	if r.MaxInventoryImbalance < 0 || r.MaxInventoryImbalance >= 1 {
+		return fmt.Errorf("max inventory imbalance must be 0 <= x < 1, but got %v", r.MaxInventoryImbalance)
+	}
+	return nil
+}
+
+// RiskLimitType identifies a risk limit.
+type RiskLimitType string
+
+const (
+	RiskLimitDrawdown           RiskLimitType = "drawdown"
+	RiskLimitInventoryImbalance RiskLimitType = "inventoryImbalance"
+	RiskLimitFailedSwaps        RiskLimitType = "failedSwaps"
+	RiskLimitPendingWithdrawals RiskLimitType = "pendingWithdrawals"
+)
+
+// RiskLimitEvent is recorded in the event log when a risk limit is exceeded
+// and the bot is stopped.
+type RiskLimitEvent struct {
+	Limit     RiskLimitType `json:"limit"`
+	Value     float64       `json:"value"`
+	Threshold float64       `json:"threshold"`
+	Details   string        `json:"details"`
+}
+
+// riskMonitor tracks the state needed to check a bot's risk limits.
+type riskMonitor struct {
+	tripped atomic.Bool
+
+	mtx                    sync.Mutex
+	peakProfit             float64
+	consecutiveFailedSwaps uint32
+	// matchOutcomes are the matches that have already been counted as
+	// either a failure (true) or success (false).
+	matchOutcomes map[order.MatchID]bool
+}
+
+// matchFailed checks whether a match has either failed or succeeded. final is
+// false if the match is still in progress.
+func matchFailed(m *core.Match) (failed, final bool) {
+	switch {
+	case m.Refund != nil:
+		return true, true
+	case m.Redeem != nil:
+		return false, true
+	case m.Revoked && !m.Active:
+		return true, true
+	}
+	return false, false
+}
+
+// inventoryImbalance is |base - quote| / (base + quote), where base and quote
+// are the USD values of the bot's base and quote holdings.
+func inventoryImbalance(stats *RunStats, baseID, quoteID uint32, fiatRates map[uint32]float64) (float64, bool) {
+	total := func(assetID uint32) uint64 {
+		var t uint64
+		for _, bals := range []map[uint32]*BotBalance{stats.DEXBalances, stats.CEXBalances} {
+			if bal := bals[assetID]; bal != nil {
+				t += bal.Available + bal.Locked + bal.Pending + bal.Reserved
+			}
+		}
+		return t
+	}
+	baseUSD := NewAmount(baseID, int64(total(baseID)), fiatRates[baseID]).USD
+	quoteUSD := NewAmount(quoteID, int64(total(quoteID)), fiatRates[quoteID]).USD
+	if fiatRates[baseID] == 0 || fiatRates[quoteID] == 0 || baseUSD+quoteUSD == 0 {
+		return 0, false
+	}
+	return math.Abs(baseUSD-quoteUSD) / (baseUSD + quoteUSD), true
+}
+
+// checkRiskLimits checks the limits that depend on the bot's run stats.
+func (u *unifiedExchangeAdaptor) checkRiskLimits(stats *RunStats) {
+	limits := u.botCfg().RiskLimits
+	if limits == nil || stats == nil || u.risk.tripped.Load() {
+		return
+	}
+
+	if limits.MaxDrawdownUSD > 0 && stats.ProfitLoss != nil {
+		u.risk.mtx.Lock()
+		if stats.ProfitLoss.Profit > u.risk.peakProfit {
+			u.risk.peakProfit = stats.ProfitLoss.Profit
+		}
+		peak := u.risk.peakProfit
+		u.risk.mtx.Unlock()
+		if drawdown := peak - stats.ProfitLoss.Profit; drawdown > limits.MaxDrawdownUSD {
+			u.tripRiskLimit(&RiskLimitEvent{
+				Limit:     RiskLimitDrawdown,
+				Value:     drawdown,
+				Threshold: limits.MaxDrawdownUSD,
+				Details:   fmt.Sprintf("Profit fell by $%.2f from its high of $%.2f, exceeding the limit of $%.2f", drawdown, peak, limits.MaxDrawdownUSD),
+			})
+			return
+		}
+	}
+
+	if limits.MaxInventoryImbalance > 0 {
+		fiatRates := u.fiatRates.Load().(map[uint32]float64)
+		if imbalance, ok := inventoryImbalance(stats, u.baseID, u.quoteID, fiatRates); ok && imbalance > limits.MaxInventoryImbalance {
+			u.tripRiskLimit(&RiskLimitEvent{
+				Limit:     RiskLimitInventoryImbalance,
+				Value:     imbalance,
+				Threshold: limits.MaxInventoryImbalance,
+				Details:   fmt.Sprintf("Inventory imbalance of %.2f exceeds the limit of %.2f", imbalance, limits.MaxInventoryImbalance),
+			})
+			return
+		}
+	}
+
+	if limits.MaxPendingWithdrawals > 0 && stats.PendingWithdrawals > int(limits.MaxPendingWithdrawals) {
+		u.tripRiskLimit(&RiskLimitEvent{
+			Limit:     RiskLimitPendingWithdrawals,
+			Value:     float64(stats.PendingWithdrawals),
+			Threshold: float64(limits.MaxPendingWithdrawals),
+			Details:   fmt.Sprintf("%d pending CEX withdrawals exceeds the limit of %d", stats.PendingWithdrawals, limits.MaxPendingWithdrawals),
+		})
+	}
+}
+
+// recordSwapOutcomes counts the failed and successful matches of one of the
+// bot's orders, and stops the bot if the number of consecutive failed matches
+// reaches the limit.
+func (u *unifiedExchangeAdaptor) recordSwapOutcomes(o *core.Order) {
+	limits := u.botCfg().RiskLimits
+	if limits == nil || limits.MaxConsecutiveFailedSwaps == 0 {
+		return
+	}
+
+	u.risk.mtx.Lock()
+	if u.risk.matchOutcomes == nil {
+		u.risk.matchOutcomes = make(map[order.MatchID]bool)
+	}
+	for _, m := range o.Matches {
+		if m.IsCancel {
+			continue
+		}
+		var mid order.MatchID
+		copy(mid[:], m.MatchID)
+		if _, found := u.risk.matchOutcomes[mid]; found {
+			continue
+		}
+		failed, final := matchFailed(m)
+		if !final {
+			continue
+		}
+		u.risk.matchOutcomes[mid] = failed
+		if failed {
+			u.risk.consecutiveFailedSwaps++
+		} else {
+			u.risk.consecutiveFailedSwaps = 0
+		}
+	}
+	failures := u.risk.consecutiveFailedSwaps
+	u.risk.mtx.Unlock()
+
+	if failures >= limits.MaxConsecutiveFailedSwaps {
+		u.tripRiskLimit(&RiskLimitEvent{
+			Limit:     RiskLimitFailedSwaps,
+			Value:     float64(failures),
+			Threshold: float64(limits.MaxConsecutiveFailedSwaps),
+			Details:   fmt.Sprintf("%d consecutive failed swaps reached the limit of %d", failures, limits.MaxConsecutiveFailedSwaps),
+		})
+	}
+}
+
+// tripRiskLimit records the event in the event log, sends a notification, and
+// stops the bot. Stopping the bot cancels all of its orders.
+func (u *unifiedExchangeAdaptor) tripRiskLimit(e *RiskLimitEvent) {
+	if !u.risk.tripped.CompareAndSwap(false, true) {
+		return
+	}
+
+	u.log.Errorf("Stopping bot: %s risk limit exceeded: %s", e.Limit, e.Details)
+
+	event := &MarketMakingEvent{
+		ID:             u.eventLogID.Add(1),
+		TimeStamp:      time.Now().Unix(),
+		RiskLimitEvent: e,
+	}
+	u.eventLogDB.storeEvent(u.startTime.Load(), u.mwh, event, u.balanceState())
+	u.notifyEvent(event)
+	u.clientCore.Broadcast(newRiskLimitNote(u.host, u.baseID, u.quoteID, u.name, e))
+
+	if u.kill != nil {
+		u.kill()
+	}
+}
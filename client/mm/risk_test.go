@@ -0,0 +1,164 @@
+//go:build !harness && !botlive
+
+package mm
+
+import (
+	"sync"
+	"testing"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex/order"
+)
+
+// tNoteCore is a tCore that records broadcast notifications.
+type tNoteCore struct {
+	*tCore
+	notesMtx sync.Mutex
+	notes    []core.Notification
+}
+
+func (c *tNoteCore) Broadcast(n core.Notification) {
+	c.notesMtx.Lock()
+	c.notes = append(c.notes, n)
+	c.notesMtx.Unlock()
+}
+
+func TestRiskLimits(t *testing.T) {
+	const baseID, quoteID = 42, 0
+
+	newAdaptor := func(limits *RiskLimits) (*unifiedExchangeAdaptor, *tNoteCore, *bool) {
+		u := mustParseAdaptorFromMarket(&core.Market{
+			LotSize:  1e8,
+			BaseID:   baseID,
+			QuoteID:  quoteID,
+			RateStep: 1e2,
+		})
+		c := &tNoteCore{tCore: u.clientCore.(*tCore)}
+		u.clientCore = c
+		u.fiatRates.Store(map[uint32]float64{baseID: 20, quoteID: 50_000})
+		u.botCfgV.Store(&BotConfig{
+			BaseID:     baseID,
+			QuoteID:    quoteID,
+			RiskLimits: limits,
+		})
+		var killed bool
+		u.kill = func() { killed = true }
+		return u, c, &killed
+	}
+
+	checkTripped := func(t *testing.T, u *unifiedExchangeAdaptor, c *tNoteCore, killed bool, exp RiskLimitType) {
+		t.Helper()
+		if !killed {
+			t.Fatalf("bot not stopped")
+		}
+		events := u.eventLogDB.(*tEventLogDB).storedEvents
+		if len(events) != 1 || events[0].RiskLimitEvent == nil {
+			t.Fatalf("expected 1 risk limit event, got %d events", len(events))
+		}
+		if events[0].RiskLimitEvent.Limit != exp {
+			t.Fatalf("expected %s limit, got %s", exp, events[0].RiskLimitEvent.Limit)
+		}
+		var found bool
+		for _, n := range c.notes {
+			if note, is := n.(*riskLimitNotification); is {
+				found = true
+				if note.Event.Limit != exp {
+					t.Fatalf("wrong limit in notification. expected %s, got %s", exp, note.Event.Limit)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("no risk limit notification")
+		}
+	}
+
+	checkNotTripped := func(t *testing.T, u *unifiedExchangeAdaptor, killed bool) {
+		t.Helper()
+		if killed {
+			t.Fatalf("bot stopped")
+		}
+		if n := len(u.eventLogDB.(*tEventLogDB).storedEvents); n != 0 {
+			t.Fatalf("expected no events, got %d", n)
+		}
+	}
+
+	t.Run("drawdown", func(t *testing.T) {
+		u, c, killed := newAdaptor(&RiskLimits{MaxDrawdownUSD: 6})
+		for _, profit := range []float64{-5, 10, 5} {
+			u.checkRiskLimits(&RunStats{ProfitLoss: &ProfitLoss{Profit: profit}})
+			checkNotTripped(t, u, *killed)
+		}
+		u.checkRiskLimits(&RunStats{ProfitLoss: &ProfitLoss{Profit: 3}})
+		checkTripped(t, u, c, *killed, RiskLimitDrawdown)
+		// Only tripped once.
+		u.checkRiskLimits(&RunStats{ProfitLoss: &ProfitLoss{Profit: 1}})
+		if n := len(u.eventLogDB.(*tEventLogDB).storedEvents); n != 1 {
+			t.Fatalf("expected 1 event, got %d", n)
+		}
+	})
+
+	t.Run("inventory imbalance", func(t *testing.T) {
+		u, c, killed := newAdaptor(&RiskLimits{MaxInventoryImbalance: 0.4})
+		// $200 of each.
+		u.checkRiskLimits(&RunStats{
+			DEXBalances: map[uint32]*BotBalance{baseID: {Available: 5e8}, quoteID: {Available: 2e5}},
+			CEXBalances: map[uint32]*BotBalance{baseID: {Locked: 5e8}, quoteID: {Available: 2e5}},
+		})
+		checkNotTripped(t, u, *killed)
+		// $600 base, $200 quote.
+		u.checkRiskLimits(&RunStats{
+			DEXBalances: map[uint32]*BotBalance{baseID: {Available: 25e8}, quoteID: {Available: 2e5}},
+			CEXBalances: map[uint32]*BotBalance{baseID: {Locked: 5e8}, quoteID: {Available: 2e5}},
+		})
+		checkTripped(t, u, c, *killed, RiskLimitInventoryImbalance)
+	})
+
+	t.Run("inventory imbalance without fiat rates", func(t *testing.T) {
+		u, _, killed := newAdaptor(&RiskLimits{MaxInventoryImbalance: 0.4})
+		u.fiatRates.Store(map[uint32]float64{})
+		u.checkRiskLimits(&RunStats{
+			DEXBalances: map[uint32]*BotBalance{baseID: {Available: 25e8}},
+		})
+		checkNotTripped(t, u, *killed)
+	})
+
+	t.Run("pending withdrawals", func(t *testing.T) {
+		u, c, killed := newAdaptor(&RiskLimits{MaxPendingWithdrawals: 2})
+		u.checkRiskLimits(&RunStats{PendingWithdrawals: 2})
+		checkNotTripped(t, u, *killed)
+		u.checkRiskLimits(&RunStats{PendingWithdrawals: 3})
+		checkTripped(t, u, c, *killed, RiskLimitPendingWithdrawals)
+	})
+
+	t.Run("failed swaps", func(t *testing.T) {
+		u, c, killed := newAdaptor(&RiskLimits{MaxConsecutiveFailedSwaps: 2})
+		coin := &core.Coin{}
+		newMatch := func(b byte) *core.Match {
+			var mid order.MatchID
+			mid[0] = b
+			return &core.Match{MatchID: mid[:], Active: true}
+		}
+		refunded, redeemed, revoked, active, cancel := newMatch(1), newMatch(2), newMatch(3), newMatch(4), newMatch(5)
+		refunded.Refund = coin
+		redeemed.Redeem = coin
+		revoked.Revoked = true
+		revoked.Active = false
+		cancel.IsCancel = true
+		cancel.Active = false
+		cancel.Revoked = true
+
+		u.recordSwapOutcomes(&core.Order{Matches: []*core.Match{refunded, active, cancel}})
+		checkNotTripped(t, u, *killed)
+		u.recordSwapOutcomes(&core.Order{Matches: []*core.Match{redeemed}})
+		checkNotTripped(t, u, *killed)
+		// Already counted.
+		u.recordSwapOutcomes(&core.Order{Matches: []*core.Match{refunded}})
+		checkNotTripped(t, u, *killed)
+		// Active match becomes refunded. Two failures in a row now.
+		active.Refund = coin
+		u.recordSwapOutcomes(&core.Order{Matches: []*core.Match{active}})
+		checkNotTripped(t, u, *killed)
+		u.recordSwapOutcomes(&core.Order{Matches: []*core.Match{revoked}})
+		checkTripped(t, u, c, *killed, RiskLimitFailedSwaps)
+	})
+}
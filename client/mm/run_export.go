@@ -0,0 +1,497 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/calc"
+)
+
+// RunExportFormat is the file format of an exported market making run.
+type RunExportFormat string
+
+const (
+	// RunExportCSV exports the run's events as CSV with one row per event,
+	// and the PnL report as CSV with one row per asset.
+	RunExportCSV RunExportFormat = "csv"
+	// RunExportJSON exports the run's events as JSON lines with one
+	// MarketMakingEvent per line, and the PnL report as a JSON object.
+	RunExportJSON RunExportFormat = "json"
+)
+
+// AssetPnL is the profit and loss of one of the assets used in a run.
+type AssetPnL struct {
+	AssetID uint32 `json:"assetID"`
+	Symbol  string `json:"symbol"`
+	// Initial is the balance allocated to the bot when it was started.
+	Initial *Amount `json:"initial"`
+	// Mods are the changes to the bot's inventory made by the user while
+	// the bot was running.
+	Mods *Amount `json:"mods"`
+	// Final is the bot's balance at the end of the run.
+	Final *Amount `json:"final"`
+	// Diff is Final - Initial - Mods.
+	Diff *Amount `json:"diff"`
+}
+
+// PnLReport is a profit and loss report for a market making run. Fiat values
+// use the fiat rates at the end of the run.
+type PnLReport struct {
+	Market    *MarketWithHost `json:"market"`
+	StartTime int64           `json:"startTime"`
+	EndTime   *int64          `json:"endTime,omitempty"`
+	Assets    []*AssetPnL     `json:"assets"`
+
+	InitialUSD  float64 `json:"initialUSD"`
+	ModsUSD     float64 `json:"modsUSD"`
+	FinalUSD    float64 `json:"finalUSD"`
+	ProfitUSD   float64 `json:"profitUSD"`
+	ProfitRatio float64 `json:"profitRatio"`
+	// ProfitBase and ProfitQuote are the profit in conventional units of the
+	// base and quote assets. They are zero if the fiat rate of the asset is
+	// not known.
+	ProfitBase  float64 `json:"profitBase"`
+	ProfitQuote float64 `json:"profitQuote"`
+
+	FinalBalances map[uint32]*BotBalance `json:"finalBalances"`
+	FiatRates     map[uint32]float64     `json:"fiatRates"`
+
+	DEXOrders   int `json:"dexOrders"`
+	CEXOrders   int `json:"cexOrders"`
+	Deposits    int `json:"deposits"`
+	Withdrawals int `json:"withdrawals"`
+}
+
+func newPnLReport(startTime int64, mkt *MarketWithHost, overview *MarketMakingRunOverview, events []*MarketMakingEvent) *PnLReport {
+	pl := overview.ProfitLoss
+	fiatRates := overview.FinalState.FiatRates
+	r := &PnLReport{
+		Market:        mkt,
+		StartTime:     startTime,
+		EndTime:       overview.EndTime,
+		InitialUSD:    pl.InitialUSD,
+		ModsUSD:       pl.ModsUSD,
+		FinalUSD:      pl.FinalUSD,
+		ProfitUSD:     pl.Profit,
+		ProfitRatio:   pl.ProfitRatio,
+		FinalBalances: overview.FinalState.Balances,
+		FiatRates:     fiatRates,
+	}
+	if rate := fiatRates[mkt.BaseID]; rate > 0 {
+		r.ProfitBase = pl.Profit / rate
+	}
+	if rate := fiatRates[mkt.QuoteID]; rate > 0 {
+		r.ProfitQuote = pl.Profit / rate
+	}
+
+	amt := func(amts map[uint32]*Amount, assetID uint32) *Amount {
+		if a := amts[assetID]; a != nil {
+			return a
+		}
+		return NewAmount(assetID, 0, fiatRates[assetID])
+	}
+	mods := overview.FinalState.InventoryMods
+	for _, assetID := range runAssets(mkt, overview, nil) {
+		final := amt(pl.Final, assetID)
+		r.Assets = append(r.Assets, &AssetPnL{
+			AssetID: assetID,
+			Symbol:  dex.BipIDSymbol(assetID),
+			Initial: amt(pl.Initial, assetID),
+			Mods:    NewAmount(assetID, mods[assetID], fiatRates[assetID]),
+			Final:   final,
+			Diff:    NewAmount(assetID, final.Atoms-int64(overview.InitialBalances[assetID])-mods[assetID], fiatRates[assetID]),
+		})
+	}
+
+	for _, e := range events {
+		switch {
+		case e.DEXOrderEvent != nil:
+			r.DEXOrders++
+		case e.CEXOrderEvent != nil:
+			r.CEXOrders++
+		case e.DepositEvent != nil:
+			r.Deposits++
+		case e.WithdrawalEvent != nil:
+			r.Withdrawals++
+		}
+	}
+
+	return r
+}
+
+// runAssets returns the assets used in a run, with the base and quote assets
+// first, followed by the rest sorted by ID.
+func runAssets(mkt *MarketWithHost, overview *MarketMakingRunOverview, events []*MarketMakingEvent) []uint32 {
+	others := make(map[uint32]bool)
+	for assetID := range overview.InitialBalances {
+		others[assetID] = true
+	}
+	for assetID := range overview.FinalState.Balances {
+		others[assetID] = true
+	}
+	for assetID := range overview.FinalState.InventoryMods {
+		others[assetID] = true
+	}
+	for _, e := range events {
+		if e.BalanceEffects == nil {
+			continue
+		}
+		for assetID := range e.BalanceEffects.Settled {
+			others[assetID] = true
+		}
+	}
+	delete(others, mkt.BaseID)
+	delete(others, mkt.QuoteID)
+
+	assets := []uint32{mkt.BaseID, mkt.QuoteID}
+	sorted := make([]uint32, 0, len(others))
+	for assetID := range others {
+		sorted = append(sorted, assetID)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return append(assets, sorted...)
+}
+
+// runEventsAndOverview returns all of the events of a run in chronological
+// order, and the run's overview. Pending events of completed runs are
+// updated first.
+func (m *MarketMaker) runEventsAndOverview(startTime int64, mkt *MarketWithHost) ([]*MarketMakingEvent, *MarketMakingRunOverview, error) {
+	_, updatedEvents, overview, err := m.RunLogs(startTime, mkt, 1, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// RunLogs filters out config and inventory updates, so the events are
+	// retrieved unfiltered.
+	events, err := m.eventLogDB.runEvents(startTime, mkt, 0, nil, false, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The updated events are stored asynchronously.
+	updated := make(map[uint64]*MarketMakingEvent, len(updatedEvents))
+	for _, e := range updatedEvents {
+		updated[e.ID] = e
+	}
+	for i, e := range events {
+		if u, found := updated[e.ID]; found {
+			events[i] = u
+		}
+	}
+	// RunLogs returns the newest events first.
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, overview, nil
+}
+
+// RunPnLReport generates a profit and loss report for a market making run.
+func (m *MarketMaker) RunPnLReport(startTime int64, mkt *MarketWithHost) (*PnLReport, error) {
+	events, overview, err := m.runEventsAndOverview(startTime, mkt)
+	if err != nil {
+		return nil, err
+	}
+	return newPnLReport(startTime, mkt, overview, events), nil
+}
+
+// ExportRunEvents writes the events of a market making run to w.
+func (m *MarketMaker) ExportRunEvents(startTime int64, mkt *MarketWithHost, format RunExportFormat, w io.Writer) error {
+	events, overview, err := m.runEventsAndOverview(startTime, mkt)
+	if err != nil {
+		return err
+	}
+	return writeRunEvents(w, format, mkt, overview, events)
+}
+
+// ExportRun writes the events of a market making run to eventsPath and the
+// PnL report to pnlPath. If pnlPath is empty, only the events are written.
+// The PnL report is returned.
+func (m *MarketMaker) ExportRun(startTime int64, mkt *MarketWithHost, format RunExportFormat, eventsPath, pnlPath string) (*PnLReport, error) {
+	if format != RunExportCSV && format != RunExportJSON {
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+
+	events, overview, err := m.runEventsAndOverview(startTime, mkt)
+	if err != nil {
+		return nil, err
+	}
+	report := newPnLReport(startTime, mkt, overview, events)
+
+	writeFile := func(path string, write func(io.Writer) error) error {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := write(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	if err := writeFile(eventsPath, func(w io.Writer) error {
+		return writeRunEvents(w, format, mkt, overview, events)
+	}); err != nil {
+		return nil, fmt.Errorf("error writing events to %s: %w", eventsPath, err)
+	}
+
+	if pnlPath != "" {
+		if err := writeFile(pnlPath, func(w io.Writer) error {
+			return writePnLReport(w, format, report)
+		}); err != nil {
+			return nil, fmt.Errorf("error writing PnL report to %s: %w", pnlPath, err)
+		}
+	}
+
+	return report, nil
+}
+
+func writeRunEvents(w io.Writer, format RunExportFormat, mkt *MarketWithHost, overview *MarketMakingRunOverview, events []*MarketMakingEvent) error {
+	switch format {
+	case RunExportJSON:
+		enc := json.NewEncoder(w)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case RunExportCSV:
+		return writeRunEventsCSV(w, mkt, overview, events)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+func writePnLReport(w io.Writer, format RunExportFormat, r *PnLReport) error {
+	switch format {
+	case RunExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return enc.Encode(r)
+	case RunExportCSV:
+		return writePnLReportCSV(w, r)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// conventionalString formats atoms in the conventional units of the asset
+// without a unit suffix.
+func conventionalString(assetID uint32, atoms int64) string {
+	ui, err := asset.UnitInfo(assetID)
+	if err != nil {
+		return strconv.FormatInt(atoms, 10)
+	}
+	if atoms < 0 {
+		return "-" + ui.ConventionalString(uint64(-atoms))
+	}
+	return ui.ConventionalString(uint64(atoms))
+}
+
+func conventionalRateString(baseID, quoteID uint32, msgRate uint64) string {
+	bui, err := asset.UnitInfo(baseID)
+	if err != nil {
+		return strconv.FormatUint(msgRate, 10)
+	}
+	qui, err := asset.UnitInfo(quoteID)
+	if err != nil {
+		return strconv.FormatUint(msgRate, 10)
+	}
+	return strconv.FormatFloat(calc.ConventionalRate(msgRate, bui, qui), 'f', -1, 64)
+}
+
+func csvSymbol(assetID uint32) string {
+	return strings.ToUpper(dex.BipIDSymbol(assetID))
+}
+
+func formatUSD(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// writeRunEventsCSV writes one row per event. Amounts are in conventional
+// units. For each asset, the settled balance effect of the event and the
+// bot's settled balance after the event are included.
+func writeRunEventsCSV(w io.Writer, mkt *MarketWithHost, overview *MarketMakingRunOverview, events []*MarketMakingEvent) error {
+	assets := runAssets(mkt, overview, events)
+
+	header := []string{
+		"ID",
+		"Time",
+		"Type",
+		"Pending",
+		"Reference ID",
+		"Side",
+		"Base",
+		"Quote",
+		"Rate",
+		"Quantity",
+		"Base Filled",
+		"Quote Filled",
+		"Asset",
+		"Amount",
+		"CEX Amount",
+		"Tx Fees",
+	}
+	for _, assetID := range assets {
+		sym := csvSymbol(assetID)
+		header = append(header, sym+" Settled", sym+" Balance")
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+
+	balances := make(map[uint32]int64, len(assets))
+	for assetID, v := range overview.InitialBalances {
+		balances[assetID] = int64(v)
+	}
+
+	side := func(sell bool) string {
+		if sell {
+			return "sell"
+		}
+		return "buy"
+	}
+
+	for _, e := range events {
+		var eventType, refID, sideStr, base, quote, rate, qty, baseFilled, quoteFilled, assetSym, amount, cexAmount, txFees string
+		switch {
+		case e.DEXOrderEvent != nil:
+			o := e.DEXOrderEvent
+			eventType, refID, sideStr = "DEX Order", o.ID, side(o.Sell)
+			base, quote = csvSymbol(mkt.BaseID), csvSymbol(mkt.QuoteID)
+			rate = conventionalRateString(mkt.BaseID, mkt.QuoteID, o.Rate)
+			qty = conventionalString(mkt.BaseID, int64(o.Qty))
+		case e.CEXOrderEvent != nil:
+			o := e.CEXOrderEvent
+			baseID, quoteID := o.BaseID, o.QuoteID
+			if baseID == quoteID {
+				baseID, quoteID = mkt.BaseID, mkt.QuoteID
+			}
+			eventType, refID, sideStr = "CEX Order", o.ID, side(o.Sell)
+			base, quote = csvSymbol(baseID), csvSymbol(quoteID)
+			rate = conventionalRateString(baseID, quoteID, o.Rate)
+			qty = conventionalString(baseID, int64(o.Qty))
+			baseFilled = conventionalString(baseID, int64(o.BaseFilled))
+			quoteFilled = conventionalString(quoteID, int64(o.QuoteFilled))
+		case e.DepositEvent != nil:
+			d := e.DepositEvent
+			eventType, assetSym = "Deposit", csvSymbol(d.AssetID)
+			cexAmount = conventionalString(d.AssetID, int64(d.CEXCredit))
+			if d.Transaction != nil {
+				refID = d.Transaction.ID
+				amount = conventionalString(d.AssetID, int64(d.Transaction.Amount))
+				txFees = conventionalString(feeAssetID(d.AssetID), int64(d.Transaction.Fees))
+			}
+		case e.WithdrawalEvent != nil:
+			wd := e.WithdrawalEvent
+			eventType, refID, assetSym = "Withdrawal", wd.ID, csvSymbol(wd.AssetID)
+			cexAmount = conventionalString(wd.AssetID, int64(wd.CEXDebit))
+			if wd.Transaction != nil {
+				amount = conventionalString(wd.AssetID, int64(wd.Transaction.Amount))
+			}
+		case e.UpdateConfig != nil:
+			eventType = "Config Update"
+		case e.UpdateInventory != nil:
+			eventType = "Inventory Update"
+			for assetID, diff := range *e.UpdateInventory {
+				balances[assetID] += diff
+			}
+		case e.RiskLimitEvent != nil:
+			eventType, refID = "Risk Limit", string(e.RiskLimitEvent.Limit)
+		}
+
+		row := []string{
+			strconv.FormatUint(e.ID, 10),
+			time.Unix(e.TimeStamp, 0).UTC().Format(time.RFC3339),
+			eventType,
+			strconv.FormatBool(e.Pending),
+			refID,
+			sideStr,
+			base,
+			quote,
+			rate,
+			qty,
+			baseFilled,
+			quoteFilled,
+			assetSym,
+			amount,
+			cexAmount,
+			txFees,
+		}
+		for _, assetID := range assets {
+			var settled int64
+			if e.BalanceEffects != nil {
+				settled = e.BalanceEffects.Settled[assetID]
+			}
+			balances[assetID] += settled
+			row = append(row, conventionalString(assetID, settled), conventionalString(assetID, balances[assetID]))
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writePnLReportCSV writes one row per asset followed by a total row with
+// the USD values.
+func writePnLReportCSV(w io.Writer, r *PnLReport) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{
+		"Asset",
+		"Initial",
+		"Inventory Changes",
+		"Final",
+		"Difference",
+		"Fiat Rate",
+		"Initial USD",
+		"Inventory Changes USD",
+		"Final USD",
+		"Difference USD",
+	}); err != nil {
+		return err
+	}
+
+	for _, a := range r.Assets {
+		if err := csvWriter.Write([]string{
+			csvSymbol(a.AssetID),
+			conventionalString(a.AssetID, a.Initial.Atoms),
+			conventionalString(a.AssetID, a.Mods.Atoms),
+			conventionalString(a.AssetID, a.Final.Atoms),
+			conventionalString(a.AssetID, a.Diff.Atoms),
+			strconv.FormatFloat(r.FiatRates[a.AssetID], 'f', -1, 64),
+			formatUSD(a.Initial.USD),
+			formatUSD(a.Mods.USD),
+			formatUSD(a.Final.USD),
+			formatUSD(a.Diff.USD),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := csvWriter.Write([]string{
+		"Total", "", "", "", "", "",
+		formatUSD(r.InitialUSD),
+		formatUSD(r.ModsUSD),
+		formatUSD(r.FinalUSD),
+		formatUSD(r.ProfitUSD),
+	}); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,247 @@
+//go:build !harness && !botlive
+
+package mm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/asset"
+	"decred.org/dcrdex/dex/calc"
+)
+
+func TestExportRun(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := newBoltEventLogDB(ctx, filepath.Join(dir, "event_log.db"), tLogger)
+	if err != nil {
+		t.Fatalf("error creating event log db: %v", err)
+	}
+
+	m := &MarketMaker{
+		log:         tLogger,
+		eventLogDB:  db,
+		runningBots: make(map[MarketWithHost]*runningBot),
+	}
+
+	const dcrID, ethID = 42, 60
+	startTime := time.Now().Unix()
+	mkt := &MarketWithHost{Host: "dex.com", BaseID: dcrID, QuoteID: ethID}
+	fiatRates := map[uint32]float64{dcrID: 20, ethID: 2500}
+
+	balanceState := func(dcr, eth uint64, mods map[uint32]int64) *BalanceState {
+		return &BalanceState{
+			FiatRates:     fiatRates,
+			Balances:      map[uint32]*BotBalance{dcrID: {Available: dcr}, ethID: {Available: eth}},
+			InventoryMods: mods,
+		}
+	}
+
+	cfg := &BotConfig{Host: mkt.Host, BaseID: dcrID, QuoteID: ethID}
+	if err := db.storeNewRun(startTime, mkt, cfg, balanceState(3e9, 3e9, map[uint32]int64{})); err != nil {
+		t.Fatalf("error storing new run: %v", err)
+	}
+
+	dcrUI, _ := asset.UnitInfo(dcrID)
+	ethUI, _ := asset.UnitInfo(ethID)
+	rate := calc.MessageRate(0.01, dcrUI, ethUI)
+
+	events := []*MarketMakingEvent{{
+		ID:        1,
+		TimeStamp: startTime + 1,
+		DEXOrderEvent: &DEXOrderEvent{
+			ID:   "dexorder",
+			Rate: rate,
+			Qty:  1e8,
+			Sell: true,
+		},
+		BalanceEffects: &BalanceEffects{Settled: map[uint32]int64{dcrID: -1e8, ethID: 1e7}},
+	}, {
+		ID:        2,
+		TimeStamp: startTime + 2,
+		CEXOrderEvent: &CEXOrderEvent{
+			ID:          "cexorder",
+			Rate:        rate,
+			Qty:         1e8,
+			BaseFilled:  1e8,
+			QuoteFilled: 1e7,
+		},
+		BalanceEffects: &BalanceEffects{Settled: map[uint32]int64{dcrID: 1e8, ethID: -1e7}},
+	}, {
+		ID:        3,
+		TimeStamp: startTime + 3,
+		DepositEvent: &DepositEvent{
+			AssetID:     dcrID,
+			Transaction: &asset.WalletTransaction{ID: "deposittx", Amount: 5e8, Fees: 1e4},
+			CEXCredit:   5e8,
+		},
+		BalanceEffects: &BalanceEffects{Settled: map[uint32]int64{dcrID: -1e4}},
+	}, {
+		ID:              4,
+		TimeStamp:       startTime + 4,
+		UpdateInventory: &map[uint32]int64{dcrID: 1e9},
+	}}
+	finalState := balanceState(3e9-1e4+1e9, 3e9, map[uint32]int64{dcrID: 1e9})
+	for _, e := range events {
+		db.storeEvent(startTime, mkt, e, finalState)
+	}
+	tryWithTimeout(t, func() error {
+		stored, err := db.runEvents(startTime, mkt, 0, nil, false, nil)
+		if err != nil {
+			return err
+		}
+		if len(stored) != len(events) {
+			return fmt.Errorf("expected %d events, got %d", len(events), len(stored))
+		}
+		overview, err := db.runOverview(startTime, mkt)
+		if err != nil {
+			return err
+		}
+		if overview.FinalState.InventoryMods[dcrID] != 1e9 {
+			return fmt.Errorf("final state not stored")
+		}
+		return nil
+	})
+
+	report, err := m.RunPnLReport(startTime, mkt)
+	if err != nil {
+		t.Fatalf("error generating report: %v", err)
+	}
+	// Initial: 30 DCR * $20 + 3 ETH * $2500 = $8100
+	// Mods: 10 DCR * $20 = $200
+	// Final: 39.9999 DCR * $20 + 3 ETH * $2500 = $8299.998
+	expProfit := -0.002
+	if math.Abs(report.ProfitUSD-expProfit) > 1e-9 {
+		t.Fatalf("wrong profit. expected %f, got %f", expProfit, report.ProfitUSD)
+	}
+	if math.Abs(report.ProfitBase-expProfit/20) > 1e-12 || math.Abs(report.ProfitQuote-expProfit/2500) > 1e-12 {
+		t.Fatalf("wrong base/quote profit %f, %f", report.ProfitBase, report.ProfitQuote)
+	}
+	if len(report.Assets) != 2 || report.Assets[0].AssetID != dcrID || report.Assets[1].AssetID != ethID {
+		t.Fatalf("wrong assets in report")
+	}
+	if report.Assets[0].Diff.Atoms != -1e4 || report.Assets[1].Diff.Atoms != 0 {
+		t.Fatalf("wrong diffs %d, %d", report.Assets[0].Diff.Atoms, report.Assets[1].Diff.Atoms)
+	}
+	if report.DEXOrders != 1 || report.CEXOrders != 1 || report.Deposits != 1 || report.Withdrawals != 0 {
+		t.Fatalf("wrong event counts")
+	}
+
+	// CSV
+	eventsPath, pnlPath := filepath.Join(dir, "events.csv"), filepath.Join(dir, "pnl.csv")
+	if _, err := m.ExportRun(startTime, mkt, RunExportCSV, eventsPath, pnlPath); err != nil {
+		t.Fatalf("error exporting run: %v", err)
+	}
+	readCSV := func(path string) []map[string]string {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("error opening %s: %v", path, err)
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("error reading %s: %v", path, err)
+		}
+		rows := make([]map[string]string, 0, len(records)-1)
+		for _, rec := range records[1:] {
+			row := make(map[string]string, len(rec))
+			for i, v := range rec {
+				row[records[0][i]] = v
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+	checkCSV := func(row map[string]string, col, exp string) {
+		t.Helper()
+		if row[col] != exp {
+			t.Fatalf("wrong %q. expected %q, got %q", col, exp, row[col])
+		}
+	}
+
+	rows := readCSV(eventsPath)
+	if len(rows) != len(events) {
+		t.Fatalf("expected %d rows, got %d", len(events), len(rows))
+	}
+	checkCSV(rows[0], "Type", "DEX Order")
+	checkCSV(rows[0], "Side", "sell")
+	checkCSV(rows[0], "Rate", "0.01")
+	checkCSV(rows[0], "Quantity", "1.00000000")
+	checkCSV(rows[0], "DCR Settled", "-1.00000000")
+	checkCSV(rows[0], "DCR Balance", "29.00000000")
+	checkCSV(rows[0], "ETH Balance", "3.010000000")
+	// CEX orders without asset IDs use the run's market.
+	checkCSV(rows[1], "Type", "CEX Order")
+	checkCSV(rows[1], "Side", "buy")
+	checkCSV(rows[1], "Base", "DCR")
+	checkCSV(rows[1], "Quote Filled", "0.010000000")
+	checkCSV(rows[1], "ETH Balance", "3.000000000")
+	checkCSV(rows[2], "Reference ID", "deposittx")
+	checkCSV(rows[2], "Tx Fees", "0.00010000")
+	checkCSV(rows[2], "DCR Balance", "29.99990000")
+	checkCSV(rows[3], "Type", "Inventory Update")
+	checkCSV(rows[3], "DCR Balance", "39.99990000")
+
+	rows = readCSV(pnlPath)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 PnL rows, got %d", len(rows))
+	}
+	checkCSV(rows[0], "Asset", "DCR")
+	checkCSV(rows[0], "Inventory Changes", "10.00000000")
+	checkCSV(rows[0], "Difference", "-0.00010000")
+	checkCSV(rows[2], "Asset", "Total")
+	checkCSV(rows[2], "Initial USD", "8100.00")
+	checkCSV(rows[2], "Difference USD", "-0.00")
+
+	// JSON
+	eventsPath, pnlPath = filepath.Join(dir, "events.jsonl"), filepath.Join(dir, "pnl.json")
+	if _, err := m.ExportRun(startTime, mkt, RunExportJSON, eventsPath, pnlPath); err != nil {
+		t.Fatalf("error exporting run: %v", err)
+	}
+	b, err := os.ReadFile(eventsPath)
+	if err != nil {
+		t.Fatalf("error reading events: %v", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	var n int
+	for ; scanner.Scan(); n++ {
+		var e MarketMakingEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("error decoding event: %v", err)
+		}
+		if e.ID != events[n].ID {
+			t.Fatalf("wrong event order. expected ID %d, got %d", events[n].ID, e.ID)
+		}
+	}
+	if n != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), n)
+	}
+	b, err = os.ReadFile(pnlPath)
+	if err != nil {
+		t.Fatalf("error reading PnL report: %v", err)
+	}
+	var jsonReport PnLReport
+	if err := json.Unmarshal(b, &jsonReport); err != nil {
+		t.Fatalf("error decoding PnL report: %v", err)
+	}
+	if jsonReport.ProfitUSD != report.ProfitUSD {
+		t.Fatalf("wrong profit in JSON report")
+	}
+
+	if _, err := m.ExportRun(startTime, mkt, "xml", eventsPath, ""); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
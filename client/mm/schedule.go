@@ -0,0 +1,399 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package mm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/utils"
+)
+
+// ScheduleAction is an action performed by a bot schedule.
+type ScheduleAction string
+
+const (
+	// ScheduleActionStart starts the bot if it is not running.
+	ScheduleActionStart ScheduleAction = "start"
+	// ScheduleActionStop stops the bot if it is running.
+	ScheduleActionStop ScheduleAction = "stop"
+	// ScheduleActionPlacements swaps the placements of a running bot.
+	ScheduleActionPlacements ScheduleAction = "placements"
+)
+
+// SchedulePlacements are the placements that a running bot is updated to
+// use by a ScheduleActionPlacements entry. The placements matching the bot's
+// type are used.
+type SchedulePlacements struct {
+	// BuyPlacements and SellPlacements are used by basic market makers.
+	BuyPlacements  []*OrderPlacement `json:"buyPlacements,omitempty"`
+	SellPlacements []*OrderPlacement `json:"sellPlacements,omitempty"`
+	// ArbBuyPlacements and ArbSellPlacements are used by arb market makers.
+	ArbBuyPlacements  []*ArbMarketMakingPlacement `json:"arbBuyPlacements,omitempty"`
+	ArbSellPlacements []*ArbMarketMakingPlacement `json:"arbSellPlacements,omitempty"`
+}
+
+// ScheduleEntry is an action that is performed at the times matched by a cron
+// expression.
+type ScheduleEntry struct {
+	// Cron is a standard five field cron expression: minute, hour, day of
+	// month, month, and day of week. Lists, ranges, steps, and three letter
+	// month and weekday names are supported. Times are matched in UTC.
+	Cron   string         `json:"cron"`
+	Action ScheduleAction `json:"action"`
+	// Alloc and AutoRebalance are used by ScheduleActionStart. If Alloc is
+	// nil, the bot config's RPCConfig is used.
+	Alloc         *BotBalanceAllocation `json:"alloc,omitempty"`
+	AutoRebalance *AutoRebalanceConfig  `json:"autoRebalance,omitempty"`
+	// Placements are used by ScheduleActionPlacements.
+	Placements *SchedulePlacements `json:"placements,omitempty"`
+}
+
+func (e *ScheduleEntry) validate() error {
+	if _, err := parseCron(e.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", e.Cron, err)
+	}
+	switch e.Action {
+	case ScheduleActionStart, ScheduleActionStop:
+	case ScheduleActionPlacements:
+		if e.Placements == nil {
+			return fmt.Errorf("no placements for %s action", e.Action)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", e.Action)
+	}
+	return nil
+}
+
+// BotSchedule starts, stops, or swaps the placements of the bot on a market
+// on a schedule. A scheduled start requires that the bot's wallets are
+// already unlocked, since the app password is not stored.
+type BotSchedule struct {
+	MarketWithHost
+	Disabled bool             `json:"disabled,omitempty"`
+	Entries  []*ScheduleEntry `json:"entries"`
+}
+
+func (s *BotSchedule) validate() error {
+	if s.Host == "" {
+		return fmt.Errorf("no host")
+	}
+	if len(s.Entries) == 0 {
+		return fmt.Errorf("no schedule entries")
+	}
+	for i, e := range s.Entries {
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bitset of the
+// matching values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// As with cron, if both the day of month and day of week fields are
+	// restricted, a time matches if either of them match.
+	domStar, dowStar bool
+}
+
+var (
+	cronMonths = map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	cronWeekdays = map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// parseCron parses a five field cron expression.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+	var c cronSchedule
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	// 7 is also Sunday.
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronWeekdays); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	// A field that covers its full range, e.g. "*" or "*/1", is unrestricted.
+	c.domStar = cronFieldFull(c.dom, 1, 31)
+	c.dowStar = cronFieldFull(c.dow, 0, 6)
+	return &c, nil
+}
+
+// cronFieldFull is true if every value from min to max is set in bits.
+func cronFieldFull(bits uint64, min, max uint) bool {
+	full := (uint64(1)<<(max+1) - 1) &^ (uint64(1)<<min - 1)
+	return bits&full == full
+}
+
+func parseCronField(field string, min, max uint, names map[string]uint) (uint64, error) {
+	parseValue := func(s string) (uint, error) {
+		if v, found := names[strings.ToLower(s)]; found {
+			return v, nil
+		}
+		v, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		return uint(v), nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangeStr, step := part, uint(1)
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			s, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangeStr, step = part[:i], uint(s)
+		}
+
+		var lo, hi uint
+		if rangeStr == "*" {
+			lo, hi = min, max
+		} else if i := strings.IndexByte(rangeStr, '-'); i >= 0 {
+			var err error
+			if lo, err = parseValue(rangeStr[:i]); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(rangeStr[i+1:]); err != nil {
+				return 0, err
+			}
+		} else {
+			v, err := parseValue(rangeStr)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			// "5/15" means every 15 starting at 5.
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// matches checks whether the minute containing t matches the schedule.
+func (c *cronSchedule) matches(t time.Time) bool {
+	t = t.UTC()
+	if c.minute&(1<<uint(t.Minute())) == 0 ||
+		c.hour&(1<<uint(t.Hour())) == 0 ||
+		c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// BotSchedules returns the saved bot schedules.
+func (m *MarketMaker) BotSchedules() []*BotSchedule {
+	return m.defaultConfig().Schedules
+}
+
+// UpdateBotSchedule saves the schedule for a market, replacing any existing
+// schedule for the market.
+func (m *MarketMaker) UpdateBotSchedule(sched *BotSchedule) error {
+	if sched == nil {
+		return fmt.Errorf("nil schedule")
+	}
+	if err := sched.validate(); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	cfg := m.defaultConfig()
+	var updated bool
+	for i, s := range cfg.Schedules {
+		if s.MarketWithHost == sched.MarketWithHost {
+			cfg.Schedules[i] = sched
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		cfg.Schedules = append(cfg.Schedules, sched)
+	}
+
+	return m.writeConfigFile(cfg)
+}
+
+// RemoveBotSchedule removes the schedule for a market.
+func (m *MarketMaker) RemoveBotSchedule(mkt *MarketWithHost) error {
+	cfg := m.defaultConfig()
+	for i, s := range cfg.Schedules {
+		if s.MarketWithHost == *mkt {
+			cfg.Schedules = append(cfg.Schedules[:i], cfg.Schedules[i+1:]...)
+			return m.writeConfigFile(cfg)
+		}
+	}
+	return fmt.Errorf("no schedule found for %s", mkt)
+}
+
+// runSchedules runs the scheduled actions and the portfolio allocator at the
+// start of each minute until the context is canceled.
+func (m *MarketMaker) runSchedules(ctx context.Context) {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-time.After(next.Sub(now)):
+		case <-ctx.Done():
+			return
+		}
+		m.runScheduledActions(next)
+		m.runPortfolioAllocator(next)
+	}
+}
+
+// runScheduledActions runs the actions of all schedule entries that match t.
+// The entries of a schedule are run in order.
+func (m *MarketMaker) runScheduledActions(t time.Time) {
+	for _, sched := range m.defaultConfig().Schedules {
+		if sched.Disabled {
+			continue
+		}
+		mkt := sched.MarketWithHost
+		for _, entry := range sched.Entries {
+			cron, err := parseCron(entry.Cron)
+			if err != nil {
+				m.log.Errorf("Invalid cron expression %q in %s schedule: %v", entry.Cron, mkt, err)
+				continue
+			}
+			if !cron.matches(t) {
+				continue
+			}
+			m.log.Infof("Running scheduled %s action for %s", entry.Action, mkt)
+			if err := m.runScheduleEntry(&mkt, entry); err != nil {
+				m.log.Errorf("Error running scheduled %s action for %s: %v", entry.Action, mkt, err)
+				m.core.Broadcast(newScheduleErrorNote(&mkt, entry.Action, err))
+			}
+		}
+	}
+}
+
+func (m *MarketMaker) runScheduleEntry(mkt *MarketWithHost, entry *ScheduleEntry) error {
+	switch entry.Action {
+	case ScheduleActionStart:
+		return m.startScheduledBot(mkt, entry)
+	case ScheduleActionStop:
+		if _, running := m.runningBotsLookup()[*mkt]; !running {
+			return nil
+		}
+		return m.StopBot(mkt)
+	case ScheduleActionPlacements:
+		return m.updateScheduledPlacements(mkt, entry.Placements)
+	}
+	return fmt.Errorf("unknown action %q", entry.Action)
+}
+
+// startScheduledBot starts a bot without the app password. The wallets of the
+// market's assets, and of the parent chains of any tokens, must already be
+// unlocked. The bot is not started if the market's lot size
+// has changed since the bot was configured.
+func (m *MarketMaker) startScheduledBot(mkt *MarketWithHost, entry *ScheduleEntry) error {
+	m.startUpdateMtx.Lock()
+	defer m.startUpdateMtx.Unlock()
+
+	if _, running := m.runningBotsLookup()[*mkt]; running {
+		return nil
+	}
+
+	botCfg, cexCfg, err := m.botStartConfigs(mkt, nil, false)
+	if err != nil {
+		return err
+	}
+
+	startCfg := &StartConfig{
+		MarketWithHost: *mkt,
+		Alloc:          entry.Alloc,
+		AutoRebalance:  entry.AutoRebalance,
+	}
+	if startCfg.Alloc == nil && botCfg.RPCConfig != nil {
+		startCfg.Alloc = botCfg.RPCConfig.Alloc
+		startCfg.AutoRebalance = botCfg.RPCConfig.AutoRebalance
+	}
+
+	if err := m.checkStartAllocation(startCfg, botCfg, cexCfg); err != nil {
+		return err
+	}
+
+	for _, assetID := range requiredWallets(botCfg.BaseID, botCfg.QuoteID) {
+		if ws := m.core.WalletState(assetID); ws == nil || !ws.Open {
+			return fmt.Errorf("cannot start bot. %s wallet is locked or not connected", dex.BipIDSymbol(assetID))
+		}
+	}
+
+	return m.startBot(startCfg, botCfg, cexCfg, nil)
+}
+
+// updateScheduledPlacements updates the placements of a running bot. Nothing
+// is done if the bot is not running.
+func (m *MarketMaker) updateScheduledPlacements(mkt *MarketWithHost, placements *SchedulePlacements) error {
+	rb, running := m.runningBotsLookup()[*mkt]
+	if !running {
+		m.log.Debugf("Not updating placements for %s. Bot is not running.", mkt)
+		return nil
+	}
+
+	copyOrderPlacement := func(p *OrderPlacement) *OrderPlacement {
+		return &OrderPlacement{Lots: p.Lots, GapFactor: p.GapFactor}
+	}
+	copyArbMarketMakingPlacement := func(p *ArbMarketMakingPlacement) *ArbMarketMakingPlacement {
+		return &ArbMarketMakingPlacement{Lots: p.Lots, Multiplier: p.Multiplier}
+	}
+
+	cfg := rb.botCfg().copy()
+	switch {
+	case cfg.BasicMMConfig != nil:
+		cfg.BasicMMConfig.BuyPlacements = utils.Map(placements.BuyPlacements, copyOrderPlacement)
+		cfg.BasicMMConfig.SellPlacements = utils.Map(placements.SellPlacements, copyOrderPlacement)
+	case cfg.ArbMarketMakerConfig != nil:
+		cfg.ArbMarketMakerConfig.BuyPlacements = utils.Map(placements.ArbBuyPlacements, copyArbMarketMakingPlacement)
+		cfg.ArbMarketMakerConfig.SellPlacements = utils.Map(placements.ArbSellPlacements, copyArbMarketMakingPlacement)
+	default:
+		return fmt.Errorf("bot type does not use placements")
+	}
+	// Check here so that invalid placements don't stop the bot.
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("invalid placements: %w", err)
+	}
+
+	return m.UpdateRunningBotCfg(cfg, nil, false)
+}
@@ -0,0 +1,307 @@
+//go:build !harness && !botlive
+
+package mm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex"
+)
+
+func TestParseCron(t *testing.T) {
+	// Saturday, March 2, 2024 14:30 UTC.
+	sat := time.Date(2024, 3, 2, 14, 30, 0, 0, time.UTC)
+	// Monday, March 4, 2024 09:00 UTC.
+	mon := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+		matches []time.Time
+		misses  []time.Time
+	}{
+		{
+			name:    "every minute",
+			expr:    "* * * * *",
+			matches: []time.Time{sat, mon},
+		},
+		{
+			name:    "weekends",
+			expr:    "* * * * sat,sun",
+			matches: []time.Time{sat, sat.Add(24 * time.Hour)},
+			misses:  []time.Time{mon},
+		},
+		{
+			name:    "sunday as 7",
+			expr:    "* * * * 7",
+			matches: []time.Time{sat.Add(24 * time.Hour)},
+			misses:  []time.Time{sat},
+		},
+		{
+			name:    "weekday mornings",
+			expr:    "0 9 * * mon-fri",
+			matches: []time.Time{mon},
+			misses:  []time.Time{mon.Add(time.Minute), sat},
+		},
+		{
+			name:    "steps",
+			expr:    "*/15 14 * * *",
+			matches: []time.Time{sat, sat.Add(-15 * time.Minute)},
+			misses:  []time.Time{sat.Add(5 * time.Minute)},
+		},
+		{
+			name:    "start and step",
+			expr:    "10/20 * * * *",
+			matches: []time.Time{sat, sat.Add(-20 * time.Minute), sat.Add(20 * time.Minute)},
+			misses:  []time.Time{sat.Add(-30 * time.Minute), sat.Add(5 * time.Minute)},
+		},
+		{
+			name:    "day of month or day of week",
+			expr:    "30 14 4 * sat",
+			matches: []time.Time{sat, mon.Add(5*time.Hour + 30*time.Minute)},
+			misses:  []time.Time{sat.Add(24 * time.Hour)},
+		},
+		{
+			name:    "full range step is unrestricted",
+			expr:    "30 14 4 * */1",
+			matches: []time.Time{mon.Add(5*time.Hour + 30*time.Minute)},
+			misses:  []time.Time{sat},
+		},
+		{
+			name:    "month names",
+			expr:    "* * * jan-feb *",
+			misses:  []time.Time{sat},
+			matches: []time.Time{sat.AddDate(0, -1, 0)},
+		},
+		{
+			name:    "non-utc time",
+			expr:    "30 14 * * *",
+			matches: []time.Time{sat.In(time.FixedZone("", 5*3600))},
+		},
+		{name: "too few fields", expr: "* * * *", wantErr: true},
+		{name: "minute out of range", expr: "60 * * * *", wantErr: true},
+		{name: "zero day of month", expr: "* * 0 * *", wantErr: true},
+		{name: "backwards range", expr: "* 10-5 * * *", wantErr: true},
+		{name: "zero step", expr: "*/0 * * * *", wantErr: true},
+		{name: "bad name", expr: "* * * * weekend", wantErr: true},
+	}
+
+	for _, test := range tests {
+		c, err := parseCron(test.expr)
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		for _, tm := range test.matches {
+			if !c.matches(tm) {
+				t.Fatalf("%s: %s should match", test.name, tm)
+			}
+		}
+		for _, tm := range test.misses {
+			if c.matches(tm) {
+				t.Fatalf("%s: %s should not match", test.name, tm)
+			}
+		}
+	}
+}
+
+// tPauseExchangeAdaptor runs the function passed to withPause.
+type tPauseExchangeAdaptor struct {
+	*tExchangeAdaptor
+}
+
+func (t *tPauseExchangeAdaptor) withPause(f func() error) error { return f() }
+
+func TestRunScheduledActions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dcrBtc := MarketWithHost{Host: "host1", BaseID: 42, QuoteID: 0}
+	btcEth := MarketWithHost{Host: "host1", BaseID: 0, QuoteID: 60}
+
+	basicCfg := &BotConfig{
+		Host:    dcrBtc.Host,
+		BaseID:  dcrBtc.BaseID,
+		QuoteID: dcrBtc.QuoteID,
+		BasicMMConfig: &BasicMarketMakingConfig{
+			GapStrategy:    GapStrategyPercent,
+			BuyPlacements:  []*OrderPlacement{{Lots: 1, GapFactor: 0.01}},
+			SellPlacements: []*OrderPlacement{{Lots: 1, GapFactor: 0.01}},
+		},
+	}
+
+	tCore := newTCore()
+	tCore.market = &core.Market{LotSize: 1e8}
+	m := &MarketMaker{
+		ctx:         ctx,
+		log:         tLogger,
+		core:        tCore,
+		runningBots: make(map[MarketWithHost]*runningBot),
+		defaultCfg: &MarketMakingConfig{
+			BotConfigs: []*BotConfig{basicCfg},
+			Schedules: []*BotSchedule{{
+				MarketWithHost: dcrBtc,
+				Entries: []*ScheduleEntry{{
+					Cron:   "0 22 * * fri",
+					Action: ScheduleActionStop,
+				}, {
+					Cron:   "0 0 * * mon",
+					Action: ScheduleActionStart,
+				}, {
+					Cron:   "0 12 * * *",
+					Action: ScheduleActionPlacements,
+					Placements: &SchedulePlacements{
+						SellPlacements: []*OrderPlacement{{Lots: 2, GapFactor: 0.02}},
+					},
+				}},
+			}, {
+				MarketWithHost: btcEth,
+				Disabled:       true,
+				Entries: []*ScheduleEntry{{
+					Cron:   "* * * * *",
+					Action: ScheduleActionStop,
+				}},
+			}},
+		},
+	}
+
+	bot := &tPauseExchangeAdaptor{&tExchangeAdaptor{cfg: basicCfg}}
+	cm := dex.NewConnectionMaster(bot)
+	if err := cm.ConnectOnce(ctx); err != nil {
+		t.Fatalf("error connecting bot: %v", err)
+	}
+	m.runningBots[dcrBtc] = &runningBot{bot: bot, cm: cm}
+
+	disabledBot := &tExchangeAdaptor{cfg: &BotConfig{Host: btcEth.Host, BaseID: btcEth.BaseID, QuoteID: btcEth.QuoteID}}
+	disabledCM := dex.NewConnectionMaster(disabledBot)
+	if err := disabledCM.ConnectOnce(ctx); err != nil {
+		t.Fatalf("error connecting bot: %v", err)
+	}
+	m.runningBots[btcEth] = &runningBot{bot: disabledBot, cm: disabledCM}
+
+	// Friday, March 1, 2024 12:00 UTC. Placements are swapped.
+	noon := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.runScheduledActions(noon)
+	if !cm.On() {
+		t.Fatalf("bot stopped")
+	}
+	newCfg := bot.botCfg().BasicMMConfig
+	if len(newCfg.BuyPlacements) != 0 {
+		t.Fatalf("expected no buy placements, got %d", len(newCfg.BuyPlacements))
+	}
+	if !reflect.DeepEqual(newCfg.SellPlacements, []*OrderPlacement{{Lots: 2, GapFactor: 0.02}}) {
+		t.Fatalf("wrong sell placements")
+	}
+	if !disabledCM.On() {
+		t.Fatalf("bot with disabled schedule stopped")
+	}
+
+	// Invalid placements are not applied.
+	m.defaultCfg.Schedules[0].Entries[2].Placements.SellPlacements[0].GapFactor = 1
+	m.runScheduledActions(noon.Add(24 * time.Hour))
+	if !cm.On() {
+		t.Fatalf("bot stopped by invalid placements")
+	}
+	if bot.botCfg().BasicMMConfig.SellPlacements[0].GapFactor != 0.02 {
+		t.Fatalf("invalid placements applied")
+	}
+
+	// Friday 22:00 UTC. The bot is stopped.
+	m.runScheduledActions(noon.Add(10 * time.Hour))
+	if cm.On() {
+		t.Fatalf("bot not stopped")
+	}
+	delete(m.runningBots, dcrBtc)
+
+	// Monday 00:00 UTC. The bot is not started without a balance
+	// allocation.
+	startEntry := m.defaultCfg.Schedules[0].Entries[1]
+	err := m.runScheduleEntry(&dcrBtc, startEntry)
+	if err == nil || !strings.Contains(err.Error(), "no balance allocation") {
+		t.Fatalf("expected no allocation error, got %v", err)
+	}
+
+	// The bot is not started because the wallets are locked.
+	tCore.setAssetBalances(map[uint32]uint64{42: 1e8, 0: 1e8, 60: 1e8, 60001: 1e8})
+	startEntry.Alloc = &BotBalanceAllocation{DEX: map[uint32]uint64{42: 1e8, 0: 1e8}}
+	err = m.runScheduleEntry(&dcrBtc, startEntry)
+	if err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("expected locked wallet error, got %v", err)
+	}
+
+	// The parent chain's wallet must be unlocked to start a token market
+	// bot.
+	usdcBtc := MarketWithHost{Host: "host1", BaseID: 60001, QuoteID: 0}
+	m.defaultCfg.BotConfigs = append(m.defaultCfg.BotConfigs, &BotConfig{
+		Host:          usdcBtc.Host,
+		BaseID:        usdcBtc.BaseID,
+		QuoteID:       usdcBtc.QuoteID,
+		BasicMMConfig: basicCfg.BasicMMConfig,
+	})
+	tCore.walletStates[60001] = &core.WalletState{Open: true}
+	tCore.walletStates[0] = &core.WalletState{Open: true}
+	err = m.startScheduledBot(&usdcBtc, &ScheduleEntry{
+		Action: ScheduleActionStart,
+		Alloc:  &BotBalanceAllocation{DEX: map[uint32]uint64{60001: 1e8, 0: 1e8}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "eth wallet is locked") {
+		t.Fatalf("expected locked eth wallet error, got %v", err)
+	}
+}
+
+func TestUpdateBotSchedule(t *testing.T) {
+	m := &MarketMaker{
+		log:            tLogger,
+		defaultCfgPath: t.TempDir() + "/mm.json",
+		defaultCfg:     &MarketMakingConfig{},
+	}
+
+	mkt := MarketWithHost{Host: "host1", BaseID: 42, QuoteID: 0}
+	for _, sched := range []*BotSchedule{
+		{MarketWithHost: mkt},
+		{MarketWithHost: mkt, Entries: []*ScheduleEntry{{Cron: "* * *", Action: ScheduleActionStop}}},
+		{MarketWithHost: mkt, Entries: []*ScheduleEntry{{Cron: "* * * * *", Action: "pause"}}},
+		{MarketWithHost: mkt, Entries: []*ScheduleEntry{{Cron: "* * * * *", Action: ScheduleActionPlacements}}},
+	} {
+		if err := m.UpdateBotSchedule(sched); err == nil {
+			t.Fatalf("expected error for invalid schedule")
+		}
+	}
+
+	sched := &BotSchedule{MarketWithHost: mkt, Entries: []*ScheduleEntry{{Cron: "0 0 * * sat", Action: ScheduleActionStop}}}
+	if err := m.UpdateBotSchedule(sched); err != nil {
+		t.Fatalf("error saving schedule: %v", err)
+	}
+	sched = &BotSchedule{MarketWithHost: mkt, Entries: []*ScheduleEntry{{Cron: "0 0 * * mon", Action: ScheduleActionStart}}}
+	if err := m.UpdateBotSchedule(sched); err != nil {
+		t.Fatalf("error updating schedule: %v", err)
+	}
+	cfg, err := getMarketMakingConfig(m.defaultCfgPath)
+	if err != nil {
+		t.Fatalf("error reading config file: %v", err)
+	}
+	if len(cfg.Schedules) != 1 || cfg.Schedules[0].Entries[0].Action != ScheduleActionStart {
+		t.Fatalf("schedule not updated in config file")
+	}
+
+	if err := m.RemoveBotSchedule(&mkt); err != nil {
+		t.Fatalf("error removing schedule: %v", err)
+	}
+	if len(m.BotSchedules()) != 0 {
+		t.Fatalf("schedule not removed")
+	}
+	if err := m.RemoveBotSchedule(&mkt); err == nil {
+		t.Fatalf("expected error removing missing schedule")
+	}
+}
@@ -0,0 +1,201 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"text/template"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/db"
+)
+
+// Endpoint types.
+const (
+	EndpointWebhook = "webhook"
+	EndpointSMTP    = "smtp"
+	EndpointNtfy    = "ntfy"
+	EndpointMatrix  = "matrix"
+)
+
+const (
+	defaultRetries    = 3
+	defaultRetryDelay = 5 * time.Second
+	// defaultTemplate is used for the message text of endpoints that do not
+	// specify a template. Webhooks without a template are sent the JSON
+	// encoded Note instead.
+	defaultTemplate = "[{{.Severity}}] {{.Subject}}: {{.Details}}"
+)
+
+// DefaultNoteTypes are the notification types that are dispatched by
+// endpoints that do not specify NoteTypes.
+var DefaultNoteTypes = []string{
+	core.NoteTypeOrder,
+	core.NoteTypeMatch,
+	core.NoteTypeBondPost,
+	core.NoteTypeWalletState,
+	core.NoteTypeBot,
+	core.NoteTypeSecurity,
+	core.NoteTypeActionRequired,
+}
+
+// Config is the dispatcher configuration.
+type Config struct {
+	Endpoints []*EndpointConfig `json:"endpoints"`
+}
+
+// SMTPConfig is the configuration of an SMTP endpoint.
+type SMTPConfig struct {
+	// Host is the host:port of the SMTP server.
+	Host string   `json:"host"`
+	User string   `json:"user,omitempty"`
+	Pass string   `json:"pass,omitempty"`
+	From string   `json:"from"`
+	To   []string `json:"to"`
+}
+
+// EndpointConfig is the configuration of an endpoint that notifications are
+// forwarded to.
+type EndpointConfig struct {
+	// Name identifies the endpoint in logs.
+	Name string `json:"name"`
+	// Type is one of webhook, smtp, ntfy or matrix.
+	Type string `json:"type"`
+	// URL is the webhook URL, the ntfy topic URL, or the Matrix homeserver
+	// URL.
+	URL string `json:"url,omitempty"`
+	// Headers are additional HTTP headers sent to webhook and ntfy
+	// endpoints.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Token is the access token for ntfy and Matrix endpoints.
+	Token string `json:"token,omitempty"`
+	// Room is the Matrix room ID.
+	Room string      `json:"room,omitempty"`
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+	// NoteTypes are the notification types that are forwarded. If empty,
+	// DefaultNoteTypes are forwarded.
+	NoteTypes []string `json:"noteTypes,omitempty"`
+	// Topics, if not empty, further limits the forwarded notifications to
+	// these topics.
+	Topics []string `json:"topics,omitempty"`
+	// MinSeverity is the minimum severity of forwarded notifications, one of
+	// data, poke, success, warning or error. Default: poke.
+	MinSeverity string `json:"minSeverity,omitempty"`
+	// Template is a text/template for the message that is sent. The template
+	// is executed with a *Note.
+	Template string `json:"template,omitempty"`
+	// Retries is the number of times that a failed delivery is retried.
+	// Default: 3.
+	Retries *int `json:"retries,omitempty"`
+	// RetryDelay is the delay in seconds before the first retry. The delay
+	// doubles with each retry. Default: 5.
+	RetryDelay uint64 `json:"retryDelay,omitempty"`
+}
+
+// LoadConfig loads a JSON dispatcher configuration from a file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notification dispatcher config: %w", err)
+	}
+	cfg := new(Config)
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing notification dispatcher config: %w", err)
+	}
+	return cfg, nil
+}
+
+func parseSeverity(s string) (db.Severity, error) {
+	switch s {
+	case "data":
+		return db.Data, nil
+	case "", "poke":
+		return db.Poke, nil
+	case "success":
+		return db.Success, nil
+	case "warning":
+		return db.WarningLevel, nil
+	case "error":
+		return db.ErrorLevel, nil
+	}
+	return 0, fmt.Errorf("unknown severity %q", s)
+}
+
+// filter decides which notifications are forwarded to an endpoint.
+type filter struct {
+	noteTypes   map[string]bool
+	topics      map[db.Topic]bool
+	minSeverity db.Severity
+}
+
+func (f *filter) matches(n core.Notification) bool {
+	if !f.noteTypes[n.Type()] || n.Severity() < f.minSeverity {
+		return false
+	}
+	return len(f.topics) == 0 || f.topics[n.Topic()]
+}
+
+func (cfg *EndpointConfig) filter() (*filter, error) {
+	minSeverity, err := parseSeverity(cfg.MinSeverity)
+	if err != nil {
+		return nil, err
+	}
+	noteTypes := cfg.NoteTypes
+	if len(noteTypes) == 0 {
+		noteTypes = DefaultNoteTypes
+	}
+	f := &filter{
+		noteTypes:   make(map[string]bool, len(noteTypes)),
+		topics:      make(map[db.Topic]bool, len(cfg.Topics)),
+		minSeverity: minSeverity,
+	}
+	for _, noteType := range noteTypes {
+		f.noteTypes[noteType] = true
+	}
+	for _, topic := range cfg.Topics {
+		f.topics[db.Topic(topic)] = true
+	}
+	return f, nil
+}
+
+func (cfg *EndpointConfig) template() (*template.Template, error) {
+	tmpl := cfg.Template
+	if tmpl == "" {
+		if cfg.Type == EndpointWebhook {
+			return nil, nil
+		}
+		tmpl = defaultTemplate
+	}
+	return template.New(cfg.Name).Parse(tmpl)
+}
+
+func (cfg *EndpointConfig) validate() error {
+	switch cfg.Type {
+	case EndpointWebhook, EndpointNtfy:
+		if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+			return fmt.Errorf("invalid url %q: %w", cfg.URL, err)
+		}
+	case EndpointMatrix:
+		if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+			return fmt.Errorf("invalid homeserver url %q: %w", cfg.URL, err)
+		}
+		if cfg.Room == "" || cfg.Token == "" {
+			return fmt.Errorf("matrix endpoints require a room and an access token")
+		}
+	case EndpointSMTP:
+		if cfg.SMTP == nil || cfg.SMTP.Host == "" || cfg.SMTP.From == "" || len(cfg.SMTP.To) == 0 {
+			return fmt.Errorf("smtp endpoints require a host, a sender and recipients")
+		}
+	default:
+		return fmt.Errorf("unknown endpoint type %q", cfg.Type)
+	}
+	if cfg.Retries != nil && *cfg.Retries < 0 {
+		return fmt.Errorf("negative retries")
+	}
+	return nil
+}
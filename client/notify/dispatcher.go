@@ -0,0 +1,252 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+// Package notify forwards core notifications to external endpoints such as
+// HTTP webhooks, email, ntfy and Matrix.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"sync"
+	"text/template"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex"
+)
+
+const (
+	// queueSize is the number of notifications that can be waiting for
+	// delivery to an endpoint. Notifications are dropped when the queue is
+	// full.
+	queueSize = 256
+	// sendTimeout is the timeout of a single delivery attempt.
+	sendTimeout = 30 * time.Second
+)
+
+// NoteFeeder is satisfied by *core.Core.
+type NoteFeeder interface {
+	NotificationFeed() *core.NoteFeed
+}
+
+// Note is the information about a notification that is forwarded to
+// endpoints. Templates are executed with a *Note, and webhooks without a
+// template are sent the JSON encoded Note.
+type Note struct {
+	Type     string    `json:"type"`
+	Topic    string    `json:"topic"`
+	Subject  string    `json:"subject"`
+	Details  string    `json:"details"`
+	Severity string    `json:"severity"`
+	Stamp    uint64    `json:"stamp"`
+	ID       dex.Bytes `json:"id"`
+	// Payload is the full notification.
+	Payload core.Notification `json:"payload"`
+}
+
+func newNote(n core.Notification) *Note {
+	return &Note{
+		Type:     n.Type(),
+		Topic:    string(n.Topic()),
+		Subject:  n.Subject(),
+		Details:  n.Details(),
+		Severity: n.Severity().String(),
+		Stamp:    n.Time(),
+		ID:       n.ID(),
+		Payload:  n,
+	}
+}
+
+// Time is the notification time.
+func (n *Note) Time() time.Time {
+	return time.UnixMilli(int64(n.Stamp))
+}
+
+type endpoint struct {
+	name       string
+	filter     *filter
+	tmpl       *template.Template
+	sender     sender
+	retries    int
+	retryDelay time.Duration
+	queue      chan *Note
+	log        dex.Logger
+}
+
+func newEndpoint(cfg *EndpointConfig, log dex.Logger) (*endpoint, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	f, err := cfg.filter()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := cfg.template()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %w", err)
+	}
+
+	httpClient := &http.Client{Timeout: sendTimeout}
+	var s sender
+	switch cfg.Type {
+	case EndpointWebhook:
+		s = &webhookSender{client: httpClient, url: cfg.URL, headers: cfg.Headers}
+	case EndpointNtfy:
+		s = &ntfySender{client: httpClient, url: cfg.URL, token: cfg.Token, headers: cfg.Headers}
+	case EndpointMatrix:
+		s = &matrixSender{client: httpClient, url: cfg.URL, room: cfg.Room, token: cfg.Token}
+	case EndpointSMTP:
+		s = &smtpSender{cfg: cfg.SMTP, sendMail: smtp.SendMail}
+	}
+
+	retries := defaultRetries
+	if cfg.Retries != nil {
+		retries = *cfg.Retries
+	}
+	retryDelay := defaultRetryDelay
+	if cfg.RetryDelay > 0 {
+		retryDelay = time.Duration(cfg.RetryDelay) * time.Second
+	}
+
+	return &endpoint{
+		name:       cfg.Name,
+		filter:     f,
+		tmpl:       tmpl,
+		sender:     s,
+		retries:    retries,
+		retryDelay: retryDelay,
+		queue:      make(chan *Note, queueSize),
+		log:        log,
+	}, nil
+}
+
+func (e *endpoint) enqueue(n *Note) {
+	select {
+	case e.queue <- n:
+	default:
+		e.log.Warnf("Notification queue for endpoint %q is full. Dropping %s notification %q.", e.name, n.Type, n.Subject)
+	}
+}
+
+func (e *endpoint) run(ctx context.Context) {
+	for {
+		select {
+		case n := <-e.queue:
+			e.deliver(ctx, n)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver sends the notification, retrying with an exponential backoff on
+// failure.
+func (e *endpoint) deliver(ctx context.Context, n *Note) {
+	var msg string
+	if e.tmpl != nil {
+		var b bytes.Buffer
+		if err := e.tmpl.Execute(&b, n); err != nil {
+			e.log.Errorf("Error executing template for endpoint %q: %v", e.name, err)
+			return
+		}
+		msg = b.String()
+	}
+
+	delay := e.retryDelay
+	for attempt := 0; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := e.sender.send(sendCtx, n, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= e.retries || ctx.Err() != nil {
+			e.log.Errorf("Failed to deliver %s notification %q to endpoint %q after %d attempts: %v",
+				n.Type, n.Subject, e.name, attempt+1, err)
+			return
+		}
+		e.log.Debugf("Error delivering notification to endpoint %q. Retrying in %s: %v", e.name, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay *= 2
+	}
+}
+
+// Dispatcher forwards core notifications to the configured endpoints.
+type Dispatcher struct {
+	feeder    NoteFeeder
+	endpoints []*endpoint
+	log       dex.Logger
+}
+
+// New is the constructor for a *Dispatcher.
+func New(cfg *Config, feeder NoteFeeder, log dex.Logger) (*Dispatcher, error) {
+	d := &Dispatcher{
+		feeder: feeder,
+		log:    log,
+	}
+	names := make(map[string]bool, len(cfg.Endpoints))
+	for i, epCfg := range cfg.Endpoints {
+		if epCfg.Name == "" {
+			epCfg.Name = fmt.Sprintf("%s-%d", epCfg.Type, i)
+		}
+		if names[epCfg.Name] {
+			return nil, fmt.Errorf("duplicate endpoint name %q", epCfg.Name)
+		}
+		names[epCfg.Name] = true
+		ep, err := newEndpoint(epCfg, log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q: %w", epCfg.Name, err)
+		}
+		d.endpoints = append(d.endpoints, ep)
+	}
+	return d, nil
+}
+
+// Connect starts the Dispatcher. Connect satisfies dex.Connector.
+func (d *Dispatcher) Connect(ctx context.Context) (*sync.WaitGroup, error) {
+	feed := d.feeder.NotificationFeed()
+
+	var wg sync.WaitGroup
+	for _, ep := range d.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			ep.run(ctx)
+		}(ep)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer feed.ReturnFeed()
+		for {
+			select {
+			case n := <-feed.C:
+				var note *Note
+				for _, ep := range d.endpoints {
+					if !ep.filter.matches(n) {
+						continue
+					}
+					if note == nil {
+						note = newNote(n)
+					}
+					ep.enqueue(note)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	d.log.Infof("Forwarding notifications to %d endpoints", len(d.endpoints))
+
+	return &wg, nil
+}
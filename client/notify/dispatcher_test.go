@@ -0,0 +1,323 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/db"
+	"decred.org/dcrdex/dex"
+)
+
+var tLogger = dex.StdOutLogger("TNOTIFY", dex.LevelInfo)
+
+type tFeeder struct {
+	ch chan core.Notification
+}
+
+func (f *tFeeder) NotificationFeed() *core.NoteFeed {
+	return &core.NoteFeed{C: f.ch}
+}
+
+func newTNote(noteType string, topic db.Topic, severity db.Severity) core.Notification {
+	n := db.NewNotification(noteType, topic, "subject "+string(topic), "details", severity)
+	return &n
+}
+
+type tRequest struct {
+	method      string
+	path        string
+	contentType string
+	headers     http.Header
+	body        string
+}
+
+type tServer struct {
+	*httptest.Server
+	mtx      sync.Mutex
+	requests []*tRequest
+	failures int
+}
+
+func newTServer() *tServer {
+	s := new(tServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		if s.failures > 0 {
+			s.failures--
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		s.requests = append(s.requests, &tRequest{
+			method:      r.Method,
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			headers:     r.Header,
+			body:        string(b),
+		})
+	}))
+	return s
+}
+
+func (s *tServer) waitForRequests(t *testing.T, n int) []*tRequest {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mtx.Lock()
+		reqs := s.requests
+		s.mtx.Unlock()
+		if len(reqs) >= n {
+			return reqs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d requests", n)
+	return nil
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *EndpointConfig
+		note core.Notification
+		exp  bool
+	}{{
+		name: "default types",
+		cfg:  &EndpointConfig{},
+		note: newTNote(core.NoteTypeMatch, core.TopicMatchRevoked, db.WarningLevel),
+		exp:  true,
+	}, {
+		name: "type not in defaults",
+		cfg:  &EndpointConfig{},
+		note: newTNote(core.NoteTypeBalance, "", db.WarningLevel),
+	}, {
+		name: "below default severity",
+		cfg:  &EndpointConfig{},
+		note: newTNote(core.NoteTypeOrder, "", db.Data),
+	}, {
+		name: "min severity",
+		cfg:  &EndpointConfig{MinSeverity: "error"},
+		note: newTNote(core.NoteTypeOrder, "", db.WarningLevel),
+	}, {
+		name: "custom types",
+		cfg:  &EndpointConfig{NoteTypes: []string{core.NoteTypeBalance}},
+		note: newTNote(core.NoteTypeBalance, "", db.Poke),
+		exp:  true,
+	}, {
+		name: "topic",
+		cfg:  &EndpointConfig{Topics: []string{string(core.TopicMatchRevoked)}},
+		note: newTNote(core.NoteTypeMatch, core.TopicMatchRevoked, db.Poke),
+		exp:  true,
+	}, {
+		name: "wrong topic",
+		cfg:  &EndpointConfig{Topics: []string{string(core.TopicMatchRevoked)}},
+		note: newTNote(core.NoteTypeMatch, core.TopicAudit, db.Poke),
+	}}
+
+	for _, tt := range tests {
+		f, err := tt.cfg.filter()
+		if err != nil {
+			t.Fatalf("%s: filter error: %v", tt.name, err)
+		}
+		if f.matches(tt.note) != tt.exp {
+			t.Fatalf("%s: expected match = %t", tt.name, tt.exp)
+		}
+	}
+
+	if _, err := (&EndpointConfig{MinSeverity: "critical"}).filter(); err == nil {
+		t.Fatalf("no error for unknown severity")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *EndpointConfig
+		wantErr bool
+	}{{
+		name: "webhook",
+		cfg:  &EndpointConfig{Type: EndpointWebhook, URL: "https://example.com/hook"},
+	}, {
+		name:    "bad url",
+		cfg:     &EndpointConfig{Type: EndpointWebhook, URL: "example"},
+		wantErr: true,
+	}, {
+		name:    "unknown type",
+		cfg:     &EndpointConfig{Type: "pigeon", URL: "https://example.com"},
+		wantErr: true,
+	}, {
+		name:    "matrix without room",
+		cfg:     &EndpointConfig{Type: EndpointMatrix, URL: "https://matrix.org", Token: "abc"},
+		wantErr: true,
+	}, {
+		name:    "smtp without recipients",
+		cfg:     &EndpointConfig{Type: EndpointSMTP, SMTP: &SMTPConfig{Host: "mail.example.com:587", From: "a@example.com"}},
+		wantErr: true,
+	}, {
+		name:    "bad template",
+		cfg:     &EndpointConfig{Type: EndpointNtfy, URL: "https://ntfy.sh/topic", Template: "{{.Subject"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		_, err := New(&Config{Endpoints: []*EndpointConfig{tt.cfg}}, &tFeeder{}, tLogger)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: expected error = %t, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	_, err := New(&Config{Endpoints: []*EndpointConfig{
+		{Name: "a", Type: EndpointWebhook, URL: "https://example.com/1"},
+		{Name: "a", Type: EndpointWebhook, URL: "https://example.com/2"},
+	}}, &tFeeder{}, tLogger)
+	if err == nil {
+		t.Fatalf("no error for duplicate endpoint names")
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	webhook := newTServer()
+	defer webhook.Close()
+	ntfy := newTServer()
+	defer ntfy.Close()
+	matrix := newTServer()
+	defer matrix.Close()
+
+	noRetries := 0
+	cfg := &Config{Endpoints: []*EndpointConfig{{
+		Name:      "webhook",
+		Type:      EndpointWebhook,
+		URL:       webhook.URL + "/hook",
+		Headers:   map[string]string{"X-Api-Key": "key"},
+		NoteTypes: []string{core.NoteTypeMatch},
+	}, {
+		Name:     "ntfy",
+		Type:     EndpointNtfy,
+		URL:      ntfy.URL + "/dex",
+		Token:    "ntfytoken",
+		Template: "{{.Type}} {{.Severity}}: {{.Details}}",
+	}, {
+		Name:        "matrix",
+		Type:        EndpointMatrix,
+		URL:         matrix.URL,
+		Room:        "!room:example.com",
+		Token:       "matrixtoken",
+		MinSeverity: "error",
+		Retries:     &noRetries,
+	}, {
+		Name:      "smtp",
+		Type:      EndpointSMTP,
+		SMTP:      &SMTPConfig{Host: "mail.example.com:587", User: "u", Pass: "p", From: "dex@example.com", To: []string{"ops@example.com"}},
+		NoteTypes: []string{core.NoteTypeSecurity},
+	}}}
+
+	feeder := &tFeeder{ch: make(chan core.Notification, 16)}
+	d, err := New(cfg, feeder, tLogger)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	var mailMtx sync.Mutex
+	var mails []string
+	for _, ep := range d.endpoints {
+		ep.retryDelay = time.Millisecond
+		if s, is := ep.sender.(*smtpSender); is {
+			s.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				mailMtx.Lock()
+				defer mailMtx.Unlock()
+				if addr != "mail.example.com:587" || a == nil || from != "dex@example.com" || len(to) != 1 {
+					return errors.New("wrong smtp parameters")
+				}
+				mails = append(mails, string(msg))
+				return nil
+			}
+		}
+	}
+
+	webhook.failures = 2
+	matrix.failures = 1
+
+	wg, err := d.Connect(ctx)
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+
+	feeder.ch <- newTNote(core.NoteTypeMatch, core.TopicMatchRevoked, db.WarningLevel)
+	feeder.ch <- newTNote(core.NoteTypeBalance, "", db.ErrorLevel)
+	feeder.ch <- newTNote(core.NoteTypeSecurity, core.TopicSeedNeedsSaving, db.Success)
+	feeder.ch <- newTNote(core.NoteTypeWalletState, core.TopicWalletCommsWarning, db.ErrorLevel)
+
+	// The webhook is retried and only receives match notifications, as JSON.
+	reqs := webhook.waitForRequests(t, 1)
+	if reqs[0].path != "/hook" || reqs[0].contentType != "application/json" || reqs[0].headers.Get("X-Api-Key") != "key" {
+		t.Fatalf("wrong webhook request: %+v", reqs[0])
+	}
+	var note struct {
+		Type     string          `json:"type"`
+		Topic    string          `json:"topic"`
+		Severity string          `json:"severity"`
+		Payload  json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal([]byte(reqs[0].body), &note); err != nil {
+		t.Fatalf("error decoding webhook body: %v", err)
+	}
+	if note.Type != core.NoteTypeMatch || note.Topic != string(core.TopicMatchRevoked) || note.Severity != "warning" || len(note.Payload) == 0 {
+		t.Fatalf("wrong webhook note: %+v", note)
+	}
+
+	// ntfy receives the default types.
+	reqs = ntfy.waitForRequests(t, 3)
+	if reqs[0].body != "match warning: details" {
+		t.Fatalf("wrong ntfy body %q", reqs[0].body)
+	}
+	if reqs[0].headers.Get("Authorization") != "Bearer ntfytoken" || reqs[0].headers.Get("Title") != "subject "+string(core.TopicMatchRevoked) {
+		t.Fatalf("wrong ntfy headers %v", reqs[0].headers)
+	}
+	if reqs[2].headers.Get("Priority") != "high" {
+		t.Fatalf("wrong ntfy priority %q", reqs[2].headers.Get("Priority"))
+	}
+
+	// Matrix only accepts errors, and the only error notification failed
+	// without retries.
+	feeder.ch <- newTNote(core.NoteTypeBot, "", db.ErrorLevel)
+	reqs = matrix.waitForRequests(t, 1)
+	if reqs[0].method != http.MethodPut || !strings.HasPrefix(reqs[0].path, "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/") {
+		t.Fatalf("wrong matrix request %s %s", reqs[0].method, reqs[0].path)
+	}
+	var msg map[string]string
+	if err := json.Unmarshal([]byte(reqs[0].body), &msg); err != nil {
+		t.Fatalf("error decoding matrix body: %v", err)
+	}
+	if msg["msgtype"] != "m.text" || msg["body"] != "[error] subject : details" {
+		t.Fatalf("wrong matrix message %v", msg)
+	}
+
+	mailMtx.Lock()
+	if len(mails) != 1 || !strings.Contains(mails[0], "Subject: subject "+string(core.TopicSeedNeedsSaving)) {
+		t.Fatalf("wrong emails %v", mails)
+	}
+	mailMtx.Unlock()
+
+	cancel()
+	wg.Wait()
+
+	webhook.mtx.Lock()
+	defer webhook.mtx.Unlock()
+	if len(webhook.requests) != 1 {
+		t.Fatalf("expected 1 webhook request, got %d", len(webhook.requests))
+	}
+}
@@ -0,0 +1,152 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sender delivers a notification to an endpoint. msg is the rendered
+// template, and is empty for webhooks without a template.
+type sender interface {
+	send(ctx context.Context, n *Note, msg string) error
+}
+
+func postHTTP(ctx context.Context, client *http.Client, method, uri string, headers map[string]string, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+// webhookSender POSTs the JSON encoded Note, or the rendered template if one
+// is configured, to a URL.
+type webhookSender struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func (s *webhookSender) send(ctx context.Context, n *Note, msg string) error {
+	if msg != "" {
+		return postHTTP(ctx, s.client, http.MethodPost, s.url, s.headers, "text/plain; charset=utf-8", []byte(msg))
+	}
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return postHTTP(ctx, s.client, http.MethodPost, s.url, s.headers, "application/json", b)
+}
+
+// ntfySender publishes to an ntfy topic.
+type ntfySender struct {
+	client  *http.Client
+	url     string
+	token   string
+	headers map[string]string
+}
+
+func (s *ntfySender) send(ctx context.Context, n *Note, msg string) error {
+	headers := map[string]string{
+		"Title":    n.Subject,
+		"Priority": ntfyPriority(n.Severity),
+		"Tags":     n.Type,
+	}
+	if s.token != "" {
+		headers["Authorization"] = "Bearer " + s.token
+	}
+	for k, v := range s.headers {
+		headers[k] = v
+	}
+	return postHTTP(ctx, s.client, http.MethodPost, s.url, headers, "text/plain; charset=utf-8", []byte(msg))
+}
+
+func ntfyPriority(severity string) string {
+	switch severity {
+	case "error":
+		return "high"
+	case "warning":
+		return "default"
+	}
+	return "low"
+}
+
+// matrixSender sends a text message to a Matrix room using the client-server
+// API.
+type matrixSender struct {
+	client *http.Client
+	url    string
+	room   string
+	token  string
+	txnID  atomic.Uint64
+}
+
+func (s *matrixSender) send(ctx context.Context, n *Note, msg string) error {
+	txnID := fmt.Sprintf("%d-%d", time.Now().UnixNano(), s.txnID.Add(1))
+	uri := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(s.url, "/"), url.PathEscape(s.room), txnID)
+	b, err := json.Marshal(map[string]string{
+		"msgtype": "m.text",
+		"body":    msg,
+	})
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + s.token}
+	return postHTTP(ctx, s.client, http.MethodPut, uri, headers, "application/json", b)
+}
+
+// smtpSender emails the rendered template, with the notification subject as
+// the email subject.
+type smtpSender struct {
+	cfg *SMTPConfig
+	// sendMail is smtp.SendMail, and is replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (s *smtpSender) send(_ context.Context, n *Note, msg string) error {
+	var auth smtp.Auth
+	if s.cfg.User != "" {
+		host, _, err := net.SplitHostPort(s.cfg.Host)
+		if err != nil {
+			return fmt.Errorf("invalid smtp host %q: %w", s.cfg.Host, err)
+		}
+		auth = smtp.PlainAuth("", s.cfg.User, s.cfg.Pass, host)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", "", "\n", " ").Replace(n.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return s.sendMail(s.cfg.Host, auth, s.cfg.From, s.cfg.To, []byte(b.String()))
+}
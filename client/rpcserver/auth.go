@@ -0,0 +1,238 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package rpcserver
+
+import (
+	"context"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/mm"
+	"decred.org/dcrdex/dex/msgjson"
+)
+
+type ctxKey int
+
+// ctxAPIToken is the request context key for the *core.APIToken that
+// authenticated the request. Requests authenticated with the RPC user and
+// password do not have a token, and have full access.
+const ctxAPIToken ctxKey = iota
+
+func apiTokenFromContext(ctx context.Context) *core.APIToken {
+	tkn, _ := ctx.Value(ctxAPIToken).(*core.APIToken)
+	return tkn
+}
+
+// routeScopes maps routes to the API token scope that is required to use
+// them. Routes that are not listed require the admin scope. Routes that write
+// files to a path chosen by the caller must stay admin-only.
+var routeScopes = map[string]string{
+	// Routes that only read data are available to every token.
+	exchangesRoute:           core.APIScopeRead,
+	helpRoute:                core.APIScopeRead,
+	versionRoute:             core.APIScopeRead,
+	myOrdersRoute:            core.APIScopeRead,
+	orderBookRoute:           core.APIScopeRead,
+	getDEXConfRoute:          core.APIScopeRead,
+	bondAssetsRoute:          core.APIScopeRead,
+	walletsRoute:             core.APIScopeRead,
+	walletPeersRoute:         core.APIScopeRead,
+	notificationsRoute:       core.APIScopeRead,
+	mmAvailableBalancesRoute: core.APIScopeRead,
+	mmStatusRoute:            core.APIScopeRead,
+	mktRecorderStatusRoute:   core.APIScopeRead,
+	mmSchedulesRoute:         core.APIScopeRead,
+	portfolioConfigRoute:     core.APIScopeRead,
+	portfolioReallocsRoute:   core.APIScopeRead,
+	stakeStatusRoute:         core.APIScopeRead,
+	txHistoryRoute:           core.APIScopeRead,
+	walletTxRoute:            core.APIScopeRead,
+	checkBridgeApprovalRoute: core.APIScopeRead,
+	pendingBridgesRoute:      core.APIScopeRead,
+	bridgeHistoryRoute:       core.APIScopeRead,
+	conditionalOrdersRoute:   core.APIScopeRead,
+	executionsRoute:          core.APIScopeRead,
+	exportLedgerRoute:        core.APIScopeRead,
+	accountActivityRoute:     core.APIScopeRead,
+
+	tradeRoute:      core.APIScopeTrade,
+	multiTradeRoute: core.APIScopeTrade,
+	cancelRoute:     core.APIScopeTrade,
+	amendRoute:      core.APIScopeTrade,
+
+	conditionalTradeRoute:  core.APIScopeTrade,
+	cancelConditionalRoute: core.APIScopeTrade,
+	startExecutionRoute:    core.APIScopeTrade,
+	cancelExecutionRoute:   core.APIScopeTrade,
+
+	startBotRoute:            core.APIScopeMM,
+	stopBotRoute:             core.APIScopeMM,
+	updateRunningBotCfgRoute: core.APIScopeMM,
+	updateRunningBotInvRoute: core.APIScopeMM,
+	stopMktRecorderRoute:     core.APIScopeMM,
+	setMMScheduleRoute:       core.APIScopeMM,
+	removeMMScheduleRoute:    core.APIScopeMM,
+	setPortfolioConfigRoute:  core.APIScopeMM,
+	reallocatePortfolioRoute: core.APIScopeMM,
+
+	sendRoute:           core.APIScopeSend,
+	withdrawRoute:       core.APIScopeSend,
+	withdrawBchSpvRoute: core.APIScopeSend,
+	bridgeRoute:         core.APIScopeSend,
+}
+
+// routeMarkets returns the markets targeted by a request to a route in the
+// trade or mm scope. Routes in those scopes that are not listed are not
+// available to tokens that are restricted to specific markets.
+var routeMarkets = map[string]func(s *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error){
+	tradeRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseTradeArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		f := form.srvForm
+		return []*mm.MarketWithHost{{Host: f.Host, BaseID: f.Base, QuoteID: f.Quote}}, nil
+	},
+	multiTradeRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseMultiTradeArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		f := form.srvForm
+		return []*mm.MarketWithHost{{Host: f.Host, BaseID: f.Base, QuoteID: f.Quote}}, nil
+	},
+	cancelRoute: func(s *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseCancelArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		ord, err := s.core.Order(form.orderID)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{{Host: ord.Host, BaseID: ord.BaseID, QuoteID: ord.QuoteID}}, nil
+	},
+	amendRoute: func(s *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseAmendArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		ord, err := s.core.Order(form.orderID)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{{Host: ord.Host, BaseID: ord.BaseID, QuoteID: ord.QuoteID}}, nil
+	},
+	conditionalTradeRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseConditionalTradeArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		f := form.srvForm.Trade
+		return []*mm.MarketWithHost{{Host: f.Host, BaseID: f.Base, QuoteID: f.Quote}}, nil
+	},
+	cancelConditionalRoute: func(s *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		id, err := parseCancelConditionalArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		ord, err := s.core.ConditionalOrder(id)
+		if err != nil {
+			return nil, err
+		}
+		f := ord.Trade
+		return []*mm.MarketWithHost{{Host: f.Host, BaseID: f.Base, QuoteID: f.Quote}}, nil
+	},
+	startExecutionRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseStartExecutionArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		f := form.srvForm
+		return []*mm.MarketWithHost{{Host: f.Host, BaseID: f.Base, QuoteID: f.Quote}}, nil
+	},
+	cancelExecutionRoute: func(s *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		id, err := parseCancelExecutionArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		exec, err := s.core.Execution(id)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{{Host: exec.Host, BaseID: exec.Base, QuoteID: exec.Quote}}, nil
+	},
+	startBotRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseStartBotArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{form.mkt}, nil
+	},
+	stopBotRoute:          stopBotMarkets,
+	removeMMScheduleRoute: stopBotMarkets,
+	updateRunningBotCfgRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseUpdateRunningBotArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{form.mkt}, nil
+	},
+	updateRunningBotInvRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		form, err := parseUpdateRunningBotInventoryArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{form.mkt}, nil
+	},
+	setMMScheduleRoute: func(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+		sched, err := parseSetMMScheduleArgs(params)
+		if err != nil {
+			return nil, err
+		}
+		return []*mm.MarketWithHost{&sched.MarketWithHost}, nil
+	},
+}
+
+func stopBotMarkets(_ *RPCServer, params *RawParams) ([]*mm.MarketWithHost, error) {
+	mkt, err := parseStopBotArgs(params)
+	if err != nil {
+		return nil, err
+	}
+	return []*mm.MarketWithHost{mkt}, nil
+}
+
+// authorizeRoute checks that the API token that authenticated a request
+// grants access to the route and the markets that the request targets. A nil
+// token has full access.
+func (s *RPCServer) authorizeRoute(tkn *core.APIToken, route string, params *RawParams) *msgjson.Error {
+	if tkn == nil {
+		return nil
+	}
+	scope, found := routeScopes[route]
+	if !found {
+		scope = core.APIScopeAdmin
+	}
+	if !tkn.HasScope(scope) {
+		return msgjson.NewError(msgjson.UnauthorizedConnection, "API token %q does not have the %s scope required for %s",
+			tkn.Name, scope, route)
+	}
+	if !tkn.MarketRestricted() || tkn.HasScope(core.APIScopeAdmin) ||
+		(scope != core.APIScopeTrade && scope != core.APIScopeMM) {
+		return nil
+	}
+	getMarkets, found := routeMarkets[route]
+	if !found {
+		return msgjson.NewError(msgjson.UnauthorizedConnection, "%s is not available to API tokens restricted to markets", route)
+	}
+	mkts, err := getMarkets(s, params)
+	if err != nil {
+		return msgjson.NewError(msgjson.UnauthorizedConnection, "unable to determine the market for %s: %v", route, err)
+	}
+	for _, mkt := range mkts {
+		if !tkn.AllowsMarket(mkt.Host, mkt.BaseID, mkt.QuoteID) {
+			return msgjson.NewError(msgjson.UnauthorizedConnection, "API token %q is not allowed to use market %s", tkn.Name, mkt)
+		}
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 // routes
 const (
 	cancelRoute                = "cancel"
+	amendRoute                 = "amend"
 	closeWalletRoute           = "closewallet"
 	discoverAcctRoute          = "discoveracct"
 	exchangesRoute             = "exchanges"
@@ -57,6 +58,17 @@ const (
 	updateRunningBotInvRoute   = "updaterunningbotinv"
 	mmAvailableBalancesRoute   = "mmavailablebalances"
 	mmStatusRoute              = "mmstatus"
+	startMktRecorderRoute      = "startmktrecorder"
+	stopMktRecorderRoute       = "stopmktrecorder"
+	mktRecorderStatusRoute     = "mktrecorderstatus"
+	mmSchedulesRoute           = "mmschedules"
+	setMMScheduleRoute         = "setmmschedule"
+	removeMMScheduleRoute      = "removemmschedule"
+	exportMMRunRoute           = "exportmmrun"
+	portfolioConfigRoute       = "portfolioconfig"
+	setPortfolioConfigRoute    = "setportfolioconfig"
+	portfolioReallocsRoute     = "portfolioreallocations"
+	reallocatePortfolioRoute   = "reallocateportfolio"
 	multiTradeRoute            = "multitrade"
 	stakeStatusRoute           = "stakestatus"
 	setVSPRoute                = "setvsp"
@@ -70,18 +82,35 @@ const (
 	approveBridgeContractRoute = "approvebridgecontract"
 	pendingBridgesRoute        = "pendingbridges"
 	bridgeHistoryRoute         = "bridgehistory"
+	createAPITokenRoute        = "createapitoken"
+	apiTokensRoute             = "apitokens"
+	revokeAPITokenRoute        = "revokeapitoken"
+	conditionalTradeRoute      = "conditionaltrade"
+	conditionalOrdersRoute     = "conditionalorders"
+	cancelConditionalRoute     = "cancelconditional"
+	startExecutionRoute        = "startexecution"
+	executionsRoute            = "executions"
+	cancelExecutionRoute       = "cancelexecution"
+	exportLedgerRoute          = "exportledger"
+	exportReadOnlyRoute        = "exportreadonly"
+	importReadOnlyRoute        = "importreadonly"
+	accountActivityRoute       = "accountactivity"
 )
 
 const (
-	initializedStr    = "app initialized"
-	walletCreatedStr  = "%s wallet created and unlocked"
-	walletLockedStr   = "%s wallet locked"
-	walletUnlockedStr = "%s wallet unlocked"
-	canceledOrderStr  = "canceled order %s"
-	logoutStr         = "goodbye"
-	walletStatusStr   = "%s wallet has been %s"
-	setVotePrefsStr   = "vote preferences set"
-	setVSPStr         = "vsp set to %s"
+	initializedStr      = "app initialized"
+	walletCreatedStr    = "%s wallet created and unlocked"
+	walletLockedStr     = "%s wallet locked"
+	walletUnlockedStr   = "%s wallet unlocked"
+	canceledOrderStr    = "canceled order %s"
+	logoutStr           = "goodbye"
+	walletStatusStr     = "%s wallet has been %s"
+	setVotePrefsStr     = "vote preferences set"
+	setVSPStr           = "vsp set to %s"
+	revokedTokenStr     = "revoked API token %q"
+	canceledCondStr     = "canceled conditional order %s"
+	canceledExecStr     = "canceled execution %s"
+	importedReadOnlyStr = "read-only data imported"
 )
 
 // createResponse creates a msgjson response payload.
@@ -105,6 +134,7 @@ func usage(route string, err error) *msgjson.ResponsePayload {
 // routes maps routes to a handler function.
 var routes = map[string]func(s *RPCServer, params *RawParams) *msgjson.ResponsePayload{
 	cancelRoute:                handleCancel,
+	amendRoute:                 handleAmend,
 	closeWalletRoute:           handleCloseWallet,
 	discoverAcctRoute:          handleDiscoverAcct,
 	exchangesRoute:             handleExchanges,
@@ -137,6 +167,17 @@ var routes = map[string]func(s *RPCServer, params *RawParams) *msgjson.ResponseP
 	stopBotRoute:               handleStopBot,
 	mmAvailableBalancesRoute:   handleMMAvailableBalances,
 	mmStatusRoute:              handleMMStatus,
+	startMktRecorderRoute:      handleStartMktRecorder,
+	stopMktRecorderRoute:       handleStopMktRecorder,
+	mktRecorderStatusRoute:     handleMktRecorderStatus,
+	mmSchedulesRoute:           handleMMSchedules,
+	setMMScheduleRoute:         handleSetMMSchedule,
+	removeMMScheduleRoute:      handleRemoveMMSchedule,
+	exportMMRunRoute:           handleExportMMRun,
+	portfolioConfigRoute:       handlePortfolioConfig,
+	setPortfolioConfigRoute:    handleSetPortfolioConfig,
+	portfolioReallocsRoute:     handlePortfolioReallocations,
+	reallocatePortfolioRoute:   handleReallocatePortfolio,
 	updateRunningBotCfgRoute:   handleUpdateRunningBotCfg,
 	updateRunningBotInvRoute:   handleUpdateRunningBotInventory,
 	multiTradeRoute:            handleMultiTrade,
@@ -152,6 +193,19 @@ var routes = map[string]func(s *RPCServer, params *RawParams) *msgjson.ResponseP
 	approveBridgeContractRoute: handleApproveBridge,
 	pendingBridgesRoute:        handlePendingBridges,
 	bridgeHistoryRoute:         handleBridgeHistory,
+	createAPITokenRoute:        handleCreateAPIToken,
+	apiTokensRoute:             handleAPITokens,
+	revokeAPITokenRoute:        handleRevokeAPIToken,
+	conditionalTradeRoute:      handleConditionalTrade,
+	conditionalOrdersRoute:     handleConditionalOrders,
+	cancelConditionalRoute:     handleCancelConditional,
+	startExecutionRoute:        handleStartExecution,
+	executionsRoute:            handleExecutions,
+	cancelExecutionRoute:       handleCancelExecution,
+	exportLedgerRoute:          handleExportLedger,
+	exportReadOnlyRoute:        handleExportReadOnly,
+	importReadOnlyRoute:        handleImportReadOnly,
+	accountActivityRoute:       handleAccountActivity,
 }
 
 // handleHelp handles requests for help. Returns general help for all commands
@@ -569,6 +623,27 @@ func handleCancel(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
 	return createResponse(cancelRoute, &res, nil)
 }
 
+// handleAmend handles requests for amend. *msgjson.ResponsePayload.Error is
+// empty if successful.
+func handleAmend(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseAmendArgs(params)
+	if err != nil {
+		return usage(amendRoute, err)
+	}
+	defer form.appPass.Clear()
+	res, err := s.core.AmendOrder(form.appPass, form.orderID, form.rate, form.qty)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCAmendError, "unable to amend order %q: %v", form.orderID, err)
+		return createResponse(amendRoute, nil, resErr)
+	}
+	amendRes := &tradeResponse{
+		OrderID: res.ID.String(),
+		Sig:     res.Sig.String(),
+		Stamp:   res.Stamp,
+	}
+	return createResponse(amendRoute, &amendRes, nil)
+}
+
 // handleWithdraw handles requests for withdraw. *msgjson.ResponsePayload.Error
 // is empty if successful.
 func handleWithdraw(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
@@ -980,6 +1055,116 @@ func handleMMStatus(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
 	return createResponse(mmStatusRoute, status, nil)
 }
 
+func handleStartMktRecorder(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseStartMktRecorderArgs(params)
+	if err != nil {
+		return usage(startMktRecorderRoute, err)
+	}
+
+	err = s.mm.StartRecording(form.mkts, form.dir)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCMarketRecorderError, "unable to start market data recorder: %v", err)
+		return createResponse(startMktRecorderRoute, nil, resErr)
+	}
+
+	return createResponse(startMktRecorderRoute, s.mm.RecorderStatus(), nil)
+}
+
+func handleStopMktRecorder(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	err := s.mm.StopRecording()
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCMarketRecorderError, "unable to stop market data recorder: %v", err)
+		return createResponse(stopMktRecorderRoute, nil, resErr)
+	}
+
+	return createResponse(stopMktRecorderRoute, "stopped market data recorder", nil)
+}
+
+func handleMktRecorderStatus(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(mktRecorderStatusRoute, s.mm.RecorderStatus(), nil)
+}
+
+func handleMMSchedules(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(mmSchedulesRoute, s.mm.BotSchedules(), nil)
+}
+
+func handleSetMMSchedule(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	sched, err := parseSetMMScheduleArgs(params)
+	if err != nil {
+		return usage(setMMScheduleRoute, err)
+	}
+
+	if err := s.mm.UpdateBotSchedule(sched); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCMMScheduleError, "unable to set schedule: %v", err)
+		return createResponse(setMMScheduleRoute, nil, resErr)
+	}
+
+	return createResponse(setMMScheduleRoute, "set bot schedule", nil)
+}
+
+func handleRemoveMMSchedule(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	mkt, err := parseStopBotArgs(params)
+	if err != nil {
+		return usage(removeMMScheduleRoute, err)
+	}
+
+	if err := s.mm.RemoveBotSchedule(mkt); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCMMScheduleError, "unable to remove schedule: %v", err)
+		return createResponse(removeMMScheduleRoute, nil, resErr)
+	}
+
+	return createResponse(removeMMScheduleRoute, "removed bot schedule", nil)
+}
+
+func handleExportMMRun(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseExportMMRunArgs(params)
+	if err != nil {
+		return usage(exportMMRunRoute, err)
+	}
+
+	report, err := s.mm.ExportRun(form.startTime, form.mkt, form.format, form.eventsPath, form.pnlPath)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCMMExportError, "unable to export run: %v", err)
+		return createResponse(exportMMRunRoute, nil, resErr)
+	}
+
+	return createResponse(exportMMRunRoute, report, nil)
+}
+
+func handlePortfolioConfig(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(portfolioConfigRoute, s.mm.PortfolioConfig(), nil)
+}
+
+func handleSetPortfolioConfig(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	cfg, err := parseSetPortfolioConfigArgs(params)
+	if err != nil {
+		return usage(setPortfolioConfigRoute, err)
+	}
+
+	if err := s.mm.UpdatePortfolioConfig(cfg); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCPortfolioError, "unable to set portfolio config: %v", err)
+		return createResponse(setPortfolioConfigRoute, nil, resErr)
+	}
+
+	if cfg == nil {
+		return createResponse(setPortfolioConfigRoute, "removed portfolio config", nil)
+	}
+	return createResponse(setPortfolioConfigRoute, "set portfolio config", nil)
+}
+
+func handlePortfolioReallocations(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(portfolioReallocsRoute, s.mm.PortfolioReallocations(), nil)
+}
+
+func handleReallocatePortfolio(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	realloc, err := s.mm.ReallocatePortfolio()
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCPortfolioError, "unable to reallocate portfolio: %v", err)
+		return createResponse(reallocatePortfolioRoute, nil, resErr)
+	}
+	return createResponse(reallocatePortfolioRoute, realloc, nil)
+}
+
 func handleSetVSP(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
 	form, err := parseSetVSPArgs(params)
 	if err != nil {
@@ -1207,6 +1392,186 @@ func handleBridgeHistory(s *RPCServer, params *RawParams) *msgjson.ResponsePaylo
 	return createResponse(bridgeHistoryRoute, bridges, nil)
 }
 
+// handleCreateAPIToken handles requests for createapitoken.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleCreateAPIToken(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseCreateAPITokenArgs(params)
+	if err != nil {
+		return usage(createAPITokenRoute, err)
+	}
+	defer form.appPass.Clear()
+
+	token, tkn, err := s.core.CreateAPIToken(form.appPass, form.srvForm)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCAPITokenError, "unable to create API token: %v", err)
+		return createResponse(createAPITokenRoute, nil, resErr)
+	}
+
+	return createResponse(createAPITokenRoute, &createAPITokenResponse{Token: token, APIToken: tkn}, nil)
+}
+
+// handleAPITokens handles requests for apitokens.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleAPITokens(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(apiTokensRoute, s.core.APITokens(), nil)
+}
+
+// handleRevokeAPIToken handles requests for revokeapitoken.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleRevokeAPIToken(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	name, err := parseRevokeAPITokenArgs(params)
+	if err != nil {
+		return usage(revokeAPITokenRoute, err)
+	}
+
+	if err := s.core.RevokeAPIToken(name); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCAPITokenError, "unable to revoke API token: %v", err)
+		return createResponse(revokeAPITokenRoute, nil, resErr)
+	}
+
+	return createResponse(revokeAPITokenRoute, fmt.Sprintf(revokedTokenStr, name), nil)
+}
+
+// handleConditionalTrade handles requests for conditionaltrade.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleConditionalTrade(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseConditionalTradeArgs(params)
+	if err != nil {
+		return usage(conditionalTradeRoute, err)
+	}
+	defer form.appPass.Clear()
+
+	ord, err := s.core.PlaceConditionalOrder(form.appPass, form.srvForm)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCConditionalOrderError, "unable to place conditional order: %v", err)
+		return createResponse(conditionalTradeRoute, nil, resErr)
+	}
+	return createResponse(conditionalTradeRoute, ord, nil)
+}
+
+// handleConditionalOrders handles requests for conditionalorders.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleConditionalOrders(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(conditionalOrdersRoute, s.core.ConditionalOrders(), nil)
+}
+
+// handleCancelConditional handles requests for cancelconditional.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleCancelConditional(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	id, err := parseCancelConditionalArgs(params)
+	if err != nil {
+		return usage(cancelConditionalRoute, err)
+	}
+
+	if err := s.core.CancelConditionalOrder(id); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCConditionalOrderError, "unable to cancel conditional order: %v", err)
+		return createResponse(cancelConditionalRoute, nil, resErr)
+	}
+
+	return createResponse(cancelConditionalRoute, fmt.Sprintf(canceledCondStr, id), nil)
+}
+
+// handleStartExecution handles requests for startexecution.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleStartExecution(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseStartExecutionArgs(params)
+	if err != nil {
+		return usage(startExecutionRoute, err)
+	}
+	defer form.appPass.Clear()
+
+	exec, err := s.core.StartExecution(form.appPass, form.srvForm)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCExecutionError, "unable to start execution: %v", err)
+		return createResponse(startExecutionRoute, nil, resErr)
+	}
+	return createResponse(startExecutionRoute, exec, nil)
+}
+
+// handleExecutions handles requests for executions.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleExecutions(s *RPCServer, _ *RawParams) *msgjson.ResponsePayload {
+	return createResponse(executionsRoute, s.core.Executions(), nil)
+}
+
+// handleCancelExecution handles requests for cancelexecution.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleCancelExecution(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	id, err := parseCancelExecutionArgs(params)
+	if err != nil {
+		return usage(cancelExecutionRoute, err)
+	}
+
+	if err := s.core.CancelExecution(id); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCExecutionError, "unable to cancel execution: %v", err)
+		return createResponse(cancelExecutionRoute, nil, resErr)
+	}
+
+	return createResponse(cancelExecutionRoute, fmt.Sprintf(canceledExecStr, id), nil)
+}
+
+// handleExportLedger handles requests for exportledger.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleExportLedger(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseExportLedgerArgs(params)
+	if err != nil {
+		return usage(exportLedgerRoute, err)
+	}
+
+	var b strings.Builder
+	if err := s.core.ExportLedger(&b, form.filter, form.format); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCLedgerError, "unable to export ledger: %v", err)
+		return createResponse(exportLedgerRoute, nil, resErr)
+	}
+	return createResponse(exportLedgerRoute, b.String(), nil)
+}
+
+// handleExportReadOnly handles requests for exportreadonly.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleExportReadOnly(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	appPass, err := parseExportReadOnlyArgs(params)
+	if err != nil {
+		return usage(exportReadOnlyRoute, err)
+	}
+	defer appPass.Clear()
+	exp, err := s.core.ExportReadOnly(appPass)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCReadOnlyError, "unable to export read-only data: %v", err)
+		return createResponse(exportReadOnlyRoute, nil, resErr)
+	}
+	return createResponse(exportReadOnlyRoute, exp, nil)
+}
+
+// handleImportReadOnly handles requests for importreadonly.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleImportReadOnly(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	form, err := parseImportReadOnlyArgs(params)
+	if err != nil {
+		return usage(importReadOnlyRoute, err)
+	}
+	defer form.appPass.Clear()
+	if err := s.core.ImportReadOnly(form.appPass, form.exp); err != nil {
+		resErr := msgjson.NewError(msgjson.RPCReadOnlyError, "unable to import read-only data: %v", err)
+		return createResponse(importReadOnlyRoute, nil, resErr)
+	}
+	return createResponse(importReadOnlyRoute, importedReadOnlyStr, nil)
+}
+
+// handleAccountActivity handles requests for accountactivity.
+// *msgjson.ResponsePayload.Error is empty if successful.
+func handleAccountActivity(s *RPCServer, params *RawParams) *msgjson.ResponsePayload {
+	host, err := parseAccountActivityArgs(params)
+	if err != nil {
+		return usage(accountActivityRoute, err)
+	}
+	act, err := s.core.AccountActivity(host)
+	if err != nil {
+		resErr := msgjson.NewError(msgjson.RPCReadOnlyError, "unable to get account activity: %v", err)
+		return createResponse(accountActivityRoute, nil, resErr)
+	}
+	return createResponse(accountActivityRoute, act, nil)
+}
+
 // format concatenates thing and tail. If thing is empty, returns an empty
 // string.
 func format(thing, tail string) string {
@@ -1615,6 +1980,28 @@ var helpMsgs = map[string]helpMsg{
     orderID (string): The hex ID of the order to cancel`,
 		returns: `Returns:
     string: The message "` + fmt.Sprintf(canceledOrderStr, "[order ID]") + `"`,
+	},
+	amendRoute: {
+		pwArgsShort: `"appPass"`,
+		argsShort:   `"orderID" rate qty`,
+		cmdSummary: `Change the rate and quantity of a standing limit order. The order is
+canceled and replaced by a new limit order in the same epoch. The replacement
+is only matched if the cancel order executes. The cancel order of an amend is
+not counted against the account's cancellation rate.`,
+		pwArgsLong: `Password Args:
+    appPass (string): The Bison Wallet password.`,
+		argsLong: `Args:
+    orderID (string): The hex ID of the order to amend.
+    rate (int): The new rate, in atoms quote asset per unit base asset.
+    qty (int): The new quantity. Must be a multiple of the lot size.`,
+		returns: `Returns:
+    obj: The replacement order details.
+    {
+      "orderid" (string): The replacement order's unique hex identifier.
+      "sig" (string): The DEX's signature of the order information.
+      "stamp" (int): The time the order was signed in milliseconds since 00:00:00
+        Jan 1 1970.
+    }`,
 	},
 	rescanWalletRoute: {
 		argsShort: `assetID (force)`,
@@ -1842,6 +2229,161 @@ needed to complete a swap.`,
 	mmStatusRoute: {
 		cmdSummary: `Get market making status.`,
 	},
+	startMktRecorderRoute: {
+		cmdSummary: `Start recording DEX, and optionally CEX, order book updates for a
+list of markets. Each market is recorded to an append-only file that can be
+used to reconstruct the order book at any time or to run backtests.`,
+		argsShort: `(markets) (dir)`,
+		argsLong: `Args:
+		markets (array): The markets to record, e.g.
+    [{"host":"dex.decred.org:7232","baseID":42,"quoteID":0,"cexName":"Binance"}].
+    cexName is optional.
+		dir (string): Optional. The directory to write the files to. Defaults to
+    a mktdata directory next to the market making event log database.`,
+		returns: `Returns:
+  obj: The market data recorder status.`,
+	},
+	stopMktRecorderRoute: {
+		cmdSummary: `Stop recording market data.`,
+	},
+	mktRecorderStatusRoute: {
+		cmdSummary: `Get the status of the market data recorder.`,
+		returns: `Returns:
+  obj: The market data recorder status.
+    {
+      running (bool): Whether the recorder is running.
+      dir (string): The directory that market data is written to.
+      markets (array): The recorded markets, with the path of their files,
+        the number of records written and any error that stopped recording.
+    }`,
+	},
+	mmSchedulesRoute: {
+		cmdSummary: `Get the saved bot schedules.`,
+		returns: `Returns:
+  array: The bot schedules.
+    [
+      {
+        host (string): The DEX address.
+        baseID (int): The base asset's BIP-44 registered coin index.
+        quoteID (int): The quote asset's BIP-44 registered coin index.
+        disabled (bool): Whether the schedule is disabled.
+        entries (array): The scheduled actions.
+      },...
+    ]`,
+	},
+	setMMScheduleRoute: {
+		cmdSummary: `Save a schedule that starts, stops, or swaps the placements of the
+bot on a market. Any existing schedule for the market is replaced. Scheduled
+starts require that the bot's wallets are unlocked.`,
+		argsShort: `(schedule)`,
+		argsLong: `Args:
+		schedule (obj): The schedule, e.g.
+    {"host":"dex.decred.org:7232","baseID":42,"quoteID":0,"entries":[
+      {"cron":"0 22 * * fri","action":"stop"},
+      {"cron":"0 0 * * mon","action":"start","alloc":{"dex":{"42":1000000000,"0":10000000}}},
+      {"cron":"0 8 * * *","action":"placements","placements":{"buyPlacements":[{"lots":1,"gapFactor":0.01}]}}]}
+    cron is a five field cron expression (minute hour day-of-month month
+    day-of-week) evaluated in UTC. action is one of start, stop, or
+    placements. If a start action has no alloc, the allocation in the bot
+    config's rpcConfig is used.`,
+	},
+	removeMMScheduleRoute: {
+		cmdSummary: `Remove the schedule for a market.`,
+		argsShort:  `(host) (baseID) (quoteID)`,
+		argsLong: `Args:
+		host (string): The DEX address.
+		baseID (int): The base asset's BIP-44 registered coin index.
+		quoteID (int): The quote asset's BIP-44 registered coin index.`,
+	},
+	exportMMRunRoute: {
+		cmdSummary: `Export the events of a market making run and its profit and loss
+report. Amounts in the exported files are in conventional units. The paths are
+on the machine running bisonw.`,
+		argsShort: `(host) (baseID) (quoteID) (startTime) (format) (eventsPath) (pnlPath)`,
+		argsLong: `Args:
+		host (string): The DEX address.
+		baseID (int): The base asset's BIP-44 registered coin index.
+		quoteID (int): The quote asset's BIP-44 registered coin index.
+		startTime (int): The unix time in seconds that the run started.
+		format (string): csv or json. With json, events are written as JSON
+		  lines and the report as a JSON object.
+		eventsPath (string): The file to write the events to. Each CSV row
+		  includes the settled balance of each asset after the event.
+		pnlPath (string): (optional) The file to write the profit and loss
+		  report to.`,
+		returns: `Returns:
+  obj: The profit and loss report.
+    {
+      market (obj): The run's market.
+      startTime (int): The unix time that the run started.
+      endTime (int): The unix time that the run ended. Omitted if the run
+        has not ended.
+      assets (array): The initial, inventory changes, final, and difference
+        amounts for each asset.
+      initialUSD (float): The USD value of the initial balances.
+      modsUSD (float): The USD value of inventory changes.
+      finalUSD (float): The USD value of the final balances.
+      profitUSD (float): The profit in USD.
+      profitRatio (float): The profit as a ratio of the initial and added
+        balances.
+      profitBase (float): The profit in base asset units.
+      profitQuote (float): The profit in quote asset units.
+      finalBalances (obj): The final balances of each asset.
+      fiatRates (obj): The fiat rates used to value the balances.
+      dexOrders (int): The number of DEX orders.
+      cexOrders (int): The number of CEX orders.
+      deposits (int): The number of deposits.
+      withdrawals (int): The number of withdrawals.
+    }`,
+	},
+	portfolioConfigRoute: {
+		cmdSummary: `Get the portfolio allocator configuration.`,
+		returns: `Returns:
+  obj: The portfolio config, or null if there is none.
+    {
+      disabled (bool): Whether the allocator is disabled.
+      cron (string): When the portfolio is reallocated.
+      budgets (obj): The total DEX budget in atoms, keyed by asset ID.
+      markets (array): The markets that are included. Empty for all.
+      profitWeight (float): The weight of profitability in the shares.
+      minShare (float): The minimum share of a budget.
+    }`,
+	},
+	setPortfolioConfigRoute: {
+		cmdSummary: `Save the portfolio allocator configuration. The allocator
+periodically redistributes a total DEX budget of each asset between the running
+bots that trade it, based on their balance utilization and recent profitability.
+With no arguments, the configuration is removed.`,
+		argsShort: `(config)`,
+		argsLong: `Args:
+		config (obj): (optional) The portfolio config, e.g.
+    {"cron":"0 * * * *","budgets":{"42":10000000000,"0":50000000},
+      "profitWeight":0.5,"minShare":0.1}
+    cron is a five field cron expression evaluated in UTC. Default: hourly.
+    markets optionally limits the allocator to a list of markets, e.g.
+    [{"host":"dex.decred.org:7232","baseID":42,"quoteID":0}].`,
+	},
+	portfolioReallocsRoute: {
+		cmdSummary: `Get the most recent portfolio reallocations.`,
+		returns: `Returns:
+  array: The reallocations, oldest first.
+    [
+      {
+        stamp (int): The unix time of the reallocation.
+        bots (array): The change to each bot's DEX inventory, keyed by
+          asset ID, and any error updating the bot's inventory.
+      },...
+    ]`,
+	},
+	reallocatePortfolioRoute: {
+		cmdSummary: `Redistribute the portfolio budgets between the running bots now.`,
+		returns: `Returns:
+  obj: The reallocation.
+    {
+      stamp (int): The unix time of the reallocation.
+      bots (array): The change to each bot's DEX inventory.
+    }`,
+	},
 	updateRunningBotCfgRoute: {
 		cmdSummary: `Update the config and optionally the inventory of a running bot`,
 		argsShort:  `(cfgPath) (host) (baseID) (quoteID) (dexInventory) (cexInventory)`,
@@ -2015,4 +2557,251 @@ an spv wallet and enables options to view and set the vsp.
 		past (bool): If true, the transactions before the reference tx will be returned. If false, the
 		transactions after the reference tx will be returned.`,
 	},
+	createAPITokenRoute: {
+		pwArgsShort: `"appPass"`,
+		argsShort:   `"name" "scopes" ("markets") (expiration)`,
+		cmdSummary: `Create a named API token. RPC requests are authenticated with an API
+token by sending it as a bearer token in the Authorization header, e.g. with
+bwctl --rpctoken. Tokens are stored encrypted, and can only be used after the
+app is logged in. The token is only shown once.`,
+		pwArgsLong: `Password Args:
+    appPass (string): The Bison Wallet password.`,
+		argsLong: `Args:
+    name (string): A unique name for the token.
+    scopes (string): A comma-separated list of scopes granted to the token.
+      read: routes that only read data. Every token has this scope.
+      trade: trade, multitrade, cancel, conditional orders and executions.
+      mm: market making, and stopping the market data recorder. Starting
+        the recorder and exporting runs write files, and require admin.
+      send: send, withdraw and bridge funds.
+      admin: all routes, including wallet and token management.
+    markets (string): Optional comma-separated list of markets that the trade
+      and mm scopes are restricted to. A market is a market name, e.g.
+      dcr_btc, optionally prefixed with the DEX host and a slash, e.g.
+      dex.decred.org:7232/dcr_btc. An empty string for no restrictions.
+    expiration (string): Optional duration after which the token expires,
+      e.g. 720h. Default: never.`,
+		returns: `Returns:
+  obj: The token.
+  {
+    token (string): The API token.
+    apiToken (obj): {
+      name (string): The token's name.
+      scopes ([string]): The token's scopes.
+      markets ([string]): The markets that the token is restricted to.
+      created (int): The unix time that the token was created.
+      expiration (int): The unix time that the token expires. Omitted if the
+        token does not expire.
+    }
+  }`,
+	},
+	apiTokensRoute: {
+		cmdSummary: `List the API tokens. The tokens themselves are not shown.`,
+		returns: `Returns:
+  array: The tokens.
+  [
+    {
+      name (string): The token's name.
+      scopes ([string]): The token's scopes.
+      markets ([string]): The markets that the token is restricted to.
+      created (int): The unix time that the token was created.
+      expiration (int): The unix time that the token expires. Omitted if the
+        token does not expire.
+    },...
+  ]`,
+	},
+	revokeAPITokenRoute: {
+		cmdSummary: `Revoke an API token.`,
+		argsShort:  `"name"`,
+		argsLong: `Args:
+    name (string): The token's name.`,
+		returns: `Returns:
+    string: The message "` + fmt.Sprintf(revokedTokenStr, "[name]") + `"`,
+	},
+	conditionalTradeRoute: {
+		pwArgsShort: `"appPass"`,
+		argsShort:   `"type" "priceSource" triggerRate trailPct "host" isLimit sell base quote qty rate immediate options`,
+		cmdSummary: `Place a conditional order. The order is held by the client and is submitted
+when the market price reaches the trigger. The wallets for the market are
+unlocked when the conditional order is placed and must remain unlocked until
+it is triggered. Conditional orders are only watched while logged in.`,
+		pwArgsLong: `Password Args:
+    appPass (string): The Bison Wallet password.`,
+		argsLong: `Args:
+    type (string): The type of conditional order.
+      stop: submit a market order when the price moves against the order
+        to the trigger rate, i.e. falls to it for a sell or rises to it
+        for a buy.
+      stoplimit: the same as stop, but submit a limit order.
+      takeprofit: submit the order when the price moves in favor of the
+        order to the trigger rate.
+      trailingstop: a stop whose trigger follows the best price seen
+        since the order was placed at a distance of trailPct percent.
+    priceSource (string): The price that the trigger is evaluated against,
+      midgap or lasttrade. An empty string for midgap.
+    triggerRate (int): The trigger rate in atoms quote asset per unit base
+      asset. Ignored for trailing stops.
+    trailPct (float): The trailing stop distance in percent. Only used for
+      trailing stops.
+    host (string): The DEX to trade on.
+    isLimit (bool): Whether the order is a limit order.
+    sell (bool): Whether the order is selling.
+    base (int): The BIP-44 coin index for the market's base asset.
+    quote (int): The BIP-44 coin index for the market's quote asset.
+    qty (int): The number of units to buy/sell. Must be a multiple of the lot size.
+    rate (int): The limit rate. 0 for market orders.
+    immediate (bool): Require immediate match. Do not book the order.
+    options (string): A JSON-encoded string->string mapping of additional
+       trade options.`,
+		returns: `Returns:
+    obj: The conditional order.
+    {
+      "id" (string): The conditional order's hex ID.
+      "type" (string): The type of conditional order.
+      "priceSource" (string): The price source.
+      "triggerRate" (int): The trigger rate.
+      "trailPct" (float): The trailing stop distance in percent.
+      "trade" (obj): The order that is placed when triggered.
+      "status" (string): active, triggered or failed.
+      "stamp" (int): The time the conditional order was placed in
+        milliseconds since 00:00:00 Jan 1 1970.
+    }`,
+	},
+	conditionalOrdersRoute: {
+		cmdSummary: `List the conditional orders, newest first. Triggered and failed orders are
+included.`,
+		returns: `Returns:
+    array: The conditional orders. See conditionaltrade for the fields.
+      Triggered orders also have "triggerStamp", "triggeredRate" and the
+      hex "orderID" of the placed order, and failed orders have an
+      "error".`,
+	},
+	startExecutionRoute: {
+		pwArgsShort: `"appPass"`,
+		argsShort:   `(execution)`,
+		cmdSummary: `Start executing a large order over time as smaller limit orders using an
+execution algorithm. The child orders are placed at the book's mid-gap, but
+never at a worse rate than the limit rate. The wallets for the market are
+unlocked when the execution is started and must remain unlocked while it is
+active. Executions only run while logged in.`,
+		pwArgsLong: `Password Args:
+    appPass (string): The Bison Wallet password.`,
+		argsLong: `Args:
+    execution (obj): The execution, e.g.
+      {"algo":"twap","host":"dex.decred.org:7232","sell":true,"base":42,
+        "quote":0,"qty":10000000000,"rate":0,"duration":3600000,"slices":12}
+      algo is twap or iceberg. twap places slices equal child orders over
+      duration milliseconds. iceberg keeps one child order of visibleQty on
+      the book at a time. qty, visibleQty and rate are in atoms. An optional
+      driftPct reprices booked child orders that are more than driftPct
+      percent from the current rate. maxLock and options are as for
+      multitrade.`,
+		returns: `Returns:
+    obj: The execution.
+    {
+      "id" (string): The execution's hex ID.
+      "status" (string): active, completed or canceled.
+      "stamp" (int): The time the execution was started in milliseconds
+        since 00:00:00 Jan 1 1970.
+      "filled" (int): The filled quantity of all child orders.
+      "children" (array): The child orders.
+      ...the fields of the execution argument.
+    }`,
+	},
+	executionsRoute: {
+		cmdSummary: `List the executions, newest first. Completed and canceled executions are
+kept for 30 days.`,
+		returns: `Returns:
+    array: The executions. See startexecution for the fields. Finished
+      executions also have an "endStamp", and executions that failed to
+      place their latest child orders have a "placeError".`,
+	},
+	cancelExecutionRoute: {
+		argsShort:  `"id"`,
+		cmdSummary: `Cancel an active execution and its booked child orders.`,
+		argsLong: `Args:
+    id (string): The execution's hex ID.`,
+		returns: `Returns:
+    string: The message "` + fmt.Sprintf(canceledExecStr, "[id]") + `"`,
+	},
+	exportLedgerRoute: {
+		argsShort: `("format") ("host") (since) (until)`,
+		cmdSummary: `Export a ledger of the settled fills of all trades as CSV. Each fill has the
+amounts sent and received, the swap, redemption and refund fees, and the USD
+values and cost basis at the time of the match. Fills of matches made before
+fiat rates were recorded have no USD values. Refunded swaps are not trades, and
+only their fees are reported by the tax tool formats.`,
+		argsLong: `Args:
+    format (string): Optional. The CSV format. default for all fields, koinly
+      for the Koinly universal format, or cointracking for the CoinTracking
+      format. Default is default.
+    host (string): Optional. Only include trades on this DEX. All DEXes if
+      empty.
+    since (int): Optional. Only include fills matched at or after this time
+      in milliseconds since 00:00:00 Jan 1 1970.
+    until (int): Optional. Only include fills matched at or before this time
+      in milliseconds since 00:00:00 Jan 1 1970.`,
+		returns: `Returns:
+    string: The CSV ledger.`,
+	},
+	exportReadOnlyRoute: {
+		pwArgsShort: `"appPass"`,
+		cmdSummary: `Export the DEX accounts and watch-only wallet descriptors for a read-only
+  client. Import the result into a client running with --readonly using
+  importreadonly. The export includes the account keys, which the servers
+  require to authenticate, so keep it safe.`,
+		pwArgsLong: `Password Args:
+    appPass (string): The Bison Wallet password.`,
+		returns: `Returns:
+    obj: The read-only export.
+    {
+      "accounts" (array): The DEX accounts and their bonds, as from
+        exportaccount.
+      "watchOnly" (obj): The public descriptors for each wallet that supports
+        watch-only export, keyed by asset ID.
+    }`,
+	},
+	importReadOnlyRoute: {
+		pwArgsShort: `"appPass"`,
+		argsShort:   `"export"`,
+		cmdSummary: `Import the DEX accounts and watch-only wallet descriptors from
+  exportreadonly. The wallets for the descriptors must already be configured
+  as watch-only wallets, e.g. a Bitcoin Core descriptor wallet with private
+  keys disabled.`,
+		pwArgsLong: `Password Args:
+    appPass (string): The Bison Wallet password.`,
+		argsLong: `Args:
+    export (string): The JSON result of exportreadonly.`,
+		returns: `Returns:
+    string: The message "` + importedReadOnlyStr + `"`,
+	},
+	accountActivityRoute: {
+		argsShort: `"host"`,
+		cmdSummary: `Show the active orders, matches and bonds of the account as last reported
+  by the DEX. Only available with --readonly. The status is refreshed every
+  minute.`,
+		argsLong: `Args:
+    host (string): The DEX address.`,
+		returns: `Returns:
+    obj: The account activity.
+    {
+      "host" (string): The DEX address.
+      "stamp" (int): When the status was received, in milliseconds since
+        00:00:00 Jan 1 1970.
+      "orders" (array): The active orders, with hex "id" and "status".
+      "matches" (array): The active matches, with hex "orderID" and "matchID",
+        "qty", "rate", "status" and "side".
+      "bonds" (array): The active bonds, with "assetID", "coinID", "amount",
+        "expiry" and "strength".
+      "score" (int): The account's score.
+    }`,
+	},
+	cancelConditionalRoute: {
+		argsShort:  `"id"`,
+		cmdSummary: `Cancel a conditional order. The order is removed.`,
+		argsLong: `Args:
+    id (string): The hex ID of the conditional order.`,
+		returns: `Returns:
+    string: The message "` + fmt.Sprintf(canceledCondStr, "[id]") + `"`,
+	},
 }
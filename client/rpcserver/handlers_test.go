@@ -762,6 +762,261 @@ func TestHandleCancel(t *testing.T) {
 	}
 }
 
+func TestHandleAmend(t *testing.T) {
+	pw := encode.PassBytes("abc")
+	params := &RawParams{
+		PWArgs: []encode.PassBytes{pw},
+		Args:   []string{"fb94fe99e4e32200a341f0f1cb33f34a08ac23eedab636e8adb991fa76343e1e", "1000", "10"},
+	}
+	tests := []struct {
+		name        string
+		params      *RawParams
+		amendErr    error
+		wantErrCode int
+	}{{
+		name:        "ok",
+		params:      params,
+		wantErrCode: -1,
+	}, {
+		name:        "core.AmendOrder error",
+		params:      params,
+		amendErr:    errors.New("error"),
+		wantErrCode: msgjson.RPCAmendError,
+	}, {
+		name:        "bad params",
+		params:      &RawParams{PWArgs: []encode.PassBytes{pw}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{amendErr: test.amendErr}
+		r := &RPCServer{core: tc}
+		payload := handleAmend(r, test.params)
+		res := new(tradeResponse)
+		if err := verifyResponse(payload, res, test.wantErrCode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandleConditionalTrade(t *testing.T) {
+	pw := encode.PassBytes("abc")
+	params := &RawParams{
+		PWArgs: []encode.PassBytes{pw},
+		Args:   []string{"stoplimit", "lasttrade", "900", "0", "dex.com", "true", "true", "42", "0", "10", "1000", "false", "{}"},
+	}
+	tests := []struct {
+		name         string
+		params       *RawParams
+		condOrderErr error
+		wantErrCode  int
+	}{{
+		name:        "ok",
+		params:      params,
+		wantErrCode: -1,
+	}, {
+		name:         "core error",
+		params:       params,
+		condOrderErr: errors.New("error"),
+		wantErrCode:  msgjson.RPCConditionalOrderError,
+	}, {
+		name:        "bad params",
+		params:      &RawParams{PWArgs: []encode.PassBytes{pw}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{
+			condOrder:    &core.ConditionalOrder{ID: dex.Bytes{0x01}, Type: core.ConditionalStopLimit},
+			condOrderErr: test.condOrderErr,
+		}
+		r := &RPCServer{core: tc}
+		payload := handleConditionalTrade(r, test.params)
+		res := new(core.ConditionalOrder)
+		if err := verifyResponse(payload, res, test.wantErrCode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandleCancelConditional(t *testing.T) {
+	params := &RawParams{Args: []string{"0a0b"}}
+	tests := []struct {
+		name         string
+		params       *RawParams
+		condOrderErr error
+		wantErrCode  int
+	}{{
+		name:        "ok",
+		params:      params,
+		wantErrCode: -1,
+	}, {
+		name:         "core error",
+		params:       params,
+		condOrderErr: errors.New("error"),
+		wantErrCode:  msgjson.RPCConditionalOrderError,
+	}, {
+		name:        "bad id",
+		params:      &RawParams{Args: []string{"zz"}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{condOrderErr: test.condOrderErr}
+		r := &RPCServer{core: tc}
+		payload := handleCancelConditional(r, test.params)
+		res := ""
+		if err := verifyResponse(payload, &res, test.wantErrCode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandleStartExecution(t *testing.T) {
+	pw := encode.PassBytes("abc")
+	params := &RawParams{
+		PWArgs: []encode.PassBytes{pw},
+		Args:   []string{`{"algo":"twap","host":"dex.com","base":42,"quote":0,"qty":10,"duration":1000,"slices":2}`},
+	}
+	tests := []struct {
+		name        string
+		params      *RawParams
+		execErr     error
+		wantErrCode int
+	}{{
+		name:        "ok",
+		params:      params,
+		wantErrCode: -1,
+	}, {
+		name:        "core error",
+		params:      params,
+		execErr:     errors.New("error"),
+		wantErrCode: msgjson.RPCExecutionError,
+	}, {
+		name:        "bad params",
+		params:      &RawParams{PWArgs: []encode.PassBytes{pw}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{
+			exec:    &core.Execution{ID: dex.Bytes{0x01}, Status: core.ExecutionStatusActive},
+			execErr: test.execErr,
+		}
+		r := &RPCServer{core: tc}
+		payload := handleStartExecution(r, test.params)
+		res := new(core.Execution)
+		if err := verifyResponse(payload, res, test.wantErrCode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandleCancelExecution(t *testing.T) {
+	params := &RawParams{Args: []string{"0a0b"}}
+	tests := []struct {
+		name        string
+		params      *RawParams
+		execErr     error
+		wantErrCode int
+	}{{
+		name:        "ok",
+		params:      params,
+		wantErrCode: -1,
+	}, {
+		name:        "core error",
+		params:      params,
+		execErr:     errors.New("error"),
+		wantErrCode: msgjson.RPCExecutionError,
+	}, {
+		name:        "bad id",
+		params:      &RawParams{Args: []string{"zz"}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{execErr: test.execErr}
+		r := &RPCServer{core: tc}
+		payload := handleCancelExecution(r, test.params)
+		res := ""
+		if err := verifyResponse(payload, &res, test.wantErrCode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandleExportLedger(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      *RawParams
+		ledgerErr   error
+		wantErrCode int
+	}{{
+		name:        "ok",
+		params:      &RawParams{Args: []string{"koinly", "dex.com", "1000"}},
+		wantErrCode: -1,
+	}, {
+		name:        "core error",
+		params:      &RawParams{},
+		ledgerErr:   errors.New("error"),
+		wantErrCode: msgjson.RPCLedgerError,
+	}, {
+		name:        "bad format",
+		params:      &RawParams{Args: []string{"excel"}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{ledgerErr: test.ledgerErr}
+		r := &RPCServer{core: tc}
+		payload := handleExportLedger(r, test.params)
+		res := ""
+		if err := verifyResponse(payload, &res, test.wantErrCode); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if test.wantErrCode == -1 && res == "" {
+			t.Fatalf("%s: empty ledger", test.name)
+		}
+	}
+}
+
+func TestHandleImportReadOnly(t *testing.T) {
+	pw := encode.PassBytes("abc")
+	tests := []struct {
+		name        string
+		params      *RawParams
+		readOnlyErr error
+		wantErrCode int
+	}{{
+		name:        "ok",
+		params:      &RawParams{PWArgs: []encode.PassBytes{pw}, Args: []string{`{"accounts":[{"account":{"host":"dex.com"}}],"watchOnly":{"0":["wpkh(xpub/0/*)"]}}`}},
+		wantErrCode: -1,
+	}, {
+		name:        "core error",
+		params:      &RawParams{PWArgs: []encode.PassBytes{pw}, Args: []string{`{}`}},
+		readOnlyErr: errors.New("error"),
+		wantErrCode: msgjson.RPCReadOnlyError,
+	}, {
+		name:        "bad export",
+		params:      &RawParams{PWArgs: []encode.PassBytes{pw}, Args: []string{`{"accounts":`}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}, {
+		name:        "no password",
+		params:      &RawParams{Args: []string{`{}`}},
+		wantErrCode: msgjson.RPCArgumentsError,
+	}}
+	for _, test := range tests {
+		tc := &TCore{readOnlyErr: test.readOnlyErr}
+		r := &RPCServer{core: tc}
+		payload := handleImportReadOnly(r, test.params)
+		res := ""
+		if err := verifyResponse(payload, &res, test.wantErrCode); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if test.wantErrCode != -1 {
+			continue
+		}
+		exp := tc.readOnlyExport
+		if len(exp.Accounts) != 1 || exp.Accounts[0].Account.Host != "dex.com" || len(exp.WatchOnly[0]) != 1 {
+			t.Fatalf("%s: wrong export imported %+v", test.name, exp)
+		}
+	}
+}
+
 // tCoin satisfies the asset.Coin interface.
 type tCoin struct{}
 
@@ -17,6 +17,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -60,6 +61,7 @@ type clientCore interface {
 	AssetBalance(assetID uint32) (*core.WalletBalance, error)
 	Book(host string, base, quote uint32) (orderBook *core.OrderBook, err error)
 	Cancel(orderID dex.Bytes) error
+	AmendOrder(appPass []byte, orderID dex.Bytes, rate, qty uint64) (*core.Order, error)
 	CloseWallet(assetID uint32) error
 	CreateWallet(appPass, walletPass []byte, form *core.WalletForm) error
 	DiscoverAccount(dexAddr string, pass []byte, certI any) (*core.Exchange, bool, error)
@@ -83,6 +85,7 @@ type clientCore interface {
 	AddWalletPeer(assetID uint32, host string) error
 	RemoveWalletPeer(assetID uint32, host string) error
 	Notifications(int) (notes, pokes []*db.Notification, _ error)
+	NotificationFeed() *core.NoteFeed
 	MultiTrade(pw []byte, form *core.MultiTradeForm) []*core.MultiTradeResult
 	TxHistory(assetID uint32, n int, refID *string, past bool) ([]*asset.WalletTransaction, error)
 	WalletTransaction(assetID uint32, txID string) (*asset.WalletTransaction, error)
@@ -99,6 +102,30 @@ type clientCore interface {
 	PurchaseTickets(assetID uint32, pw []byte, n int) error
 	SetVotingPreferences(assetID uint32, choices, tSpendPolicy, treasuryPolicy map[string]string) error
 	GenerateBCHRecoveryTransaction(appPW []byte, recipient string) ([]byte, error)
+
+	// These are core's API token interface.
+	Order(oid dex.Bytes) (*core.Order, error)
+	CreateAPIToken(appPW []byte, form *core.APITokenForm) (string, *core.APIToken, error)
+	APITokens() []*core.APIToken
+	RevokeAPIToken(name string) error
+	AuthorizeAPIToken(token string) (*core.APIToken, error)
+
+	// These are core's conditional order interface.
+	PlaceConditionalOrder(appPW []byte, form *core.ConditionalOrderForm) (*core.ConditionalOrder, error)
+	ConditionalOrders() []*core.ConditionalOrder
+	ConditionalOrder(id dex.Bytes) (*core.ConditionalOrder, error)
+	CancelConditionalOrder(id dex.Bytes) error
+
+	// These are core's execution algorithm interface.
+	StartExecution(pw []byte, form *core.ExecutionForm) (*core.Execution, error)
+	Executions() []*core.Execution
+	Execution(id dex.Bytes) (*core.Execution, error)
+	CancelExecution(id dex.Bytes) error
+
+	ExportLedger(w io.Writer, filter *core.LedgerFilter, format core.LedgerFormat) error
+	ExportReadOnly(pw []byte) (*core.ReadOnlyExport, error)
+	ImportReadOnly(pw []byte, exp *core.ReadOnlyExport) error
+	AccountActivity(host string) (*core.AccountActivity, error)
 }
 
 // RPCServer is a single-client http and websocket server enabling a JSON
@@ -115,6 +142,9 @@ type RPCServer struct {
 	wg        sync.WaitGroup
 	bwVersion *SemVersion
 	ctx       context.Context
+	// profiles are the servers of the other user profiles, keyed by profile
+	// name. Requests under /profile/{name} are routed to them.
+	profiles map[string]*RPCServer
 }
 
 // genCertPair generates a key/cert pair to the paths provided.
@@ -190,7 +220,7 @@ func (s *RPCServer) handleJSON(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Responses not accepted", http.StatusMethodNotAllowed)
 		return
 	}
-	s.parseHTTPRequest(w, req)
+	s.parseHTTPRequest(w, req, apiTokenFromContext(r.Context()))
 }
 
 // Config holds variables needed to create a new RPC Server.
@@ -200,6 +230,17 @@ type Config struct {
 	Addr, User, Pass, Cert, Key string
 	BWVersion                   *SemVersion
 	CertHosts                   []string
+	// Profiles are additional user profiles served under /profile/{name}.
+	Profiles []*Profile
+}
+
+// Profile is a user profile with its own Core, MarketMaker and RPC
+// credentials.
+type Profile struct {
+	Name        string
+	Core        clientCore
+	MarketMaker *mm.MarketMaker
+	User, Pass  string
 }
 
 // SetLogger sets the logger for the RPCServer package.
@@ -273,9 +314,64 @@ func New(cfg *Config) (*RPCServer, error) {
 	// HTTPS endpoint
 	mux.Post("/", s.handleJSON)
 
+	if len(cfg.Profiles) > 0 {
+		if err := s.addProfiles(cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	return s, nil
 }
 
+// addProfiles creates a server for each of the profiles in the Config. The
+// profile servers share the listener of this server, but each has its own
+// credentials, so that the credentials of one profile cannot be used to
+// access another.
+func (s *RPCServer) addProfiles(cfg *Config) error {
+	s.profiles = make(map[string]*RPCServer, len(cfg.Profiles))
+	for _, p := range cfg.Profiles {
+		if p.Name == "" || strings.Contains(p.Name, "/") || s.profiles[p.Name] != nil {
+			return fmt.Errorf("invalid or duplicate profile name %q", p.Name)
+		}
+		if p.Pass == "" {
+			return fmt.Errorf("no rpc credentials for profile %q", p.Name)
+		}
+		if p.User == cfg.User && p.Pass == cfg.Pass {
+			return fmt.Errorf("profile %q must not use the rpc credentials of the default profile", p.Name)
+		}
+		pcfg := *cfg
+		pcfg.Core, pcfg.MarketMaker = p.Core, p.MarketMaker
+		pcfg.User, pcfg.Pass = p.User, p.Pass
+		pcfg.Profiles = nil
+		ps, err := New(&pcfg)
+		if err != nil {
+			return fmt.Errorf("error creating rpc server for profile %q: %w", p.Name, err)
+		}
+		s.profiles[p.Name] = ps
+	}
+	s.srv.Handler = s.profileRouter(s.mux)
+	return nil
+}
+
+// profileRouter routes requests under /profile/{name} to the profile's
+// server.
+func (s *RPCServer) profileRouter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, found := strings.CutPrefix(r.URL.Path, "/profile/")
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+		name, _, _ := strings.Cut(rest, "/")
+		ps := s.profiles[name]
+		if ps == nil {
+			http.Error(w, "unknown profile", http.StatusNotFound)
+			return
+		}
+		http.StripPrefix("/profile/"+name, ps.mux).ServeHTTP(w, r)
+	})
+}
+
 // Connect starts the RPC server. Satisfies the dex.Connector interface.
 func (s *RPCServer) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 	// Create listener.
@@ -305,6 +401,25 @@ func (s *RPCServer) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 		s.wsServer.HandleConnect(ctx, w, r)
 	})
 
+	// Stream notifications to subscribed websocket clients.
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.streamNotifications(ctx)
+	}()
+
+	for _, ps := range s.profiles {
+		ps.ctx, ps.addr = ctx, s.addr
+		ps.mux.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+			ps.wsServer.HandleConnect(ctx, w, r)
+		})
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ps.streamNotifications(ctx)
+		}()
+	}
+
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
@@ -314,14 +429,35 @@ func (s *RPCServer) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 		// Disconnect the websocket clients since http.(*Server).Shutdown does
 		// not deal with hijacked websocket connections.
 		s.wsServer.Shutdown()
+		for _, ps := range s.profiles {
+			ps.wsServer.Shutdown()
+		}
 		log.Infof("RPC server off")
 	}()
 	log.Infof("RPC server listening on %s", s.addr)
 	return &s.wg, nil
 }
 
+// streamNotifications reads from the Core notification channel and relays to
+// websocket clients with matching subscriptions.
+func (s *RPCServer) streamNotifications(ctx context.Context) {
+	ch := s.core.NotificationFeed()
+	defer ch.ReturnFeed()
+
+	for {
+		select {
+		case n := <-ch.C:
+			s.wsServer.StreamNote(n)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // handleRequest sends the request to the correct handler function if able.
-func (s *RPCServer) handleRequest(req *msgjson.Message) *msgjson.ResponsePayload {
+// The request is only handled if the API token that authenticated it, if
+// any, grants access to the route.
+func (s *RPCServer) handleRequest(req *msgjson.Message, tkn *core.APIToken) *msgjson.ResponsePayload {
 	payload := new(msgjson.ResponsePayload)
 	if req.Route == "" {
 		log.Debugf("route not specified")
@@ -345,13 +481,19 @@ func (s *RPCServer) handleRequest(req *msgjson.Message) *msgjson.ResponsePayload
 		return payload
 	}
 
+	if msgErr := s.authorizeRoute(tkn, req.Route, params); msgErr != nil {
+		log.Warnf("unauthorized %s request: %s", req.Route, msgErr.Message)
+		payload.Error = msgErr
+		return payload
+	}
+
 	return h(s, params)
 }
 
 // parseHTTPRequest parses the msgjson message in the request body, creates a
 // response message, and writes it to the http.ResponseWriter.
-func (s *RPCServer) parseHTTPRequest(w http.ResponseWriter, req *msgjson.Message) {
-	payload := s.handleRequest(req)
+func (s *RPCServer) parseHTTPRequest(w http.ResponseWriter, req *msgjson.Message, tkn *core.APIToken) {
+	payload := s.handleRequest(req, tkn)
 	resp, err := msgjson.NewResponse(req.ID, payload.Result, payload.Error)
 	if err != nil {
 		msg := fmt.Sprintf("error encoding response: %v", err)
@@ -362,7 +504,10 @@ func (s *RPCServer) parseHTTPRequest(w http.ResponseWriter, req *msgjson.Message
 	writeJSON(w, resp)
 }
 
-// authMiddleware checks incoming requests for authentication.
+// authMiddleware checks incoming requests for authentication. Requests are
+// authenticated with either the RPC user and password, using basic access
+// authentication, or an API token as a bearer token. The API token is added
+// to the request context.
 func (s *RPCServer) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fail := func() {
@@ -375,6 +520,17 @@ func (s *RPCServer) authMiddleware(next http.Handler) http.Handler {
 			fail()
 			return
 		}
+		if token, found := strings.CutPrefix(auth[0], "Bearer "); found {
+			tkn, err := s.core.AuthorizeAPIToken(token)
+			if err != nil {
+				log.Warnf("API token authentication failure from ip %s: %v", r.RemoteAddr, err)
+				fail()
+				return
+			}
+			log.Debugf("authenticated API token %q with ip: %s", tkn.Name, r.RemoteAddr)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxAPIToken, tkn)))
+			return
+		}
 		authSHA := sha256.Sum256([]byte(auth[0]))
 		if subtle.ConstantTimeCompare(s.authSHA[:], authSHA[:]) != 1 {
 			fail()
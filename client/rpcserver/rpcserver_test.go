@@ -11,8 +11,11 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -23,6 +26,7 @@ import (
 	"decred.org/dcrdex/client/mnemonic"
 	"decred.org/dcrdex/client/orderbook"
 	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
 	"decred.org/dcrdex/dex/msgjson"
 )
 
@@ -56,6 +60,7 @@ type TCore struct {
 	order                    *core.Order
 	tradeErr                 error
 	cancelErr                error
+	amendErr                 error
 	coin                     asset.Coin
 	sendErr                  error
 	logoutErr                error
@@ -71,6 +76,16 @@ type TCore struct {
 	stakeStatus              *asset.TicketStakingStatus
 	stakeStatusErr           error
 	setVotingPrefErr         error
+	apiTokens                map[string]*core.APIToken
+	createAPITokenErr        error
+	revokeAPITokenErr        error
+	condOrder                *core.ConditionalOrder
+	condOrderErr             error
+	exec                     *core.Execution
+	execErr                  error
+	ledgerErr                error
+	readOnlyExport           *core.ReadOnlyExport
+	readOnlyErr              error
 }
 
 func (c *TCore) Balance(uint32) (uint64, error) {
@@ -86,6 +101,12 @@ func (c *TCore) AssetBalance(uint32) (*core.WalletBalance, error) {
 func (c *TCore) Cancel(oid dex.Bytes) error {
 	return c.cancelErr
 }
+func (c *TCore) AmendOrder(appPass []byte, oid dex.Bytes, rate, qty uint64) (*core.Order, error) {
+	if c.amendErr != nil {
+		return nil, c.amendErr
+	}
+	return &core.Order{ID: dex.Bytes{0x01}, Rate: rate, Qty: qty}, nil
+}
 func (c *TCore) CreateWallet(appPW, walletPW []byte, form *core.WalletForm) error {
 	c.newWalletForm = form
 	return c.createWalletErr
@@ -177,6 +198,11 @@ func (c *TCore) RemoveWalletPeer(assetID uint32, address string) error {
 func (c *TCore) Notifications(n int) (notes, pokes []*db.Notification, _ error) {
 	return nil, nil, nil
 }
+func (c *TCore) NotificationFeed() *core.NoteFeed {
+	return &core.NoteFeed{
+		C: make(chan core.Notification, 1),
+	}
+}
 func (c *TCore) MultiTrade(appPass []byte, form *core.MultiTradeForm) []*core.MultiTradeResult {
 	return nil
 }
@@ -220,6 +246,90 @@ func (c *TCore) PendingBridges(fromAssetID uint32) ([]*asset.WalletTransaction,
 	return nil, nil
 }
 
+func (c *TCore) Order(oid dex.Bytes) (*core.Order, error) {
+	if c.order == nil {
+		return nil, errors.New("order not found")
+	}
+	return c.order, nil
+}
+func (c *TCore) CreateAPIToken(appPW []byte, form *core.APITokenForm) (string, *core.APIToken, error) {
+	return "token", &core.APIToken{Name: form.Name, Scopes: form.Scopes, Markets: form.Markets}, c.createAPITokenErr
+}
+func (c *TCore) APITokens() []*core.APIToken {
+	tkns := make([]*core.APIToken, 0, len(c.apiTokens))
+	for _, tkn := range c.apiTokens {
+		tkns = append(tkns, tkn)
+	}
+	return tkns
+}
+func (c *TCore) RevokeAPIToken(name string) error {
+	return c.revokeAPITokenErr
+}
+func (c *TCore) AuthorizeAPIToken(token string) (*core.APIToken, error) {
+	tkn, found := c.apiTokens[token]
+	if !found {
+		return nil, core.ErrUnknownAPIToken
+	}
+	return tkn, nil
+}
+func (c *TCore) PlaceConditionalOrder(appPW []byte, form *core.ConditionalOrderForm) (*core.ConditionalOrder, error) {
+	return c.condOrder, c.condOrderErr
+}
+func (c *TCore) ConditionalOrders() []*core.ConditionalOrder {
+	if c.condOrder == nil {
+		return nil
+	}
+	return []*core.ConditionalOrder{c.condOrder}
+}
+func (c *TCore) ConditionalOrder(id dex.Bytes) (*core.ConditionalOrder, error) {
+	if c.condOrder == nil {
+		return nil, errors.New("conditional order not found")
+	}
+	return c.condOrder, nil
+}
+func (c *TCore) CancelConditionalOrder(id dex.Bytes) error {
+	return c.condOrderErr
+}
+func (c *TCore) StartExecution(pw []byte, form *core.ExecutionForm) (*core.Execution, error) {
+	return c.exec, c.execErr
+}
+func (c *TCore) Executions() []*core.Execution {
+	if c.exec == nil {
+		return nil
+	}
+	return []*core.Execution{c.exec}
+}
+func (c *TCore) Execution(id dex.Bytes) (*core.Execution, error) {
+	if c.exec == nil {
+		return nil, errors.New("execution not found")
+	}
+	return c.exec, nil
+}
+func (c *TCore) CancelExecution(id dex.Bytes) error {
+	return c.execErr
+}
+func (c *TCore) ExportLedger(w io.Writer, filter *core.LedgerFilter, format core.LedgerFormat) error {
+	if c.ledgerErr != nil {
+		return c.ledgerErr
+	}
+	_, err := io.WriteString(w, "Time,Host\n")
+	return err
+}
+
+func (c *TCore) ExportReadOnly(pw []byte) (*core.ReadOnlyExport, error) {
+	return c.readOnlyExport, c.readOnlyErr
+}
+func (c *TCore) ImportReadOnly(pw []byte, exp *core.ReadOnlyExport) error {
+	c.readOnlyExport = exp
+	return c.readOnlyErr
+}
+func (c *TCore) AccountActivity(host string) (*core.AccountActivity, error) {
+	if c.readOnlyErr != nil {
+		return nil, c.readOnlyErr
+	}
+	return &core.AccountActivity{Host: host}, nil
+}
+
 type tBookFeed struct{}
 
 func (*tBookFeed) Next() <-chan *core.BookUpdate {
@@ -229,6 +339,9 @@ func (*tBookFeed) Close() {}
 func (*tBookFeed) Candles(dur string) error {
 	return nil
 }
+func (*tBookFeed) Trades() error {
+	return nil
+}
 
 func newTServer(t *testing.T, start bool, user, pass string) (*RPCServer, func()) {
 	tSrv, fn, err := newTServerWErr(t, start, user, pass)
@@ -541,3 +654,317 @@ func TestAuthMiddleware(t *testing.T) {
 		wantAuthError(test.name, test.wantErr)
 	}
 }
+
+func TestAPITokenAuth(t *testing.T) {
+	s, shutdown := newTServer(t, false, "user", "pass")
+	defer shutdown()
+
+	tradeTkn := &core.APIToken{Name: "trader", Scopes: []string{core.APIScopeTrade}, Markets: []string{"dcr_btc"}}
+	adminTkn := &core.APIToken{Name: "admin", Scopes: []string{core.APIScopeAdmin}, Markets: []string{"dcr_btc"}}
+	tCore := s.core.(*TCore)
+	tCore.apiTokens = map[string]*core.APIToken{
+		"tradetoken": tradeTkn,
+		"admintoken": adminTkn,
+	}
+
+	var reqTkn *core.APIToken
+	am := s.authMiddleware(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			reqTkn = apiTokenFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+	for _, test := range []struct {
+		name, auth string
+		wantTkn    *core.APIToken
+		wantCode   int
+	}{{
+		name:     "user and password",
+		auth:     "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")),
+		wantCode: http.StatusOK,
+	}, {
+		name:     "token",
+		auth:     "Bearer tradetoken",
+		wantTkn:  tradeTkn,
+		wantCode: http.StatusOK,
+	}, {
+		name:     "unknown token",
+		auth:     "Bearer unknown",
+		wantCode: http.StatusUnauthorized,
+	}} {
+		reqTkn = nil
+		r, _ := http.NewRequest("POST", "", nil)
+		r.Header.Set("Authorization", test.auth)
+		w := &tResponseWriter{}
+		am.ServeHTTP(w, r)
+		if w.code != test.wantCode {
+			t.Fatalf("%s: expected code %d, got %d", test.name, test.wantCode, w.code)
+		}
+		if reqTkn != test.wantTkn {
+			t.Fatalf("%s: wrong token in request context", test.name)
+		}
+	}
+
+	dcrBtcOrder := &core.Order{Host: "dex.com", BaseID: 42, QuoteID: 0}
+	tradeArgs := func(base, quote string) *RawParams {
+		return &RawParams{
+			PWArgs: []encode.PassBytes{encode.PassBytes("abc")},
+			Args:   []string{"dex.com", "true", "false", base, quote, "1", "1", "false", "{}"},
+		}
+	}
+	condTradeArgs := func(base, quote string) *RawParams {
+		params := tradeArgs(base, quote)
+		params.Args = append([]string{"stop", "", "1", "0"}, params.Args...)
+		return params
+	}
+	execArgs := func(base, quote uint32) *RawParams {
+		return &RawParams{
+			PWArgs: []encode.PassBytes{encode.PassBytes("abc")},
+			Args:   []string{fmt.Sprintf(`{"algo":"twap","host":"dex.com","base":%d,"quote":%d,"qty":10}`, base, quote)},
+		}
+	}
+	for _, test := range []struct {
+		name      string
+		tkn       *core.APIToken
+		route     string
+		params    *RawParams
+		order     *core.Order
+		condOrder *core.ConditionalOrder
+		exec      *core.Execution
+		wantErr   bool
+	}{{
+		name:  "no token",
+		route: sendRoute,
+	}, {
+		name:  "read route",
+		tkn:   tradeTkn,
+		route: walletsRoute,
+	}, {
+		name:    "missing scope",
+		tkn:     tradeTkn,
+		route:   sendRoute,
+		wantErr: true,
+	}, {
+		name:    "unlisted route requires admin",
+		tkn:     tradeTkn,
+		route:   appSeedRoute,
+		wantErr: true,
+	}, {
+		name:   "allowed market",
+		tkn:    tradeTkn,
+		route:  tradeRoute,
+		params: tradeArgs("42", "0"),
+	}, {
+		name:    "restricted market",
+		tkn:     tradeTkn,
+		route:   tradeRoute,
+		params:  tradeArgs("42", "60"),
+		wantErr: true,
+	}, {
+		name:   "cancel on allowed market",
+		tkn:    tradeTkn,
+		route:  cancelRoute,
+		params: &RawParams{Args: []string{"fb94fe99e4e32200a341f0f1cb33f34a08ac23eedab636e8adb991fa76343e1e"}},
+		order:  dcrBtcOrder,
+	}, {
+		name:    "cancel unknown order",
+		tkn:     tradeTkn,
+		route:   cancelRoute,
+		params:  &RawParams{Args: []string{"fb94fe99e4e32200a341f0f1cb33f34a08ac23eedab636e8adb991fa76343e1e"}},
+		wantErr: true,
+	}, {
+		name:  "amend on allowed market",
+		tkn:   tradeTkn,
+		route: amendRoute,
+		params: &RawParams{
+			PWArgs: []encode.PassBytes{encode.PassBytes("abc")},
+			Args:   []string{"fb94fe99e4e32200a341f0f1cb33f34a08ac23eedab636e8adb991fa76343e1e", "1000", "10"},
+		},
+		order: dcrBtcOrder,
+	}, {
+		name:  "amend unknown order",
+		tkn:   tradeTkn,
+		route: amendRoute,
+		params: &RawParams{
+			PWArgs: []encode.PassBytes{encode.PassBytes("abc")},
+			Args:   []string{"fb94fe99e4e32200a341f0f1cb33f34a08ac23eedab636e8adb991fa76343e1e", "1000", "10"},
+		},
+		wantErr: true,
+	}, {
+		name:   "conditional trade on allowed market",
+		tkn:    tradeTkn,
+		route:  conditionalTradeRoute,
+		params: condTradeArgs("42", "0"),
+	}, {
+		name:    "conditional trade on restricted market",
+		tkn:     tradeTkn,
+		route:   conditionalTradeRoute,
+		params:  condTradeArgs("42", "60"),
+		wantErr: true,
+	}, {
+		name:      "cancel conditional on allowed market",
+		tkn:       tradeTkn,
+		route:     cancelConditionalRoute,
+		params:    &RawParams{Args: []string{"0a0b"}},
+		condOrder: &core.ConditionalOrder{Trade: &core.TradeForm{Host: "dex.com", Base: 42, Quote: 0}},
+	}, {
+		name:    "cancel unknown conditional order",
+		tkn:     tradeTkn,
+		route:   cancelConditionalRoute,
+		params:  &RawParams{Args: []string{"0a0b"}},
+		wantErr: true,
+	}, {
+		name:   "execution on allowed market",
+		tkn:    tradeTkn,
+		route:  startExecutionRoute,
+		params: execArgs(42, 0),
+	}, {
+		name:    "execution on restricted market",
+		tkn:     tradeTkn,
+		route:   startExecutionRoute,
+		params:  execArgs(42, 60),
+		wantErr: true,
+	}, {
+		name:   "cancel execution on allowed market",
+		tkn:    tradeTkn,
+		route:  cancelExecutionRoute,
+		params: &RawParams{Args: []string{"0a0b"}},
+		exec:   &core.Execution{ExecutionForm: core.ExecutionForm{Host: "dex.com", Base: 42, Quote: 0}},
+	}, {
+		name:    "cancel unknown execution",
+		tkn:     tradeTkn,
+		route:   cancelExecutionRoute,
+		params:  &RawParams{Args: []string{"0a0b"}},
+		wantErr: true,
+	}, {
+		name:    "route without market",
+		tkn:     &core.APIToken{Name: "mm", Scopes: []string{core.APIScopeMM}, Markets: []string{"dcr_btc"}},
+		route:   stopMktRecorderRoute,
+		wantErr: true,
+	}, {
+		name:   "route without market, unrestricted",
+		tkn:    &core.APIToken{Name: "mm", Scopes: []string{core.APIScopeMM}},
+		route:  stopMktRecorderRoute,
+		params: &RawParams{},
+	}, {
+		name:    "file writing route requires admin",
+		tkn:     &core.APIToken{Name: "mm", Scopes: []string{core.APIScopeMM}},
+		route:   exportMMRunRoute,
+		wantErr: true,
+	}, {
+		name:    "recorder start requires admin",
+		tkn:     &core.APIToken{Name: "mm", Scopes: []string{core.APIScopeMM}},
+		route:   startMktRecorderRoute,
+		wantErr: true,
+	}, {
+		name:   "admin ignores market restrictions",
+		tkn:    adminTkn,
+		route:  tradeRoute,
+		params: tradeArgs("42", "60"),
+	}} {
+		tCore.order = test.order
+		tCore.condOrder = test.condOrder
+		tCore.exec = test.exec
+		params := test.params
+		if params == nil {
+			params = &RawParams{}
+		}
+		msgErr := s.authorizeRoute(test.tkn, test.route, params)
+		if (msgErr != nil) != test.wantErr {
+			t.Fatalf("%s: expected error = %t, got %v", test.name, test.wantErr, msgErr)
+		}
+		if msgErr != nil && msgErr.Code != msgjson.UnauthorizedConnection {
+			t.Fatalf("%s: wrong error code %d", test.name, msgErr.Code)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	tempDir := t.TempDir()
+	altCore := &TCore{apiTokens: map[string]*core.APIToken{"tkn": {Name: "alt"}}}
+	s, err := New(&Config{
+		Core:     &TCore{},
+		Addr:     "127.0.0.1:0",
+		Pass:     "abc",
+		Cert:     tempDir + "/cert.cert",
+		Key:      tempDir + "/key.key",
+		Profiles: []*Profile{{Name: "alt", Core: altCore, User: "alt", Pass: "def"}},
+	})
+	if err != nil {
+		t.Fatalf("error creating server: %v", err)
+	}
+
+	post := func(path, auth string) int {
+		t.Helper()
+		msg, _ := msgjson.NewRequest(1, "version", nil)
+		b, _ := json.Marshal(msg)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	basic := func(user, pass string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	}
+	for _, test := range []struct {
+		name, path, auth string
+		wantCode         int
+	}{{
+		// The token is only known to the alt profile's core.
+		name:     "alt token on default profile",
+		path:     "/",
+		auth:     "Bearer tkn",
+		wantCode: http.StatusUnauthorized,
+	}, {
+		name:     "alt token on alt profile",
+		path:     "/profile/alt/",
+		auth:     "Bearer tkn",
+		wantCode: http.StatusOK,
+	}, {
+		name:     "default credentials on default profile",
+		path:     "/",
+		auth:     basic("", "abc"),
+		wantCode: http.StatusOK,
+	}, {
+		name:     "default credentials on alt profile",
+		path:     "/profile/alt/",
+		auth:     basic("", "abc"),
+		wantCode: http.StatusUnauthorized,
+	}, {
+		name:     "alt credentials on alt profile",
+		path:     "/profile/alt/",
+		auth:     basic("alt", "def"),
+		wantCode: http.StatusOK,
+	}, {
+		name:     "alt credentials on default profile",
+		path:     "/",
+		auth:     basic("alt", "def"),
+		wantCode: http.StatusUnauthorized,
+	}, {
+		name:     "unknown profile",
+		path:     "/profile/nope/",
+		auth:     "Bearer tkn",
+		wantCode: http.StatusNotFound,
+	}} {
+		if code := post(test.path, test.auth); code != test.wantCode {
+			t.Fatalf("%s: wanted code %d, got %d", test.name, test.wantCode, code)
+		}
+	}
+
+	for _, p := range []*Profile{
+		{Name: "nocreds", Core: altCore},
+		{Name: "samecreds", Core: altCore, Pass: "abc"},
+	} {
+		_, err := New(&Config{
+			Core:     &TCore{},
+			Addr:     "127.0.0.1:0",
+			Pass:     "abc",
+			Cert:     tempDir + "/cert.cert",
+			Key:      tempDir + "/key.key",
+			Profiles: []*Profile{p},
+		})
+		if err == nil {
+			t.Fatalf("no error for profile %q", p.Name)
+		}
+	}
+}
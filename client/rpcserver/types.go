@@ -9,7 +9,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"decred.org/dcrdex/client/core"
@@ -161,6 +163,14 @@ type cancelForm struct {
 	orderID dex.Bytes
 }
 
+// amendForm is information necessary to amend a standing limit order.
+type amendForm struct {
+	appPass encode.PassBytes
+	orderID dex.Bytes
+	rate    uint64
+	qty     uint64
+}
+
 // sendOrWithdrawForm is information necessary to send or withdraw funds.
 type sendOrWithdrawForm struct {
 	appPass encode.PassBytes
@@ -217,6 +227,32 @@ type updateRunningBotInventoryForm struct {
 	balances *mm.BotInventoryDiffs
 }
 
+type startMktRecorderForm struct {
+	mkts []*mm.RecorderMarket
+	dir  string
+}
+
+type exportMMRunForm struct {
+	mkt        *mm.MarketWithHost
+	startTime  int64
+	format     mm.RunExportFormat
+	eventsPath string
+	pnlPath    string
+}
+
+// createAPITokenForm combines the application password and the API token
+// details.
+type createAPITokenForm struct {
+	appPass encode.PassBytes
+	srvForm *core.APITokenForm
+}
+
+// createAPITokenResponse is used when responding to the createapitoken route.
+type createAPITokenResponse struct {
+	Token    string         `json:"token"`
+	APIToken *core.APIToken `json:"apiToken"`
+}
+
 type setVSPForm struct {
 	assetID uint32
 	addr    string
@@ -684,6 +720,29 @@ func parseCancelArgs(params *RawParams) (*cancelForm, error) {
 	return &cancelForm{orderID: oidB}, nil
 }
 
+func parseAmendArgs(params *RawParams) (*amendForm, error) {
+	if err := checkNArgs(params, []int{1}, []int{3}); err != nil {
+		return nil, err
+	}
+	id := params.Args[0]
+	if len(id) != orderIdLen {
+		return nil, fmt.Errorf("%w: orderID has incorrect length", errArgs)
+	}
+	oidB, err := hex.DecodeString(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order id hex", errArgs)
+	}
+	rate, err := checkUIntArg(params.Args[1], "rate", 64)
+	if err != nil {
+		return nil, err
+	}
+	qty, err := checkUIntArg(params.Args[2], "qty", 64)
+	if err != nil {
+		return nil, err
+	}
+	return &amendForm{appPass: params.PWArgs[0], orderID: oidB, rate: rate, qty: qty}, nil
+}
+
 func parseSendOrWithdrawArgs(params *RawParams) (*sendOrWithdrawForm, error) {
 	if err := checkNArgs(params, []int{1}, []int{3}); err != nil {
 		return nil, err
@@ -946,6 +1005,87 @@ func parseStopBotArgs(params *RawParams) (*mm.MarketWithHost, error) {
 	return parseMktWithHost(params.Args[0], params.Args[1], params.Args[2])
 }
 
+func parseStartMktRecorderArgs(params *RawParams) (*startMktRecorderForm, error) {
+	if err := checkNArgs(params, []int{0}, []int{1, 2}); err != nil {
+		return nil, err
+	}
+	var mkts []*mm.RecorderMarket
+	if err := json.Unmarshal([]byte(params.Args[0]), &mkts); err != nil {
+		return nil, fmt.Errorf("%w: failed to unmarshal markets: %v", errArgs, err)
+	}
+	if len(mkts) == 0 {
+		return nil, fmt.Errorf("%w: no markets", errArgs)
+	}
+	for _, mkt := range mkts {
+		if mkt == nil || mkt.Host == "" {
+			return nil, fmt.Errorf("%w: market host not specified", errArgs)
+		}
+	}
+	form := &startMktRecorderForm{mkts: mkts}
+	if len(params.Args) > 1 {
+		form.dir = params.Args[1]
+	}
+	return form, nil
+}
+
+func parseSetMMScheduleArgs(params *RawParams) (*mm.BotSchedule, error) {
+	if err := checkNArgs(params, []int{0}, []int{1}); err != nil {
+		return nil, err
+	}
+	sched := new(mm.BotSchedule)
+	if err := json.Unmarshal([]byte(params.Args[0]), sched); err != nil {
+		return nil, fmt.Errorf("%w: failed to unmarshal schedule: %v", errArgs, err)
+	}
+	if sched.Host == "" {
+		return nil, fmt.Errorf("%w: market host not specified", errArgs)
+	}
+	return sched, nil
+}
+
+// parseSetPortfolioConfigArgs parses the portfolio config. A nil config
+// without an error means the config should be removed.
+func parseSetPortfolioConfigArgs(params *RawParams) (*mm.PortfolioConfig, error) {
+	if err := checkNArgs(params, []int{0}, []int{0, 1}); err != nil {
+		return nil, err
+	}
+	if len(params.Args) == 0 {
+		return nil, nil
+	}
+	cfg := new(mm.PortfolioConfig)
+	if err := json.Unmarshal([]byte(params.Args[0]), cfg); err != nil {
+		return nil, fmt.Errorf("%w: failed to unmarshal portfolio config: %v", errArgs, err)
+	}
+	return cfg, nil
+}
+
+func parseExportMMRunArgs(params *RawParams) (*exportMMRunForm, error) {
+	if err := checkNArgs(params, []int{0}, []int{6, 7}); err != nil {
+		return nil, err
+	}
+	mkt, err := parseMktWithHost(params.Args[0], params.Args[1], params.Args[2])
+	if err != nil {
+		return nil, err
+	}
+	startTime, err := checkIntArg(params.Args[3], "startTime", 64)
+	if err != nil {
+		return nil, err
+	}
+	format := mm.RunExportFormat(params.Args[4])
+	if format != mm.RunExportCSV && format != mm.RunExportJSON {
+		return nil, fmt.Errorf("%w: unknown format %q", errArgs, format)
+	}
+	form := &exportMMRunForm{
+		mkt:        mkt,
+		startTime:  startTime,
+		format:     format,
+		eventsPath: params.Args[5],
+	}
+	if len(params.Args) > 6 {
+		form.pnlPath = params.Args[6]
+	}
+	return form, nil
+}
+
 func parseUpdateRunningBotArgs(params *RawParams) (*updateRunningBotForm, error) {
 	if err := checkNArgs(params, []int{0}, []int{4, 6}); err != nil {
 		return nil, err
@@ -1168,3 +1308,192 @@ func parseWalletTxArgs(params *RawParams) (*walletTxForm, error) {
 		txID:    params.Args[1],
 	}, nil
 }
+
+func parseCreateAPITokenArgs(params *RawParams) (*createAPITokenForm, error) {
+	if err := checkNArgs(params, []int{1}, []int{2, 4}); err != nil {
+		return nil, err
+	}
+	splitList := func(s string) []string {
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	srvForm := &core.APITokenForm{
+		Name:   params.Args[0],
+		Scopes: splitList(params.Args[1]),
+	}
+	if len(srvForm.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: no scopes", errArgs)
+	}
+	if len(params.Args) > 2 {
+		srvForm.Markets = splitList(params.Args[2])
+	}
+	if len(params.Args) > 3 && params.Args[3] != "" {
+		d, err := time.ParseDuration(params.Args[3])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: invalid expiration %q", errArgs, params.Args[3])
+		}
+		srvForm.Expiration = time.Now().Add(d)
+	}
+	return &createAPITokenForm{appPass: params.PWArgs[0], srvForm: srvForm}, nil
+}
+
+// conditionalTradeForm combines the application password and the conditional
+// order details.
+type conditionalTradeForm struct {
+	appPass encode.PassBytes
+	srvForm *core.ConditionalOrderForm
+}
+
+func parseConditionalTradeArgs(params *RawParams) (*conditionalTradeForm, error) {
+	if err := checkNArgs(params, []int{1}, []int{13}); err != nil {
+		return nil, err
+	}
+	triggerRate, err := checkUIntArg(params.Args[2], "triggerRate", 64)
+	if err != nil {
+		return nil, err
+	}
+	trailPct, err := strconv.ParseFloat(params.Args[3], 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot parse trailPct: %v", errArgs, err)
+	}
+	trade, err := parseTradeArgs(&RawParams{PWArgs: params.PWArgs, Args: params.Args[4:]})
+	if err != nil {
+		return nil, err
+	}
+	return &conditionalTradeForm{
+		appPass: params.PWArgs[0],
+		srvForm: &core.ConditionalOrderForm{
+			Type:        params.Args[0],
+			PriceSource: params.Args[1],
+			TriggerRate: triggerRate,
+			TrailPct:    trailPct,
+			Trade:       trade.srvForm,
+		},
+	}, nil
+}
+
+func parseCancelConditionalArgs(params *RawParams) (dex.Bytes, error) {
+	if err := checkNArgs(params, []int{0}, []int{1}); err != nil {
+		return nil, err
+	}
+	id, err := hex.DecodeString(params.Args[0])
+	if err != nil || len(id) == 0 {
+		return nil, fmt.Errorf("%w: invalid conditional order id", errArgs)
+	}
+	return id, nil
+}
+
+// startExecutionForm combines the application password and the execution
+// details.
+type startExecutionForm struct {
+	appPass encode.PassBytes
+	srvForm *core.ExecutionForm
+}
+
+func parseStartExecutionArgs(params *RawParams) (*startExecutionForm, error) {
+	if err := checkNArgs(params, []int{1}, []int{1}); err != nil {
+		return nil, err
+	}
+	form := new(core.ExecutionForm)
+	if err := json.Unmarshal([]byte(params.Args[0]), form); err != nil {
+		return nil, fmt.Errorf("%w: failed to unmarshal execution: %v", errArgs, err)
+	}
+	if form.Host == "" {
+		return nil, fmt.Errorf("%w: host not specified", errArgs)
+	}
+	return &startExecutionForm{appPass: params.PWArgs[0], srvForm: form}, nil
+}
+
+func parseCancelExecutionArgs(params *RawParams) (dex.Bytes, error) {
+	if err := checkNArgs(params, []int{0}, []int{1}); err != nil {
+		return nil, err
+	}
+	id, err := hex.DecodeString(params.Args[0])
+	if err != nil || len(id) == 0 {
+		return nil, fmt.Errorf("%w: invalid execution id", errArgs)
+	}
+	return id, nil
+}
+
+// exportLedgerForm is information necessary to export a ledger.
+type exportLedgerForm struct {
+	format core.LedgerFormat
+	filter *core.LedgerFilter
+}
+
+func parseExportLedgerArgs(params *RawParams) (*exportLedgerForm, error) {
+	if err := checkNArgs(params, []int{0}, []int{0, 4}); err != nil {
+		return nil, err
+	}
+	form := &exportLedgerForm{
+		format: core.LedgerFormatDefault,
+		filter: new(core.LedgerFilter),
+	}
+	if len(params.Args) > 0 && params.Args[0] != "" {
+		form.format = core.LedgerFormat(params.Args[0])
+		if !slices.Contains(core.LedgerFormats, form.format) {
+			return nil, fmt.Errorf("%w: unknown ledger format %q", errArgs, params.Args[0])
+		}
+	}
+	if len(params.Args) > 1 && params.Args[1] != "" {
+		form.filter.Hosts = []string{params.Args[1]}
+	}
+	if len(params.Args) > 2 {
+		since, err := checkUIntArg(params.Args[2], "since", 64)
+		if err != nil {
+			return nil, err
+		}
+		form.filter.Since = since
+	}
+	if len(params.Args) > 3 {
+		until, err := checkUIntArg(params.Args[3], "until", 64)
+		if err != nil {
+			return nil, err
+		}
+		form.filter.Until = until
+	}
+	return form, nil
+}
+
+func parseExportReadOnlyArgs(params *RawParams) (encode.PassBytes, error) {
+	if err := checkNArgs(params, []int{1}, []int{0}); err != nil {
+		return nil, err
+	}
+	return params.PWArgs[0], nil
+}
+
+// importReadOnlyForm is information necessary to import read-only data.
+type importReadOnlyForm struct {
+	appPass encode.PassBytes
+	exp     *core.ReadOnlyExport
+}
+
+func parseImportReadOnlyArgs(params *RawParams) (*importReadOnlyForm, error) {
+	if err := checkNArgs(params, []int{1}, []int{1}); err != nil {
+		return nil, err
+	}
+	exp := new(core.ReadOnlyExport)
+	if err := json.Unmarshal([]byte(params.Args[0]), exp); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse export: %v", errArgs, err)
+	}
+	return &importReadOnlyForm{appPass: params.PWArgs[0], exp: exp}, nil
+}
+
+func parseAccountActivityArgs(params *RawParams) (string, error) {
+	if err := checkNArgs(params, []int{0}, []int{1}); err != nil {
+		return "", err
+	}
+	return params.Args[0], nil
+}
+
+func parseRevokeAPITokenArgs(params *RawParams) (string, error) {
+	if err := checkNArgs(params, []int{0}, []int{1}); err != nil {
+		return "", err
+	}
+	return params.Args[0], nil
+}
@@ -7,8 +7,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
+	"decred.org/dcrdex/client/core"
 	"decred.org/dcrdex/dex/encode"
 )
 
@@ -482,6 +484,53 @@ func TestParseCancelArgs(t *testing.T) {
 	}
 }
 
+func TestParseAmendArgs(t *testing.T) {
+	const oid = "fb94fe99e4e32200a341f0f1cb33f34a08ac23eedab636e8adb991fa76343e1e"
+	paramsWithArgs := func(args ...string) *RawParams {
+		pw := encode.PassBytes("abc")
+		return &RawParams{PWArgs: []encode.PassBytes{pw}, Args: args}
+	}
+	tests := []struct {
+		name    string
+		params  *RawParams
+		wantErr error
+	}{{
+		name:   "ok",
+		params: paramsWithArgs(oid, "1000", "10"),
+	}, {
+		name:    "order ID incorrect length",
+		params:  paramsWithArgs(oid[2:], "1000", "10"),
+		wantErr: errArgs,
+	}, {
+		name:    "bad rate",
+		params:  paramsWithArgs(oid, "-1", "10"),
+		wantErr: errArgs,
+	}, {
+		name:    "bad qty",
+		params:  paramsWithArgs(oid, "1000", "abc"),
+		wantErr: errArgs,
+	}, {
+		name:    "not enough args",
+		params:  paramsWithArgs(oid, "1000"),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseAmendArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if form.orderID.String() != oid || form.rate != 1000 || form.qty != 10 {
+			t.Fatalf("%q: wrong form values %+v", test.name, form)
+		}
+	}
+}
+
 func TestParseSendOrWithdrawArgs(t *testing.T) {
 	paramsWithArgs := func(id, value string) *RawParams {
 		pw := encode.PassBytes("password123")
@@ -902,3 +951,397 @@ func TestParseSetVotingPreferencesArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStartMktRecorderArgs(t *testing.T) {
+	paramsWithArgs := func(args ...string) *RawParams {
+		return &RawParams{Args: args}
+	}
+	tests := []struct {
+		name    string
+		params  *RawParams
+		expMkts int
+		expDir  string
+		wantErr error
+	}{{
+		name:    "ok",
+		params:  paramsWithArgs(`[{"host":"127.0.0.1:17273","baseID":42,"quoteID":0,"cexName":"Binance"},{"host":"127.0.0.1:17273","baseID":60,"quoteID":0}]`),
+		expMkts: 2,
+	}, {
+		name:    "ok with dir",
+		params:  paramsWithArgs(`[{"host":"127.0.0.1:17273","baseID":42,"quoteID":0}]`, "/tmp/mktdata"),
+		expMkts: 1,
+		expDir:  "/tmp/mktdata",
+	}, {
+		name:    "bad json",
+		params:  paramsWithArgs(`[{"host":`),
+		wantErr: errArgs,
+	}, {
+		name:    "no markets",
+		params:  paramsWithArgs(`[]`),
+		wantErr: errArgs,
+	}, {
+		name:    "no host",
+		params:  paramsWithArgs(`[{"baseID":42,"quoteID":0}]`),
+		wantErr: errArgs,
+	}, {
+		name:    "no args",
+		params:  paramsWithArgs(),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseStartMktRecorderArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if len(form.mkts) != test.expMkts || form.dir != test.expDir {
+			t.Fatalf("%q: wrong form %+v", test.name, form)
+		}
+	}
+}
+
+func TestParseSetPortfolioConfigArgs(t *testing.T) {
+	paramsWithArgs := func(args ...string) *RawParams {
+		return &RawParams{Args: args}
+	}
+	tests := []struct {
+		name       string
+		params     *RawParams
+		expBudgets int
+		wantErr    error
+	}{{
+		name:       "ok",
+		params:     paramsWithArgs(`{"cron":"0 * * * *","budgets":{"42":10000000000,"0":50000000},"profitWeight":0.5}`),
+		expBudgets: 2,
+	}, {
+		name:   "remove",
+		params: paramsWithArgs(),
+	}, {
+		name:    "bad json",
+		params:  paramsWithArgs(`{"budgets":`),
+		wantErr: errArgs,
+	}, {
+		name:    "too many args",
+		params:  paramsWithArgs(`{}`, `{}`),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		cfg, err := parseSetPortfolioConfigArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if test.expBudgets == 0 {
+			if cfg != nil {
+				t.Fatalf("%q: expected nil config", test.name)
+			}
+			continue
+		}
+		if len(cfg.Budgets) != test.expBudgets {
+			t.Fatalf("%q: expected %d budgets, got %d", test.name, test.expBudgets, len(cfg.Budgets))
+		}
+	}
+}
+
+func TestParseSetMMScheduleArgs(t *testing.T) {
+	paramsWithArgs := func(args ...string) *RawParams {
+		return &RawParams{Args: args}
+	}
+	tests := []struct {
+		name       string
+		params     *RawParams
+		expEntries int
+		wantErr    error
+	}{{
+		name:       "ok",
+		params:     paramsWithArgs(`{"host":"127.0.0.1:17273","baseID":42,"quoteID":0,"entries":[{"cron":"0 22 * * fri","action":"stop"},{"cron":"0 0 * * mon","action":"start"}]}`),
+		expEntries: 2,
+	}, {
+		name:    "bad json",
+		params:  paramsWithArgs(`{"host":`),
+		wantErr: errArgs,
+	}, {
+		name:    "no host",
+		params:  paramsWithArgs(`{"baseID":42,"quoteID":0,"entries":[]}`),
+		wantErr: errArgs,
+	}, {
+		name:    "no args",
+		params:  paramsWithArgs(),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		sched, err := parseSetMMScheduleArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if len(sched.Entries) != test.expEntries {
+			t.Fatalf("%q: expected %d entries, got %d", test.name, test.expEntries, len(sched.Entries))
+		}
+	}
+}
+
+func TestParseExportMMRunArgs(t *testing.T) {
+	paramsWithArgs := func(args ...string) *RawParams {
+		return &RawParams{Args: args}
+	}
+	tests := []struct {
+		name    string
+		params  *RawParams
+		expPnL  string
+		wantErr error
+	}{{
+		name:   "ok",
+		params: paramsWithArgs("127.0.0.1:17273", "42", "0", "1700000000", "csv", "/tmp/events.csv", "/tmp/pnl.csv"),
+		expPnL: "/tmp/pnl.csv",
+	}, {
+		name:   "no pnl path",
+		params: paramsWithArgs("127.0.0.1:17273", "42", "0", "1700000000", "json", "/tmp/events.jsonl"),
+	}, {
+		name:    "bad start time",
+		params:  paramsWithArgs("127.0.0.1:17273", "42", "0", "yesterday", "csv", "/tmp/events.csv"),
+		wantErr: errArgs,
+	}, {
+		name:    "bad format",
+		params:  paramsWithArgs("127.0.0.1:17273", "42", "0", "1700000000", "xlsx", "/tmp/events.xlsx"),
+		wantErr: errArgs,
+	}, {
+		name:    "not enough args",
+		params:  paramsWithArgs("127.0.0.1:17273", "42", "0", "1700000000", "csv"),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseExportMMRunArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		if form.startTime != 1700000000 || form.mkt.BaseID != 42 {
+			t.Fatalf("%q: wrong form values", test.name)
+		}
+		if form.pnlPath != test.expPnL {
+			t.Fatalf("%q: expected pnl path %q, got %q", test.name, test.expPnL, form.pnlPath)
+		}
+	}
+}
+
+func TestParseCreateAPITokenArgs(t *testing.T) {
+	paramsWithArgs := func(args ...string) *RawParams {
+		pw := encode.PassBytes("abc")
+		return &RawParams{PWArgs: []encode.PassBytes{pw}, Args: args}
+	}
+	tests := []struct {
+		name       string
+		params     *RawParams
+		expScopes  int
+		expMarkets int
+		expExpires bool
+		wantErr    error
+	}{{
+		name:      "ok",
+		params:    paramsWithArgs("bot", "trade, mm"),
+		expScopes: 2,
+	}, {
+		name:       "markets and expiration",
+		params:     paramsWithArgs("bot", "trade", "dcr_btc,dex.com:7232/eth_btc", "720h"),
+		expScopes:  1,
+		expMarkets: 2,
+		expExpires: true,
+	}, {
+		name:      "empty markets",
+		params:    paramsWithArgs("bot", "read", "", ""),
+		expScopes: 1,
+	}, {
+		name:    "no scopes",
+		params:  paramsWithArgs("bot", " , "),
+		wantErr: errArgs,
+	}, {
+		name:    "bad expiration",
+		params:  paramsWithArgs("bot", "trade", "", "tomorrow"),
+		wantErr: errArgs,
+	}, {
+		name:    "negative expiration",
+		params:  paramsWithArgs("bot", "trade", "", "-1h"),
+		wantErr: errArgs,
+	}, {
+		name:    "not enough args",
+		params:  paramsWithArgs("bot"),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseCreateAPITokenArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		f := form.srvForm
+		if f.Name != "bot" || len(f.Scopes) != test.expScopes || len(f.Markets) != test.expMarkets {
+			t.Fatalf("%q: wrong form values %+v", test.name, f)
+		}
+		if f.Expiration.IsZero() == test.expExpires {
+			t.Fatalf("%q: expected expiration = %t", test.name, test.expExpires)
+		}
+	}
+}
+
+func TestParseConditionalTradeArgs(t *testing.T) {
+	paramsWithArgs := func(args ...string) *RawParams {
+		pw := encode.PassBytes("abc")
+		tradeArgs := []string{"dex.com", "true", "true", "42", "0", "10", "1000", "false", "{}"}
+		return &RawParams{PWArgs: []encode.PassBytes{pw}, Args: append(args, tradeArgs...)}
+	}
+	tests := []struct {
+		name    string
+		params  *RawParams
+		wantErr error
+	}{{
+		name:   "ok",
+		params: paramsWithArgs("stoplimit", "lasttrade", "900", "0"),
+	}, {
+		name:   "trailing stop",
+		params: paramsWithArgs("trailingstop", "", "0", "2.5"),
+	}, {
+		name:    "bad trigger rate",
+		params:  paramsWithArgs("stoplimit", "", "-1", "0"),
+		wantErr: errArgs,
+	}, {
+		name:    "bad trail pct",
+		params:  paramsWithArgs("trailingstop", "", "0", "abc"),
+		wantErr: errArgs,
+	}, {
+		name:    "not enough args",
+		params:  paramsWithArgs("stoplimit", "", "900"),
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseConditionalTradeArgs(test.params)
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		f := form.srvForm
+		if f.Type != test.params.Args[0] || f.PriceSource != test.params.Args[1] || f.Trade == nil ||
+			f.Trade.Host != "dex.com" || f.Trade.Base != 42 || f.Trade.Qty != 10 {
+			t.Fatalf("%q: wrong form values %+v", test.name, f)
+		}
+	}
+}
+
+func TestParseStartExecutionArgs(t *testing.T) {
+	pw := encode.PassBytes("abc")
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{{
+		name: "ok",
+		args: []string{`{"algo":"iceberg","host":"dex.com","base":42,"quote":0,"qty":10,"visibleQty":2}`},
+	}, {
+		name:    "bad json",
+		args:    []string{`{"algo":`},
+		wantErr: errArgs,
+	}, {
+		name:    "no host",
+		args:    []string{`{"algo":"twap","base":42,"quote":0,"qty":10}`},
+		wantErr: errArgs,
+	}, {
+		name:    "no args",
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseStartExecutionArgs(&RawParams{PWArgs: []encode.PassBytes{pw}, Args: test.args})
+		if test.wantErr != nil {
+			if errors.Is(err, test.wantErr) {
+				continue
+			}
+			t.Fatalf("%q: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.name, err)
+		}
+		f := form.srvForm
+		if f.Algo != core.ExecAlgoIceberg || f.Host != "dex.com" || f.Base != 42 || f.Qty != 10 || f.VisibleQty != 2 {
+			t.Fatalf("%q: wrong form values %+v", test.name, f)
+		}
+	}
+}
+
+func TestParseExportLedgerArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantFormat core.LedgerFormat
+		wantFilter core.LedgerFilter
+		wantErr    error
+	}{{
+		name:       "no args",
+		wantFormat: core.LedgerFormatDefault,
+	}, {
+		name:       "all args",
+		args:       []string{"cointracking", "dex.com", "1000", "2000"},
+		wantFormat: core.LedgerFormatCoinTracking,
+		wantFilter: core.LedgerFilter{Hosts: []string{"dex.com"}, Since: 1000, Until: 2000},
+	}, {
+		name:       "empty format and host",
+		args:       []string{"", "", "1000"},
+		wantFormat: core.LedgerFormatDefault,
+		wantFilter: core.LedgerFilter{Since: 1000},
+	}, {
+		name:    "unknown format",
+		args:    []string{"excel"},
+		wantErr: errArgs,
+	}, {
+		name:    "bad since",
+		args:    []string{"koinly", "", "-1"},
+		wantErr: errArgs,
+	}, {
+		name:    "too many args",
+		args:    []string{"koinly", "", "1", "2", "3"},
+		wantErr: errArgs,
+	}}
+	for _, test := range tests {
+		form, err := parseExportLedgerArgs(&RawParams{Args: test.args})
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("%s: expected error %v, got %v", test.name, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if form.format != test.wantFormat || !reflect.DeepEqual(*form.filter, test.wantFilter) {
+			t.Fatalf("%s: wrong form %s %+v", test.name, form.format, form.filter)
+		}
+	}
+}
@@ -918,6 +918,149 @@ func (s *WebServer) apiCancel(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, simpleAck())
 }
 
+// apiAmend is the handler for the '/amend' API request.
+func (s *WebServer) apiAmend(w http.ResponseWriter, r *http.Request) {
+	form := new(amendForm)
+	defer form.Pass.Clear()
+	if !readPost(w, r, form) {
+		return
+	}
+	pass, err := s.resolvePass(form.Pass, r)
+	if err != nil {
+		s.writeAPIError(w, fmt.Errorf("password error: %w", err))
+		return
+	}
+	defer zero(pass)
+	ord, err := s.core.AmendOrder(pass, form.OrderID, form.Rate, form.Qty)
+	if err != nil {
+		s.writeAPIError(w, fmt.Errorf("error amending order %s: %w", form.OrderID, err))
+		return
+	}
+	writeJSON(w, &struct {
+		OK    bool        `json:"ok"`
+		Order *core.Order `json:"order"`
+	}{
+		OK:    true,
+		Order: ord,
+	})
+}
+
+// apiConditionalTrade is the handler for the '/conditionaltrade' API request.
+func (s *WebServer) apiConditionalTrade(w http.ResponseWriter, r *http.Request) {
+	form := new(conditionalTradeForm)
+	defer form.Pass.Clear()
+	if !readPost(w, r, form) {
+		return
+	}
+	pass, err := s.resolvePass(form.Pass, r)
+	if err != nil {
+		s.writeAPIError(w, fmt.Errorf("password error: %w", err))
+		return
+	}
+	defer zero(pass)
+	if form.Order == nil {
+		s.writeAPIError(w, errors.New("order missing"))
+		return
+	}
+	ord, err := s.core.PlaceConditionalOrder(pass, form.Order)
+	if err != nil {
+		s.writeAPIError(w, fmt.Errorf("error placing conditional order: %w", err))
+		return
+	}
+	writeJSON(w, &struct {
+		OK    bool                   `json:"ok"`
+		Order *core.ConditionalOrder `json:"order"`
+	}{
+		OK:    true,
+		Order: ord,
+	})
+}
+
+// apiConditionalOrders is the handler for the '/conditionalorders' API
+// request.
+func (s *WebServer) apiConditionalOrders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &struct {
+		OK     bool                     `json:"ok"`
+		Orders []*core.ConditionalOrder `json:"orders"`
+	}{
+		OK:     true,
+		Orders: s.core.ConditionalOrders(),
+	})
+}
+
+// apiCancelConditional is the handler for the '/cancelconditional' API
+// request.
+func (s *WebServer) apiCancelConditional(w http.ResponseWriter, r *http.Request) {
+	form := &struct {
+		ID dex.Bytes `json:"id"`
+	}{}
+	if !readPost(w, r, form) {
+		return
+	}
+	if err := s.core.CancelConditionalOrder(form.ID); err != nil {
+		s.writeAPIError(w, fmt.Errorf("error canceling conditional order %s: %w", form.ID, err))
+		return
+	}
+	writeJSON(w, simpleAck())
+}
+
+// apiStartExecution is the handler for the '/startexecution' API request.
+func (s *WebServer) apiStartExecution(w http.ResponseWriter, r *http.Request) {
+	form := new(startExecutionForm)
+	defer form.Pass.Clear()
+	if !readPost(w, r, form) {
+		return
+	}
+	pass, err := s.resolvePass(form.Pass, r)
+	if err != nil {
+		s.writeAPIError(w, fmt.Errorf("password error: %w", err))
+		return
+	}
+	defer zero(pass)
+	if form.Execution == nil {
+		s.writeAPIError(w, errors.New("execution missing"))
+		return
+	}
+	exec, err := s.core.StartExecution(pass, form.Execution)
+	if err != nil {
+		s.writeAPIError(w, fmt.Errorf("error starting execution: %w", err))
+		return
+	}
+	writeJSON(w, &struct {
+		OK        bool            `json:"ok"`
+		Execution *core.Execution `json:"execution"`
+	}{
+		OK:        true,
+		Execution: exec,
+	})
+}
+
+// apiExecutions is the handler for the '/executions' API request.
+func (s *WebServer) apiExecutions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &struct {
+		OK         bool              `json:"ok"`
+		Executions []*core.Execution `json:"executions"`
+	}{
+		OK:         true,
+		Executions: s.core.Executions(),
+	})
+}
+
+// apiCancelExecution is the handler for the '/cancelexecution' API request.
+func (s *WebServer) apiCancelExecution(w http.ResponseWriter, r *http.Request) {
+	form := &struct {
+		ID dex.Bytes `json:"id"`
+	}{}
+	if !readPost(w, r, form) {
+		return
+	}
+	if err := s.core.CancelExecution(form.ID); err != nil {
+		s.writeAPIError(w, fmt.Errorf("error canceling execution %s: %w", form.ID, err))
+		return
+	}
+	writeJSON(w, simpleAck())
+}
+
 // apiCloseWallet is the handler for the '/closewallet' API request.
 func (s *WebServer) apiCloseWallet(w http.ResponseWriter, r *http.Request) {
 	form := &struct {
@@ -2019,6 +2162,98 @@ func (s *WebServer) apiRemoveBotConfig(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, simpleAck())
 }
 
+func (s *WebServer) apiBotSchedules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &struct {
+		OK        bool              `json:"ok"`
+		Schedules []*mm.BotSchedule `json:"schedules"`
+	}{
+		OK:        true,
+		Schedules: s.mm.BotSchedules(),
+	})
+}
+
+func (s *WebServer) apiUpdateBotSchedule(w http.ResponseWriter, r *http.Request) {
+	var sched *mm.BotSchedule
+	if !readPost(w, r, &sched) {
+		s.writeAPIError(w, fmt.Errorf("failed to read schedule"))
+		return
+	}
+
+	if err := s.mm.UpdateBotSchedule(sched); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, simpleAck())
+}
+
+func (s *WebServer) apiRemoveBotSchedule(w http.ResponseWriter, r *http.Request) {
+	var form struct {
+		Market *mm.MarketWithHost `json:"market"`
+	}
+	if !readPost(w, r, &form) {
+		s.writeAPIError(w, fmt.Errorf("failed to read form"))
+		return
+	}
+	if form.Market == nil {
+		s.writeAPIError(w, errors.New("market missing"))
+		return
+	}
+
+	if err := s.mm.RemoveBotSchedule(form.Market); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, simpleAck())
+}
+
+func (s *WebServer) apiPortfolio(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &struct {
+		OK            bool                        `json:"ok"`
+		Config        *mm.PortfolioConfig         `json:"config"`
+		Reallocations []*mm.PortfolioReallocation `json:"reallocations"`
+	}{
+		OK:            true,
+		Config:        s.mm.PortfolioConfig(),
+		Reallocations: s.mm.PortfolioReallocations(),
+	})
+}
+
+// apiUpdatePortfolioConfig saves the portfolio allocator config. A null
+// config removes it.
+func (s *WebServer) apiUpdatePortfolioConfig(w http.ResponseWriter, r *http.Request) {
+	var form struct {
+		Config *mm.PortfolioConfig `json:"config"`
+	}
+	if !readPost(w, r, &form) {
+		s.writeAPIError(w, fmt.Errorf("failed to read form"))
+		return
+	}
+
+	if err := s.mm.UpdatePortfolioConfig(form.Config); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, simpleAck())
+}
+
+func (s *WebServer) apiReallocatePortfolio(w http.ResponseWriter, r *http.Request) {
+	realloc, err := s.mm.ReallocatePortfolio()
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, &struct {
+		OK           bool                      `json:"ok"`
+		Reallocation *mm.PortfolioReallocation `json:"reallocation"`
+	}{
+		OK:           true,
+		Reallocation: realloc,
+	})
+}
+
 func (s *WebServer) apiMarketMakingStatus(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, &struct {
 		OK     bool       `json:"ok"`
@@ -4,11 +4,13 @@
 package webserver
 
 import (
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,10 +34,12 @@ const (
 	settingsRoute    = "/settings"
 	ordersRoute      = "/orders"
 	exportOrderRoute = "/orders/export"
+	ledgerRoute      = "/orders/ledger"
 	marketMakerRoute = "/mm"
 	mmSettingsRoute  = "/mmsettings"
 	mmArchivesRoute  = "/mmarchives"
 	mmLogsRoute      = "/mmlogs"
+	profileRoute     = "/profile"
 )
 
 // sendTemplate processes the template and sends the result.
@@ -84,7 +88,18 @@ func (s *WebServer) handleLogin(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, walletsRoute, http.StatusSeeOther)
 		return
 	}
-	s.sendTemplate(w, "login", cArgs)
+	s.sendTemplate(w, "login", s.profileArgs(cArgs))
+}
+
+// profileTmplData is template data for the pages with the profile selector.
+type profileTmplData struct {
+	CommonArguments
+	// Profiles are the names of the user profiles to choose from, including
+	// the empty name of the default profile. Empty if there is only the
+	// default profile.
+	Profiles []string
+	// Profile is the name of the selected profile.
+	Profile string
 }
 
 // registerTmplData is template data for the /register page.
@@ -280,7 +295,7 @@ func (s *WebServer) handleGenerateCompanionAppQRCode(w http.ResponseWriter, r *h
 
 // handleInit is the handler for the '/init' page request
 func (s *WebServer) handleInit(w http.ResponseWriter, r *http.Request) {
-	s.sendTemplate(w, "init", s.commonArgs(r, "Welcome | Bison Wallet"))
+	s.sendTemplate(w, "init", s.profileArgs(s.commonArgs(r, "Welcome | Bison Wallet")))
 }
 
 // handleSettings is the handler for the '/settings' page request.
@@ -355,9 +370,10 @@ func (s *WebServer) handleMMLogs(w http.ResponseWriter, r *http.Request) {
 
 type ordersTmplData struct {
 	CommonArguments
-	Assets   map[uint32]*core.SupportedAsset
-	Hosts    []string
-	Statuses map[uint8]string
+	Assets        map[uint32]*core.SupportedAsset
+	Hosts         []string
+	Statuses      map[uint8]string
+	LedgerFormats []core.LedgerFormat
 }
 
 var allStatuses = map[uint8]string{
@@ -381,6 +397,7 @@ func (s *WebServer) handleOrders(w http.ResponseWriter, r *http.Request) {
 		Assets:          s.core.SupportedAssets(),
 		Hosts:           hosts,
 		Statuses:        allStatuses,
+		LedgerFormats:   core.LedgerFormats,
 	})
 }
 
@@ -497,6 +514,72 @@ func (s *WebServer) handleExportOrders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleExportLedger is the handler for the /orders/ledger page request. The
+// ledger of settled trade fills is sent as CSV in the requested format.
+func (s *WebServer) handleExportLedger(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		log.Errorf("error parsing form for export ledger: %v", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	filter := &core.LedgerFilter{Hosts: r.Form["hosts"]}
+	for _, assetStrID := range r.Form["assets"] {
+		assetID, err := strconv.ParseUint(assetStrID, 10, 32)
+		if err != nil {
+			log.Errorf("error parsing asset id: %v", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		filter.Assets = append(filter.Assets, uint32(assetID))
+	}
+	parseStamp := func(k string) (uint64, bool) {
+		v := r.Form.Get(k)
+		if v == "" {
+			return 0, true
+		}
+		stamp, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			log.Errorf("error parsing %s: %v", k, err)
+			return 0, false
+		}
+		return stamp, true
+	}
+	var ok bool
+	if filter.Since, ok = parseStamp("since"); !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, ok = parseStamp("until"); !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	format := core.LedgerFormat(r.Form.Get("format"))
+	if format == "" {
+		format = core.LedgerFormatDefault
+	}
+	if !slices.Contains(core.LedgerFormats, format) {
+		log.Errorf("unknown ledger format %q", format)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := s.core.ExportLedger(&b, filter, format); err != nil {
+		log.Errorf("error exporting ledger: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=ledger-%s.csv", format))
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b.Bytes()); err != nil {
+		log.Errorf("error writing ledger: %v", err)
+	}
+}
+
 type orderTmplData struct {
 	CommonArguments
 	Order *core.OrderReader
@@ -12,6 +12,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	mrand "math/rand"
 	"sort"
@@ -443,6 +444,10 @@ func (t *tBookFeed) Candles(dur string) error {
 	return nil
 }
 
+func (t *tBookFeed) Trades() error {
+	return nil
+}
+
 type TCore struct {
 	inited    bool
 	mtx       sync.RWMutex
@@ -1743,6 +1748,34 @@ func (c *TCore) trade(form *core.TradeForm) *core.Order {
 	}
 }
 
+func (c *TCore) PlaceConditionalOrder(pw []byte, form *core.ConditionalOrderForm) (*core.ConditionalOrder, error) {
+	return &core.ConditionalOrder{
+		ID:          encode.RandomBytes(16),
+		Type:        form.Type,
+		PriceSource: form.PriceSource,
+		TriggerRate: form.TriggerRate,
+		TrailPct:    form.TrailPct,
+		Trade:       form.Trade,
+		Status:      core.ConditionalStatusActive,
+		Stamp:       uint64(time.Now().UnixMilli()),
+	}, nil
+}
+func (c *TCore) ConditionalOrders() []*core.ConditionalOrder { return nil }
+func (c *TCore) CancelConditionalOrder(id dex.Bytes) error   { return nil }
+func (c *TCore) StartExecution(pw []byte, form *core.ExecutionForm) (*core.Execution, error) {
+	return &core.Execution{
+		ExecutionForm: *form,
+		ID:            encode.RandomBytes(16),
+		Status:        core.ExecutionStatusActive,
+		Stamp:         uint64(time.Now().UnixMilli()),
+	}, nil
+}
+func (c *TCore) Executions() []*core.Execution      { return nil }
+func (c *TCore) CancelExecution(id dex.Bytes) error { return nil }
+func (c *TCore) ExportLedger(w io.Writer, filter *core.LedgerFilter, format core.LedgerFormat) error {
+	return nil
+}
+
 func (c *TCore) Cancel(oid dex.Bytes) error {
 	for _, xc := range tExchanges {
 		for _, mkt := range xc.Markets {
@@ -1756,6 +1789,16 @@ func (c *TCore) Cancel(oid dex.Bytes) error {
 	return nil
 }
 
+func (c *TCore) AmendOrder(pw []byte, oid dex.Bytes, rate, qty uint64) (*core.Order, error) {
+	return &core.Order{
+		ID:    encode.RandomBytes(32),
+		Type:  order.LimitOrderType,
+		Stamp: uint64(time.Now().UnixMilli()),
+		Rate:  rate,
+		Qty:   qty,
+	}, nil
+}
+
 func (c *TCore) NotificationFeed() *core.NoteFeed {
 	return &core.NoteFeed{
 		C: c.noteFeed,
@@ -2607,6 +2650,51 @@ func (m *TMarketMaker) CEXBook(host string, baseID, quoteID uint32) (buys, sells
 	return book.Buys, book.Sells, nil
 }
 
+func (m *TMarketMaker) BotSchedules() []*mm.BotSchedule {
+	return m.cfg.Schedules
+}
+
+func (m *TMarketMaker) UpdateBotSchedule(sched *mm.BotSchedule) error {
+	for i, s := range m.cfg.Schedules {
+		if s.MarketWithHost == sched.MarketWithHost {
+			m.cfg.Schedules[i] = sched
+			return nil
+		}
+	}
+	m.cfg.Schedules = append(m.cfg.Schedules, sched)
+	return nil
+}
+
+func (m *TMarketMaker) RemoveBotSchedule(mkt *mm.MarketWithHost) error {
+	for i, s := range m.cfg.Schedules {
+		if s.MarketWithHost == *mkt {
+			m.cfg.Schedules = append(m.cfg.Schedules[:i], m.cfg.Schedules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no schedule found for %s", mkt)
+}
+
+func (m *TMarketMaker) PortfolioConfig() *mm.PortfolioConfig {
+	return m.cfg.Portfolio
+}
+
+func (m *TMarketMaker) UpdatePortfolioConfig(portfolioCfg *mm.PortfolioConfig) error {
+	m.cfg.Portfolio = portfolioCfg
+	return nil
+}
+
+func (m *TMarketMaker) PortfolioReallocations() []*mm.PortfolioReallocation {
+	return nil
+}
+
+func (m *TMarketMaker) ReallocatePortfolio() (*mm.PortfolioReallocation, error) {
+	if m.cfg.Portfolio == nil {
+		return nil, fmt.Errorf("no portfolio config")
+	}
+	return &mm.PortfolioReallocation{Stamp: time.Now().Unix()}, nil
+}
+
 func makeRequiredAction(assetID uint32, actionID string) *asset.ActionRequiredNote {
 	txID := dex.Bytes(encode.RandomBytes(32)).String()
 	var payload any
@@ -211,6 +211,7 @@ var EnUS = map[string]*intl.Translation{
 	"ready":                       {T: "ready"},
 	"off":                         {T: "off"},
 	"Export Trades":               {T: "Export Trades"},
+	"Export Ledger":               {T: "Export Ledger"},
 	"change the wallet type":      {T: "change the wallet type"},
 	"confirmations":               {T: "confirmations"},
 	"pick a different asset":      {T: "pick a different asset"},
@@ -689,4 +690,7 @@ var EnUS = map[string]*intl.Translation{
 	"delete_bot":                  {T: "Delete Bot"},
 	"export_logs":                 {T: "Export Logs"},
 	"address has been used":       {T: "address has been used"},
+	"Profile":                     {T: "Profile"},
+	"default_profile":             {T: "Default"},
+	"Switch":                      {T: "Switch"},
 }
@@ -0,0 +1,107 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package webserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// addProfiles creates a server for each of the profiles in the Config. The
+// profile servers do not listen. Requests are routed to them from this server
+// based on the profile cookie.
+func (s *WebServer) addProfiles(cfg *Config) error {
+	s.profiles = make(map[string]*WebServer, len(cfg.Profiles))
+	names := make([]string, 0, len(cfg.Profiles)+1)
+	names = append(names, "") // the default profile
+	for _, p := range cfg.Profiles {
+		if p.Name == "" || s.profiles[p.Name] != nil {
+			return fmt.Errorf("invalid or duplicate profile name %q", p.Name)
+		}
+		pcfg := *cfg
+		pcfg.Core, pcfg.MarketMaker = p.Core, p.MarketMaker
+		pcfg.Profiles = nil
+		// The profile servers share the listener of this server.
+		pcfg.CertFile, pcfg.KeyFile = "", ""
+		pcfg.Tor, pcfg.HttpProf = false, false
+		ps, err := New(&pcfg)
+		if err != nil {
+			return fmt.Errorf("error creating web server for profile %q: %w", p.Name, err)
+		}
+		ps.profile = p.Name
+		s.profiles[p.Name] = ps
+		names = append(names, p.Name)
+	}
+	s.profileNames = names
+	for _, ps := range s.profiles {
+		ps.profileNames = names
+	}
+	s.srv.Handler = s.profileRouter(s.mux)
+	return nil
+}
+
+// connectProfiles starts the websocket servers and notification feeds of the
+// profile servers.
+func (s *WebServer) connectProfiles(ctx context.Context, wg *sync.WaitGroup) {
+	for _, ps := range s.profiles {
+		ps.ctx, ps.csp, ps.addr = ctx, s.csp, s.addr
+		ps.mux.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+			ps.wsServer.HandleConnect(ctx, w, r)
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ps.readNotifications(ctx)
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			ps.wsServer.Shutdown()
+		}()
+	}
+}
+
+// profileRouter routes requests for the other profiles to their servers.
+func (s *WebServer) profileRouter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie(profileCK); err == nil {
+			if ps := s.profiles[cookie.Value]; ps != nil {
+				ps.mux.ServeHTTP(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleSelectProfile handles the profile selection form. The profile cookie
+// is set, and the user is sent to the login page of the profile. Since each
+// profile has its own auth tokens, switching profiles requires logging in to
+// the profile.
+func (s *WebServer) handleSelectProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PostFormValue("profile")
+	if !slices.Contains(s.profileNames, name) {
+		http.Error(w, "unknown profile", http.StatusBadRequest)
+		return
+	}
+	if name == "" {
+		clearCookie(profileCK, w)
+	} else {
+		setCookie(profileCK, name, w)
+	}
+	http.Redirect(w, r, loginRoute, http.StatusSeeOther)
+}
+
+// profileArgs adds the profile selection to the CommonArguments.
+func (s *WebServer) profileArgs(cArgs *CommonArguments) *profileTmplData {
+	return &profileTmplData{
+		CommonArguments: *cArgs,
+		Profiles:        s.profileNames,
+		Profile:         s.profile,
+	}
+}
@@ -55,6 +55,7 @@ function eventPassesFilter (e: MarketMakingEvent, filters: logFilters): boolean
   }
   if (e.depositEvent) return filters.deposits
   if (e.withdrawalEvent) return filters.withdrawals
+  if (e.riskLimitEvent) return true
   return false
 }
 
@@ -374,6 +375,8 @@ export default class MarketMakerLogsPage extends BasePage {
       return event.dexOrderEvent.sell ? 'DEX Sell' : 'DEX Buy'
     } else if (event.cexOrderEvent) {
       return event.cexOrderEvent.sell ? 'CEX Sell' : 'CEX Buy'
+    } else if (event.riskLimitEvent) {
+      return 'Risk Limit'
     }
 
     return ''
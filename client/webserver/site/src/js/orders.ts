@@ -107,6 +107,10 @@ export default class OrdersPage extends BasePage {
       this.exportOrders()
     })
 
+    Doc.bind(page.exportLedger, 'click', () => {
+      this.exportLedger()
+    })
+
     page.showArchivedDateField.addEventListener('change', () => {
       if (page.showArchivedDateField.checked) Doc.show(page.archivedDateField)
       else Doc.hide(page.archivedDateField, page.deleteArchivedRecordsErr)
@@ -259,6 +263,22 @@ export default class OrdersPage extends BasePage {
     window.open(url.toString())
   }
 
+  /*
+   * exportLedger downloads a csv ledger of the settled fills of the user's
+   * trades for the current host and asset filters.
+   */
+  exportLedger () {
+    const filterState = this.currentFilter()
+    const url = new URL(window.location.href)
+    const search = new URLSearchParams('')
+    for (const host of filterState.hosts ?? []) search.append('hosts', host)
+    for (const assetID of filterState.assets ?? []) search.append('assets', String(assetID))
+    search.set('format', this.page.ledgerFormat.value || '')
+    url.search = search.toString()
+    url.pathname = '/orders/ledger'
+    window.open(url.toString())
+  }
+
   /* deleteArchivedRecords removes the user's archived orders and matches
    * created before user specified date time in millisecond. Deleted archived
    * records are saved to a CSV file if the user specify so.
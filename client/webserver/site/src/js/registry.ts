@@ -762,11 +762,26 @@ export interface AutoRebalanceConfig {
   minQuoteTransfer: number
 }
 
+export interface InventorySkewConfig {
+  targetBaseRatio: number
+  spreadSkew: number
+  lotSkew: number
+}
+
 export interface BasicMarketMakingConfig {
   gapStrategy: string
   sellPlacements: OrderPlacement[]
   buyPlacements: OrderPlacement[]
   driftTolerance: number
+  inventorySkew?: InventorySkewConfig
+  oracle?: OracleConfig
+}
+
+export interface OracleConfig {
+  weights?: Record<string, number>
+  maxDeviation?: number
+  maxAge?: number
+  cexWeight?: number
 }
 
 export interface ArbMarketMakingPlacement {
@@ -788,6 +803,13 @@ export interface SimpleArbConfig {
   numEpochsLeaveOpen: number
 }
 
+export interface TriangularArbConfig {
+  bridgeAssetID: number
+  profitTrigger: number
+  maxActiveArbs: number
+  numEpochsLeaveOpen: number
+}
+
 export interface BotCEXCfg {
   name: string
   autoRebalance?: AutoRebalanceConfig
@@ -828,6 +850,38 @@ export interface BotConfig {
   basicMarketMakingConfig?: BasicMarketMakingConfig
   arbMarketMakingConfig?: ArbMarketMakingConfig
   simpleArbConfig?: SimpleArbConfig
+  triangularArbConfig?: TriangularArbConfig
+  riskLimits?: RiskLimits
+}
+
+export interface RiskLimits {
+  maxDrawdownUSD?: number
+  maxInventoryImbalance?: number
+  maxConsecutiveFailedSwaps?: number
+  maxPendingWithdrawals?: number
+}
+
+export interface SchedulePlacements {
+  buyPlacements?: OrderPlacement[]
+  sellPlacements?: OrderPlacement[]
+  arbBuyPlacements?: ArbMarketMakingPlacement[]
+  arbSellPlacements?: ArbMarketMakingPlacement[]
+}
+
+export interface ScheduleEntry {
+  cron: string
+  action: 'start' | 'stop' | 'placements'
+  alloc?: BotBalanceAllocation
+  autoRebalance?: AutoRebalanceConfig
+  placements?: SchedulePlacements
+}
+
+export interface BotSchedule {
+  host: string
+  baseID: number
+  quoteID: number
+  disabled?: boolean
+  entries: ScheduleEntry[]
 }
 
 export interface CEXConfig {
@@ -1000,11 +1054,22 @@ export interface OrderReport {
   error?: BotProblems
 }
 
+export interface InventorySkewReport {
+  baseRatio: number
+  targetBaseRatio: number
+  deviation: number
+  basisPrice: number
+  skewedBasisPrice: number
+  buyLotsFactor: number
+  sellLotsFactor: number
+}
+
 export interface EpochReport {
   epochNum: number
   preOrderProblems?: BotProblems
   buysReport?: OrderReport
   sellsReport?: OrderReport
+  inventorySkew?: InventorySkewReport
 }
 
 export interface CEXProblems {
@@ -1036,6 +1101,8 @@ export interface DEXOrderEvent {
 
 export interface CEXOrderEvent {
   id: string
+  baseID: number
+  quoteID: number
   rate: number
   qty: number
   sell: boolean
@@ -1056,6 +1123,13 @@ export interface WithdrawalEvent {
   cexDebit: number
 }
 
+export interface RiskLimitEvent {
+  limit: string
+  value: number
+  threshold: number
+  details: string
+}
+
 export interface BalanceEffects {
   settled: Record<number, number>
   pending: Record<number, number>
@@ -1072,6 +1146,7 @@ export interface MarketMakingEvent {
   cexOrderEvent?: CEXOrderEvent
   depositEvent?: DepositEvent
   withdrawalEvent?: WithdrawalEvent
+  riskLimitEvent?: RiskLimitEvent
 }
 
 interface MarketDay {
@@ -1099,6 +1174,7 @@ export interface OracleReport {
   usdVol: number
   bestBuy: number
   bestSell: number
+  stamp: number
 }
 
 export interface ExchangeBalance {
@@ -113,6 +113,23 @@ type cancelForm struct {
 	OrderID dex.Bytes `json:"orderID"`
 }
 
+type amendForm struct {
+	Pass    encode.PassBytes `json:"pw"`
+	OrderID dex.Bytes        `json:"orderID"`
+	Rate    uint64           `json:"rate"`
+	Qty     uint64           `json:"qty"`
+}
+
+type conditionalTradeForm struct {
+	Pass  encode.PassBytes           `json:"pw"`
+	Order *core.ConditionalOrderForm `json:"order"`
+}
+
+type startExecutionForm struct {
+	Pass      encode.PassBytes    `json:"pw"`
+	Execution *core.ExecutionForm `json:"execution"`
+}
+
 // sendForm is sent to initiate either send tx.
 type sendForm struct {
 	AssetID  uint32           `json:"assetID"`
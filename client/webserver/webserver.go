@@ -59,6 +59,8 @@ const (
 	authCK = "dexauth"
 	// pwKeyCK is the cookie used to unencrypt the user's password.
 	pwKeyCK = "sessionkey"
+	// profileCK is the cookie that selects the user profile.
+	profileCK = "bwprofile"
 	// ctxKeyUserInfo is used in the authorization middleware for saving user
 	// info in http request contexts.
 	ctxKeyUserInfo = contextKey("userinfo")
@@ -127,9 +129,17 @@ type clientCore interface {
 	Trade(pw []byte, form *core.TradeForm) (*core.Order, error)
 	TradeAsync(pw []byte, form *core.TradeForm) (*core.InFlightOrder, error)
 	Cancel(oid dex.Bytes) error
+	AmendOrder(pw []byte, oid dex.Bytes, rate, qty uint64) (*core.Order, error)
+	PlaceConditionalOrder(pw []byte, form *core.ConditionalOrderForm) (*core.ConditionalOrder, error)
+	ConditionalOrders() []*core.ConditionalOrder
+	CancelConditionalOrder(id dex.Bytes) error
+	StartExecution(pw []byte, form *core.ExecutionForm) (*core.Execution, error)
+	Executions() []*core.Execution
+	CancelExecution(id dex.Bytes) error
 	NotificationFeed() *core.NoteFeed
 	Logout() error
 	Orders(*core.OrderFilter) ([]*core.Order, error)
+	ExportLedger(w io.Writer, filter *core.LedgerFilter, format core.LedgerFormat) error
 	Order(oid dex.Bytes) (*core.Order, error)
 	MaxBuy(host string, base, quote uint32, rate uint64) (*core.MaxOrderEstimate, error)
 	MaxSell(host string, base, quote uint32) (*core.MaxOrderEstimate, error)
@@ -188,6 +198,13 @@ type MMCore interface {
 	RunOverview(startTime int64, mkt *mm.MarketWithHost) (*mm.MarketMakingRunOverview, error)
 	RunLogs(startTime int64, mkt *mm.MarketWithHost, n uint64, refID *uint64, filter *mm.RunLogFilters) (events, updatedEvents []*mm.MarketMakingEvent, overview *mm.MarketMakingRunOverview, err error)
 	CEXBook(host string, baseID, quoteID uint32) (buys, sells []*core.MiniOrder, _ error)
+	BotSchedules() []*mm.BotSchedule
+	UpdateBotSchedule(sched *mm.BotSchedule) error
+	RemoveBotSchedule(mkt *mm.MarketWithHost) error
+	PortfolioConfig() *mm.PortfolioConfig
+	UpdatePortfolioConfig(portfolioCfg *mm.PortfolioConfig) error
+	PortfolioReallocations() []*mm.PortfolioReallocation
+	ReallocatePortfolio() (*mm.PortfolioReallocation, error)
 }
 
 // genCertPair generates a key/cert pair to the paths provided.
@@ -246,6 +263,17 @@ type Config struct {
 	HttpProf        bool
 	Tor             bool
 	MainLogFilePath string
+	// Profiles are additional user profiles, each with its own Core and
+	// market maker, that are served on the same address. The profile is
+	// selected on the login page.
+	Profiles []*Profile
+}
+
+// Profile is a user profile with its own Core and market maker.
+type Profile struct {
+	Name        string
+	Core        clientCore // *core.Core
+	MarketMaker MMCore     // *mm.MarketMaker
 }
 
 type valStamp struct {
@@ -283,6 +311,14 @@ type WebServer struct {
 
 	useDEXBranding  bool
 	mainLogFilePath string
+
+	// profile is the name of the user profile served, empty for the default
+	// profile. profileNames are the names of all profiles. profiles are the
+	// servers of the other profiles, and are only set for the default
+	// profile's server, which routes requests to them.
+	profile      string
+	profileNames []string
+	profiles     map[string]*WebServer
 }
 
 // New is the constructor for a new WebServer. CustomSiteDir in the Config can
@@ -453,6 +489,9 @@ func New(cfg *Config) (*WebServer, error) {
 
 	// The WebSocket handler is mounted on /ws in Connect.
 
+	// Profile selection is available before init and login.
+	mux.Post(profileRoute, s.handleSelectProfile)
+
 	// Webpages
 	mux.Group(func(web chi.Router) {
 		web.Use(s.tokenAuthMiddleware)
@@ -499,6 +538,7 @@ func New(cfg *Config) (*WebServer, error) {
 				webDC.With(orderIDCtx).Get("/order/{oid}", s.handleOrder)
 				webDC.Get(ordersRoute, s.handleOrders)
 				webDC.Get(exportOrderRoute, s.handleExportOrders)
+				webDC.Get(ledgerRoute, s.handleExportLedger)
 				webDC.Get(marketsRoute, s.handleMarkets)
 				webDC.Get(mmSettingsRoute, s.handleMMSettings)
 				webDC.Get(mmArchivesRoute, s.handleMMArchives)
@@ -547,6 +587,13 @@ func New(cfg *Config) (*WebServer, error) {
 			apiAuth.Post("/trade", s.apiTrade)
 			apiAuth.Post("/tradeasync", s.apiTradeAsync)
 			apiAuth.Post("/cancel", s.apiCancel)
+			apiAuth.Post("/amend", s.apiAmend)
+			apiAuth.Post("/conditionaltrade", s.apiConditionalTrade)
+			apiAuth.Post("/conditionalorders", s.apiConditionalOrders)
+			apiAuth.Post("/cancelconditional", s.apiCancelConditional)
+			apiAuth.Post("/startexecution", s.apiStartExecution)
+			apiAuth.Post("/executions", s.apiExecutions)
+			apiAuth.Post("/cancelexecution", s.apiCancelExecution)
 			apiAuth.Post("/logout", s.apiLogout)
 			apiAuth.Post("/balance", s.apiGetBalance)
 			apiAuth.Post("/parseconfig", s.apiParseConfig)
@@ -606,6 +653,12 @@ func New(cfg *Config) (*WebServer, error) {
 			apiAuth.Get("/archivedmmruns", s.apiArchivedRuns)
 			apiAuth.Post("/mmrunlogs", s.apiRunLogs)
 			apiAuth.Post("/cexbook", s.apiCEXBook)
+			apiAuth.Get("/botschedules", s.apiBotSchedules)
+			apiAuth.Post("/updatebotschedule", s.apiUpdateBotSchedule)
+			apiAuth.Post("/removebotschedule", s.apiRemoveBotSchedule)
+			apiAuth.Get("/portfolio", s.apiPortfolio)
+			apiAuth.Post("/updateportfolioconfig", s.apiUpdatePortfolioConfig)
+			apiAuth.Post("/reallocateportfolio", s.apiReallocatePortfolio)
 		})
 	})
 
@@ -615,6 +668,12 @@ func New(cfg *Config) (*WebServer, error) {
 	fileServer(mux, "/img", siteDir, "src/img", "")
 	fileServer(mux, "/font", siteDir, "src/font", "")
 
+	if len(cfg.Profiles) > 0 {
+		if err := s.addProfiles(cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	return s, nil
 }
 
@@ -664,7 +723,7 @@ func (s *WebServer) buildTemplates(lang string) error {
 		addTemplate("orders", bb).
 		addTemplate("order", bb, "forms").
 		addTemplate("dexsettings", bb, "forms").
-		addTemplate("init", bb).
+		addTemplate("init", bb, "forms").
 		addTemplate("mm", bb, "forms").
 		addTemplate("mmsettings", bb, "forms").
 		addTemplate("mmarchives", bb).
@@ -765,6 +824,7 @@ func (s *WebServer) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 	s.mux.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
 		s.wsServer.HandleConnect(ctx, w, r)
 	})
+	s.connectProfiles(ctx, &wg)
 
 	for _, listener := range listeners {
 		wg.Add(1)
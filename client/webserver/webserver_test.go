@@ -13,6 +13,7 @@ import (
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -219,6 +220,22 @@ func trade(form *core.TradeForm) *core.Order {
 	}
 }
 func (c *TCore) Cancel(oid dex.Bytes) error { return nil }
+func (c *TCore) AmendOrder(pw []byte, oid dex.Bytes, rate, qty uint64) (*core.Order, error) {
+	return &core.Order{ID: oid, Rate: rate, Qty: qty}, nil
+}
+func (c *TCore) PlaceConditionalOrder(pw []byte, form *core.ConditionalOrderForm) (*core.ConditionalOrder, error) {
+	return &core.ConditionalOrder{Type: form.Type, Trade: form.Trade}, nil
+}
+func (c *TCore) ConditionalOrders() []*core.ConditionalOrder { return nil }
+func (c *TCore) CancelConditionalOrder(id dex.Bytes) error   { return nil }
+func (c *TCore) StartExecution(pw []byte, form *core.ExecutionForm) (*core.Execution, error) {
+	return &core.Execution{ExecutionForm: *form, Status: core.ExecutionStatusActive}, nil
+}
+func (c *TCore) Executions() []*core.Execution      { return nil }
+func (c *TCore) CancelExecution(id dex.Bytes) error { return nil }
+func (c *TCore) ExportLedger(w io.Writer, filter *core.LedgerFilter, format core.LedgerFormat) error {
+	return nil
+}
 
 func (c *TCore) NotificationFeed() *core.NoteFeed {
 	return &core.NoteFeed{
@@ -1077,3 +1094,69 @@ func Test_prepareAddr(t *testing.T) {
 		}
 	}
 }
+
+func TestProfiles(t *testing.T) {
+	altCore := &TCore{isInited: true}
+	s, err := New(&Config{
+		Core:     &TCore{},
+		Addr:     "127.0.0.1:0",
+		Logger:   tLogger,
+		Profiles: []*Profile{{Name: "alt", Core: altCore}},
+	})
+	if err != nil {
+		t.Fatalf("error creating server: %v", err)
+	}
+
+	isInited := func(cookie *http.Cookie) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/isinitialized", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(w, req)
+		return strings.TrimSpace(w.Body.String())
+	}
+	selectProfile := func(name string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, profileRoute, strings.NewReader("profile="+name))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if resp := isInited(nil); resp != `{"ok":true,"initialized":false}` {
+		t.Fatalf("wrong default profile response %s", resp)
+	}
+
+	w := selectProfile("alt")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != loginRoute {
+		t.Fatalf("wrong select profile response %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != profileCK || cookies[0].Value != "alt" {
+		t.Fatalf("profile cookie not set")
+	}
+	if resp := isInited(cookies[0]); resp != `{"ok":true,"initialized":true}` {
+		t.Fatalf("wrong alt profile response %s", resp)
+	}
+
+	// Unknown profiles are rejected, and an unknown cookie is served by the
+	// default profile.
+	if w := selectProfile("nope"); w.Code != http.StatusBadRequest {
+		t.Fatalf("wrong code for unknown profile %d", w.Code)
+	}
+	if resp := isInited(&http.Cookie{Name: profileCK, Value: "nope"}); resp != `{"ok":true,"initialized":false}` {
+		t.Fatalf("wrong response for unknown profile cookie %s", resp)
+	}
+
+	if _, err := New(&Config{
+		Core:     &TCore{},
+		Addr:     "127.0.0.1:0",
+		Logger:   tLogger,
+		Profiles: []*Profile{{Name: "alt", Core: altCore}, {Name: "alt", Core: altCore}},
+	}); err == nil {
+		t.Fatalf("no error for duplicate profile names")
+	}
+}
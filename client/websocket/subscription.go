@@ -0,0 +1,271 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/msgjson"
+)
+
+// NoteSubscription is sent by websocket clients with the 'subscribe' route to
+// have core notifications streamed to them. Each matching notification is
+// sent once as a notification message whose route is the notification type,
+// e.g. order, match or balance, and whose payload is the notification.
+//
+// Empty filters match everything. The host and market filters only exclude
+// notifications that are associated with a host or market, so e.g. a
+// subscription with a market filter still receives security notifications
+// unless they are excluded by NoteTypes. Balance notifications match a market
+// filter if the asset is the base or quote asset of one of the markets.
+type NoteSubscription struct {
+	NoteTypes []string `json:"noteTypes,omitempty"`
+	Hosts     []string `json:"hosts,omitempty"`
+	// Markets are market names, e.g. dcr_btc.
+	Markets []string `json:"markets,omitempty"`
+}
+
+func (sub *NoteSubscription) validate() error {
+	for _, mkt := range sub.Markets {
+		base, quote, found := strings.Cut(mkt, "_")
+		if !found {
+			return fmt.Errorf("invalid market %q", mkt)
+		}
+		if _, found := dex.BipSymbolID(base); !found {
+			return fmt.Errorf("unknown base asset in market %q", mkt)
+		}
+		if _, found := dex.BipSymbolID(quote); !found {
+			return fmt.Errorf("unknown quote asset in market %q", mkt)
+		}
+	}
+	return nil
+}
+
+// subscribeResult is the result of a 'subscribe' request. The ID is used to
+// unsubscribe.
+type subscribeResult struct {
+	ID uint32 `json:"id"`
+}
+
+// unsubscribeReq is sent with the 'unsubscribe' route. An ID of zero removes
+// all of the client's subscriptions.
+type unsubscribeReq struct {
+	ID uint32 `json:"id"`
+}
+
+// noteMeta is the host and market information parsed from an encoded
+// notification.
+type noteMeta struct {
+	Type     string  `json:"type"`
+	Host     string  `json:"host"`
+	MarketID string  `json:"marketID"`
+	BaseID   *uint32 `json:"baseID"`
+	QuoteID  *uint32 `json:"quoteID"`
+	AssetID  *uint32 `json:"assetID"`
+	Order    *struct {
+		Host    string `json:"host"`
+		BaseID  uint32 `json:"baseID"`
+		QuoteID uint32 `json:"quoteID"`
+	} `json:"order"`
+}
+
+// hostAndMarket returns the host and market name of the notification, if
+// any.
+func (m *noteMeta) hostAndMarket() (host, mkt string) {
+	switch {
+	case m.Order != nil:
+		mkt, _ = dex.MarketName(m.Order.BaseID, m.Order.QuoteID)
+		return m.Order.Host, mkt
+	case m.MarketID != "":
+		return m.Host, m.MarketID
+	case m.BaseID != nil && m.QuoteID != nil:
+		mkt, _ = dex.MarketName(*m.BaseID, *m.QuoteID)
+		return m.Host, mkt
+	}
+	return m.Host, ""
+}
+
+// noteFilter is a NoteSubscription prepared for matching notifications.
+type noteFilter struct {
+	noteTypes map[string]bool
+	hosts     map[string]bool
+	markets   map[string]bool
+	assets    map[uint32]bool
+}
+
+func newNoteFilter(sub *NoteSubscription) *noteFilter {
+	f := &noteFilter{
+		noteTypes: make(map[string]bool, len(sub.NoteTypes)),
+		hosts:     make(map[string]bool, len(sub.Hosts)),
+		markets:   make(map[string]bool, len(sub.Markets)),
+		assets:    make(map[uint32]bool),
+	}
+	for _, noteType := range sub.NoteTypes {
+		f.noteTypes[noteType] = true
+	}
+	for _, host := range sub.Hosts {
+		f.hosts[host] = true
+	}
+	for _, mkt := range sub.Markets {
+		f.markets[mkt] = true
+		base, quote, _ := strings.Cut(mkt, "_")
+		baseID, _ := dex.BipSymbolID(base)
+		quoteID, _ := dex.BipSymbolID(quote)
+		f.assets[baseID] = true
+		f.assets[quoteID] = true
+	}
+	return f
+}
+
+func (f *noteFilter) matches(meta *noteMeta, host, mkt string) bool {
+	if len(f.noteTypes) > 0 && !f.noteTypes[meta.Type] {
+		return false
+	}
+	if len(f.hosts) > 0 && host != "" && !f.hosts[host] {
+		return false
+	}
+	if len(f.markets) > 0 {
+		if mkt != "" {
+			return f.markets[mkt]
+		}
+		if meta.AssetID != nil {
+			return f.assets[*meta.AssetID]
+		}
+	}
+	return true
+}
+
+// hasSubs checks whether the client has any notification subscriptions.
+func (cl *wsClient) hasSubs() bool {
+	cl.subsMtx.RLock()
+	defer cl.subsMtx.RUnlock()
+	return len(cl.subs) > 0
+}
+
+func (cl *wsClient) subscribed(meta *noteMeta, host, mkt string) bool {
+	cl.subsMtx.RLock()
+	defer cl.subsMtx.RUnlock()
+	for _, f := range cl.subs {
+		if f.matches(meta, host, mkt) {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamNote sends the notification to the clients with a matching
+// subscription.
+func (s *Server) StreamNote(n core.Notification) {
+	// Collect the subscribed clients so that a slow client doesn't block the
+	// clients map while we send.
+	s.clientsMtx.RLock()
+	clients := make([]*wsClient, 0, len(s.clients))
+	for _, cl := range s.clients {
+		if cl.hasSubs() {
+			clients = append(clients, cl)
+		}
+	}
+	s.clientsMtx.RUnlock()
+
+	var msg *msgjson.Message
+	var meta *noteMeta
+	var host, mkt string
+	for _, cl := range clients {
+		if meta == nil {
+			b, err := json.Marshal(n)
+			if err != nil {
+				s.log.Errorf("%s notification encoding error: %v", n.Type(), err)
+				return
+			}
+			meta = new(noteMeta)
+			if err := json.Unmarshal(b, meta); err != nil {
+				s.log.Errorf("%s notification decoding error: %v", n.Type(), err)
+				return
+			}
+			host, mkt = meta.hostAndMarket()
+			if msg, err = msgjson.NewNotification(n.Type(), json.RawMessage(b)); err != nil {
+				s.log.Errorf("%s notification encoding error: %v", n.Type(), err)
+				return
+			}
+		}
+		if !cl.subscribed(meta, host, mkt) {
+			continue
+		}
+		if err := cl.Send(msg); err != nil {
+			s.log.Warnf("Failed to stream %s notification to client %v at %v: %v",
+				n.Type(), cl.cid, cl.Addr(), err)
+		}
+	}
+}
+
+// wsSubscribe is the handler for the 'subscribe' websocket route. It adds a
+// notification subscription for the client and responds with the
+// subscription ID.
+func wsSubscribe(s *Server, cl *wsClient, msg *msgjson.Message) *msgjson.Error {
+	sub := new(NoteSubscription)
+	if len(msg.Payload) > 0 {
+		if err := msg.Unmarshal(sub); err != nil {
+			return msgjson.NewError(msgjson.RPCParseError, "error unmarshalling subscribe payload: %v", err)
+		}
+	}
+	if err := sub.validate(); err != nil {
+		return msgjson.NewError(msgjson.RPCArgumentsError, "invalid subscription: %v", err)
+	}
+
+	cl.subsMtx.Lock()
+	if cl.subs == nil {
+		cl.subs = make(map[uint32]*noteFilter)
+	}
+	cl.subID++
+	id := cl.subID
+	cl.subs[id] = newNoteFilter(sub)
+	cl.subsMtx.Unlock()
+
+	s.log.Debugf("Websocket client %s subscribed to notifications with id %d: %+v", cl.Addr(), id, sub)
+
+	resp, err := msgjson.NewResponse(msg.ID, &subscribeResult{ID: id}, nil)
+	if err != nil {
+		return msgjson.NewError(msgjson.RPCInternal, "error encoding response: %v", err)
+	}
+	if err := cl.Send(resp); err != nil {
+		s.log.Debugf("Error sending subscribe response to %s: %v", cl.Addr(), err)
+	}
+	return nil
+}
+
+// wsUnsubscribe is the handler for the 'unsubscribe' websocket route. It
+// removes the specified notification subscription, or all of them if no ID is
+// specified.
+func wsUnsubscribe(s *Server, cl *wsClient, msg *msgjson.Message) *msgjson.Error {
+	req := new(unsubscribeReq)
+	if len(msg.Payload) > 0 {
+		if err := msg.Unmarshal(req); err != nil {
+			return msgjson.NewError(msgjson.RPCParseError, "error unmarshalling unsubscribe payload: %v", err)
+		}
+	}
+
+	cl.subsMtx.Lock()
+	if req.ID == 0 {
+		cl.subs = nil
+	} else if _, found := cl.subs[req.ID]; found {
+		delete(cl.subs, req.ID)
+	} else {
+		cl.subsMtx.Unlock()
+		return msgjson.NewError(msgjson.RPCArgumentsError, "unknown subscription %d", req.ID)
+	}
+	cl.subsMtx.Unlock()
+
+	resp, err := msgjson.NewResponse(msg.ID, true, nil)
+	if err != nil {
+		return msgjson.NewError(msgjson.RPCInternal, "error encoding response: %v", err)
+	}
+	if err := cl.Send(resp); err != nil {
+		s.log.Debugf("Error sending unsubscribe response to %s: %v", cl.Addr(), err)
+	}
+	return nil
+}
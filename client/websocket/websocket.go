@@ -45,6 +45,10 @@ type wsClient struct {
 
 	feedMtx sync.RWMutex
 	feed    *bookFeed
+
+	subsMtx sync.RWMutex
+	subs    map[uint32]*noteFilter
+	subID   uint32
 }
 
 func newWSClient(addr string, conn ws.Connection, hndlr func(msg *msgjson.Message) *msgjson.Error, logger dex.Logger) *wsClient {
@@ -213,8 +217,11 @@ type wsHandler func(*Server, *wsClient, *msgjson.Message) *msgjson.Error
 var wsHandlers = map[string]wsHandler{
 	"loadmarket":  wsLoadMarket,
 	"loadcandles": wsLoadCandles,
+	"loadtrades":  wsLoadTrades,
 	"unmarket":    wsUnmarket,
 	"acknotes":    wsAckNotes,
+	"subscribe":   wsSubscribe,
+	"unsubscribe": wsUnsubscribe,
 }
 
 // marketLoad is sent by websocket clients to subscribe to a market and request
@@ -321,26 +328,52 @@ func wsLoadCandles(s *Server, cl *wsClient, msg *msgjson.Message) *msgjson.Error
 	if err != nil {
 		return msgjson.NewError(msgjson.RPCInternal, "error unmarshalling candlesLoad payload: %v", err)
 	}
+	feed, msgErr := marketFeed(s, cl, &req.marketLoad)
+	if msgErr != nil {
+		return msgErr
+	}
+	err = feed.Candles(req.Dur)
+	if err != nil {
+		return msgjson.NewError(msgjson.RPCInternal, "%v", err)
+	}
+	return nil
+}
+
+// wsLoadTrades is the handler for the 'loadtrades' websocket route. The
+// market's recent trades are sent with a 'trades' book update, followed by
+// 'trade_tape' updates as new trades are matched.
+func wsLoadTrades(s *Server, cl *wsClient, msg *msgjson.Message) *msgjson.Error {
+	req := new(marketLoad)
+	err := json.Unmarshal(msg.Payload, req)
+	if err != nil {
+		return msgjson.NewError(msgjson.RPCInternal, "error unmarshalling marketLoad payload: %v", err)
+	}
+	feed, msgErr := marketFeed(s, cl, req)
+	if msgErr != nil {
+		return msgErr
+	}
+	err = feed.Trades()
+	if err != nil {
+		return msgjson.NewError(msgjson.RPCInternal, "%v", err)
+	}
+	return nil
+}
+
+// marketFeed gets the client's feed for the market, loading the market if
+// necessary.
+func marketFeed(s *Server, cl *wsClient, req *marketLoad) (*bookFeed, *msgjson.Error) {
 	cl.feedMtx.RLock()
 	feed := cl.feed
 	cl.feedMtx.RUnlock()
 	// If market hasn't been initialized/chosen yet (client should do it in a separate
 	// 'loadmarket' request), or if client wants to change currently chosen market (requesting
-	// candles for market that's different from currently chosen implies that) - we can
+	// data for market that's different from currently chosen implies that) - we can
 	// try to load it here.
 	if feed == nil ||
 		(feed.host != req.Host || feed.base != req.Base || feed.quote != req.Quote) {
-		var msgErr *msgjson.Error
-		feed, msgErr = loadMarket(s, cl, &req.marketLoad)
-		if msgErr != nil {
-			return msgErr
-		}
-	}
-	err = feed.Candles(req.Dur)
-	if err != nil {
-		return msgjson.NewError(msgjson.RPCInternal, "%v", err)
+		return loadMarket(s, cl, req)
 	}
-	return nil
+	return feed, nil
 }
 
 // wsUnmarket is the handler for the 'unmarket' websocket route. This empty
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"decred.org/dcrdex/client/core"
+	"decred.org/dcrdex/client/db"
 	"decred.org/dcrdex/client/orderbook"
 	"decred.org/dcrdex/dex"
 	"decred.org/dcrdex/dex/msgjson"
@@ -140,6 +141,9 @@ func (*tBookFeed) Close() {}
 func (*tBookFeed) Candles(dur string) error {
 	return nil
 }
+func (*tBookFeed) Trades() error {
+	return nil
+}
 
 func TestMain(m *testing.M) {
 	var shutdown func()
@@ -302,3 +306,150 @@ func TestClientMap(t *testing.T) {
 		t.Fatal("connection not closed on server shutdown")
 	}
 }
+
+func TestSubscribe(t *testing.T) {
+	srv, _ := newTServer()
+	link := newLink()
+	link.conn.respReady = make(chan []byte, 16)
+	linkWg, err := link.cl.Connect(tCtx)
+	if err != nil {
+		t.Fatalf("WSLink Start: %v", err)
+	}
+	defer func() {
+		link.cl.Disconnect()
+		linkWg.Wait()
+	}()
+	srv.clients[link.cl.cid] = link.cl
+
+	nextMsg := func() *msgjson.Message {
+		t.Helper()
+		select {
+		case b := <-link.conn.respReady:
+			msg, err := msgjson.DecodeMessage(b)
+			if err != nil {
+				t.Fatalf("error decoding message: %v", err)
+			}
+			return msg
+		case <-time.After(time.Second):
+			t.Fatalf("no message received")
+		}
+		return nil
+	}
+
+	subscribe := func(sub *NoteSubscription) uint32 {
+		t.Helper()
+		req, _ := msgjson.NewRequest(1, "subscribe", sub)
+		if msgErr := srv.handleMessage(link.cl, req); msgErr != nil {
+			t.Fatalf("'subscribe' error: %d: %s", msgErr.Code, msgErr.Message)
+		}
+		var res subscribeResult
+		if err := nextMsg().UnmarshalResult(&res); err != nil {
+			t.Fatalf("error decoding subscribe result: %v", err)
+		}
+		return res.ID
+	}
+
+	req, _ := msgjson.NewRequest(1, "subscribe", &NoteSubscription{Markets: []string{"dcr-btc"}})
+	if msgErr := srv.handleMessage(link.cl, req); msgErr == nil || msgErr.Code != msgjson.RPCArgumentsError {
+		t.Fatalf("wrong error for invalid market: %v", msgErr)
+	}
+
+	// Notifications are not streamed without a subscription.
+	srv.StreamNote(&core.ConnEventNote{
+		Notification: db.NewNotification(core.NoteTypeConnEvent, core.TopicDEXConnected, "", "", db.Data),
+		Host:         "dex.com",
+	})
+	select {
+	case <-link.conn.respReady:
+		t.Fatalf("notification streamed without a subscription")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	orderNote := func(host string, base, quote uint32) core.Notification {
+		return &core.OrderNote{
+			Notification: db.NewNotification(core.NoteTypeOrder, core.TopicOrderBooked, "", "", db.Data),
+			Order:        &core.Order{Host: host, BaseID: base, QuoteID: quote},
+		}
+	}
+	balanceNote := func(assetID uint32) core.Notification {
+		return &core.BalanceNote{
+			Notification: db.NewNotification(core.NoteTypeBalance, core.TopicBalanceUpdated, "", "", db.Data),
+			AssetID:      assetID,
+		}
+	}
+	matchNote := &core.MatchNote{
+		Notification: db.NewNotification(core.NoteTypeMatch, core.TopicNewMatch, "", "", db.Data),
+		Host:         "dex.com",
+		MarketID:     "dcr_btc",
+	}
+	securityNote := &core.SecurityNote{
+		Notification: db.NewNotification(core.NoteTypeSecurity, core.TopicSeedNeedsSaving, "", "", db.Data),
+	}
+	// The sentinel is matched by a separate subscription and marks the end of
+	// each batch of notifications.
+	sentinel := &core.EpochNotification{
+		Notification: db.NewNotification(core.NoteTypeEpoch, core.TopicEpoch, "", "", db.Data),
+		Host:         "other.com",
+		MarketID:     "eth_btc",
+	}
+	subscribe(&NoteSubscription{NoteTypes: []string{core.NoteTypeEpoch}, Markets: []string{"eth_btc"}})
+	id := subscribe(&NoteSubscription{
+		NoteTypes: []string{core.NoteTypeOrder, core.NoteTypeBalance, core.NoteTypeSecurity},
+		Hosts:     []string{"dex.com"},
+		Markets:   []string{"dcr_btc"},
+	})
+
+	checkStream := func(notes []core.Notification, expRoutes []string) {
+		t.Helper()
+		for _, n := range append(notes, sentinel) {
+			srv.StreamNote(n)
+		}
+		for _, route := range append(expRoutes, core.NoteTypeEpoch) {
+			msg := nextMsg()
+			if msg.Type != msgjson.Notification || msg.Route != route {
+				t.Fatalf("expected %s notification, got %s", route, msg.Route)
+			}
+		}
+	}
+
+	checkStream([]core.Notification{
+		orderNote("dex.com", 42, 0),
+		orderNote("other.com", 42, 0),
+		orderNote("dex.com", 42, 60),
+		balanceNote(42),
+		balanceNote(60),
+		matchNote,
+		securityNote,
+	}, []string{core.NoteTypeOrder, core.NoteTypeBalance, core.NoteTypeSecurity})
+
+	// The payload is the notification.
+	srv.StreamNote(orderNote("dex.com", 42, 0))
+	var on core.OrderNote
+	if err := nextMsg().Unmarshal(&on); err != nil {
+		t.Fatalf("error decoding order note: %v", err)
+	}
+	if on.Order == nil || on.Order.Host != "dex.com" || on.Topic() != core.TopicOrderBooked {
+		t.Fatalf("wrong order note streamed")
+	}
+
+	req, _ = msgjson.NewRequest(2, "unsubscribe", &unsubscribeReq{ID: id})
+	if msgErr := srv.handleMessage(link.cl, req); msgErr != nil {
+		t.Fatalf("'unsubscribe' error: %d: %s", msgErr.Code, msgErr.Message)
+	}
+	nextMsg()
+	checkStream([]core.Notification{orderNote("dex.com", 42, 0), balanceNote(42)}, nil)
+
+	if msgErr := srv.handleMessage(link.cl, req); msgErr == nil {
+		t.Fatalf("no error for unknown subscription")
+	}
+
+	// Removing all subscriptions.
+	req, _ = msgjson.NewRequest(3, "unsubscribe", nil)
+	if msgErr := srv.handleMessage(link.cl, req); msgErr != nil {
+		t.Fatalf("'unsubscribe' error: %d: %s", msgErr.Code, msgErr.Message)
+	}
+	nextMsg()
+	if link.cl.hasSubs() {
+		t.Fatalf("subscriptions not removed")
+	}
+}
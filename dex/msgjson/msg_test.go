@@ -760,6 +760,86 @@ func TestCancel(t *testing.T) {
 	}
 }
 
+func TestAmend(t *testing.T) {
+	// serialization: cancel (121) + limit (variable)
+	acctID := randomBytes(32)
+	cancel := &CancelOrder{
+		Prefix: Prefix{
+			AccountID:  acctID,
+			Base:       256,
+			Quote:      65536,
+			OrderType:  3,
+			ClientTime: 1571874397,
+			Commit:     randomBytes(32),
+		},
+		TargetID: randomBytes(32),
+	}
+	limit := &LimitOrder{
+		Prefix: Prefix{
+			AccountID:  acctID,
+			Base:       256,
+			Quote:      65536,
+			OrderType:  1,
+			ClientTime: 1571874397,
+			Commit:     randomBytes(32),
+		},
+		Trade: Trade{
+			Side:     1,
+			Quantity: 600_000_000,
+			Coins:    []*Coin{randomCoin(), randomCoin()},
+			Address:  "DsDePXLAKNsFCSmgfrEsYm8G1aCVZdYvP9",
+		},
+		Rate: 350_000_000,
+		TiF:  1,
+	}
+	amend := &AmendOrder{
+		Cancel: *cancel,
+		Order:  *limit,
+	}
+
+	b := amend.Serialize()
+
+	// Compare the cancel byte-for-byte and pop it from the front.
+	x := cancel.Serialize()
+	xLen := len(x)
+	if !bytes.Equal(x, b[:xLen]) {
+		t.Fatal(x, b[:xLen])
+	}
+	b = b[xLen:]
+
+	// The rest is the replacement limit order.
+	if x = limit.Serialize(); !bytes.Equal(x, b) {
+		t.Fatal(x, b)
+	}
+
+	// Stamping the sub-orders changes the serialization.
+	amend.Cancel.Stamp(1571874405)
+	if bytes.Equal(amend.Serialize()[:len(x)], cancel.Serialize()) {
+		t.Fatal("stamped cancel serialization unchanged")
+	}
+
+	amendB, err := json.Marshal(amend)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var amendBack AmendOrder
+	err = json.Unmarshal(amendB, &amendBack)
+	if err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	comparePrefix(t, &amendBack.Cancel.Prefix, &amend.Cancel.Prefix)
+	if !bytes.Equal(amendBack.Cancel.TargetID, amend.Cancel.TargetID) {
+		t.Fatal(amendBack.Cancel.TargetID, amend.Cancel.TargetID)
+	}
+	comparePrefix(t, &amendBack.Order.Prefix, &amend.Order.Prefix)
+	compareTrade(t, &amendBack.Order.Trade, &amend.Order.Trade)
+	if amendBack.Order.Rate != amend.Order.Rate {
+		t.Fatal(amendBack.Order.Rate, amend.Order.Rate)
+	}
+}
+
 func TestConnect(t *testing.T) {
 	// serialization: account ID (32) + api version (2) + timestamp (8) = 42 bytes
 	acctID, _ := hex.DecodeString("14ae3cbc703587122d68ac6fa9194dfdc8466fb5dec9f47d2805374adff3e016")
@@ -805,6 +885,13 @@ func TestConnect(t *testing.T) {
 	if connectBack.Time != connect.Time {
 		t.Fatal(connectBack.Time, connect.Time)
 	}
+
+	// The read-only flag is only serialized when set.
+	connect.ReadOnly = true
+	b = connect.Serialize()
+	if !bytes.Equal(b, append(exp, 1)) {
+		t.Fatalf("unexpected read-only serialization. Wanted %x, got %x", append(exp, 1), b)
+	}
 }
 
 func TestPenalty(t *testing.T) {
@@ -99,6 +99,16 @@ const (
 	RPCUpdateRunningBotInvError          // 81
 	RPCMMStatusError                     // 82
 	RPCBridgeError                       // 83
+	RPCMarketRecorderError               // 84
+	RPCMMScheduleError                   // 85
+	RPCMMExportError                     // 86
+	RPCAPITokenError                     // 87
+	RPCConditionalOrderError             // 88
+	RPCLedgerError                       // 89
+	RPCReadOnlyError                     // 90
+	RPCPortfolioError                    // 91
+	RPCExecutionError                    // 92
+	RPCAmendError                        // 93
 )
 
 // Routes are destinations for a "payload" of data. The type of data being
@@ -148,6 +158,9 @@ const (
 	// CancelRoute is the client-originating request-type message placing a cancel
 	// order.
 	CancelRoute = "cancel"
+	// AmendRoute is the client-originating request-type message that replaces a
+	// standing limit order with a new limit order in a single epoch.
+	AmendRoute = "amend"
 	// OrderBookRoute is the client-originating request-type message subscribing
 	// to an order book update notification feed.
 	OrderBookRoute = "orderbook"
@@ -170,6 +183,10 @@ const (
 	// indicates the end of an epoch's book updates and provides stats for
 	// maintaining a candlestick cache.
 	EpochReportRoute = "epoch_report"
+	// TradeTapeRoute is the DEX-originating notification-type message that
+	// publishes the anonymized trades matched in an epoch to order book
+	// subscribers. It follows the epoch_report for the epoch.
+	TradeTapeRoute = "trade_tape"
 	// ConnectRoute is a client-originating request-type message seeking
 	// authentication so that the connection can be used for trading.
 	ConnectRoute = "connect"
@@ -210,6 +227,10 @@ const (
 	// client of an upcoming trade resumption. This is part of the
 	// subscription-based orderbook notification feed.
 	ResumptionRoute = "resumption"
+	// MarketParamsRoute is the DEX-originating notification-type message
+	// informing the client of an upcoming change to a market's lot size and
+	// rate step.
+	MarketParamsRoute = "market_params"
 	// NotifyRoute is the DEX-originating notification-type message
 	// delivering text messages from the operator.
 	NotifyRoute = "notify"
@@ -231,6 +252,9 @@ const (
 	// CandlesRoute is the HTTP request to get the set of candlesticks
 	// representing market activity history.
 	CandlesRoute = "candles"
+	// TradesRoute is the HTTP request to get a market's recent anonymized
+	// trades.
+	TradesRoute = "trades"
 )
 
 const errNullRespPayload = dex.ErrorKind("null response payload")
@@ -603,6 +627,9 @@ func (audit *Audit) Serialize() []byte {
 type RevokeOrder struct {
 	Signature
 	OrderID Bytes `json:"orderid"`
+	// Reason is an optional explanation for the revocation. It is not part of
+	// the signed serialization.
+	Reason string `json:"reason,omitempty"`
 }
 
 var _ Signable = (*RevokeMatch)(nil)
@@ -796,6 +823,31 @@ func (c *CancelOrder) Serialize() []byte {
 	return append(c.Prefix.Serialize(), c.TargetID...)
 }
 
+// AmendOrder is the payload for the AmendRoute, which atomically replaces a
+// standing limit order. The Cancel targets the order being amended, and Order
+// is the replacement. Both are placed in the same epoch, and the replacement is
+// only matched or booked if the cancel order removes its target from the book.
+// The sub-orders are not signed individually. Instead, the client signs the
+// concatenation of their serializations.
+type AmendOrder struct {
+	Signature
+	Cancel CancelOrder `json:"cancel"`
+	Order  LimitOrder  `json:"order"`
+}
+
+// Serialize serializes the AmendOrder data.
+func (a *AmendOrder) Serialize() []byte {
+	// serialization: cancel (121) + limit order (variable)
+	return append(a.Cancel.Serialize(), a.Order.Serialize()...)
+}
+
+// AmendResult is returned from the AmendRoute. There is an OrderResult for each
+// of the cancel and replacement orders.
+type AmendResult struct {
+	Cancel *OrderResult `json:"cancel"`
+	Order  *OrderResult `json:"order"`
+}
+
 // RedeemSig is a signature proving ownership of the redeeming address. This is
 // only necessary as part of a Trade if the asset received is account-based.
 type RedeemSig struct {
@@ -917,6 +969,22 @@ type TradeResumption struct {
 	// TODO: ConfigChange bool or entire Config Market here.
 }
 
+// MarketParamsChange describes a scheduled change to a market's lot size and
+// rate step. The new values apply to orders placed in Epoch and later. Booked
+// orders that do not conform to the new values are revoked when the change
+// takes effect.
+type MarketParamsChange struct {
+	Epoch    uint64 `json:"epoch"`
+	LotSize  uint64 `json:"lotsize"`
+	RateStep uint64 `json:"ratestep"`
+}
+
+// MarketParamsNote is the MarketParamsRoute notification payload.
+type MarketParamsNote struct {
+	MarketID string `json:"marketid"`
+	MarketParamsChange
+}
+
 // PreimageRequest is the server-originating preimage request payload.
 type PreimageRequest struct {
 	OrderID        Bytes `json:"orderid"`
@@ -935,15 +1003,25 @@ type Connect struct {
 	AccountID  Bytes  `json:"accountid"`
 	APIVersion uint16 `json:"apiver"`
 	Time       uint64 `json:"timestamp"`
+	// ReadOnly requests the account's status without authorizing the
+	// connection for trading. An existing connection for the account is not
+	// replaced, and no requests or notifications are routed to a read-only
+	// connection.
+	ReadOnly bool `json:"readonly,omitempty"`
 }
 
 // Serialize serializes the Connect data.
 func (c *Connect) Serialize() []byte {
-	// serialization: account ID (32) + api version (2) + timestamp (8) = 42 bytes
-	s := make([]byte, 0, 42)
+	// serialization: account ID (32) + api version (2) + timestamp (8) +
+	// read-only flag (1, only if set) = 42 or 43 bytes
+	s := make([]byte, 0, 43)
 	s = append(s, c.AccountID...)
 	s = append(s, uint16Bytes(c.APIVersion)...)
-	return append(s, uint64Bytes(c.Time)...)
+	s = append(s, uint64Bytes(c.Time)...)
+	if c.ReadOnly {
+		s = append(s, 1)
+	}
+	return s
 }
 
 // Bond is information on a fidelity bond. This is part of the ConnectResult and
@@ -1225,6 +1303,8 @@ type Market struct {
 	MarketBuyBuffer float64 `json:"buybuffer"`
 	ParcelSize      uint32  `json:"parcelSize"`
 	MarketStatus    `json:"status"`
+	// ParamsChange is a scheduled change to LotSize and RateStep, if any.
+	ParamsChange *MarketParamsChange `json:"paramschange,omitempty"`
 }
 
 // Running indicates if the market should be running given the known StartEpoch,
@@ -1306,6 +1386,33 @@ type CandlesRequest struct {
 	NumCandles int    `json:"numCandles,omitempty"` // default and max defined in apidata.
 }
 
+// TradesRequest is a data API request for a market's recent trades.
+type TradesRequest struct {
+	BaseID  uint32 `json:"baseID"`
+	QuoteID uint32 `json:"quoteID"`
+	// Before, if non-zero, limits the trades to those from epochs that ended
+	// before this time stamp (ms), for paging through older trades.
+	Before    uint64 `json:"before,omitempty"`
+	NumTrades int    `json:"numTrades,omitempty"` // default and max defined in apidata.
+}
+
+// MarketTrade is an anonymized record of a trade order match.
+type MarketTrade struct {
+	// Stamp is the end time (ms) of the epoch in which the match was made.
+	Stamp     uint64 `json:"stamp"`
+	Rate      uint64 `json:"rate"`
+	Qty       uint64 `json:"qty"`
+	TakerSell bool   `json:"takerSell"`
+}
+
+// TradeTapeNote is the payload for a DEX-originating TradeTapeRoute
+// notification. Trades are in match order.
+type TradeTapeNote struct {
+	MarketID string         `json:"marketid"`
+	Epoch    uint64         `json:"epoch"`
+	Trades   []*MarketTrade `json:"trades"`
+}
+
 // Candle is a statistical history of a specified period of market activity.
 type Candle struct {
 	StartStamp  uint64 `json:"startStamp"`
@@ -21,6 +21,7 @@ import (
 	"decred.org/dcrdex/server/account"
 	dexsrv "decred.org/dcrdex/server/dex"
 	"decred.org/dcrdex/server/market"
+	"decred.org/dcrdex/server/metrics"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -57,6 +58,15 @@ func apiPing(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, pongStr)
 }
 
+// apiMetrics is the handler for the '/metrics' API request. The server's
+// metrics are written in the OpenMetrics text format.
+func apiMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.Default.Write(w); err != nil {
+		log.Errorf("Write error: %v", err)
+	}
+}
+
 // apiConfig is the handler for the '/config' API request.
 func (s *Server) apiConfig(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, s.core.ConfigMsg())
@@ -146,6 +156,7 @@ func (s *Server) apiMarkets(w http.ResponseWriter, r *http.Request) {
 			ActiveEpoch:   status.ActiveEpoch,
 			StartEpoch:    status.StartEpoch,
 			SuspendEpoch:  status.SuspendEpoch,
+			ParamsChange:  paramsChange(status),
 		}
 		if status.SuspendEpoch != 0 {
 			persist := status.PersistBook
@@ -157,6 +168,21 @@ func (s *Server) apiMarkets(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, mktStatuses)
 }
 
+// paramsChange converts a market's scheduled lot size and rate step change,
+// if any, for a MarketStatus.
+func paramsChange(status *market.Status) *ParamsChange {
+	change := status.ParamsChange
+	if change == nil {
+		return nil
+	}
+	return &ParamsChange{
+		LotSize:     change.LotSize,
+		RateStep:    change.RateStep,
+		ChangeEpoch: change.Epoch,
+		ChangeTime:  APITime{time.UnixMilli(change.Epoch * int64(status.EpochDuration))},
+	}
+}
+
 // apiMarketInfo is the handler for the '/market/{marketName}' API request.
 func (s *Server) apiMarketInfo(w http.ResponseWriter, r *http.Request) {
 	mkt := strings.ToLower(chi.URLParam(r, marketNameKey))
@@ -179,6 +205,7 @@ func (s *Server) apiMarketInfo(w http.ResponseWriter, r *http.Request) {
 		StartEpoch:    status.StartEpoch,
 		SuspendEpoch:  status.SuspendEpoch,
 		PersistBook:   persist,
+		ParamsChange:  paramsChange(status),
 	}
 	if status.SuspendEpoch != 0 {
 		persist := status.PersistBook
@@ -435,6 +462,132 @@ func (s *Server) apiSuspend(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// apiAddMarket is the handler for the '/markets' POST API request. The body is
+// a JSON-encoded dexsrv.MarketConfig.
+func (s *Server) apiAddMarket(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read request body: %v", err), http.StatusInternalServerError)
+		return
+	}
+	mktCfg := new(dexsrv.MarketConfig)
+	if err = json.Unmarshal(body, mktCfg); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse market config: %v", err), http.StatusBadRequest)
+		return
+	}
+	if mktCfg.Market == nil {
+		http.Error(w, "no market specified", http.StatusBadRequest)
+		return
+	}
+
+	mkt, startEpoch, startTime, err := s.core.AddMarket(mktCfg)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to add market: %v", err)
+		log.Errorf(msg)
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, &AddMarketResult{
+		Market:     mkt,
+		StartEpoch: startEpoch,
+		StartTime:  APITime{startTime},
+	})
+}
+
+// handler for route '/market/{marketName}/retire'
+func (s *Server) apiRetireMarket(w http.ResponseWriter, r *http.Request) {
+	// Ensure the market exists and is not running.
+	mkt := strings.ToLower(chi.URLParam(r, marketNameKey))
+	found, running := s.core.MarketRunning(mkt)
+	if !found {
+		http.Error(w, fmt.Sprintf("unknown market %q", mkt), http.StatusBadRequest)
+		return
+	}
+	if running {
+		http.Error(w, fmt.Sprintf("market %q running. Suspend it first", mkt), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.core.RetireMarket(mkt); err != nil {
+		msg := fmt.Sprintf("Failed to retire market: %v", err)
+		log.Errorf(msg)
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, fmt.Sprintf("Market %s retired", mkt))
+}
+
+// handler for route '/market/{marketName}/params?lotsize=ATOMS&ratestep=ATOMS&t=UNIXMS'
+func (s *Server) apiMarketParams(w http.ResponseWriter, r *http.Request) {
+	// Ensure the market exists and is running.
+	mkt := strings.ToLower(chi.URLParam(r, marketNameKey))
+	found, running := s.core.MarketRunning(mkt)
+	if !found {
+		http.Error(w, fmt.Sprintf("unknown market %q", mkt), http.StatusBadRequest)
+		return
+	}
+	if !running {
+		http.Error(w, fmt.Sprintf("market %q not running", mkt), http.StatusBadRequest)
+		return
+	}
+
+	parseAtoms := func(key string) (uint64, bool) {
+		v, err := strconv.ParseUint(r.URL.Query().Get(key), 10, 64)
+		if err != nil || v == 0 {
+			http.Error(w, fmt.Sprintf("invalid %s %q", key, r.URL.Query().Get(key)), http.StatusBadRequest)
+			return 0, false
+		}
+		return v, true
+	}
+	lotSize, ok := parseAtoms("lotsize")
+	if !ok {
+		return
+	}
+	rateStep, ok := parseAtoms("ratestep")
+	if !ok {
+		return
+	}
+
+	// Validate the change time provided in the "t" query. If not specified,
+	// the zero time.Time is used to indicate ASAP.
+	var changeTime time.Time
+	if tChangeStr := r.URL.Query().Get("t"); tChangeStr != "" {
+		changeTimeMs, err := strconv.ParseInt(tChangeStr, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid change time %q: %v", tChangeStr, err), http.StatusBadRequest)
+			return
+		}
+
+		changeTime = time.UnixMilli(changeTimeMs)
+		if time.Until(changeTime) < 0 {
+			http.Error(w, fmt.Sprintf("specified market params change time is in the past: %v", changeTime),
+				http.StatusBadRequest)
+			return
+		}
+	}
+
+	changeEpoch, changeTime, err := s.core.ScheduleMarketParamsChange(mkt, changeTime, lotSize, rateStep)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to schedule market params change: %v", err)
+		log.Errorf(msg)
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, &MarketParamsResult{
+		Market: mkt,
+		ParamsChange: ParamsChange{
+			LotSize:     lotSize,
+			RateStep:    rateStep,
+			ChangeEpoch: changeEpoch,
+			ChangeTime:  APITime{changeTime},
+		},
+	})
+}
+
 // apiEnableDataAPI is the handler for the `/enabledataapi/{yes}` API request,
 // used to enable or disable the HTTP data API.
 func (s *Server) apiEnableDataAPI(w http.ResponseWriter, r *http.Request) {
@@ -70,6 +70,9 @@ type SvrCore interface {
 	MarketStatuses() map[string]*market.Status
 	SuspendMarket(name string, tSusp time.Time, persistBooks bool) (*market.SuspendEpoch, error)
 	ResumeMarket(name string, asSoonAs time.Time) (startEpoch int64, startTime time.Time, err error)
+	AddMarket(mktCfg *dexsrv.MarketConfig) (name string, startEpoch int64, startTime time.Time, err error)
+	RetireMarket(name string) error
+	ScheduleMarketParamsChange(name string, asSoonAs time.Time, lotSize, rateStep uint64) (changeEpoch int64, changeTime time.Time, err error)
 	ForgiveMatchFail(aid account.AccountID, mid order.MatchID) (forgiven, unbanned bool, err error)
 	AccountMatchOutcomesN(user account.AccountID, n int) ([]*auth.MatchOutcome, error)
 	BookOrders(base, quote uint32) (orders []*order.LimitOrder, err error)
@@ -164,6 +167,7 @@ func NewServer(cfg *SrvConfig) (*Server, error) {
 		})
 		r.Post("/notifyall", s.apiNotifyAll)
 		r.Get("/markets", s.apiMarkets)
+		r.Post("/markets", s.apiAddMarket)
 		r.Route("/market/{"+marketNameKey+"}", func(rm chi.Router) {
 			rm.Get("/", s.apiMarketInfo)
 			rm.Get("/orderbook", s.apiMarketOrderBook)
@@ -171,8 +175,11 @@ func NewServer(cfg *SrvConfig) (*Server, error) {
 			rm.Get("/matches", s.apiMarketMatches)
 			rm.Get("/suspend", s.apiSuspend)
 			rm.Get("/resume", s.apiResume)
+			rm.Get("/retire", s.apiRetireMarket)
+			rm.Get("/params", s.apiMarketParams)
 		})
 		r.Get("/prepaybonds", s.prepayBonds)
+		r.Get("/metrics", apiMetrics)
 	})
 
 	return s, nil
@@ -51,6 +51,7 @@ type TMarket struct {
 	resumeEpoch int64
 	resumeTime  time.Time
 	persist     bool
+	change      *market.ParamsChange
 }
 
 type TCore struct {
@@ -68,6 +69,9 @@ type TCore struct {
 	marketMatches    []*dexsrv.MatchData
 	marketMatchesErr error
 	dataEnabled      uint32
+	addMarketErr     error
+	retireMarketErr  error
+	paramsErr        error
 }
 
 func (c *TCore) ConfigMsg() json.RawMessage { return nil }
@@ -85,6 +89,47 @@ func (c *TCore) ResumeMarket(name string, tRes time.Time) (startEpoch int64, sta
 	tMkt.resumeTime = time.UnixMilli(tMkt.resumeEpoch * int64(tMkt.dur))
 	return tMkt.resumeEpoch, tMkt.resumeTime, nil
 }
+func (c *TCore) AddMarket(mktCfg *dexsrv.MarketConfig) (name string, startEpoch int64, startTime time.Time, err error) {
+	if c.addMarketErr != nil {
+		return "", 0, time.Time{}, c.addMarketErr
+	}
+	name = strings.ToLower(mktCfg.Market.Base + "_" + mktCfg.Market.Quote)
+	if c.markets[name] != nil {
+		err = fmt.Errorf("market %s is already running", name)
+		return
+	}
+	tMkt := &TMarket{
+		running: true,
+		dur:     mktCfg.Market.Duration,
+	}
+	tMkt.startEpoch = 1 + time.Now().UnixMilli()/int64(tMkt.dur)
+	c.markets[name] = tMkt
+	return name, tMkt.startEpoch, time.UnixMilli(tMkt.startEpoch * int64(tMkt.dur)), nil
+}
+func (c *TCore) RetireMarket(name string) error {
+	if c.retireMarketErr != nil {
+		return c.retireMarketErr
+	}
+	delete(c.markets, name)
+	return nil
+}
+func (c *TCore) ScheduleMarketParamsChange(name string, asSoonAs time.Time, lotSize, rateStep uint64) (changeEpoch int64, changeTime time.Time, err error) {
+	if c.paramsErr != nil {
+		return 0, time.Time{}, c.paramsErr
+	}
+	tMkt := c.markets[name]
+	if tMkt == nil {
+		err = fmt.Errorf("unknown market %s", name)
+		return
+	}
+	changeEpoch = max(1+asSoonAs.UnixMilli()/int64(tMkt.dur), 2+time.Now().UnixMilli()/int64(tMkt.dur))
+	tMkt.change = &market.ParamsChange{
+		Epoch:    changeEpoch,
+		LotSize:  lotSize,
+		RateStep: rateStep,
+	}
+	return changeEpoch, time.UnixMilli(changeEpoch * int64(tMkt.dur)), nil
+}
 func (c *TCore) SuspendMarket(name string, tSusp time.Time, persistBooks bool) (suspEpoch *market.SuspendEpoch, err error) {
 	tMkt := c.markets[name]
 	if tMkt == nil {
@@ -120,6 +165,7 @@ func (c *TCore) MarketStatus(mktName string) *market.Status {
 		StartEpoch:    mkt.startEpoch,
 		SuspendEpoch:  suspendEpoch,
 		PersistBook:   mkt.persist,
+		ParamsChange:  mkt.change,
 	}
 }
 
@@ -314,6 +360,30 @@ func TestPing(t *testing.T) {
 	}
 }
 
+func TestMetrics(t *testing.T) {
+	w := httptest.NewRecorder()
+	apiMetrics(w, nil)
+	if w.Code != 200 {
+		t.Fatalf("apiMetrics returned code %d, expected 200", w.Code)
+	}
+
+	ctHdr := w.Result().Header.Get("Content-Type")
+	if !strings.HasPrefix(ctHdr, "application/openmetrics-text") {
+		t.Errorf("Content-Type incorrect. got %q", ctHdr)
+	}
+
+	body := w.Body.String()
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("metrics missing EOF marker")
+	}
+	// Metrics registered by packages the admin server imports are included.
+	for _, name := range []string{"dcrdex_connected_clients", "dcrdex_epochs_processed", "dcrdex_swap_step_seconds"} {
+		if !strings.Contains(body, "# TYPE "+name+" ") {
+			t.Errorf("metric %s not found", name)
+		}
+	}
+}
+
 func TestMarkets(t *testing.T) {
 	core := &TCore{
 		markets: make(map[string]*TMarket),
@@ -850,6 +920,232 @@ func TestResume(t *testing.T) {
 	}
 }
 
+func TestAddMarket(t *testing.T) {
+	core := &TCore{
+		markets: make(map[string]*TMarket),
+	}
+	srv := &Server{
+		core: core,
+	}
+
+	mux := chi.NewRouter()
+	mux.Post("/markets", srv.apiAddMarket)
+
+	const goodCfg = `{"market":{"base":"DCR","quote":"BTC","lotSize":100000000,` +
+		`"parcelSize":1,"rateStep":100,"epochDuration":6000,"marketBuyBuffer":1.2}}`
+
+	tests := []struct {
+		name     string
+		body     string
+		coreErr  error
+		wantCode int
+	}{{
+		name:     "ok",
+		body:     goodCfg,
+		wantCode: http.StatusOK,
+	}, {
+		name:     "already running",
+		body:     goodCfg,
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "core error",
+		body:     goodCfg,
+		coreErr:  errors.New("invalid market configuration"),
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "bad json",
+		body:     `{"market":`,
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "no market",
+		body:     `{}`,
+		wantCode: http.StatusBadRequest,
+	}}
+	for _, test := range tests {
+		core.addMarketErr = test.coreErr
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, "https://localhost/markets", strings.NewReader(test.body))
+		r.RemoteAddr = "localhost"
+
+		mux.ServeHTTP(w, r)
+
+		if w.Code != test.wantCode {
+			t.Fatalf("%q: apiAddMarket returned code %d, expected %d", test.name, w.Code, test.wantCode)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		res := new(AddMarketResult)
+		if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+			t.Fatalf("%q: failed to unmarshal result: %v", test.name, err)
+		}
+		if res.Market != "dcr_btc" {
+			t.Errorf("%q: incorrect market name %q, expected %q", test.name, res.Market, "dcr_btc")
+		}
+		if res.StartEpoch == 0 {
+			t.Errorf("%q: start epoch not set", test.name)
+		}
+		if res.StartTime.IsZero() {
+			t.Errorf("%q: start time not set", test.name)
+		}
+	}
+}
+
+func TestRetireMarket(t *testing.T) {
+	core := &TCore{
+		markets: make(map[string]*TMarket),
+	}
+	srv := &Server{
+		core: core,
+	}
+
+	mux := chi.NewRouter()
+	mux.Get("/market/{"+marketNameKey+"}/retire", srv.apiRetireMarket)
+
+	name := "dcr_btc"
+	retire := func() *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "https://localhost/market/"+name+"/retire", nil)
+		r.RemoteAddr = "localhost"
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	// Non-existent market
+	w := retire()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("apiRetireMarket returned code %d, expected %d", w.Code, http.StatusBadRequest)
+	}
+
+	// Running market
+	tMkt := &TMarket{
+		running: true,
+		dur:     6000,
+	}
+	core.markets[name] = tMkt
+	w = retire()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("apiRetireMarket returned code %d, expected %d", w.Code, http.StatusBadRequest)
+	}
+	wantMsg := "market \"dcr_btc\" running. Suspend it first\n"
+	if w.Body.String() != wantMsg {
+		t.Errorf("expected body %q, got %q", wantMsg, w.Body)
+	}
+
+	// Core error
+	tMkt.running = false
+	core.retireMarketErr = errors.New("markets file not writable")
+	w = retire()
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("apiRetireMarket returned code %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+
+	// Stopped
+	core.retireMarketErr = nil
+	w = retire()
+	if w.Code != http.StatusOK {
+		t.Fatalf("apiRetireMarket returned code %d, expected %d", w.Code, http.StatusOK)
+	}
+	if core.markets[name] != nil {
+		t.Fatalf("market not retired")
+	}
+}
+
+func TestMarketParams(t *testing.T) {
+	core := &TCore{
+		markets: make(map[string]*TMarket),
+	}
+	srv := &Server{
+		core: core,
+	}
+
+	mux := chi.NewRouter()
+	mux.Get("/market/{"+marketNameKey+"}/params", srv.apiMarketParams)
+	mux.Get("/market/{"+marketNameKey+"}", srv.apiMarketInfo)
+
+	name := "dcr_btc"
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "https://localhost/market/"+path, nil)
+		r.RemoteAddr = "localhost"
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	// Non-existent market
+	w := get(name + "/params?lotsize=200000000&ratestep=1000")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("apiMarketParams returned code %d, expected %d", w.Code, http.StatusBadRequest)
+	}
+
+	// Not running
+	tMkt := &TMarket{
+		dur: 6000,
+	}
+	core.markets[name] = tMkt
+	w = get(name + "/params?lotsize=200000000&ratestep=1000")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("apiMarketParams returned code %d, expected %d", w.Code, http.StatusBadRequest)
+	}
+	wantMsg := "market \"dcr_btc\" not running\n"
+	if w.Body.String() != wantMsg {
+		t.Errorf("expected body %q, got %q", wantMsg, w.Body)
+	}
+
+	// Bad values
+	tMkt.running = true
+	for _, query := range []string{
+		"ratestep=1000",
+		"lotsize=0&ratestep=1000",
+		"lotsize=200000000&ratestep=x",
+		"lotsize=200000000&ratestep=1000&t=12",
+	} {
+		w = get(name + "/params?" + query)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("apiMarketParams returned code %d for query %q, expected %d", w.Code, query, http.StatusBadRequest)
+		}
+	}
+
+	// Core error
+	core.paramsErr = errors.New("lot size too low")
+	w = get(name + "/params?lotsize=200000000&ratestep=1000")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("apiMarketParams returned code %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+	core.paramsErr = nil
+
+	tChange := time.Now().Add(time.Hour).UnixMilli()
+	w = get(fmt.Sprintf("%s/params?lotsize=200000000&ratestep=1000&t=%d", name, tChange))
+	if w.Code != http.StatusOK {
+		t.Fatalf("apiMarketParams returned code %d, expected %d", w.Code, http.StatusOK)
+	}
+	res := new(MarketParamsResult)
+	if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if res.Market != name || res.LotSize != 200000000 || res.RateStep != 1000 {
+		t.Errorf("wrong result %+v", res)
+	}
+	if res.ChangeTime.UnixMilli() < tChange || res.ChangeEpoch != res.ChangeTime.UnixMilli()/int64(tMkt.dur) {
+		t.Errorf("wrong change epoch %d and time %v", res.ChangeEpoch, res.ChangeTime)
+	}
+
+	// The scheduled change is in the market status.
+	w = get(name)
+	if w.Code != http.StatusOK {
+		t.Fatalf("apiMarketInfo returned code %d, expected %d", w.Code, http.StatusOK)
+	}
+	mktStatus := new(MarketStatus)
+	if err := json.Unmarshal(w.Body.Bytes(), mktStatus); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if mktStatus.ParamsChange == nil || mktStatus.ParamsChange.ChangeEpoch != res.ChangeEpoch {
+		t.Errorf("scheduled change not in market status")
+	}
+}
+
 func TestSuspend(t *testing.T) {
 	core := &TCore{
 		markets: make(map[string]*TMarket),
@@ -27,13 +27,14 @@ type AssetInfo struct {
 
 // MarketStatus summarizes the operational status of a market.
 type MarketStatus struct {
-	Name          string `json:"market,omitempty"`
-	Running       bool   `json:"running"`
-	EpochDuration uint64 `json:"epochlen"`
-	ActiveEpoch   int64  `json:"activeepoch"`
-	StartEpoch    int64  `json:"startepoch"`
-	SuspendEpoch  int64  `json:"finalepoch,omitempty"`
-	PersistBook   *bool  `json:"persistbook,omitempty"`
+	Name          string        `json:"market,omitempty"`
+	Running       bool          `json:"running"`
+	EpochDuration uint64        `json:"epochlen"`
+	ActiveEpoch   int64         `json:"activeepoch"`
+	StartEpoch    int64         `json:"startepoch"`
+	SuspendEpoch  int64         `json:"finalepoch,omitempty"`
+	PersistBook   *bool         `json:"persistbook,omitempty"`
+	ParamsChange  *ParamsChange `json:"paramschange,omitempty"`
 }
 
 // MatchData describes a match.
@@ -82,6 +83,29 @@ type ResumeResult struct {
 	StartTime  APITime `json:"starttime"`
 }
 
+// AddMarketResult is the result of an add market request.
+type AddMarketResult struct {
+	Market     string  `json:"market"`
+	StartEpoch int64   `json:"startepoch"`
+	StartTime  APITime `json:"starttime"`
+}
+
+// ParamsChange describes a scheduled change to a market's lot size and rate
+// step.
+type ParamsChange struct {
+	LotSize     uint64  `json:"lotsize"`
+	RateStep    uint64  `json:"ratestep"`
+	ChangeEpoch int64   `json:"changeepoch"`
+	ChangeTime  APITime `json:"changetime"`
+}
+
+// MarketParamsResult is the result of a market lot size and rate step change
+// request.
+type MarketParamsResult struct {
+	Market string `json:"market"`
+	ParamsChange
+}
+
 // RFC3339Milli is the RFC3339 time formatting with millisecond precision.
 const RFC3339Milli = "2006-01-02T15:04:05.999Z07:00"
 
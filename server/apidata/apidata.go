@@ -6,6 +6,7 @@ package apidata
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,9 +15,19 @@ import (
 	"decred.org/dcrdex/dex/candles"
 	"decred.org/dcrdex/dex/msgjson"
 	"decred.org/dcrdex/server/comms"
+	"decred.org/dcrdex/server/db"
 	"decred.org/dcrdex/server/matcher"
 )
 
+const (
+	// TradeCacheSize is the number of recent trades kept in memory for each
+	// market. Older trades are retrieved from the DBSource.
+	TradeCacheSize = 1000
+	// DefaultTradesRequest is the number of trades returned for a trades
+	// request that does not specify a number.
+	DefaultTradesRequest = 100
+)
+
 var (
 	// Our internal millisecond representation of the bin sizes.
 	binSizes []uint64
@@ -29,6 +40,7 @@ type DBSource interface {
 	LoadEpochStats(base, quote uint32, caches []*candles.Cache) error
 	LastCandleEndStamp(base, quote uint32, candleDur uint64) (uint64, error)
 	InsertCandles(base, quote uint32, dur uint64, cs []*candles.Candle) error
+	MarketTrades(base, quote uint32, before uint64, n int) ([]*db.Trade, error)
 }
 
 // MarketSource is a source of market information. Markets are added after
@@ -45,6 +57,43 @@ type BookSource interface {
 	Book(mktName string) (*msgjson.OrderBook, error)
 }
 
+// tradeCache is a capped ring buffer of a market's most recent trades.
+type tradeCache struct {
+	trades []*msgjson.MarketTrade
+	cursor int // position of the oldest trade once the cache is full
+}
+
+// add adds trades to the cache, oldest first, replacing the oldest cached
+// trades if the cache is full.
+func (c *tradeCache) add(trades []*msgjson.MarketTrade) {
+	for _, t := range trades {
+		if len(c.trades) < TradeCacheSize {
+			c.trades = append(c.trades, t)
+			continue
+		}
+		c.trades[c.cursor] = t
+		c.cursor = (c.cursor + 1) % TradeCacheSize
+	}
+}
+
+// full is true if trades may have been dropped from the cache.
+func (c *tradeCache) full() bool {
+	return len(c.trades) == TradeCacheSize
+}
+
+// recent returns up to n cached trades from epochs that ended before the time
+// stamp, newest first.
+func (c *tradeCache) recent(before uint64, n int) []*msgjson.MarketTrade {
+	trades := make([]*msgjson.MarketTrade, 0, min(n, len(c.trades)))
+	for i := len(c.trades) - 1; i >= 0 && len(trades) < n; i-- {
+		t := c.trades[(c.cursor+i)%len(c.trades)]
+		if t.Stamp < before {
+			trades = append(trades, t)
+		}
+	}
+	return trades
+}
+
 type cacheWithStoredTime struct {
 	*candles.Cache
 	lastStoredEndStamp uint64 // protected by DataAPI.cacheMtx
@@ -52,15 +101,18 @@ type cacheWithStoredTime struct {
 
 // DataAPI is a data API backend.
 type DataAPI struct {
-	db             DBSource
-	epochDurations map[string]uint64
-	bookSource     BookSource
+	db         DBSource
+	bookSource BookSource
 
 	spotsMtx sync.RWMutex
 	spots    map[string]json.RawMessage
 
-	cacheMtx     sync.RWMutex
-	marketCaches map[string]map[uint64]*cacheWithStoredTime
+	cacheMtx       sync.RWMutex
+	epochDurations map[string]uint64
+	marketCaches   map[string]map[uint64]*cacheWithStoredTime
+
+	tradesMtx   sync.RWMutex
+	tradeCaches map[string]*tradeCache
 }
 
 // NewDataAPI is the constructor for a new DataAPI.
@@ -70,24 +122,25 @@ func NewDataAPI(dbSrc DBSource, registerHTTP func(route string, handler comms.HT
 		epochDurations: make(map[string]uint64),
 		spots:          make(map[string]json.RawMessage),
 		marketCaches:   make(map[string]map[uint64]*cacheWithStoredTime),
+		tradeCaches:    make(map[string]*tradeCache),
 	}
 
 	if atomic.CompareAndSwapUint32(&started, 0, 1) {
 		registerHTTP(msgjson.SpotsRoute, s.handleSpots)
 		registerHTTP(msgjson.CandlesRoute, s.handleCandles)
 		registerHTTP(msgjson.OrderBookRoute, s.handleOrderBook)
+		registerHTTP(msgjson.TradesRoute, s.handleTrades)
 	}
 	return s
 }
 
-// AddMarketSource should be called before any markets are running.
+// AddMarketSource should be called before the market is running.
 func (s *DataAPI) AddMarketSource(mkt MarketSource) error {
 	mktName, err := dex.MarketName(mkt.Base(), mkt.Quote())
 	if err != nil {
 		return err
 	}
 	epochDur := mkt.EpochDuration()
-	s.epochDurations[mktName] = epochDur
 	binCaches := make(map[uint64]*cacheWithStoredTime, len(binSizes)+1)
 	cacheList := make([]*candles.Cache, 0, len(binSizes)+1)
 	for _, binSize := range append([]uint64{epochDur}, binSizes...) {
@@ -104,12 +157,46 @@ func (s *DataAPI) AddMarketSource(mkt MarketSource) error {
 	if err != nil {
 		return err
 	}
+	// Prime the trade cache with the most recent stored trades.
+	storedTrades, err := s.db.MarketTrades(mkt.Base(), mkt.Quote(), math.MaxInt64, TradeCacheSize)
+	if err != nil {
+		return fmt.Errorf("MarketTrades: %w", err)
+	}
+	trades := make([]*msgjson.MarketTrade, len(storedTrades))
+	for i, t := range storedTrades { // newest first
+		trades[len(trades)-1-i] = marketTrade(t)
+	}
+	tc := new(tradeCache)
+	tc.add(trades)
+
 	s.cacheMtx.Lock()
+	s.epochDurations[mktName] = epochDur
 	s.marketCaches[mktName] = binCaches
 	s.cacheMtx.Unlock()
+
+	s.tradesMtx.Lock()
+	s.tradeCaches[mktName] = tc
+	s.tradesMtx.Unlock()
 	return nil
 }
 
+// RemoveMarketSource removes the candle caches and spot price of a market that
+// has been retired.
+func (s *DataAPI) RemoveMarketSource(mktName string) {
+	s.cacheMtx.Lock()
+	delete(s.epochDurations, mktName)
+	delete(s.marketCaches, mktName)
+	s.cacheMtx.Unlock()
+
+	s.tradesMtx.Lock()
+	delete(s.tradeCaches, mktName)
+	s.tradesMtx.Unlock()
+
+	s.spotsMtx.Lock()
+	delete(s.spots, mktName)
+	s.spotsMtx.Unlock()
+}
+
 // SetBookSource should be called before the first call to handleBook.
 func (s *DataAPI) SetBookSource(bs BookSource) {
 	s.bookSource = bs
@@ -197,6 +284,23 @@ func (s *DataAPI) ReportEpoch(base, quote uint32, epochIdx uint64, stats *matche
 	return spot, err
 }
 
+// ReportTrades should be called by every Market after every match cycle that
+// produces trade matches, with the trades in match order.
+func (s *DataAPI) ReportTrades(base, quote uint32, trades []*msgjson.MarketTrade) error {
+	mktName, err := dex.MarketName(base, quote)
+	if err != nil {
+		return err
+	}
+	s.tradesMtx.Lock()
+	defer s.tradesMtx.Unlock()
+	tc := s.tradeCaches[mktName]
+	if tc == nil {
+		return fmt.Errorf("unknown market %q", mktName)
+	}
+	tc.add(trades)
+	return nil
+}
+
 // handleSpots implements comms.HTTPHandler for the /spots endpoint.
 func (s *DataAPI) handleSpots(any) (any, error) {
 	s.spotsMtx.RLock()
@@ -261,6 +365,64 @@ func (s *DataAPI) handleOrderBook(thing any) (any, error) {
 	return s.bookSource.Book(mkt)
 }
 
+// handleTrades implements comms.HTTPHandler for the /trades endpoints. Trades
+// are served from the cache when possible, and from the DBSource otherwise.
+func (s *DataAPI) handleTrades(thing any) (any, error) {
+	req, ok := thing.(*msgjson.TradesRequest)
+	if !ok {
+		return nil, fmt.Errorf("trades request unparseable")
+	}
+
+	n := req.NumTrades
+	if n <= 0 {
+		n = DefaultTradesRequest
+	} else if n > TradeCacheSize {
+		return nil, fmt.Errorf("requested numTrades %d exceeds maximum request size %d", n, TradeCacheSize)
+	}
+	before := req.Before
+	if before == 0 || before > math.MaxInt64 {
+		before = math.MaxInt64
+	}
+
+	mkt, err := dex.MarketName(req.BaseID, req.QuoteID)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing market for %d - %d", req.BaseID, req.QuoteID)
+	}
+
+	s.tradesMtx.RLock()
+	tc := s.tradeCaches[mkt]
+	if tc == nil {
+		s.tradesMtx.RUnlock()
+		return nil, fmt.Errorf("market %s not known", mkt)
+	}
+	trades, full := tc.recent(before, n), tc.full()
+	s.tradesMtx.RUnlock()
+
+	// If the cache has dropped older trades, there may be more in the DB.
+	if len(trades) == n || !full {
+		return trades, nil
+	}
+	storedTrades, err := s.db.MarketTrades(req.BaseID, req.QuoteID, before, n)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving trades for market %s", mkt)
+	}
+	trades = make([]*msgjson.MarketTrade, 0, len(storedTrades))
+	for _, t := range storedTrades {
+		trades = append(trades, marketTrade(t))
+	}
+	return trades, nil
+}
+
+// marketTrade converts a stored trade to a *msgjson.MarketTrade.
+func marketTrade(t *db.Trade) *msgjson.MarketTrade {
+	return &msgjson.MarketTrade{
+		Stamp:     t.EndStamp(),
+		Rate:      t.Rate,
+		Qty:       t.Quantity,
+		TakerSell: t.TakerSell,
+	}
+}
+
 func init() {
 	for _, s := range candles.BinSizes {
 		dur, err := time.ParseDuration(s)
@@ -11,7 +11,9 @@ import (
 
 	"decred.org/dcrdex/dex/candles"
 	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/dex/order"
 	"decred.org/dcrdex/server/comms"
+	"decred.org/dcrdex/server/db"
 	"decred.org/dcrdex/server/matcher"
 )
 
@@ -27,6 +29,9 @@ func (m *TMarketSource) Quote() uint32         { return m.quote }
 
 type TDBSource struct {
 	loadEpochErr error
+	trades       []*db.Trade // newest first
+	tradesErr    error
+	tradesCalls  int
 }
 
 func (db *TDBSource) LoadEpochStats(base, quote uint32, caches []*candles.Cache) error {
@@ -41,6 +46,20 @@ func (db *TDBSource) InsertCandles(base, quote uint32, dur uint64, cs []*candles
 	return nil
 }
 
+func (src *TDBSource) MarketTrades(base, quote uint32, before uint64, n int) ([]*db.Trade, error) {
+	src.tradesCalls++
+	if src.tradesErr != nil {
+		return nil, src.tradesErr
+	}
+	var trades []*db.Trade
+	for _, t := range src.trades {
+		if t.EndStamp() < before && len(trades) < n {
+			trades = append(trades, t)
+		}
+	}
+	return trades, nil
+}
+
 type TBookSource struct {
 	book *msgjson.OrderBook
 }
@@ -195,3 +214,89 @@ func TestOrderBook(t *testing.T) {
 		t.Fatalf("where did this book come from?")
 	}
 }
+
+func TestTrades(t *testing.T) {
+	rig := newTestRig()
+	mktSrc := &TMarketSource{42, 0}
+	dur := mktSrc.EpochDuration()
+	rig.db.trades = []*db.Trade{
+		{Epoch: order.EpochID{Idx: 1, Dur: dur}, TakerSell: true, Quantity: 2, Rate: 20},
+		{Epoch: order.EpochID{Idx: 0, Dur: dur}, Quantity: 1, Rate: 10},
+	}
+	err := rig.api.AddMarketSource(mktSrc)
+	if err != nil {
+		t.Fatalf("AddMarketSource error: %v", err)
+	}
+
+	if err = rig.api.ReportTrades(42, 2, nil); err == nil {
+		t.Fatalf("no error for unknown market")
+	}
+	reported := []*msgjson.MarketTrade{
+		{Stamp: 3 * dur, Rate: 30, Qty: 3},
+		{Stamp: 3 * dur, Rate: 31, Qty: 4, TakerSell: true},
+	}
+	if err = rig.api.ReportTrades(42, 0, reported); err != nil {
+		t.Fatalf("ReportTrades error: %v", err)
+	}
+
+	getTrades := func(req *msgjson.TradesRequest) []*msgjson.MarketTrade {
+		t.Helper()
+		tradesI, err := rig.api.handleTrades(req)
+		if err != nil {
+			t.Fatalf("handleTrades error: %v", err)
+		}
+		return tradesI.([]*msgjson.MarketTrade)
+	}
+
+	// Newest first, including the trades primed from the DB.
+	rig.db.tradesCalls = 0
+	trades := getTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 0})
+	if len(trades) != 4 {
+		t.Fatalf("expected 4 trades, got %d", len(trades))
+	}
+	if trades[0] != reported[1] || trades[1] != reported[0] {
+		t.Fatalf("reported trades not newest first")
+	}
+	if trades[2].Stamp != 2*dur || trades[2].Rate != 20 || trades[2].Qty != 2 || !trades[2].TakerSell ||
+		trades[3].Stamp != dur || trades[3].Rate != 10 {
+		t.Fatalf("wrong primed trades %+v, %+v", trades[2], trades[3])
+	}
+	// Paging.
+	trades = getTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 0, Before: 3 * dur, NumTrades: 1})
+	if len(trades) != 1 || trades[0].Stamp != 2*dur {
+		t.Fatalf("wrong trades before epoch 2 ended: %+v", trades)
+	}
+	// The cache has every trade, so the DB is not queried.
+	if rig.db.tradesCalls != 0 {
+		t.Fatalf("DB queried for cached trades")
+	}
+
+	// Bad requests.
+	if _, err = rig.api.handleTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 0, NumTrades: TradeCacheSize + 1}); err == nil {
+		t.Fatalf("no error for too many trades")
+	}
+	if _, err = rig.api.handleTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 2}); err == nil {
+		t.Fatalf("no error for unknown market")
+	}
+
+	// Once the cache is full, older trades come from the DB.
+	full := make([]*msgjson.MarketTrade, TradeCacheSize)
+	for i := range full {
+		full[i] = &msgjson.MarketTrade{Stamp: 10 * dur, Rate: 100, Qty: uint64(i)}
+	}
+	if err = rig.api.ReportTrades(42, 0, full); err != nil {
+		t.Fatalf("ReportTrades error: %v", err)
+	}
+	trades = getTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 0, NumTrades: 2})
+	if len(trades) != 2 || trades[0] != full[len(full)-1] || rig.db.tradesCalls != 0 {
+		t.Fatalf("wrong trades from a full cache")
+	}
+	trades = getTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 0, Before: 10 * dur})
+	if len(trades) != 2 || rig.db.tradesCalls != 1 {
+		t.Fatalf("expected 2 trades from the DB, got %d", len(trades))
+	}
+	rig.db.tradesErr = dummyErr
+	if _, err = rig.api.handleTrades(&msgjson.TradesRequest{BaseID: 42, QuoteID: 0, Before: 10 * dur}); err == nil {
+		t.Fatalf("no error for DB error")
+	}
+}
@@ -195,6 +195,7 @@ type Backend struct {
 
 // Check that Backend satisfies the Backend interface.
 var _ asset.Backend = (*Backend)(nil)
+var _ asset.TipHeighter = (*Backend)(nil)
 var _ srvdex.Bonder = (*Backend)(nil)
 
 // NewBackend is the exported constructor by which the DEX will import the
@@ -531,6 +532,12 @@ func (btc *Backend) ValidateSecret(secret, contract []byte) bool {
 	return bytes.Equal(h[:], secretHash)
 }
 
+// TipHeight is the height of the best known block. Part of the
+// asset.TipHeighter interface.
+func (btc *Backend) TipHeight() uint64 {
+	return uint64(btc.blockCache.tipHeight())
+}
+
 // Synced is true if the blockchain is ready for action.
 func (btc *Backend) Synced() (bool, error) {
 	chainInfo, err := btc.node.GetBlockChainInfo()
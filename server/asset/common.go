@@ -104,6 +104,13 @@ type AccountBalancer interface {
 	InitTxSize() uint64
 }
 
+// TipHeighter is implemented by Backends that can report the height of the
+// best block they know of.
+type TipHeighter interface {
+	// TipHeight is the height of the best known block.
+	TipHeight() uint64
+}
+
 // TokenBacker is implemented by Backends that support degenerate tokens.
 type TokenBacker interface {
 	TokenBackend(assetID uint32, configPath string) (Backend, error)
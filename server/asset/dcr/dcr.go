@@ -277,6 +277,7 @@ type Backend struct {
 
 // Check that Backend satisfies the Backend interface.
 var _ asset.Backend = (*Backend)(nil)
+var _ asset.TipHeighter = (*Backend)(nil)
 
 // unconnectedDCR returns a Backend without a node. The node should be set
 // before use.
@@ -496,6 +497,12 @@ func (dcr *Backend) ValidateSecret(secret, contract []byte) bool {
 	return bytes.Equal(h[:], secretHash)
 }
 
+// TipHeight is the height of the best known block. Part of the
+// asset.TipHeighter interface.
+func (dcr *Backend) TipHeight() uint64 {
+	return uint64(dcr.blockCache.tipHeight())
+}
+
 // Synced is true if the blockchain is ready for action.
 func (dcr *Backend) Synced() (bool, error) {
 	// With ws autoreconnect enabled, requests hang when backend is
@@ -16,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"decred.org/dcrdex/dex"
@@ -249,9 +250,8 @@ type baseBackend struct {
 
 	// bestHeight is the last best known chain tip height. bestHeight is set
 	// in Connect before the poll loop is started, and only updated in the poll
-	// loop thereafter. Do not use bestHeight outside of the poll loop unless
-	// you change it to an atomic.
-	bestHeight uint64
+	// loop thereafter.
+	bestHeight atomic.Uint64
 
 	// A logger will be provided by the DEX. All logging should use the provided
 	// logger.
@@ -301,6 +301,10 @@ var _ asset.Backend = (*ETHBackend)(nil)
 var _ asset.AccountBalancer = (*TokenBackend)(nil)
 var _ asset.AccountBalancer = (*ETHBackend)(nil)
 
+// Check that Backend satisfies the TipHeighter interface.
+var _ asset.TipHeighter = (*TokenBackend)(nil)
+var _ asset.TipHeighter = (*ETHBackend)(nil)
+
 // unconnectedETH returns a Backend without a node. The node should be set
 // before use.
 func unconnectedETH(bipID, contractVer uint32, contractAddr, contractAddrV1 common.Address, vTokens map[uint32]*VersionedToken, logger dex.Logger, net dex.Network) (*ETHBackend, error) {
@@ -451,7 +455,7 @@ func (eth *ETHBackend) Connect(ctx context.Context) (*sync.WaitGroup, error) {
 		cancelNodeContext()
 		return nil, fmt.Errorf("error getting best block header: %w", err)
 	}
-	eth.baseBackend.bestHeight = bn
+	eth.baseBackend.bestHeight.Store(bn)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -837,15 +841,22 @@ func (eth *ETHBackend) poll(ctx context.Context) {
 		send(fmt.Errorf("error getting best block header: %w", err))
 		return
 	}
-	if bn == eth.bestHeight {
+	bestHeight := eth.bestHeight.Load()
+	if bn == bestHeight {
 		// Same height, nothing to do.
 		return
 	}
-	eth.log.Debugf("Tip change from %d to %d.", eth.bestHeight, bn)
-	eth.bestHeight = bn
+	eth.log.Debugf("Tip change from %d to %d.", bestHeight, bn)
+	eth.bestHeight.Store(bn)
 	send(nil)
 }
 
+// TipHeight is the height of the best known block. Part of the
+// asset.TipHeighter interface.
+func (eth *baseBackend) TipHeight() uint64 {
+	return eth.bestHeight.Load()
+}
+
 // run processes the queue and monitors the application context.
 func (eth *ETHBackend) run(ctx context.Context) {
 	// Non-loopback providers are metered at 10 seconds internally to rpcclient,
@@ -341,7 +341,7 @@ func TestRun(t *testing.T) {
 		t.Fatalf("unconnectedETH error: %v", err)
 	}
 	backend.node = &testNode{
-		blkNum: backend.bestHeight + 1,
+		blkNum: backend.bestHeight.Load() + 1,
 	}
 	ch := backend.BlockChannel(1)
 	go func() {
@@ -903,9 +903,9 @@ func TestPoll(t *testing.T) {
 		eth := &ETHBackend{be}
 		node.blkNumErr = test.blockNumErr
 		if test.addBlock {
-			node.blkNum = be.bestHeight + 1
+			node.blkNum = be.bestHeight.Load() + 1
 		} else {
-			node.blkNum = be.bestHeight
+			node.blkNum = be.bestHeight.Load()
 		}
 		ch := make(chan *asset.BlockUpdate, 1)
 		eth.blockChans[ch] = struct{}{}
@@ -258,6 +258,7 @@ type AuthManager struct {
 	preimgOutcomes map[account.AccountID]*latestPreimageOutcomes
 	orderOutcomes  map[account.AccountID]*latestOrders // cancel/complete, was in clientInfo.recentOrders
 
+	txDataMtx     sync.RWMutex
 	txDataSources map[uint32]TxDataSource
 
 	prepaidBondMtx sync.Mutex
@@ -944,6 +945,7 @@ func (auth *AuthManager) Inaction(user account.AccountID, misstep NoActionStep,
 		log.Errorf("Invalid inaction step %d", misstep)
 		return
 	}
+	countViolation(violation)
 	score := auth.registerMatchOutcome(user, misstep, mmid, matchValue, refTime)
 
 	// Recompute tier.
@@ -1001,6 +1003,7 @@ func (auth *AuthManager) PreimageSuccess(user account.AccountID, epochEnd time.T
 
 // MissedPreimage registers a missed preimage violation by the user.
 func (auth *AuthManager) MissedPreimage(user account.AccountID, epochEnd time.Time, oid order.OrderID) {
+	countViolation(ViolationPreimageMiss)
 	score := auth.registerPreimageOutcome(user, true, oid, epochEnd)
 	if score < auth.penaltyThreshold {
 		return
@@ -1559,12 +1562,15 @@ func (auth *AuthManager) handleConnect(conn comms.Link, msg *msgjson.Message) *m
 	log.Debugf("User %v score = %d:%d (%d successes) - %d (violations) - %d (%d preimage misses) ",
 		user, score, successScore, successCount, -violationScore, -piMissScore, piMissCount)
 
-	// Make outcome entries for the user.
-	auth.violationMtx.Lock()
-	auth.matchOutcomes[user] = latestMatches
-	auth.preimgOutcomes[user] = latestPreimageResults
-	auth.orderOutcomes[user] = latestFinished
-	auth.violationMtx.Unlock()
+	// Make outcome entries for the user. A read-only connect leaves the
+	// entries of any connected client alone.
+	if !connect.ReadOnly {
+		auth.violationMtx.Lock()
+		auth.matchOutcomes[user] = latestMatches
+		auth.preimgOutcomes[user] = latestPreimageResults
+		auth.orderOutcomes[user] = latestFinished
+		auth.violationMtx.Unlock()
+	}
 
 	client := &clientInfo{
 		acct:         acctInfo,
@@ -1645,7 +1651,9 @@ func (auth *AuthManager) handleConnect(conn comms.Link, msg *msgjson.Message) *m
 		msgMatchForSide(match, order.Taker)
 	}
 
-	conn.Authorized()
+	if !connect.ReadOnly {
+		conn.Authorized()
+	}
 
 	// Prepare bond info for response.
 	var bondTier int64
@@ -1703,6 +1711,14 @@ func (auth *AuthManager) handleConnect(conn comms.Link, msg *msgjson.Message) *m
 		return nil
 	}
 
+	// A read-only connection is not a client. It gets no requests or
+	// notifications, and does not replace an existing client.
+	if connect.ReadOnly {
+		log.Debugf("Read-only connect for account %v from %v with %d active orders, %d active matches",
+			user, conn.Addr(), len(msgOrderStatuses), len(msgMatches))
+		return nil
+	}
+
 	log.Infof("Authenticated account %v from %v with %d active orders, %d active matches, tier = %v, "+
 		"bond tier = %v, score = %v",
 		user, conn.Addr(), len(msgOrderStatuses), len(msgMatches), client.tier, bondTier, score)
@@ -1801,9 +1817,22 @@ func (mm *marketMatches) idList() []order.MatchID {
 	return ids
 }
 
+// AddTxDataSource sets the source of tx data for an asset, such as an asset
+// added with a new market while the DEX is running.
+func (auth *AuthManager) AddTxDataSource(assetID uint32, src TxDataSource) {
+	auth.txDataMtx.Lock()
+	if auth.txDataSources == nil {
+		auth.txDataSources = make(map[uint32]TxDataSource)
+	}
+	auth.txDataSources[assetID] = src
+	auth.txDataMtx.Unlock()
+}
+
 // getTxData gets the tx data for the coin ID.
 func (auth *AuthManager) getTxData(assetID uint32, coinID []byte) ([]byte, error) {
+	auth.txDataMtx.RLock()
 	txDataSrc, found := auth.txDataSources[assetID]
+	auth.txDataMtx.RUnlock()
 	if !found {
 		return nil, fmt.Errorf("no tx data source for asset ID %d", assetID)
 	}
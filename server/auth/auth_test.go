@@ -1527,3 +1527,47 @@ func Test_checkSigS256(t *testing.T) {
 	sig = []byte{0x30, 1, 0x02, 0x01, 9, 0x2, 0x01, 10}
 	ecdsa.ParseDERSignature(sig) // panic on line 139: rLen := int(sigStr[index]) with index=3 and len = 3
 }
+
+func TestReadOnlyConnect(t *testing.T) {
+	user := tNewUser(t)
+	rig.signer.sig = user.randomSignature()
+
+	matchData, userMatch := userMatchData(user.acctID)
+	rig.storage.orderStatuses = []*db.OrderStatus{{ID: userMatch.OrderID, Status: order.OrderStatusBooked}}
+	defer func() { rig.storage.orderStatuses = nil }()
+	rig.storage.matches = []*db.MatchData{matchData}
+	defer func() { rig.storage.matches = nil }()
+
+	connectUser(t, user)
+	defer rig.mgr.removeClient(rig.mgr.user(user.acctID))
+	tradingConn := user.conn
+
+	// A read-only connect from another connection gets the account status, but
+	// does not replace the trading connection.
+	roConn := tNewRPCClient()
+	connect := tNewConnect(user)
+	connect.ReadOnly = true
+	connect.SetSig(signMsg(user.privKey, connect.Serialize()))
+	msg, _ := msgjson.NewRequest(comms.NextID(), msgjson.ConnectRoute, connect)
+	if rpcErr := rig.mgr.handleConnect(roConn, msg); rpcErr != nil {
+		t.Fatalf("read-only connect error: %v", rpcErr)
+	}
+	result := extractConnectResult(t, roConn.getSend())
+	if len(result.ActiveOrderStatuses) != 1 || len(result.ActiveMatches) != 1 {
+		t.Fatalf("expected 1 order and 1 match, got %d and %d",
+			len(result.ActiveOrderStatuses), len(result.ActiveMatches))
+	}
+	if client := rig.mgr.user(user.acctID); client == nil || client.conn != tradingConn {
+		t.Fatalf("trading connection replaced by read-only connect")
+	}
+	if rig.mgr.conn(roConn) != nil {
+		t.Fatalf("read-only connection registered as a client")
+	}
+
+	// The read-only flag is signed.
+	connect.ReadOnly = false
+	msg, _ = msgjson.NewRequest(comms.NextID(), msgjson.ConnectRoute, connect)
+	if rpcErr := rig.mgr.handleConnect(tNewRPCClient(), msg); rpcErr == nil || rpcErr.Code != msgjson.SignatureError {
+		t.Fatalf("expected signature error for modified read-only flag, got %v", rpcErr)
+	}
+}
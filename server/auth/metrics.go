@@ -0,0 +1,19 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package auth
+
+import (
+	"strings"
+
+	"decred.org/dcrdex/server/metrics"
+)
+
+var violationCount = metrics.NewCounterVec("dcrdex_auth_violations",
+	"Number of user violations, such as failing to act in a swap or missing a preimage request.",
+	"violation")
+
+// countViolation records a user violation.
+func countViolation(v Violation) {
+	violationCount.With(strings.ReplaceAll(v.String(), " ", "_")).Inc()
+}
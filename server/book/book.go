@@ -56,9 +56,20 @@ func (b *Book) Clear() (removedBuys, removedSells []*order.LimitOrder) {
 
 // LotSize returns the Book's configured lot size in atoms of the base asset.
 func (b *Book) LotSize() uint64 {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
 	return b.lotSize
 }
 
+// SetLotSize changes the Book's lot size. Orders already on the book are not
+// checked, so the caller should remove any that do not conform to the new lot
+// size.
+func (b *Book) SetLotSize(lotSize uint64) {
+	b.mtx.Lock()
+	b.lotSize = lotSize
+	b.mtx.Unlock()
+}
+
 // BuyCount returns the number of buy orders.
 func (b *Book) BuyCount() int {
 	return b.buys.Count()
@@ -95,12 +106,12 @@ func (b *Book) Best() (bestBuy, bestSell *order.LimitOrder) {
 // boolean indicating if the insertion was successful. If the order is not an
 // integer multiple of the Book's lot size, the order will not be inserted.
 func (b *Book) Insert(o *order.LimitOrder) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
 	if o.Quantity%b.lotSize != 0 {
 		log.Warnf("(*Book).Insert: Refusing to insert an order with a quantity that is not a multiple of lot size.")
 		return false
 	}
-	b.mtx.Lock()
-	defer b.mtx.Unlock()
 	if o.Sell {
 		if b.sells.Insert(o) {
 			b.acctTracker.add(o)
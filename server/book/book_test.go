@@ -268,6 +268,28 @@ func TestBook(t *testing.T) {
 	}
 }
 
+func TestSetLotSize(t *testing.T) {
+	b := New(LotSize, AccountTrackingBase)
+	if !b.Insert(newLimitOrder(false, 2500000, 1, order.StandingTiF, 0)) {
+		t.Fatalf("Failed to insert a one lot order")
+	}
+
+	b.SetLotSize(2 * LotSize)
+	if b.LotSize() != 2*LotSize {
+		t.Errorf("wrong lot size. wanted %d, got %d", 2*LotSize, b.LotSize())
+	}
+	// Existing orders are left for the caller to remove.
+	if b.BuyCount() != 1 {
+		t.Errorf("SetLotSize removed orders")
+	}
+	if b.Insert(newLimitOrder(false, 2500000, 3, order.StandingTiF, 0)) {
+		t.Errorf("Inserted an order that is not a multiple of the new lot size")
+	}
+	if !b.Insert(newLimitOrder(false, 2500000, 4, order.StandingTiF, 0)) {
+		t.Errorf("Failed to insert an order with the new lot size")
+	}
+}
+
 func TestAccountTracking(t *testing.T) {
 	firstSell := bookSellOrders[len(bookSellOrders)-1]
 	allOrders := append(bookBuyOrders, bookSellOrders...)
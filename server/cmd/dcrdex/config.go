@@ -43,6 +43,8 @@ const (
 	defaultPGHost              = "127.0.0.1:5432"
 	defaultPGUser              = "dcrdex"
 	defaultPGDBName            = "dcrdex_{netname}"
+	defaultDBDriver            = "pg"
+	defaultDBFilename          = "dcrdex.db"
 	defaultDEXPrivKeyFilename  = "sigkey"
 	defaultRPCHost             = "127.0.0.1"
 	defaultRPCPort             = "7232"
@@ -70,6 +72,8 @@ type procOpts struct {
 type dexConf struct {
 	DataDir          string
 	Network          dex.Network
+	DBDriver         string
+	DBPath           string
 	DBName           string
 	DBUser           string
 	DBPass           string
@@ -136,6 +140,8 @@ type flagsData struct {
 	HTTPProfile bool   `long:"httpprof" short:"p" description:"Start HTTP profiler."`
 	CPUProfile  string `long:"cpuprofile" description:"File for CPU profiling."`
 
+	DBDriver           string `long:"dbdriver" description:"Storage driver, pg (PostgreSQL) or bolt (embedded database file)."`
+	DBPath             string `long:"dbpath" description:"Database file for the bolt storage driver. Default is dcrdex.db in the network's data directory."`
 	PGDBName           string `long:"pgdbname" description:"PostgreSQL DB name."`
 	PGUser             string `long:"pguser" description:"PostgreSQL DB user."`
 	PGPass             string `long:"pgpass" description:"PostgreSQL DB password."`
@@ -255,6 +261,7 @@ func loadConfig() (*dexConf, *procOpts, error) {
 		RPCCert:          defaultRPCCertFilename,
 		RPCKey:           defaultRPCKeyFilename,
 		DebugLevel:       defaultLogLevel,
+		DBDriver:         defaultDBDriver,
 		PGDBName:         defaultPGDBName,
 		PGUser:           defaultPGUser,
 		PGHost:           defaultPGHost,
@@ -497,6 +504,18 @@ func loadConfig() (*dexConf, *procOpts, error) {
 		log.Infof("Logging with UTC time stamps. Current local time is %v", time.Now().Local().Format("15:04:05 MST"))
 	}
 
+	switch cfg.DBDriver {
+	case "pg":
+	case "bolt":
+		if cfg.DBPath == "" {
+			cfg.DBPath = filepath.Join(cfg.DataDir, defaultDBFilename)
+		}
+		cfg.DBPath = dex.CleanAndExpandPath(cfg.DBPath)
+		log.Infof("Database file:   %s", cfg.DBPath)
+	default:
+		return loadConfigError(fmt.Errorf("unknown DB driver %q", cfg.DBDriver))
+	}
+
 	var dbPort uint16
 	dbHost := cfg.PGHost
 	// For UNIX sockets, do not attempt to parse out a port.
@@ -532,6 +551,8 @@ func loadConfig() (*dexConf, *procOpts, error) {
 	dexCfg := &dexConf{
 		DataDir:          cfg.DataDir,
 		Network:          network,
+		DBDriver:         cfg.DBDriver,
+		DBPath:           cfg.DBPath,
 		DBName:           cfg.PGDBName,
 		DBHost:           dbHost,
 		DBPort:           dbPort,
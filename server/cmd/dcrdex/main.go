@@ -94,12 +94,6 @@ func mainCore(ctx context.Context) error {
 	}
 	log.Infof("Found %d assets, loaded %d markets, for network %s",
 		len(assets), len(markets), strings.ToUpper(cfg.Network.String()))
-	// NOTE: If MaxUserCancelsPerEpoch is ultimately a setting we want to keep,
-	// bake it into the markets.json file and load it per-market in settings.go.
-	// For now, patch it into each dex.MarketInfo.
-	for _, mkt := range markets {
-		mkt.MaxUserCancelsPerEpoch = cfg.MaxUserCancels
-	}
 
 	// Load, or create and save, the DEX signing key.
 	var privKey *secp256k1.PrivateKey
@@ -123,6 +117,8 @@ func mainCore(ctx context.Context) error {
 		Assets:     assets,
 		Network:    cfg.Network,
 		DBConf: &dexsrv.DBConf{
+			Driver:       cfg.DBDriver,
+			Path:         cfg.DBPath,
 			DBName:       cfg.DBName,
 			Host:         cfg.DBHost,
 			User:         cfg.DBUser,
@@ -145,8 +141,10 @@ func mainCore(ctx context.Context) error {
 			DisableDataAPI:    cfg.DisableDataAPI,
 			HiddenServiceAddr: cfg.HiddenService,
 		},
-		NoResumeSwaps: cfg.NoResumeSwaps,
-		NodeRelayAddr: cfg.NodeRelayAddr,
+		NoResumeSwaps:   cfg.NoResumeSwaps,
+		NodeRelayAddr:   cfg.NodeRelayAddr,
+		MaxUserCancels:  cfg.MaxUserCancels,
+		MarketsConfPath: cfg.MarketsConfPath,
 	}
 	dexMan, err := dexsrv.NewDEX(ctx, dexConf) // ctx cancel just aborts setup; Stop does normal shutdown
 	if err != nil {
@@ -273,6 +273,7 @@ ordersLoop:
 // DEXCoinLocker manages multiple MasterCoinLocker, one for each asset used by
 // the DEX.
 type DEXCoinLocker struct {
+	mtx         sync.RWMutex
 	masterLocks map[uint32]*MasterCoinLocker
 }
 
@@ -283,17 +284,30 @@ func NewDEXCoinLocker(assets []uint32) *DEXCoinLocker {
 		masterLocks[asset] = NewMasterCoinLocker()
 	}
 
-	return &DEXCoinLocker{masterLocks}
+	return &DEXCoinLocker{masterLocks: masterLocks}
+}
+
+// AddAsset creates a MasterCoinLocker for an asset that was not known when the
+// DEXCoinLocker was created. AddAsset is a no-op if the asset already has a
+// MasterCoinLocker.
+func (c *DEXCoinLocker) AddAsset(asset uint32) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.masterLocks[asset] == nil {
+		c.masterLocks[asset] = NewMasterCoinLocker()
+	}
 }
 
 // AssetLocker retrieves the MasterCoinLocker for an asset.
 func (c *DEXCoinLocker) AssetLocker(asset uint32) *MasterCoinLocker {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
 	return c.masterLocks[asset]
 }
 
 // CoinLocked checks if a coin belonging to an asset is locked.
 func (c *DEXCoinLocker) CoinLocked(asset uint32, coin string) bool {
-	locker := c.masterLocks[asset]
+	locker := c.AssetLocker(asset)
 	if locker == nil {
 		panic(fmt.Sprintf("unknown asset %d", asset))
 	}
@@ -303,7 +317,7 @@ func (c *DEXCoinLocker) CoinLocked(asset uint32, coin string) bool {
 
 // OrderCoinsLocked retrieves all locked coins for a given asset and user.
 func (c *DEXCoinLocker) OrderCoinsLocked(asset uint32, oid order.OrderID) []CoinID {
-	locker := c.masterLocks[asset]
+	locker := c.AssetLocker(asset)
 	if locker == nil {
 		panic(fmt.Sprintf("unknown asset %d", asset))
 	}
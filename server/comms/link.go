@@ -173,6 +173,8 @@ func (s *Server) handleMessage(c *wsLink, msg *msgjson.Message) *msgjson.Error {
 		switch msg.Route {
 		case msgjson.CandlesRoute:
 			thing = new(msgjson.CandlesRequest)
+		case msgjson.TradesRoute:
+			thing = new(msgjson.TradesRequest)
 		case msgjson.OrderBookRoute:
 			thing = new(msgjson.OrderBookSubscription)
 		}
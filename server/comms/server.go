@@ -21,6 +21,7 @@ import (
 	"decred.org/dcrdex/dex"
 	"decred.org/dcrdex/dex/msgjson"
 	"decred.org/dcrdex/dex/ws"
+	"decred.org/dcrdex/server/metrics"
 	"github.com/decred/dcrd/certgen"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -83,6 +84,10 @@ var (
 	// separate limiters used with the websocket routes, rpcRoutes.
 	ipHTTPRateLimiter = make(map[dex.IPKey]*ipRateLimiter)
 	rateLimiterMtx    sync.RWMutex
+
+	// connectedClients is the number of active websocket connections.
+	connectedClients = metrics.NewGauge("dcrdex_connected_clients",
+		"Number of active websocket connections.")
 )
 
 var idCounter uint64
@@ -222,14 +227,16 @@ func newRouteLimiter() *routeLimiter {
 			msgjson.LimitRoute:  orderLimiter,
 			msgjson.MarketRoute: orderLimiter,
 			msgjson.CancelRoute: orderLimiter,
+			msgjson.AmendRoute:  orderLimiter,
 			// Order book and price feed subscriptions
 			msgjson.OrderBookRoute: marketSubsLimiter,
 			msgjson.PriceFeedRoute: marketSubsLimiter,
-			// Config, fee rate, spot prices, and candles
+			// Config, fee rate, spot prices, candles, and trades
 			msgjson.FeeRateRoute: infoLimiter,
 			msgjson.ConfigRoute:  infoLimiter,
 			msgjson.SpotsRoute:   infoLimiter,
 			msgjson.CandlesRoute: infoLimiter,
+			msgjson.TradesRoute:  infoLimiter,
 		},
 	}
 }
@@ -724,6 +731,7 @@ func (s *Server) addClient(ctx context.Context, client *wsLink) (*dex.Connection
 	client.id = s.counter
 	s.counter++
 	s.clients[client.id] = client
+	connectedClients.Set(float64(len(s.clients)))
 	return cm, nil
 }
 
@@ -731,6 +739,7 @@ func (s *Server) addClient(ctx context.Context, client *wsLink) (*dex.Connection
 func (s *Server) removeClient(id uint64) {
 	s.clientMtx.Lock()
 	delete(s.clients, id)
+	connectedClients.Set(float64(len(s.clients)))
 	s.clientMtx.Unlock()
 }
 
@@ -0,0 +1,1273 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+// Package dbtest is a conformance test suite for server/db.DEXArchivist
+// implementations. The storage drivers run the suite from their own tests so
+// that they all behave the same way through the interface.
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/candles"
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/account"
+	"decred.org/dcrdex/server/db"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+const (
+	LotSize         = uint64(100_0000_0000) // 100
+	RateStep        = uint64(10_0000)       // 0.001
+	EpochDuration   = uint64(10_000)
+	MarketBuyBuffer = 1.1
+)
+
+// NewArchivistFunc creates a DEXArchivist with no stored data, supporting the
+// provided markets. The archivist should be closed with t.Cleanup.
+type NewArchivistFunc func(t *testing.T, mkts []*dex.MarketInfo) db.DEXArchivist
+
+// Markets are the markets used by the suite.
+func Markets(t *testing.T) []*dex.MarketInfo {
+	dcrBtc, err := dex.NewMarketInfoFromSymbols("dcr", "btc", LotSize, RateStep, EpochDuration, 0, MarketBuyBuffer)
+	if err != nil {
+		t.Fatalf("invalid market: %v", err)
+	}
+	btcLtc, err := dex.NewMarketInfoFromSymbols("btc", "ltc", LotSize, RateStep, EpochDuration, 0, MarketBuyBuffer)
+	if err != nil {
+		t.Fatalf("invalid market: %v", err)
+	}
+	return []*dex.MarketInfo{dcrBtc, btcLtc}
+}
+
+// Run runs the conformance tests. Each test gets a new archivist.
+func Run(t *testing.T, newArchivist NewArchivistFunc) {
+	tests := []struct {
+		name string
+		f    func(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo)
+	}{
+		{"Orders", testOrders},
+		{"CancelOrders", testCancelOrders},
+		{"RevokeAndFlush", testRevokeAndFlush},
+		{"UserOrders", testUserOrders},
+		{"Accounts", testAccounts},
+		{"Matches", testMatches},
+		{"MatchFails", testMatchFails},
+		{"MarketTrades", testMarketTrades},
+		{"EpochsAndCandles", testEpochsAndCandles},
+		{"AddMarket", testAddMarket},
+		{"UpdateLotSize", testUpdateLotSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mkts := Markets(t)
+			tt.f(t, newArchivist(t, mkts), mkts)
+		})
+	}
+}
+
+func randomBytes(len int) []byte {
+	bytes := make([]byte, len)
+	rand.Read(bytes)
+	return bytes
+}
+
+func randomAccount(t *testing.T) *account.Account {
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	acct, err := account.NewAccountFromPubKey(priv.PubKey().SerializeCompressed())
+	if err != nil {
+		t.Fatalf("error creating account from pubkey: %v", err)
+	}
+	return acct
+}
+
+func randomAccountID() account.AccountID {
+	return account.NewID(randomBytes(account.PubKeySize))
+}
+
+func randomCommitment() (com order.Commitment) {
+	rand.Read(com[:])
+	return
+}
+
+func randomOrderID() (oid order.OrderID) {
+	rand.Read(oid[:])
+	return
+}
+
+func randomMatchID() (mid order.MatchID) {
+	rand.Read(mid[:])
+	return
+}
+
+func newPrefix(user account.AccountID, mkt *dex.MarketInfo, orderType order.OrderType, timeOffset int64) order.Prefix {
+	return order.Prefix{
+		AccountID:  user,
+		BaseAsset:  mkt.Base,
+		QuoteAsset: mkt.Quote,
+		OrderType:  orderType,
+		ClientTime: time.Unix(1566497653+timeOffset, 0).UTC(),
+		ServerTime: time.Unix(1566497656+timeOffset, 0).UTC(),
+		Commit:     randomCommitment(),
+	}
+}
+
+func newLimitOrder(user account.AccountID, mkt *dex.MarketInfo, sell bool, quantityLots uint64, timeOffset int64) *order.LimitOrder {
+	addr := "DcqXswjTPnUcd4FRCkX4vRJxmVtfgGVa5ui"
+	if sell {
+		addr = "149RQGLaHf2gGiL4NXZdH7aA8nYEuLLrgm"
+	}
+	return &order.LimitOrder{
+		P: newPrefix(user, mkt, order.LimitOrderType, timeOffset),
+		T: order.Trade{
+			Coins:    []order.CoinID{randomBytes(36), randomBytes(36)},
+			Sell:     sell,
+			Quantity: quantityLots * mkt.LotSize,
+			Address:  addr,
+		},
+		Rate:  4_0000_0000,
+		Force: order.StandingTiF,
+	}
+}
+
+func newMarketSellOrder(user account.AccountID, mkt *dex.MarketInfo, quantityLots uint64, timeOffset int64) *order.MarketOrder {
+	return &order.MarketOrder{
+		P: newPrefix(user, mkt, order.MarketOrderType, timeOffset),
+		T: order.Trade{
+			Coins:    []order.CoinID{randomBytes(36)},
+			Sell:     true,
+			Quantity: quantityLots * mkt.LotSize,
+			Address:  "149RQGLaHf2gGiL4NXZdH7aA8nYEuLLrgm",
+		},
+	}
+}
+
+func newCancelOrder(user account.AccountID, mkt *dex.MarketInfo, target order.OrderID, timeOffset int64) *order.CancelOrder {
+	return &order.CancelOrder{
+		P:             newPrefix(user, mkt, order.CancelOrderType, timeOffset),
+		TargetOrderID: target,
+	}
+}
+
+func newMatch(maker *order.LimitOrder, taker order.Order, quantity uint64, epochID order.EpochID) *order.Match {
+	return &order.Match{
+		Maker:        maker,
+		Taker:        taker,
+		Quantity:     quantity,
+		Rate:         maker.Rate,
+		FeeRateBase:  12,
+		FeeRateQuote: 14,
+		Status:       order.NewlyMatched,
+		Sigs:         order.Signatures{},
+		Epoch:        epochID,
+	}
+}
+
+func isArchiveErr(err error, code uint16) bool {
+	var errA db.ArchiveError
+	return errors.As(err, &errA) && errA.Code == code
+}
+
+func checkStatus(t *testing.T, archie db.DEXArchivist, ord order.Order, wantStatus order.OrderStatus, wantFilled int64) {
+	t.Helper()
+	status, ordType, filled, err := archie.OrderStatus(ord)
+	if err != nil {
+		t.Fatalf("OrderStatus(%v) failed: %v", ord.ID(), err)
+	}
+	if status != wantStatus {
+		t.Errorf("order %v has status %v, expected %v", ord.ID(), status, wantStatus)
+	}
+	if ordType != ord.Type() {
+		t.Errorf("order %v has type %v, expected %v", ord.ID(), ordType, ord.Type())
+	}
+	if filled != wantFilled {
+		t.Errorf("order %v has filled amount %d, expected %d", ord.ID(), filled, wantFilled)
+	}
+}
+
+func orderIDs(ords []order.Order) map[order.OrderID]bool {
+	oids := make(map[order.OrderID]bool, len(ords))
+	for _, ord := range ords {
+		oids[ord.ID()] = true
+	}
+	return oids
+}
+
+func testOrders(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt := mkts[0]
+	user := randomAccountID()
+	epochDur := int64(mkt.EpochDuration)
+
+	lo := newLimitOrder(user, mkt, true, 4, 0)
+	if err := archie.NewEpochOrder(lo, 10, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	if err := archie.NewEpochOrder(lo, 10, epochDur, db.EpochGapNA); err == nil {
+		t.Errorf("no error storing the same order twice")
+	}
+
+	ord, status, err := archie.Order(lo.ID(), mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("Order failed: %v", err)
+	}
+	if ord.ID() != lo.ID() {
+		t.Errorf("loaded order %v, expected %v", ord.ID(), lo.ID())
+	}
+	if status != order.OrderStatusEpoch {
+		t.Errorf("loaded order status %v, expected epoch", status)
+	}
+	if _, _, err = archie.Order(randomOrderID(), mkt.Base, mkt.Quote); !db.IsErrOrderUnknown(err) {
+		t.Errorf("expected ErrUnknownOrder for an unknown order, got %v", err)
+	}
+	if status, _, _, err := archie.OrderStatus(newLimitOrder(user, mkt, true, 1, 0)); !db.IsErrOrderUnknown(err) ||
+		status != order.OrderStatusUnknown {
+		t.Errorf("expected ErrUnknownOrder and unknown status for an unknown order, got %v, %v", status, err)
+	}
+
+	epochOrds, err := archie.EpochOrders(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("EpochOrders failed: %v", err)
+	}
+	if len(epochOrds) != 1 || epochOrds[0].ID() != lo.ID() {
+		t.Errorf("expected the one epoch order, got %d", len(epochOrds))
+	}
+
+	// Book it, and fill some of it.
+	if err = archie.BookOrder(lo); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+	lo.FillAmt = mkt.LotSize
+	if err = archie.UpdateOrderFilled(lo); err != nil {
+		t.Fatalf("UpdateOrderFilled failed: %v", err)
+	}
+	checkStatus(t, archie, lo, order.OrderStatusBooked, int64(mkt.LotSize))
+
+	bookOrds, err := archie.BookOrders(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("BookOrders failed: %v", err)
+	}
+	if len(bookOrds) != 1 || bookOrds[0].ID() != lo.ID() {
+		t.Fatalf("expected the one book order, got %d", len(bookOrds))
+	}
+	if bookOrds[0].FillAmt != mkt.LotSize {
+		t.Errorf("book order filled amount %d, expected %d", bookOrds[0].FillAmt, mkt.LotSize)
+	}
+	if epochOrds, _ = archie.EpochOrders(mkt.Base, mkt.Quote); len(epochOrds) != 0 {
+		t.Errorf("expected no epoch orders, got %d", len(epochOrds))
+	}
+
+	// Locked coins of active orders.
+	buy := newLimitOrder(user, mkt, false, 1, 1)
+	if err = archie.NewEpochOrder(buy, 11, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	baseCoins, quoteCoins, err := archie.ActiveOrderCoins(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("ActiveOrderCoins failed: %v", err)
+	}
+	if len(baseCoins) != 1 || len(baseCoins[lo.ID()]) != len(lo.Coins) {
+		t.Errorf("wrong base coins: %v", baseCoins)
+	}
+	if len(quoteCoins) != 1 || len(quoteCoins[buy.ID()]) != len(buy.Coins) {
+		t.Errorf("wrong quote coins: %v", quoteCoins)
+	}
+
+	// Commitments must be unique across all markets.
+	found, oid, err := archie.OrderWithCommit(context.Background(), lo.Commit)
+	if err != nil {
+		t.Fatalf("OrderWithCommit failed: %v", err)
+	}
+	if !found || oid != lo.ID() {
+		t.Errorf("OrderWithCommit found = %v, oid = %v, expected %v", found, oid, lo.ID())
+	}
+	if found, _, _ = archie.OrderWithCommit(context.Background(), randomCommitment()); found {
+		t.Errorf("found an order for an unknown commitment")
+	}
+	reuse := newLimitOrder(user, mkts[1], true, 1, 2)
+	reuse.Commit = lo.Commit
+	if err = archie.NewEpochOrder(reuse, 12, epochDur, db.EpochGapNA); !db.IsErrReusedCommit(err) {
+		t.Errorf("expected ErrReusedCommit, got %v", err)
+	}
+
+	// Execute it. It may not be made active again.
+	lo.FillAmt = lo.Quantity
+	if err = archie.ExecuteOrder(lo); err != nil {
+		t.Fatalf("ExecuteOrder failed: %v", err)
+	}
+	checkStatus(t, archie, lo, order.OrderStatusExecuted, int64(lo.Quantity))
+	if err = archie.BookOrder(lo); err == nil {
+		t.Errorf("no error booking an executed order")
+	}
+	if bookOrds, _ = archie.BookOrders(mkt.Base, mkt.Quote); len(bookOrds) != 0 {
+		t.Errorf("expected no book orders, got %d", len(bookOrds))
+	}
+
+	// Canceled limit order.
+	if err = archie.BookOrder(buy); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+	if err = archie.CancelOrder(buy); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	checkStatus(t, archie, buy, order.OrderStatusCanceled, 0)
+
+	// Market order.
+	mo := newMarketSellOrder(user, mkt, 2, 3)
+	if err = archie.NewEpochOrder(mo, 13, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	mo.FillAmt = mkt.LotSize
+	if err = archie.ExecuteOrder(mo); err != nil {
+		t.Fatalf("ExecuteOrder failed: %v", err)
+	}
+	checkStatus(t, archie, mo, order.OrderStatusExecuted, int64(mkt.LotSize))
+
+	// Invalid orders.
+	bad := newLimitOrder(user, mkt, true, 1, 4)
+	bad.Quantity++ // not a multiple of the lot size
+	if err = archie.NewEpochOrder(bad, 14, epochDur, db.EpochGapNA); !db.IsErrInvalidOrder(err) {
+		t.Errorf("expected ErrInvalidOrder, got %v", err)
+	}
+	dcrLtc := &dex.MarketInfo{Base: mkt.Base, Quote: mkts[1].Quote, LotSize: LotSize}
+	if err = archie.NewEpochOrder(newLimitOrder(user, dcrLtc, true, 1, 5), 14, epochDur, db.EpochGapNA); !isArchiveErr(err, db.ErrUnsupportedMarket) {
+		t.Errorf("expected ErrUnsupportedMarket, got %v", err)
+	}
+}
+
+func testCancelOrders(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt := mkts[0]
+	user := randomAccountID()
+	epochDur := int64(mkt.EpochDuration)
+
+	lo := newLimitOrder(user, mkt, true, 1, 0)
+	if err := archie.NewEpochOrder(lo, 10, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	if err := archie.BookOrder(lo); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+
+	// A failed cancel order is executed to the market.
+	failed := newCancelOrder(user, mkt, lo.ID(), 1)
+	if err := archie.NewEpochOrder(failed, 11, epochDur, 1); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	checkStatus(t, archie, failed, order.OrderStatusEpoch, -1)
+	if err := archie.FailCancelOrder(failed); err != nil {
+		t.Fatalf("FailCancelOrder failed: %v", err)
+	}
+	checkStatus(t, archie, failed, order.OrderStatusExecuted, -1)
+
+	// An executed cancel order with a stored epoch is counted.
+	co := newCancelOrder(user, mkt, lo.ID(), 2)
+	if err := archie.NewEpochOrder(co, 12, epochDur, 2); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	if err := archie.ExecuteOrder(co); err != nil {
+		t.Fatalf("ExecuteOrder failed: %v", err)
+	}
+	if err := archie.CancelOrder(lo); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	matchTime := int64(13 * mkt.EpochDuration)
+	err := archie.InsertEpoch(&db.EpochResults{
+		MktBase:   mkt.Base,
+		MktQuote:  mkt.Quote,
+		Idx:       12,
+		Dur:       epochDur,
+		MatchTime: matchTime,
+		CSum:      randomBytes(32),
+		Seed:      randomBytes(32),
+	})
+	if err != nil {
+		t.Fatalf("InsertEpoch failed: %v", err)
+	}
+
+	// The cancel order of an amend is not counted.
+	lo2 := newLimitOrder(user, mkt, false, 1, 3)
+	if err = archie.NewEpochOrder(lo2, 13, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	if err = archie.BookOrder(lo2); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+	amendCo := newCancelOrder(user, mkt, lo2.ID(), 4)
+	amendLo := newLimitOrder(user, mkt, false, 2, 4)
+	if err = archie.NewEpochAmend(amendCo, amendLo, 14, epochDur, 1); err != nil {
+		t.Fatalf("NewEpochAmend failed: %v", err)
+	}
+	checkStatus(t, archie, amendCo, order.OrderStatusEpoch, -1)
+	checkStatus(t, archie, amendLo, order.OrderStatusEpoch, 0)
+	if err = archie.ExecuteOrder(amendCo); err != nil {
+		t.Fatalf("ExecuteOrder failed: %v", err)
+	}
+	err = archie.InsertEpoch(&db.EpochResults{
+		MktBase:   mkt.Base,
+		MktQuote:  mkt.Quote,
+		Idx:       14,
+		Dur:       epochDur,
+		MatchTime: matchTime + epochDur*2,
+		CSum:      randomBytes(32),
+		Seed:      randomBytes(32),
+	})
+	if err != nil {
+		t.Fatalf("InsertEpoch failed: %v", err)
+	}
+
+	// An amend with a reused commitment is stored atomically, so neither order
+	// is stored.
+	badCo := newCancelOrder(user, mkt, amendLo.ID(), 5)
+	badLo := newLimitOrder(user, mkt, false, 1, 5)
+	badLo.Commit = lo.Commit
+	if err = archie.NewEpochAmend(badCo, badLo, 15, epochDur, 0); !db.IsErrReusedCommit(err) {
+		t.Errorf("expected ErrReusedCommit, got %v", err)
+	}
+	if _, _, _, err = archie.OrderStatus(badCo); !db.IsErrOrderUnknown(err) {
+		t.Errorf("cancel order of a failed amend was stored")
+	}
+
+	// An archived cancel order is stored as executed.
+	archived := newCancelOrder(user, mkt, amendLo.ID(), 6)
+	if err = archie.NewArchivedCancel(archived, 16, epochDur); err != nil {
+		t.Fatalf("NewArchivedCancel failed: %v", err)
+	}
+	checkStatus(t, archie, archived, order.OrderStatusExecuted, -1)
+
+	cancels, err := archie.ExecutedCancelsForUser(user, 10)
+	if err != nil {
+		t.Fatalf("ExecutedCancelsForUser failed: %v", err)
+	}
+	if len(cancels) != 1 {
+		t.Fatalf("expected 1 executed cancel, got %d", len(cancels))
+	}
+	if cancels[0].ID != co.ID() || cancels[0].TargetID != lo.ID() ||
+		cancels[0].MatchTime != matchTime || cancels[0].EpochGap != 2 {
+		t.Errorf("wrong cancel record %+v", cancels[0])
+	}
+}
+
+func testRevokeAndFlush(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt := mkts[0]
+	user := randomAccountID()
+	epochDur := int64(mkt.EpochDuration)
+
+	bookOrder := func(sell bool, timeOffset int64) *order.LimitOrder {
+		t.Helper()
+		lo := newLimitOrder(user, mkt, sell, 1, timeOffset)
+		if err := archie.NewEpochOrder(lo, 10, epochDur, db.EpochGapNA); err != nil {
+			t.Fatalf("NewEpochOrder failed: %v", err)
+		}
+		if err := archie.BookOrder(lo); err != nil {
+			t.Fatalf("BookOrder failed: %v", err)
+		}
+		return lo
+	}
+
+	counted, exempt := bookOrder(true, 0), bookOrder(true, 1)
+	cancelID, timeStamp, err := archie.RevokeOrder(counted)
+	if err != nil {
+		t.Fatalf("RevokeOrder failed: %v", err)
+	}
+	checkStatus(t, archie, counted, order.OrderStatusRevoked, 0)
+	co, status, err := archie.Order(cancelID, mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("Order failed for the revoke's cancel order: %v", err)
+	}
+	if status != order.OrderStatusRevoked || co.Type() != order.CancelOrderType {
+		t.Errorf("revoke's cancel order has status %v and type %v", status, co.Type())
+	}
+	if co.(*order.CancelOrder).TargetOrderID != counted.ID() {
+		t.Errorf("revoke's cancel order has the wrong target")
+	}
+	if _, _, err = archie.RevokeOrderUncounted(exempt); err != nil {
+		t.Fatalf("RevokeOrderUncounted failed: %v", err)
+	}
+	checkStatus(t, archie, exempt, order.OrderStatusRevoked, 0)
+
+	sells := []*order.LimitOrder{bookOrder(true, 2), bookOrder(true, 3)}
+	buy := bookOrder(false, 4)
+	sellsRemoved, buysRemoved, err := archie.FlushBook(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("FlushBook failed: %v", err)
+	}
+	if len(sellsRemoved) != len(sells) || len(buysRemoved) != 1 || buysRemoved[0] != buy.ID() {
+		t.Errorf("flushed %d sells and %d buys, expected %d and 1", len(sellsRemoved), len(buysRemoved), len(sells))
+	}
+	for _, lo := range append(sells, buy) {
+		checkStatus(t, archie, lo, order.OrderStatusRevoked, 0)
+	}
+	bookOrds, err := archie.BookOrders(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("BookOrders failed: %v", err)
+	}
+	if len(bookOrds) != 0 {
+		t.Errorf("expected an empty book, got %d orders", len(bookOrds))
+	}
+
+	// Only the counted revoke is an executed cancel.
+	cancels, err := archie.ExecutedCancelsForUser(user, 10)
+	if err != nil {
+		t.Fatalf("ExecutedCancelsForUser failed: %v", err)
+	}
+	if len(cancels) != 1 {
+		t.Fatalf("expected 1 executed cancel, got %d", len(cancels))
+	}
+	if cancels[0].ID != cancelID || cancels[0].TargetID != counted.ID() ||
+		cancels[0].MatchTime != timeStamp.UnixMilli() || cancels[0].EpochGap != db.EpochGapNA {
+		t.Errorf("wrong cancel record %+v", cancels[0])
+	}
+}
+
+func testAddMarket(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	adder, is := archie.(db.MarketAdder)
+	if !is {
+		t.Skip("archivist is not a MarketAdder")
+	}
+	dcrLtc, err := dex.NewMarketInfoFromSymbols("dcr", "ltc", LotSize, RateStep, EpochDuration, 0, MarketBuyBuffer)
+	if err != nil {
+		t.Fatalf("invalid market: %v", err)
+	}
+	user := randomAccountID()
+	epochDur := int64(dcrLtc.EpochDuration)
+	lo := newLimitOrder(user, dcrLtc, true, 1, 0)
+	if err = archie.NewEpochOrder(lo, 10, epochDur, db.EpochGapNA); !isArchiveErr(err, db.ErrUnsupportedMarket) {
+		t.Fatalf("expected ErrUnsupportedMarket before AddMarket, got %v", err)
+	}
+
+	if err = adder.AddMarket(dcrLtc); err != nil {
+		t.Fatalf("AddMarket failed: %v", err)
+	}
+	if err = adder.AddMarket(dcrLtc); err == nil {
+		t.Errorf("no error adding the market twice")
+	}
+	if err = adder.AddMarket(mkts[0]); err == nil {
+		t.Errorf("no error adding a configured market")
+	}
+
+	if err = archie.NewEpochOrder(lo, 10, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed for the added market: %v", err)
+	}
+	if err = archie.BookOrder(lo); err != nil {
+		t.Fatalf("BookOrder failed for the added market: %v", err)
+	}
+	bookOrds, err := archie.BookOrders(dcrLtc.Base, dcrLtc.Quote)
+	if err != nil {
+		t.Fatalf("BookOrders failed: %v", err)
+	}
+	if len(bookOrds) != 1 || bookOrds[0].ID() != lo.ID() {
+		t.Errorf("expected the booked order in the added market's book, got %d orders", len(bookOrds))
+	}
+}
+
+func testUpdateLotSize(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	updater, is := archie.(db.LotSizeUpdater)
+	if !is {
+		t.Skip("archivist is not a LotSizeUpdater")
+	}
+	mkt := mkts[0]
+	user := randomAccountID()
+	epochDur := int64(mkt.EpochDuration)
+
+	booked := newLimitOrder(user, mkt, true, 1, 0)
+	if err := archie.NewEpochOrder(booked, 10, epochDur, db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	if err := archie.BookOrder(booked); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+
+	newMkt := *mkt
+	newMkt.LotSize = mkt.LotSize * 2
+	if err := updater.UpdateLotSize(mkt.Base, mkt.Quote, newMkt.LotSize); err != nil {
+		t.Fatalf("UpdateLotSize failed: %v", err)
+	}
+	if mkts[0].LotSize != LotSize {
+		t.Errorf("UpdateLotSize modified the configured MarketInfo")
+	}
+	if err := updater.UpdateLotSize(42, 2, LotSize); err == nil {
+		t.Errorf("no error updating the lot size of an unknown market")
+	}
+
+	// The book is not flushed.
+	bookOrds, err := archie.BookOrders(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("BookOrders failed: %v", err)
+	}
+	if len(bookOrds) != 1 || bookOrds[0].ID() != booked.ID() {
+		t.Errorf("expected the booked order to remain on the book, got %d orders", len(bookOrds))
+	}
+
+	// New orders are validated against the new lot size.
+	oldLot := newLimitOrder(user, mkt, false, 1, 1)
+	if err = archie.NewEpochOrder(oldLot, 11, epochDur, db.EpochGapNA); !isArchiveErr(err, db.ErrInvalidOrder) {
+		t.Errorf("expected ErrInvalidOrder for an order with the old lot size, got %v", err)
+	}
+	newLot := newLimitOrder(user, &newMkt, false, 1, 2)
+	if err = archie.NewEpochOrder(newLot, 11, epochDur, db.EpochGapNA); err != nil {
+		t.Errorf("NewEpochOrder failed for an order with the new lot size: %v", err)
+	}
+}
+
+func testUserOrders(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt, mkt2 := mkts[0], mkts[1]
+	user := randomAccountID()
+	epochDur := int64(mkt.EpochDuration)
+	ctx := context.Background()
+
+	newEpochOrder := func(ord order.Order, epochIdx int64) {
+		t.Helper()
+		if err := archie.NewEpochOrder(ord, epochIdx, epochDur, db.EpochGapNA); err != nil {
+			t.Fatalf("NewEpochOrder failed: %v", err)
+		}
+	}
+
+	booked := newLimitOrder(user, mkt, true, 1, 0)
+	newEpochOrder(booked, 10)
+	if err := archie.BookOrder(booked); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+	epoch := newLimitOrder(user, mkt2, false, 1, 1)
+	newEpochOrder(epoch, 11)
+	executed := newLimitOrder(user, mkt, false, 1, 2)
+	newEpochOrder(executed, 12)
+	if err := archie.StorePreimage(executed, order.Preimage{1}); err != nil {
+		t.Fatalf("StorePreimage failed: %v", err)
+	}
+	executed.FillAmt = executed.Quantity
+	if err := archie.ExecuteOrder(executed); err != nil {
+		t.Fatalf("ExecuteOrder failed: %v", err)
+	}
+	missed := newLimitOrder(user, mkt, false, 1, 3)
+	newEpochOrder(missed, 13)
+	if _, _, err := archie.RevokeOrder(missed); err != nil { // no preimage
+		t.Fatalf("RevokeOrder failed: %v", err)
+	}
+	newEpochOrder(newCancelOrder(user, mkt, booked.ID(), 4), 14)
+	newEpochOrder(newLimitOrder(randomAccountID(), mkt, true, 1, 5), 14) // someone else's
+
+	ords, statuses, err := archie.UserOrders(ctx, user, mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("UserOrders failed: %v", err)
+	}
+	wantStatuses := map[order.OrderID]order.OrderStatus{
+		booked.ID():   order.OrderStatusBooked,
+		executed.ID(): order.OrderStatusExecuted,
+		missed.ID():   order.OrderStatusRevoked,
+	}
+	if len(ords) != len(wantStatuses) || len(statuses) != len(ords) {
+		t.Fatalf("expected %d user orders, got %d", len(wantStatuses), len(ords))
+	}
+	for i, ord := range ords {
+		if wantStatuses[ord.ID()] != statuses[i] {
+			t.Errorf("user order %v has status %v, expected %v", ord.ID(), statuses[i], wantStatuses[ord.ID()])
+		}
+	}
+
+	orderStatuses, err := archie.UserOrderStatuses(user, mkt.Base, mkt.Quote, nil)
+	if err != nil {
+		t.Fatalf("UserOrderStatuses failed: %v", err)
+	}
+	if len(orderStatuses) != len(wantStatuses) {
+		t.Errorf("expected %d order statuses, got %d", len(wantStatuses), len(orderStatuses))
+	}
+	orderStatuses, err = archie.UserOrderStatuses(user, mkt.Base, mkt.Quote, []order.OrderID{executed.ID(), epoch.ID(), randomOrderID()})
+	if err != nil {
+		t.Fatalf("UserOrderStatuses failed: %v", err)
+	}
+	if len(orderStatuses) != 1 || orderStatuses[0].ID != executed.ID() || orderStatuses[0].Status != order.OrderStatusExecuted {
+		t.Errorf("wrong order statuses for the requested order IDs")
+	}
+
+	active, err := archie.ActiveUserOrderStatuses(user)
+	if err != nil {
+		t.Fatalf("ActiveUserOrderStatuses failed: %v", err)
+	}
+	activeOrds := orderIDs([]order.Order{booked, epoch})
+	if len(active) != len(activeOrds) {
+		t.Fatalf("expected %d active orders, got %d", len(activeOrds), len(active))
+	}
+	for _, s := range active {
+		if !activeOrds[s.ID] {
+			t.Errorf("unexpected active order %v", s.ID)
+		}
+	}
+
+	// Only executed orders may have a completion time.
+	if err = archie.SetOrderCompleteTime(booked, 1234); !db.IsErrOrderNotExecuted(err) {
+		t.Errorf("expected ErrOrderNotExecuted, got %v", err)
+	}
+	if err = archie.SetOrderCompleteTime(executed, 1234); err != nil {
+		t.Fatalf("SetOrderCompleteTime failed: %v", err)
+	}
+	oids, compTimes, err := archie.CompletedUserOrders(user, 10)
+	if err != nil {
+		t.Fatalf("CompletedUserOrders failed: %v", err)
+	}
+	if len(oids) != 1 || oids[0] != executed.ID() || compTimes[0] != 1234 {
+		t.Errorf("wrong completed orders %v, %v", oids, compTimes)
+	}
+
+	outcomes, err := archie.PreimageStats(user, 10)
+	if err != nil {
+		t.Fatalf("PreimageStats failed: %v", err)
+	}
+	if len(outcomes) != 2 {
+		t.Fatalf("expected 2 preimage results, got %d", len(outcomes))
+	}
+	// Newest first.
+	if outcomes[0].ID != missed.ID() || !outcomes[0].Miss || outcomes[0].Time != 14*epochDur {
+		t.Errorf("wrong preimage result for the missed order %+v", outcomes[0])
+	}
+	if outcomes[1].ID != executed.ID() || outcomes[1].Miss || outcomes[1].Time != 13*epochDur {
+		t.Errorf("wrong preimage result for the executed order %+v", outcomes[1])
+	}
+}
+
+func testAccounts(t *testing.T, archie db.DEXArchivist, _ []*dex.MarketInfo) {
+	acct := randomAccount(t)
+	if a, _ := archie.Account(acct.ID, time.Unix(0, 0)); a != nil {
+		t.Fatalf("found an unknown account")
+	}
+	if _, err := archie.AccountInfo(acct.ID); !db.IsErrAccountUnknown(err) {
+		t.Fatalf("expected ErrAccountUnknown, got %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	bond := &db.Bond{
+		Version:  0,
+		AssetID:  42,
+		CoinID:   randomBytes(36),
+		Amount:   1e8,
+		Strength: 1,
+		LockTime: now.Add(time.Hour).Unix(),
+	}
+	if err := archie.CreateAccountWithBond(acct, bond); err != nil {
+		t.Fatalf("CreateAccountWithBond failed: %v", err)
+	}
+	if err := archie.CreateAccountWithBond(acct, &db.Bond{AssetID: 42, CoinID: randomBytes(36)}); err == nil {
+		t.Errorf("no error creating an existing account")
+	}
+	info, err := archie.AccountInfo(acct.ID)
+	if err != nil {
+		t.Fatalf("AccountInfo failed: %v", err)
+	}
+	if info.AccountID != acct.ID || !bytes.Equal(info.Pubkey, acct.PubKey.SerializeCompressed()) {
+		t.Errorf("wrong account info")
+	}
+
+	bond2 := &db.Bond{
+		Version:  0,
+		AssetID:  0,
+		CoinID:   randomBytes(36),
+		Amount:   2e8,
+		Strength: 2,
+		LockTime: now.Add(2 * time.Hour).Unix(),
+	}
+	if err = archie.AddBond(acct.ID, bond2); err != nil {
+		t.Fatalf("AddBond failed: %v", err)
+	}
+	if err = archie.AddBond(acct.ID, bond2); err == nil {
+		t.Errorf("no error adding a bond twice")
+	}
+
+	checkBonds := func(lockTimeThresh time.Time, wantBonds ...*db.Bond) {
+		t.Helper()
+		a, bonds := archie.Account(acct.ID, lockTimeThresh)
+		if a == nil || a.ID != acct.ID {
+			t.Fatalf("account not loaded")
+		}
+		if len(bonds) != len(wantBonds) {
+			t.Fatalf("expected %d bonds, got %d", len(wantBonds), len(bonds))
+		}
+		for i, b := range bonds {
+			w := wantBonds[i]
+			if b.Version != w.Version || b.AssetID != w.AssetID || !bytes.Equal(b.CoinID, w.CoinID) ||
+				b.Amount != w.Amount || b.Strength != w.Strength || b.LockTime != w.LockTime {
+				t.Errorf("wrong bond %d: %+v != %+v", i, b, w)
+			}
+		}
+	}
+	checkBonds(now, bond, bond2)
+	checkBonds(now.Add(90*time.Minute), bond2)
+
+	if err = archie.DeleteBond(bond2.AssetID, bond2.CoinID); err != nil {
+		t.Fatalf("DeleteBond failed: %v", err)
+	}
+	checkBonds(time.Unix(0, 0), bond)
+
+	// Prepaid bonds.
+	coinIDs := [][]byte{randomBytes(32), randomBytes(32)}
+	lockTime := now.Add(time.Hour).Unix()
+	if err = archie.StorePrepaidBonds(coinIDs, 3, lockTime); err != nil {
+		t.Fatalf("StorePrepaidBonds failed: %v", err)
+	}
+	strength, lt, err := archie.FetchPrepaidBond(coinIDs[1])
+	if err != nil {
+		t.Fatalf("FetchPrepaidBond failed: %v", err)
+	}
+	if strength != 3 || lt != lockTime {
+		t.Errorf("wrong prepaid bond strength %d and lock time %d", strength, lt)
+	}
+	if err = archie.DeletePrepaidBond(coinIDs[1]); err != nil {
+		t.Fatalf("DeletePrepaidBond failed: %v", err)
+	}
+	if _, _, err = archie.FetchPrepaidBond(coinIDs[1]); err == nil {
+		t.Errorf("no error fetching a deleted prepaid bond")
+	}
+	if _, _, err = archie.FetchPrepaidBond(coinIDs[0]); err != nil {
+		t.Errorf("FetchPrepaidBond failed: %v", err)
+	}
+
+	// Key indexes.
+	xpub := "tpubVqK2qrv4mACchWcV6HiGe4Ld6rxy1PSzYU1dzgeBpZE1xRwrnRevj3UvXMsg3aAvJzRaxqjw1kSSGbnnTSumU4eX7tX5GqdqFZxp7bJSS3z"
+	idx, err := archie.KeyIndex(xpub)
+	if err != nil {
+		t.Fatalf("KeyIndex failed: %v", err)
+	}
+	if idx != 0 {
+		t.Errorf("new key index is %d, expected 0", idx)
+	}
+	if err = archie.SetKeyIndex(5, xpub); err != nil {
+		t.Fatalf("SetKeyIndex failed: %v", err)
+	}
+	if idx, _ = archie.KeyIndex(xpub); idx != 5 {
+		t.Errorf("key index is %d, expected 5", idx)
+	}
+}
+
+func testMatches(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt := mkts[0]
+	maker := newLimitOrder(randomAccountID(), mkt, true, 2, 0)
+	taker := newLimitOrder(randomAccountID(), mkt, false, 1, 1)
+	epochID := order.EpochID{Idx: 10, Dur: mkt.EpochDuration}
+	match := newMatch(maker, taker, mkt.LotSize, epochID)
+	if err := archie.InsertMatch(match); err != nil {
+		t.Fatalf("InsertMatch failed: %v", err)
+	}
+	mid := db.MatchID(match)
+
+	md, err := archie.MatchByID(match.ID(), mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("MatchByID failed: %v", err)
+	}
+	if md.ID != match.ID() || md.Maker != maker.ID() || md.MakerAcct != maker.User() ||
+		md.MakerAddr != maker.Address || md.Taker != taker.ID() || md.TakerAcct != taker.User() ||
+		md.TakerAddr != taker.Address || md.TakerSell || md.Epoch != epochID ||
+		md.Quantity != match.Quantity || md.Rate != match.Rate || md.BaseRate != 12 ||
+		md.QuoteRate != 14 || md.Status != order.NewlyMatched || !md.Active {
+		t.Errorf("wrong match data %+v", md)
+	}
+	if _, err = archie.MatchByID(randomMatchID(), mkt.Base, mkt.Quote); !db.IsErrMatchUnknown(err) {
+		t.Errorf("expected ErrUnknownMatch, got %v", err)
+	}
+
+	// Updating a match sets the quantity and status.
+	match.Quantity = 2 * mkt.LotSize
+	if err = archie.InsertMatch(match); err != nil {
+		t.Fatalf("InsertMatch failed to update: %v", err)
+	}
+	if md, _ = archie.MatchByID(match.ID(), mkt.Base, mkt.Quote); md.Quantity != match.Quantity {
+		t.Errorf("match quantity not updated")
+	}
+
+	for _, aid := range []account.AccountID{maker.User(), taker.User()} {
+		userMatches, err := archie.UserMatches(aid, mkt.Base, mkt.Quote)
+		if err != nil {
+			t.Fatalf("UserMatches failed: %v", err)
+		}
+		if len(userMatches) != 1 || userMatches[0].ID != match.ID() {
+			t.Errorf("expected the one user match")
+		}
+		activeMatches, err := archie.AllActiveUserMatches(aid)
+		if err != nil {
+			t.Fatalf("AllActiveUserMatches failed: %v", err)
+		}
+		if len(activeMatches) != 1 || activeMatches[0].ID != match.ID() {
+			t.Errorf("expected the one active user match")
+		}
+	}
+
+	// The swap.
+	sigA, sigB := randomBytes(72), randomBytes(72)
+	contractA, coinA, contractB, coinB := randomBytes(90), randomBytes(36), randomBytes(90), randomBytes(36)
+	redeemA, secret, redeemB := randomBytes(36), randomBytes(32), randomBytes(36)
+	auditSigA, auditSigB, redeemSigB := randomBytes(72), randomBytes(72), randomBytes(72)
+	for i, f := range []func() error{
+		func() error { return archie.SaveMatchAckSigA(mid, sigA) },
+		func() error { return archie.SaveMatchAckSigB(mid, sigB) },
+		func() error { return archie.SaveContractA(mid, contractA, coinA, 1000) },
+		func() error { return archie.SaveAuditAckSigB(mid, auditSigB) },
+		func() error { return archie.SaveContractB(mid, contractB, coinB, 2000) },
+		func() error { return archie.SaveAuditAckSigA(mid, auditSigA) },
+		func() error { return archie.SaveRedeemA(mid, redeemA, secret, 3000) },
+		func() error { return archie.SaveRedeemAckSigB(mid, redeemSigB) },
+	} {
+		if err := f(); err != nil {
+			t.Fatalf("swap step %d failed: %v", i, err)
+		}
+	}
+	unknownMid := db.MarketMatchID{MatchID: randomMatchID(), Base: mkt.Base, Quote: mkt.Quote}
+	if err = archie.SaveMatchAckSigA(unknownMid, sigA); err == nil {
+		t.Errorf("no error updating an unknown match")
+	}
+
+	status, sd, err := archie.SwapData(mid)
+	if err != nil {
+		t.Fatalf("SwapData failed: %v", err)
+	}
+	if status != order.MakerRedeemed {
+		t.Errorf("match status %v, expected MakerRedeemed", status)
+	}
+	if !bytes.Equal(sd.SigMatchAckMaker, sigA) || !bytes.Equal(sd.SigMatchAckTaker, sigB) ||
+		!bytes.Equal(sd.ContractA, contractA) || !bytes.Equal(sd.ContractACoinID, coinA) || sd.ContractATime != 1000 ||
+		!bytes.Equal(sd.ContractAAckSig, auditSigB) ||
+		!bytes.Equal(sd.ContractB, contractB) || !bytes.Equal(sd.ContractBCoinID, coinB) || sd.ContractBTime != 2000 ||
+		!bytes.Equal(sd.ContractBAckSig, auditSigA) ||
+		!bytes.Equal(sd.RedeemACoinID, redeemA) || !bytes.Equal(sd.RedeemASecret, secret) || sd.RedeemATime != 3000 ||
+		!bytes.Equal(sd.RedeemAAckSig, redeemSigB) || len(sd.RedeemBCoinID) != 0 {
+		t.Errorf("wrong swap data %+v", sd)
+	}
+
+	swaps, err := archie.ActiveSwaps()
+	if err != nil {
+		t.Fatalf("ActiveSwaps failed: %v", err)
+	}
+	if len(swaps) != 1 || swaps[0].ID != match.ID() || swaps[0].Base != mkt.Base ||
+		swaps[0].Quote != mkt.Quote || !bytes.Equal(swaps[0].RedeemASecret, secret) {
+		t.Errorf("wrong active swaps")
+	}
+
+	matchStatuses, err := archie.MatchStatuses(maker.User(), mkt.Base, mkt.Quote, []order.MatchID{match.ID(), randomMatchID()})
+	if err != nil {
+		t.Fatalf("MatchStatuses failed: %v", err)
+	}
+	if len(matchStatuses) != 1 {
+		t.Fatalf("expected 1 match status, got %d", len(matchStatuses))
+	}
+	if ms := matchStatuses[0]; ms.ID != match.ID() || ms.Status != order.MakerRedeemed || !ms.IsMaker || ms.IsTaker ||
+		ms.TakerSell || !ms.Active || !bytes.Equal(ms.MakerContract, contractA) || !bytes.Equal(ms.TakerContract, contractB) ||
+		!bytes.Equal(ms.MakerSwap, coinA) || !bytes.Equal(ms.TakerSwap, coinB) ||
+		!bytes.Equal(ms.MakerRedeem, redeemA) || !bytes.Equal(ms.Secret, secret) {
+		t.Errorf("wrong match status %+v", ms)
+	}
+	if matchStatuses, _ = archie.MatchStatuses(randomAccountID(), mkt.Base, mkt.Quote, []order.MatchID{match.ID()}); len(matchStatuses) != 0 {
+		t.Errorf("got a match status for a user that is not a party")
+	}
+
+	// MakerRedeemed is success for the maker, but not yet for the taker.
+	outcomes, err := archie.CompletedAndAtFaultMatchStats(maker.User(), 10)
+	if err != nil {
+		t.Fatalf("CompletedAndAtFaultMatchStats failed: %v", err)
+	}
+	if len(outcomes) != 1 || outcomes[0].Fail || outcomes[0].Time != int64(11*mkt.EpochDuration) ||
+		outcomes[0].Value != match.Quantity || outcomes[0].Base != mkt.Base || outcomes[0].Quote != mkt.Quote {
+		t.Errorf("wrong maker outcomes %+v", outcomes)
+	}
+	if outcomes, _ = archie.CompletedAndAtFaultMatchStats(taker.User(), 10); len(outcomes) != 0 {
+		t.Errorf("expected no taker outcomes, got %d", len(outcomes))
+	}
+
+	if err = archie.SaveRedeemB(mid, redeemB, 4000); err != nil {
+		t.Fatalf("SaveRedeemB failed: %v", err)
+	}
+	if md, _ = archie.MatchByID(match.ID(), mkt.Base, mkt.Quote); md.Status != order.MatchComplete || md.Active {
+		t.Errorf("match not complete and inactive after SaveRedeemB")
+	}
+	if outcomes, _ = archie.CompletedAndAtFaultMatchStats(taker.User(), 10); len(outcomes) != 1 || outcomes[0].Fail {
+		t.Errorf("expected a successful taker outcome")
+	}
+	if swaps, _ = archie.ActiveSwaps(); len(swaps) != 0 {
+		t.Errorf("expected no active swaps, got %d", len(swaps))
+	}
+
+	// A cancel order match is complete and inactive, and not a market match.
+	co := newCancelOrder(maker.User(), mkt, maker.ID(), 2)
+	cancelMatch := newMatch(maker, co, mkt.LotSize, order.EpochID{Idx: 11, Dur: mkt.EpochDuration})
+	if err = archie.InsertMatch(cancelMatch); err != nil {
+		t.Fatalf("InsertMatch failed for cancel match: %v", err)
+	}
+	if md, err = archie.MatchByID(cancelMatch.ID(), mkt.Base, mkt.Quote); err != nil {
+		t.Fatalf("MatchByID failed: %v", err)
+	} else if md.Status != order.MatchComplete || md.Active || md.TakerAddr != "" || md.MakerAddr != "" {
+		t.Errorf("wrong cancel match data %+v", md)
+	}
+	if userMatches, _ := archie.UserMatches(maker.User(), mkt.Base, mkt.Quote); len(userMatches) != 2 {
+		t.Errorf("expected 2 user matches including the cancel match, got %d", len(userMatches))
+	}
+
+	// An active match in an earlier epoch.
+	match2 := newMatch(maker, newLimitOrder(randomAccountID(), mkt, false, 1, 3), mkt.LotSize, order.EpochID{Idx: 9, Dur: mkt.EpochDuration})
+	if err = archie.InsertMatch(match2); err != nil {
+		t.Fatalf("InsertMatch failed: %v", err)
+	}
+	marketMatches, err := archie.MarketMatches(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("MarketMatches failed: %v", err)
+	}
+	if len(marketMatches) != 1 || marketMatches[0].ID != match2.ID() {
+		t.Errorf("expected only the active market match")
+	}
+	var streamed []*db.MatchDataWithCoins
+	N, err := archie.MarketMatchesStreaming(mkt.Base, mkt.Quote, true, -1, func(m *db.MatchDataWithCoins) error {
+		streamed = append(streamed, m)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("MarketMatchesStreaming failed: %v", err)
+	}
+	if N != 2 || len(streamed) != 2 {
+		t.Fatalf("streamed %d matches, expected 2", N)
+	}
+	// Newest first.
+	if m := streamed[0]; m.ID != match.ID() || !bytes.Equal(m.MakerSwapCoin, coinA) || !bytes.Equal(m.TakerSwapCoin, coinB) ||
+		!bytes.Equal(m.MakerRedeemCoin, redeemA) || !bytes.Equal(m.TakerRedeemCoin, redeemB) || m.Active {
+		t.Errorf("wrong streamed match %+v", m)
+	}
+	if streamed[1].ID != match2.ID() || !streamed[1].Active {
+		t.Errorf("wrong second streamed match")
+	}
+	if N, _ = archie.MarketMatchesStreaming(mkt.Base, mkt.Quote, true, 1, func(*db.MatchDataWithCoins) error { return nil }); N != 1 {
+		t.Errorf("streamed %d matches with a limit of 1", N)
+	}
+}
+
+func testMarketTrades(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt := mkts[0]
+	const farFuture = uint64(1) << 50
+
+	if trades, err := archie.MarketTrades(mkt.Base, mkt.Quote, farFuture, 10); err != nil {
+		t.Fatalf("MarketTrades failed: %v", err)
+	} else if len(trades) != 0 {
+		t.Fatalf("expected no trades, got %d", len(trades))
+	}
+
+	// Trade matches in epochs 10, 11, and 12, with the taker selling in the
+	// second.
+	for i := range 3 {
+		maker := newLimitOrder(randomAccountID(), mkt, i != 1, 2, int64(i))
+		maker.Rate += uint64(i) * mkt.RateStep
+		taker := newLimitOrder(randomAccountID(), mkt, i == 1, 1, int64(i))
+		match := newMatch(maker, taker, uint64(i+1)*mkt.LotSize, order.EpochID{Idx: uint64(10 + i), Dur: mkt.EpochDuration})
+		if err := archie.InsertMatch(match); err != nil {
+			t.Fatalf("InsertMatch failed: %v", err)
+		}
+	}
+	// A cancel order match is not a trade.
+	maker := newLimitOrder(randomAccountID(), mkt, true, 1, 3)
+	cancelMatch := newMatch(maker, newCancelOrder(maker.User(), mkt, maker.ID(), 3), mkt.LotSize,
+		order.EpochID{Idx: 13, Dur: mkt.EpochDuration})
+	if err := archie.InsertMatch(cancelMatch); err != nil {
+		t.Fatalf("InsertMatch failed for cancel match: %v", err)
+	}
+
+	trades, err := archie.MarketTrades(mkt.Base, mkt.Quote, farFuture, 10)
+	if err != nil {
+		t.Fatalf("MarketTrades failed: %v", err)
+	}
+	if len(trades) != 3 {
+		t.Fatalf("expected 3 trades, got %d", len(trades))
+	}
+	// Newest first.
+	for i, tr := range trades {
+		j := 2 - i
+		if tr.Epoch.Idx != uint64(10+j) || tr.Epoch.Dur != mkt.EpochDuration || tr.TakerSell != (j == 1) ||
+			tr.Quantity != uint64(j+1)*mkt.LotSize || tr.Rate != 4_0000_0000+uint64(j)*mkt.RateStep {
+			t.Errorf("wrong trade %d: %+v", i, tr)
+		}
+	}
+
+	// Only trades from epochs that ended before the time stamp.
+	if trades, _ = archie.MarketTrades(mkt.Base, mkt.Quote, 13*mkt.EpochDuration, 10); len(trades) != 2 || trades[0].Epoch.Idx != 11 {
+		t.Errorf("expected 2 trades before epoch 12 ended, got %d", len(trades))
+	}
+	if trades, _ = archie.MarketTrades(mkt.Base, mkt.Quote, farFuture, 1); len(trades) != 1 || trades[0].Epoch.Idx != 12 {
+		t.Errorf("expected only the newest trade with a limit of 1")
+	}
+	if trades, _ = archie.MarketTrades(mkts[1].Base, mkts[1].Quote, farFuture, 10); len(trades) != 0 {
+		t.Errorf("expected no trades for the other market, got %d", len(trades))
+	}
+}
+
+func testMatchFails(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	maker := randomAccountID()
+	var mids []db.MarketMatchID
+	for i, mkt := range mkts {
+		makerOrd := newLimitOrder(maker, mkt, true, 1, int64(i))
+		match := newMatch(makerOrd, newLimitOrder(randomAccountID(), mkt, false, 1, int64(i)), mkt.LotSize,
+			order.EpochID{Idx: uint64(10 + i), Dur: mkt.EpochDuration})
+		if err := archie.InsertMatch(match); err != nil {
+			t.Fatalf("InsertMatch failed: %v", err)
+		}
+		// The maker never sent their swap.
+		mid := db.MatchID(match)
+		if err := archie.SetMatchInactive(mid, false); err != nil {
+			t.Fatalf("SetMatchInactive failed: %v", err)
+		}
+		mids = append(mids, mid)
+	}
+
+	fails, err := archie.UserMatchFails(maker, 10)
+	if err != nil {
+		t.Fatalf("UserMatchFails failed: %v", err)
+	}
+	if len(fails) != 2 {
+		t.Fatalf("expected 2 match fails, got %d", len(fails))
+	}
+	for _, f := range fails {
+		if f.Status != order.NewlyMatched {
+			t.Errorf("match fail status %v, expected NewlyMatched", f.Status)
+		}
+	}
+	if fails, _ = archie.UserMatchFails(maker, 1); len(fails) != 1 {
+		t.Errorf("expected 1 match fail with a limit of 1, got %d", len(fails))
+	}
+
+	outcomes, err := archie.CompletedAndAtFaultMatchStats(maker, 10)
+	if err != nil {
+		t.Fatalf("CompletedAndAtFaultMatchStats failed: %v", err)
+	}
+	if len(outcomes) != 2 || !outcomes[0].Fail || !outcomes[1].Fail {
+		t.Fatalf("expected 2 failed outcomes")
+	}
+	// Newest first.
+	if outcomes[0].ID != mids[1].MatchID || outcomes[0].Base != mkts[1].Base ||
+		outcomes[0].Time != int64(12*mkts[1].EpochDuration) {
+		t.Errorf("wrong newest outcome %+v", outcomes[0])
+	}
+
+	forgiven, err := archie.ForgiveMatchFail(mids[0].MatchID)
+	if err != nil {
+		t.Fatalf("ForgiveMatchFail failed: %v", err)
+	}
+	if !forgiven {
+		t.Errorf("match fail not forgiven")
+	}
+	if forgiven, _ = archie.ForgiveMatchFail(randomMatchID()); forgiven {
+		t.Errorf("forgave an unknown match")
+	}
+	if fails, _ = archie.UserMatchFails(maker, 10); len(fails) != 1 || fails[0].ID != mids[1].MatchID {
+		t.Errorf("forgiven match fail still counted")
+	}
+
+	// Forgiven when set inactive.
+	if err = archie.SetMatchInactive(mids[1], true); err != nil {
+		t.Fatalf("SetMatchInactive failed: %v", err)
+	}
+	if fails, _ = archie.UserMatchFails(maker, 10); len(fails) != 0 {
+		t.Errorf("expected no match fails, got %d", len(fails))
+	}
+}
+
+func testEpochsAndCandles(t *testing.T, archie db.DEXArchivist, mkts []*dex.MarketInfo) {
+	mkt := mkts[0]
+	dur := mkt.EpochDuration
+
+	rate, err := archie.LastEpochRate(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("LastEpochRate failed: %v", err)
+	}
+	if rate != 0 {
+		t.Errorf("last epoch rate %d with no epochs, expected 0", rate)
+	}
+
+	// Two recent epochs.
+	nowIdx := uint64(time.Now().UnixMilli()) / dur
+	epochIdxs := []uint64{nowIdx - 5, nowIdx - 4}
+	for i, idx := range epochIdxs {
+		ed := &db.EpochResults{
+			MktBase:        mkt.Base,
+			MktQuote:       mkt.Quote,
+			Idx:            int64(idx),
+			Dur:            int64(dur),
+			MatchTime:      int64((idx + 1) * dur),
+			CSum:           randomBytes(32),
+			Seed:           randomBytes(32),
+			OrdersRevealed: []order.OrderID{randomOrderID(), randomOrderID()},
+			OrdersMissed:   []order.OrderID{randomOrderID()},
+			MatchVolume:    uint64(i+1) * mkt.LotSize,
+			QuoteVolume:    uint64(i+1) * 4 * mkt.LotSize,
+			BookBuys:       5 * mkt.LotSize,
+			BookSells:      6 * mkt.LotSize,
+			HighRate:       uint64(5+i) * RateStep,
+			LowRate:        uint64(3+i) * RateStep,
+			StartRate:      uint64(4+i) * RateStep,
+			EndRate:        uint64(4+i) * RateStep,
+		}
+		if err = archie.InsertEpoch(ed); err != nil {
+			t.Fatalf("InsertEpoch failed: %v", err)
+		}
+		if i == 0 {
+			if err = archie.InsertEpoch(ed); err == nil {
+				t.Errorf("no error inserting an epoch twice")
+			}
+		}
+	}
+	if rate, _ = archie.LastEpochRate(mkt.Base, mkt.Quote); rate != 5*RateStep {
+		t.Errorf("last epoch rate %d, expected %d", rate, 5*RateStep)
+	}
+
+	// With no stored candles, recent epochs are loaded into the cache.
+	cache := candles.NewCache(candles.CacheSize, dur)
+	if err = archie.LoadEpochStats(mkt.Base, mkt.Quote, []*candles.Cache{cache}); err != nil {
+		t.Fatalf("LoadEpochStats failed: %v", err)
+	}
+	if len(cache.Candles) != 2 {
+		t.Fatalf("expected 2 candles, got %d", len(cache.Candles))
+	}
+	if last := cache.Last(); last.EndStamp != (epochIdxs[1]+1)*dur || last.EndRate != 5*RateStep ||
+		last.MatchVolume != 2*mkt.LotSize || last.StartStamp != epochIdxs[1]*dur {
+		t.Errorf("wrong last candle %+v", last)
+	}
+
+	// Store candles for older bins.
+	endStamp, err := archie.LastCandleEndStamp(mkt.Base, mkt.Quote, dur)
+	if err != nil {
+		t.Fatalf("LastCandleEndStamp failed: %v", err)
+	}
+	if endStamp != 0 {
+		t.Errorf("last candle end stamp %d with no candles, expected 0", endStamp)
+	}
+	storedCandles := []*candles.Candle{
+		{EndStamp: (nowIdx - 9) * dur, MatchVolume: 1, QuoteVolume: 2, HighRate: 3, LowRate: 1, StartRate: 2, EndRate: 2},
+		{EndStamp: (nowIdx - 8) * dur, MatchVolume: 3, QuoteVolume: 4, HighRate: 5, LowRate: 2, StartRate: 3, EndRate: 4},
+	}
+	if err = archie.InsertCandles(mkt.Base, mkt.Quote, dur, storedCandles); err != nil {
+		t.Fatalf("InsertCandles failed: %v", err)
+	}
+	// Replace the last one.
+	storedCandles[1].EndRate = 5
+	if err = archie.InsertCandles(mkt.Base, mkt.Quote, dur, storedCandles[1:]); err != nil {
+		t.Fatalf("InsertCandles failed: %v", err)
+	}
+	if endStamp, _ = archie.LastCandleEndStamp(mkt.Base, mkt.Quote, dur); endStamp != storedCandles[1].EndStamp {
+		t.Errorf("last candle end stamp %d, expected %d", endStamp, storedCandles[1].EndStamp)
+	}
+	if endStamp, _ = archie.LastCandleEndStamp(mkt.Base, mkt.Quote, 2*dur); endStamp != 0 {
+		t.Errorf("last candle end stamp %d for other candle duration, expected 0", endStamp)
+	}
+
+	// The stored candles are loaded, followed by the newer epochs.
+	cache = candles.NewCache(candles.CacheSize, dur)
+	if err = archie.LoadEpochStats(mkt.Base, mkt.Quote, []*candles.Cache{cache}); err != nil {
+		t.Fatalf("LoadEpochStats failed: %v", err)
+	}
+	if len(cache.Candles) != 4 {
+		t.Fatalf("expected 4 candles, got %d", len(cache.Candles))
+	}
+	if c := cache.Candles[1]; c.EndStamp != storedCandles[1].EndStamp || c.EndRate != 5 ||
+		c.StartStamp != storedCandles[1].EndStamp-dur || c.MatchVolume != 3 {
+		t.Errorf("wrong stored candle %+v", c)
+	}
+	if cache.Last().EndStamp != (epochIdxs[1]+1)*dur {
+		t.Errorf("wrong last candle end stamp %d", cache.Last().EndStamp)
+	}
+}
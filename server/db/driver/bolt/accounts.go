@@ -0,0 +1,190 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"decred.org/dcrdex/server/account"
+	"decred.org/dcrdex/server/db"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"go.etcd.io/bbolt"
+)
+
+// bondCoinKey is the key of the bond coins index.
+func bondCoinKey(assetID uint32, coinID []byte) []byte {
+	return append(uint32Bytes(assetID), coinID...)
+}
+
+// Account retrieves the account pubkey and the bonds with a lock time at or
+// after bondExpiry. If the account does not exist or there is in an error
+// retrieving any data, a nil *account.Account is returned.
+func (a *Archiver) Account(aid account.AccountID, bondExpiry time.Time) (acct *account.Account, bonds []*db.Bond) {
+	err := a.db.View(func(tx *bbolt.Tx) error {
+		pubKey := tx.Bucket(accountsBucket).Get(aid[:])
+		if pubKey == nil {
+			return nil
+		}
+		var err error
+		if acct, err = account.NewAccountFromPubKey(pubKey); err != nil {
+			return err
+		}
+		c := tx.Bucket(bondsBucket).Cursor()
+		for k, v := c.Seek(aid[:]); k != nil && bytes.HasPrefix(k, aid[:]); k, v = c.Next() {
+			coinKey := k[len(aid):]
+			bond, err := decodeBond(intCoder.Uint32(coinKey[:4]), coinKey[4:], v)
+			if err != nil {
+				return err
+			}
+			if bond.LockTime >= bondExpiry.Unix() {
+				bonds = append(bonds, bond)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Account error: %v", err)
+		return nil, nil
+	}
+
+	sort.Slice(bonds, func(i, j int) bool {
+		return bonds[i].LockTime < bonds[j].LockTime
+	})
+	return acct, bonds
+}
+
+// AccountInfo returns data for an account.
+func (a *Archiver) AccountInfo(aid account.AccountID) (*db.Account, error) {
+	var acct *db.Account
+	err := a.db.View(func(tx *bbolt.Tx) error {
+		pubKey := tx.Bucket(accountsBucket).Get(aid[:])
+		if pubKey == nil {
+			return db.ArchiveError{Code: db.ErrAccountUnknown}
+		}
+		acct = &db.Account{
+			AccountID: aid,
+			Pubkey:    bytes.Clone(pubKey),
+		}
+		return nil
+	})
+	return acct, err
+}
+
+// CreateAccountWithBond creates a new account with a fidelity bond.
+func (a *Archiver) CreateAccountWithBond(acct *account.Account, bond *db.Bond) error {
+	return a.update(func(tx *bbolt.Tx) error {
+		accts := tx.Bucket(accountsBucket)
+		if accts.Get(acct.ID[:]) != nil {
+			return newUpdateErr("account %v already exists", acct.ID)
+		}
+		if err := accts.Put(acct.ID[:], acct.PubKey.SerializeCompressed()); err != nil {
+			return err
+		}
+		return addBond(tx, acct.ID, bond)
+	})
+}
+
+// addBond stores the bond for the account. It is an error if a bond with the
+// same asset ID and coin ID is already stored.
+func addBond(tx *bbolt.Tx, aid account.AccountID, bond *db.Bond) error {
+	coinKey := bondCoinKey(bond.AssetID, bond.CoinID)
+	bondCoins := tx.Bucket(bondCoinsBucket)
+	if bondCoins.Get(coinKey) != nil {
+		return newUpdateErr("bond %x for asset %d already stored", bond.CoinID, bond.AssetID)
+	}
+	if err := bondCoins.Put(coinKey, aid[:]); err != nil {
+		return err
+	}
+	return tx.Bucket(bondsBucket).Put(append(aid[:len(aid):len(aid)], coinKey...), encodeBond(bond))
+}
+
+// AddBond stores a new Bond for an existing account.
+func (a *Archiver) AddBond(aid account.AccountID, bond *db.Bond) error {
+	return a.update(func(tx *bbolt.Tx) error {
+		return addBond(tx, aid, bond)
+	})
+}
+
+func (a *Archiver) DeleteBond(assetID uint32, coinID []byte) error {
+	return a.update(func(tx *bbolt.Tx) error {
+		coinKey := bondCoinKey(assetID, coinID)
+		bondCoins := tx.Bucket(bondCoinsBucket)
+		aid := bondCoins.Get(coinKey)
+		if aid == nil {
+			return nil
+		}
+		if err := tx.Bucket(bondsBucket).Delete(append(bytes.Clone(aid), coinKey...)); err != nil {
+			return err
+		}
+		return bondCoins.Delete(coinKey)
+	})
+}
+
+func (a *Archiver) FetchPrepaidBond(coinID []byte) (strength uint32, lockTime int64, err error) {
+	err = a.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(prepaidBondsBucket).Get(coinID)
+		if len(b) != 12 {
+			return fmt.Errorf("prepaid bond %x not found", coinID)
+		}
+		strength = intCoder.Uint32(b[:4])
+		lockTime = int64(intCoder.Uint64(b[4:]))
+		return nil
+	})
+	return
+}
+
+func (a *Archiver) DeletePrepaidBond(coinID []byte) (err error) {
+	return a.update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(prepaidBondsBucket).Delete(coinID)
+	})
+}
+
+func (a *Archiver) StorePrepaidBonds(coinIDs [][]byte, strength uint32, lockTime int64) error {
+	v := append(uint32Bytes(strength), uint64Bytes(uint64(lockTime))...)
+	return a.update(func(tx *bbolt.Tx) error {
+		prepaidBonds := tx.Bucket(prepaidBondsBucket)
+		for i := range coinIDs {
+			if prepaidBonds.Get(coinIDs[i]) != nil {
+				return newUpdateErr("prepaid bond %x already stored", coinIDs[i])
+			}
+			if err := prepaidBonds.Put(coinIDs[i], v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// KeyIndex returns the current child index for the an xpub. If it is not
+// known, this creates a new entry with index zero.
+func (a *Archiver) KeyIndex(xpub string) (uint32, error) {
+	keyHash := dcrutil.Hash160([]byte(xpub))
+
+	var child uint32
+	err := a.update(func(tx *bbolt.Tx) error {
+		feeKeys := tx.Bucket(feeKeysBucket)
+		if idxB := feeKeys.Get(keyHash); idxB != nil {
+			child = intCoder.Uint32(idxB)
+			return nil
+		}
+		log.Debugf("Inserting key entry for xpub %.40s..., hash160 = %x", xpub, keyHash)
+		return feeKeys.Put(keyHash, uint32Bytes(0))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return child, nil
+}
+
+// SetKeyIndex records the child index for an xpub.
+func (a *Archiver) SetKeyIndex(idx uint32, xpub string) error {
+	keyHash := dcrutil.Hash160([]byte(xpub))
+	log.Debugf("Recording new index %d for xpub %.40s... (%x)", idx, xpub, keyHash)
+	return a.update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(feeKeysBucket).Put(keyHash, uint32Bytes(idx))
+	})
+}
@@ -0,0 +1,441 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+// Package bolt provides an embedded server/db.DEXArchivist backed by a single
+// bbolt database file. It is intended for small deployments and development
+// setups where running PostgreSQL is impractical. The data model follows the
+// pg driver, with buckets in place of tables.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/server/db"
+	"go.etcd.io/bbolt"
+)
+
+// Driver implements db.Driver.
+type Driver struct{}
+
+// Open creates the DB backend, returning a DEXArchivist.
+func (d *Driver) Open(ctx context.Context, cfg any) (db.DEXArchivist, error) {
+	switch c := cfg.(type) {
+	case *Config:
+		return NewArchiver(ctx, c)
+	case Config:
+		return NewArchiver(ctx, &c)
+	default:
+		return nil, fmt.Errorf("invalid config type %t", cfg)
+	}
+}
+
+// UseLogger sets the package-wide logger for the registered DB Driver.
+func (*Driver) UseLogger(logger dex.Logger) {
+	UseLogger(logger)
+}
+
+func init() {
+	db.Register("bolt", &Driver{})
+}
+
+// DBVersion is the current database version.
+const DBVersion = 0
+
+// Config holds the Archiver's configuration.
+type Config struct {
+	// Path is the database file. It is created if it does not exist.
+	Path string
+
+	// MarketCfg specifies all of the markets that the Archiver should prepare.
+	MarketCfg []*dex.MarketInfo
+}
+
+// Short names for some commonly used imported functions.
+var (
+	intCoder    = encode.IntCoder
+	uint16Bytes = encode.Uint16Bytes
+	uint32Bytes = encode.Uint32Bytes
+	uint64Bytes = encode.Uint64Bytes
+)
+
+// Bolt works on []byte keys and values. The top level buckets hold the market
+// independent data. Each market has a bucket in the markets bucket, with a
+// sub-bucket for each of the market tables of the pg driver.
+var (
+	// top level buckets
+	metaBucket         = []byte("meta")
+	marketsBucket      = []byte("markets")
+	accountsBucket     = []byte("accounts")     // account ID => pubkey
+	bondsBucket        = []byte("bonds")        // account ID | assetID | coinID => bond
+	bondCoinsBucket    = []byte("bondCoins")    // index: assetID | coinID => account ID
+	prepaidBondsBucket = []byte("prepaidBonds") // coinID => strength | lockTime
+	feeKeysBucket      = []byte("feeKeys")      // hash160(xpub) => child index
+	commitsBucket      = []byte("commits")      // commit => order ID, for all markets
+
+	// market sub-buckets
+	ordersBucket        = []byte("orders")        // trade and cancel orders
+	activeOrdersBucket  = []byte("activeOrders")  // index of epoch and booked orders
+	userOrdersBucket    = []byte("userOrders")    // index: account ID | order ID
+	matchesBucket       = []byte("matches")       // all matches
+	activeMatchesBucket = []byte("activeMatches") // index of active matches
+	tradeMatchesBucket  = []byte("tradeMatches")  // index: epoch end | match ID, for trade matches
+	userMatchesBucket   = []byte("userMatches")   // index: account ID | match ID
+	epochsBucket        = []byte("epochs")        // epoch idx | dur => epoch
+	epochReportsBucket  = []byte("epochReports")  // epoch end => report
+	candlesBucket       = []byte("candles")       // candle dur => (end stamp => candle)
+
+	marketSubBuckets = [][]byte{ordersBucket, activeOrdersBucket, userOrdersBucket,
+		matchesBucket, activeMatchesBucket, tradeMatchesBucket, userMatchesBucket,
+		epochsBucket, epochReportsBucket, candlesBucket}
+
+	// value keys
+	versionKey = []byte("version")
+	lotSizeKey = []byte("lotSize")
+)
+
+// Archiver must implement server/db.DEXArchivist.
+type Archiver struct {
+	ctx context.Context
+	db  *bbolt.DB
+	// markets is replaced, not modified, when a market is added with
+	// AddMarket, so the map returned by marketMap may be used without locking.
+	marketsMtx sync.RWMutex
+	markets    map[string]*dex.MarketInfo
+
+	fatalMtx sync.RWMutex
+	fatal    chan struct{}
+	fatalErr error
+}
+
+// Check that Archiver satisfies the db.DEXArchivist and db.MarketAdder
+// interfaces.
+var _ db.DEXArchivist = (*Archiver)(nil)
+var _ db.MarketAdder = (*Archiver)(nil)
+
+// LastErr returns any fatal or unexpected error encountered in a recent query.
+// This may be used to check if the database had an unrecoverable error.
+func (a *Archiver) LastErr() error {
+	a.fatalMtx.RLock()
+	defer a.fatalMtx.RUnlock()
+	return a.fatalErr
+}
+
+// Fatal returns a nil or closed channel for select use. Use LastErr to get the
+// latest fatal error.
+func (a *Archiver) Fatal() <-chan struct{} {
+	a.fatalMtx.RLock()
+	defer a.fatalMtx.RUnlock()
+	return a.fatal
+}
+
+func (a *Archiver) fatalBackendErr(err error) {
+	if err == nil {
+		return
+	}
+	a.fatalMtx.Lock()
+	if a.fatalErr == nil {
+		close(a.fatal)
+	}
+	a.fatalErr = err
+	a.fatalMtx.Unlock()
+}
+
+// NewArchiver constructs a new Archiver. The buckets for all configured markets
+// are created. The books of markets with a changed lot size are flushed. Use
+// Close when done with the Archiver.
+func NewArchiver(ctx context.Context, cfg *Config) (*Archiver, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("no database path specified")
+	}
+	bdb, err := bbolt.Open(cfg.Path, 0600, &bbolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		if errors.Is(err, bbolt.ErrTimeout) {
+			err = fmt.Errorf("%w, could happen when database is already being used by another process", err)
+		}
+		return nil, err
+	}
+
+	a := &Archiver{
+		ctx:     ctx,
+		db:      bdb,
+		markets: make(map[string]*dex.MarketInfo, len(cfg.MarketCfg)),
+		fatal:   make(chan struct{}),
+	}
+	for _, mkt := range cfg.MarketCfg {
+		a.markets[mkt.Name] = mkt
+	}
+
+	staleMarkets, err := a.prepareBuckets()
+	if err != nil {
+		bdb.Close()
+		return nil, err
+	}
+	for _, mkt := range staleMarkets {
+		unbookedSells, unbookedBuys, err := a.FlushBook(mkt.Base, mkt.Quote)
+		if err != nil {
+			bdb.Close()
+			return nil, fmt.Errorf("failed to flush book for market %v: %w", mkt.Name, err)
+		}
+		log.Infof("Flushed %d sell orders and %d buy orders from market %v with a changed lot size.",
+			len(unbookedSells), len(unbookedBuys), mkt.Name)
+	}
+
+	log.Infof("Started database (version = %d, file = %s)", DBVersion, cfg.Path)
+
+	return a, nil
+}
+
+// prepareBuckets creates the top level buckets and the buckets for each
+// configured market. The markets with a lot size that differs from the stored
+// lot size are returned.
+func (a *Archiver) prepareBuckets() (staleMarkets []*dex.MarketInfo, err error) {
+	err = a.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{metaBucket, marketsBucket, accountsBucket,
+			bondsBucket, bondCoinsBucket, prepaidBondsBucket, feeKeysBucket, commitsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("failed to create %s bucket: %w", string(name), err)
+			}
+		}
+
+		meta := tx.Bucket(metaBucket)
+		if verB := meta.Get(versionKey); verB == nil {
+			if err := meta.Put(versionKey, uint32Bytes(DBVersion)); err != nil {
+				return err
+			}
+		} else if ver := intCoder.Uint32(verB); ver > DBVersion {
+			return fmt.Errorf("unknown database version %d, latest known is %d", ver, DBVersion)
+		}
+
+		mkts := tx.Bucket(marketsBucket)
+		for _, mkt := range a.markets {
+			stale, err := prepareMarketBucket(mkts, mkt)
+			if err != nil {
+				return err
+			}
+			if stale {
+				staleMarkets = append(staleMarkets, mkt)
+			}
+		}
+		return nil
+	})
+	return staleMarkets, err
+}
+
+// prepareMarketBucket creates the bucket for a market in the markets bucket,
+// storing the market's lot size. stale is true if the market existed with a
+// different lot size.
+func prepareMarketBucket(mkts *bbolt.Bucket, mkt *dex.MarketInfo) (stale bool, err error) {
+	name := mkt.Name
+	mktBkt, err := mkts.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return false, fmt.Errorf("failed to create bucket for market %s: %w", name, err)
+	}
+	for _, sub := range marketSubBuckets {
+		if _, err := mktBkt.CreateBucketIfNotExists(sub); err != nil {
+			return false, fmt.Errorf("failed to create %s bucket for market %s: %w", string(sub), name, err)
+		}
+	}
+	lotSizeB := uint64Bytes(mkt.LotSize)
+	switch oldLotSize := mktBkt.Get(lotSizeKey); {
+	case oldLotSize == nil:
+		log.Debugf("Created new market %q", name)
+	case !bytes.Equal(oldLotSize, lotSizeB):
+		log.Infof("Lot size changed for market %s: %d => %d", name,
+			intCoder.Uint64(oldLotSize), mkt.LotSize)
+		stale = true
+	default:
+		return false, nil
+	}
+	return stale, mktBkt.Put(lotSizeKey, lotSizeB)
+}
+
+// AddMarket prepares the buckets for a market that was not configured when the
+// Archiver was created, and begins storing data for it. If the market's
+// buckets exist with a different lot size, the market's book is flushed. Part
+// of the db.MarketAdder interface.
+func (a *Archiver) AddMarket(mkt *dex.MarketInfo) error {
+	a.marketsMtx.Lock()
+	if _, found := a.markets[mkt.Name]; found {
+		a.marketsMtx.Unlock()
+		return fmt.Errorf("market %s already exists", mkt.Name)
+	}
+	var stale bool
+	err := a.db.Update(func(tx *bbolt.Tx) (err error) {
+		stale, err = prepareMarketBucket(tx.Bucket(marketsBucket), mkt)
+		return err
+	})
+	if err != nil {
+		a.marketsMtx.Unlock()
+		return err
+	}
+	markets := maps.Clone(a.markets)
+	markets[mkt.Name] = mkt
+	a.markets = markets
+	a.marketsMtx.Unlock()
+
+	if stale {
+		unbookedSells, unbookedBuys, err := a.FlushBook(mkt.Base, mkt.Quote)
+		if err != nil {
+			return fmt.Errorf("failed to flush book for market %v: %w", mkt.Name, err)
+		}
+		log.Infof("Flushed %d sell orders and %d buy orders from market %v with a changed lot size.",
+			len(unbookedSells), len(unbookedBuys), mkt.Name)
+	}
+	return nil
+}
+
+// Check that Archiver satisfies the db.LotSizeUpdater interface.
+var _ db.LotSizeUpdater = (*Archiver)(nil)
+
+// UpdateLotSize sets the lot size of a configured market without flushing the
+// book. Part of the db.LotSizeUpdater interface.
+func (a *Archiver) UpdateLotSize(base, quote uint32, lotSize uint64) error {
+	marketName, err := a.marketName(base, quote)
+	if err != nil {
+		return err
+	}
+	a.marketsMtx.Lock()
+	defer a.marketsMtx.Unlock()
+	err = a.db.Update(func(tx *bbolt.Tx) error {
+		mktBkt, err := marketBucket(tx, marketName)
+		if err != nil {
+			return err
+		}
+		return mktBkt.Put(lotSizeKey, uint64Bytes(lotSize))
+	})
+	if err != nil {
+		return err
+	}
+	mkt := *a.markets[marketName]
+	mkt.LotSize = lotSize
+	markets := maps.Clone(a.markets)
+	markets[marketName] = &mkt
+	a.markets = markets
+	log.Debugf("Updated %s lot size to %d.", marketName, lotSize)
+	return nil
+}
+
+// marketMap returns the configured markets. The map must not be modified.
+func (a *Archiver) marketMap() map[string]*dex.MarketInfo {
+	a.marketsMtx.RLock()
+	defer a.marketsMtx.RUnlock()
+	return a.markets
+}
+
+// Close closes the database.
+func (a *Archiver) Close() error {
+	return a.db.Close()
+}
+
+// marketName gets the name of a configured market. ErrUnsupportedMarket is
+// returned for unknown markets.
+func (a *Archiver) marketName(base, quote uint32) (string, error) {
+	marketName, err := dex.MarketName(base, quote)
+	if err != nil {
+		return "", err
+	}
+	if _, found := a.marketMap()[marketName]; !found {
+		return "", db.ArchiveError{
+			Code:   db.ErrUnsupportedMarket,
+			Detail: fmt.Sprintf(`archiver does not support the market "%s"`, marketName),
+		}
+	}
+	return marketName, nil
+}
+
+// marketBucket gets a market's bucket.
+func marketBucket(tx *bbolt.Tx, marketName string) (*bbolt.Bucket, error) {
+	mktBkt := tx.Bucket(marketsBucket).Bucket([]byte(marketName))
+	if mktBkt == nil {
+		return nil, fmt.Errorf("no bucket for market %s", marketName)
+	}
+	return mktBkt, nil
+}
+
+// marketView runs the function in a read-only transaction with the bucket of
+// the market specified by base and quote.
+func (a *Archiver) marketView(base, quote uint32, f func(mktBkt *bbolt.Bucket) error) error {
+	marketName, err := a.marketName(base, quote)
+	if err != nil {
+		return err
+	}
+	return a.db.View(func(tx *bbolt.Tx) error {
+		mktBkt, err := marketBucket(tx, marketName)
+		if err != nil {
+			return err
+		}
+		return f(mktBkt)
+	})
+}
+
+// marketUpdate is like marketView, but with a read-write transaction. Errors
+// other than an ArchiveError are considered fatal backend errors.
+func (a *Archiver) marketUpdate(base, quote uint32, f func(tx *bbolt.Tx, mktBkt *bbolt.Bucket) error) error {
+	marketName, err := a.marketName(base, quote)
+	if err != nil {
+		return err
+	}
+	return a.update(func(tx *bbolt.Tx) error {
+		mktBkt, err := marketBucket(tx, marketName)
+		if err != nil {
+			return err
+		}
+		return f(tx, mktBkt)
+	})
+}
+
+// update runs the function in a read-write transaction. Errors from bbolt,
+// unlike an ArchiveError, are flagged as fatal backend errors.
+func (a *Archiver) update(f func(tx *bbolt.Tx) error) error {
+	err := a.db.Update(f)
+	var errA db.ArchiveError
+	if err != nil && !errors.As(err, &errA) && !isUpdateErr(err) {
+		a.fatalBackendErr(err)
+	}
+	return err
+}
+
+// eachMarket runs the function for every configured market in a read-only
+// transaction.
+func (a *Archiver) eachMarket(f func(mkt *dex.MarketInfo, mktBkt *bbolt.Bucket) error) error {
+	return a.db.View(func(tx *bbolt.Tx) error {
+		for name, mkt := range a.marketMap() {
+			mktBkt, err := marketBucket(tx, name)
+			if err != nil {
+				return err
+			}
+			if err = f(mkt, mktBkt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// updateErr is an error for an update of a record that does not exist or is
+// in an unexpected state. Unlike other errors from a read-write transaction,
+// it is not a fatal backend error.
+type updateErr struct {
+	msg string
+}
+
+func (e updateErr) Error() string {
+	return e.msg
+}
+
+func newUpdateErr(format string, args ...any) error {
+	return updateErr{fmt.Sprintf(format, args...)}
+}
+
+func isUpdateErr(err error) bool {
+	var errU updateErr
+	return errors.As(err, &errU)
+}
@@ -0,0 +1,100 @@
+package bolt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/db"
+	"decred.org/dcrdex/server/db/dbtest"
+	"github.com/decred/slog"
+)
+
+func TestMain(m *testing.M) {
+	logger := slog.NewBackend(os.Stdout).Logger("BOLT_DB_TEST")
+	logger.SetLevel(slog.LevelWarn)
+	UseLogger(logger)
+	os.Exit(m.Run())
+}
+
+func newTestArchiver(t *testing.T, path string, mkts []*dex.MarketInfo) *Archiver {
+	t.Helper()
+	archie, err := NewArchiver(context.Background(), &Config{
+		Path:      path,
+		MarketCfg: mkts,
+	})
+	if err != nil {
+		t.Fatalf("NewArchiver failed: %v", err)
+	}
+	return archie
+}
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, mkts []*dex.MarketInfo) db.DEXArchivist {
+		archie := newTestArchiver(t, filepath.Join(t.TempDir(), "dcrdex.db"), mkts)
+		t.Cleanup(func() { archie.Close() })
+		return archie
+	})
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dcrdex.db")
+	mkts := dbtest.Markets(t)
+	mkt := mkts[0]
+
+	archie := newTestArchiver(t, path, mkts)
+	lo := &order.LimitOrder{
+		P: order.Prefix{
+			BaseAsset:  mkt.Base,
+			QuoteAsset: mkt.Quote,
+			OrderType:  order.LimitOrderType,
+			ClientTime: time.Unix(1566497653, 0).UTC(),
+			ServerTime: time.Unix(1566497656, 0).UTC(),
+			Commit:     order.Commitment{1},
+		},
+		T: order.Trade{
+			Coins:    []order.CoinID{{2}},
+			Sell:     true,
+			Quantity: mkt.LotSize,
+			Address:  "149RQGLaHf2gGiL4NXZdH7aA8nYEuLLrgm",
+		},
+		Rate:  4_0000_0000,
+		Force: order.StandingTiF,
+	}
+	if err := archie.NewEpochOrder(lo, 10, int64(mkt.EpochDuration), db.EpochGapNA); err != nil {
+		t.Fatalf("NewEpochOrder failed: %v", err)
+	}
+	if err := archie.BookOrder(lo); err != nil {
+		t.Fatalf("BookOrder failed: %v", err)
+	}
+	if err := archie.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Same lot size. The book is kept.
+	archie = newTestArchiver(t, path, mkts)
+	bookOrds, err := archie.BookOrders(mkt.Base, mkt.Quote)
+	if err != nil {
+		t.Fatalf("BookOrders failed: %v", err)
+	}
+	if len(bookOrds) != 1 || bookOrds[0].ID() != lo.ID() {
+		t.Fatalf("book order not loaded after reopening")
+	}
+	archie.Close()
+
+	// Changed lot size. The book is flushed.
+	mkts = dbtest.Markets(t)
+	mkts[0].LotSize *= 10
+	archie = newTestArchiver(t, path, mkts)
+	defer archie.Close()
+	if bookOrds, _ = archie.BookOrders(mkt.Base, mkt.Quote); len(bookOrds) != 0 {
+		t.Fatalf("book not flushed after a lot size change")
+	}
+	if _, status, err := archie.Order(lo.ID(), mkt.Base, mkt.Quote); err != nil || status != order.OrderStatusRevoked {
+		t.Fatalf("flushed order status %v, error %v", status, err)
+	}
+}
@@ -0,0 +1,205 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package bolt
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"decred.org/dcrdex/dex/candles"
+	"decred.org/dcrdex/server/db"
+	"go.etcd.io/bbolt"
+)
+
+// InsertEpoch stores the results of a newly-processed epoch.
+func (a *Archiver) InsertEpoch(ed *db.EpochResults) error {
+	return a.marketUpdate(ed.MktBase, ed.MktQuote, func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		epochs := mktBkt.Bucket(epochsBucket)
+		k := epochKey(ed.Idx, ed.Dur)
+		if epochs.Get(k) != nil {
+			return newUpdateErr("epoch %d-%d already stored", ed.Idx, ed.Dur)
+		}
+		if err := epochs.Put(k, encodeEpoch(ed)); err != nil {
+			return err
+		}
+		epochEnd := (ed.Idx + 1) * ed.Dur
+		return mktBkt.Bucket(epochReportsBucket).Put(uint64Bytes(uint64(epochEnd)), encodeEpochReport(ed))
+	})
+}
+
+// LastEpochRate gets the EndRate of the last EpochResults inserted for the
+// market. If the database is empty, no error and a rate of zero are returned.
+func (a *Archiver) LastEpochRate(base, quote uint32) (rate uint64, err error) {
+	err = a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		k, v := mktBkt.Bucket(epochReportsBucket).Cursor().Last()
+		if k == nil {
+			return nil
+		}
+		report, err := decodeEpochReport(v)
+		if err != nil {
+			return err
+		}
+		rate = report.endRate
+		return nil
+	})
+	return
+}
+
+// LoadEpochStats reads all market epoch history from the database, updating the
+// provided caches along the way.
+func (a *Archiver) LoadEpochStats(base, quote uint32, caches []*candles.Cache) error {
+	tstart := time.Now()
+	defer func() { log.Debugf("load epoch candles in: %v", time.Since(tstart)) }()
+
+	return a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		// First, load stored candles from the candles bucket. Establish a start
+		// stamp for scanning epoch reports for partial candles.
+		var oldestNeeded uint64 = math.MaxUint64
+		sinces := make([]uint64, len(caches)) // oldest end stamp needed by each cache
+		now := uint64(time.Now().UnixMilli())
+		for i, cache := range caches {
+			if err := loadCandles(mktBkt, cache, candles.CacheSize); err != nil {
+				return fmt.Errorf("loadCandles: %w", err)
+			}
+
+			var since uint64
+			if len(cache.Candles) > 0 {
+				// If we have candles, set our since value to the next expected
+				// epoch stamp.
+				idx := cache.Last().EndStamp / cache.BinSize
+				since = (idx + 1) * cache.BinSize
+			} else {
+				since = now - (cache.BinSize * candles.CacheSize)
+				since = since - since%cache.BinSize // truncate to first end stamp of the epoch
+			}
+			if since < oldestNeeded {
+				oldestNeeded = since
+			}
+			sinces[i] = since
+		}
+
+		c := mktBkt.Bucket(epochReportsBucket).Cursor()
+		for k, v := c.Seek(uint64Bytes(oldestNeeded)); k != nil; k, v = c.Next() {
+			endStamp := intCoder.Uint64(k)
+			report, err := decodeEpochReport(v)
+			if err != nil {
+				return err
+			}
+			candle := &candles.Candle{
+				StartStamp:  endStamp - report.dur,
+				EndStamp:    endStamp,
+				MatchVolume: report.matchVol,
+				QuoteVolume: report.quoteVol,
+				HighRate:    report.highRate,
+				LowRate:     report.lowRate,
+				StartRate:   report.startRate,
+				EndRate:     report.endRate,
+			}
+			for i, cache := range caches {
+				if endStamp > sinces[i] {
+					cache.Add(candle)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// candleBucket gets the candles bucket for a candle duration. A nil bucket is
+// returned if no candles of the duration are stored.
+func candleBucket(mktBkt *bbolt.Bucket, candleDur uint64) *bbolt.Bucket {
+	return mktBkt.Bucket(candlesBucket).Bucket(uint64Bytes(candleDur))
+}
+
+// LastCandleEndStamp pulls the last stored candles end stamp for a market and
+// candle duration.
+func (a *Archiver) LastCandleEndStamp(base, quote uint32, candleDur uint64) (endStamp uint64, err error) {
+	err = a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		bkt := candleBucket(mktBkt, candleDur)
+		if bkt == nil {
+			return nil
+		}
+		if k, _ := bkt.Cursor().Last(); k != nil {
+			endStamp = intCoder.Uint64(k)
+		}
+		return nil
+	})
+	return
+}
+
+// InsertCandles inserts new candles for a market and candle duration. Existing
+// candles with the same end stamp are replaced.
+func (a *Archiver) InsertCandles(base, quote uint32, candleDur uint64, cs []*candles.Candle) error {
+	return a.marketUpdate(base, quote, func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		bkt, err := mktBkt.Bucket(candlesBucket).CreateBucketIfNotExists(uint64Bytes(candleDur))
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			if err := bkt.Put(uint64Bytes(c.EndStamp), encodeCandle(c)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// loadCandles loads the last n candles of a specified duration into the
+// provided cache.
+func loadCandles(mktBkt *bbolt.Bucket, cache *candles.Cache, n uint64) error {
+	candleDur := cache.BinSize
+	bkt := candleBucket(mktBkt, candleDur)
+	if bkt == nil {
+		return nil
+	}
+
+	// Find the oldest of the last n candles, then add them in ascending order.
+	c := bkt.Cursor()
+	k, _ := c.Last()
+	for i := uint64(1); i < n && k != nil; i++ {
+		if prev, _ := c.Prev(); prev != nil {
+			k = prev
+		} else {
+			break
+		}
+	}
+	for k, v := c.Seek(k); k != nil; k, v = c.Next() {
+		candle, err := decodeCandle(intCoder.Uint64(k), candleDur, v)
+		if err != nil {
+			return err
+		}
+		cache.Add(candle)
+	}
+	return nil
+}
+
+// encodeCandle encodes a candle for storage. The end stamp is the key, and the
+// candle duration is the bucket.
+func encodeCandle(c *candles.Candle) []byte {
+	b := make([]byte, 0, 6*8)
+	for _, v := range []uint64{c.MatchVolume, c.QuoteVolume, c.HighRate, c.LowRate, c.StartRate, c.EndRate} {
+		b = append(b, uint64Bytes(v)...)
+	}
+	return b
+}
+
+func decodeCandle(endStamp, candleDur uint64, b []byte) (*candles.Candle, error) {
+	if len(b) != 6*8 {
+		return nil, fmt.Errorf("invalid candle record length %d", len(b))
+	}
+	u64 := func(i int) uint64 {
+		return intCoder.Uint64(b[i*8:])
+	}
+	return &candles.Candle{
+		StartStamp:  endStamp - candleDur,
+		EndStamp:    endStamp,
+		MatchVolume: u64(0),
+		QuoteVolume: u64(1),
+		HighRate:    u64(2),
+		LowRate:     u64(3),
+		StartRate:   u64(4),
+		EndRate:     u64(5),
+	}, nil
+}
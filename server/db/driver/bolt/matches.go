@@ -0,0 +1,666 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package bolt
+
+import (
+	"fmt"
+	"sort"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/account"
+	"decred.org/dcrdex/server/db"
+	"go.etcd.io/bbolt"
+)
+
+// getMatch loads the match from the market bucket. A nil matchRecord is
+// returned if the match is not found.
+func getMatch(mktBkt *bbolt.Bucket, mid []byte) (*matchRecord, error) {
+	b := mktBkt.Bucket(matchesBucket).Get(mid)
+	if b == nil {
+		return nil, nil
+	}
+	return decodeMatchRecord(b)
+}
+
+// putMatch stores the match and updates the active and trade matches indexes.
+func putMatch(mktBkt *bbolt.Bucket, m *matchRecord) error {
+	if err := mktBkt.Bucket(matchesBucket).Put(m.ID[:], m.encode()); err != nil {
+		return err
+	}
+	if !m.cancel {
+		if err := mktBkt.Bucket(tradeMatchesBucket).Put(tradeKey(m), []byte{}); err != nil {
+			return err
+		}
+	}
+	if m.Active {
+		return mktBkt.Bucket(activeMatchesBucket).Put(m.ID[:], []byte{})
+	}
+	return mktBkt.Bucket(activeMatchesBucket).Delete(m.ID[:])
+}
+
+// tradeKey is the key of a match in the trade matches index, which orders the
+// trade matches by the end of their epoch.
+func tradeKey(m *matchRecord) []byte {
+	return append(uint64Bytes((m.Epoch.Idx+1)*m.Epoch.Dur), m.ID[:]...)
+}
+
+// forEachMatch runs the function for every match in the market.
+func forEachMatch(mktBkt *bbolt.Bucket, f func(m *matchRecord) error) error {
+	return mktBkt.Bucket(matchesBucket).ForEach(func(_, v []byte) error {
+		m, err := decodeMatchRecord(v)
+		if err != nil {
+			return err
+		}
+		return f(m)
+	})
+}
+
+// forEachIndexedMatch runs the function for the matches with the given IDs,
+// which come from the active matches or user matches index.
+func forEachIndexedMatch(mktBkt *bbolt.Bucket, mids [][]byte, f func(m *matchRecord) error) error {
+	for _, mid := range mids {
+		m, err := getMatch(mktBkt, mid)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return fmt.Errorf("indexed match %x not found", mid)
+		}
+		if err = f(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeMatchIDs lists the IDs in the active matches index.
+func activeMatchIDs(mktBkt *bbolt.Bucket) (mids [][]byte, err error) {
+	return mids, mktBkt.Bucket(activeMatchesBucket).ForEach(func(mid, _ []byte) error {
+		mids = append(mids, mid)
+		return nil
+	})
+}
+
+// userMatchIDs lists the IDs of the user's matches in the user matches index.
+func userMatchIDs(mktBkt *bbolt.Bucket, aid account.AccountID) (mids [][]byte, err error) {
+	return mids, forEachUserID(mktBkt.Bucket(userMatchesBucket), aid, func(mid []byte) error {
+		mids = append(mids, mid)
+		return nil
+	})
+}
+
+// userMatches loads the user's matches in the market, optionally only the
+// active ones.
+func userMatches(mktBkt *bbolt.Bucket, aid account.AccountID, activeOnly bool) ([]*db.MatchData, error) {
+	mids, err := userMatchIDs(mktBkt, aid)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*db.MatchData
+	return ms, forEachIndexedMatch(mktBkt, mids, func(m *matchRecord) error {
+		if !activeOnly || m.Active {
+			md := m.MatchData
+			ms = append(ms, &md)
+		}
+		return nil
+	})
+}
+
+// isParty checks if the account is the maker or taker of the match.
+func (m *matchRecord) isParty(aid account.AccountID) bool {
+	return m.MakerAcct == aid || m.TakerAcct == aid
+}
+
+// atFault checks if the match is an unforgiven failed swap where the account
+// was the party that failed to act.
+func (m *matchRecord) atFault(aid account.AccountID) bool {
+	if m.cancel || m.Active || m.forgiven {
+		return false
+	}
+	switch m.Status {
+	case order.NewlyMatched, order.TakerSwapCast: // fault for maker
+		return m.MakerAcct == aid
+	case order.MakerSwapCast, order.MakerRedeemed: // fault for taker
+		return m.TakerAcct == aid
+	}
+	return false
+}
+
+// success checks if the swap was successfully completed by the account, which
+// is at MakerRedeemed for the maker, and MatchComplete for both.
+func (m *matchRecord) success(aid account.AccountID) bool {
+	return m.Status == order.MatchComplete ||
+		(m.Status == order.MakerRedeemed && m.MakerAcct == aid && m.TakerAcct != aid)
+}
+
+// lastTime is the time of the last swap action, or the end of the match's
+// epoch if there were none.
+func (m *matchRecord) lastTime() int64 {
+	return max(int64(m.Epoch.Idx+1)*int64(m.Epoch.Dur), m.ContractATime,
+		m.ContractBTime, m.RedeemATime, m.RedeemBTime)
+}
+
+// InsertMatch stores a new match, or updates the quantity and status of an
+// existing match.
+func (a *Archiver) InsertMatch(match *order.Match) error {
+	var takerAddr string
+	tt := match.Taker.Trade()
+	if tt != nil {
+		takerAddr = tt.SwapAddress()
+	}
+
+	mid := match.ID()
+	return a.marketUpdate(match.Maker.Base(), match.Maker.Quote(), func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		m, err := getMatch(mktBkt, mid[:])
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			if takerAddr == "" {
+				return newUpdateErr("cancel order match %v already stored", mid)
+			}
+			m.Quantity = match.Quantity
+			m.Status = match.Status
+			return putMatch(mktBkt, m)
+		}
+
+		m = &matchRecord{
+			MatchData: db.MatchData{
+				ID:        mid,
+				Taker:     match.Taker.ID(),
+				TakerAcct: match.Taker.User(),
+				Maker:     match.Maker.ID(),
+				MakerAcct: match.Maker.User(),
+				Epoch:     match.Epoch,
+				Quantity:  match.Quantity,
+				Rate:      match.Rate,
+			},
+		}
+		// Cancel orders do not store taker or maker addresses, and are stored
+		// with complete status with no active swap negotiation.
+		if takerAddr == "" {
+			m.cancel = true
+			m.Status = order.MatchComplete
+		} else {
+			m.TakerSell = tt.Sell
+			m.TakerAddr = takerAddr
+			m.MakerAddr = match.Maker.Trade().SwapAddress()
+			m.BaseRate = match.FeeRateBase
+			m.QuoteRate = match.FeeRateQuote
+			m.Status = match.Status
+			m.Active = true
+		}
+
+		userIdx := mktBkt.Bucket(userMatchesBucket)
+		for _, aid := range []account.AccountID{m.TakerAcct, m.MakerAcct} {
+			if err := userIdx.Put(userKey(aid, mid[:]), []byte{}); err != nil {
+				return err
+			}
+		}
+		return putMatch(mktBkt, m)
+	})
+}
+
+// MatchByID retrieves the match for the given MatchID.
+func (a *Archiver) MatchByID(mid order.MatchID, base, quote uint32) (*db.MatchData, error) {
+	var md *db.MatchData
+	return md, a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		m, err := getMatch(mktBkt, mid[:])
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return db.ArchiveError{Code: db.ErrUnknownMatch}
+		}
+		md = &m.MatchData
+		return nil
+	})
+}
+
+// UserMatches retrieves all matches involving a user on the given market.
+func (a *Archiver) UserMatches(aid account.AccountID, base, quote uint32) ([]*db.MatchData, error) {
+	var ms []*db.MatchData
+	return ms, a.marketView(base, quote, func(mktBkt *bbolt.Bucket) (err error) {
+		ms, err = userMatches(mktBkt, aid, false)
+		return err
+	})
+}
+
+// AllActiveUserMatches retrieves a MatchData slice for active matches in all
+// markets involving the given user. Swaps that have successfully completed or
+// failed are not included.
+func (a *Archiver) AllActiveUserMatches(aid account.AccountID) ([]*db.MatchData, error) {
+	var matches []*db.MatchData
+	return matches, a.eachMarket(func(_ *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		mdM, err := userMatches(mktBkt, aid, true)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, mdM...)
+		return nil
+	})
+}
+
+// CompletedAndAtFaultMatchStats retrieves the outcomes of matches that were (1)
+// successfully completed by the specified user, or (2) failed with the user
+// being the at-fault party. Note that the MakerRedeemed match status may be
+// either a success or failure depending on if the user was the maker or taker
+// in the swap, respectively, and the MatchOutcome.Fail flag disambiguates this.
+func (a *Archiver) CompletedAndAtFaultMatchStats(aid account.AccountID, lastN int) ([]*db.MatchOutcome, error) {
+	var outcomes []*db.MatchOutcome
+	err := a.eachMarket(func(mkt *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		mids, err := userMatchIDs(mktBkt, aid)
+		if err != nil {
+			return err
+		}
+		return forEachIndexedMatch(mktBkt, mids, func(m *matchRecord) error {
+			if m.cancel {
+				return nil
+			}
+			success := m.success(aid)
+			if !success && !m.atFault(aid) {
+				return nil
+			}
+			outcomes = append(outcomes, &db.MatchOutcome{
+				Status: m.Status,
+				ID:     m.ID,
+				Fail:   !success,
+				Time:   m.lastTime(),
+				Value:  m.Quantity,
+				Base:   mkt.Base,
+				Quote:  mkt.Quote,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(outcomes, func(i, j int) bool {
+		return outcomes[j].Time < outcomes[i].Time // descending
+	})
+	if len(outcomes) > lastN {
+		outcomes = outcomes[:lastN]
+	}
+	return outcomes, nil
+}
+
+// UserMatchFails retrieves up to the last n most recent failed and unforgiven
+// match outcomes for the user.
+func (a *Archiver) UserMatchFails(aid account.AccountID, lastN int) ([]*db.MatchFail, error) {
+	var fails []*db.MatchFail
+	err := a.eachMarket(func(_ *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		mids, err := userMatchIDs(mktBkt, aid)
+		if err != nil {
+			return err
+		}
+		var marketFails []*matchRecord
+		err = forEachIndexedMatch(mktBkt, mids, func(m *matchRecord) error {
+			if m.atFault(aid) {
+				marketFails = append(marketFails, m)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sort.Slice(marketFails, func(i, j int) bool {
+			return marketFails[j].lastTime() < marketFails[i].lastTime() // descending
+		})
+		if len(marketFails) > lastN {
+			marketFails = marketFails[:lastN]
+		}
+		for _, m := range marketFails {
+			fails = append(fails, &db.MatchFail{
+				Status: m.Status,
+				ID:     m.ID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fails) > lastN {
+		fails = fails[:lastN]
+	}
+	return fails, nil
+}
+
+// ForgiveMatchFail marks the specified match as forgiven. Since this is an
+// administrative function, the burden is on the operator to ensure the match
+// can actually be forgiven (inactive, not already forgiven, and not in
+// MatchComplete status).
+func (a *Archiver) ForgiveMatchFail(mid order.MatchID) (bool, error) {
+	var forgiven bool
+	err := a.update(func(tx *bbolt.Tx) error {
+		for name := range a.marketMap() {
+			mktBkt, err := marketBucket(tx, name)
+			if err != nil {
+				return err
+			}
+			m, err := getMatch(mktBkt, mid[:])
+			if err != nil {
+				return err
+			}
+			if m == nil || m.Active {
+				continue // not eligible to forgive, but just keep going
+			}
+			m.forgiven = true
+			forgiven = true
+			return putMatch(mktBkt, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return forgiven, nil
+}
+
+func (a *Archiver) marketMatches(base, quote uint32, includeInactive bool, N int64, f func(*db.MatchDataWithCoins) error) (int, error) {
+	var ms []*matchRecord
+	err := a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		add := func(m *matchRecord) error {
+			if !m.cancel {
+				ms = append(ms, m)
+			}
+			return nil
+		}
+		if includeInactive {
+			return forEachMatch(mktBkt, add)
+		}
+		mids, err := activeMatchIDs(mktBkt)
+		if err != nil {
+			return err
+		}
+		return forEachIndexedMatch(mktBkt, mids, add)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sort.SliceStable(ms, func(i, j int) bool {
+		return ms[i].Epoch.Idx*ms[i].Epoch.Dur > ms[j].Epoch.Idx*ms[j].Epoch.Dur
+	})
+	if includeInactive && N > 0 && int64(len(ms)) > N {
+		ms = ms[:N]
+	}
+
+	var n int
+	for _, m := range ms {
+		err := f(&db.MatchDataWithCoins{
+			MatchData:       m.MatchData,
+			MakerSwapCoin:   m.ContractACoinID,
+			MakerRedeemCoin: m.RedeemACoinID,
+			TakerSwapCoin:   m.ContractBCoinID,
+			TakerRedeemCoin: m.RedeemBCoinID,
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// MarketMatches retrieves all active matches for a market.
+func (a *Archiver) MarketMatches(base, quote uint32) ([]*db.MatchDataWithCoins, error) {
+	var ms []*db.MatchDataWithCoins
+	f := func(m *db.MatchDataWithCoins) error {
+		ms = append(ms, m)
+		return nil
+	}
+	_, err := a.marketMatches(base, quote, false, -1, f) // N ignored with only active
+	if err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// MarketMatchesStreaming streams all active matches for a market into the
+// provided function. If includeInactive, all matches are streamed. A limit may
+// be specified, where <=0 means unlimited.
+func (a *Archiver) MarketMatchesStreaming(base, quote uint32, includeInactive bool, N int64, f func(*db.MatchDataWithCoins) error) (int, error) {
+	return a.marketMatches(base, quote, includeInactive, N, f)
+}
+
+// MarketTrades retrieves up to n of a market's most recent trade order matches
+// from epochs that ended before the time stamp (ms), newest first. A limit
+// <= 0 means unlimited.
+func (a *Archiver) MarketTrades(base, quote uint32, before uint64, n int) ([]*db.Trade, error) {
+	var trades []*db.Trade
+	err := a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		// Walk the trade matches index back from the first key at or after
+		// the before stamp.
+		c := mktBkt.Bucket(tradeMatchesBucket).Cursor()
+		k, _ := c.Seek(uint64Bytes(before))
+		if k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+		for ; k != nil && (n <= 0 || len(trades) < n); k, _ = c.Prev() {
+			m, err := getMatch(mktBkt, k[8:])
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				return fmt.Errorf("indexed match %x not found", k[8:])
+			}
+			trades = append(trades, &db.Trade{
+				Epoch:     m.Epoch,
+				TakerSell: m.TakerSell,
+				Quantity:  m.Quantity,
+				Rate:      m.Rate,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// MatchStatuses retrieves a *db.MatchStatus for every match in matchIDs for
+// which there is data, and for which the user is at least one of the parties.
+// It is not an error if a match ID in matchIDs does not match, i.e. the
+// returned slice need not be the same length as matchIDs.
+func (a *Archiver) MatchStatuses(aid account.AccountID, base, quote uint32, matchIDs []order.MatchID) ([]*db.MatchStatus, error) {
+	statuses := make([]*db.MatchStatus, 0, len(matchIDs))
+	return statuses, a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		for i := range matchIDs {
+			m, err := getMatch(mktBkt, matchIDs[i][:])
+			if err != nil {
+				return err
+			}
+			if m == nil || !m.isParty(aid) {
+				continue
+			}
+			statuses = append(statuses, &db.MatchStatus{
+				ID:            m.ID,
+				Status:        m.Status,
+				MakerContract: m.ContractA,
+				TakerContract: m.ContractB,
+				MakerSwap:     m.ContractACoinID,
+				TakerSwap:     m.ContractBCoinID,
+				MakerRedeem:   m.RedeemACoinID,
+				TakerRedeem:   m.RedeemBCoinID,
+				Secret:        m.RedeemASecret,
+				Active:        m.Active,
+				TakerSell:     m.TakerSell,
+				IsTaker:       m.TakerAcct == aid,
+				IsMaker:       m.MakerAcct == aid,
+			})
+		}
+		return nil
+	})
+}
+
+// Swap Data
+//
+// In the swap process, the counterparties are:
+// - Initiator or party A on chain X. This is the maker in the DEX.
+// - Participant or party B on chain Y. This is the taker in the DEX.
+//
+// The methods for saving this data are defined below in the order in which the
+// data is expected from the parties.
+
+// ActiveSwaps loads the full details for all active swaps across all markets.
+func (a *Archiver) ActiveSwaps() ([]*db.SwapDataFull, error) {
+	var sd []*db.SwapDataFull
+	return sd, a.eachMarket(func(mkt *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		mids, err := activeMatchIDs(mktBkt)
+		if err != nil {
+			return err
+		}
+		return forEachIndexedMatch(mktBkt, mids, func(m *matchRecord) error {
+			if m.cancel {
+				return nil
+			}
+			sd = append(sd, &db.SwapDataFull{
+				Base:      mkt.Base,
+				Quote:     mkt.Quote,
+				MatchData: &m.MatchData,
+				SwapData:  &m.SwapData,
+			})
+			return nil
+		})
+	})
+}
+
+// SwapData retrieves the match status and all the SwapData for a match.
+func (a *Archiver) SwapData(mid db.MarketMatchID) (order.MatchStatus, *db.SwapData, error) {
+	var m *matchRecord
+	err := a.marketView(mid.Base, mid.Quote, func(mktBkt *bbolt.Bucket) (err error) {
+		m, err = getMatch(mktBkt, mid.MatchID[:])
+		if err == nil && m == nil {
+			err = fmt.Errorf("match %v not found", mid)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return m.Status, &m.SwapData, nil
+}
+
+// updateMatch modifies a stored match with the provided function, choosing the
+// market's bucket from the MarketMatchID. The match must exist, otherwise an
+// error is returned.
+func (a *Archiver) updateMatch(mid db.MarketMatchID, f func(m *matchRecord)) error {
+	return a.marketUpdate(mid.Base, mid.Quote, func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		m, err := getMatch(mktBkt, mid.MatchID[:])
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return newUpdateErr("updateMatch: match %v not found", mid)
+		}
+		f(m)
+		return putMatch(mktBkt, m)
+	})
+}
+
+// Match acknowledgement message signatures.
+
+// SaveMatchAckSigA records the match data acknowledgement signature from swap
+// party A (the initiator), which is the maker in the DEX.
+func (a *Archiver) SaveMatchAckSigA(mid db.MarketMatchID, sig []byte) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.SigMatchAckMaker = sig
+	})
+}
+
+// SaveMatchAckSigB records the match data acknowledgement signature from swap
+// party B (the participant), which is the taker in the DEX.
+func (a *Archiver) SaveMatchAckSigB(mid db.MarketMatchID, sig []byte) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.SigMatchAckTaker = sig
+	})
+}
+
+// Swap contracts, and counterparty audit acknowledgement signatures.
+
+// SaveContractA records party A's swap contract script and the coinID (e.g.
+// transaction output) containing the contract on chain X. Note that this
+// contract contains the secret hash.
+func (a *Archiver) SaveContractA(mid db.MarketMatchID, contract []byte, coinID []byte, timestamp int64) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.Status = order.MakerSwapCast
+		m.ContractACoinID, m.ContractA, m.ContractATime = coinID, contract, timestamp
+	})
+}
+
+// SaveAuditAckSigB records party B's signature acknowledging their audit of A's
+// swap contract.
+func (a *Archiver) SaveAuditAckSigB(mid db.MarketMatchID, sig []byte) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.ContractAAckSig = sig
+	})
+}
+
+// SaveContractB records party B's swap contract script and the coinID (e.g.
+// transaction output) containing the contract on chain Y.
+func (a *Archiver) SaveContractB(mid db.MarketMatchID, contract []byte, coinID []byte, timestamp int64) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.Status = order.TakerSwapCast
+		m.ContractBCoinID, m.ContractB, m.ContractBTime = coinID, contract, timestamp
+	})
+}
+
+// SaveAuditAckSigA records party A's signature acknowledging their audit of B's
+// swap contract.
+func (a *Archiver) SaveAuditAckSigA(mid db.MarketMatchID, sig []byte) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.ContractBAckSig = sig
+	})
+}
+
+// Redemption transactions, and counterparty acknowledgement signatures.
+
+// SaveRedeemA records party A's redemption coinID (e.g. transaction output),
+// which spends party B's swap contract on chain Y, and the secret revealed by
+// the signature script of the input spending the contract.
+func (a *Archiver) SaveRedeemA(mid db.MarketMatchID, coinID, secret []byte, timestamp int64) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.Status = order.MakerRedeemed
+		m.RedeemACoinID, m.RedeemASecret, m.RedeemATime = coinID, secret, timestamp
+	})
+}
+
+// SaveRedeemAckSigB records party B's signature acknowledging party A's
+// redemption, which spent their swap contract on chain Y and revealed the
+// secret.
+func (a *Archiver) SaveRedeemAckSigB(mid db.MarketMatchID, sig []byte) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.RedeemAAckSig = sig
+	})
+}
+
+// SaveRedeemB records party B's redemption coinID (e.g. transaction output),
+// which spends party A's swap contract on chain X. The match is complete and
+// flagged as inactive.
+func (a *Archiver) SaveRedeemB(mid db.MarketMatchID, coinID []byte, timestamp int64) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.Status = order.MatchComplete
+		m.RedeemBCoinID, m.RedeemBTime = coinID, timestamp
+		m.Active = false
+	})
+}
+
+// SetMatchInactive flags the match as done/inactive. This is not necessary if
+// SaveRedeemB is run for the match since it will flag the match as done.
+func (a *Archiver) SetMatchInactive(mid db.MarketMatchID, forgive bool) error {
+	return a.updateMatch(mid, func(m *matchRecord) {
+		m.Active = false
+		if forgive {
+			m.forgiven = true
+		}
+	})
+}
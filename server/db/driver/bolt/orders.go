@@ -0,0 +1,831 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/account"
+	"decred.org/dcrdex/server/db"
+	"go.etcd.io/bbolt"
+)
+
+// userKey is the key for the user indexes of orders and matches.
+func userKey(aid account.AccountID, id []byte) []byte {
+	return append(aid[:len(aid):len(aid)], id...)
+}
+
+// forEachUserID runs the function for the order or match IDs of a user index
+// bucket for the specified account.
+func forEachUserID(bkt *bbolt.Bucket, aid account.AccountID, f func(id []byte) error) error {
+	c := bkt.Cursor()
+	for k, _ := c.Seek(aid[:]); k != nil && bytes.HasPrefix(k, aid[:]); k, _ = c.Next() {
+		if err := f(k[len(aid):]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOrder loads the order from the market bucket. A nil orderRecord is
+// returned if the order is not found.
+func getOrder(mktBkt *bbolt.Bucket, oid []byte) (*orderRecord, error) {
+	b := mktBkt.Bucket(ordersBucket).Get(oid)
+	if b == nil {
+		return nil, nil
+	}
+	return decodeOrderRecord(b)
+}
+
+// mustGetOrder is like getOrder, but ErrUnknownOrder is returned for an order
+// that is not found.
+func mustGetOrder(mktBkt *bbolt.Bucket, oid order.OrderID) (*orderRecord, error) {
+	r, err := getOrder(mktBkt, oid[:])
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, db.ArchiveError{Code: db.ErrUnknownOrder}
+	}
+	return r, nil
+}
+
+// putOrder stores the order and updates the active orders index.
+func putOrder(mktBkt *bbolt.Bucket, r *orderRecord) error {
+	oid := r.ord.ID()
+	if err := mktBkt.Bucket(ordersBucket).Put(oid[:], r.encode()); err != nil {
+		return err
+	}
+	if r.status.active() {
+		return mktBkt.Bucket(activeOrdersBucket).Put(oid[:], []byte{})
+	}
+	return mktBkt.Bucket(activeOrdersBucket).Delete(oid[:])
+}
+
+// insertOrder stores a new order, and indexes it by user and commitment. If
+// checkCommit is true, ErrReusedCommit is returned if the commitment is already
+// used by any order in any market.
+func insertOrder(tx *bbolt.Tx, mktBkt *bbolt.Bucket, r *orderRecord, checkCommit bool) error {
+	oid := r.ord.ID()
+	if mktBkt.Bucket(ordersBucket).Get(oid[:]) != nil {
+		return newUpdateErr("order %v already stored", oid)
+	}
+	commits := tx.Bucket(commitsBucket)
+	commit := r.ord.Commitment()
+	if !commit.IsZero() { // server-generated cancel orders have no commitment
+		if prevOid := commits.Get(commit[:]); prevOid != nil {
+			if checkCommit {
+				return db.ArchiveError{
+					Code: db.ErrReusedCommit,
+					Detail: fmt.Sprintf("order %v reuses commit %v from previous order %x",
+						r.ord.UID(), commit, prevOid),
+				}
+			}
+		} else if err := commits.Put(commit[:], oid[:]); err != nil {
+			return err
+		}
+	}
+	if err := mktBkt.Bucket(userOrdersBucket).Put(userKey(r.ord.User(), oid[:]), []byte{}); err != nil {
+		return err
+	}
+	return putOrder(mktBkt, r)
+}
+
+// activeOrders loads the orders in the active orders index with the given
+// status.
+func activeOrders(mktBkt *bbolt.Bucket, status orderStatus) ([]*orderRecord, error) {
+	var ords []*orderRecord
+	return ords, mktBkt.Bucket(activeOrdersBucket).ForEach(func(oid, _ []byte) error {
+		r, err := getOrder(mktBkt, oid)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return fmt.Errorf("indexed active order %x not found", oid)
+		}
+		if r.status == status {
+			ords = append(ords, r)
+		}
+		return nil
+	})
+}
+
+// userOrders loads all of the user's orders in the market.
+func userOrders(mktBkt *bbolt.Bucket, aid account.AccountID) ([]*orderRecord, error) {
+	var ords []*orderRecord
+	return ords, forEachUserID(mktBkt.Bucket(userOrdersBucket), aid, func(oid []byte) error {
+		r, err := getOrder(mktBkt, oid)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return fmt.Errorf("indexed user order %x not found", oid)
+		}
+		ords = append(ords, r)
+		return nil
+	})
+}
+
+// Order retrieves an order with the given OrderID, stored for the market
+// specified by the given base and quote assets. A non-nil error will be
+// returned if the market is not recognized. If the order is not found, the
+// error value is ErrUnknownOrder, and the type is order.OrderStatusUnknown.
+func (a *Archiver) Order(oid order.OrderID, base, quote uint32) (order.Order, order.OrderStatus, error) {
+	var r *orderRecord
+	err := a.marketView(base, quote, func(mktBkt *bbolt.Bucket) (err error) {
+		r, err = mustGetOrder(mktBkt, oid)
+		return err
+	})
+	if err != nil {
+		return nil, order.OrderStatusUnknown, err
+	}
+	prefix := r.ord.Prefix()
+	prefix.BaseAsset, prefix.QuoteAsset = base, quote
+	return r.ord, r.status.market(), nil
+}
+
+// NewEpochOrder stores the given order with epoch status. The epoch gap is
+// only meaningful for cancel orders.
+func (a *Archiver) NewEpochOrder(ord order.Order, epochIdx, epochDur int64, epochGap int32) error {
+	return a.storeOrder(ord, epochIdx, epochDur, epochGap, orderStatusEpoch)
+}
+
+// NewEpochAmend stores the cancel and replacement orders of an amend with
+// epoch status in a single transaction. The cancel order records the
+// replacement order ID.
+func (a *Archiver) NewEpochAmend(co *order.CancelOrder, lo *order.LimitOrder, epochIdx, epochDur int64, epochGap int32) error {
+	marketName, err := a.marketName(lo.Base(), lo.Quote())
+	if err != nil {
+		return err
+	}
+
+	status := orderStatusEpoch
+	for _, ord := range []order.Order{co, lo} {
+		if !validateOrder(ord, status, a.marketMap()[marketName]) {
+			return db.ArchiveError{
+				Code: db.ErrInvalidOrder,
+				Detail: fmt.Sprintf("invalid order %v for status %v and market %v",
+					ord.UID(), status, a.marketMap()[marketName]),
+			}
+		}
+	}
+
+	loID := lo.ID()
+	return a.marketUpdate(lo.Base(), lo.Quote(), func(tx *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		err := insertOrder(tx, mktBkt, &orderRecord{
+			ord:         co,
+			status:      status,
+			epochIdx:    epochIdx,
+			epochDur:    epochDur,
+			epochGap:    epochGap,
+			replacement: loID[:],
+		}, true)
+		if err != nil {
+			return err
+		}
+		return insertOrder(tx, mktBkt, &orderRecord{
+			ord:      lo,
+			status:   status,
+			epochIdx: epochIdx,
+			epochDur: epochDur,
+			epochGap: db.EpochGapNA,
+		}, true)
+	})
+}
+
+// NewArchivedCancel stores a cancel order directly in the executed state. This
+// is used for orders that are canceled when the market is suspended, and
+// therefore do not need to be matched.
+func (a *Archiver) NewArchivedCancel(ord *order.CancelOrder, epochID, epochDur int64) error {
+	return a.marketUpdate(ord.Base(), ord.Quote(), func(tx *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		return insertOrder(tx, mktBkt, &orderRecord{
+			ord:      ord,
+			status:   orderStatusExecuted,
+			epochIdx: epochID,
+			epochDur: epochDur,
+			epochGap: db.EpochGapNA,
+		}, false)
+	})
+}
+
+func makePseudoCancel(target order.OrderID, user account.AccountID, base, quote uint32, timeStamp time.Time) *order.CancelOrder {
+	// Create a server-generated cancel order to record the server's revoke
+	// order action. The Commitment is the zero value.
+	return &order.CancelOrder{
+		P: order.Prefix{
+			AccountID:  user,
+			BaseAsset:  base,
+			QuoteAsset: quote,
+			OrderType:  order.CancelOrderType,
+			ClientTime: timeStamp,
+			ServerTime: timeStamp,
+		},
+		TargetOrderID: target,
+	}
+}
+
+// FlushBook revokes all booked orders for a market.
+func (a *Archiver) FlushBook(base, quote uint32) (sellsRemoved, buysRemoved []order.OrderID, err error) {
+	timeStamp := time.Now().Truncate(time.Millisecond).UTC()
+	err = a.marketUpdate(base, quote, func(tx *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		booked, err := activeOrders(mktBkt, orderStatusBooked)
+		if err != nil {
+			return err
+		}
+		for _, r := range booked {
+			r.status = orderStatusRevoked
+			if err = putOrder(mktBkt, r); err != nil {
+				return err
+			}
+			oid := r.ord.ID()
+			if r.ord.Trade().Sell {
+				sellsRemoved = append(sellsRemoved, oid)
+			} else {
+				buysRemoved = append(buysRemoved, oid)
+			}
+			// The pseudo-cancel order is exempt, consistent with
+			// RevokeOrderUncounted.
+			err = insertOrder(tx, mktBkt, &orderRecord{
+				ord:      makePseudoCancel(oid, r.ord.User(), base, quote, timeStamp),
+				status:   orderStatusRevoked,
+				epochIdx: exemptEpochIdx,
+				epochDur: dummyEpochDur,
+				epochGap: db.EpochGapNA,
+			}, false)
+			if err != nil {
+				return fmt.Errorf("failed to store pseudo-cancel order: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// BookOrders retrieves all booked orders (with order status booked) for the
+// specified market.
+func (a *Archiver) BookOrders(base, quote uint32) ([]*order.LimitOrder, error) {
+	var los []*order.LimitOrder
+	return los, a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		booked, err := activeOrders(mktBkt, orderStatusBooked)
+		if err != nil {
+			return err
+		}
+		for _, r := range booked {
+			lo, ok := r.ord.(*order.LimitOrder)
+			if !ok {
+				return fmt.Errorf("booked order %v is not a limit order", r.ord.ID())
+			}
+			los = append(los, lo)
+		}
+		return nil
+	})
+}
+
+// EpochOrders retrieves all epoch status orders for the specified market.
+func (a *Archiver) EpochOrders(base, quote uint32) ([]order.Order, error) {
+	var ords []order.Order
+	return ords, a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		epochOrds, err := activeOrders(mktBkt, orderStatusEpoch)
+		if err != nil {
+			return err
+		}
+		for _, r := range epochOrds {
+			ords = append(ords, r.ord)
+		}
+		return nil
+	})
+}
+
+// ActiveOrderCoins retrieves a CoinID slice for each active order.
+func (a *Archiver) ActiveOrderCoins(base, quote uint32) (baseCoins, quoteCoins map[order.OrderID][]order.CoinID, err error) {
+	baseCoins = make(map[order.OrderID][]order.CoinID)
+	quoteCoins = make(map[order.OrderID][]order.CoinID)
+	err = a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		return mktBkt.Bucket(activeOrdersBucket).ForEach(func(oidB, _ []byte) error {
+			r, err := getOrder(mktBkt, oidB)
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				return fmt.Errorf("indexed active order %x not found", oidB)
+			}
+			trade := r.ord.Trade()
+			if trade == nil {
+				return nil // cancel order
+			}
+			// Sell orders lock base asset coins.
+			if trade.Sell {
+				baseCoins[r.ord.ID()] = trade.Coins
+			} else {
+				// Buy orders lock quote asset coins.
+				quoteCoins[r.ord.ID()] = trade.Coins
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+// BookOrder updates the given LimitOrder with booked status.
+func (a *Archiver) BookOrder(lo *order.LimitOrder) error {
+	return a.updateOrderStatus(lo, orderStatusBooked)
+}
+
+// ExecuteOrder updates the given Order with executed status.
+func (a *Archiver) ExecuteOrder(ord order.Order) error {
+	return a.updateOrderStatus(ord, orderStatusExecuted)
+}
+
+// CancelOrder updates a LimitOrder with canceled status. If the order does not
+// exist in the Archiver, CancelOrder returns ErrUnknownOrder.
+func (a *Archiver) CancelOrder(lo *order.LimitOrder) error {
+	return a.updateOrderStatus(lo, orderStatusCanceled)
+}
+
+// RevokeOrder updates an Order with revoked status, which is used for
+// DEX-revoked orders rather than orders matched with a user's CancelOrder. If
+// the order does not exist in the Archiver, RevokeOrder returns
+// ErrUnknownOrder.
+func (a *Archiver) RevokeOrder(ord order.Order) (cancelID order.OrderID, timeStamp time.Time, err error) {
+	return a.revokeOrder(ord, false)
+}
+
+// RevokeOrderUncounted is like RevokeOrder except that the generated cancel
+// order will not be counted against the user. i.e. ExecutedCancelsForUser
+// should not return the cancel orders created this way.
+func (a *Archiver) RevokeOrderUncounted(ord order.Order) (cancelID order.OrderID, timeStamp time.Time, err error) {
+	return a.revokeOrder(ord, true)
+}
+
+func (a *Archiver) revokeOrder(ord order.Order, exempt bool) (cancelID order.OrderID, timeStamp time.Time, err error) {
+	timeStamp = time.Now().Truncate(time.Millisecond).UTC()
+	co := makePseudoCancel(ord.ID(), ord.User(), ord.Base(), ord.Quote(), timeStamp)
+	epochIdx := countedEpochIdx
+	if exempt {
+		epochIdx = exemptEpochIdx
+	}
+	// Revoke the targeted order, and store the pseudo-cancel order with
+	// status orderStatusRevoked.
+	err = a.marketUpdate(ord.Base(), ord.Quote(), func(tx *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		if err := setOrderStatus(mktBkt, ord.ID(), orderStatusRevoked, filledAmt(ord)); err != nil {
+			return err
+		}
+		return insertOrder(tx, mktBkt, &orderRecord{
+			ord:      co,
+			status:   orderStatusRevoked,
+			epochIdx: epochIdx,
+			epochDur: dummyEpochDur,
+			epochGap: db.EpochGapNA,
+		}, false)
+	})
+	if err != nil {
+		return order.OrderID{}, time.Time{}, err
+	}
+	return co.ID(), timeStamp, nil
+}
+
+// FailCancelOrder updates the given CancelOrder with failed status. To update a
+// CancelOrder with executed status, use ExecuteOrder.
+func (a *Archiver) FailCancelOrder(co *order.CancelOrder) error {
+	return a.updateOrderStatus(co, orderStatusFailed)
+}
+
+func validateOrder(ord order.Order, status orderStatus, mkt *dex.MarketInfo) bool {
+	if status == orderStatusFailed && ord.Type() != order.CancelOrderType {
+		return false
+	}
+	return db.ValidateOrder(ord, status.market(), mkt)
+}
+
+func (a *Archiver) storeOrder(ord order.Order, epochIdx, epochDur int64, epochGap int32, status orderStatus) error {
+	marketName, err := a.marketName(ord.Base(), ord.Quote())
+	if err != nil {
+		return err
+	}
+
+	if !validateOrder(ord, status, a.marketMap()[marketName]) {
+		return db.ArchiveError{
+			Code: db.ErrInvalidOrder,
+			Detail: fmt.Sprintf("invalid order %v for status %v and market %v",
+				ord.UID(), status, a.marketMap()[marketName]),
+		}
+	}
+
+	return a.marketUpdate(ord.Base(), ord.Quote(), func(tx *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		return insertOrder(tx, mktBkt, &orderRecord{
+			ord:      ord,
+			status:   status,
+			epochIdx: epochIdx,
+			epochDur: epochDur,
+			epochGap: epochGap,
+		}, true)
+	})
+}
+
+// StorePreimage stores the preimage associated with an existing order.
+func (a *Archiver) StorePreimage(ord order.Order, pi order.Preimage) error {
+	return a.marketUpdate(ord.Base(), ord.Quote(), func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		r, err := mustGetOrder(mktBkt, ord.ID())
+		if err != nil {
+			return err
+		}
+		// Preimages are stored during epoch processing, so the order should
+		// still be active.
+		if !r.status.active() {
+			log.Warnf("Attempting to set preimage for archived order %v", ord.UID())
+		}
+		r.preimage = pi[:]
+		return putOrder(mktBkt, r)
+	})
+}
+
+// SetOrderCompleteTime sets the successful swap completion time for an existing
+// order. It is an error if the order is not in executed status.
+func (a *Archiver) SetOrderCompleteTime(ord order.Order, compTimeMs int64) error {
+	return a.marketUpdate(ord.Base(), ord.Quote(), func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		r, err := mustGetOrder(mktBkt, ord.ID())
+		if err != nil {
+			return err
+		}
+		if r.status != orderStatusExecuted { // complete time is only set for executed orders, not canceled or revoked
+			log.Warnf("Attempting to set swap completion time for order %v in status %v, not executed",
+				ord.UID(), r.status)
+			return db.ArchiveError{
+				Code: db.ErrOrderNotExecuted,
+				Detail: fmt.Sprintf("unable to set completed time for order %v in status %v, not executed",
+					ord.UID(), r.status),
+			}
+		}
+		r.completeTime = compTimeMs
+		return putOrder(mktBkt, r)
+	})
+}
+
+// OrderStatus gets the status, type, and filled amount of the given order. The
+// filled amount is -1 for cancel orders. If the order is not found, the error
+// value is ErrUnknownOrder, and the status is order.OrderStatusUnknown.
+func (a *Archiver) OrderStatus(ord order.Order) (order.OrderStatus, order.OrderType, int64, error) {
+	var r *orderRecord
+	err := a.marketView(ord.Base(), ord.Quote(), func(mktBkt *bbolt.Bucket) (err error) {
+		r, err = mustGetOrder(mktBkt, ord.ID())
+		return err
+	})
+	if err != nil {
+		return order.OrderStatusUnknown, order.UnknownOrderType, -1, err
+	}
+	return r.status.market(), r.ord.Type(), r.filled(), nil
+}
+
+// filledAmt is the filled amount of a trade order, or zero for a cancel order.
+func filledAmt(ord order.Order) int64 {
+	if ord.Type() == order.CancelOrderType {
+		return 0
+	}
+	return int64(ord.Trade().Filled())
+}
+
+// setOrderStatus updates the status and filled amount of a stored order. If
+// filled is -1, the filled amount is unchanged. For cancel orders, the filled
+// amount is ignored. An archived order may not be made active.
+func setOrderStatus(mktBkt *bbolt.Bucket, oid order.OrderID, status orderStatus, filled int64) error {
+	r, err := mustGetOrder(mktBkt, oid)
+	if err != nil {
+		return err
+	}
+	initFilled := r.filled()
+	if r.status == status && filled == initFilled {
+		log.Tracef("Not updating order with no status or filled amount change: %v.", oid)
+		return nil
+	}
+	if filled == -1 {
+		filled = initFilled
+	}
+
+	if !r.status.active() {
+		if status.active() {
+			return newUpdateErr("Moving an order from an archived to active status: "+
+				"Order %s (%s -> %s)", oid, r.status, status)
+		}
+		log.Infof("Archived order is changing status: Order %s (%s -> %s)",
+			oid, r.status, status)
+	}
+
+	r.status = status
+	if trade := r.ord.Trade(); trade != nil {
+		trade.SetFill(uint64(filled))
+	}
+	return putOrder(mktBkt, r)
+}
+
+// UpdateOrderStatus updates the status and filled amount of the given order.
+// Both the market and new filled amount are determined from the Order.
+func (a *Archiver) UpdateOrderStatus(ord order.Order, status order.OrderStatus) error {
+	return a.updateOrderStatus(ord, marketToDBStatus(status))
+}
+
+func (a *Archiver) updateOrderStatus(ord order.Order, status orderStatus) error {
+	return a.marketUpdate(ord.Base(), ord.Quote(), func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		return setOrderStatus(mktBkt, ord.ID(), status, filledAmt(ord))
+	})
+}
+
+// UpdateOrderFilled updates the filled amount of the given order. This function
+// applies only to limit orders, not market or cancel orders. Market orders may
+// only be updated by ExecuteOrder since their filled amount only changes when
+// their status changes.
+func (a *Archiver) UpdateOrderFilled(lo *order.LimitOrder) error {
+	switch orderType := lo.Type(); orderType {
+	case order.MarketOrderType, order.LimitOrderType:
+	default:
+		return fmt.Errorf("cannot set filled amount for order type %v", orderType)
+	}
+	filled := lo.Trade().Filled()
+	return a.marketUpdate(lo.Base(), lo.Quote(), func(_ *bbolt.Tx, mktBkt *bbolt.Bucket) error {
+		r, err := mustGetOrder(mktBkt, lo.ID())
+		if err != nil {
+			return err
+		}
+		trade := r.ord.Trade()
+		if trade == nil {
+			return newUpdateErr("cannot set filled amount for order type %v", r.ord.Type())
+		}
+		if trade.Filled() == filled {
+			return nil // nothing to do
+		}
+		trade.SetFill(filled)
+		return putOrder(mktBkt, r)
+	})
+}
+
+// UserOrders retrieves all orders for the given account in the market specified
+// by a base and quote asset.
+func (a *Archiver) UserOrders(_ context.Context, aid account.AccountID, base, quote uint32) ([]order.Order, []order.OrderStatus, error) {
+	var ords []order.Order
+	var statuses []order.OrderStatus
+	err := a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		recs, err := userOrders(mktBkt, aid)
+		if err != nil {
+			return err
+		}
+		for _, r := range recs {
+			if r.ord.Type() == order.CancelOrderType {
+				continue
+			}
+			ords = append(ords, r.ord)
+			statuses = append(statuses, r.status.market())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ords, statuses, nil
+}
+
+// UserOrderStatuses retrieves the statuses and filled amounts of the orders
+// with the provided order IDs for the given account in the market specified
+// by a base and quote asset. If no order IDs are provided, the statuses of all
+// of the user's orders in the market are returned.
+// The number and ordering of the returned statuses is not necessarily the same
+// as the number and ordering of the provided order IDs. It is not an error if
+// any or all of the provided order IDs cannot be found for the given account
+// in the specified market.
+func (a *Archiver) UserOrderStatuses(aid account.AccountID, base, quote uint32, oids []order.OrderID) ([]*db.OrderStatus, error) {
+	statuses := make([]*db.OrderStatus, 0, len(oids))
+	return statuses, a.marketView(base, quote, func(mktBkt *bbolt.Bucket) error {
+		add := func(r *orderRecord) {
+			if r.ord.Type() != order.CancelOrderType {
+				statuses = append(statuses, &db.OrderStatus{
+					ID:     r.ord.ID(),
+					Status: r.status.market(),
+				})
+			}
+		}
+		if len(oids) == 0 {
+			recs, err := userOrders(mktBkt, aid)
+			if err != nil {
+				return err
+			}
+			for _, r := range recs {
+				add(r)
+			}
+			return nil
+		}
+		userIdx := mktBkt.Bucket(userOrdersBucket)
+		for _, oid := range oids {
+			if userIdx.Get(userKey(aid, oid[:])) == nil {
+				continue
+			}
+			r, err := mustGetOrder(mktBkt, oid)
+			if err != nil {
+				return err
+			}
+			add(r)
+		}
+		return nil
+	})
+}
+
+// ActiveUserOrderStatuses retrieves the statuses of all active orders for a
+// user across all markets.
+func (a *Archiver) ActiveUserOrderStatuses(aid account.AccountID) ([]*db.OrderStatus, error) {
+	var statuses []*db.OrderStatus
+	return statuses, a.eachMarket(func(_ *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		recs, err := userOrders(mktBkt, aid)
+		if err != nil {
+			return err
+		}
+		for _, r := range recs {
+			if r.status.active() && r.ord.Type() != order.CancelOrderType {
+				statuses = append(statuses, &db.OrderStatus{
+					ID:     r.ord.ID(),
+					Status: r.status.market(),
+				})
+			}
+		}
+		return nil
+	})
+}
+
+// CompletedUserOrders retrieves the N most recently completed orders for a user
+// across all markets.
+func (a *Archiver) CompletedUserOrders(aid account.AccountID, N int) (oids []order.OrderID, compTimes []int64, err error) {
+	var ords []*orderRecord
+	err = a.eachMarket(func(_ *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		recs, err := userOrders(mktBkt, aid)
+		if err != nil {
+			return err
+		}
+		for _, r := range recs {
+			if !r.status.active() && r.completeTime != 0 && r.ord.Type() != order.CancelOrderType {
+				ords = append(ords, r)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(ords, func(i, j int) bool {
+		return ords[i].completeTime > ords[j].completeTime // descending, latest completed order first
+	})
+	if N > len(ords) {
+		N = len(ords)
+	}
+	for _, r := range ords[:N] {
+		oids = append(oids, r.ord.ID())
+		compTimes = append(compTimes, r.completeTime)
+	}
+	return
+}
+
+// PreimageStats retrieves results of the N most recent preimage requests for
+// the user across all markets.
+func (a *Archiver) PreimageStats(user account.AccountID, lastN int) ([]*db.PreimageResult, error) {
+	var outcomes []*db.PreimageResult
+	err := a.eachMarket(func(_ *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		recs, err := userOrders(mktBkt, user)
+		if err != nil {
+			return err
+		}
+		for _, r := range recs {
+			// Only archived orders, excluding forgiven misses.
+			if r.status.active() || r.status < 0 {
+				continue
+			}
+			// Exclude server-generated cancels.
+			if r.ord.Type() == order.CancelOrderType && r.ord.Commitment() == (order.Commitment{}) {
+				continue
+			}
+			outcomes = append(outcomes, &db.PreimageResult{
+				Miss: r.preimage == nil && r.status == orderStatusRevoked,
+				Time: (r.epochIdx + 1) * r.epochDur, // when preimages are requested
+				ID:   r.ord.ID(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(outcomes, func(i, j int) bool {
+		return outcomes[j].Time < outcomes[i].Time // descending
+	})
+	if len(outcomes) > lastN {
+		outcomes = outcomes[:lastN]
+	}
+	return outcomes, nil
+}
+
+// ExecutedCancelsForUser retrieves up to N executed cancel orders for a given
+// user from each market. These may be user-initiated cancels, or cancels
+// created by the server (revokes).
+func (a *Archiver) ExecutedCancelsForUser(aid account.AccountID, N int) ([]*db.CancelRecord, error) {
+	var ords []*db.CancelRecord
+	err := a.eachMarket(func(_ *dex.MarketInfo, mktBkt *bbolt.Bucket) error {
+		recs, err := userOrders(mktBkt, aid)
+		if err != nil {
+			return err
+		}
+		epochs := mktBkt.Bucket(epochsBucket)
+		var executed, revoked []*db.CancelRecord
+		var revokedIdxs []int64
+		for _, r := range recs {
+			co, ok := r.ord.(*order.CancelOrder)
+			if !ok {
+				continue
+			}
+			switch {
+			case r.status == orderStatusExecuted && r.replacement == nil: // amends are not counted as cancels
+				// The cancel's match time is that of its epoch.
+				epochB := epochs.Get(epochKey(r.epochIdx, r.epochDur))
+				if epochB == nil {
+					continue
+				}
+				matchTime, err := epochMatchTime(epochB)
+				if err != nil {
+					return err
+				}
+				executed = append(executed, &db.CancelRecord{
+					ID:        co.ID(),
+					TargetID:  co.TargetOrderID,
+					MatchTime: matchTime,
+					EpochGap:  r.epochGap,
+				})
+			case r.status == orderStatusRevoked:
+				revoked = append(revoked, &db.CancelRecord{
+					ID:        co.ID(),
+					TargetID:  co.TargetOrderID,
+					MatchTime: co.ServerTime.UnixMilli(),
+					EpochGap:  db.EpochGapNA,
+				})
+				revokedIdxs = append(revokedIdxs, r.epochIdx)
+			}
+		}
+
+		sort.Slice(executed, func(i, j int) bool {
+			return executed[i].MatchTime > executed[j].MatchTime
+		})
+		if len(executed) > N {
+			executed = executed[:N]
+		}
+		ords = append(ords, executed...)
+
+		// Only include non-exempt/counted revokes, of the N most recent.
+		idxs := make([]int, len(revoked))
+		for i := range idxs {
+			idxs[i] = i
+		}
+		sort.Slice(idxs, func(i, j int) bool {
+			return revoked[idxs[i]].MatchTime > revoked[idxs[j]].MatchTime
+		})
+		if len(idxs) > N {
+			idxs = idxs[:N]
+		}
+		for _, i := range idxs {
+			if revokedIdxs[i] != exemptEpochIdx {
+				ords = append(ords, revoked[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ords, func(i, j int) bool {
+		return ords[i].MatchTime > ords[j].MatchTime // descending, latest completed order first
+	})
+	return ords, nil
+}
+
+// OrderWithCommit searches all markets' trade and cancel orders, both active
+// and archived, for an order with the given Commitment.
+func (a *Archiver) OrderWithCommit(_ context.Context, commit order.Commitment) (found bool, oid order.OrderID, err error) {
+	if commit.IsZero() {
+		return
+	}
+	err = a.db.View(func(tx *bbolt.Tx) error {
+		if oidB := tx.Bucket(commitsBucket).Get(commit[:]); oidB != nil {
+			found = true
+			copy(oid[:], oidB)
+		}
+		return nil
+	})
+	return
+}
@@ -0,0 +1,389 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+
+	"decred.org/dcrdex/dex/encode"
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/account"
+	"decred.org/dcrdex/server/db"
+)
+
+// orderStatus is the stored status of an order. It has the same values as the
+// pg driver's order status.
+type orderStatus int16
+
+const (
+	orderStatusUnknown orderStatus = iota
+	orderStatusEpoch
+	orderStatusBooked
+	orderStatusExecuted
+	orderStatusFailed // failed helps distinguish matched from unmatched executed cancel orders
+	orderStatusCanceled
+	orderStatusRevoked // indicates a trade order was revoked, or for a cancel order that it is server-generated
+)
+
+func marketToDBStatus(status order.OrderStatus) orderStatus {
+	switch status {
+	case order.OrderStatusEpoch:
+		return orderStatusEpoch
+	case order.OrderStatusBooked:
+		return orderStatusBooked
+	case order.OrderStatusExecuted:
+		return orderStatusExecuted
+	case order.OrderStatusCanceled:
+		return orderStatusCanceled
+	case order.OrderStatusRevoked:
+		return orderStatusRevoked
+	}
+	return orderStatusUnknown
+}
+
+func (status orderStatus) market() order.OrderStatus {
+	switch status {
+	case orderStatusEpoch:
+		return order.OrderStatusEpoch
+	case orderStatusBooked:
+		return order.OrderStatusBooked
+	case orderStatusExecuted, orderStatusFailed: // failed is executed as far as the market is concerned
+		return order.OrderStatusExecuted
+	case orderStatusCanceled:
+		return order.OrderStatusCanceled
+	case orderStatusRevoked, -orderStatusRevoked: // negative revoke status means forgiven preimage miss
+		return order.OrderStatusRevoked
+	}
+	return order.OrderStatusUnknown
+}
+
+func (status orderStatus) String() string {
+	if status == orderStatusFailed {
+		return "failed"
+	}
+	return status.market().String()
+}
+
+// active is true for the statuses of orders that are in the pg driver's
+// active orders tables.
+func (status orderStatus) active() bool {
+	return status == orderStatusEpoch || status == orderStatusBooked
+}
+
+const (
+	exemptEpochIdx  int64 = -1
+	countedEpochIdx int64 = 0
+	dummyEpochDur   int64 = 1 // for idx*duration math
+)
+
+// orderRecord is a stored trade or cancel order.
+type orderRecord struct {
+	ord          order.Order
+	status       orderStatus
+	epochIdx     int64
+	epochDur     int64
+	epochGap     int32
+	preimage     []byte // nil until revealed
+	completeTime int64  // zero until the swaps are complete
+	replacement  []byte // for the cancel order of an amend, the replacement order ID
+}
+
+const orderRecordPushes = 8
+
+func (r *orderRecord) encode() []byte {
+	var completeTime []byte
+	if r.completeTime != 0 {
+		completeTime = uint64Bytes(uint64(r.completeTime))
+	}
+	return encode.BuildyBytes{0}.
+		AddData(order.EncodeOrder(r.ord)).
+		AddData(uint16Bytes(uint16(r.status))).
+		AddData(uint64Bytes(uint64(r.epochIdx))).
+		AddData(uint64Bytes(uint64(r.epochDur))).
+		AddData(uint32Bytes(uint32(r.epochGap))).
+		AddData(r.preimage).
+		AddData(completeTime).
+		AddData(r.replacement)
+}
+
+// decodeOrderRecord decodes an orderRecord. The input is copied since bbolt
+// values are only valid for the life of the transaction.
+func decodeOrderRecord(b []byte) (*orderRecord, error) {
+	ver, pushes, err := encode.DecodeBlob(bytes.Clone(b), orderRecordPushes)
+	if err != nil {
+		return nil, err
+	}
+	if ver != 0 {
+		return nil, fmt.Errorf("unknown order record version %d", ver)
+	}
+	if len(pushes) != orderRecordPushes {
+		return nil, fmt.Errorf("expected %d order record pushes, got %d", orderRecordPushes, len(pushes))
+	}
+	if len(pushes[1]) != 2 || len(pushes[2]) != 8 || len(pushes[3]) != 8 || len(pushes[4]) != 4 {
+		return nil, fmt.Errorf("invalid order record")
+	}
+	ord, err := order.DecodeOrder(pushes[0])
+	if err != nil {
+		return nil, err
+	}
+	prefix := ord.Prefix()
+	prefix.ClientTime, prefix.ServerTime = prefix.ClientTime.UTC(), prefix.ServerTime.UTC()
+	r := &orderRecord{
+		ord:         ord,
+		status:      orderStatus(intCoder.Uint16(pushes[1])),
+		epochIdx:    int64(intCoder.Uint64(pushes[2])),
+		epochDur:    int64(intCoder.Uint64(pushes[3])),
+		epochGap:    int32(intCoder.Uint32(pushes[4])),
+		preimage:    pushes[5],
+		replacement: pushes[7],
+	}
+	if len(pushes[6]) == 8 {
+		r.completeTime = int64(intCoder.Uint64(pushes[6]))
+	}
+	return r, nil
+}
+
+// filled is the filled amount of a trade order, or -1 for a cancel order.
+func (r *orderRecord) filled() int64 {
+	if r.ord.Type() == order.CancelOrderType {
+		return -1
+	}
+	return int64(r.ord.Trade().Filled())
+}
+
+// matchRecord is a stored match, including the swap data.
+type matchRecord struct {
+	db.MatchData
+	cancel   bool // a cancel order match
+	forgiven bool
+	db.SwapData
+}
+
+const matchRecordPushes = 34
+
+func boolByte(b bool) []byte {
+	if b {
+		return encode.ByteTrue
+	}
+	return encode.ByteFalse
+}
+
+func (m *matchRecord) encode() []byte {
+	return encode.BuildyBytes{0}.
+		AddData(m.ID[:]).
+		AddData(boolByte(m.cancel)).
+		AddData(boolByte(m.TakerSell)).
+		AddData(m.Taker[:]).
+		AddData(m.TakerAcct[:]).
+		AddData([]byte(m.TakerAddr)).
+		AddData(m.Maker[:]).
+		AddData(m.MakerAcct[:]).
+		AddData([]byte(m.MakerAddr)).
+		AddData(uint64Bytes(m.Epoch.Idx)).
+		AddData(uint64Bytes(m.Epoch.Dur)).
+		AddData(uint64Bytes(m.Quantity)).
+		AddData(uint64Bytes(m.Rate)).
+		AddData(uint64Bytes(m.BaseRate)).
+		AddData(uint64Bytes(m.QuoteRate)).
+		AddData([]byte{byte(m.Status)}).
+		AddData(boolByte(m.Active)).
+		AddData(boolByte(m.forgiven)).
+		AddData(m.SigMatchAckMaker).
+		AddData(m.SigMatchAckTaker).
+		AddData(m.ContractA).
+		AddData(m.ContractACoinID).
+		AddData(uint64Bytes(uint64(m.ContractATime))).
+		AddData(m.ContractAAckSig).
+		AddData(m.ContractB).
+		AddData(m.ContractBCoinID).
+		AddData(uint64Bytes(uint64(m.ContractBTime))).
+		AddData(m.ContractBAckSig).
+		AddData(m.RedeemACoinID).
+		AddData(m.RedeemASecret).
+		AddData(uint64Bytes(uint64(m.RedeemATime))).
+		AddData(m.RedeemAAckSig).
+		AddData(m.RedeemBCoinID).
+		AddData(uint64Bytes(uint64(m.RedeemBTime)))
+}
+
+// decodeMatchRecord decodes a matchRecord. The input is copied since bbolt
+// values are only valid for the life of the transaction.
+func decodeMatchRecord(b []byte) (*matchRecord, error) {
+	ver, pushes, err := encode.DecodeBlob(bytes.Clone(b), matchRecordPushes)
+	if err != nil {
+		return nil, err
+	}
+	if ver != 0 {
+		return nil, fmt.Errorf("unknown match record version %d", ver)
+	}
+	if len(pushes) != matchRecordPushes {
+		return nil, fmt.Errorf("expected %d match record pushes, got %d", matchRecordPushes, len(pushes))
+	}
+	for _, i := range []int{9, 10, 11, 12, 13, 14, 22, 26, 30, 33} {
+		if len(pushes[i]) != 8 {
+			return nil, fmt.Errorf("invalid match record integer push %d", i)
+		}
+	}
+	if len(pushes[0]) != order.MatchIDSize || len(pushes[3]) != order.OrderIDSize ||
+		len(pushes[4]) != account.HashSize || len(pushes[6]) != order.OrderIDSize ||
+		len(pushes[7]) != account.HashSize || len(pushes[15]) != 1 {
+		return nil, fmt.Errorf("invalid match record")
+	}
+	isTrue := func(b []byte) bool {
+		return bytes.Equal(b, encode.ByteTrue)
+	}
+	u64 := intCoder.Uint64
+	m := &matchRecord{
+		MatchData: db.MatchData{
+			TakerSell: isTrue(pushes[2]),
+			TakerAddr: string(pushes[5]),
+			MakerAddr: string(pushes[8]),
+			Epoch: order.EpochID{
+				Idx: u64(pushes[9]),
+				Dur: u64(pushes[10]),
+			},
+			Quantity:  u64(pushes[11]),
+			Rate:      u64(pushes[12]),
+			BaseRate:  u64(pushes[13]),
+			QuoteRate: u64(pushes[14]),
+			Status:    order.MatchStatus(pushes[15][0]),
+			Active:    isTrue(pushes[16]),
+		},
+		cancel:   isTrue(pushes[1]),
+		forgiven: isTrue(pushes[17]),
+		SwapData: db.SwapData{
+			SigMatchAckMaker: pushes[18],
+			SigMatchAckTaker: pushes[19],
+			ContractA:        pushes[20],
+			ContractACoinID:  pushes[21],
+			ContractATime:    int64(u64(pushes[22])),
+			ContractAAckSig:  pushes[23],
+			ContractB:        pushes[24],
+			ContractBCoinID:  pushes[25],
+			ContractBTime:    int64(u64(pushes[26])),
+			ContractBAckSig:  pushes[27],
+			RedeemACoinID:    pushes[28],
+			RedeemASecret:    pushes[29],
+			RedeemATime:      int64(u64(pushes[30])),
+			RedeemAAckSig:    pushes[31],
+			RedeemBCoinID:    pushes[32],
+			RedeemBTime:      int64(u64(pushes[33])),
+		},
+	}
+	copy(m.ID[:], pushes[0])
+	copy(m.Taker[:], pushes[3])
+	copy(m.TakerAcct[:], pushes[4])
+	copy(m.Maker[:], pushes[6])
+	copy(m.MakerAcct[:], pushes[7])
+	return m, nil
+}
+
+// encodeBond encodes a bond for storage. The asset ID and coin ID are in the
+// key.
+func encodeBond(bond *db.Bond) []byte {
+	return encode.BuildyBytes{0}.
+		AddData(uint16Bytes(bond.Version)).
+		AddData(uint64Bytes(uint64(bond.Amount))).
+		AddData(uint32Bytes(bond.Strength)).
+		AddData(uint64Bytes(uint64(bond.LockTime)))
+}
+
+func decodeBond(assetID uint32, coinID, b []byte) (*db.Bond, error) {
+	ver, pushes, err := encode.DecodeBlob(b, 4)
+	if err != nil {
+		return nil, err
+	}
+	if ver != 0 || len(pushes) != 4 || len(pushes[0]) != 2 || len(pushes[1]) != 8 ||
+		len(pushes[2]) != 4 || len(pushes[3]) != 8 {
+		return nil, fmt.Errorf("invalid bond record")
+	}
+	return &db.Bond{
+		Version:  intCoder.Uint16(pushes[0]),
+		AssetID:  assetID,
+		CoinID:   bytes.Clone(coinID),
+		Amount:   int64(intCoder.Uint64(pushes[1])),
+		Strength: intCoder.Uint32(pushes[2]),
+		LockTime: int64(intCoder.Uint64(pushes[3])),
+	}, nil
+}
+
+// encodeEpoch encodes an epoch for storage. The epoch index and duration are
+// in the key.
+func encodeEpoch(ed *db.EpochResults) []byte {
+	return encode.BuildyBytes{0}.
+		AddData(uint64Bytes(uint64(ed.MatchTime))).
+		AddData(ed.CSum).
+		AddData(ed.Seed).
+		AddData(encodeOrderIDs(ed.OrdersRevealed)).
+		AddData(encodeOrderIDs(ed.OrdersMissed))
+}
+
+func encodeOrderIDs(oids []order.OrderID) []byte {
+	b := make([]byte, 0, len(oids)*order.OrderIDSize)
+	for i := range oids {
+		b = append(b, oids[i][:]...)
+	}
+	return b
+}
+
+// epochMatchTime decodes the match time of an encoded epoch.
+func epochMatchTime(b []byte) (int64, error) {
+	ver, pushes, err := encode.DecodeBlob(b, 5)
+	if err != nil {
+		return 0, err
+	}
+	if ver != 0 || len(pushes) != 5 || len(pushes[0]) != 8 {
+		return 0, fmt.Errorf("invalid epoch record")
+	}
+	return int64(intCoder.Uint64(pushes[0])), nil
+}
+
+func epochKey(idx, dur int64) []byte {
+	return append(uint64Bytes(uint64(idx)), uint64Bytes(uint64(dur))...)
+}
+
+// encodeEpochReport encodes the epoch report of the EpochResults. The epoch
+// end stamp is the key.
+func encodeEpochReport(ed *db.EpochResults) []byte {
+	b := encode.BuildyBytes{0}
+	for _, v := range []uint64{uint64(ed.Dur), ed.MatchVolume, ed.QuoteVolume,
+		ed.BookBuys, ed.BookBuys5, ed.BookBuys25, ed.BookSells, ed.BookSells5,
+		ed.BookSells25, ed.HighRate, ed.LowRate, ed.StartRate, ed.EndRate} {
+		b = b.AddData(uint64Bytes(v))
+	}
+	return b
+}
+
+const epochReportPushes = 13
+
+// epochReport is the part of a stored epoch report used for candles.
+type epochReport struct {
+	dur, matchVol, quoteVol, highRate, lowRate, startRate, endRate uint64
+}
+
+func decodeEpochReport(b []byte) (*epochReport, error) {
+	ver, pushes, err := encode.DecodeBlob(b, epochReportPushes)
+	if err != nil {
+		return nil, err
+	}
+	if ver != 0 || len(pushes) != epochReportPushes {
+		return nil, fmt.Errorf("invalid epoch report record")
+	}
+	for _, p := range pushes {
+		if len(p) != 8 {
+			return nil, fmt.Errorf("invalid epoch report record")
+		}
+	}
+	u64 := intCoder.Uint64
+	return &epochReport{
+		dur:       u64(pushes[0]),
+		matchVol:  u64(pushes[1]),
+		quoteVol:  u64(pushes[2]),
+		highRate:  u64(pushes[9]),
+		lowRate:   u64(pushes[10]),
+		startRate: u64(pushes[11]),
+		endRate:   u64(pushes[12]),
+	}, nil
+}
@@ -0,0 +1,39 @@
+//go:build pgonline
+
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/server/db"
+	"decred.org/dcrdex/server/db/dbtest"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, mkts []*dex.MarketInfo) db.DEXArchivist {
+		dbe, err := connect(PGTestsHost, PGTestsPort, PGTestsUser, PGTestsPass, PGTestsDBName)
+		if err != nil {
+			t.Fatalf("connect failed: %v", err)
+		}
+		if err = nukeAll(dbe); err != nil {
+			t.Fatalf("nukeAll: %v", err)
+		}
+		dbe.Close()
+
+		archiver, err := NewArchiver(context.Background(), &Config{
+			Host:      PGTestsHost,
+			Port:      PGTestsPort,
+			User:      PGTestsUser,
+			Pass:      PGTestsPass,
+			DBName:    PGTestsDBName,
+			MarketCfg: mkts,
+		})
+		if err != nil {
+			t.Fatalf("NewArchiver failed: %v", err)
+		}
+		t.Cleanup(func() { archiver.Close() })
+		return archiver
+	})
+}
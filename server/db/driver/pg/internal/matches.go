@@ -127,6 +127,15 @@ const (
 	ORDER BY epochIdx * epochDur DESC
 	LIMIT $1;`
 
+	// RetrieveMarketTrades retrieves the trade matches from epochs that ended
+	// before a time stamp, without any identifying data.
+	RetrieveMarketTrades = `SELECT epochIdx, epochDur, takerSell, quantity, rate
+	FROM %s
+	WHERE takerSell IS NOT NULL -- not a cancel order
+		AND (epochIdx + 1) * epochDur < $1
+	ORDER BY epochIdx * epochDur DESC
+	LIMIT $2;`
+
 	RetrieveActiveMarketMatches = `SELECT matchid, takerSell,
 		takerOrder, takerAccount, takerAddress,
 		makerOrder, makerAccount, makerAddress,
@@ -181,7 +181,8 @@ const (
 		status INT2,
 		epoch_idx INT8, epoch_dur INT4, -- 0 for rule-based revocations, -1 for exempt (e.g. book purge)
 		epoch_gap INT4 DEFAULT -1, -- epochs between order and cancel order. -1 for revocations
-		preimage BYTEA UNIQUE  -- null before preimage collection, and all server-generated cancels (revocations)
+		preimage BYTEA UNIQUE, -- null before preimage collection, and all server-generated cancels (revocations)
+		replacement BYTEA      -- the replacement order of an amend, null for plain cancels
 	);`
 
 	SelectCancelOrder = `SELECT oid, account_id, client_time, server_time,
@@ -226,6 +227,7 @@ const (
 		FROM %[1]s -- a cancels table
 		JOIN %[2]s ON %[2]s.epoch_idx = %[1]s.epoch_idx AND %[2]s.epoch_dur = %[1]s.epoch_dur -- join on epochs table PK
 		WHERE account_id = $1 AND status = $2
+			AND replacement IS NULL -- amends are not counted as cancels
 		ORDER BY match_time DESC
 		LIMIT $3;` // NOTE: find revoked orders via SelectRevokeCancels
 
@@ -234,6 +236,12 @@ const (
 			commit, target_order, status, epoch_idx, epoch_dur, epoch_gap)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
 
+	// InsertAmendCancelOrder is like InsertCancelOrder, but also sets the
+	// replacement order ID of an amend.
+	InsertAmendCancelOrder = `INSERT INTO %s (oid, account_id, client_time, server_time,
+			commit, target_order, status, epoch_idx, epoch_dur, epoch_gap, replacement)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+
 	// CancelOrderStatus retrieves an order's status
 	CancelOrderStatus = `SELECT status FROM %s WHERE oid = $1;`
 
@@ -242,8 +250,8 @@ const (
 	MoveCancelOrder = `WITH moved AS (
 		DELETE FROM %s
 		WHERE oid = $1
-		RETURNING oid, account_id, client_time, server_time, commit, target_order, %d, epoch_idx, epoch_dur, epoch_gap, preimage
+		RETURNING oid, account_id, client_time, server_time, commit, target_order, %d, epoch_idx, epoch_dur, epoch_gap, preimage, replacement
 	)
-	INSERT INTO %s (oid, account_id, client_time, server_time, commit, target_order, status, epoch_idx, epoch_dur, epoch_gap, preimage)
+	INSERT INTO %s (oid, account_id, client_time, server_time, commit, target_order, status, epoch_idx, epoch_dur, epoch_gap, preimage, replacement)
 	SELECT * FROM moved;`
 )
@@ -31,7 +31,7 @@ func (a *Archiver) matchTableName(match *order.Match) (string, error) {
 // can actually be forgiven (inactive, not already forgiven, and not in
 // MatchComplete status).
 func (a *Archiver) ForgiveMatchFail(mid order.MatchID) (bool, error) {
-	for schema := range a.markets {
+	for schema := range a.marketMap() {
 		stmt := fmt.Sprintf(internal.ForgiveMatchFail, fullMatchesTableName(a.dbName, schema))
 		N, err := sqlExec(a.db, stmt, mid)
 		if err != nil { // not just no rows updated
@@ -49,7 +49,7 @@ func (a *Archiver) ForgiveMatchFail(mid order.MatchID) (bool, error) {
 func (a *Archiver) ActiveSwaps() ([]*db.SwapDataFull, error) {
 	var sd []*db.SwapDataFull
 
-	for schema, mkt := range a.markets {
+	for schema, mkt := range a.marketMap() {
 		matchesTableName := fullMatchesTableName(a.dbName, schema)
 		ctx, cancel := context.WithTimeout(a.ctx, a.queryTimeout)
 		matches, swapData, err := activeSwaps(ctx, a.db, matchesTableName)
@@ -140,7 +140,7 @@ func activeSwaps(ctx context.Context, dbe *sql.DB, tableName string) (matches []
 func (a *Archiver) CompletedAndAtFaultMatchStats(aid account.AccountID, lastN int) ([]*db.MatchOutcome, error) {
 	var outcomes []*db.MatchOutcome
 
-	for schema, mkt := range a.markets {
+	for schema, mkt := range a.marketMap() {
 		matchesTableName := fullMatchesTableName(a.dbName, schema)
 		ctx, cancel := context.WithTimeout(a.ctx, a.queryTimeout)
 		matchOutcomes, err := completedAndAtFaultMatches(ctx, a.db, matchesTableName, aid, lastN, mkt.Base, mkt.Quote)
@@ -166,7 +166,7 @@ func (a *Archiver) CompletedAndAtFaultMatchStats(aid account.AccountID, lastN in
 func (a *Archiver) UserMatchFails(aid account.AccountID, lastN int) ([]*db.MatchFail, error) {
 	var fails []*db.MatchFail
 
-	for schema := range a.markets {
+	for schema := range a.marketMap() {
 		matchesTableName := fullMatchesTableName(a.dbName, schema)
 		ctx, cancel := context.WithTimeout(a.ctx, a.queryTimeout)
 		marketFails, err := atFaultMatches(ctx, a.db, matchesTableName, aid, lastN)
@@ -403,6 +403,40 @@ func (a *Archiver) MarketMatchesStreaming(base, quote uint32, includeInactive bo
 	return a.marketMatches(base, quote, includeInactive, N, f)
 }
 
+// MarketTrades retrieves up to n of a market's most recent trade order matches
+// from epochs that ended before the time stamp (ms), newest first. A limit
+// <= 0 means unlimited.
+func (a *Archiver) MarketTrades(base, quote uint32, before uint64, n int) ([]*db.Trade, error) {
+	marketSchema, err := a.marketSchema(base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := fmt.Sprintf(internal.RetrieveMarketTrades, fullMatchesTableName(a.dbName, marketSchema))
+	if n <= 0 {
+		n = math.MaxInt32
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, a.queryTimeout)
+	defer cancel()
+	rows, err := a.db.QueryContext(ctx, stmt, int64(before), n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []*db.Trade
+	for rows.Next() {
+		var t db.Trade
+		err = rows.Scan(&t.Epoch.Idx, &t.Epoch.Dur, &t.TakerSell, &t.Quantity, &t.Rate)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, &t)
+	}
+	return trades, rows.Err()
+}
+
 func rowsToMatchDataWithCoinsStreaming(rows *sql.Rows, includeInactive bool, f func(*db.MatchDataWithCoins) error) (int, error) {
 	defer rows.Close()
 
@@ -462,7 +496,7 @@ func (a *Archiver) AllActiveUserMatches(aid account.AccountID) ([]*db.MatchData,
 	defer cancel()
 
 	var matches []*db.MatchData
-	for schema := range a.markets {
+	for schema := range a.marketMap() {
 		matchesTableName := fullMatchesTableName(a.dbName, schema)
 		mdM, err := userMatches(ctx, a.db, matchesTableName, aid, false)
 		if err != nil {
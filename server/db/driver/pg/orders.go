@@ -187,6 +187,68 @@ func (a *Archiver) NewEpochOrder(ord order.Order, epochIdx, epochDur int64, epoc
 	return a.storeOrder(ord, epochIdx, epochDur, epochGap, orderStatusEpoch)
 }
 
+// NewEpochAmend stores the cancel and replacement orders of an amend with
+// epoch status in a single transaction. The cancel order row records the
+// replacement order ID.
+func (a *Archiver) NewEpochAmend(co *order.CancelOrder, lo *order.LimitOrder, epochIdx, epochDur int64, epochGap int32) (err error) {
+	marketSchema, err := a.marketSchema(lo.Base(), lo.Quote())
+	if err != nil {
+		return err
+	}
+
+	status := orderStatusEpoch
+	for _, ord := range []order.Order{co, lo} {
+		if !validateOrder(ord, status, a.marketMap()[marketSchema]) {
+			return db.ArchiveError{
+				Code: db.ErrInvalidOrder,
+				Detail: fmt.Sprintf("invalid order %v for status %v and market %v",
+					ord.UID(), status, a.marketMap()[marketSchema]),
+			}
+		}
+		commit := ord.Commitment()
+		found, prevOid, err := a.OrderWithCommit(a.ctx, commit)
+		if err != nil {
+			return err
+		}
+		if found {
+			return db.ArchiveError{
+				Code: db.ErrReusedCommit,
+				Detail: fmt.Sprintf("order %v reuses commit %v from previous order %v",
+					ord.UID(), commit, prevOid),
+			}
+		}
+	}
+
+	dbTx, err := a.db.BeginTx(a.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil || errors.Is(err, sql.ErrTxDone) {
+			return
+		}
+		a.fatalBackendErr(err)
+		if errR := dbTx.Rollback(); errR != nil {
+			log.Errorf("Rollback failed: %v", errR)
+		}
+	}()
+
+	cancelTable := fullCancelOrderTableName(a.dbName, marketSchema, status.active())
+	stmt := fmt.Sprintf(internal.InsertAmendCancelOrder, cancelTable)
+	if _, err = sqlExec(dbTx, stmt, co.ID(), co.AccountID, co.ClientTime, co.ServerTime,
+		co.Commit, co.TargetOrderID, status, epochIdx, epochDur, epochGap, lo.ID()); err != nil {
+		return fmt.Errorf("failed to store amend cancel order %v: %w", co.UID(), err)
+	}
+
+	orderTable := fullOrderTableName(a.dbName, marketSchema, status.active())
+	if _, err = storeLimitOrder(dbTx, orderTable, lo, status, epochIdx, epochDur); err != nil {
+		return fmt.Errorf("failed to store amend replacement order %v: %w", lo.UID(), err)
+	}
+
+	err = dbTx.Commit() // for the defer
+	return err
+}
+
 // NewArchivedCancel stores a cancel order directly in the executed state. This
 // is used for orders that are canceled when the market is suspended, and therefore
 // do not need to be matched.
@@ -544,11 +606,11 @@ func (a *Archiver) storeOrder(ord order.Order, epochIdx, epochDur int64, epochGa
 		return err
 	}
 
-	if !validateOrder(ord, status, a.markets[marketSchema]) {
+	if !validateOrder(ord, status, a.marketMap()[marketSchema]) {
 		return db.ArchiveError{
 			Code: db.ErrInvalidOrder,
 			Detail: fmt.Sprintf("invalid order %v for status %v and market %v",
-				ord.UID(), status, a.markets[marketSchema]),
+				ord.UID(), status, a.marketMap()[marketSchema]),
 		}
 	}
 
@@ -743,7 +805,7 @@ type orderCompStamped struct {
 func (a *Archiver) CompletedUserOrders(aid account.AccountID, N int) (oids []order.OrderID, compTimes []int64, err error) {
 	var ords []orderCompStamped
 
-	for schema := range a.markets {
+	for schema := range a.marketMap() {
 		tableName := fullOrderTableName(a.dbName, schema, false) // NOT active table
 		ctx, cancel := context.WithTimeout(a.ctx, a.queryTimeout)
 		mktOids, err := completedUserOrders(ctx, a.db, tableName, aid, N)
@@ -831,7 +893,7 @@ func (a *Archiver) PreimageStats(user account.AccountID, lastN int) ([]*db.Preim
 		return rows.Err()
 	}
 
-	for schema := range a.markets {
+	for schema := range a.marketMap() {
 		// archived trade orders
 		stmt := fmt.Sprintf(internal.PreimageResultsLastN, fullOrderTableName(a.dbName, schema, false))
 		if err := queryOutcomes(stmt); err != nil {
@@ -1132,7 +1194,7 @@ func (a *Archiver) UserOrderStatuses(aid account.AccountID, base, quote uint32,
 // active orders for a user across all markets.
 func (a *Archiver) ActiveUserOrderStatuses(aid account.AccountID) ([]*db.OrderStatus, error) {
 	var orders []*db.OrderStatus
-	for schema := range a.markets {
+	for schema := range a.marketMap() {
 		tableName := fullOrderTableName(a.dbName, schema, true) // active table
 		mktOrders, err := a.userOrderStatusesFromTable(tableName, aid, nil)
 		if err != nil {
@@ -1192,7 +1254,7 @@ func (a *Archiver) userOrderStatusesFromTable(fullTable string, aid account.Acco
 // and archived, for an order with the given Commitment.
 func (a *Archiver) OrderWithCommit(ctx context.Context, commit order.Commitment) (found bool, oid order.OrderID, err error) {
 	// Check all markets.
-	for marketSchema := range a.markets {
+	for marketSchema := range a.marketMap() {
 		found, oid, err = orderForCommit(ctx, a.db, a.dbName, marketSchema, commit)
 		if err != nil {
 			a.fatalBackendErr(err)
@@ -1213,7 +1275,7 @@ func (a *Archiver) OrderWithCommit(ctx context.Context, commit order.Commitment)
 func (a *Archiver) ExecutedCancelsForUser(aid account.AccountID, N int) (ords []*db.CancelRecord, err error) {
 
 	// Check all markets.
-	for marketSchema := range a.markets {
+	for marketSchema := range a.marketMap() {
 		// Query for executed cancels (user-initiated).
 		cancelTableName := fullCancelOrderTableName(a.dbName, marketSchema, false) // executed cancel orders are inactive
 		epochsTableName := fullEpochsTableName(a.dbName, marketSchema)
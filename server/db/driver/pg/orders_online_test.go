@@ -1176,6 +1176,71 @@ func TestFailCancelOrder(t *testing.T) {
 	}
 }
 
+func TestNewEpochAmend(t *testing.T) {
+	if err := cleanTables(archie.db); err != nil {
+		t.Fatalf("cleanTables: %v", err)
+	}
+
+	var epochIdx, epochDur int64 = 13245678, 6000
+
+	target := newLimitOrder(true, 4900000, 1, order.StandingTiF, 0)
+	target.BaseAsset, target.QuoteAsset = mktInfo.Base, mktInfo.Quote
+	err := archie.StoreOrder(target, epochIdx, epochDur, order.OrderStatusBooked)
+	if err != nil {
+		t.Fatalf("StoreOrder failed: %v", err)
+	}
+
+	co := newCancelOrder(target.ID(), mktInfo.Base, mktInfo.Quote, 1)
+	co.AccountID = target.AccountID
+	lo := newLimitOrder(true, 5000000, 1, order.StandingTiF, 1)
+	lo.AccountID = target.AccountID
+	lo.BaseAsset, lo.QuoteAsset = mktInfo.Base, mktInfo.Quote
+	epochIdx++
+	if err = archie.NewEpochAmend(co, lo, epochIdx, epochDur, 1); err != nil {
+		t.Fatalf("NewEpochAmend failed: %v", err)
+	}
+
+	// Both orders are stored with epoch status.
+	if status, _, _, err := archie.OrderStatus(lo); err != nil || status != order.OrderStatusEpoch {
+		t.Fatalf("replacement order status %v, err = %v", status, err)
+	}
+	_, status, err := loadCancelOrder(archie.db, archie.dbName, mktInfo.Name, co.ID())
+	if err != nil {
+		t.Fatalf("loadCancelOrder failed: %v", err)
+	}
+	if status != orderStatusEpoch {
+		t.Fatalf("cancel order should have been %s, got %s", orderStatusEpoch, status)
+	}
+
+	// Reused commitments are rejected.
+	if err = archie.NewEpochAmend(co, lo, epochIdx, epochDur, 1); err == nil {
+		t.Fatalf("no error storing amend again")
+	}
+
+	// An executed amend cancel is not counted as a cancel.
+	if err = archie.ExecuteOrder(co); err != nil {
+		t.Fatalf("ExecuteOrder failed: %v", err)
+	}
+	err = archie.InsertEpoch(&db.EpochResults{
+		MktBase:        mktInfo.Base,
+		MktQuote:       mktInfo.Quote,
+		Idx:            epochIdx,
+		Dur:            epochDur,
+		MatchTime:      time.Now().UnixMilli(),
+		OrdersRevealed: []order.OrderID{co.ID(), lo.ID()},
+	})
+	if err != nil {
+		t.Fatalf("InsertEpoch failed: %v", err)
+	}
+	cancels, err := archie.ExecutedCancelsForUser(co.User(), cancelThreshWindow)
+	if err != nil {
+		t.Fatalf("ExecutedCancelsForUser failed: %v", err)
+	}
+	if len(cancels) != 0 {
+		t.Fatalf("found %d executed cancels, expected 0", len(cancels))
+	}
+}
+
 func TestUpdateOrderFilled(t *testing.T) {
 	if err := cleanTables(archie.db); err != nil {
 		t.Fatalf("cleanTables: %v", err)
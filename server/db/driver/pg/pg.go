@@ -7,6 +7,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"maps"
 	"sync"
 	"time"
 
@@ -67,8 +68,11 @@ type Archiver struct {
 	queryTimeout time.Duration
 	db           *sql.DB
 	dbName       string
-	markets      map[string]*dex.MarketInfo
-	tables       archiverTables
+	// markets is replaced, not modified, when a market is added with
+	// AddMarket, so the map returned by marketMap may be used without locking.
+	marketsMtx sync.RWMutex
+	markets    map[string]*dex.MarketInfo
+	tables     archiverTables
 
 	fatalMtx sync.RWMutex
 	fatal    chan struct{}
@@ -200,13 +204,79 @@ func (a *Archiver) Close() error {
 	return a.db.Close()
 }
 
+// Check that Archiver satisfies the db.MarketAdder interface.
+var _ db.MarketAdder = (*Archiver)(nil)
+
+// AddMarket prepares the tables for a market that was not configured when the
+// Archiver was created, and begins storing data for it. If the market's tables
+// exist with a different lot size, the market's book is flushed. Part of the
+// db.MarketAdder interface.
+func (a *Archiver) AddMarket(mkt *dex.MarketInfo) error {
+	schema := marketSchema(mkt.Name)
+	a.marketsMtx.Lock()
+	if _, found := a.markets[schema]; found {
+		a.marketsMtx.Unlock()
+		return fmt.Errorf("market %s already exists", mkt.Name)
+	}
+	purgeMarkets, err := prepareMarkets(a.db, []*dex.MarketInfo{mkt})
+	if err != nil {
+		a.marketsMtx.Unlock()
+		return err
+	}
+	markets := maps.Clone(a.markets)
+	markets[schema] = mkt
+	a.markets = markets
+	a.marketsMtx.Unlock()
+
+	if len(purgeMarkets) > 0 {
+		unbookedSells, unbookedBuys, err := a.FlushBook(mkt.Base, mkt.Quote)
+		if err != nil {
+			return fmt.Errorf("failed to flush book for market %v: %w", mkt.Name, err)
+		}
+		log.Infof("Flushed %d sell orders and %d buy orders from market %v with a changed lot size.",
+			len(unbookedSells), len(unbookedBuys), mkt.Name)
+	}
+	return nil
+}
+
+// Check that Archiver satisfies the db.LotSizeUpdater interface.
+var _ db.LotSizeUpdater = (*Archiver)(nil)
+
+// UpdateLotSize sets the lot size of a configured market without flushing the
+// book. Part of the db.LotSizeUpdater interface.
+func (a *Archiver) UpdateLotSize(base, quote uint32, lotSize uint64) error {
+	schema, err := a.marketSchema(base, quote)
+	if err != nil {
+		return err
+	}
+	a.marketsMtx.Lock()
+	defer a.marketsMtx.Unlock()
+	mkt := *a.markets[schema]
+	if err = updateLotSize(a.db, publicSchema, mkt.Name, lotSize); err != nil {
+		return err
+	}
+	mkt.LotSize = lotSize
+	markets := maps.Clone(a.markets)
+	markets[schema] = &mkt
+	a.markets = markets
+	return nil
+}
+
+// marketMap returns the configured markets, keyed by market schema name. The
+// map must not be modified.
+func (a *Archiver) marketMap() map[string]*dex.MarketInfo {
+	a.marketsMtx.RLock()
+	defer a.marketsMtx.RUnlock()
+	return a.markets
+}
+
 func (a *Archiver) marketSchema(base, quote uint32) (string, error) {
 	marketName, err := dex.MarketName(base, quote)
 	if err != nil {
 		return "", err
 	}
 	schema := marketSchema(marketName)
-	_, found := a.markets[schema]
+	_, found := a.marketMap()[schema]
 	if !found {
 		return "", db.ArchiveError{
 			Code:   db.ErrUnsupportedMarket,
@@ -17,7 +17,7 @@ import (
 	"decred.org/dcrdex/server/db/driver/pg/internal"
 )
 
-const dbVersion = 6
+const dbVersion = 7
 
 // The number of upgrades defined MUST be equal to dbVersion.
 var upgrades = []func(db *sql.Tx) error{
@@ -48,6 +48,10 @@ var upgrades = []func(db *sql.Tx) error{
 	// old_fee_coin column to the accounts table for when a manual refund is
 	// processed.
 	v6Upgrade,
+
+	// v7 upgrade adds a replacement column to the cancel order tables to link
+	// the cancel orders of amend requests to their replacement orders.
+	v7Upgrade,
 }
 
 // v1Upgrade adds the schema_version column and removes the state_hash column
@@ -357,6 +361,30 @@ func v6Upgrade(tx *sql.Tx) error {
 	return nil
 }
 
+func v7Upgrade(tx *sql.Tx) (err error) {
+	mkts, err := loadMarkets(tx, marketsTableName)
+	if err != nil {
+		return fmt.Errorf("failed to read markets table: %w", err)
+	}
+
+	doTable := func(tableName string) error {
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS replacement BYTEA;", tableName))
+		return err
+	}
+
+	log.Infof("Adding replacement column to cancel tables for %d markets", len(mkts))
+
+	for _, mkt := range mkts {
+		if err := doTable(mkt.Name + "." + cancelsArchivedTableName); err != nil {
+			return err
+		}
+		if err := doTable(mkt.Name + "." + cancelsActiveTableName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DBVersion retrieves the database version from the meta table.
 func DBVersion(db *sql.DB) (ver uint32, err error) {
 	err = db.QueryRow(internal.SelectDBVersion).Scan(&ver)
@@ -88,6 +88,11 @@ type DEXArchivist interface {
 	LastCandleEndStamp(base, quote uint32, candleDur uint64) (uint64, error)
 	InsertCandles(base, quote uint32, dur uint64, cs []*candles.Candle) error
 
+	// MarketTrades retrieves up to n of a market's most recent trade order
+	// matches from epochs that ended before the time stamp (ms), newest first.
+	// Cancel order matches are not included. A limit <= 0 means unlimited.
+	MarketTrades(base, quote uint32, before uint64, n int) ([]*Trade, error)
+
 	OrderArchiver
 	AccountArchiver
 	KeyIndexer
@@ -95,6 +100,25 @@ type DEXArchivist interface {
 	SwapArchiver
 }
 
+// MarketAdder is implemented by DEXArchivist backends that can begin storing
+// data for a market added while the DEX is running.
+type MarketAdder interface {
+	// AddMarket prepares storage for a market that was not configured when the
+	// archivist was created. If storage for the market exists with a
+	// different lot size, the market's book is flushed.
+	AddMarket(mkt *dex.MarketInfo) error
+}
+
+// LotSizeUpdater is implemented by DEXArchivist backends that can change the
+// lot size of a running market. Unlike a lot size change at startup, the book
+// is not flushed, since the market is responsible for revoking any booked
+// orders that do not conform to the new lot size.
+type LotSizeUpdater interface {
+	// UpdateLotSize sets the lot size of a configured market. New orders are
+	// validated against the new lot size.
+	UpdateLotSize(base, quote uint32, lotSize uint64) error
+}
+
 // OrderArchiver is the interface required for storage and retrieval of all
 // order data.
 type OrderArchiver interface {
@@ -159,6 +183,12 @@ type OrderArchiver interface {
 	// the targeted order was placed, as described in the docs for CancelRecord.
 	NewEpochOrder(ord order.Order, epochIdx, epochDur int64, epochGap int32) error
 
+	// NewEpochAmend stores the cancel and replacement orders of an amend
+	// request with epoch status, linking the replacement to the cancel order
+	// and thus to the amended order. The epoch gap is for the cancel order.
+	// Executed amend cancels are not returned by ExecutedCancelsForUser.
+	NewEpochAmend(co *order.CancelOrder, lo *order.LimitOrder, epochIdx, epochDur int64, epochGap int32) error
+
 	// StorePreimage stores the preimage associated with an existing order.
 	StorePreimage(ord order.Order, pi order.Preimage) error
 
@@ -288,6 +318,20 @@ type MatchData struct {
 	Status    order.MatchStatus // note that failed swaps, where Active=false, can have any status
 }
 
+// Trade is an anonymized record of a trade order match, as published by the
+// data API.
+type Trade struct {
+	Epoch     order.EpochID
+	TakerSell bool
+	Quantity  uint64
+	Rate      uint64
+}
+
+// EndStamp is the end time of the match's epoch, in milliseconds.
+func (t *Trade) EndStamp() uint64 {
+	return (t.Epoch.Idx + 1) * t.Epoch.Dur
+}
+
 // MatchDataWithCoins pairs MatchData (embedded) with the encode swap and redeem
 // coin IDs blobs for both maker and taker.
 type MatchDataWithCoins struct {
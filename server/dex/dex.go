@@ -8,10 +8,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,6 +33,7 @@ import (
 	"decred.org/dcrdex/server/coinlock"
 	"decred.org/dcrdex/server/comms"
 	"decred.org/dcrdex/server/db"
+	"decred.org/dcrdex/server/db/driver/bolt"
 	"decred.org/dcrdex/server/db/driver/pg"
 	"decred.org/dcrdex/server/market"
 	"decred.org/dcrdex/server/noderelay"
@@ -260,6 +263,11 @@ func loadMarketConf(net dex.Network, src io.Reader) ([]*dex.MarketInfo, []*Asset
 
 // DBConf groups the database configuration parameters.
 type DBConf struct {
+	// Driver is the storage driver, "pg" (default) or "bolt". The bolt driver
+	// uses the embedded database file at Path, and ignores the PostgreSQL
+	// settings.
+	Driver       string
+	Path         string
 	DBName       string
 	User         string
 	Pass         string
@@ -423,6 +431,13 @@ type DexConf struct {
 	CommsCfg         *RPCConfig
 	NoResumeSwaps    bool
 	NodeRelayAddr    string
+	// MaxUserCancels is the maximum number of cancel orders a user may submit
+	// in an epoch, for every market.
+	MaxUserCancels uint32
+	// MarketsConfPath is the markets JSON file that Markets and Assets were
+	// loaded from. Markets added or retired with AddMarket and RetireMarket
+	// are saved to this file.
+	MarketsConfPath string
 }
 
 type signer struct {
@@ -440,6 +455,25 @@ type subsystem struct {
 	cm  *dex.ConnectionMaster
 }
 
+// newSubsystem starts a dex.Runner or dex.Connector as a subsystem named name.
+func newSubsystem(name string, rc any) (subsystem, error) {
+	subsys := subsystem{name: name}
+	switch st := rc.(type) {
+	case dex.Runner:
+		subsys.ssw = dex.NewStartStopWaiter(st)
+		subsys.ssw.Start(context.Background()) // stopped with Stop
+	case dex.Connector:
+		subsys.cm = dex.NewConnectionMaster(st)
+		err := subsys.cm.Connect(context.Background()) // stopped with Disconnect
+		if err != nil {
+			return subsys, err
+		}
+	default:
+		panic(fmt.Sprintf("Invalid subsystem type %T", rc))
+	}
+	return subsys, nil
+}
+
 func (ss *subsystem) stop() {
 	if ss.ssw != nil {
 		ss.ssw.Stop()
@@ -454,25 +488,47 @@ func (ss *subsystem) stop() {
 // components of the DEX.
 type DEX struct {
 	network     dex.Network
-	markets     map[string]*market.Market
-	assets      map[uint32]*swap.SwapperAsset
 	storage     db.DEXArchivist
 	authMgr     *auth.AuthManager
 	swapper     *swap.Swapper
 	orderRouter *market.OrderRouter
 	bookRouter  *market.BookRouter
-	subsystems  []subsystem
 	server      *comms.Server
 
+	// The following are used to add markets and assets while running.
+	marketsConfPath string
+	maxUserCancels  uint32
+	assetLogger     dex.Logger
+	coinLocker      *coinlock.DEXCoinLocker
+	feeMgr          *FeeManager
+	dataAPI         *apidata.DataAPI
+	dexBalancer     *market.DEXBalancer
+
+	// mktMgmtMtx serializes AddMarket and RetireMarket, and protects
+	// storedMarkets.
+	mktMgmtMtx sync.Mutex
+	// storedMarkets are the markets that AddMarket has added to storage.
+	// Storage cannot remove a market, so a market that failed to start after
+	// it was added to storage is not added again when it is retried.
+	storedMarkets map[string]bool
+
+	// mtx protects the markets, retired, assets and subsystems fields, which
+	// change when markets are added or retired.
+	mtx        sync.RWMutex
+	markets    map[string]*market.Market
+	retired    map[string]*market.Market // may still have swaps to finish
+	assets     map[uint32]*swap.SwapperAsset
+	subsystems []subsystem
+
 	configRespMtx sync.RWMutex
 	configResp    *configResponse
 }
 
-// configResponse is defined here to leave open the possibility for hot
-// adjustable parameters while storing a pre-encoded config response message. An
-// update method will need to be defined in the future for this purpose.
+// configResponse stores a pre-encoded config response message, along with the
+// message itself so that it may be updated and re-encoded when market status
+// changes or when markets are added or retired.
 type configResponse struct {
-	configMsg *msgjson.ConfigResult // constant for now
+	configMsg *msgjson.ConfigResult
 	configEnc json.RawMessage
 }
 
@@ -522,6 +578,36 @@ func (cr *configResponse) setMktSuspend(name string, finalEpoch uint64, persist
 	log.Errorf("Failed to update MarketStatus for market %q", name)
 }
 
+// setMktParamsChange sets or clears a market's scheduled lot size and rate step
+// change.
+func (cr *configResponse) setMktParamsChange(name string, change *msgjson.MarketParamsChange) {
+	for _, mkt := range cr.configMsg.Markets {
+		if mkt.Name == name {
+			mkt.ParamsChange = change
+			cr.remarshal()
+			return
+		}
+	}
+	log.Errorf("Failed to update ParamsChange for market %q", name)
+}
+
+// setMktParams sets a market's lot size and rate step when a scheduled change
+// takes effect, clearing the scheduled change if it is the one for changeEpoch.
+func (cr *configResponse) setMktParams(name string, lotSize, rateStep, changeEpoch uint64) {
+	for _, mkt := range cr.configMsg.Markets {
+		if mkt.Name == name {
+			mkt.LotSize = lotSize
+			mkt.RateStep = rateStep
+			if mkt.ParamsChange != nil && mkt.ParamsChange.Epoch == changeEpoch {
+				mkt.ParamsChange = nil
+			}
+			cr.remarshal()
+			return
+		}
+	}
+	log.Errorf("Failed to update lot size and rate step for market %q", name)
+}
+
 func (cr *configResponse) setMktResume(name string, startEpoch uint64) (epochLen uint64) {
 	for _, mkt := range cr.configMsg.Markets {
 		if mkt.Name == name {
@@ -535,6 +621,32 @@ func (cr *configResponse) setMktResume(name string, startEpoch uint64) (epochLen
 	return 0
 }
 
+// addAssets adds any of the assets that are not yet listed to the config
+// message.
+func (cr *configResponse) addAssets(assets ...*msgjson.Asset) {
+	for _, a := range assets {
+		if !slices.ContainsFunc(cr.configMsg.Assets, func(ca *msgjson.Asset) bool { return ca.ID == a.ID }) {
+			cr.configMsg.Assets = append(cr.configMsg.Assets, a)
+		}
+	}
+	cr.remarshal()
+}
+
+// addMarket adds a market to the config message.
+func (cr *configResponse) addMarket(mkt *msgjson.Market) {
+	cr.configMsg.Markets = append(cr.configMsg.Markets, mkt)
+	cr.remarshal()
+}
+
+// removeMarket removes a market from the config message. Assets are not
+// removed since there may still be active swaps using them.
+func (cr *configResponse) removeMarket(name string) {
+	cr.configMsg.Markets = slices.DeleteFunc(cr.configMsg.Markets, func(mkt *msgjson.Market) bool {
+		return mkt.Name == name
+	})
+	cr.remarshal()
+}
+
 func (cr *configResponse) remarshal() {
 	encResult, err := json.Marshal(cr.configMsg)
 	if err != nil {
@@ -548,7 +660,10 @@ func (cr *configResponse) remarshal() {
 // completed their shutdown.
 func (dm *DEX) Stop() {
 	log.Infof("Stopping all DEX subsystems.")
-	for _, ss := range dm.subsystems {
+	dm.mtx.RLock()
+	subsystems := dm.subsystems
+	dm.mtx.RUnlock()
+	for _, ss := range subsystems {
 		log.Infof("Stopping %s...", ss.name)
 		ss.stop()
 		log.Infof("%s is now shut down.", ss.name)
@@ -589,6 +704,75 @@ type Bonder interface {
 		bondPubKeyHash []byte, lockTime int64, acct account.AccountID, err error)
 }
 
+// newAssetBackend creates the backend for an asset. The backend for a token's
+// parent asset must be in backedAssets.
+func newAssetBackend(assetID uint32, assetConf *Asset, net dex.Network, logger dex.Logger,
+	relayAddr string, backedAssets map[uint32]*asset.BackedAsset) (asset.Backend, error) {
+
+	symbol := strings.ToLower(assetConf.Symbol)
+	if isToken, parentID := asset.IsToken(assetID); isToken {
+		parent, found := backedAssets[parentID]
+		if !found {
+			return nil, fmt.Errorf("attempting to load token asset %d before parent %d", assetID, parentID)
+		}
+		backer, is := parent.Backend.(asset.TokenBacker)
+		if !is {
+			return nil, fmt.Errorf("token %d parent %d is not a TokenBacker", assetID, parentID)
+		}
+		be, err := backer.TokenBackend(assetID, assetConf.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup token %q: %w", symbol, err)
+		}
+		return be, nil
+	}
+
+	be, err := asset.Setup(&asset.BackendConfig{
+		AssetID:    assetID,
+		ConfigPath: assetConf.ConfigPath,
+		Logger:     logger,
+		Net:        net,
+		RelayAddr:  relayAddr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup asset %q: %w", symbol, err)
+	}
+	return be, nil
+}
+
+// newBackedAsset pairs an asset backend with the asset's configuration, also
+// returning the asset's entry for the config response.
+func newBackedAsset(assetID uint32, assetConf *Asset, be asset.Backend) (*asset.BackedAsset, *msgjson.Asset, error) {
+	symbol := strings.ToLower(assetConf.Symbol)
+	assetVer, err := asset.Version(assetID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve asset %q version: %w", symbol, err)
+	}
+	unitInfo, err := asset.UnitInfo(assetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ba := &asset.BackedAsset{
+		Asset: dex.Asset{
+			ID:         assetID,
+			Symbol:     symbol,
+			Version:    assetVer,
+			MaxFeeRate: assetConf.MaxFeeRate,
+			SwapConf:   assetConf.SwapConf,
+			UnitInfo:   unitInfo,
+		},
+		Backend: be,
+	}
+	cfgAsset := &msgjson.Asset{
+		Symbol:     assetConf.Symbol,
+		ID:         assetID,
+		Version:    assetVer,
+		MaxFeeRate: assetConf.MaxFeeRate,
+		SwapConf:   uint16(assetConf.SwapConf),
+		UnitInfo:   unitInfo,
+	}
+	return ba, cfgAsset, nil
+}
+
 // NewDEX creates the dex manager and starts all subsystems. Use Stop to
 // shutdown cleanly. The Context is used to abort setup.
 //  1. Validate each specified asset.
@@ -602,24 +786,13 @@ type Bonder interface {
 //  9. Create and start the comms server.
 func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 	var subsystems []subsystem
-	startSubSys := func(name string, rc any) (err error) {
-		subsys := subsystem{name: name}
-		switch st := rc.(type) {
-		case dex.Runner:
-			subsys.ssw = dex.NewStartStopWaiter(st)
-			subsys.ssw.Start(context.Background()) // stopped with Stop
-		case dex.Connector:
-			subsys.cm = dex.NewConnectionMaster(st)
-			err = subsys.cm.Connect(context.Background()) // stopped with Disconnect
-			if err != nil {
-				return
-			}
-		default:
-			panic(fmt.Sprintf("Invalid subsystem type %T", rc))
+	startSubSys := func(name string, rc any) error {
+		subsys, err := newSubsystem(name, rc)
+		if err != nil {
+			return err
 		}
-
 		subsystems = append([]subsystem{subsys}, subsystems...) // top of stack
-		return
+		return nil
 	}
 
 	// Do not wrap the caller's context for the DB since we must coordinate it's
@@ -671,17 +844,31 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		assetIDs[i] = assetID
 	}
 
-	// Create DEXArchivist with the pg DB driver. The fee Addressers require the
-	// archivist for key index storage and retrieval.
-	pgCfg := &pg.Config{
-		Host:         cfg.DBConf.Host,
-		Port:         strconv.Itoa(int(cfg.DBConf.Port)),
-		User:         cfg.DBConf.User,
-		Pass:         cfg.DBConf.Pass,
-		DBName:       cfg.DBConf.DBName,
-		ShowPGConfig: cfg.DBConf.ShowPGConfig,
-		QueryTimeout: 20 * time.Minute,
-		MarketCfg:    cfg.Markets,
+	// Create DEXArchivist with the configured DB driver. The fee Addressers
+	// require the archivist for key index storage and retrieval.
+	var dbDriver string
+	var dbCfg any
+	switch cfg.DBConf.Driver {
+	case "", "pg":
+		dbDriver = "pg"
+		dbCfg = &pg.Config{
+			Host:         cfg.DBConf.Host,
+			Port:         strconv.Itoa(int(cfg.DBConf.Port)),
+			User:         cfg.DBConf.User,
+			Pass:         cfg.DBConf.Pass,
+			DBName:       cfg.DBConf.DBName,
+			ShowPGConfig: cfg.DBConf.ShowPGConfig,
+			QueryTimeout: 20 * time.Minute,
+			MarketCfg:    cfg.Markets,
+		}
+	case "bolt":
+		dbDriver = "bolt"
+		dbCfg = &bolt.Config{
+			Path:      cfg.DBConf.Path,
+			MarketCfg: cfg.Markets,
+		}
+	default:
+		return nil, fmt.Errorf("unknown DB driver %q", cfg.DBConf.Driver)
 	}
 	// After DEX construction, the storage subsystem should be stopped
 	// gracefully with its Close method, and in coordination with other
@@ -696,7 +883,7 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		case <-running: // DB shutdown now only via dex.Stop=>db.Close
 		}
 	}()
-	storage, err := db.Open(ctxDB, "pg", pgCfg)
+	storage, err := db.Open(ctxDB, dbDriver, dbCfg)
 	if err != nil {
 		return nil, fmt.Errorf("db.Open: %w", err)
 	}
@@ -753,43 +940,13 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 	addAsset := func(assetID uint32, assetConf *Asset) error {
 		symbol := strings.ToLower(assetConf.Symbol)
 
-		assetVer, err := asset.Version(assetID)
-		if err != nil {
-			return fmt.Errorf("failed to retrieve asset %q version: %w", symbol, err)
-		}
-
 		// Create a new asset backend. An asset driver with a name matching the
 		// asset symbol must be available.
 		log.Infof("Starting asset backend %q...", symbol)
-		logger := assetLogger.SubLogger(symbol)
-
-		isToken, parentID := asset.IsToken(assetID)
-		var be asset.Backend
-		if isToken {
-			parent, found := backedAssets[parentID]
-			if !found {
-				return fmt.Errorf("attempting to load token asset %d before parent %d", assetID, parentID)
-			}
-			backer, is := parent.Backend.(asset.TokenBacker)
-			if !is {
-				return fmt.Errorf("token %d parent %d is not a TokenBacker", assetID, parentID)
-			}
-			be, err = backer.TokenBackend(assetID, assetConf.ConfigPath)
-			if err != nil {
-				return fmt.Errorf("failed to setup token %q: %w", symbol, err)
-			}
-		} else {
-			cfg := &asset.BackendConfig{
-				AssetID:    assetID,
-				ConfigPath: assetConf.ConfigPath,
-				Logger:     logger,
-				Net:        cfg.Network,
-				RelayAddr:  relayAddrs[assetConf.NodeRelayID],
-			}
-			be, err = asset.Setup(cfg)
-			if err != nil {
-				return fmt.Errorf("failed to setup asset %q: %w", symbol, err)
-			}
+		be, err := newAssetBackend(assetID, assetConf, cfg.Network, assetLogger.SubLogger(symbol),
+			relayAddrs[assetConf.NodeRelayID], backedAssets)
+		if err != nil {
+			return err
 		}
 
 		err = startSubSys(fmt.Sprintf("Asset[%s]", symbol), be)
@@ -814,7 +971,7 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 				symbol, assetConf.BondAmt, assetConf.BondConfs)
 		}
 
-		unitInfo, err := asset.UnitInfo(assetID)
+		ba, cfgAsset, err := newBackedAsset(assetID, assetConf, be)
 		if err != nil {
 			return err
 		}
@@ -824,18 +981,6 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 			coinLocker = dexCoinLocker.AssetLocker(assetID).Swap()
 		}
 
-		ba := &asset.BackedAsset{
-			Asset: dex.Asset{
-				ID:         assetID,
-				Symbol:     symbol,
-				Version:    assetVer,
-				MaxFeeRate: assetConf.MaxFeeRate,
-				SwapConf:   assetConf.SwapConf,
-				UnitInfo:   unitInfo,
-			},
-			Backend: be,
-		}
-
 		backedAssets[assetID] = ba
 		lockableAssets[assetID] = &swap.SwapperAsset{
 			BackedAsset: ba,
@@ -844,14 +989,7 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		feeMgr.AddFetcher(ba)
 
 		// Prepare assets portion of config response.
-		cfgAssets = append(cfgAssets, &msgjson.Asset{
-			Symbol:     assetConf.Symbol,
-			ID:         assetID,
-			Version:    assetVer,
-			MaxFeeRate: assetConf.MaxFeeRate,
-			SwapConf:   uint16(assetConf.SwapConf),
-			UnitInfo:   unitInfo,
-		})
+		cfgAssets = append(cfgAssets, cfgAsset)
 
 		txDataSources[assetID] = be.TxData
 		return nil
@@ -877,18 +1015,40 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		}
 	}
 
+	startSubSys("Block height monitor", &blockHeightMonitor{assets: backedAssets})
+
+	// NOTE: If MaxUserCancelsPerEpoch is ultimately a setting we want to keep,
+	// bake it into the markets.json file and load it per-market in settings.go.
+	// For now, patch it into each dex.MarketInfo.
 	for _, mkt := range cfg.Markets {
 		mkt.Name = strings.ToLower(mkt.Name)
+		mkt.MaxUserCancelsPerEpoch = cfg.MaxUserCancels
 	}
 
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	// Create the user order unbook dispatcher for the AuthManager.
+	// The DEX manager is created now since the user order unbook and swapDone
+	// dispatchers use its markets map, which may change while running. The
+	// remaining fields are set as the subsystems are created.
 	markets := make(map[string]*market.Market, len(cfg.Markets))
+	dexMgr := &DEX{
+		network:         cfg.Network,
+		markets:         markets,
+		retired:         make(map[string]*market.Market),
+		storedMarkets:   make(map[string]bool),
+		assets:          lockableAssets,
+		marketsConfPath: cfg.MarketsConfPath,
+		maxUserCancels:  cfg.MaxUserCancels,
+		assetLogger:     assetLogger,
+		coinLocker:      dexCoinLocker,
+		feeMgr:          feeMgr,
+	}
+
+	// Create the user order unbook dispatcher for the AuthManager.
 	userUnbookFun := func(user account.AccountID) {
-		for _, mkt := range markets {
+		for _, mkt := range dexMgr.marketList() {
 			mkt.UnbookUserOrders(user)
 		}
 	}
@@ -958,7 +1118,12 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 			log.Errorf("bad market for order %v: %v", ord.ID(), err)
 			return
 		}
-		markets[name].SwapDone(ord, match, fail)
+		mkt := dexMgr.swapMarket(name)
+		if mkt == nil {
+			log.Errorf("unknown market %s for order %v", name, ord.ID())
+			return
+		}
+		mkt.SwapDone(ord, match, fail)
 	}
 
 	// Create the swapper.
@@ -1009,44 +1174,18 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		return nil, fmt.Errorf("NewDEXBalancer error: %w", err)
 	}
 
+	dexMgr.storage = storage
+	dexMgr.authMgr = authMgr
+	dexMgr.swapper = swapper
+	dexMgr.dataAPI = dataAPI
+	dexMgr.dexBalancer = dexBalancer
+
 	// Markets
-	var orderRouter *market.OrderRouter
 	usersWithOrders := make(map[account.AccountID]struct{})
 	for _, mktInf := range cfg.Markets {
-		// nilness of the coin locker signals account-based asset.
-		var baseCoinLocker, quoteCoinLocker coinlock.CoinLocker
-		b, q := backedAssets[mktInf.Base], backedAssets[mktInf.Quote]
-		if _, ok := b.Backend.(asset.OutputTracker); ok {
-			baseCoinLocker = dexCoinLocker.AssetLocker(mktInf.Base).Book()
-		}
-		if _, ok := q.Backend.(asset.OutputTracker); ok {
-			quoteCoinLocker = dexCoinLocker.AssetLocker(mktInf.Quote).Book()
-		}
-
-		// Calculate a minimum market rate that avoids dust.
-		// quote_dust = base_lot * min_rate / rate_encoding_factor
-		// => min_rate = quote_dust * rate_encoding_factor * base_lot
-		quoteMinLotSize, _, _ := asset.Minimums(mktInf.Quote, q.Asset.MaxFeeRate)
-		minRate := calc.MinimumMarketRate(mktInf.LotSize, quoteMinLotSize)
-
-		mkt, err := market.NewMarket(&market.Config{
-			MarketInfo:      mktInf,
-			Storage:         storage,
-			Swapper:         swapper,
-			AuthManager:     authMgr,
-			FeeFetcherBase:  feeMgr.FeeFetcher(mktInf.Base),
-			CoinLockerBase:  baseCoinLocker,
-			FeeFetcherQuote: feeMgr.FeeFetcher(mktInf.Quote),
-			CoinLockerQuote: quoteCoinLocker,
-			DataCollector:   dataAPI,
-			Balancer:        dexBalancer,
-			CheckParcelLimit: func(user account.AccountID, calcParcels market.MarketParcelCalculator) bool {
-				return orderRouter.CheckParcelLimit(user, mktInf.Name, calcParcels)
-			},
-			MinimumRate: minRate,
-		})
+		mkt, err := dexMgr.newMarket(mktInf)
 		if err != nil {
-			return nil, fmt.Errorf("NewMarket failed: %w", err)
+			return nil, err
 		}
 		markets[mktInf.Name] = mkt
 		marketTunnels[mktInf.Name] = mkt
@@ -1114,7 +1253,7 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 	}
 
 	// Order router
-	orderRouter = market.NewOrderRouter(&market.OrderRouterConfig{
+	orderRouter := market.NewOrderRouter(&market.OrderRouterConfig{
 		Assets:       backedAssets,
 		AuthManager:  authMgr,
 		Markets:      marketTunnels,
@@ -1133,19 +1272,11 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		return nil, err
 	}
 
-	dexMgr := &DEX{
-		network:     cfg.Network,
-		markets:     markets,
-		assets:      lockableAssets,
-		swapper:     swapper,
-		authMgr:     authMgr,
-		storage:     storage,
-		orderRouter: orderRouter,
-		bookRouter:  bookRouter,
-		subsystems:  subsystems,
-		server:      server,
-		configResp:  cfgResp,
-	}
+	dexMgr.orderRouter = orderRouter
+	dexMgr.bookRouter = bookRouter
+	dexMgr.server = server
+	dexMgr.configResp = cfgResp
+	dexMgr.subsystems = subsystems
 
 	server.RegisterHTTP(msgjson.ConfigRoute, dexMgr.handleDEXConfig)
 	server.RegisterHTTP(msgjson.HealthRoute, dexMgr.handleHealthFlag)
@@ -1164,6 +1295,8 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 		rr.With(candleParamsParser).Get("/candles/{baseSymbol}/{quoteSymbol}/{binSize}", server.NewRouteHandler(msgjson.CandlesRoute))
 		rr.With(candleParamsParser).Get("/candles/{baseSymbol}/{quoteSymbol}/{binSize}/{count}", server.NewRouteHandler(msgjson.CandlesRoute))
 		rr.With(orderBookParamsParser).Get("/orderbook/{baseSymbol}/{quoteSymbol}", server.NewRouteHandler(msgjson.OrderBookRoute))
+		rr.With(tradesParamsParser).Get("/trades/{baseSymbol}/{quoteSymbol}", server.NewRouteHandler(msgjson.TradesRoute))
+		rr.With(tradesParamsParser).Get("/trades/{baseSymbol}/{quoteSymbol}/{count}", server.NewRouteHandler(msgjson.TradesRoute))
 	})
 
 	startSubSys("Comms Server", server)
@@ -1173,10 +1306,95 @@ func NewDEX(ctx context.Context, cfg *DexConf) (*DEX, error) {
 	return dexMgr, nil
 }
 
+// newMarket creates a Market for a configured market. The backends for the
+// market's assets must be running.
+func (dm *DEX) newMarket(mktInf *dex.MarketInfo) (*market.Market, error) {
+	dm.mtx.RLock()
+	b, q := dm.assets[mktInf.Base], dm.assets[mktInf.Quote]
+	dm.mtx.RUnlock()
+	if b == nil || q == nil {
+		return nil, fmt.Errorf("missing asset backend for market %s", mktInf.Name)
+	}
+
+	// nilness of the coin locker signals account-based asset.
+	var baseCoinLocker, quoteCoinLocker coinlock.CoinLocker
+	if _, ok := b.Backend.(asset.OutputTracker); ok {
+		baseCoinLocker = dm.coinLocker.AssetLocker(mktInf.Base).Book()
+	}
+	if _, ok := q.Backend.(asset.OutputTracker); ok {
+		quoteCoinLocker = dm.coinLocker.AssetLocker(mktInf.Quote).Book()
+	}
+
+	name := mktInf.Name
+	mkt, err := market.NewMarket(&market.Config{
+		MarketInfo:      mktInf,
+		Storage:         dm.storage,
+		Swapper:         dm.swapper,
+		AuthManager:     dm.authMgr,
+		FeeFetcherBase:  dm.feeMgr.FeeFetcher(mktInf.Base),
+		CoinLockerBase:  baseCoinLocker,
+		FeeFetcherQuote: dm.feeMgr.FeeFetcher(mktInf.Quote),
+		CoinLockerQuote: quoteCoinLocker,
+		DataCollector:   dm.dataAPI,
+		Balancer:        dm.dexBalancer,
+		CheckParcelLimit: func(user account.AccountID, calcParcels market.MarketParcelCalculator) bool {
+			return dm.orderRouter.CheckParcelLimit(user, name, calcParcels)
+		},
+		MinimumRate: minimumMarketRate(mktInf.LotSize, q),
+		OnParamsChange: func(change *market.ParamsChange) {
+			dm.marketParamsChanged(name, change)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("NewMarket failed: %w", err)
+	}
+	return mkt, nil
+}
+
+// minimumMarketRate calculates a minimum market rate that avoids dust.
+// quote_dust = base_lot * min_rate / rate_encoding_factor
+// => min_rate = quote_dust * rate_encoding_factor * base_lot
+func minimumMarketRate(lotSize uint64, quote *swap.SwapperAsset) uint64 {
+	quoteMinLotSize, _, _ := asset.Minimums(quote.ID, quote.Asset.MaxFeeRate)
+	return calc.MinimumMarketRate(lotSize, quoteMinLotSize)
+}
+
+// market retrieves a running or suspended market by name.
+func (dm *DEX) market(name string) *market.Market {
+	dm.mtx.RLock()
+	defer dm.mtx.RUnlock()
+	return dm.markets[name]
+}
+
+// marketList lists the running and suspended markets.
+func (dm *DEX) marketList() []*market.Market {
+	dm.mtx.RLock()
+	defer dm.mtx.RUnlock()
+	return slices.Collect(maps.Values(dm.markets))
+}
+
+// swapMarket retrieves the market for a finished swap, which may have been
+// retired after the match was made.
+func (dm *DEX) swapMarket(name string) *market.Market {
+	dm.mtx.RLock()
+	defer dm.mtx.RUnlock()
+	if mkt := dm.markets[name]; mkt != nil {
+		return mkt
+	}
+	return dm.retired[name]
+}
+
+// swapperAsset retrieves an asset by its ID, or nil if the asset is unknown.
+func (dm *DEX) swapperAsset(id uint32) *swap.SwapperAsset {
+	dm.mtx.RLock()
+	defer dm.mtx.RUnlock()
+	return dm.assets[id]
+}
+
 // Asset retrieves an asset backend by its ID.
 func (dm *DEX) Asset(id uint32) (*asset.BackedAsset, error) {
-	asset, found := dm.assets[id]
-	if !found {
+	asset := dm.swapperAsset(id)
+	if asset == nil {
 		return nil, fmt.Errorf("no backend for asset %d", id)
 	}
 	return asset.BackedAsset, nil
@@ -1186,7 +1404,7 @@ func (dm *DEX) Asset(id uint32) (*asset.BackedAsset, error) {
 // the optimal fee rates for new swaps for for the specified asset. That is,
 // values above 1 increase the fee rate, while values below 1 decrease it.
 func (dm *DEX) SetFeeRateScale(assetID uint32, scale float64) {
-	for _, mkt := range dm.markets {
+	for _, mkt := range dm.marketList() {
 		if mkt.Base() == assetID || mkt.Quote() == assetID {
 			mkt.SetFeeRateScale(assetID, scale)
 		}
@@ -1197,7 +1415,7 @@ func (dm *DEX) SetFeeRateScale(assetID uint32, scale float64) {
 // rate scale factor, which is 1.0 by default.
 func (dm *DEX) ScaleFeeRate(assetID uint32, rate uint64) uint64 {
 	// Any market will have the rate. Just find the first one.
-	for _, mkt := range dm.markets {
+	for _, mkt := range dm.marketList() {
 		if mkt.Base() == assetID || mkt.Quote() == assetID {
 			return mkt.ScaleFeeRate(assetID, rate)
 		}
@@ -1215,7 +1433,7 @@ func (dm *DEX) ConfigMsg() json.RawMessage {
 // TODO: for just market running status, the DEX manager should use its
 // knowledge of Market subsystem state.
 func (dm *DEX) MarketRunning(mktName string) (found, running bool) {
-	mkt := dm.markets[mktName]
+	mkt := dm.market(mktName)
 	if mkt == nil {
 		return
 	}
@@ -1225,7 +1443,7 @@ func (dm *DEX) MarketRunning(mktName string) (found, running bool) {
 // MarketStatus returns the market.Status for the named market. If the market is
 // unknown to the DEX, nil is returned.
 func (dm *DEX) MarketStatus(mktName string) *market.Status {
-	mkt := dm.markets[mktName]
+	mkt := dm.market(mktName)
 	if mkt == nil {
 		return nil
 	}
@@ -1235,6 +1453,8 @@ func (dm *DEX) MarketStatus(mktName string) *market.Status {
 // MarketStatuses returns a map of market names to market.Status for all known
 // markets.
 func (dm *DEX) MarketStatuses() map[string]*market.Status {
+	dm.mtx.RLock()
+	defer dm.mtx.RUnlock()
 	statuses := make(map[string]*market.Status, len(dm.markets))
 	for name, mkt := range dm.markets {
 		statuses[name] = mkt.Status()
@@ -1251,12 +1471,15 @@ func (dm *DEX) SuspendMarket(name string, tSusp time.Time, persistBooks bool) (s
 	name = strings.ToLower(name)
 
 	// Locate the (running) subsystem for this market.
+	dm.mtx.RLock()
 	i := dm.findSubsys(marketSubSysName(name))
+	running := i != -1 && dm.subsystems[i].ssw.On()
+	dm.mtx.RUnlock()
 	if i == -1 {
 		err = fmt.Errorf("market subsystem %s not found", name)
 		return
 	}
-	if !dm.subsystems[i].ssw.On() {
+	if !running {
 		err = fmt.Errorf("market subsystem %s is not running", name)
 		return
 	}
@@ -1290,6 +1513,7 @@ func (dm *DEX) SuspendMarket(name string, tSusp time.Time, persistBooks bool) (s
 	return
 }
 
+// findSubsys locates the named subsystem. The mtx must be locked.
 func (dm *DEX) findSubsys(name string) int {
 	for i := range dm.subsystems {
 		if dm.subsystems[i].name == name {
@@ -1304,6 +1528,8 @@ func (dm *DEX) findSubsys(name string) int {
 // duration, as the market only starts at the beginning of an epoch.
 func (dm *DEX) ResumeMarket(name string, asSoonAs time.Time) (startEpoch int64, startTime time.Time, err error) {
 	name = strings.ToLower(name)
+	dm.mtx.Lock()
+	defer dm.mtx.Unlock()
 	mkt := dm.markets[name]
 	if mkt == nil {
 		err = fmt.Errorf("unknown market %s", name)
@@ -1415,7 +1641,7 @@ func (dm *DEX) Healthy() bool {
 		return false
 	}
 	if assetID, found := dex.BipSymbolID("btc"); found {
-		if synced, _ := dm.assets[assetID].Backend.Synced(); !synced {
+		if synced, _ := dm.swapperAsset(assetID).Backend.Synced(); !synced {
 			return false
 		}
 	}
@@ -1476,11 +1702,11 @@ func convertMatchData(baseAsset, quoteAsset asset.Backend, md *db.MatchDataWithC
 
 // MarketMatchesStreaming streams all matches for market with base and quote.
 func (dm *DEX) MarketMatchesStreaming(base, quote uint32, includeInactive bool, N int64, f func(*MatchData) error) (int, error) {
-	baseAsset := dm.assets[base]
+	baseAsset := dm.swapperAsset(base)
 	if baseAsset == nil {
 		return 0, fmt.Errorf("asset %d not found", base)
 	}
-	quoteAsset := dm.assets[quote]
+	quoteAsset := dm.swapperAsset(quote)
 	if quoteAsset == nil {
 		return 0, fmt.Errorf("asset %d not found", quote)
 	}
@@ -1493,11 +1719,11 @@ func (dm *DEX) MarketMatchesStreaming(base, quote uint32, includeInactive bool,
 
 // MarketMatches returns matches for market with base and quote.
 func (dm *DEX) MarketMatches(base, quote uint32) ([]*MatchData, error) {
-	baseAsset := dm.assets[base]
+	baseAsset := dm.swapperAsset(base)
 	if baseAsset == nil {
 		return nil, fmt.Errorf("asset %d not found", base)
 	}
-	quoteAsset := dm.assets[quote]
+	quoteAsset := dm.swapperAsset(quote)
 	if quoteAsset == nil {
 		return nil, fmt.Errorf("asset %d not found", quote)
 	}
@@ -1575,6 +1801,45 @@ func orderBookParamsParser(next http.Handler) http.Handler {
 	})
 }
 
+// tradesParamsParser is middleware for the /trades routes. Parses the
+// *msgjson.TradesRequest from the URL parameters and the optional "before"
+// query parameter, a time stamp in milliseconds.
+func tradesParamsParser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		baseID, quoteID, errMsg := parseBaseQuoteIDs(r)
+		if errMsg != "" {
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		countStr := chi.URLParam(r, "count")
+		count := 0
+		if countStr != "" {
+			count, err = strconv.Atoi(countStr)
+			if err != nil {
+				http.Error(w, "count unparseable", http.StatusBadRequest)
+				return
+			}
+		}
+		var before uint64
+		if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+			before, err = strconv.ParseUint(beforeStr, 10, 64)
+			if err != nil {
+				http.Error(w, "before unparseable", http.StatusBadRequest)
+				return
+			}
+		}
+		ctx := context.WithValue(r.Context(), comms.CtxThing, &msgjson.TradesRequest{
+			BaseID:    baseID,
+			QuoteID:   quoteID,
+			Before:    before,
+			NumTrades: count,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // parseBaseQuoteIDs parses the "baseSymbol" and "quoteSymbol" URL parameters
 // from the request.
 func parseBaseQuoteIDs(r *http.Request) (baseID, quoteID uint32, errMsg string) {
@@ -3,6 +3,7 @@ package dex
 import (
 	"context"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -10,8 +11,10 @@ import (
 	"decred.org/dcrdex/server/market"
 )
 
-// FeeManager manages fee fetchers and a fee cache.
+// FeeManager manages fee fetchers and a fee cache. Fetchers may be added while
+// the DEX is running, so the maps are protected by the mtx.
 type FeeManager struct {
+	mtx    sync.RWMutex
 	assets map[uint32]*asset.BackedAsset
 	cache  map[uint32]*uint64
 }
@@ -39,13 +42,20 @@ func (m *FeeManager) AddFetcher(asset *asset.BackedAsset) {
 	if rate > asset.MaxFeeRate {
 		rate = asset.MaxFeeRate
 	}
+	if rate > 0 {
+		assetFeeRate.With(asset.Symbol).Set(float64(rate))
+	}
+	m.mtx.Lock()
 	m.cache[asset.ID] = &rate
 	m.assets[asset.ID] = asset
+	m.mtx.Unlock()
 }
 
 // FeeFetcher creates and returns an asset-specific fetcher that satisfies
 // market.FeeFetcher, implemented by *feeFetcher.
 func (m *FeeManager) FeeFetcher(assetID uint32) market.FeeFetcher {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
 	asset := m.assets[assetID]
 	if asset == nil {
 		panic("no fetcher for " + strconv.Itoa(int(assetID)))
@@ -55,7 +65,9 @@ func (m *FeeManager) FeeFetcher(assetID uint32) market.FeeFetcher {
 
 // LastRate is the last rate cached for the specified asset.
 func (m *FeeManager) LastRate(assetID uint32) uint64 {
+	m.mtx.RLock()
 	r := m.cache[assetID]
+	m.mtx.RUnlock()
 	if r == nil {
 		return 0
 	}
@@ -92,6 +104,7 @@ func (f *feeFetcher) FeeRate(ctx context.Context) uint64 {
 		r = f.Asset.MaxFeeRate
 	}
 	atomic.StoreUint64(f.lastRate, r)
+	assetFeeRate.With(f.Symbol).Set(float64(r))
 	return r
 }
 
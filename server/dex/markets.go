@@ -0,0 +1,619 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package dex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/dex"
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/server/asset"
+	"decred.org/dcrdex/server/db"
+	"decred.org/dcrdex/server/market"
+	"decred.org/dcrdex/server/swap"
+)
+
+// MarketConfig describes a market to add with AddMarket. Market.Base and
+// Market.Quote are keys of the assets in the markets file. Assets lists any of
+// the market's assets that are not already in the markets file.
+type MarketConfig struct {
+	Market *Market           `json:"market"`
+	Assets map[string]*Asset `json:"assets,omitempty"`
+}
+
+// readMarketsConf reads the markets file.
+func readMarketsConf(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var conf Config
+	if err = json.Unmarshal(b, &conf); err != nil {
+		return nil, fmt.Errorf("error parsing markets file: %w", err)
+	}
+	if conf.Assets == nil {
+		conf.Assets = make(map[string]*Asset)
+	}
+	return &conf, nil
+}
+
+// writeMarketsConf replaces the markets file. The new file is written next to
+// the old one and then renamed so that a failed write does not leave a
+// truncated markets file.
+func writeMarketsConf(path string, conf *Config) error {
+	b, err := json.MarshalIndent(conf, "", "    ")
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0600)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+	if _, err = tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// validateMarketsConf checks that the Config would load without error.
+func validateMarketsConf(net dex.Network, conf *Config) ([]*dex.MarketInfo, []*Asset, error) {
+	b, err := json.Marshal(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	markets, assets, err := loadMarketConf(net, bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid market configuration: %w", err)
+	}
+	return markets, assets, nil
+}
+
+// confMarketName is the market name for a market in the Config, or an empty
+// string if the market's assets are not configured for the network.
+func confMarketName(net dex.Network, conf *Config, mkt *Market) string {
+	base, quote := conf.Assets[mkt.Base], conf.Assets[mkt.Quote]
+	if base == nil || quote == nil {
+		return ""
+	}
+	if baseNet, err := dex.NetFromString(base.Network); err != nil || baseNet != net {
+		return ""
+	}
+	baseID, found := dex.BipSymbolID(strings.ToLower(base.Symbol))
+	if !found {
+		return ""
+	}
+	quoteID, found := dex.BipSymbolID(strings.ToLower(quote.Symbol))
+	if !found {
+		return ""
+	}
+	name, err := dex.MarketName(baseID, quoteID)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// disableUnusedAssets disables the network's assets that are not used by any
+// enabled market, since LoadConfig rejects unused assets.
+func disableUnusedAssets(net dex.Network, conf *Config) {
+	used := make(map[uint32]bool)
+	for _, mkt := range conf.Markets {
+		if mkt.Disabled {
+			continue
+		}
+		for _, key := range []string{mkt.Base, mkt.Quote} {
+			a := conf.Assets[key]
+			if a == nil {
+				continue
+			}
+			assetID, found := dex.BipSymbolID(strings.ToLower(a.Symbol))
+			if !found {
+				continue
+			}
+			used[assetID] = true
+			if isToken, parentID := asset.IsToken(assetID); isToken {
+				used[parentID] = true
+			}
+		}
+	}
+	for _, a := range conf.Assets {
+		if a.Disabled {
+			continue
+		}
+		if assetNet, err := dex.NetFromString(a.Network); err != nil || assetNet != net {
+			continue
+		}
+		if assetID, found := dex.BipSymbolID(strings.ToLower(a.Symbol)); found && !used[assetID] {
+			a.Disabled = true
+		}
+	}
+}
+
+// startSubsys starts a subsystem while the DEX is running.
+func (dm *DEX) startSubsys(name string, rc any) error {
+	subsys, err := newSubsystem(name, rc)
+	if err != nil {
+		return err
+	}
+	dm.mtx.Lock()
+	dm.subsystems = append([]subsystem{subsys}, dm.subsystems...) // top of stack
+	dm.mtx.Unlock()
+	return nil
+}
+
+// addAsset starts the backend for an asset that is needed by a market added
+// with AddMarket, and adds the asset to the subsystems that use it. Assets that
+// require a node relay, accept bonds, or are account-based are only supported
+// at startup.
+func (dm *DEX) addAsset(assetID uint32, assetConf *Asset) (*msgjson.Asset, error) {
+	symbol := strings.ToLower(assetConf.Symbol)
+	if assetConf.NodeRelayID != "" {
+		return nil, fmt.Errorf("asset %q uses a node relay and cannot be added without a restart", symbol)
+	}
+	if assetConf.BondAmt > 0 {
+		return nil, fmt.Errorf("bond asset %q cannot be added without a restart", symbol)
+	}
+
+	dm.mtx.RLock()
+	backedAssets := make(map[uint32]*asset.BackedAsset, len(dm.assets))
+	for id, a := range dm.assets {
+		backedAssets[id] = a.BackedAsset
+	}
+	dm.mtx.RUnlock()
+
+	log.Infof("Starting asset backend %q...", symbol)
+	be, err := newAssetBackend(assetID, assetConf, dm.network, dm.assetLogger.SubLogger(symbol), "", backedAssets)
+	if err != nil {
+		return nil, err
+	}
+	// The balance trackers of account-based assets are set up at startup.
+	if _, is := be.(asset.AccountBalancer); is {
+		return nil, fmt.Errorf("account-based asset %q cannot be added without a restart", symbol)
+	}
+
+	ba, cfgAsset, err := newBackedAsset(assetID, assetConf, be)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = dm.startSubsys(fmt.Sprintf("Asset[%s]", symbol), be); err != nil {
+		return nil, fmt.Errorf("failed to start asset %q: %w", symbol, err)
+	}
+
+	sa := &swap.SwapperAsset{BackedAsset: ba}
+	if err = dm.swapper.AddAsset(sa); err != nil {
+		return nil, err
+	}
+	dm.coinLocker.AddAsset(assetID)
+	dm.feeMgr.AddFetcher(ba)
+	dm.authMgr.AddTxDataSource(assetID, be.TxData)
+
+	dm.mtx.Lock()
+	dm.assets[assetID] = sa
+	dm.mtx.Unlock()
+
+	err = dm.startSubsys(fmt.Sprintf("Block height monitor[%s]", symbol),
+		&blockHeightMonitor{assets: map[uint32]*asset.BackedAsset{assetID: ba}})
+	if err != nil {
+		return nil, err
+	}
+
+	return cfgAsset, nil
+}
+
+// AddMarket adds and starts a new market, starting the backends for any of its
+// assets that are not already running. The market, and any new assets, are
+// saved to the markets file so that they are loaded on the next start. A market
+// that was retired with RetireMarket may be added again.
+func (dm *DEX) AddMarket(mktCfg *MarketConfig) (name string, startEpoch int64, startTime time.Time, err error) {
+	if mktCfg == nil || mktCfg.Market == nil {
+		err = errors.New("no market specified")
+		return
+	}
+
+	dm.mktMgmtMtx.Lock()
+	defer dm.mktMgmtMtx.Unlock()
+
+	if dm.marketsConfPath == "" {
+		err = errors.New("no markets file to save the market to")
+		return
+	}
+	archiver, ok := dm.storage.(db.MarketAdder)
+	if !ok {
+		err = errors.New("storage backend does not support adding markets")
+		return
+	}
+
+	conf, err := readMarketsConf(dm.marketsConfPath)
+	if err != nil {
+		return
+	}
+
+	for key, a := range mktCfg.Assets {
+		if a == nil {
+			err = fmt.Errorf("no configuration for asset %s", key)
+			return
+		}
+		if cur := conf.Assets[key]; cur != nil && !cur.Disabled {
+			err = fmt.Errorf("asset %s is already configured", key)
+			return
+		}
+		a := *a
+		a.Disabled = false
+		conf.Assets[key] = &a
+	}
+	// The assets of a retired market may have been disabled.
+	for _, key := range []string{mktCfg.Market.Base, mktCfg.Market.Quote} {
+		if a := conf.Assets[key]; a != nil {
+			a.Disabled = false
+		}
+	}
+
+	mktConf := *mktCfg.Market
+	mktConf.Disabled = false
+	name = confMarketName(dm.network, conf, &mktConf)
+	if name == "" {
+		err = fmt.Errorf("unknown %s assets for market (%s, %s)", dm.network, mktConf.Base, mktConf.Quote)
+		return
+	}
+	i := slices.IndexFunc(conf.Markets, func(mkt *Market) bool {
+		return confMarketName(dm.network, conf, mkt) == name
+	})
+	switch {
+	case i == -1:
+		conf.Markets = append(conf.Markets, &mktConf)
+	case conf.Markets[i].Disabled:
+		conf.Markets[i] = &mktConf
+	default:
+		err = fmt.Errorf("market %s is already configured", name)
+		return
+	}
+
+	markets, assets, err := validateMarketsConf(dm.network, conf)
+	if err != nil {
+		return
+	}
+	idx := slices.IndexFunc(markets, func(mkt *dex.MarketInfo) bool { return mkt.Name == name })
+	if idx == -1 {
+		err = fmt.Errorf("market %s is not a %s market", name, dm.network)
+		return
+	}
+	mktInf := markets[idx]
+	mktInf.MaxUserCancelsPerEpoch = dm.maxUserCancels
+	dm.mtx.RLock()
+	_, running := dm.markets[name]
+	retiredMkt, retired := dm.retired[name]
+	dm.mtx.RUnlock()
+	if running {
+		err = fmt.Errorf("market %s is already running", name)
+		return
+	}
+
+	// Start the backends for new assets, parent assets before tokens. Assets
+	// are left running if the market fails to start, and are listed in the
+	// config response right away so that they are not missed when adding the
+	// market is retried.
+	startAsset := func(assetID uint32) error {
+		if dm.swapperAsset(assetID) != nil {
+			return nil
+		}
+		i := slices.IndexFunc(assets, func(a *Asset) bool {
+			id, _ := dex.BipSymbolID(strings.ToLower(a.Symbol))
+			return id == assetID
+		})
+		if i == -1 {
+			return fmt.Errorf("no configuration for asset %s", dex.BipIDSymbol(assetID))
+		}
+		cfgAsset, err := dm.addAsset(assetID, assets[i])
+		if err != nil {
+			return err
+		}
+		dm.configRespMtx.Lock()
+		dm.configResp.addAssets(cfgAsset)
+		dm.configRespMtx.Unlock()
+		return nil
+	}
+	for _, assetID := range []uint32{mktInf.Base, mktInf.Quote} {
+		if isToken, parentID := asset.IsToken(assetID); isToken {
+			if err = startAsset(parentID); err != nil {
+				return
+			}
+		}
+		if err = startAsset(assetID); err != nil {
+			return
+		}
+	}
+
+	// Storage still has a market that was retired since startup, or that was
+	// added to storage by an earlier attempt that failed, but the lot size
+	// may have changed.
+	if retired || dm.storedMarkets[name] {
+		lsu, ok := dm.storage.(db.LotSizeUpdater)
+		if !ok {
+			err = errors.New("storage backend does not support changing lot sizes")
+			return
+		}
+		if err = lsu.UpdateLotSize(mktInf.Base, mktInf.Quote, mktInf.LotSize); err != nil {
+			err = fmt.Errorf("failed to update lot size of market %s in storage: %w", name, err)
+			return
+		}
+	} else {
+		if err = archiver.AddMarket(mktInf); err != nil {
+			err = fmt.Errorf("failed to add market %s to storage: %w", name, err)
+			return
+		}
+		dm.storedMarkets[name] = true
+	}
+	mkt, err := dm.newMarket(mktInf)
+	if err != nil {
+		return
+	}
+	if err = dm.dataAPI.AddMarketSource(mkt); err != nil {
+		err = fmt.Errorf("DataSource.AddMarketSource: %w", err)
+		return
+	}
+
+	startEpoch = 1 + time.Now().UnixMilli()/int64(mkt.EpochDuration())
+	startTimeMS := startEpoch * int64(mkt.EpochDuration())
+	startTime = time.UnixMilli(startTimeMS)
+	mkt.SetStartEpochIdx(startEpoch)
+	if err = dm.bookRouter.AddBook(name, mkt); err != nil {
+		dm.dataAPI.RemoveMarketSource(name)
+		return
+	}
+	dm.orderRouter.AddMarket(name, mkt, dm.swapperAsset(mktInf.Base).BackedAsset,
+		dm.swapperAsset(mktInf.Quote).BackedAsset)
+
+	dm.mtx.Lock()
+	dm.markets[name] = mkt
+	delete(dm.retired, name)
+	dm.mtx.Unlock()
+	if err = dm.startSubsys(marketSubSysName(name), mkt); err != nil {
+		// Undo the registration so that adding the market can be retried.
+		dm.mtx.Lock()
+		delete(dm.markets, name)
+		if retired {
+			dm.retired[name] = retiredMkt
+		}
+		dm.mtx.Unlock()
+		dm.orderRouter.RemoveMarket(name)
+		if err := dm.bookRouter.RemoveBook(name); err != nil {
+			log.Errorf("Failed to remove book for market %s: %v", name, err)
+		}
+		dm.dataAPI.RemoveMarketSource(name)
+		return
+	}
+
+	dm.configRespMtx.Lock()
+	dm.configResp.addMarket(&msgjson.Market{
+		Name:            name,
+		Base:            mkt.Base(),
+		Quote:           mkt.Quote(),
+		LotSize:         mkt.LotSize(),
+		RateStep:        mkt.RateStep(),
+		EpochLen:        mkt.EpochDuration(),
+		MarketBuyBuffer: mkt.MarketBuyBuffer(),
+		ParcelSize:      mkt.ParcelSize(),
+		MarketStatus: msgjson.MarketStatus{
+			StartEpoch: uint64(startEpoch),
+		},
+	})
+	dm.configRespMtx.Unlock()
+
+	log.Infof("Added market %s, starting at epoch %d (%v).", name, startEpoch, startTime)
+
+	// Clients learn of the new market from a TradeResumption for a market
+	// that is not in their copy of the config, and refresh it.
+	note, errMsg := msgjson.NewNotification(msgjson.ResumptionRoute, msgjson.TradeResumption{
+		MarketID:   name,
+		ResumeTime: uint64(startTimeMS),
+		StartEpoch: uint64(startEpoch),
+	})
+	if errMsg != nil {
+		log.Errorf("Failed to create resume notification: %v", errMsg)
+	} else {
+		dm.server.Broadcast(note)
+	}
+
+	if err = writeMarketsConf(dm.marketsConfPath, conf); err != nil {
+		err = fmt.Errorf("market %s was added but the markets file was not updated: %w", name, err)
+	}
+	return
+}
+
+// RetireMarket permanently removes a market. The market must already be
+// suspended, and any orders left on its book are revoked. The market is
+// disabled in the markets file, along with any assets that are no longer used
+// by an enabled market. Asset backends are left running since swaps for
+// matches made before the market was suspended may still be active.
+func (dm *DEX) RetireMarket(name string) error {
+	name = strings.ToLower(name)
+
+	dm.mktMgmtMtx.Lock()
+	defer dm.mktMgmtMtx.Unlock()
+
+	if dm.marketsConfPath == "" {
+		return errors.New("no markets file to remove the market from")
+	}
+
+	conf, err := readMarketsConf(dm.marketsConfPath)
+	if err != nil {
+		return err
+	}
+	for _, mkt := range conf.Markets {
+		if !mkt.Disabled && confMarketName(dm.network, conf, mkt) == name {
+			mkt.Disabled = true
+		}
+	}
+	disableUnusedAssets(dm.network, conf)
+	if _, _, err = validateMarketsConf(dm.network, conf); err != nil {
+		return err
+	}
+
+	dm.mtx.Lock()
+	mkt := dm.markets[name]
+	if mkt == nil {
+		dm.mtx.Unlock()
+		return fmt.Errorf("unknown market %s", name)
+	}
+	i := dm.findSubsys(marketSubSysName(name))
+	if i >= 0 && dm.subsystems[i].ssw.On() {
+		dm.mtx.Unlock()
+		return fmt.Errorf("market %s is running. Suspend it first", name)
+	}
+	if i >= 0 {
+		dm.subsystems = slices.Delete(dm.subsystems, i, i+1)
+	}
+	delete(dm.markets, name)
+	dm.retired[name] = mkt
+	dm.mtx.Unlock()
+
+	dm.orderRouter.RemoveMarket(name)
+	mkt.PurgeBook()
+	if err = dm.bookRouter.RemoveBook(name); err != nil {
+		log.Errorf("Failed to remove book for market %s: %v", name, err)
+	}
+	dm.dataAPI.RemoveMarketSource(name)
+
+	dm.configRespMtx.Lock()
+	dm.configResp.removeMarket(name)
+	dm.configRespMtx.Unlock()
+
+	log.Infof("Retired market %s.", name)
+
+	if err = writeMarketsConf(dm.marketsConfPath, conf); err != nil {
+		return fmt.Errorf("market %s was retired but the markets file was not updated: %w", name, err)
+	}
+	return nil
+}
+
+// enabledConfMarket finds the enabled market with the given name in the
+// Config.
+func enabledConfMarket(net dex.Network, conf *Config, name string) *Market {
+	i := slices.IndexFunc(conf.Markets, func(mkt *Market) bool {
+		return !mkt.Disabled && confMarketName(net, conf, mkt) == name
+	})
+	if i == -1 {
+		return nil
+	}
+	return conf.Markets[i]
+}
+
+// ScheduleMarketParamsChange schedules a change to a running market's lot size
+// and rate step, taking effect with the first epoch that starts at or after
+// asSoonAs. Clients are notified of the upcoming change, and booked orders
+// that do not conform to the new values are revoked when the change takes
+// effect. The markets file is updated when the change takes effect, so a
+// change that is scheduled but not yet in effect does not survive a restart.
+func (dm *DEX) ScheduleMarketParamsChange(name string, asSoonAs time.Time, lotSize, rateStep uint64) (changeEpoch int64, changeTime time.Time, err error) {
+	name = strings.ToLower(name)
+
+	dm.mktMgmtMtx.Lock()
+	defer dm.mktMgmtMtx.Unlock()
+
+	if dm.marketsConfPath == "" {
+		err = errors.New("no markets file to save the change to")
+		return
+	}
+	if _, ok := dm.storage.(db.LotSizeUpdater); !ok {
+		err = errors.New("storage backend does not support changing lot sizes")
+		return
+	}
+	mkt := dm.market(name)
+	if mkt == nil {
+		err = fmt.Errorf("unknown market %s", name)
+		return
+	}
+
+	// Check that the markets file would load with the new values.
+	conf, err := readMarketsConf(dm.marketsConfPath)
+	if err != nil {
+		return
+	}
+	mktConf := enabledConfMarket(dm.network, conf, name)
+	if mktConf == nil {
+		err = fmt.Errorf("market %s is not in the markets file", name)
+		return
+	}
+	mktConf.LotSize, mktConf.RateStep = lotSize, rateStep
+	if _, _, err = validateMarketsConf(dm.network, conf); err != nil {
+		return
+	}
+
+	minRate := minimumMarketRate(lotSize, dm.swapperAsset(mkt.Quote()))
+	changeEpoch, err = mkt.ScheduleParamsChange(asSoonAs, lotSize, rateStep, minRate)
+	if err != nil {
+		return
+	}
+	changeTime = time.UnixMilli(changeEpoch * int64(mkt.EpochDuration()))
+
+	change := msgjson.MarketParamsChange{
+		Epoch:    uint64(changeEpoch),
+		LotSize:  lotSize,
+		RateStep: rateStep,
+	}
+	dm.configRespMtx.Lock()
+	dm.configResp.setMktParamsChange(name, &change)
+	dm.configRespMtx.Unlock()
+
+	// Broadcast a MarketParamsNote to all connected clients.
+	note, errMsg := msgjson.NewNotification(msgjson.MarketParamsRoute, msgjson.MarketParamsNote{
+		MarketID:           name,
+		MarketParamsChange: change,
+	})
+	if errMsg != nil {
+		log.Errorf("Failed to create market params notification: %v", errMsg)
+		// Notification or not, the change is scheduled, so do not return error.
+	} else {
+		dm.server.Broadcast(note)
+	}
+	return
+}
+
+// marketParamsChanged updates the config response and the markets file when a
+// change scheduled with ScheduleMarketParamsChange takes effect.
+func (dm *DEX) marketParamsChanged(name string, change *market.ParamsChange) {
+	dm.configRespMtx.Lock()
+	dm.configResp.setMktParams(name, change.LotSize, change.RateStep, uint64(change.Epoch))
+	dm.configRespMtx.Unlock()
+
+	dm.mktMgmtMtx.Lock()
+	defer dm.mktMgmtMtx.Unlock()
+
+	conf, err := readMarketsConf(dm.marketsConfPath)
+	if err != nil {
+		log.Errorf("Failed to read markets file to save %s lot size and rate step: %v", name, err)
+		return
+	}
+	mktConf := enabledConfMarket(dm.network, conf, name)
+	if mktConf == nil {
+		log.Errorf("Market %s is no longer in the markets file. Not saving lot size and rate step.", name)
+		return
+	}
+	mktConf.LotSize, mktConf.RateStep = change.LotSize, change.RateStep
+	if err = writeMarketsConf(dm.marketsConfPath, conf); err != nil {
+		log.Errorf("Failed to save %s lot size and rate step to the markets file: %v", name, err)
+	}
+}
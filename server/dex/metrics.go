@@ -0,0 +1,56 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package dex
+
+import (
+	"context"
+	"sync"
+
+	"decred.org/dcrdex/server/asset"
+	"decred.org/dcrdex/server/metrics"
+)
+
+var (
+	assetBlockHeight = metrics.NewGaugeVec("dcrdex_asset_block_height",
+		"Height of the best block known to the asset backend.", "asset")
+	assetFeeRate = metrics.NewGaugeVec("dcrdex_asset_fee_rate",
+		"Last fee rate from the asset backend, limited by the asset's max fee rate.", "asset")
+)
+
+// blockHeightMonitor updates the block height metric for each asset backend
+// that is an asset.TipHeighter when it reports a new block.
+type blockHeightMonitor struct {
+	assets map[uint32]*asset.BackedAsset
+}
+
+// Run starts monitoring block updates, blocking until the context is
+// canceled. Part of the dex.Runner interface.
+func (m *blockHeightMonitor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, ba := range m.assets {
+		tipper, is := ba.Backend.(asset.TipHeighter)
+		if !is {
+			continue
+		}
+		height := assetBlockHeight.With(ba.Symbol)
+		height.Set(float64(tipper.TipHeight()))
+		blocks := ba.Backend.BlockChannel(8)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case _, ok := <-blocks:
+					if !ok {
+						return
+					}
+					height.Set(float64(tipper.TipHeight()))
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -112,6 +112,7 @@ type sigDataEpochReport struct {
 	baseFeeRate  uint64
 	quoteFeeRate uint64
 	matches      [][2]int64
+	trades       []*msgjson.MarketTrade
 }
 
 type sigDataNewEpoch struct {
@@ -203,6 +204,9 @@ type msgBook struct {
 	source        BookSource
 	baseID        uint32
 	quoteID       uint32
+	// stop stops the book's monitoring loop. It is set by the BookRouter when
+	// the loop is started, and is protected by the BookRouter's booksMtx.
+	stop context.CancelFunc
 }
 
 func (book *msgBook) setEpoch(idx int64) {
@@ -283,7 +287,11 @@ func (book *msgBook) addBulkOrders(epoch int64, orderSets ...[]*order.LimitOrder
 // of subscribers, and maintaining an intermediate copy of the orderbook in
 // message payload format for quick, full-book syncing.
 type BookRouter struct {
-	books     map[string]*msgBook
+	booksMtx sync.RWMutex
+	books    map[string]*msgBook
+	// startBook is set while Run is running, and starts the monitoring loop
+	// for a book added with AddBook. It is protected by the booksMtx.
+	startBook func(book *msgBook)
 	feeSource FeeSource
 
 	priceFeeders *subscribers
@@ -305,18 +313,7 @@ func NewBookRouter(sources map[string]BookSource, feeSource FeeSource, route fun
 		spots: make(map[string]*msgjson.Spot),
 	}
 	for mkt, src := range sources {
-		subs := &subscribers{
-			conns: make(map[uint64]comms.Link),
-		}
-		book := &msgBook{
-			name:    mkt,
-			orders:  make(map[order.OrderID]*msgjson.BookOrderNote),
-			subs:    subs,
-			source:  src,
-			baseID:  src.Base(),
-			quoteID: src.Quote(),
-		}
-		router.books[mkt] = book
+		router.books[mkt] = newMsgBook(mkt, src)
 	}
 	route(msgjson.OrderBookRoute, router.handleOrderBook)
 	route(msgjson.UnsubOrderBookRoute, router.handleUnsubOrderBook)
@@ -326,19 +323,84 @@ func NewBookRouter(sources map[string]BookSource, feeSource FeeSource, route fun
 	return router
 }
 
+func newMsgBook(mkt string, src BookSource) *msgBook {
+	return &msgBook{
+		name:   mkt,
+		orders: make(map[order.OrderID]*msgjson.BookOrderNote),
+		subs: &subscribers{
+			conns: make(map[uint64]comms.Link),
+		},
+		source:  src,
+		baseID:  src.Base(),
+		quoteID: src.Quote(),
+	}
+}
+
 // Run implements dex.Runner, and is blocking.
 func (r *BookRouter) Run(ctx context.Context) {
 	var wg sync.WaitGroup
-	for _, b := range r.books {
+	r.booksMtx.Lock()
+	r.startBook = func(b *msgBook) {
+		ctxBook, cancel := context.WithCancel(ctx)
+		b.stop = cancel
 		wg.Add(1)
-		go func(b *msgBook) {
-			r.runBook(ctx, b)
+		go func() {
+			r.runBook(ctxBook, b)
 			wg.Done()
-		}(b)
+		}()
 	}
+	for _, b := range r.books {
+		r.startBook(b)
+	}
+	r.booksMtx.Unlock()
+
+	<-ctx.Done()
+
+	r.booksMtx.Lock()
+	r.startBook = nil
+	r.booksMtx.Unlock()
 	wg.Wait()
 }
 
+// AddBook adds the order book for a new market. If the BookRouter is running,
+// the book's monitoring loop is started immediately.
+func (r *BookRouter) AddBook(mktName string, src BookSource) error {
+	r.booksMtx.Lock()
+	defer r.booksMtx.Unlock()
+	if _, found := r.books[mktName]; found {
+		return fmt.Errorf("market %s already has a book", mktName)
+	}
+	book := newMsgBook(mktName, src)
+	r.books[mktName] = book
+	if r.startBook != nil {
+		r.startBook(book)
+	}
+	return nil
+}
+
+// RemoveBook stops the monitoring loop for a market's order book and removes
+// the book. Subscribers receive no further updates for the market.
+func (r *BookRouter) RemoveBook(mktName string) error {
+	r.booksMtx.Lock()
+	defer r.booksMtx.Unlock()
+	book := r.books[mktName]
+	if book == nil {
+		return fmt.Errorf("market %s unknown", mktName)
+	}
+	if book.stop != nil {
+		book.stop()
+	}
+	delete(r.books, mktName)
+	return nil
+}
+
+// book retrieves the msgBook for the named market.
+func (r *BookRouter) book(mktName string) *msgBook {
+	r.booksMtx.RLock()
+	defer r.booksMtx.RUnlock()
+	return r.books[mktName]
+}
+
 // runBook is a monitoring loop for an order book.
 func (r *BookRouter) runBook(ctx context.Context, book *msgBook) {
 	// Get the initial book.
@@ -372,6 +434,7 @@ out:
 			var note any
 			var route string
 			var spot *msgjson.Spot
+			var tape *msgjson.TradeTapeNote
 			switch sigData := u.data.(type) {
 			case sigDataNewEpoch:
 				// New epoch index should be sent here by the market following
@@ -453,6 +516,14 @@ out:
 					MatchSummary: sigData.matches,
 				}
 
+				if len(sigData.trades) > 0 {
+					tape = &msgjson.TradeTapeNote{
+						MarketID: book.name,
+						Epoch:    uint64(sigData.epochIdx),
+						Trades:   sigData.trades,
+					}
+				}
+
 			case sigDataEpochOrder:
 				route = msgjson.EpochOrderRoute
 				epochNote := new(msgjson.EpochOrderNote)
@@ -538,6 +609,10 @@ out:
 
 			r.sendNote(route, subs, note)
 
+			if tape != nil {
+				r.sendNote(msgjson.TradeTapeRoute, subs, tape)
+			}
+
 			if spot != nil {
 				r.sendNote(msgjson.PriceUpdateRoute, r.priceFeeders, spot)
 			}
@@ -549,7 +624,7 @@ out:
 
 // Book creates a copy of the book as a *msgjson.OrderBook.
 func (r *BookRouter) Book(mktName string) (*msgjson.OrderBook, error) {
-	book := r.books[mktName]
+	book := r.book(mktName)
 	if book == nil {
 		return nil, fmt.Errorf("market %s unknown", mktName)
 	}
@@ -627,8 +702,8 @@ func (r *BookRouter) handleOrderBook(conn comms.Link, msg *msgjson.Message) *msg
 			Message: "market name error: " + err.Error(),
 		}
 	}
-	book, found := r.books[mkt]
-	if !found {
+	book := r.book(mkt)
+	if book == nil {
 		return &msgjson.Error{
 			Code:    msgjson.UnknownMarket,
 			Message: "unknown market",
@@ -651,7 +726,7 @@ func (r *BookRouter) handleUnsubOrderBook(conn comms.Link, msg *msgjson.Message)
 			Message: "error parsing unsub_orderbook request",
 		}
 	}
-	book := r.books[unsub.MarketID]
+	book := r.book(unsub.MarketID)
 	if book == nil {
 		return &msgjson.Error{
 			Code:    msgjson.UnknownMarket,
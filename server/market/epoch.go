@@ -24,6 +24,9 @@ type EpochQueue struct {
 	UserCancels map[account.AccountID]uint32
 	// CancelTargets maps known targeted order IDs with the CancelOrder
 	CancelTargets map[order.OrderID]*order.CancelOrder
+	// Amends maps the replacement order IDs of amend requests to the IDs of
+	// the cancel orders that must execute before the replacements are matched.
+	Amends map[order.OrderID]order.OrderID
 }
 
 // NewEpoch creates an epoch with the given index and duration in milliseconds.
@@ -37,6 +40,7 @@ func NewEpoch(idx int64, duration int64) *EpochQueue {
 		Orders:        make(map[order.OrderID]order.Order),
 		UserCancels:   make(map[account.AccountID]uint32),
 		CancelTargets: make(map[order.OrderID]*order.CancelOrder),
+		Amends:        make(map[order.OrderID]order.OrderID),
 	}
 }
 
@@ -58,6 +62,14 @@ func (eq *EpochQueue) Insert(ord order.Order) {
 	}
 }
 
+// InsertAmend stores the cancel and replacement orders of an amend request,
+// and records the replacement's dependence on the cancel.
+func (eq *EpochQueue) InsertAmend(co *order.CancelOrder, lo *order.LimitOrder) {
+	eq.Insert(co)
+	eq.Insert(lo)
+	eq.Amends[lo.ID()] = co.ID()
+}
+
 // IncludesTime checks if the given time falls in the epoch.
 func (eq *EpochQueue) IncludesTime(t time.Time) bool {
 	// [Start,End): Check the inclusive lower bound.
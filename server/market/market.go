@@ -50,6 +50,7 @@ const (
 	ErrCancelNotPermitted     = Error("cancel order account does not match targeted order account")
 	ErrTargetNotActive        = Error("target order not active on this market")
 	ErrTargetNotCancelable    = Error("targeted order is not a limit order with standing time-in-force")
+	ErrAmendSideMismatch      = Error("replacement order is not on the same side as the amended order")
 	ErrSuspendedAccount       = Error("suspended account")
 	ErrMalformedOrderResponse = Error("malformed order response")
 	ErrInternalServer         = Error("internal server error")
@@ -64,6 +65,7 @@ type Swapper interface {
 
 type DataCollector interface {
 	ReportEpoch(base, quote uint32, epochIdx uint64, stats *matcher.MatchCycleStats) (*msgjson.Spot, error)
+	ReportTrades(base, quote uint32, trades []*msgjson.MarketTrade) error
 }
 
 // FeeFetcher is a fee fetcher for fetching fees. Fees are fickle, so fetch fees
@@ -98,6 +100,18 @@ type Config struct {
 	Balancer         Balancer
 	CheckParcelLimit func(user account.AccountID, calcParcels MarketParcelCalculator) bool
 	MinimumRate      uint64
+	// OnParamsChange, if set, is called when a change scheduled with
+	// ScheduleParamsChange takes effect.
+	OnParamsChange func(*ParamsChange)
+}
+
+// ParamsChange is a change to a market's lot size and rate step. The new
+// values apply to orders placed in the epoch with index Epoch and later.
+type ParamsChange struct {
+	Epoch       int64
+	LotSize     uint64
+	RateStep    uint64
+	MinimumRate uint64
 }
 
 // Market is the market manager. It should not be overly involved with details
@@ -173,7 +187,15 @@ type Market struct {
 
 	checkParcelLimit func(user account.AccountID, calcParcels MarketParcelCalculator) bool
 
-	minimumRate uint64
+	// paramsMtx guards the order parameters that may be changed while the
+	// market is running.
+	paramsMtx      sync.RWMutex
+	lotSize        uint64
+	rateStep       uint64
+	minimumRate    uint64
+	paramsChange   *ParamsChange // scheduled
+	bookParams     *ParamsChange // in effect, but not yet applied to the book
+	onParamsChange func(*ParamsChange)
 }
 
 // Storage is the DB interface required by Market.
@@ -505,7 +527,10 @@ ordersLoop:
 		dataCollector:    cfg.DataCollector,
 		lastRate:         lastEpochEndRate,
 		checkParcelLimit: cfg.CheckParcelLimit,
+		lotSize:          mktInfo.LotSize,
+		rateStep:         mktInfo.RateStep,
 		minimumRate:      cfg.MinimumRate,
+		onParamsChange:   cfg.OnParamsChange,
 	}, nil
 }
 
@@ -621,6 +646,7 @@ type Status struct {
 	SuspendEpoch  int64
 	PersistBook   bool
 	Base, Quote   uint32
+	ParamsChange  *ParamsChange // scheduled, if any
 }
 
 // Status returns the current operating state of the Market.
@@ -636,6 +662,7 @@ func (m *Market) Status() *Status {
 		PersistBook:   m.persistBook,
 		Base:          m.marketInfo.Base,
 		Quote:         m.marketInfo.Quote,
+		ParamsChange:  m.ParamsChange(),
 	}
 }
 
@@ -671,12 +698,164 @@ func (m *Market) MarketBuyBuffer() float64 {
 
 // LotSize returns the market's lot size in units of the base asset.
 func (m *Market) LotSize() uint64 {
-	return m.marketInfo.LotSize
+	m.paramsMtx.RLock()
+	defer m.paramsMtx.RUnlock()
+	return m.lotSize
 }
 
 // RateStep returns the market's rate step in units of the quote asset.
 func (m *Market) RateStep() uint64 {
-	return m.marketInfo.RateStep
+	m.paramsMtx.RLock()
+	defer m.paramsMtx.RUnlock()
+	return m.rateStep
+}
+
+// params returns the lot size, rate step, and minimum rate in effect.
+func (m *Market) params() (lotSize, rateStep, minRate uint64) {
+	m.paramsMtx.RLock()
+	defer m.paramsMtx.RUnlock()
+	return m.lotSize, m.rateStep, m.minimumRate
+}
+
+// ParamsChange returns the scheduled change to the market's lot size and rate
+// step, or nil if no change is scheduled.
+func (m *Market) ParamsChange() *ParamsChange {
+	m.paramsMtx.RLock()
+	defer m.paramsMtx.RUnlock()
+	if m.paramsChange == nil {
+		return nil
+	}
+	change := *m.paramsChange
+	return &change
+}
+
+// ScheduleParamsChange schedules a change to the market's lot size and rate
+// step, taking effect with the first epoch that starts at or after asSoonAs.
+// So that clients have notice of the change, it takes effect no sooner than
+// the epoch after the next. A change that has not yet taken effect is
+// replaced. Booked orders that do not conform to the new lot size or rate step
+// are revoked before the first epoch with the new values is matched. The
+// market must be running.
+func (m *Market) ScheduleParamsChange(asSoonAs time.Time, lotSize, rateStep, minRate uint64) (epochIdx int64, err error) {
+	if lotSize == 0 || rateStep == 0 {
+		return 0, fmt.Errorf("invalid lot size %d or rate step %d", lotSize, rateStep)
+	}
+
+	// epochMtx guards activeEpochIdx and suspendEpochIdx.
+	m.epochMtx.Lock()
+	defer m.epochMtx.Unlock()
+	if m.activeEpochIdx == 0 {
+		return 0, ErrMarketNotRunning
+	}
+
+	dur := int64(m.EpochDuration())
+	ms := asSoonAs.UnixMilli()
+	epochIdx = ms / dur
+	if ms%dur != 0 {
+		epochIdx++
+	}
+	epochIdx = max(epochIdx, m.activeEpochIdx+2)
+	if m.suspendEpochIdx >= m.activeEpochIdx && epochIdx > m.suspendEpochIdx {
+		return 0, fmt.Errorf("market is suspending with epoch %d, before change epoch %d",
+			m.suspendEpochIdx, epochIdx)
+	}
+
+	m.paramsMtx.Lock()
+	m.paramsChange = &ParamsChange{
+		Epoch:       epochIdx,
+		LotSize:     lotSize,
+		RateStep:    rateStep,
+		MinimumRate: minRate,
+	}
+	m.paramsMtx.Unlock()
+
+	log.Infof("Market %s lot size change to %d and rate step change to %d scheduled for epoch %d",
+		m.marketInfo.Name, lotSize, rateStep, epochIdx)
+	return epochIdx, nil
+}
+
+// activateParams puts a scheduled params change into effect if the epoch is
+// at or after the change epoch. New orders are validated against the new
+// values, and the book is migrated by migrateBook before the epoch is matched.
+// The epochMtx must be locked.
+func (m *Market) activateParams(epochIdx int64) {
+	m.paramsMtx.Lock()
+	change := m.paramsChange
+	if change == nil || epochIdx < change.Epoch {
+		m.paramsMtx.Unlock()
+		return
+	}
+	// Storage validates new orders against the lot size.
+	if updater, is := m.storage.(db.LotSizeUpdater); is && change.LotSize != m.lotSize {
+		err := updater.UpdateLotSize(m.marketInfo.Base, m.marketInfo.Quote, change.LotSize)
+		if err != nil {
+			log.Errorf("Failed to update stored lot size for market %s: %v", m.marketInfo.Name, err)
+		}
+	}
+	m.lotSize, m.rateStep, m.minimumRate = change.LotSize, change.RateStep, change.MinimumRate
+	m.paramsChange = nil
+	m.bookParams = change
+	m.paramsMtx.Unlock()
+
+	log.Infof("Market %s lot size is now %d and rate step is now %d, starting with epoch %d",
+		m.marketInfo.Name, change.LotSize, change.RateStep, epochIdx)
+
+	if m.onParamsChange != nil {
+		m.lazy(func() { m.onParamsChange(change) })
+	}
+}
+
+// migrateBook applies a params change that is in effect for the epoch to the
+// book. Booked orders that do not conform to the new lot size or rate step are
+// revoked without counting against the users.
+func (m *Market) migrateBook(epochIdx int64, notifyChan chan<- *updateSignal) {
+	m.paramsMtx.Lock()
+	change := m.bookParams
+	if change == nil || epochIdx < change.Epoch {
+		m.paramsMtx.Unlock()
+		return
+	}
+	m.bookParams = nil
+	m.paramsMtx.Unlock()
+
+	conforms := func(lo *order.LimitOrder) bool {
+		return lo.Quantity%change.LotSize == 0 && lo.FillAmt%change.LotSize == 0 &&
+			lo.Rate%change.RateStep == 0
+	}
+
+	var removed []*order.LimitOrder
+	m.bookMtx.Lock()
+	m.book.SetLotSize(change.LotSize)
+	for _, lo := range append(m.book.BuyOrders(), m.book.SellOrders()...) {
+		if conforms(lo) {
+			continue
+		}
+		if _, ok := m.book.Remove(lo.ID()); ok {
+			delete(m.settling, lo.ID()) // no order completion credit in SwapDone for revoked orders
+			removed = append(removed, lo)
+		}
+	}
+	m.bookMtx.Unlock()
+
+	log.Infof("Revoking %d orders from market %s that do not conform to lot size %d and rate step %d.",
+		len(removed), m.marketInfo.Name, change.LotSize, change.RateStep)
+
+	reason := fmt.Sprintf("market %s lot size changed to %d and rate step changed to %d",
+		m.marketInfo.Name, change.LotSize, change.RateStep)
+	for _, lo := range removed {
+		m.unlockOrderCoins(lo)
+		if _, _, err := m.storage.RevokeOrderUncounted(lo); err != nil {
+			log.Errorf("Failed to revoke order %v: %v", lo, err)
+		}
+		m.sendRevokeOrderNote(lo.ID(), lo.User(), reason)
+		notifyChan <- &updateSignal{
+			action: unbookAction,
+			data: sigDataUnbookedOrder{
+				order:    lo,
+				epochIdx: epochIdx,
+			},
+		}
+	}
 }
 
 // Base is the base asset ID.
@@ -922,6 +1101,13 @@ func (m *Market) SubmitOrderAsync(rec *orderRecord) <-chan error {
 			rec.order.User(), rec.order.Commitment(), err)
 		return sendErr(err)
 	}
+	if rec.amend != nil {
+		if err := m.validateOrder(rec.amend.cancel); err != nil {
+			log.Debugf("SubmitOrderAsync: Invalid amend cancel order received from user %v with commitment %v: %v",
+				rec.order.User(), rec.amend.cancel.Commitment(), err)
+			return sendErr(err)
+		}
+	}
 
 	// Only submit orders while market is running.
 	m.runMtx.RLock()
@@ -1009,12 +1195,22 @@ func (m *Market) Cancelable(oid order.OrderID) bool {
 // time-in-force standing (implied for book orders), and (3) AccountID field
 // matching the provided account ID.
 func (m *Market) CancelableBy(oid order.OrderID, aid account.AccountID) (bool, time.Time, error) {
+	lo, err := m.cancelTarget(oid, aid)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return true, lo.ServerTime, nil
+}
+
+// cancelTarget returns the order that the account may cancel. See
+// CancelableBy.
+func (m *Market) cancelTarget(oid order.OrderID, aid account.AccountID) (*order.LimitOrder, error) {
 	// All book orders are standing limit orders.
 	if lo := m.book.Order(oid); lo != nil {
 		if lo.AccountID == aid {
-			return true, lo.ServerTime, nil
+			return lo, nil
 		}
-		return false, time.Time{}, ErrCancelNotPermitted
+		return nil, ErrCancelNotPermitted
 	}
 
 	// Check the active epochs (includes current and next).
@@ -1023,20 +1219,20 @@ func (m *Market) CancelableBy(oid order.OrderID, aid account.AccountID) (bool, t
 	m.epochMtx.RUnlock()
 
 	if ord == nil {
-		return false, time.Time{}, ErrTargetNotActive
+		return nil, ErrTargetNotActive
 	}
 
 	lo, ok := ord.(*order.LimitOrder)
 	if !ok {
-		return false, time.Time{}, ErrTargetNotCancelable
+		return nil, ErrTargetNotCancelable
 	}
 	if lo.Force != order.StandingTiF {
-		return false, time.Time{}, ErrTargetNotCancelable
+		return nil, ErrTargetNotCancelable
 	}
 	if lo.AccountID != aid {
-		return false, time.Time{}, ErrCancelNotPermitted
+		return nil, ErrCancelNotPermitted
 	}
-	return true, lo.ServerTime, nil
+	return lo, nil
 }
 
 func (m *Market) checkUnfilledOrders(assetID uint32, unfilled []*order.LimitOrder) (unbooked []*order.LimitOrder) {
@@ -1189,7 +1385,7 @@ func (m *Market) AccountPending(acctAddr string, assetID uint32) (qty, lots uint
 		midGap = m.RateStep()
 	}
 
-	lotSize := m.marketInfo.LotSize
+	lotSize := m.LotSize()
 	switch assetID {
 	case base:
 		m.iterateBaseAccount(acctAddr, func(trade *order.Trade, rate uint64) {
@@ -1285,7 +1481,7 @@ func (m *Market) PurgeBook() {
 	// orderbook subscription, so the users will receive them whether or not
 	// they are subscribed for book updates.
 	for oid, aid := range removed {
-		m.sendRevokeOrderNote(oid, aid)
+		m.sendRevokeOrderNote(oid, aid, "")
 	}
 }
 
@@ -1525,6 +1721,7 @@ func (m *Market) Run(ctx context.Context) {
 		currentEpoch = nextEpoch
 		nextEpochIdx = currentEpoch.Epoch + 1
 		m.activeEpochIdx = currentEpoch.Epoch
+		m.activateParams(currentEpoch.Epoch)
 
 		if !running {
 			// Check that both blockchains are synced before actually starting.
@@ -1600,6 +1797,9 @@ func (m *Market) Run(ctx context.Context) {
 			// Set the order's server time stamp, giving the order a valid ID.
 			sTime := time.Now().Truncate(time.Millisecond).UTC()
 			s.rec.order.SetTime(sTime) // Order.ID()/UID()/String() is OK now.
+			if s.rec.amend != nil {
+				s.rec.amend.cancel.SetTime(sTime)
+			}
 			log.Tracef("Received order %v at %v", s.rec.order, sTime)
 
 			// Push the order into the next epoch if receiving and stamping it
@@ -1723,7 +1923,7 @@ func (m *Market) analysisHelpers() (
 		if ord.Type() == order.MarketOrderType && !ord.Trade().Sell {
 			// Market buy qty is in quote asset. Convert to base.
 			if midGap == 0 {
-				qty = m.marketInfo.LotSize // no orders on the book; call it 1 lot
+				qty = m.LotSize() // no orders on the book; call it 1 lot
 			} else {
 				qty = calc.QuoteToBase(midGap, qty)
 			}
@@ -1766,7 +1966,7 @@ func (m *Market) parcels(user account.AccountID, addParcelWeight uint64) float64
 
 	bookedBuyAmt, bookedSellAmt, _, _ := m.book.UserOrderTotals(user)
 	makerQty += bookedBuyAmt + bookedSellAmt
-	return calc.Parcels(makerQty+addParcelWeight, takerQty, m.marketInfo.LotSize, m.marketInfo.ParcelSize)
+	return calc.Parcels(makerQty+addParcelWeight, takerQty, m.LotSize(), m.marketInfo.ParcelSize)
 }
 
 // processOrder performs the following actions:
@@ -1806,44 +2006,56 @@ func (m *Market) processOrder(rec *orderRecord, epoch *EpochQueue, notifyChan ch
 		return nil
 	}
 
-	// Verify that another cancel order targeting the same order is not already
-	// in the epoch queue. Market and limit orders using the same coin IDs as
-	// other orders is prevented by the coinlocker.
-	epochGap := db.EpochGapNA
-	if co, ok := ord.(*order.CancelOrder); ok {
-		if eco := epoch.CancelTargets[co.TargetOrderID]; eco != nil {
-			log.Debugf("Received cancel order %v targeting %v, but already have %v.",
-				co, co.TargetOrderID, eco)
-			errChan <- ErrDuplicateCancelOrder
+	// The cancel order of an amend request gets the same checks as any other
+	// cancel order, and the replacement must be on the same side as the
+	// amended order.
+	var amendCo *order.CancelOrder
+	var amendTarget *order.LimitOrder
+	amendGap := db.EpochGapNA
+	if rec.amend != nil {
+		amendCo = rec.amend.cancel
+		coCommit := amendCo.Commitment()
+		m.epochMtx.RLock()
+		_, found := m.epochCommitments[coCommit]
+		m.epochMtx.RUnlock()
+		if found || coCommit == commit {
+			log.Debugf("Received amend cancel order %v with reused commitment %x!", amendCo, coCommit)
+			errChan <- ErrInvalidCommitment
 			return nil
 		}
-
-		if nc := epoch.UserCancels[co.AccountID]; nc >= m.marketInfo.MaxUserCancelsPerEpoch {
-			log.Debugf("Received cancel order %v targeting %v, but user already has %d cancel orders in this epoch.",
-				co, co.TargetOrderID, nc)
-			errChan <- ErrTooManyCancelOrders
+		var err error
+		amendTarget, amendGap, err = m.checkCancel(amendCo, epoch)
+		if err != nil {
+			errChan <- err
 			return nil
 		}
+		if amendTarget.Sell != ord.Trade().Sell {
+			log.Debugf("Received amend of order %v with replacement %v on the other side.", amendTarget, ord)
+			errChan <- ErrAmendSideMismatch
+			return nil
+		}
+	}
 
-		// Verify that the target order is on the books or in the epoch queue,
-		// and that the account of the CancelOrder is the same as the account of
-		// the target order.
-		cancelable, loTime, err := m.CancelableBy(co.TargetOrderID, co.AccountID)
-		if !cancelable {
-			log.Debugf("Cancel order %v (account=%v) target order %v: %v",
-				co, co.AccountID, co.TargetOrderID, err)
+	// Verify that another cancel order targeting the same order is not already
+	// in the epoch queue. Market and limit orders using the same coin IDs as
+	// other orders is prevented by the coinlocker.
+	epochGap := db.EpochGapNA
+	if co, ok := ord.(*order.CancelOrder); ok {
+		var err error
+		if _, epochGap, err = m.checkCancel(co, epoch); err != nil {
 			errChan <- err
 			return nil
 		}
-
-		epochGap = int32(epoch.Epoch - loTime.UnixMilli()/epoch.Duration)
-
 	} else { // Not a cancel order, check user limits.
 		likelyTaker, baseQty := m.analysisHelpers()
 		orderWeight := baseQty(ord)
 		if likelyTaker(ord) {
 			orderWeight *= 2
 		}
+		if amendTarget != nil {
+			// The amended order's remaining quantity is replaced.
+			orderWeight -= min(amendTarget.Remaining(), orderWeight)
+		}
 		calcParcels := func(settlingWeight uint64) float64 {
 			return m.parcels(user, settlingWeight+orderWeight)
 		}
@@ -1899,24 +2111,44 @@ func (m *Market) processOrder(rec *orderRecord, epoch *EpochQueue, notifyChan ch
 
 	// Store the new epoch order BEFORE inserting it into the epoch queue,
 	// initiating the swap, and notifying book subscribers.
-	if err := m.storage.NewEpochOrder(ord, epoch.Epoch, epoch.Duration, epochGap); err != nil {
-		errChan <- ErrInternalServer
-		return fmt.Errorf("processOrder: Failed to store new epoch order %v: %w",
-			ord, err)
+	if amendCo != nil {
+		lo := ord.(*order.LimitOrder)
+		if err := m.storage.NewEpochAmend(amendCo, lo, epoch.Epoch, epoch.Duration, amendGap); err != nil {
+			errChan <- ErrInternalServer
+			return fmt.Errorf("processOrder: Failed to store new epoch amend %v -> %v: %w",
+				amendCo.TargetOrderID, ord, err)
+		}
+		// Insert both orders into the epoch queue.
+		epoch.InsertAmend(amendCo, lo)
+	} else {
+		if err := m.storage.NewEpochOrder(ord, epoch.Epoch, epoch.Duration, epochGap); err != nil {
+			errChan <- ErrInternalServer
+			return fmt.Errorf("processOrder: Failed to store new epoch order %v: %w",
+				ord, err)
+		}
+		// Insert the order into the epoch queue.
+		epoch.Insert(ord)
 	}
 
-	// Insert the order into the epoch queue.
-	epoch.Insert(ord)
-
 	m.epochMtx.Lock()
 	m.epochOrders[oid] = ord
 	m.epochCommitments[commit] = oid
+	if amendCo != nil {
+		m.epochOrders[amendCo.ID()] = amendCo
+		m.epochCommitments[amendCo.Commitment()] = amendCo.ID()
+	}
 	m.epochMtx.Unlock()
 
 	// Respond to the order router only after updating epochOrders so that
 	// Cancelable will reflect that the order is now in the epoch queue.
 	errChan <- nil
 
+	ordType := ord.Type().String()
+	if amendCo != nil {
+		ordType = "amend"
+	}
+	ordersAccepted.With(m.marketInfo.Name, ordType).Inc()
+
 	// Inform the client that the order has been received, stamped, signed, and
 	// inserted into the current epoch queue.
 	m.lazy(func() {
@@ -1927,6 +2159,15 @@ func (m *Market) processOrder(rec *orderRecord, epoch *EpochQueue, notifyChan ch
 	})
 
 	// Send epoch update to epoch queue subscribers.
+	if amendCo != nil {
+		notifyChan <- &updateSignal{
+			action: epochAction,
+			data: sigDataEpochOrder{
+				order:    amendCo,
+				epochIdx: epoch.Epoch,
+			},
+		}
+	}
 	notifyChan <- &updateSignal{
 		action: epochAction,
 		data: sigDataEpochOrder{
@@ -1939,6 +2180,35 @@ func (m *Market) processOrder(rec *orderRecord, epoch *EpochQueue, notifyChan ch
 	return nil
 }
 
+// checkCancel verifies that the cancel order may be added to the epoch queue,
+// returning the targeted order and the epoch gap between the targeted order
+// and the cancel order.
+func (m *Market) checkCancel(co *order.CancelOrder, epoch *EpochQueue) (*order.LimitOrder, int32, error) {
+	if eco := epoch.CancelTargets[co.TargetOrderID]; eco != nil {
+		log.Debugf("Received cancel order %v targeting %v, but already have %v.",
+			co, co.TargetOrderID, eco)
+		return nil, 0, ErrDuplicateCancelOrder
+	}
+
+	if nc := epoch.UserCancels[co.AccountID]; nc >= m.marketInfo.MaxUserCancelsPerEpoch {
+		log.Debugf("Received cancel order %v targeting %v, but user already has %d cancel orders in this epoch.",
+			co, co.TargetOrderID, nc)
+		return nil, 0, ErrTooManyCancelOrders
+	}
+
+	// Verify that the target order is on the books or in the epoch queue,
+	// and that the account of the CancelOrder is the same as the account of
+	// the target order.
+	lo, err := m.cancelTarget(co.TargetOrderID, co.AccountID)
+	if err != nil {
+		log.Debugf("Cancel order %v (account=%v) target order %v: %v",
+			co, co.AccountID, co.TargetOrderID, err)
+		return nil, 0, err
+	}
+
+	return lo, int32(epoch.Epoch - lo.ServerTime.UnixMilli()/epoch.Duration), nil
+}
+
 func idToBytes(id [order.OrderIDSize]byte) []byte {
 	return id[:]
 }
@@ -2149,12 +2419,13 @@ func (m *Market) enqueueEpoch(eq *epochPump, epoch *EpochQueue) bool {
 	return true
 }
 
-func (m *Market) sendRevokeOrderNote(oid order.OrderID, user account.AccountID) {
+func (m *Market) sendRevokeOrderNote(oid order.OrderID, user account.AccountID, reason string) {
 	// Send revoke_order notification to order owner.
 	route := msgjson.RevokeOrderRoute
 	log.Infof("Sending a '%s' notification to %v for order %v", route, user, oid)
 	revMsg := &msgjson.RevokeOrder{
 		OrderID: oid.Bytes(),
+		Reason:  reason,
 	}
 	m.auth.Sign(revMsg)
 	revNtfn, err := msgjson.NewNotification(route, revMsg)
@@ -2196,7 +2467,7 @@ func (m *Market) prepEpoch(orders []order.Order, epochEnd time.Time) (cSum []byt
 		// The user is most likely offline, but it is possible they have
 		// reconnected too late for the preimage request but after
 		// storage.RevokeOrder updated the order status. Try to notify.
-		go m.sendRevokeOrderNote(oid, user)
+		go m.sendRevokeOrderNote(oid, user, "")
 	}
 
 	// Register the preimage collection successes, potentially evicting preimage
@@ -2288,7 +2559,7 @@ func (m *Market) unbookedOrder(lo *order.LimitOrder) {
 	}
 
 	// Send revoke_order notification to order owner.
-	m.sendRevokeOrderNote(oid, user)
+	m.sendRevokeOrderNote(oid, user, "")
 
 	// Send "unbook" notification to order book subscribers.
 	m.sendToFeeds(&updateSignal{
@@ -2344,6 +2615,10 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 		return
 	}
 
+	// Revoke booked orders that do not conform to a new lot size or rate step
+	// before the first epoch with the new values is matched.
+	m.migrateBook(epoch.Epoch, notifyChan)
+
 	// Get the base and quote fee rates.
 	// NOTE: We might consider moving this before the match cycle and abandoning
 	// the match cycle when no fee rate can be found (on mainnet). The only
@@ -2375,10 +2650,12 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 	// Perform order matching using the preimages to shuffle the queue.
 	m.bookMtx.Lock()        // allow a coherent view of book orders with (*Market).Book
 	matchTime := time.Now() // considered as the time at which matched cancel orders are executed
-	seed, matches, _, failed, doneOK, partial, booked, nomatched, unbooked, updates, stats := m.matcher.Match(m.book, ordersRevealed)
+	seed, matches, _, failed, doneOK, partial, booked, nomatched, unbooked, updates, stats := m.matcher.Match(m.book, ordersRevealed, epoch.Amends)
 	m.bookEpochIdx = epoch.Epoch + 1
 	epochDur := int64(m.EpochDuration())
 	var canceled []order.OrderID
+	tradeMatchCount := matchesMade.With(m.marketInfo.Name, "trade")
+	cancelMatchCount := matchesMade.With(m.marketInfo.Name, "cancel")
 	for _, ms := range matches {
 		// Set the epoch ID.
 		ms.Epoch.Idx = uint64(epoch.Epoch)
@@ -2389,6 +2666,7 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 		// Update order settling amounts.
 		for _, match := range ms.Matches() {
 			if co, ok := match.Taker.(*order.CancelOrder); ok {
+				cancelMatchCount.Inc()
 				canceled = append(canceled, co.TargetOrderID)
 				cancelMatches = append(cancelMatches, cancelMatch{
 					co:      co,
@@ -2396,6 +2674,7 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 				})
 				continue
 			}
+			tradeMatchCount.Inc()
 			m.settling[match.Taker.ID()] += match.Quantity
 			m.settling[match.Maker.ID()] += match.Quantity
 		}
@@ -2407,6 +2686,7 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 	}
 	m.bookMtx.Unlock()
 
+	epochsProcessed.With(m.marketInfo.Name).Inc()
 	if len(ordersRevealed) > 0 {
 		log.Infof("Matching complete for market %v epoch %d:"+
 			" %d matches (%d partial fills), %d completed OK (not booked),"+
@@ -2606,8 +2886,20 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 		notifyChan <- sig
 	}
 
+	// The cancel order of an amend is not counted against the user's
+	// cancellation rate if the replacement was revealed, since the user's
+	// order remains on the book.
+	amendCancels := make(map[order.OrderID]bool, len(epoch.Amends))
+	for _, ord := range ordersRevealed {
+		if coid, found := epoch.Amends[ord.Order.ID()]; found {
+			amendCancels[coid] = true
+		}
+	}
 	for _, c := range cancelMatches {
 		co, loEpoch := c.co, c.loEpoch
+		if amendCancels[co.ID()] {
+			continue
+		}
 		epochGap := int32((co.ServerTime.UnixMilli() / epochDur) - loEpoch)
 		m.auth.RecordCancel(co.User(), co.ID(), co.TargetOrderID, epochGap, matchTime)
 	}
@@ -2635,6 +2927,8 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 	}
 
 	matchReport := make([][2]int64, 0, len(matches))
+	var trades []*msgjson.MarketTrade
+	endStamp := uint64((epoch.Epoch + 1) * epoch.Duration)
 	var lastRate uint64
 	var lastSide bool
 	for _, matchSet := range matches {
@@ -2643,6 +2937,12 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 			if t == nil {
 				continue
 			}
+			trades = append(trades, &msgjson.MarketTrade{
+				Stamp:     endStamp,
+				Rate:      match.Rate,
+				Qty:       match.Quantity,
+				TakerSell: t.Sell,
+			})
 			if match.Rate != lastRate || t.Sell != lastSide {
 				matchReport = append(matchReport, [2]int64{int64(match.Rate), 0})
 				lastRate, lastSide = match.Rate, t.Sell
@@ -2654,7 +2954,12 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 			}
 		}
 	}
-	// Send "epoch_report" notifications.
+	if len(trades) > 0 {
+		if err := m.dataCollector.ReportTrades(m.Base(), m.Quote(), trades); err != nil {
+			log.Errorf("Error reporting trades to API data collector: %v", err)
+		}
+	}
+	// Send "epoch_report" and "trade_tape" notifications.
 	notifyChan <- &updateSignal{
 		action: epochReportAction,
 		data: sigDataEpochReport{
@@ -2665,6 +2970,7 @@ func (m *Market) processReadyEpoch(epoch *readyEpoch, notifyChan chan<- *updateS
 			baseFeeRate:  feeRateBase,
 			quoteFeeRate: feeRateQuote,
 			matches:      matchReport,
+			trades:       trades,
 		},
 	}
 
@@ -2686,11 +2992,15 @@ func (m *Market) validateOrder(ord order.Order) error {
 		return ErrInvalidCommitment
 	}
 
-	if !db.ValidateOrder(ord, order.OrderStatusEpoch, m.marketInfo) {
+	// The lot size may have changed since the MarketInfo was created.
+	lotSize, rateStep, minRate := m.params()
+	mktInfo := *m.marketInfo
+	mktInfo.LotSize = lotSize
+	if !db.ValidateOrder(ord, order.OrderStatusEpoch, &mktInfo) {
 		return ErrInvalidOrder // non-specific
 	}
 
-	if lo, is := ord.(*order.LimitOrder); is && lo.Rate < m.minimumRate {
+	if lo, is := ord.(*order.LimitOrder); is && (lo.Rate < minRate || lo.Rate%rateStep != 0) {
 		return ErrInvalidRate
 	}
 
@@ -2700,23 +3010,35 @@ func (m *Market) validateOrder(ord order.Order) error {
 // orderResponse signs the order data and prepares the OrderResult to be sent to
 // the client.
 func (m *Market) orderResponse(oRecord *orderRecord) (*msgjson.Message, error) {
+	res := m.orderResult(oRecord.order, oRecord.req)
+	if oRecord.amend != nil {
+		return msgjson.NewResponse(oRecord.msgID, &msgjson.AmendResult{
+			Cancel: m.orderResult(oRecord.amend.cancel, oRecord.amend.req),
+			Order:  res,
+		}, nil)
+	}
+
+	// Encode the order response as a message for the client.
+	return msgjson.NewResponse(oRecord.msgID, res, nil)
+}
+
+// orderResult stamps and signs the order request, and prepares the
+// OrderResult.
+func (m *Market) orderResult(ord order.Order, req msgjson.Stampable) *msgjson.OrderResult {
 	// Add the server timestamp.
-	stamp := uint64(oRecord.order.Time())
-	oRecord.req.Stamp(stamp)
+	stamp := uint64(ord.Time())
+	req.Stamp(stamp)
 
 	// Sign the serialized order request.
-	m.auth.Sign(oRecord.req)
+	m.auth.Sign(req)
 
 	// Prepare the OrderResult, including the server signature and time stamp.
-	oid := oRecord.order.ID()
-	res := &msgjson.OrderResult{
-		Sig:        oRecord.req.SigBytes(),
+	oid := ord.ID()
+	return &msgjson.OrderResult{
+		Sig:        req.SigBytes(),
 		OrderID:    oid[:],
 		ServerTime: stamp,
 	}
-
-	// Encode the order response as a message for the client.
-	return msgjson.NewResponse(oRecord.msgID, res, nil)
 }
 
 // SetFeeRateScale sets a swap fee scale factor for the given asset.
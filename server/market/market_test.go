@@ -42,6 +42,7 @@ type TArchivist struct {
 	archivedCancels      []*order.CancelOrder
 	epochInserted        chan struct{}
 	revoked              order.Order
+	lotSize              uint64
 }
 
 func (ta *TArchivist) Close() error           { return nil }
@@ -123,6 +124,14 @@ func (ta *TArchivist) NewEpochOrder(ord order.Order, epochIdx, epochDur int64, e
 	}
 	return nil
 }
+func (ta *TArchivist) NewEpochAmend(co *order.CancelOrder, lo *order.LimitOrder, epochIdx, epochDur int64, epochGap int32) error {
+	ta.mtx.Lock()
+	defer ta.mtx.Unlock()
+	if ta.poisonEpochOrder != nil && lo.ID() == ta.poisonEpochOrder.ID() {
+		return errors.New("barf")
+	}
+	return nil
+}
 func (ta *TArchivist) StorePreimage(ord order.Order, pi order.Preimage) error { return nil }
 func (ta *TArchivist) failOnEpochOrder(ord order.Order) {
 	ta.mtx.Lock()
@@ -160,6 +169,12 @@ func (ta *TArchivist) RevokeOrder(ord order.Order) (order.OrderID, time.Time, er
 func (ta *TArchivist) RevokeOrderUncounted(order.Order) (order.OrderID, time.Time, error) {
 	return order.OrderID{}, time.Now(), nil
 }
+func (ta *TArchivist) UpdateLotSize(base, quote uint32, lotSize uint64) error {
+	ta.mtx.Lock()
+	defer ta.mtx.Unlock()
+	ta.lotSize = lotSize
+	return nil
+}
 func (ta *TArchivist) SetOrderCompleteTime(ord order.Order, compTime int64) error { return nil }
 func (ta *TArchivist) FailCancelOrder(*order.CancelOrder) error                   { return nil }
 func (ta *TArchivist) UpdateOrderFilled(*order.LimitOrder) error                  { return nil }
@@ -226,6 +241,10 @@ func (tc *TCollector) ReportEpoch(base, quote uint32, epochIdx uint64, stats *ma
 	return collectorSpot, nil
 }
 
+func (tc *TCollector) ReportTrades(base, quote uint32, trades []*msgjson.MarketTrade) error {
+	return nil
+}
+
 type tFeeFetcher struct {
 	maxFeeRate uint64
 }
@@ -539,6 +558,128 @@ func TestMarket_Book(t *testing.T) {
 
 }
 
+func TestMarket_ParamsChange(t *testing.T) {
+	mkt, storage, auth, cleanup, err := newTestMarket()
+	if err != nil {
+		t.Fatalf("newTestMarket failure: %v", err)
+	}
+	defer cleanup()
+
+	const newLotSize, newRateStep, newMinRate = 2 * dcrLotSize, 1000 * btcRateStep, 1
+	if _, err = mkt.ScheduleParamsChange(time.Time{}, newLotSize, newRateStep, newMinRate); !errors.Is(err, ErrMarketNotRunning) {
+		t.Fatalf("expected ErrMarketNotRunning, got %v", err)
+	}
+
+	mkt.epochMtx.Lock()
+	mkt.activeEpochIdx = 100
+	mkt.epochMtx.Unlock()
+
+	if _, err = mkt.ScheduleParamsChange(time.Time{}, 0, newRateStep, newMinRate); err == nil {
+		t.Fatalf("no error for a zero lot size")
+	}
+	// The soonest change is the epoch after the next.
+	changeEpoch, err := mkt.ScheduleParamsChange(time.Time{}, newLotSize, newRateStep, newMinRate)
+	if err != nil {
+		t.Fatalf("ScheduleParamsChange error: %v", err)
+	}
+	if changeEpoch != 102 {
+		t.Fatalf("wrong change epoch. wanted 102, got %d", changeEpoch)
+	}
+	if change := mkt.ParamsChange(); change == nil || change.Epoch != 102 || change.LotSize != newLotSize {
+		t.Fatalf("wrong scheduled change %+v", change)
+	}
+
+	conforming := makeLO(buyer3, mkt3BaseRate, 2, order.StandingTiF)
+	badLots := makeLO(buyer3, mkt3BaseRate, 3, order.StandingTiF)
+	badRate := makeLO(seller3, mkt3BaseRate+btcRateStep, 2, order.StandingTiF)
+	for _, lo := range []*order.LimitOrder{conforming, badLots, badRate} {
+		if !mkt.book.Insert(lo) {
+			t.Fatalf("Failed to Insert order into book.")
+		}
+	}
+
+	changed := make(chan *ParamsChange, 1)
+	mkt.onParamsChange = func(change *ParamsChange) { changed <- change }
+
+	mkt.activateParams(101)
+	if mkt.LotSize() != dcrLotSize || mkt.ParamsChange() == nil {
+		t.Fatalf("change took effect early")
+	}
+
+	mkt.activateParams(102)
+	if mkt.LotSize() != newLotSize || mkt.RateStep() != newRateStep {
+		t.Fatalf("change did not take effect. lot size = %d, rate step = %d", mkt.LotSize(), mkt.RateStep())
+	}
+	if mkt.ParamsChange() != nil {
+		t.Fatalf("scheduled change not cleared")
+	}
+	if storage.lotSize != newLotSize {
+		t.Fatalf("stored lot size not updated")
+	}
+	select {
+	case change := <-changed:
+		if change.Epoch != 102 {
+			t.Fatalf("wrong change epoch in callback %d", change.Epoch)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("OnParamsChange not called")
+	}
+
+	// New orders are validated against the new values.
+	if err = mkt.validateOrder(makeLO(buyer3, mkt3BaseRate, 1, order.StandingTiF)); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("expected ErrInvalidOrder for a one lot order, got %v", err)
+	}
+	if err = mkt.validateOrder(makeLO(buyer3, mkt3BaseRate+btcRateStep, 2, order.StandingTiF)); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("expected ErrInvalidRate for an order with the old rate step, got %v", err)
+	}
+	if err = mkt.validateOrder(makeLO(buyer3, mkt3BaseRate, 2, order.StandingTiF)); err != nil {
+		t.Errorf("validateOrder error for a conforming order: %v", err)
+	}
+
+	// The book is not migrated until the change epoch is processed.
+	notifyChan := make(chan *updateSignal, 4)
+	mkt.migrateBook(101, notifyChan)
+	if len(notifyChan) != 0 || mkt.book.LotSize() != dcrLotSize {
+		t.Fatalf("book migrated early")
+	}
+
+	mkt.migrateBook(102, notifyChan)
+	if mkt.book.LotSize() != newLotSize {
+		t.Fatalf("book lot size not updated")
+	}
+	_, buys, sells := mkt.Book()
+	if len(buys) != 1 || buys[0].ID() != conforming.ID() || len(sells) != 0 {
+		t.Fatalf("wrong orders left on the book. %d buys, %d sells", len(buys), len(sells))
+	}
+	if len(notifyChan) != 2 {
+		t.Fatalf("expected 2 unbook signals, got %d", len(notifyChan))
+	}
+	for len(notifyChan) > 0 {
+		if sig := <-notifyChan; sig.action != unbookAction {
+			t.Fatalf("wrong signal action %v", sig.action)
+		}
+	}
+	auth.sendsMtx.Lock()
+	defer auth.sendsMtx.Unlock()
+	var revokes int
+	for _, msg := range auth.sends {
+		if msg.Route != msgjson.RevokeOrderRoute {
+			continue
+		}
+		var rev msgjson.RevokeOrder
+		if err := msg.Unmarshal(&rev); err != nil {
+			t.Fatalf("error unmarshaling revoke_order: %v", err)
+		}
+		if rev.Reason == "" {
+			t.Errorf("no reason for revoke_order")
+		}
+		revokes++
+	}
+	if revokes != 2 {
+		t.Fatalf("expected 2 revoke_order notes, got %d", revokes)
+	}
+}
+
 func TestMarket_Suspend(t *testing.T) {
 	// Create the market.
 	mkt, _, _, cleanup, err := newTestMarket()
@@ -1374,7 +1515,7 @@ func TestMarket_enqueueEpoch(t *testing.T) {
 				{bookAction, sigDataBookedOrder{lo, epochIdx}},
 				{unbookAction, sigDataUnbookedOrder{bestBuy, epochIdx}},
 				{unbookAction, sigDataUnbookedOrder{bestSell, epochIdx}},
-				{epochReportAction, sigDataEpochReport{epochIdx, epochDur, nil, nil, 10, 10, nil}},
+				{epochReportAction, sigDataEpochReport{epochIdx, epochDur, nil, nil, 10, 10, nil, nil}},
 			},
 		},
 		{
@@ -1382,7 +1523,7 @@ func TestMarket_enqueueEpoch(t *testing.T) {
 			eq2,
 			[]*updateSignal{
 				{matchProofAction, sigDataMatchProof{mp2}},
-				{epochReportAction, sigDataEpochReport{epochIdx, epochDur, nil, nil, 10, 10, nil}},
+				{epochReportAction, sigDataEpochReport{epochIdx, epochDur, nil, nil, 10, 10, nil, nil}},
 			},
 		},
 		{
@@ -1390,7 +1531,7 @@ func TestMarket_enqueueEpoch(t *testing.T) {
 			NewEpoch(epochIdx, epochDur),
 			[]*updateSignal{
 				{matchProofAction, sigDataMatchProof{mp0}},
-				{epochReportAction, sigDataEpochReport{epochIdx, epochDur, nil, nil, 10, 10, nil}},
+				{epochReportAction, sigDataEpochReport{epochIdx, epochDur, nil, nil, 10, 10, nil, nil}},
 			},
 		},
 	}
@@ -1629,6 +1770,165 @@ func TestMarket_Cancelable(t *testing.T) {
 	wg.Wait()
 }
 
+func TestMarket_Amend(t *testing.T) {
+	// Create the market.
+	mkt, storage, auth, cleanup, err := newTestMarket()
+	if err != nil {
+		t.Fatalf("newTestMarket failure: %v", err)
+		return
+	}
+	defer cleanup()
+	storage.epochInserted = make(chan struct{}, 1)
+	auth.handlePreimageDone = make(chan struct{}, 2)
+
+	epochDurationMSec := int64(mkt.EpochDuration())
+	startEpochIdx := 1 + time.Now().UnixMilli()/epochDurationMSec
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mkt.Start(ctx, startEpochIdx)
+	}()
+
+	clientTimeMSec := startEpochIdx*epochDurationMSec + 10 // 10 ms after epoch start
+	qty := uint64(dcrLotSize * 2)
+	rate := uint64(1000) * dcrRateStep
+	aid := test.NextAccount()
+	newLimit := func(sell bool, rate uint64) (*order.LimitOrder, *msgjson.LimitOrder, order.Preimage) {
+		pi := test.RandomPreimage()
+		commit := pi.Commit()
+		side := uint8(msgjson.BuyOrderNum)
+		if sell {
+			side = msgjson.SellOrderNum
+		}
+		limitMsg := &msgjson.LimitOrder{
+			Prefix: msgjson.Prefix{
+				AccountID:  aid[:],
+				Base:       dcrID,
+				Quote:      btcID,
+				OrderType:  msgjson.LimitOrderNum,
+				ClientTime: uint64(clientTimeMSec),
+				Commit:     commit[:],
+			},
+			Trade: msgjson.Trade{
+				Side:     side,
+				Quantity: qty,
+				Coins:    []*msgjson.Coin{},
+				Address:  btcAddr,
+			},
+			Rate: rate,
+			TiF:  msgjson.StandingOrderNum,
+		}
+		lo := &order.LimitOrder{
+			P: order.Prefix{
+				AccountID:  aid,
+				BaseAsset:  dcrID,
+				QuoteAsset: btcID,
+				OrderType:  order.LimitOrderType,
+				ClientTime: time.UnixMilli(clientTimeMSec),
+				Commit:     commit,
+			},
+			T: order.Trade{
+				Coins:    []order.CoinID{},
+				Sell:     sell,
+				Quantity: qty,
+				Address:  btcAddr,
+			},
+			Rate:  rate,
+			Force: order.StandingTiF,
+		}
+		return lo, limitMsg, pi
+	}
+	newAmend := func(target order.OrderID, sell bool, rate uint64) (*orderRecord, order.Preimage, order.Preimage) {
+		lo, limitMsg, loPI := newLimit(sell, rate)
+		coPI := test.RandomPreimage()
+		coCommit := coPI.Commit()
+		cancelMsg := &msgjson.CancelOrder{
+			Prefix: msgjson.Prefix{
+				AccountID:  aid[:],
+				Base:       dcrID,
+				Quote:      btcID,
+				OrderType:  msgjson.CancelOrderNum,
+				ClientTime: uint64(clientTimeMSec),
+				Commit:     coCommit[:],
+			},
+			TargetID: target[:],
+		}
+		co := &order.CancelOrder{
+			P: order.Prefix{
+				AccountID:  aid,
+				BaseAsset:  dcrID,
+				QuoteAsset: btcID,
+				OrderType:  order.CancelOrderType,
+				ClientTime: time.UnixMilli(clientTimeMSec),
+				Commit:     coCommit,
+			},
+			TargetOrderID: target,
+		}
+		return &orderRecord{
+			msgID: 2,
+			req:   limitMsg,
+			order: lo,
+			amend: &amendRecord{
+				cancel: co,
+				req:    cancelMsg,
+			},
+		}, coPI, loPI
+	}
+
+	// Book the order to amend.
+	lo, limitMsg, pi := newLimit(true, rate)
+	auth.piMtx.Lock()
+	auth.preimagesByMsgID[1] = pi
+	auth.piMtx.Unlock()
+	mkt.waitForEpochOpen()
+	if err = mkt.SubmitOrder(&orderRecord{msgID: 1, req: limitMsg, order: lo}); err != nil {
+		t.Fatal(err)
+	}
+	<-auth.handlePreimageDone
+	<-storage.epochInserted
+	if !mkt.book.HaveOrder(lo.ID()) {
+		t.Fatalf("order %v not booked", lo)
+	}
+
+	// The replacement must be on the same side.
+	rec, _, _ := newAmend(lo.ID(), false, rate)
+	if err = mkt.SubmitOrder(rec); !errors.Is(err, ErrAmendSideMismatch) {
+		t.Fatalf("wrong error for replacement on the other side: %v", err)
+	}
+
+	// Amend the rate.
+	rec, coPI, loPI := newAmend(lo.ID(), true, 2*rate)
+	if err = mkt.SubmitOrder(rec); err != nil {
+		t.Fatal(err)
+	}
+	co, replacement := rec.amend.cancel, rec.order
+	auth.piMtx.Lock()
+	auth.preimagesByOrdID[co.UID()] = coPI
+	auth.preimagesByOrdID[replacement.UID()] = loPI
+	auth.piMtx.Unlock()
+
+	<-auth.handlePreimageDone
+	<-auth.handlePreimageDone
+	<-storage.epochInserted
+
+	if mkt.book.HaveOrder(lo.ID()) {
+		t.Errorf("amended order %v still booked", lo)
+	}
+	if !mkt.book.HaveOrder(replacement.ID()) {
+		t.Errorf("replacement order %v not booked", replacement)
+	}
+
+	cancel()
+	wg.Wait()
+
+	// The amend is not counted as a cancel.
+	if auth.cancelOrder == co.ID() {
+		t.Errorf("amend cancel order recorded as a cancel")
+	}
+}
+
 func TestMarket_handlePreimageResp(t *testing.T) {
 	randomCommit := func() (com order.Commitment) {
 		rnd.Read(com[:])
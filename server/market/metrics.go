@@ -0,0 +1,63 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package market
+
+import (
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/server/account"
+	"decred.org/dcrdex/server/metrics"
+)
+
+var (
+	epochsProcessed = metrics.NewCounterVec("dcrdex_epochs_processed",
+		"Number of epochs matched.", "market")
+	ordersAccepted = metrics.NewCounterVec("dcrdex_orders_accepted",
+		"Number of orders accepted into an epoch queue.", "market", "type")
+	ordersRejected = metrics.NewCounterVec("dcrdex_orders_rejected",
+		"Number of order, cancel and amend requests rejected, by reason.", "reason")
+	matchesMade = metrics.NewCounterVec("dcrdex_matches",
+		"Number of matches made. Cancel matches have type cancel.", "market", "type")
+)
+
+// rejectReason is the rejected order metric's reason label for a msgjson error
+// code.
+func rejectReason(code int) string {
+	switch code {
+	case msgjson.RPCParseError:
+		return "parse"
+	case msgjson.SignatureError:
+		return "signature"
+	case msgjson.FundingError, msgjson.TransactionUndiscovered:
+		return "funding"
+	case msgjson.OrderParameterError:
+		return "parameter"
+	case msgjson.OrderQuantityTooHigh:
+		return "quantity_too_high"
+	case msgjson.ClockRangeError:
+		return "clock"
+	case msgjson.AccountClosedError:
+		return "account"
+	case msgjson.MarketNotRunningError:
+		return "market_not_running"
+	case msgjson.UnknownMarketError:
+		// Also used for errors returned by the Market, e.g. a reused
+		// commitment or locked coins.
+		return "market"
+	case msgjson.RPCInternal, msgjson.RPCInternalError:
+		return "internal"
+	default:
+		return "other"
+	}
+}
+
+// countRejects wraps an order route handler, counting its error responses.
+func countRejects(handler func(account.AccountID, *msgjson.Message) *msgjson.Error) func(account.AccountID, *msgjson.Message) *msgjson.Error {
+	return func(user account.AccountID, msg *msgjson.Message) *msgjson.Error {
+		msgErr := handler(user, msg)
+		if msgErr != nil {
+			ordersRejected.With(rejectReason(msgErr.Code)).Inc()
+		}
+		return msgErr
+	}
+}
@@ -0,0 +1,31 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package market
+
+import (
+	"testing"
+
+	"decred.org/dcrdex/dex/msgjson"
+	"decred.org/dcrdex/server/account"
+)
+
+func TestCountRejects(t *testing.T) {
+	var msgErr *msgjson.Error
+	handler := countRejects(func(account.AccountID, *msgjson.Message) *msgjson.Error {
+		return msgErr
+	})
+
+	sigRejects := ordersRejected.With("signature")
+	before := sigRejects.Value()
+	handler(account.AccountID{}, nil)
+	if sigRejects.Value() != before {
+		t.Fatalf("success counted as a rejection")
+	}
+
+	msgErr = msgjson.NewError(msgjson.SignatureError, "bad sig")
+	handler(account.AccountID{}, nil)
+	if sigRejects.Value() != before+1 {
+		t.Fatalf("rejection not counted")
+	}
+}
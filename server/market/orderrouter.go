@@ -9,7 +9,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"maps"
 	"math"
+	"sync"
 	"time"
 
 	"decred.org/dcrdex/dex"
@@ -109,6 +111,14 @@ type orderRecord struct {
 	order order.Order
 	req   msgjson.Stampable
 	msgID uint64
+	// amend is set if the order is the replacement order of an amend request.
+	amend *amendRecord
+}
+
+// amendRecord is the cancel order half of an amend request.
+type amendRecord struct {
+	cancel *order.CancelOrder
+	req    msgjson.Stampable
 }
 
 // assetSet is pointers to two different assets, but with 4 ways of addressing
@@ -147,7 +157,10 @@ type MatchSwapper interface {
 // OrderRouter handles the 'limit', 'market', and 'cancel' DEX routes. These
 // are authenticated routes used for placing and canceling orders.
 type OrderRouter struct {
-	auth        AuthManager
+	auth AuthManager
+	// mtx protects the assets and tunnels maps, which are modified when
+	// markets are added or removed.
+	mtx         sync.RWMutex
 	assets      map[uint32]*asset.BackedAsset
 	tunnels     map[string]MarketTunnel
 	latencyQ    *wait.TickerQueue
@@ -170,16 +183,17 @@ type OrderRouterConfig struct {
 func NewOrderRouter(cfg *OrderRouterConfig) *OrderRouter {
 	router := &OrderRouter{
 		auth:        cfg.AuthManager,
-		assets:      cfg.Assets,
-		tunnels:     cfg.Markets,
+		assets:      maps.Clone(cfg.Assets),
+		tunnels:     maps.Clone(cfg.Markets),
 		latencyQ:    wait.NewTickerQueue(2 * time.Second),
 		feeSource:   cfg.FeeSource,
 		dexBalancer: cfg.DEXBalancer,
 		swapper:     cfg.MatchSwapper,
 	}
-	cfg.AuthManager.Route(msgjson.LimitRoute, router.handleLimit)
-	cfg.AuthManager.Route(msgjson.MarketRoute, router.handleMarket)
-	cfg.AuthManager.Route(msgjson.CancelRoute, router.handleCancel)
+	cfg.AuthManager.Route(msgjson.LimitRoute, countRejects(router.handleLimit))
+	cfg.AuthManager.Route(msgjson.MarketRoute, countRejects(router.handleMarket))
+	cfg.AuthManager.Route(msgjson.CancelRoute, countRejects(router.handleCancel))
+	cfg.AuthManager.Route(msgjson.AmendRoute, countRejects(router.handleAmend))
 	return router
 }
 
@@ -187,8 +201,45 @@ func (r *OrderRouter) Run(ctx context.Context) {
 	r.latencyQ.Run(ctx)
 }
 
+// AddMarket adds a market, and the market's assets if they are not yet known,
+// to a running or stopped OrderRouter.
+func (r *OrderRouter) AddMarket(mktName string, mkt MarketTunnel, base, quote *asset.BackedAsset) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, a := range []*asset.BackedAsset{base, quote} {
+		if _, found := r.assets[a.ID]; !found {
+			r.assets[a.ID] = a
+		}
+	}
+	r.tunnels[mktName] = mkt
+}
+
+// RemoveMarket removes a market from the OrderRouter. Orders for the market are
+// rejected as orders for an unknown market.
+func (r *OrderRouter) RemoveMarket(mktName string) {
+	r.mtx.Lock()
+	delete(r.tunnels, mktName)
+	r.mtx.Unlock()
+}
+
+// tunnel retrieves the MarketTunnel for the named market.
+func (r *OrderRouter) tunnel(mktName string) (MarketTunnel, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	mkt, found := r.tunnels[mktName]
+	return mkt, found
+}
+
+// marketTunnels returns a copy of the map of market names to MarketTunnels.
+func (r *OrderRouter) marketTunnels() map[string]MarketTunnel {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return maps.Clone(r.tunnels)
+}
+
 func (r *OrderRouter) respondError(reqID uint64, user account.AccountID, msgErr *msgjson.Error) {
 	log.Debugf("Error going to user %v: %s", user, msgErr)
+	ordersRejected.With(rejectReason(msgErr.Code)).Inc()
 	msg, err := msgjson.NewResponse(reqID, nil, msgErr)
 	if err != nil {
 		log.Errorf("Failed to create error response with message '%s': %v", msg, err)
@@ -231,33 +282,54 @@ func (r *OrderRouter) handleLimit(user account.AccountID, msg *msgjson.Message)
 		return rpcErr
 	}
 
+	lo, tunnel, assets, rpcErr := r.newLimitOrder(user, limit)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	// NOTE: ServerTime is not yet set, so the order's ID, which is computed
+	// from the serialized order, is not yet valid. The Market will stamp the
+	// order on receipt, and the order ID will be valid.
+
+	oRecord := &orderRecord{
+		order: lo,
+		req:   limit,
+		msgID: msg.ID,
+	}
+
+	return r.processTrade(oRecord, tunnel, assets, limit.Coins, lo.Sell, limit.Rate, limit.RedeemSig, limit.Serialize())
+}
+
+// newLimitOrder validates the msgjson.LimitOrder and constructs an
+// order.LimitOrder from it. The signature is not checked.
+func (r *OrderRouter) newLimitOrder(user account.AccountID, limit *msgjson.LimitOrder) (*order.LimitOrder, MarketTunnel, *assetSet, *msgjson.Error) {
 	if _, tier := r.auth.AcctStatus(user); tier < 1 {
-		return msgjson.NewError(msgjson.AccountClosedError, "account %v with tier %d may not submit trade orders", user, tier)
+		return nil, nil, nil, msgjson.NewError(msgjson.AccountClosedError, "account %v with tier %d may not submit trade orders", user, tier)
 	}
 
 	tunnel, assets, sell, rpcErr := r.extractMarketDetails(&limit.Prefix, &limit.Trade)
 	if rpcErr != nil {
-		return rpcErr
+		return nil, nil, nil, rpcErr
 	}
 
 	// Spare some resources if the market is closed now. Any orders that make it
 	// through to a closed market will receive a similar error from SubmitOrder.
 	if !tunnel.Running() {
-		return msgjson.NewError(msgjson.MarketNotRunningError, "market closed to new orders")
+		return nil, nil, nil, msgjson.NewError(msgjson.MarketNotRunningError, "market closed to new orders")
 	}
 
 	// Check that OrderType is set correctly
 	if limit.OrderType != msgjson.LimitOrderNum {
-		return msgjson.NewError(msgjson.OrderParameterError, "wrong order type set for limit order. wanted %d, got %d",
+		return nil, nil, nil, msgjson.NewError(msgjson.OrderParameterError, "wrong order type set for limit order. wanted %d, got %d",
 			msgjson.LimitOrderNum, limit.OrderType)
 	}
 
 	// Check that the rate is non-zero and obeys the rate step interval.
 	if limit.Rate == 0 {
-		return msgjson.NewError(msgjson.OrderParameterError, "rate = 0 not allowed")
+		return nil, nil, nil, msgjson.NewError(msgjson.OrderParameterError, "rate = 0 not allowed")
 	}
 	if rateStep := tunnel.RateStep(); limit.Rate%rateStep != 0 {
-		return msgjson.NewError(msgjson.OrderParameterError, "rate (%d) not a multiple of ratestep (%d)",
+		return nil, nil, nil, msgjson.NewError(msgjson.OrderParameterError, "rate (%d) not a multiple of ratestep (%d)",
 			limit.Rate, rateStep)
 	}
 
@@ -269,18 +341,18 @@ func (r *OrderRouter) handleLimit(user account.AccountID, msg *msgjson.Message)
 	case msgjson.ImmediateOrderNum:
 		force = order.ImmediateTiF
 	default:
-		return msgjson.NewError(msgjson.OrderParameterError, "unknown time-in-force")
+		return nil, nil, nil, msgjson.NewError(msgjson.OrderParameterError, "unknown time-in-force")
 	}
 
 	lotSize := tunnel.LotSize()
 	rpcErr = r.checkPrefixTrade(assets, lotSize, &limit.Prefix, &limit.Trade, true)
 	if rpcErr != nil {
-		return rpcErr
+		return nil, nil, nil, rpcErr
 	}
 
 	// Commitment
 	if len(limit.Commit) != order.CommitmentSize {
-		return msgjson.NewError(msgjson.OrderParameterError, "invalid commitment")
+		return nil, nil, nil, msgjson.NewError(msgjson.OrderParameterError, "invalid commitment")
 	}
 	var commit order.Commitment
 	copy(commit[:], limit.Commit)
@@ -312,17 +384,7 @@ func (r *OrderRouter) handleLimit(user account.AccountID, msg *msgjson.Message)
 		Force: force,
 	}
 
-	// NOTE: ServerTime is not yet set, so the order's ID, which is computed
-	// from the serialized order, is not yet valid. The Market will stamp the
-	// order on receipt, and the order ID will be valid.
-
-	oRecord := &orderRecord{
-		order: lo,
-		req:   limit,
-		msgID: msg.ID,
-	}
-
-	return r.processTrade(oRecord, tunnel, assets, limit.Coins, sell, limit.Rate, limit.RedeemSig, limit.Serialize())
+	return lo, tunnel, assets, nil
 }
 
 // handleMarket is the handler for the 'market' route. This route accepts a
@@ -494,7 +556,7 @@ func (r *OrderRouter) processTrade(oRecord *orderRecord, tunnel MarketTunnel, as
 
 	// Use this as a chance to check user's existing market orders.
 	// TODO: check all markets?
-	for mktName, tunnel := range r.tunnels {
+	for mktName, tunnel := range r.marketTunnels() {
 		unbookedUnfunded := tunnel.CheckUnfilled(assets.funding.ID, oRecord.order.User())
 		for _, badLo := range unbookedUnfunded {
 			log.Infof("Unbooked unfunded order %v from market %s for user %v", badLo, mktName, oRecord.order.User())
@@ -725,7 +787,7 @@ func (r *OrderRouter) CheckParcelLimit(user account.AccountID, targetMarketName
 
 	var otherMarketParcels float64
 	var settlingQty uint64
-	for mktName, mkt := range r.tunnels {
+	for mktName, mkt := range r.marketTunnels() {
 		if mktName == targetMarketName {
 			settlingQty = settlingQuantities[mktName]
 			continue
@@ -799,34 +861,57 @@ func (r *OrderRouter) handleCancel(user account.AccountID, msg *msgjson.Message)
 
 	// NOTE: Allow suspended accounts to submit cancel orders.
 
-	tunnel, rpcErr := r.extractMarket(&cancel.Prefix)
+	co, tunnel, rpcErr := r.newCancelOrder(user, cancel)
 	if rpcErr != nil {
 		return rpcErr
 	}
 
+	// Send the order to the epoch queue.
+	oRecord := &orderRecord{
+		order: co,
+		req:   cancel,
+		msgID: msg.ID,
+	}
+	if err := tunnel.SubmitOrder(oRecord); err != nil {
+		if errors.Is(err, ErrInternalServer) {
+			log.Errorf("Market failed to SubmitOrder: %v", err)
+		}
+		return msgjson.NewError(msgjson.UnknownMarketError, "%v", err)
+	}
+	return nil
+}
+
+// newCancelOrder validates the msgjson.CancelOrder and constructs an
+// order.CancelOrder from it. The signature is not checked.
+func (r *OrderRouter) newCancelOrder(user account.AccountID, cancel *msgjson.CancelOrder) (*order.CancelOrder, MarketTunnel, *msgjson.Error) {
+	tunnel, rpcErr := r.extractMarket(&cancel.Prefix)
+	if rpcErr != nil {
+		return nil, nil, rpcErr
+	}
+
 	if len(cancel.TargetID) != order.OrderIDSize {
-		return msgjson.NewError(msgjson.OrderParameterError, "invalid target ID format")
+		return nil, nil, msgjson.NewError(msgjson.OrderParameterError, "invalid target ID format")
 	}
 	var targetID order.OrderID
 	copy(targetID[:], cancel.TargetID)
 
 	if !tunnel.Cancelable(targetID) {
-		return msgjson.NewError(msgjson.OrderParameterError, "target order not known: %v", targetID)
+		return nil, nil, msgjson.NewError(msgjson.OrderParameterError, "target order not known: %v", targetID)
 	}
 
 	// Check that OrderType is set correctly
 	if cancel.OrderType != msgjson.CancelOrderNum {
-		return msgjson.NewError(msgjson.OrderParameterError, "wrong order type set for cancel order")
+		return nil, nil, msgjson.NewError(msgjson.OrderParameterError, "wrong order type set for cancel order")
 	}
 
 	rpcErr = checkTimes(&cancel.Prefix)
 	if rpcErr != nil {
-		return rpcErr
+		return nil, nil, rpcErr
 	}
 
 	// Commitment.
 	if len(cancel.Commit) != order.CommitmentSize {
-		return msgjson.NewError(msgjson.OrderParameterError, "invalid commitment")
+		return nil, nil, msgjson.NewError(msgjson.OrderParameterError, "invalid commitment")
 	}
 	var commit order.Commitment
 	copy(commit[:], cancel.Commit)
@@ -845,19 +930,56 @@ func (r *OrderRouter) handleCancel(user account.AccountID, msg *msgjson.Message)
 		TargetOrderID: targetID,
 	}
 
-	// Send the order to the epoch queue.
+	return co, tunnel, nil
+}
+
+// handleAmend is the handler for the 'amend' route. This route accepts a
+// msgjson.AmendOrder payload, validates the cancel and replacement limit
+// orders, and submits them to the epoch queue together. The replacement is
+// only matched if the cancel order executes.
+func (r *OrderRouter) handleAmend(user account.AccountID, msg *msgjson.Message) *msgjson.Error {
+	amend := new(msgjson.AmendOrder)
+	err := msg.Unmarshal(&amend)
+	if err != nil || amend == nil {
+		return msgjson.NewError(msgjson.RPCParseError, "error decoding 'amend' payload")
+	}
+
+	// The amend signature covers both orders.
+	rpcErr := r.verifyAccount(user, amend.Order.AccountID, amend)
+	if rpcErr != nil {
+		return rpcErr
+	}
+	if !bytes.Equal(user[:], amend.Cancel.AccountID) {
+		return msgjson.NewError(msgjson.OrderParameterError, "account ID mismatch")
+	}
+
+	if amend.Cancel.Base != amend.Order.Base || amend.Cancel.Quote != amend.Order.Quote {
+		return msgjson.NewError(msgjson.OrderParameterError, "cancel and replacement orders are for different markets")
+	}
+	if amend.Order.TiF != msgjson.StandingOrderNum {
+		return msgjson.NewError(msgjson.OrderParameterError, "replacement order must be a standing order")
+	}
+
+	co, _, rpcErr := r.newCancelOrder(user, &amend.Cancel)
+	if rpcErr != nil {
+		return rpcErr
+	}
+	lo, tunnel, assets, rpcErr := r.newLimitOrder(user, &amend.Order)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
 	oRecord := &orderRecord{
-		order: co,
-		req:   cancel,
+		order: lo,
+		req:   &amend.Order,
 		msgID: msg.ID,
+		amend: &amendRecord{
+			cancel: co,
+			req:    &amend.Cancel,
+		},
 	}
-	if err := tunnel.SubmitOrder(oRecord); err != nil {
-		if errors.Is(err, ErrInternalServer) {
-			log.Errorf("Market failed to SubmitOrder: %v", err)
-		}
-		return msgjson.NewError(msgjson.UnknownMarketError, "%v", err)
-	}
-	return nil
+
+	return r.processTrade(oRecord, tunnel, assets, amend.Order.Coins, lo.Sell, lo.Rate, amend.Order.RedeemSig, amend.Order.Serialize())
 }
 
 // verifyAccount checks that the submitted order squares with the submitting user.
@@ -881,7 +1003,7 @@ func (r *OrderRouter) extractMarket(prefix *msgjson.Prefix) (MarketTunnel, *msgj
 	if err != nil {
 		return nil, msgjson.NewError(msgjson.UnknownMarketError, "asset lookup error: %v", err.Error())
 	}
-	tunnel, found := r.tunnels[mktName]
+	tunnel, found := r.tunnel(mktName)
 	if !found {
 		return nil, msgjson.NewError(msgjson.UnknownMarketError, "unknown market %s", mktName)
 	}
@@ -904,7 +1026,7 @@ type SuspendEpoch struct {
 // blocking order submission according to the schedule rather than just checking
 // Market.Running prior to submitting incoming orders to the Market.
 func (r *OrderRouter) SuspendMarket(mktName string, asSoonAs time.Time, persistBooks bool) *SuspendEpoch {
-	mkt, found := r.tunnels[mktName]
+	mkt, found := r.tunnel(mktName)
 	if !found {
 		return nil
 	}
@@ -919,8 +1041,9 @@ func (r *OrderRouter) SuspendMarket(mktName string, asSoonAs time.Time, persistB
 // Suspend is like SuspendMarket, but for all known markets.
 func (r *OrderRouter) Suspend(asSoonAs time.Time, persistBooks bool) map[string]*SuspendEpoch {
 
-	suspendTimes := make(map[string]*SuspendEpoch, len(r.tunnels))
-	for name, mkt := range r.tunnels {
+	tunnels := r.marketTunnels()
+	suspendTimes := make(map[string]*SuspendEpoch, len(tunnels))
+	for name, mkt := range tunnels {
 		idx, ts := mkt.Suspend(asSoonAs, persistBooks)
 		suspendTimes[name] = &SuspendEpoch{Idx: idx, End: ts}
 	}
@@ -951,11 +1074,13 @@ func (r *OrderRouter) extractMarketDetails(prefix *msgjson.Prefix, trade *msgjso
 		return nil, nil, false, msgjson.NewError(msgjson.OrderParameterError,
 			"invalid side value %d", trade.Side)
 	}
-	quote, found := r.assets[prefix.Quote]
-	if !found {
+	r.mtx.RLock()
+	quote, quoteFound := r.assets[prefix.Quote]
+	base, found := r.assets[prefix.Base]
+	r.mtx.RUnlock()
+	if !quoteFound {
 		panic("missing quote asset for known market should be impossible")
 	}
-	base, found := r.assets[prefix.Base]
 	if !found {
 		panic("missing base asset for known market should be impossible")
 	}
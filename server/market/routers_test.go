@@ -304,6 +304,9 @@ func (m *TMarketTunnel) SubmitOrder(o *orderRecord) error {
 	// set the server time
 	now := nowMs()
 	o.order.SetTime(now)
+	if o.amend != nil {
+		o.amend.cancel.SetTime(now)
+	}
 
 	m.adds = append(m.adds, o)
 
@@ -1335,6 +1338,111 @@ func TestCancel(t *testing.T) {
 	}
 }
 
+func TestAmend(t *testing.T) {
+	const lots = 10
+	qty := uint64(dcrLotSize) * lots
+	rate := uint64(1000) * dcrRateStep
+	user := oRig.user
+	targetID := order.OrderID{245}
+	clientTime := nowMs()
+	coCommit, loCommit := ordertest.RandomCommitment(), ordertest.RandomCommitment()
+	amend := msgjson.AmendOrder{
+		Cancel: msgjson.CancelOrder{
+			Prefix: msgjson.Prefix{
+				AccountID:  user.acct[:],
+				Base:       dcrID,
+				Quote:      btcID,
+				OrderType:  msgjson.CancelOrderNum,
+				ClientTime: uint64(clientTime.UnixMilli()),
+				Commit:     coCommit[:],
+			},
+			TargetID: targetID[:],
+		},
+		Order: msgjson.LimitOrder{
+			Prefix: msgjson.Prefix{
+				AccountID:  user.acct[:],
+				Base:       dcrID,
+				Quote:      btcID,
+				OrderType:  msgjson.LimitOrderNum,
+				ClientTime: uint64(clientTime.UnixMilli()),
+				Commit:     loCommit[:],
+			},
+			Trade: msgjson.Trade{
+				Side:     msgjson.SellOrderNum,
+				Quantity: qty,
+				Coins: []*msgjson.Coin{
+					oRig.signedUTXO(dcrID, qty-dcrLotSize, 1),
+					oRig.signedUTXO(dcrID, 2*dcrLotSize, 2),
+				},
+				Address: btcAddr,
+			},
+			Rate: rate,
+			TiF:  msgjson.StandingOrderNum,
+		},
+	}
+	reqID := uint64(5)
+
+	ensureErr := makeEnsureErr(t)
+
+	oRig.auth.sent = make(chan *msgjson.Error, 1)
+	defer func() { oRig.auth.sent = nil }()
+	oRig.market.added = make(chan struct{}, 1)
+	defer func() { oRig.market.added = nil }()
+
+	sendAmend := func() *msgjson.Error {
+		msg, _ := msgjson.NewRequest(reqID, msgjson.AmendRoute, amend)
+		err := oRig.router.handleAmend(user.acct, msg)
+		if err != nil {
+			return err
+		}
+		// wait for the async success (nil) / err (non-nil)
+		return <-oRig.auth.sent
+	}
+
+	ensureErr("valid amend", sendAmend(), -1)
+	select {
+	case <-oRig.market.added:
+	case <-time.After(time.Second):
+		t.Fatalf("no order submitted to epoch")
+	}
+	oRecord := oRig.market.pop()
+	if oRecord == nil || oRecord.amend == nil {
+		t.Fatalf("no amend submitted to epoch")
+	}
+	if oRecord.amend.cancel.TargetOrderID != targetID {
+		t.Fatalf("wrong cancel target %v", oRecord.amend.cancel.TargetOrderID)
+	}
+	if lo := oRecord.order.(*order.LimitOrder); lo.Rate != rate || lo.Quantity != qty || !lo.Sell {
+		t.Fatalf("wrong replacement order %v", lo)
+	}
+
+	// Test an invalid payload.
+	msg := new(msgjson.Message)
+	msg.Payload = []byte(`?`)
+	ensureErr("bad payload", oRig.router.handleAmend(user.acct, msg), msgjson.RPCParseError)
+
+	// The cancel must be from the same account.
+	otherAcct := ordertest.NextAccount()
+	amend.Cancel.AccountID = otherAcct[:]
+	ensureErr("cancel account mismatch", sendAmend(), msgjson.OrderParameterError)
+	amend.Cancel.AccountID = user.acct[:]
+
+	// The orders must be for the same market.
+	amend.Cancel.Quote = dcrID
+	ensureErr("market mismatch", sendAmend(), msgjson.OrderParameterError)
+	amend.Cancel.Quote = btcID
+
+	// The replacement must be a standing order.
+	amend.Order.TiF = msgjson.ImmediateOrderNum
+	ensureErr("immediate replacement", sendAmend(), msgjson.OrderParameterError)
+	amend.Order.TiF = msgjson.StandingOrderNum
+
+	// Unknown target order.
+	oRig.market.cancelable = false
+	ensureErr("non cancelable", sendAmend(), msgjson.OrderParameterError)
+	oRig.market.cancelable = true
+}
+
 func testPrefix(prefix *msgjson.Prefix, checkCode func(string, int)) {
 	ogAcct := prefix.AccountID
 	oid := ordertest.NextAccount()
@@ -1731,6 +1839,49 @@ func mkRate3(min, max float64) uint64 {
 	return randRate(mkt3BaseRate, mkt3.LotSize, min, max)
 }
 
+func TestAddRemoveBook(t *testing.T) {
+	router := rig.router
+	const mktName = "dcr_ltc"
+	src := tNewBookSource(dcrID, ltcID)
+	src.buys = []*order.LimitOrder{makeLO(buyer1, mkRate1(0.8, 1.0), randLots(10), order.StandingTiF)}
+
+	if _, err := router.Book(mktName); err == nil {
+		t.Fatalf("no error for unknown market")
+	}
+	if err := router.AddBook(mktName, src); err != nil {
+		t.Fatalf("AddBook error: %v", err)
+	}
+	if err := router.AddBook(mktName, src); err == nil {
+		t.Fatalf("no error for duplicate book")
+	}
+
+	// The router is running, so the new book is loaded right away.
+	var book *msgjson.OrderBook
+	var err error
+	for i := 0; i < 50; i++ {
+		if book, err = router.Book(mktName); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("book for added market not available: %v", err)
+	}
+	if len(book.Orders) != 1 {
+		t.Fatalf("expected 1 order, got %d", len(book.Orders))
+	}
+
+	if err = router.RemoveBook(mktName); err != nil {
+		t.Fatalf("RemoveBook error: %v", err)
+	}
+	if _, err = router.Book(mktName); err == nil {
+		t.Fatalf("no error for removed market")
+	}
+	if err = router.RemoveBook(mktName); err == nil {
+		t.Fatalf("no error for removing unknown book")
+	}
+}
+
 func TestRouter(t *testing.T) {
 	src1 := rig.source1
 	src2 := rig.source2
@@ -2192,6 +2343,49 @@ func TestPriceFeed(t *testing.T) {
 	}
 }
 
+func TestTradeTape(t *testing.T) {
+	link, sub := newSubscriber(mkt1)
+	if err := rig.router.handleOrderBook(link, sub); err != nil {
+		t.Fatalf("handleOrderBook: %v", err)
+	}
+	link.getSend() // the book
+
+	trades := []*msgjson.MarketTrade{
+		{Stamp: 20_000, Rate: mkt3BaseRate, Qty: dcrLotSize},
+		{Stamp: 20_000, Rate: mkt3BaseRate + btcRateStep, Qty: 2 * dcrLotSize, TakerSell: true},
+	}
+	rig.source1.feed <- &updateSignal{
+		action: epochReportAction,
+		data: sigDataEpochReport{
+			epochIdx: 1,
+			epochDur: 10_000,
+			stats:    &matcher.MatchCycleStats{},
+			trades:   trades,
+		},
+	}
+
+	// The trade tape follows the epoch report.
+	if msg := link.getSend(); msg.Route != msgjson.EpochReportRoute {
+		t.Fatalf("expected an epoch report, got %q", msg.Route)
+	}
+	msg := link.getSend()
+	if msg.Route != msgjson.TradeTapeRoute {
+		t.Fatalf("expected a trade tape, got %q", msg.Route)
+	}
+	tape := new(msgjson.TradeTapeNote)
+	if err := msg.Unmarshal(tape); err != nil {
+		t.Fatalf("error unmarshaling trade tape: %v", err)
+	}
+	if tape.MarketID != mktName1 || tape.Epoch != 1 || len(tape.Trades) != 2 {
+		t.Fatalf("wrong trade tape %+v", tape)
+	}
+	for i, tr := range tape.Trades {
+		if *tr != *trades[i] {
+			t.Fatalf("wrong trade %d: %+v", i, tr)
+		}
+	}
+}
+
 func TestParcelLimits(t *testing.T) {
 	mkt0 := tNewMarket(oRig.auth)
 	mkt1 := tNewMarket(oRig.auth)
@@ -142,13 +142,20 @@ func (ou *OrdersUpdated) String() string {
 // nomatched are orders that did not match anything, and discludes booked
 // limit orders that only matched as makers to down-queue takers.
 //
+// amends maps the ID of the replacement order of each amend in the queue to
+// the ID of the cancel order that was placed with it. A replacement is
+// processed immediately after its cancel order, and fails if the cancel order
+// does not remove its target from the book. Such failed replacements are in
+// both failed and nomatched, following the cancel order in nomatched.
+//
 // TODO: Eliminate order slice return args in favor of just the *OrdersUpdated.
-func (m *Matcher) Match(book Booker, queue []*OrderRevealed) (seed []byte, matches []*order.MatchSet,
+func (m *Matcher) Match(book Booker, queue []*OrderRevealed, amends map[order.OrderID]order.OrderID) (seed []byte, matches []*order.MatchSet,
 	passed, failed, doneOK, partial, booked, nomatched []*OrderRevealed,
 	unbooked []*order.LimitOrder, updates *OrdersUpdated, stats *MatchCycleStats) {
 
 	// Apply the deterministic pseudorandom shuffling.
 	seed = shuffleQueue(queue)
+	queue = sequenceAmends(queue, amends)
 
 	updates = new(OrdersUpdated)
 	stats = new(MatchCycleStats)
@@ -175,6 +182,9 @@ func (m *Matcher) Match(book Booker, queue []*OrderRevealed) (seed []byte, match
 	// are matched down-queue so that they aren't added to the nomatched slice.
 	nomatchStanding := make(map[order.OrderID]*OrderRevealed)
 
+	// Track executed cancel orders so that amend replacements can be checked.
+	cancelsExecuted := make(map[order.OrderID]bool)
+
 	tallyMakers := func(makers []*order.LimitOrder) {
 		for _, maker := range makers {
 			delete(nomatchStanding, maker.ID())
@@ -213,6 +223,7 @@ func (m *Matcher) Match(book Booker, queue []*OrderRevealed) (seed []byte, match
 			passed = append(passed, q)
 			doneOK = append(doneOK, q)
 			updates.CancelsExecuted = append(updates.CancelsExecuted, o)
+			cancelsExecuted[o.ID()] = true
 
 			// CancelOrder Match has zero values for Amounts, Rates, and Total.
 			matches = append(matches, &order.MatchSet{
@@ -225,6 +236,17 @@ func (m *Matcher) Match(book Booker, queue []*OrderRevealed) (seed []byte, match
 			updates.TradesCanceled = append(updates.TradesCanceled, removed)
 
 		case *order.LimitOrder:
+			if coid, isAmend := amends[o.ID()]; isAmend && !cancelsExecuted[coid] {
+				// The amended order was not removed from the book, so the
+				// replacement may not be placed.
+				log.Debugf("Amend replacement order %v failed with cancel order %v",
+					o.ID(), coid)
+				failed = append(failed, q)
+				updates.TradesFailed = append(updates.TradesFailed, o)
+				nomatched = append(nomatched, q)
+				break
+			}
+
 			// limit-limit order matching
 			var makers []*order.LimitOrder
 			matchSet := matchLimitOrder(book, o)
@@ -602,6 +624,43 @@ func sortQueueByID(queue []*OrderRevealed) {
 	})
 }
 
+// sequenceAmends returns the queue with the replacement order of each amend
+// moved to immediately follow its cancel order. The relative order of all other
+// orders is unchanged. A replacement with no cancel order in the queue, such as
+// when the cancel order's preimage was not revealed, is left in place and will
+// fail. The input slice is not modified.
+func sequenceAmends(queue []*OrderRevealed, amends map[order.OrderID]order.OrderID) []*OrderRevealed {
+	if len(amends) == 0 {
+		return queue
+	}
+
+	replacements := make(map[order.OrderID]*OrderRevealed, len(amends)) // by cancel order ID
+	for _, q := range queue {
+		if coid, isAmend := amends[q.Order.ID()]; isAmend {
+			replacements[coid] = q
+		}
+	}
+	cancels := make(map[order.OrderID]bool, len(replacements))
+	for _, q := range queue {
+		if oid := q.Order.ID(); replacements[oid] != nil {
+			cancels[oid] = true
+		}
+	}
+
+	seq := make([]*OrderRevealed, 0, len(queue))
+	for _, q := range queue {
+		oid := q.Order.ID()
+		if coid, isAmend := amends[oid]; isAmend && cancels[coid] {
+			continue // added after the cancel order
+		}
+		seq = append(seq, q)
+		if r := replacements[oid]; r != nil {
+			seq = append(seq, r)
+		}
+	}
+	return seq
+}
+
 func ShuffleQueue(queue []*OrderRevealed) {
 	shuffleQueue(queue)
 }
@@ -634,7 +634,7 @@ func TestMatch_cancelOnly(t *testing.T) {
 
 			numBuys0 := tt.args.book.BuyCount()
 
-			seed, matches, passed, failed, doneOK, partial, booked, nomatched, unbooked, updates, _ := me.Match(tt.args.book, tt.args.queue)
+			seed, matches, passed, failed, doneOK, partial, booked, nomatched, unbooked, updates, _ := me.Match(tt.args.book, tt.args.queue, nil)
 			matchMade := len(matches) > 0 && matches[0] != nil
 			if tt.doesMatch != matchMade {
 				t.Errorf("Match expected = %v, got = %v", tt.doesMatch, matchMade)
@@ -687,6 +687,113 @@ func TestMatch_cancelOnly(t *testing.T) {
 	}
 }
 
+func TestMatch_amend(t *testing.T) {
+	startLogger()
+	me := New()
+	rnd.Seed(1212121)
+
+	// Amend a standing buy order from the book with a larger buy order at a
+	// better rate that still does not match.
+	target := bookBuyOrders[3]
+	cancel := newCancelOrder(target.ID(), target.ServerTime.Add(time.Second))
+	replacement := newLimit(false, 3400000, 2, order.StandingTiF, 5)
+	other := newLimit(false, 2000000, 1, order.StandingTiF, 6)
+	amends := map[order.OrderID]order.OrderID{
+		replacement.Order.ID(): cancel.Order.ID(),
+	}
+
+	book := newBooker()
+	_, matches, passed, failed, _, _, booked, nomatched, unbooked, updates, _ :=
+		me.Match(book, []*OrderRevealed{replacement, other, cancel}, amends)
+	if len(failed) != 0 {
+		t.Fatalf("expected no failed orders, got %d", len(failed))
+	}
+	if len(matches) != 1 || matches[0].Taker != cancel.Order {
+		t.Fatalf("expected only the cancel match, got %d matches", len(matches))
+	}
+	if len(unbooked) != 1 || unbooked[0] != target {
+		t.Fatalf("amended order not unbooked")
+	}
+	if len(booked) != 2 || len(updates.TradesBooked) != 2 {
+		t.Fatalf("expected 2 booked orders, got %d", len(booked))
+	}
+	if len(nomatched) != 2 {
+		t.Fatalf("expected 2 nomatched orders, got %d", len(nomatched))
+	}
+	for i, q := range passed {
+		if q == cancel {
+			if i+1 == len(passed) || passed[i+1] != replacement {
+				t.Fatalf("replacement not processed after the cancel order")
+			}
+		}
+	}
+	var replacementBooked bool
+	for _, lo := range book.(*BookStub).buyOrders {
+		if lo == target {
+			t.Fatalf("amended order still booked")
+		}
+		replacementBooked = replacementBooked || lo == replacement.Order
+	}
+	if !replacementBooked {
+		t.Fatalf("replacement not booked")
+	}
+
+	// An amend of an order that is not on the book fails, and the replacement
+	// must fail with it.
+	fakeTarget := newLimitOrder(false, 4550000, 1, order.StandingTiF, 0)
+	cancel = newCancelOrder(fakeTarget.ID(), fakeTarget.ServerTime.Add(time.Second))
+	replacement = newLimit(false, 3400000, 2, order.StandingTiF, 7)
+	amends = map[order.OrderID]order.OrderID{
+		replacement.Order.ID(): cancel.Order.ID(),
+	}
+
+	book = newBooker()
+	numBuys := book.BuyCount()
+	_, matches, passed, failed, _, _, booked, nomatched, _, updates, _ =
+		me.Match(book, []*OrderRevealed{replacement, cancel}, amends)
+	if len(matches) != 0 || len(passed) != 0 || len(booked) != 0 {
+		t.Fatalf("expected no matches, passed, or booked orders")
+	}
+	if len(failed) != 2 || len(updates.CancelsFailed) != 1 || len(updates.TradesFailed) != 1 {
+		t.Fatalf("expected failed cancel and replacement")
+	}
+	if len(nomatched) != 2 || nomatched[0] != cancel || nomatched[1] != replacement {
+		t.Fatalf("expected cancel then replacement nomatched")
+	}
+	if book.BuyCount() != numBuys {
+		t.Fatalf("book changed")
+	}
+}
+
+func Test_sequenceAmends(t *testing.T) {
+	a := newLimit(false, 3400000, 1, order.StandingTiF, 1)
+	b := newLimit(false, 3400000, 1, order.StandingTiF, 2)
+	r := newLimit(false, 3400000, 1, order.StandingTiF, 3)
+	c := newCancelOrder(bookBuyOrders[0].ID(), time.Unix(1566497656, 0))
+	amends := map[order.OrderID]order.OrderID{
+		r.Order.ID(): c.Order.ID(),
+	}
+
+	check := func(queue, exp []*OrderRevealed) {
+		t.Helper()
+		seq := sequenceAmends(queue, amends)
+		if len(seq) != len(exp) {
+			t.Fatalf("wrong length %d, expected %d", len(seq), len(exp))
+		}
+		for i := range exp {
+			if seq[i] != exp[i] {
+				t.Fatalf("wrong order at index %d", i)
+			}
+		}
+	}
+
+	check([]*OrderRevealed{r, a, c, b}, []*OrderRevealed{a, c, r, b})
+	check([]*OrderRevealed{a, b, c, r}, []*OrderRevealed{a, b, c, r})
+	check([]*OrderRevealed{c, r, a}, []*OrderRevealed{c, r, a})
+	// Without its cancel order, the replacement stays in place.
+	check([]*OrderRevealed{r, a, b}, []*OrderRevealed{r, a, b})
+}
+
 func TestMatch_limitsOnly(t *testing.T) {
 	// Setup the match package's logger.
 	startLogger()
@@ -945,7 +1052,7 @@ func TestMatch_limitsOnly(t *testing.T) {
 			resetTakers()
 			resetMakers()
 
-			seed, matches, passed, failed, doneOK, partial, booked, nomatched, unbooked, updates, stats := me.Match(tt.args.book, tt.args.queue)
+			seed, matches, passed, failed, doneOK, partial, booked, nomatched, unbooked, updates, stats := me.Match(tt.args.book, tt.args.queue, nil)
 			matchMade := len(matches) > 0 && matches[0] != nil
 			if tt.doesMatch != matchMade {
 				t.Errorf("Match expected = %v, got = %v", tt.doesMatch, matchMade)
@@ -1229,7 +1336,7 @@ func TestMatch_marketSellsOnly(t *testing.T) {
 
 			fmt.Printf("%v\n", takers)
 
-			seed, matches, passed, failed, doneOK, partial, booked, _, unbooked, updates, _ := me.Match(tt.args.book, tt.args.queue)
+			seed, matches, passed, failed, doneOK, partial, booked, _, unbooked, updates, _ := me.Match(tt.args.book, tt.args.queue, nil)
 			matchMade := len(matches) > 0 && matches[0] != nil
 			if tt.doesMatch != matchMade {
 				t.Errorf("Match expected = %v, got = %v", tt.doesMatch, matchMade)
@@ -1501,7 +1608,7 @@ func TestMatch_marketBuysOnly(t *testing.T) {
 			resetTakers()
 			resetMakers()
 
-			seed, matches, passed, failed, doneOK, partial, booked, _, unbooked, updates, stats := me.Match(tt.args.book, tt.args.queue)
+			seed, matches, passed, failed, doneOK, partial, booked, _, unbooked, updates, stats := me.Match(tt.args.book, tt.args.queue, nil)
 			matchMade := len(matches) > 0 && matches[0] != nil
 			if tt.doesMatch != matchMade {
 				t.Errorf("Match expected = %v, got = %v", tt.doesMatch, matchMade)
@@ -0,0 +1,419 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+// Package metrics provides counters, gauges and histograms for monitoring the
+// DEX server. A Registry writes its metrics in the OpenMetrics text format,
+// which is understood by Prometheus and compatible scrapers.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the HTTP Content-Type of the OpenMetrics text format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// DefaultBuckets are histogram bucket upper bounds, in seconds, suitable for
+// the latency of swap negotiation steps, which range from seconds to hours.
+var DefaultBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400, 28800}
+
+var nameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Default is the Registry used by the package-level constructors.
+var Default = NewRegistry()
+
+// Registry is a set of metric families.
+type Registry struct {
+	mtx      sync.RWMutex
+	families map[string]*family
+}
+
+// NewRegistry is the constructor for a Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// metric is a single time series, or in the case of a histogram, a group of
+// related series.
+type metric interface {
+	// write writes the metric's samples. labels is the formatted label set,
+	// without braces, and may be empty.
+	write(w *bufio.Writer, name, labels string)
+}
+
+// family is a named group of metrics of the same type, distinguished by their
+// label values.
+type family struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	newMetric  func() metric
+
+	mtx     sync.RWMutex
+	metrics map[string]*labeledMetric
+}
+
+type labeledMetric struct {
+	labels string
+	metric metric
+}
+
+// register adds a new family to the Registry. register panics if the name is
+// invalid or already registered, since that is a programming error.
+func (r *Registry) register(name, help, typ string, labelNames []string, newMetric func() metric) *family {
+	if !nameRE.MatchString(name) {
+		panic(fmt.Sprintf("invalid metric name %q", name))
+	}
+	for _, l := range labelNames {
+		if !nameRE.MatchString(l) || l == "le" {
+			panic(fmt.Sprintf("invalid label name %q for metric %q", l, name))
+		}
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		newMetric:  newMetric,
+		metrics:    make(map[string]*labeledMetric),
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, found := r.families[name]; found {
+		panic(fmt.Sprintf("metric %q already registered", name))
+	}
+	r.families[name] = f
+	return f
+}
+
+// with gets the metric with the specified label values, creating it if it does
+// not yet exist.
+func (f *family) with(values []string) metric {
+	if len(values) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %q has %d labels, got %d values", f.name, len(f.labelNames), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mtx.RLock()
+	lm, found := f.metrics[key]
+	f.mtx.RUnlock()
+	if found {
+		return lm.metric
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if lm, found = f.metrics[key]; found {
+		return lm.metric
+	}
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = f.labelNames[i] + `="` + escapeLabelValue(v) + `"`
+	}
+	lm = &labeledMetric{
+		labels: strings.Join(pairs, ","),
+		metric: f.newMetric(),
+	}
+	f.metrics[key] = lm
+	return lm.metric
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mtx.RLock()
+	ms := make([]*labeledMetric, 0, len(f.metrics))
+	for _, lm := range f.metrics {
+		ms = append(ms, lm)
+	}
+	f.mtx.RUnlock()
+	slices.SortFunc(ms, func(a, b *labeledMetric) int {
+		return strings.Compare(a.labels, b.labels)
+	})
+
+	if f.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for _, lm := range ms {
+		lm.metric.write(w, f.name, lm.labels)
+	}
+}
+
+// Write writes all registered metrics to w in the OpenMetrics text format.
+// Families are sorted by name, and each exposition ends with an EOF marker.
+func (r *Registry) Write(w io.Writer) error {
+	r.mtx.RLock()
+	fs := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		fs = append(fs, f)
+	}
+	r.mtx.RUnlock()
+	slices.SortFunc(fs, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, f := range fs {
+		f.write(bw)
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// Counter is a monotonically increasing count.
+type Counter struct {
+	v atomic.Uint64
+}
+
+// Inc increments the Counter by 1.
+func (c *Counter) Inc() {
+	c.v.Add(1)
+}
+
+// Add increments the Counter by n.
+func (c *Counter) Add(n uint64) {
+	c.v.Add(n)
+}
+
+// Value is the current count.
+func (c *Counter) Value() uint64 {
+	return c.v.Load()
+}
+
+func (c *Counter) write(w *bufio.Writer, name, labels string) {
+	writeSample(w, name+"_total", labels, strconv.FormatUint(c.v.Load(), 10))
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the Gauge's value.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Add adds v, which may be negative, to the Gauge's value.
+func (g *Gauge) Add(v float64) {
+	for {
+		old := g.bits.Load()
+		if g.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Inc increments the Gauge by 1.
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the Gauge by 1.
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value is the Gauge's current value.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+func (g *Gauge) write(w *bufio.Writer, name, labels string) {
+	writeSample(w, name, labels, formatFloat(g.Value()))
+}
+
+// Histogram counts observations in cumulative buckets, and tracks their count
+// and sum.
+type Histogram struct {
+	bounds []float64 // sorted upper bounds, excluding +Inf
+
+	mtx    sync.Mutex
+	counts []uint64 // per bucket, the last being +Inf
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe adds an observation to the Histogram.
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v) // first bound >= v
+	h.mtx.Lock()
+	h.counts[i]++
+	h.count++
+	h.sum += v
+	h.mtx.Unlock()
+}
+
+// Count is the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w *bufio.Writer, name, labels string) {
+	h.mtx.Lock()
+	counts := slices.Clone(h.counts)
+	count, sum := h.count, h.sum
+	h.mtx.Unlock()
+
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	var cumulative uint64
+	for i, n := range counts {
+		cumulative += n
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = formatFloat(h.bounds[i])
+		}
+		writeSample(w, name+"_bucket", labels+sep+`le="`+le+`"`, strconv.FormatUint(cumulative, 10))
+	}
+	writeSample(w, name+"_count", labels, strconv.FormatUint(count, 10))
+	writeSample(w, name+"_sum", labels, formatFloat(sum))
+}
+
+// CounterVec is a family of Counters distinguished by label values.
+type CounterVec struct {
+	f *family
+}
+
+// With gets the Counter for the specified label values, which must be given in
+// the order the label names were registered.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.with(values).(*Counter)
+}
+
+// GaugeVec is a family of Gauges distinguished by label values.
+type GaugeVec struct {
+	f *family
+}
+
+// With gets the Gauge for the specified label values, which must be given in
+// the order the label names were registered.
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.f.with(values).(*Gauge)
+}
+
+// HistogramVec is a family of Histograms distinguished by label values.
+type HistogramVec struct {
+	f *family
+}
+
+// With gets the Histogram for the specified label values, which must be given
+// in the order the label names were registered.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.with(values).(*Histogram)
+}
+
+// NewCounter registers and returns a new Counter. The name should not include
+// the _total suffix, which is added on output.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// NewCounterVec registers and returns a new CounterVec.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, "counter", labelNames, func() metric { return new(Counter) })}
+}
+
+// NewGauge registers and returns a new Gauge.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+// NewGaugeVec registers and returns a new GaugeVec.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, "gauge", labelNames, func() metric { return new(Gauge) })}
+}
+
+// NewHistogramVec registers and returns a new HistogramVec with the given
+// bucket upper bounds. An implicit +Inf bucket is always included.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	bounds := slices.Clone(buckets)
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+	if n := len(bounds); n > 0 && math.IsInf(bounds[n-1], 1) {
+		bounds = bounds[:n-1]
+	}
+	return &HistogramVec{r.register(name, help, "histogram", labelNames, func() metric { return newHistogram(bounds) })}
+}
+
+// NewCounter registers a new Counter with the Default Registry.
+func NewCounter(name, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+// NewCounterVec registers a new CounterVec with the Default Registry.
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labelNames...)
+}
+
+// NewGauge registers a new Gauge with the Default Registry.
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+// NewGaugeVec registers a new GaugeVec with the Default Registry.
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labelNames...)
+}
+
+// NewHistogramVec registers a new HistogramVec with the Default Registry.
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labelNames...)
+}
+
+func writeSample(w *bufio.Writer, name, labels, value string) {
+	w.WriteString(name)
+	if labels != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(value)
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
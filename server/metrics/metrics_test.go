@@ -0,0 +1,73 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	epochs := r.NewCounterVec("test_epochs_processed", "Epochs processed.", "market")
+	clients := r.NewGauge("test_clients", "Connected clients.")
+	latency := r.NewHistogramVec("test_step_seconds", "Step latency.", []float64{10, 1, 5}, "step")
+	r.NewCounter("test_unused", "") // no help
+
+	epochs.With("dcr_btc").Add(3)
+	epochs.With(`a"b\c`).Inc()
+	clients.Inc()
+	clients.Inc()
+	clients.Dec()
+	latency.With("maker_swap").Observe(0.5)
+	latency.With("maker_swap").Observe(5)
+	latency.With("maker_swap").Observe(60)
+
+	var b bytes.Buffer
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	exp := `# HELP test_clients Connected clients.
+# TYPE test_clients gauge
+test_clients 1
+# HELP test_epochs_processed Epochs processed.
+# TYPE test_epochs_processed counter
+test_epochs_processed_total{market="a\"b\\c"} 1
+test_epochs_processed_total{market="dcr_btc"} 3
+# HELP test_step_seconds Step latency.
+# TYPE test_step_seconds histogram
+test_step_seconds_bucket{step="maker_swap",le="1"} 1
+test_step_seconds_bucket{step="maker_swap",le="5"} 2
+test_step_seconds_bucket{step="maker_swap",le="10"} 2
+test_step_seconds_bucket{step="maker_swap",le="+Inf"} 3
+test_step_seconds_count{step="maker_swap"} 3
+test_step_seconds_sum{step="maker_swap"} 65.5
+# TYPE test_unused counter
+test_unused_total 0
+# EOF
+`
+	if got := b.String(); got != exp {
+		t.Fatalf("wrong output. wanted:\n%s\ngot:\n%s", exp, got)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("%s: no panic", name)
+			}
+		}()
+		f()
+	}
+
+	r := NewRegistry()
+	r.NewGauge("test_gauge", "")
+	mustPanic("duplicate", func() { r.NewCounter("test_gauge", "") })
+	mustPanic("bad name", func() { r.NewCounter("test-counter", "") })
+	mustPanic("reserved label", func() { r.NewHistogramVec("test_hist", "", DefaultBuckets, "le") })
+	v := r.NewCounterVec("test_vec", "", "a", "b")
+	mustPanic("label count", func() { v.With("x") })
+}
@@ -0,0 +1,50 @@
+// This code is available on the terms of the project LICENSE.md file,
+// also available online at https://blueoakcouncil.org/license/1.0.0.
+
+package swap
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"decred.org/dcrdex/dex/order"
+	"decred.org/dcrdex/server/auth"
+	"decred.org/dcrdex/server/metrics"
+)
+
+var (
+	swapStepSeconds = metrics.NewHistogramVec("dcrdex_swap_step_seconds",
+		"Time from the previous swap step, or the match for the maker's swap, until the step's transaction was validated.",
+		metrics.DefaultBuckets, "step")
+	matchesRevoked = metrics.NewCounterVec("dcrdex_matches_revoked",
+		"Number of matches revoked, by the missed step.", "misstep", "user_fault")
+)
+
+// observeStep records the time taken to reach a swap step, given the time of
+// the previous step. A zero prev time, e.g. for a match restored without it, is
+// not recorded.
+func observeStep(step order.MatchStatus, prev, now time.Time) {
+	if prev.IsZero() {
+		return
+	}
+	var label string
+	switch step {
+	case order.MakerSwapCast:
+		label = "maker_swap"
+	case order.TakerSwapCast:
+		label = "taker_swap"
+	case order.MakerRedeemed:
+		label = "maker_redeem"
+	case order.MatchComplete:
+		label = "taker_redeem"
+	default:
+		return
+	}
+	swapStepSeconds.With(label).Observe(max(now.Sub(prev), 0).Seconds())
+}
+
+// countRevoked records the revocation of a match.
+func countRevoked(misstep auth.NoActionStep, userFault bool) {
+	matchesRevoked.With(strings.ReplaceAll(misstep.String(), " ", "_"), strconv.FormatBool(userFault)).Inc()
+}
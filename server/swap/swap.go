@@ -8,6 +8,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -221,8 +223,14 @@ type SwapperAsset struct {
 // (vua AuthManager) and validates transactions as they are reported.
 type Swapper struct {
 	// coins is a map to all the Asset information, including the asset backends,
-	// used by this Swapper.
-	coins map[uint32]*SwapperAsset
+	// used by this Swapper. Assets may be added with AddAsset, so coins is
+	// protected by the coinsMtx.
+	coinsMtx sync.RWMutex
+	coins    map[uint32]*SwapperAsset
+	// addBlockSource is set while Run is running, and starts the block
+	// notification listener for an asset added with AddAsset. It is protected
+	// by the coinsMtx.
+	addBlockSource func(assetID uint32, blockSource <-chan *asset.BlockUpdate)
 	// storage is a Database backend.
 	storage Storage
 	// authMgr is an AuthManager for client messaging and authentication.
@@ -304,7 +312,7 @@ func NewSwapper(cfg *Config) (*Swapper, error) {
 
 	authMgr := cfg.AuthManager
 	swapper := &Swapper{
-		coins:            cfg.Assets,
+		coins:            maps.Clone(cfg.Assets),
 		storage:          cfg.Storage,
 		authMgr:          authMgr,
 		swapDone:         cfg.SwapDone,
@@ -338,6 +346,41 @@ func NewSwapper(cfg *Config) (*Swapper, error) {
 	return swapper, nil
 }
 
+// coin retrieves the SwapperAsset for the asset ID, or nil if the asset is not
+// known to the Swapper.
+func (s *Swapper) coin(assetID uint32) *SwapperAsset {
+	s.coinsMtx.RLock()
+	defer s.coinsMtx.RUnlock()
+	return s.coins[assetID]
+}
+
+// AddAsset adds an asset to a running or stopped Swapper, such as when a market
+// for a new asset is added. If the Swapper is running, block notifications for
+// the asset are handled immediately.
+func (s *Swapper) AddAsset(a *SwapperAsset) error {
+	if a.MaxFeeRate == 0 {
+		return fmt.Errorf("max fee rate of 0 is invalid for asset %q", a.Symbol)
+	}
+
+	s.coinsMtx.Lock()
+	defer s.coinsMtx.Unlock()
+	if _, found := s.coins[a.ID]; found {
+		return fmt.Errorf("asset %d already added", a.ID)
+	}
+
+	if _, ok := a.Backend.(asset.AccountBalancer); ok {
+		s.matchMtx.Lock()
+		s.acctMatches[a.ID] = make(map[string]map[order.MatchID]*matchTracker)
+		s.matchMtx.Unlock()
+	}
+	s.coins[a.ID] = a
+
+	if s.addBlockSource != nil {
+		s.addBlockSource(a.ID, a.Backend.BlockChannel(32))
+	}
+	return nil
+}
+
 // addMatch registers a match. The matchMtx must be locked.
 func (s *Swapper) addMatch(mt *matchTracker) {
 	mid := mt.ID()
@@ -523,8 +566,8 @@ func (s *Swapper) AccountStats(acctAddr string, assetID uint32) (qty, swaps uint
 
 // ChainsSynced will return true if both specified asset's backends are synced.
 func (s *Swapper) ChainsSynced(base, quote uint32) (bool, error) {
-	b, found := s.coins[base]
-	if !found {
+	b := s.coin(base)
+	if b == nil {
 		return false, fmt.Errorf("no backend found for %d", base)
 	}
 	baseSynced, err := b.Backend.Synced()
@@ -534,8 +577,8 @@ func (s *Swapper) ChainsSynced(base, quote uint32) (bool, error) {
 	if !baseSynced {
 		return false, nil
 	}
-	q, found := s.coins[quote]
-	if !found {
+	q := s.coin(quote)
+	if q == nil {
 		return false, fmt.Errorf("no backend found for %d", base)
 	}
 	quoteSynced, err := q.Backend.Synced()
@@ -559,7 +602,7 @@ func (s *Swapper) restoreActiveSwaps(allowPartial bool) error {
 	// Check that the required assets backends are available.
 	missingAssets := make(map[uint32]bool)
 	checkAsset := func(id uint32) {
-		if s.coins[id] == nil && !missingAssets[id] {
+		if s.coin(id) == nil && !missingAssets[id] {
 			log.Warnf("Unable to find backend for asset %d with active swaps.", id)
 			missingAssets[id] = true
 		}
@@ -594,7 +637,7 @@ func (s *Swapper) restoreActiveSwaps(allowPartial bool) error {
 		swapCoin := ssd.ContractCoinOut
 		if len(swapCoin) > 0 {
 			assetID := ssd.SwapAsset
-			swapAsset := s.coins[assetID]
+			swapAsset := s.coin(assetID)
 			swap, err := swapAsset.Backend.Contract(swapCoin, ssd.ContractScript)
 			if err != nil {
 				return fmt.Errorf("unable to find swap out coin %x for asset %d: %w", swapCoin, assetID, err)
@@ -614,7 +657,7 @@ func (s *Swapper) restoreActiveSwaps(allowPartial bool) error {
 
 		if redeemCoin := ssd.RedeemCoinIn; len(redeemCoin) > 0 {
 			assetID := ssd.RedeemAsset
-			redeem, err := s.coins[assetID].Backend.Redemption(redeemCoin, cpSwapCoin, ssd.ContractScript)
+			redeem, err := s.coin(assetID).Backend.Redemption(redeemCoin, cpSwapCoin, ssd.ContractScript)
 			if err != nil {
 				return fmt.Errorf("unable to find redeem in coin %x for asset %d: %w", redeemCoin, assetID, err)
 			}
@@ -763,6 +806,12 @@ func (s *Swapper) Run(ctx context.Context) {
 	ctxHelpers, cancelHelpers := context.WithCancel(context.Background())
 	mainLoop := make(chan struct{}) // close after helpers stop for graceful shutdown
 	defer func() {
+		// Stop listening for block notifications from newly added assets
+		// before waiting on the helpers.
+		s.coinsMtx.Lock()
+		s.addBlockSource = nil
+		s.coinsMtx.Unlock()
+
 		// Stop handlers receiving messages and queueing latency Waiters.
 		s.handlerMtx.Lock() // block until active handlers return
 		s.stop = true       // prevent new handlers from starting waiters
@@ -784,7 +833,9 @@ func (s *Swapper) Run(ctx context.Context) {
 
 	// Start a listen loop for each asset's block channel. Normal shutdown stops
 	// this before the main loop since this sends to the main loop.
-	blockNotes := make(chan *blockNotification, 32*len(s.coins))
+	s.coinsMtx.Lock()
+	assetIDs := slices.Collect(maps.Keys(s.coins))
+	blockNotes := make(chan *blockNotification, 32*len(assetIDs))
 	addAsset := func(assetID uint32, blockSource <-chan *asset.BlockUpdate) {
 		errOut, errIn := meter.DelayedRelay(ctxHelpers, minBlockPeriod, 32)
 		wgHelpers.Add(1)
@@ -826,6 +877,8 @@ func (s *Swapper) Run(ctx context.Context) {
 	for assetID, lockable := range s.coins {
 		addAsset(assetID, lockable.Backend.BlockChannel(32))
 	}
+	s.addBlockSource = addAsset
+	s.coinsMtx.Unlock()
 
 	// Start the queue of coinwaiters for the init and redeem handlers. The
 	// handlers must be stopped/blocked before stopping this.
@@ -839,7 +892,7 @@ func (s *Swapper) Run(ctx context.Context) {
 
 	// Block-based inaction checks are started with Timers, and run in the main
 	// loop to avoid locks and WaitGroups.
-	bcastBlockTrigger := make(chan uint32, 32*len(s.coins))
+	bcastBlockTrigger := make(chan uint32, 32*len(assetIDs))
 	scheduleInactionCheck := func(assetID uint32) {
 		time.AfterFunc(s.bTimeout, func() {
 			// TODO: This pattern would still send the block trigger half of the
@@ -856,7 +909,7 @@ func (s *Swapper) Run(ctx context.Context) {
 
 	// On startup, schedule an inaction check for each asset. Ideally these
 	// would start bTimeout after the best block times.
-	for assetID := range s.coins {
+	for _, assetID := range assetIDs {
 		scheduleInactionCheck(assetID)
 	}
 
@@ -961,7 +1014,7 @@ func (s *Swapper) tryConfirmSwap(ctx context.Context, status *swapStatus, confTi
 		return true
 	}
 
-	swapConf := s.coins[status.swapAsset].SwapConf // swapStatus exists, therefore swapAsset is in the map
+	swapConf := s.coin(status.swapAsset).SwapConf // swapStatus exists, therefore swapAsset is in the map
 	if confs >= int64(swapConf) {
 		log.Debugf("Swap %v (%s) has reached %d confirmations (%d required)",
 			status.swap, dex.BipIDSymbol(status.swapAsset), confs, swapConf)
@@ -1073,6 +1126,8 @@ func (s *Swapper) failMatch(match *matchTracker, userFault bool) {
 		s.swapDone(otherOrder, match.Match, false)
 	}
 
+	countRevoked(misstep, userFault)
+
 	// Register the failure to act violation, adjusting the user's score.
 	if userFault {
 		s.authMgr.Inaction(orderAtFault.User(), misstep, db.MatchID(match.Match),
@@ -1398,7 +1453,7 @@ func (s *Swapper) step(user account.AccountID, matchID order.MatchID) (*stepInfo
 		counterParty: counterParty,
 		// By the time a match is created, the presence of the asset in the map
 		// has already been verified.
-		asset:       s.coins[actor.swapAsset].BackedAsset,
+		asset:       s.coin(actor.swapAsset).BackedAsset,
 		isBaseAsset: isBaseAsset,
 		step:        match.Status,
 		nextStep:    nextStep,
@@ -1659,6 +1714,14 @@ func (s *Swapper) processInit(msg *msgjson.Message, params *msgjson.Init, stepIn
 	stepInfo.match.Status = stepInfo.nextStep // handleInit (gate mechanism) won't allow backward progress
 	stepInfo.match.mtx.Unlock()
 
+	prevStepTime := stepInfo.match.matchTime
+	if !actor.isMaker {
+		counterParty.status.mtx.RLock()
+		prevStepTime = counterParty.status.swapTime
+		counterParty.status.mtx.RUnlock()
+	}
+	observeStep(stepInfo.nextStep, prevStepTime, swapTime)
+
 	// Only unlock match map after the statuses and txn times are stored,
 	// ensuring that checkInaction will not revoke the match as we respond and
 	// request counterparty audit.
@@ -1798,6 +1861,14 @@ func (s *Swapper) processRedeem(msg *msgjson.Message, params *msgjson.Redeem, st
 	match.Status = newStatus // handleRedeem (gate mechanism) won't allow backward progress
 	match.mtx.Unlock()
 
+	counterParty.status.mtx.RLock()
+	prevStepTime := counterParty.status.redeemTime
+	if actor.isMaker {
+		prevStepTime = counterParty.status.swapTime
+	}
+	counterParty.status.mtx.RUnlock()
+	observeStep(newStatus, prevStepTime, redeemTime)
+
 	// Only unlock match map after the statuses and txn times are stored,
 	// ensuring that checkInaction will not revoke the match as we respond.
 	s.matchMtx.RUnlock()
@@ -2239,7 +2310,7 @@ func (s *Swapper) processMatchAcks(user account.AccountID, msg *msgjson.Message,
 // asset.CoinNotFoundError is returned. CheckUnspent returns immediately with
 // no error if the requested asset is not a utxo-based asset.
 func (s *Swapper) CheckUnspent(ctx context.Context, assetID uint32, coinID []byte) error {
-	backend := s.coins[assetID]
+	backend := s.coin(assetID)
 	if backend == nil {
 		return fmt.Errorf("unknown asset %d", assetID)
 	}
@@ -2269,7 +2340,7 @@ func (s *Swapper) LockOrdersCoins(orders []order.Order) {
 }
 
 func (s *Swapper) lockOrdersCoins(assetID uint32, orders []order.Order) {
-	swapperAsset := s.coins[assetID]
+	swapperAsset := s.coin(assetID)
 	if swapperAsset == nil {
 		log.Errorf(fmt.Sprintf("lockOrderCoins called for unknown asset %d", assetID))
 		return
@@ -2283,7 +2354,7 @@ func (s *Swapper) lockOrdersCoins(assetID uint32, orders []order.Order) {
 
 // LockCoins locks coins of a given asset. The OrderID is used for tracking.
 func (s *Swapper) LockCoins(asset uint32, coins map[order.OrderID][]order.CoinID) {
-	swapperAsset := s.coins[asset]
+	swapperAsset := s.coin(asset)
 	if swapperAsset == nil {
 		panic(fmt.Sprintf("Unable to lock coins for asset %d", asset))
 	}
@@ -2306,7 +2377,7 @@ func (s *Swapper) unlockOrderCoins(ord order.Order) {
 }
 
 func (s *Swapper) unlockOrderIDCoins(assetID uint32, oid order.OrderID) {
-	swapperAsset := s.coins[assetID]
+	swapperAsset := s.coin(assetID)
 	if swapperAsset == nil {
 		log.Errorf(fmt.Sprintf("unlockOrderIDCoins called for unknown asset %d", assetID))
 		return